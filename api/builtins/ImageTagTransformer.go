@@ -4,39 +4,59 @@
 package builtins
 
 import (
+	"fmt"
+
 	"sigs.k8s.io/kustomize/api/filters/imagetag"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/types"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 	"sigs.k8s.io/yaml"
 )
 
 // Find matching image declarations and replace
-// the name, tag and/or digest.
+// the name, tag and/or digest, and replace the
+// registries of images by their mirrors.
 type ImageTagTransformerPlugin struct {
-	ImageTag   types.Image       `json:"imageTag,omitempty" yaml:"imageTag,omitempty"`
-	FieldSpecs []types.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	ImageTag        types.Image       `json:"imageTag,omitempty" yaml:"imageTag,omitempty"`
+	RegistryMirrors map[string]string `json:"registryMirrors,omitempty" yaml:"registryMirrors,omitempty"`
+	FieldSpecs      []types.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
 }
 
 func (p *ImageTagTransformerPlugin) Config(
 	_ *resmap.PluginHelpers, c []byte) (err error) {
 	p.ImageTag = types.Image{}
+	p.RegistryMirrors = nil
 	p.FieldSpecs = nil
-	return yaml.Unmarshal(c, p)
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	if p.ImageTag.NewTagFrom != nil && p.ImageTag.NewTag != "" {
+		return fmt.Errorf(
+			"image %s can't have both newTag and newTagFrom", p.ImageTag.Name)
+	}
+	return nil
 }
 
 func (p *ImageTagTransformerPlugin) Transform(m resmap.ResMap) error {
+	imageTag, err := p.resolveNewTag(m)
+	if err != nil {
+		return err
+	}
 	for _, r := range m.Resources() {
 		// traverse all fields at first
 		err := r.ApplyFilter(imagetag.LegacyFilter{
-			ImageTag: p.ImageTag,
+			ImageTag:        imageTag,
+			RegistryMirrors: p.RegistryMirrors,
 		})
 		if err != nil {
 			return err
 		}
 		// then use user specified field specs
 		err = r.ApplyFilter(imagetag.Filter{
-			ImageTag: p.ImageTag,
-			FsSlice:  p.FieldSpecs,
+			ImageTag:        imageTag,
+			RegistryMirrors: p.RegistryMirrors,
+			FsSlice:         p.FieldSpecs,
 		})
 		if err != nil {
 			return err
@@ -45,6 +65,41 @@ func (p *ImageTagTransformerPlugin) Transform(m resmap.ResMap) error {
 	return nil
 }
 
+// resolveNewTag returns the image to set, with the new tag
+// taken, if so configured, from a resource of the build.
+func (p *ImageTagTransformerPlugin) resolveNewTag(m resmap.ResMap) (types.Image, error) {
+	image := p.ImageTag
+	from := image.NewTagFrom
+	if from == nil {
+		return image, nil
+	}
+	rs := m.GetMatchingResourcesByAnyId(func(id resid.ResId) bool {
+		return from.KrmId.Match(&types.KrmId{
+			Gvk: id.Gvk, Name: id.Name, Namespace: id.Namespace})
+	})
+	if len(rs) != 1 {
+		return image, fmt.Errorf(
+			"the newTagFrom of image %s selects %d resources, expected one",
+			image.Name, len(rs))
+	}
+	path, err := kyaml.SplitFieldPath(from.FieldPath)
+	if err != nil {
+		return image, err
+	}
+	rn, err := rs[0].AsRNode().Pipe(kyaml.Lookup(path...))
+	if err != nil {
+		return image, err
+	}
+	if rn.IsNilOrEmpty() || rn.YNode().Kind != kyaml.ScalarNode {
+		return image, fmt.Errorf(
+			"the newTagFrom of image %s: %s of %s doesn't hold a tag",
+			image.Name, from.FieldPath, rs[0].CurId())
+	}
+	image.NewTag = rn.YNode().Value
+	image.NewTagFrom = nil
+	return image, nil
+}
+
 func NewImageTagTransformerPlugin() resmap.TransformerPlugin {
 	return &ImageTagTransformerPlugin{}
 }
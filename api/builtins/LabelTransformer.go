@@ -12,15 +12,20 @@ import (
 
 // Add the given labels to the given field specifications.
 type LabelTransformerPlugin struct {
-	Labels     map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
-	FieldSpecs []types.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	Labels     map[string]string      `json:"labels,omitempty" yaml:"labels,omitempty"`
+	FieldSpecs []types.FieldSpec      `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	Options    *types.MetadataOptions `json:"options,omitempty" yaml:"options,omitempty"`
 }
 
 func (p *LabelTransformerPlugin) Config(
 	_ *resmap.PluginHelpers, c []byte) (err error) {
 	p.Labels = nil
 	p.FieldSpecs = nil
-	return yaml.Unmarshal(c, p)
+	p.Options = nil
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	return p.Options.Validate()
 }
 
 func (p *LabelTransformerPlugin) Transform(m resmap.ResMap) error {
@@ -31,6 +36,7 @@ func (p *LabelTransformerPlugin) Transform(m resmap.ResMap) error {
 		err := r.ApplyFilter(labels.Filter{
 			Labels:  p.Labels,
 			FsSlice: p.FieldSpecs,
+			Options: p.Options,
 		})
 		if err != nil {
 			return err
@@ -66,7 +66,10 @@ func (p *PatchJson6902TransformerPlugin) Config(
 		return fmt.Errorf(
 			"patch appears to be empty; file=%s, JsonOp=%s", p.Path, p.JsonOp)
 	}
-	return err
+	if err = patchjson6902.ValidatePaths(p.decodedPatch); err != nil {
+		return errors.Wrapf(err, "in the patch %s", p.JsonOp)
+	}
+	return nil
 }
 
 func (p *PatchJson6902TransformerPlugin) Transform(m resmap.ResMap) error {
@@ -16,6 +16,9 @@ type PatchStrategicMergeTransformerPlugin struct {
 	loadedPatches []*resource.Resource
 	Paths         []types.PatchStrategicMerge `json:"paths,omitempty" yaml:"paths,omitempty"`
 	Patches       string                      `json:"patches,omitempty" yaml:"patches,omitempty"`
+	// KeepComments copies the comments of the patches
+	// to the fields of their targets that have none.
+	KeepComments bool `json:"keepComments,omitempty" yaml:"keepComments,omitempty"`
 }
 
 func (p *PatchStrategicMergeTransformerPlugin) Config(
@@ -45,6 +48,11 @@ func (p *PatchStrategicMergeTransformerPlugin) Config(
 		return fmt.Errorf(
 			"patch appears to be empty; files=%v, Patch=%s", p.Paths, p.Patches)
 	}
+	if p.KeepComments {
+		for _, patch := range p.loadedPatches {
+			patch.SetKeepComments()
+		}
+	}
 	return nil
 }
 
@@ -5,8 +5,11 @@ package builtins
 
 import (
 	"fmt"
+	"log"
+	"strings"
 
 	"sigs.k8s.io/kustomize/api/filters/replacement"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/types"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
@@ -17,6 +20,28 @@ import (
 type ReplacementTransformerPlugin struct {
 	ReplacementList []types.ReplacementField `json:"replacements,omitempty" yaml:"replacements,omitempty"`
 	Replacements    []types.Replacement      `json:"omitempty" yaml:"omitempty"`
+
+	// LogCreatedFields logs each field that options.create
+	// creates, rather than updates, to catch typos in field
+	// paths creating junk fields.
+	LogCreatedFields bool `json:"logCreatedFields,omitempty" yaml:"logCreatedFields,omitempty"`
+
+	// StrictGeneratedNames fails replacements copying the name
+	// of a generated resource, before the HashTransformer adds
+	// its hash suffix, to fields that won't hold the final name,
+	// i.e. those not among the NameReferences.
+	StrictGeneratedNames bool `json:"strictGeneratedNames,omitempty" yaml:"strictGeneratedNames,omitempty"`
+
+	// NameReferences holds the fields naming the resources of
+	// each kind, which kustomize updates with their final names.
+	NameReferences []NameReferenceFields `json:"nameReferences,omitempty" yaml:"nameReferences,omitempty"`
+}
+
+// NameReferenceFields are the fields naming the
+// resources of the kind of the Gvk.
+type NameReferenceFields struct {
+	resid.Gvk  `json:",inline,omitempty" yaml:",inline,omitempty"`
+	FieldSpecs types.FsSlice `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
 }
 
 func (p *ReplacementTransformerPlugin) Config(
@@ -36,9 +61,12 @@ func (p *ReplacementTransformerPlugin) Config(
 			if err != nil {
 				return err
 			}
-			repl := types.Replacement{}
-			if err := yaml.Unmarshal(content, &repl); err != nil {
-				return err
+			repl, err := replacement.Unmarshal(content)
+			if err != nil {
+				if pe, found := kyaml.AsPositionError(err); found {
+					pe.File = r.Path
+				}
+				return fmt.Errorf("invalid replacement file: %w", err)
 			}
 			p.Replacements = append(p.Replacements, repl)
 		} else {
@@ -54,12 +82,118 @@ func (p *ReplacementTransformerPlugin) Transform(m resmap.ResMap) (err error) {
 	for _, r := range m.Resources() {
 		nodes = append(nodes, r.Node())
 	}
-	_, err = replacement.Filter{
+	f := replacement.Filter{
 		Replacements: p.Replacements,
-	}.Filter(nodes)
+	}
+	if p.LogCreatedFields {
+		f.OnCreate = func(id resid.ResId, fieldPath string) {
+			log.Printf("replacement created field %s in resource %s", fieldPath, id)
+		}
+	}
+	if p.StrictGeneratedNames {
+		f.CheckTarget = p.generatedNameChecker(m)
+	}
+	_, err = f.Filter(nodes)
 	return err
 }
 
+// generatedNameChecker returns a replacement.Filter CheckTarget
+// failing replacements copying the name of a resource of m whose
+// name is to get a hash suffix to a field that won't be updated
+// with the final name: one not among the NameReferences of the
+// kind of the resource, or only part of which is replaced.
+func (p *ReplacementTransformerPlugin) generatedNameChecker(m resmap.ResMap) func(
+	value *kyaml.RNode, node *kyaml.RNode,
+	target *types.TargetSelector, fieldPath string) error {
+	generated := make(map[string][]resid.ResId)
+	for _, r := range m.Resources() {
+		if r.NeedHashSuffix() {
+			generated[r.GetName()] = append(generated[r.GetName()], r.CurId())
+		}
+	}
+	return func(value *kyaml.RNode, node *kyaml.RNode,
+		target *types.TargetSelector, fieldPath string) error {
+		if value.IsNil() || value.YNode().Kind != kyaml.ScalarNode {
+			return nil
+		}
+		ids, ok := generated[value.YNode().Value]
+		if !ok {
+			return nil
+		}
+		whole := target.InnerFieldPath == "" && (target.Options == nil ||
+			target.Options.Delimiter == "" && target.Options.Token == "")
+		if whole && fieldPath != "" {
+			for _, id := range ids {
+				if p.isNameReference(id.Gvk, node, fieldPath) {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf(
+			"replacement copies %q, the name of generated resource %s, to %s "+
+				"of %s before the name gets its hash suffix, so the field won't "+
+				"hold the final name; copy it only to whole fields kustomize "+
+				"updates with names, e.g. configMapRef.name, or set "+
+				"generatorOptions.disableNameSuffixHash",
+			value.YNode().Value, ids[0], fieldPathOrFiles(fieldPath), idOf(node))
+	}
+}
+
+// isNameReference returns whether the field of the
+// node at the field path names resources of the gvk.
+func (p *ReplacementTransformerPlugin) isNameReference(
+	gvk resid.Gvk, node *kyaml.RNode, fieldPath string) bool {
+	path, err := fieldSpecPath(fieldPath)
+	if err != nil {
+		return false
+	}
+	nodeGvk := idOf(node).Gvk
+	for _, refs := range p.NameReferences {
+		if !refs.Gvk.Equals(gvk) {
+			continue
+		}
+		for _, fs := range refs.FieldSpecs {
+			if fs.Path == path && nodeGvk.IsSelected(&fs.Gvk) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldSpecPath returns the field spec path of the replacement
+// field path, e.g. spec/containers/envFrom/configMapRef/name for
+// spec.containers.[name=app].envFrom.0.configMapRef.name: field
+// specs don't select the entries of lists.
+func fieldSpecPath(fieldPath string) (string, error) {
+	fields, err := kyaml.SplitFieldPath(fieldPath)
+	if err != nil {
+		return "", err
+	}
+	var path []string
+	for _, f := range fields {
+		if !kyaml.IsListEntryPart(f) {
+			path = append(path, f)
+		}
+	}
+	return strings.Join(path, "/"), nil
+}
+
+func idOf(node *kyaml.RNode) resid.ResId {
+	meta, _ := node.GetMeta()
+	g, v := resid.ParseGroupVersion(meta.APIVersion)
+	return resid.NewResIdWithNamespace(
+		resid.Gvk{Group: g, Version: v, Kind: meta.Kind},
+		meta.Name, meta.Namespace)
+}
+
+func fieldPathOrFiles(fieldPath string) string {
+	if fieldPath == "" {
+		return "the file sources"
+	}
+	return fieldPath
+}
+
 func NewReplacementTransformerPlugin() resmap.TransformerPlugin {
 	return &ReplacementTransformerPlugin{}
 }
@@ -19,6 +19,10 @@ type Filter struct {
 
 	// FsSlice contains the FieldSpecs to locate the namespace field
 	FsSlice types.FsSlice
+
+	// Options, if not nil, say whether the annotations override
+	// the values the inputs already have for their keys.
+	Options *types.MetadataOptions
 }
 
 var _ kio.Filter = Filter{}
@@ -30,8 +34,8 @@ func (f Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 			for _, k := range keys {
 				if err := node.PipeE(fsslice.Filter{
 					FsSlice: f.FsSlice,
-					SetValue: filtersutil.SetEntry(
-						k, f.Annotations[k], yaml.NodeTagString),
+					SetValue: filtersutil.SetEntryWithPolicy(
+						k, f.Annotations[k], yaml.NodeTagString, f.Options.PolicyFor(k)),
 					CreateKind: yaml.MappingNode, // Annotations are MappingNodes.
 					CreateTag:  yaml.NodeTagMap,
 				}); err != nil {
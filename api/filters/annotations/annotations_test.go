@@ -52,6 +52,38 @@ metadata:
 				"bean":   "cannellini",
 			}},
 		},
+		"keep existing": {
+			input: `
+apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: instance
+  annotations:
+    hero: batman
+    fiend: riddler
+`,
+			expectedOutput: `
+apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: instance
+  annotations:
+    hero: batman
+    fiend: joker
+    auto: ford
+`,
+			filter: Filter{
+				Annotations: annoMap{
+					"hero":  "superman",
+					"fiend": "joker",
+					"auto":  "ford",
+				},
+				Options: &types.MetadataOptions{
+					OnConflict: types.ConflictKeepExisting,
+					Keys:       map[string]types.ConflictPolicy{"fiend": types.ConflictOverride},
+				},
+			},
+		},
 		"update": {
 			input: `
 apiVersion: example.com/v1
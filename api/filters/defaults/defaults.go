@@ -0,0 +1,135 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package defaults
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-openapi/spec"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/schema"
+)
+
+// Filter sets the defaults of the schemas of resources to the
+// fields they omit, those of the objects they have.  As with the
+// API server, the defaults of the fields of a default are set too.
+//
+// Only the schemas of CRDs, and custom OpenAPI schemas, are
+// honored: the API server sets the defaults of the builtin kinds
+// in code, which their builtin OpenAPI schemas hardly hold, so
+// the resources having those are left as is.
+type Filter struct {
+	// CRDs holds the schemas of custom resources by type, e.g.
+	// as returned by SchemasOfCRDs.  Other resources have those
+	// of the OpenAPI schema in use, if it's a custom one.
+	CRDs map[yaml.TypeMeta]*spec.Schema
+
+	// Strip, if true, makes the filter remove the fields equal
+	// to their defaults instead, leaving minimal resources.
+	Strip bool
+
+	// LeftAsIs, if not nil, is called with the type of each
+	// resource left as is for having a builtin schema.
+	LeftAsIs func(t yaml.TypeMeta)
+}
+
+var _ kio.Filter = Filter{}
+
+func (f Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	return kio.FilterAll(yaml.FilterFunc(f.run)).Filter(nodes)
+}
+
+func (f Filter) run(node *yaml.RNode) (*yaml.RNode, error) {
+	walk := schema.SetDefaults
+	if f.Strip {
+		walk = schema.StripDefaults
+	}
+	t := typeMeta(node)
+	if s, ok := f.CRDs[t]; ok {
+		return node, walk(node, s, nil)
+	}
+	rs := openapi.SchemaForResourceType(t)
+	if rs == nil {
+		return node, nil
+	}
+	if !openapi.UsesCustomSchema() {
+		if f.LeftAsIs != nil {
+			f.LeftAsIs(t)
+		}
+		return node, nil
+	}
+	return node, walk(node, rs.Schema, openapi.Schema())
+}
+
+func typeMeta(node *yaml.RNode) yaml.TypeMeta {
+	t := yaml.TypeMeta{Kind: node.GetKind()}
+	if f := node.Field(yaml.APIVersionField); f != nil {
+		t.APIVersion = yaml.GetValue(f.Value)
+	}
+	return t
+}
+
+// SchemasOfCRDs returns the schemas of the versions of the
+// custom resources that the CustomResourceDefinitions among
+// nodes define, by type.
+func SchemasOfCRDs(nodes []*yaml.RNode) (map[yaml.TypeMeta]*spec.Schema, error) {
+	type validation struct {
+		OpenAPIV3Schema *spec.Schema `json:"openAPIV3Schema"`
+	}
+	var crd struct {
+		Spec struct {
+			Group string `json:"group"`
+			Names struct {
+				Kind string `json:"kind"`
+			} `json:"names"`
+			// Version and Validation are those of v1beta1.
+			Version    string      `json:"version"`
+			Validation *validation `json:"validation"`
+			Versions   []struct {
+				Name   string      `json:"name"`
+				Schema *validation `json:"schema"`
+			} `json:"versions"`
+		} `json:"spec"`
+	}
+	result := make(map[yaml.TypeMeta]*spec.Schema)
+	for _, n := range nodes {
+		t := typeMeta(n)
+		if t.Kind != "CustomResourceDefinition" ||
+			(t.APIVersion != "apiextensions.k8s.io/v1" &&
+				t.APIVersion != "apiextensions.k8s.io/v1beta1") {
+			continue
+		}
+		b, err := n.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		crd.Spec.Version, crd.Spec.Validation, crd.Spec.Versions = "", nil, nil
+		if err = json.Unmarshal(b, &crd); err != nil {
+			return nil, fmt.Errorf(
+				"reading the CustomResourceDefinition %s: %v", n.GetName(), err)
+		}
+		add := func(version string, v *validation) {
+			if v == nil || v.OpenAPIV3Schema == nil {
+				v = crd.Spec.Validation
+			}
+			if version == "" || v == nil || v.OpenAPIV3Schema == nil {
+				return
+			}
+			result[yaml.TypeMeta{
+				APIVersion: crd.Spec.Group + "/" + version,
+				Kind:       crd.Spec.Names.Kind,
+			}] = v.OpenAPIV3Schema
+		}
+		if len(crd.Spec.Versions) == 0 {
+			add(crd.Spec.Version, nil)
+		}
+		for _, v := range crd.Spec.Versions {
+			add(v.Name, v.Schema)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package defaults_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/api/filters/defaults"
+	filtertest_test "sigs.k8s.io/kustomize/api/testutils/filtertest"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const widgetsCRD = `
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  version: v1alpha1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            size:
+              type: string
+              default: small
+            owner:
+              type: object
+              default: {}
+              properties:
+                team:
+                  type: string
+                  default: core
+            labels:
+              type: object
+              additionalProperties:
+                type: object
+                properties:
+                  shown:
+                    type: boolean
+                    default: true
+`
+
+func crdSchemas(t *testing.T) map[yaml.TypeMeta]*spec.Schema {
+	t.Helper()
+	node, err := yaml.Parse(widgetsCRD)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	result, err := SchemasOfCRDs([]*yaml.RNode{node})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return result
+}
+
+func TestFilter(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		strip    bool
+		expected string
+	}{
+		"customResource": {
+			input: `
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: w
+spec:
+  labels:
+    a: {}
+    b:
+      shown: false
+`,
+			expected: `
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: w
+spec:
+  labels:
+    a: {shown: true}
+    b:
+      shown: false
+  owner:
+    team: core
+  size: small
+`,
+		},
+		"strip": {
+			input: `
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: w
+spec:
+  labels:
+    a:
+      shown: true
+    b:
+      shown: false
+  owner:
+    team: core
+  size: large
+`,
+			strip: true,
+			expected: `
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: w
+spec:
+  labels:
+    a: {}
+    b:
+      shown: false
+  size: large
+`,
+		},
+		"otherVersion": {
+			input: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+`,
+			expected: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+`,
+		},
+		"builtinSchema": {
+			input: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: s
+spec:
+  ports:
+  - port: 80
+`,
+			expected: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: s
+spec:
+  ports:
+  - port: 80
+`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			f := Filter{CRDs: crdSchemas(t), Strip: tc.strip}
+			if !assert.Equal(t,
+				strings.TrimSpace(tc.expected),
+				strings.TrimSpace(filtertest_test.RunFilter(t, tc.input, f))) {
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestFilterLeftAsIs(t *testing.T) {
+	var leftAsIs []yaml.TypeMeta
+	f := Filter{
+		CRDs:     crdSchemas(t),
+		LeftAsIs: func(t yaml.TypeMeta) { leftAsIs = append(leftAsIs, t) },
+	}
+	filtertest_test.RunFilter(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: d
+---
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: w
+`, f)
+	assert.Equal(t,
+		[]yaml.TypeMeta{{APIVersion: "apps/v1", Kind: "Deployment"}}, leftAsIs)
+}
+
+func TestSchemasOfCRDs(t *testing.T) {
+	schemas := crdSchemas(t)
+	assert.Len(t, schemas, 1)
+	assert.Contains(t, schemas, yaml.TypeMeta{
+		APIVersion: "example.com/v1alpha1", Kind: "Widget"})
+}
@@ -0,0 +1,9 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package defaults contains a kio.Filter setting the defaults
+// of the OpenAPI schemas of resources to the fields they omit,
+// as the API server does when it stores them, or stripping the
+// fields equal to them.  Only the schemas of CRDs, and custom
+// schemas, are honored, not the builtin ones.
+package defaults
@@ -0,0 +1,7 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fielddelete contains a kio.Filter deleting the
+// fields and list entries at field paths, as patches with
+// a delete list do.
+package fielddelete
@@ -0,0 +1,105 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fielddelete
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Filter deletes what the field paths lead to.
+type Filter struct {
+	// FieldPaths are in the syntax of yaml.SplitFieldPath,
+	// e.g. metadata.annotations.example\.com/owner, or
+	// spec.template.spec.tolerations.[key=dedicated], their
+	// last part being a field of a map or entries of a list.
+	// A path leading to nothing deletes nothing, and the
+	// maps emptied by the deletions are deleted too.
+	FieldPaths []string `json:"fieldPaths,omitempty" yaml:"fieldPaths,omitempty"`
+}
+
+var _ kio.Filter = Filter{}
+
+func (f Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	var paths [][]string
+	for _, p := range f.FieldPaths {
+		parts, err := yaml.SplitFieldPath(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("cannot delete the empty field path")
+		}
+		paths = append(paths, parts)
+	}
+	return kio.FilterAll(yaml.FilterFunc(func(node *yaml.RNode) (*yaml.RNode, error) {
+		for i, parts := range paths {
+			if err := deletePath(node, parts); err != nil {
+				return nil, fmt.Errorf(
+					"deleting field path '%s': %v", f.FieldPaths[i], err)
+			}
+		}
+		return node, nil
+	})).Filter(nodes)
+}
+
+// deletePath deletes what the parts of a field path lead to from node.
+func deletePath(node *yaml.RNode, parts []string) error {
+	if yaml.IsMissingOrNull(node) {
+		return nil
+	}
+	part, rest := parts[0], parts[1:]
+	if yaml.SelectsListEntries(node, part) {
+		entries, err := yaml.SelectListEntries(node, part)
+		if err != nil {
+			return err
+		}
+		if len(rest) == 0 {
+			deleteEntries(node, entries)
+			return nil
+		}
+		for _, e := range entries {
+			if err = deletePath(e, rest); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if node.YNode().Kind != yaml.MappingNode {
+		return fmt.Errorf("'%s' is a field, but its parent isn't a map", part)
+	}
+	if len(rest) == 0 {
+		return node.PipeE(yaml.Clear(part))
+	}
+	field := node.Field(part)
+	if field == nil {
+		return nil
+	}
+	wasEmpty := yaml.IsEmptyMap(field.Value)
+	if err := deletePath(field.Value, rest); err != nil {
+		return err
+	}
+	if !wasEmpty && yaml.IsEmptyMap(field.Value) {
+		// Emptied, e.g. annotations of which the last was deleted.
+		return node.PipeE(yaml.Clear(part))
+	}
+	return nil
+}
+
+// deleteEntries deletes the entries from the list.
+func deleteEntries(list *yaml.RNode, entries []*yaml.RNode) {
+	deleted := make(map[*yaml.Node]bool)
+	for _, e := range entries {
+		deleted[e.YNode()] = true
+	}
+	var content []*yaml.Node
+	for _, e := range list.YNode().Content {
+		if !deleted[e] {
+			content = append(content, e)
+		}
+	}
+	list.YNode().Content = content
+}
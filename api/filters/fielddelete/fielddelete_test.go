@@ -0,0 +1,169 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fielddelete
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	filtertest_test "sigs.k8s.io/kustomize/api/testutils/filtertest"
+)
+
+const deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    example.com/owner: team-a
+    example.com/tier: frontend
+    "8080": http
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: dedicated
+        operator: Exists
+      - key: gpu
+        operator: Exists
+      containers:
+      - name: app
+        args: [--fast, --debug]
+        env:
+        - name: DEBUG
+          value: "true"
+        - name: REGION
+          value: us-east1
+      - name: sidecar
+        env:
+        - name: DEBUG
+          value: "true"
+`
+
+func TestFilter(t *testing.T) {
+	testCases := map[string]struct {
+		paths    []string
+		expected string
+	}{
+		"map key": {
+			paths: []string{`metadata.annotations.example\.com/owner`},
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    example.com/tier: frontend
+    "8080": http
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: dedicated
+        operator: Exists
+      - key: gpu
+        operator: Exists
+      containers:
+      - name: app
+        args: [--fast, --debug]
+        env:
+        - name: DEBUG
+          value: "true"
+        - name: REGION
+          value: us-east1
+      - name: sidecar
+        env:
+        - name: DEBUG
+          value: "true"
+`,
+		},
+		"numeric map key and list index": {
+			paths: []string{
+				"metadata.annotations.8080",
+				"spec.template.spec.tolerations.0",
+			},
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    example.com/owner: team-a
+    example.com/tier: frontend
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: gpu
+        operator: Exists
+      containers:
+      - name: app
+        args: [--fast, --debug]
+        env:
+        - name: DEBUG
+          value: "true"
+        - name: REGION
+          value: us-east1
+      - name: sidecar
+        env:
+        - name: DEBUG
+          value: "true"
+`,
+		},
+		"list entries": {
+			paths: []string{
+				"spec.template.spec.tolerations.[key=gpu]",
+				"spec.template.spec.containers.*.env.[name=DEBUG]",
+				"spec.template.spec.containers.[name=app].args.[=--debug]",
+				"spec.template.spec.containers.[name=missing].env",
+				"spec.missing.field",
+			},
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    example.com/owner: team-a
+    example.com/tier: frontend
+    "8080": http
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: dedicated
+        operator: Exists
+      containers:
+      - name: app
+        args: [--fast]
+        env:
+        - name: REGION
+          value: us-east1
+      - name: sidecar
+        env: []
+`,
+		},
+	}
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			if !assert.Equal(t,
+				strings.TrimSpace(tc.expected),
+				strings.TrimSpace(filtertest_test.RunFilter(
+					t, deployment, Filter{FieldPaths: tc.paths}))) {
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	_, err := filtertest_test.RunFilterE(t, deployment, Filter{
+		FieldPaths: []string{"metadata.name.first"}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"deleting field path 'metadata.name.first': "+
+				"'first' is a field, but its parent isn't a map")
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"sigs.k8s.io/kustomize/api/filters/filtersutil"
-	"sigs.k8s.io/kustomize/api/internal/utils"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kyaml/errors"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -48,8 +47,15 @@ func (fltr Filter) Filter(obj *yaml.RNode) (*yaml.RNode, error) {
 	if match, err := isMatchGVK(fltr.FieldSpec, obj); !match || err != nil {
 		return obj, errors.Wrap(err)
 	}
-	fltr.path = utils.PathSplitter(fltr.FieldSpec.Path)
-	err := fltr.filter(obj)
+	if match, err := isMatchSelectors(fltr.FieldSpec, obj); !match || err != nil {
+		return obj, errors.Wrap(err)
+	}
+	path, err := splitPath(fltr.FieldSpec.Path)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	fltr.path = path
+	err = fltr.filter(obj)
 	if err != nil {
 		s, _ := obj.String()
 		return nil, errors.WrapPrefixf(err,
@@ -58,11 +64,24 @@ func (fltr Filter) Filter(obj *yaml.RNode) (*yaml.RNode, error) {
 	return obj, nil
 }
 
+// splitPath splits a fieldSpec path, which, for compatibility, may
+// be in the legacy slash delimited syntax, e.g. spec/template,
+// rather than the syntax of yaml.SplitFieldPath (see
+// yaml.IsLegacyFieldPath).  In either, lists
+// along the path are traversed implicitly, unless the path selects
+// some of their entries, e.g. spec.containers.[name=app].image.
+func splitPath(path string) ([]string, error) {
+	if path == "" || yaml.IsLegacyFieldPath(path) {
+		return yaml.SplitLegacyFieldPath(path), nil
+	}
+	return yaml.SplitFieldPath(path)
+}
+
 // Recursively called.
 func (fltr Filter) filter(obj *yaml.RNode) error {
 	if len(fltr.path) == 0 {
 		// found the field -- set its value
-		return fltr.SetValue(obj)
+		return yaml.ErrorAt(obj, fltr.SetValue(obj))
 	}
 	if obj.IsTaggedNull() || obj.IsNil() {
 		return nil
@@ -75,7 +94,7 @@ func (fltr Filter) filter(obj *yaml.RNode) error {
 	case yaml.AliasNode:
 		return fltr.filter(yaml.NewRNode(obj.YNode().Alias))
 	default:
-		return errors.Errorf("expected sequence or mapping node")
+		return yaml.ErrorAt(obj, errors.Errorf("expected sequence or mapping node"))
 	}
 }
 
@@ -85,6 +104,10 @@ func (fltr Filter) handleMap(obj *yaml.RNode) error {
 	if fieldName == "" {
 		return fmt.Errorf("cannot set or create an empty field name")
 	}
+	if yaml.IsListIndex(fieldName) || fieldName == "*" {
+		// The path selects list entries, but this isn't a list.
+		return nil
+	}
 	// lookup the field matching the next path element
 	var operation yaml.Filter
 	var kind yaml.Kind
@@ -135,8 +158,23 @@ func (fltr Filter) handleMap(obj *yaml.RNode) error {
 	return next.filter(field)
 }
 
-// seq calls filter on all sequence elements
+// seq calls filter on all sequence elements, or,
+// if the next path element selects some, on those.
 func (fltr Filter) handleSequence(obj *yaml.RNode) error {
+	if yaml.IsListEntryPart(fltr.path[0]) {
+		entries, err := yaml.SelectListEntries(obj, fltr.path[0])
+		if err != nil {
+			return err
+		}
+		var next = fltr
+		next.path = fltr.path[1:]
+		for _, e := range entries {
+			if err = next.filter(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	if err := obj.VisitElements(func(node *yaml.RNode) error {
 		// recurse on each element -- re-allocating a Filter is
 		// not strictly required, but is more consistent with field
@@ -183,3 +221,18 @@ func isMatchGVK(fs types.FieldSpec, obj *yaml.RNode) (bool, error) {
 
 	return true, nil
 }
+
+// isMatchSelectors returns true if the obj labels and annotations
+// match the fs selectors, if it has any.
+func isMatchSelectors(fs types.FieldSpec, obj *yaml.RNode) (bool, error) {
+	if fs.LabelSelector != "" {
+		match, err := obj.MatchesLabelSelector(fs.LabelSelector)
+		if !match || err != nil {
+			return false, err
+		}
+	}
+	if fs.AnnotationSelector != "" {
+		return obj.MatchesAnnotationSelector(fs.AnnotationSelector)
+	}
+	return true, nil
+}
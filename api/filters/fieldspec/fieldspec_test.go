@@ -518,6 +518,240 @@ a:
 				CreateKind: yaml.ScalarNode,
 			},
 		},
+		"dotted path": {
+			fieldSpec: `
+path: a.b\.c.d
+version: v1
+kind: Bar
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+a:
+  b.c:
+    d: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+a:
+  b.c:
+    d: bar
+`,
+			filter: fieldspec.Filter{
+				SetValue:   filtersutil.SetScalar("bar"),
+				CreateKind: yaml.ScalarNode,
+			},
+		},
+		"dotted path with a slash in a field name": {
+			fieldSpec: `
+path: metadata.annotations.app\.kubernetes\.io/name
+version: v1
+kind: Bar
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+metadata:
+  annotations:
+    app.kubernetes.io/name: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+metadata:
+  annotations:
+    app.kubernetes.io/name: bar
+`,
+			filter: fieldspec.Filter{
+				SetValue:   filtersutil.SetScalar("bar"),
+				CreateKind: yaml.ScalarNode,
+			},
+		},
+		"legacy path with a dot in a field name": {
+			fieldSpec: `
+path: metadata/annotations/example.com
+version: v1
+kind: Bar
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+metadata:
+  annotations:
+    example.com: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+metadata:
+  annotations:
+    example.com: bar
+`,
+			filter: fieldspec.Filter{
+				SetValue:   filtersutil.SetScalar("bar"),
+				CreateKind: yaml.ScalarNode,
+			},
+		},
+		"dotted path selecting list entries": {
+			fieldSpec: `
+path: spec.containers.[name=app*].image
+version: v1
+kind: Bar
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+spec:
+  containers:
+  - name: app
+    image: foo
+  - name: sidecar
+    image: foo
+  - name: app-init
+    image: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+spec:
+  containers:
+  - name: app
+    image: bar
+  - name: sidecar
+    image: foo
+  - name: app-init
+    image: bar
+`,
+			filter: fieldspec.Filter{
+				SetValue: filtersutil.SetScalar("bar"),
+			},
+		},
+		"dotted path selecting a list index": {
+			fieldSpec: `
+path: spec.containers.1.image
+version: v1
+kind: Bar
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+spec:
+  containers:
+  - name: app
+    image: foo
+  - name: sidecar
+    image: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+spec:
+  containers:
+  - name: app
+    image: foo
+  - name: sidecar
+    image: bar
+`,
+			filter: fieldspec.Filter{
+				SetValue: filtersutil.SetScalar("bar"),
+			},
+		},
+		"invalid dotted path": {
+			fieldSpec: `
+path: a..b
+version: v1
+kind: Bar
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+a:
+  b: foo
+`,
+			error: "field path 'a..b' has an empty part at offset 2",
+			filter: fieldspec.Filter{
+				SetValue: filtersutil.SetScalar("bar"),
+			},
+		},
+		"label selector matches": {
+			fieldSpec: `
+path: spec/image
+kind: Bar
+labelSelector: operator=acme
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+metadata:
+  labels:
+    operator: acme
+spec:
+  image: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+metadata:
+  labels:
+    operator: acme
+spec:
+  image: bar
+`,
+			filter: fieldspec.Filter{
+				SetValue: filtersutil.SetScalar("bar"),
+			},
+		},
+		"label selector doesn't match": {
+			fieldSpec: `
+path: spec/image
+kind: Bar
+labelSelector: operator=acme
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+metadata:
+  labels:
+    operator: other
+spec:
+  image: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+metadata:
+  labels:
+    operator: other
+spec:
+  image: foo
+`,
+			filter: fieldspec.Filter{
+				SetValue: filtersutil.SetScalar("bar"),
+			},
+		},
+		"annotation selector doesn't match": {
+			fieldSpec: `
+path: spec/image
+kind: Bar
+annotationSelector: operator in (acme)
+`,
+			input: `
+apiVersion: v1
+kind: Bar
+spec:
+  image: foo
+`,
+			expected: `
+apiVersion: v1
+kind: Bar
+spec:
+  image: foo
+`,
+			filter: fieldspec.Filter{
+				SetValue: filtersutil.SetScalar("bar"),
+			},
+		},
 	}
 
 	for n := range testCases {
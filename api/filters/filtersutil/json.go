@@ -0,0 +1,88 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filtersutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// MarshalJSONLike serializes rn as JSON in the layout of original,
+// a JSON document rn was parsed from: indented if original spanned
+// several lines, compact otherwise.  The fields keep the order they
+// have in rn, rather than being sorted.
+func MarshalJSONLike(rn *yaml.RNode, original string) (string, error) {
+	var b bytes.Buffer
+	if err := writeJSON(&b, rn.YNode()); err != nil {
+		return "", err
+	}
+	if !strings.Contains(strings.TrimSpace(original), "\n") {
+		return b.String(), nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, b.Bytes(), "", "  "); err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(original, "\n") {
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+// writeJSON writes n as compact JSON, keeping the order of the
+// fields of its maps.
+func writeJSON(b *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			b.WriteString("null")
+			return nil
+		}
+		return writeJSON(b, n.Content[0])
+	case yaml.AliasNode:
+		return writeJSON(b, n.Alias)
+	case yaml.MappingNode:
+		b.WriteByte('{')
+		for i := 0; i < len(n.Content)-1; i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, err := json.Marshal(n.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			b.Write(key)
+			b.WriteByte(':')
+			if err = writeJSON(b, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case yaml.SequenceNode:
+		b.WriteByte('[')
+		for i, elem := range n.Content {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJSON(b, elem); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	default:
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+		s, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(s)
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package filtersutil
 
 import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -31,3 +34,25 @@ func SetEntry(key, value, tag string) SetFn {
 		})
 	}
 }
+
+// SetEntryWithPolicy returns a SetFn to set an entry in a map,
+// as SetEntry does, unless the map already holds the key with
+// another value: then, per the policy, the entry is set anyway,
+// left as it is, or an error is returned.
+func SetEntryWithPolicy(key, value, tag string, policy types.ConflictPolicy) SetFn {
+	set := SetEntry(key, value, tag)
+	if policy == "" || policy == types.ConflictOverride {
+		return set
+	}
+	return func(node *yaml.RNode) error {
+		existing := node.Field(key)
+		if existing == nil || existing.Value.YNode().Value == value {
+			return set(node)
+		}
+		if policy == types.ConflictKeepExisting {
+			return nil
+		}
+		return fmt.Errorf("%s is already %q, not %q",
+			key, existing.Value.YNode().Value, value)
+	}
+}
@@ -9,4 +9,7 @@
 // LegacyFilter doesn't use a FieldSpec, and instead only updates image
 // references if the field is name image and it is underneath a field called
 // either containers or initContainers.
+//
+// Images finds, without changing anything, the image references
+// the two filters would update.
 package imagetag
@@ -0,0 +1,60 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetag
+
+import (
+	"sigs.k8s.io/kustomize/api/filters/fsslice"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Images returns the image references in the fields of node
+// that the LegacyFilter and a Filter with the given FsSlice
+// would update, in the order found and without duplicates.
+// The node isn't changed.
+func Images(node *yaml.RNode, fsSlice types.FsSlice) ([]string, error) {
+	if (Filter{}).isOnDenyList(node) {
+		return nil, nil
+	}
+	var result []string
+	seen := make(map[string]bool)
+	collect := func(n *yaml.RNode) error {
+		if err := yaml.ErrorIfInvalid(n, yaml.ScalarNode); err != nil {
+			return err
+		}
+		if v := n.YNode().Value; v != "" && !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+		return nil
+	}
+	// Work on a copy, as traversing fieldSpecs
+	// may give null fields their expected kinds.
+	node = node.Copy()
+	fff := findFieldsFilter{
+		fields: []string{"containers", "initContainers"},
+		fieldCallback: func(n *yaml.RNode) error {
+			if n.YNode().Kind != yaml.SequenceNode {
+				return nil
+			}
+			return n.VisitElements(func(e *yaml.RNode) error {
+				image, err := e.Pipe(yaml.Get("image"))
+				if err != nil || image == nil {
+					return err
+				}
+				return collect(image)
+			})
+		},
+	}
+	if err := node.PipeE(fff); err != nil {
+		return nil, err
+	}
+	if err := node.PipeE(fsslice.Filter{
+		FsSlice:  fsSlice,
+		SetValue: collect,
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestImages(t *testing.T) {
+	input := `
+apiVersion: example.com/v1
+kind: Runner
+metadata:
+  name: runner
+spec:
+  runner:
+    image: runner:v1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: app:v1
+      initContainers:
+      - name: init
+        image: init@sha256:12345
+      - name: noImage
+  volumes: null
+`
+	node, err := yaml.Parse(input)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	images, err := Images(node, types.FsSlice{
+		{Path: "spec/runner/image"},
+		{Path: "spec/volumes[]/image"},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"app:v1", "init@sha256:12345", "runner:v1"}, images)
+
+	out, err := node.String()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, input[1:], out)
+}
+
+func TestImagesSkipsCRDs(t *testing.T) {
+	node, err := yaml.Parse(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: runners.example.com
+spec:
+  containers:
+  - image: app:v1
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	images, err := Images(node, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, images)
+}
@@ -23,6 +23,10 @@ type Filter struct {
 	// FsSlice contains the FieldSpecs to locate an image field,
 	// e.g. Path: "spec/myContainers[]/image"
 	FsSlice types.FsSlice `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+
+	// RegistryMirrors map registries to the mirrors that
+	// are to replace them, as by image.MirrorRegistry.
+	RegistryMirrors map[string]string `json:"registryMirrors,omitempty" yaml:"registryMirrors,omitempty"`
 }
 
 var _ kio.Filter = Filter{}
@@ -41,7 +45,7 @@ func (f Filter) filter(node *yaml.RNode) (*yaml.RNode, error) {
 	}
 	if err := node.PipeE(fsslice.Filter{
 		FsSlice:  f.FsSlice,
-		SetValue: updateImageTagFn(f.ImageTag),
+		SetValue: updateImageTagFn(f.ImageTag, f.RegistryMirrors),
 	}); err != nil {
 		return nil, err
 	}
@@ -60,10 +64,11 @@ func (f Filter) isOnDenyList(node *yaml.RNode) bool {
 	return meta.Kind == `CustomResourceDefinition`
 }
 
-func updateImageTagFn(imageTag types.Image) filtersutil.SetFn {
+func updateImageTagFn(imageTag types.Image, mirrors map[string]string) filtersutil.SetFn {
 	return func(node *yaml.RNode) error {
 		return node.PipeE(imageTagUpdater{
-			ImageTag: imageTag,
+			ImageTag:        imageTag,
+			RegistryMirrors: mirrors,
 		})
 	}
 }
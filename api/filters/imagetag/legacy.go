@@ -14,9 +14,11 @@ import (
 // any values of any image fields that is inside a sequence under
 // a field called either containers or initContainers. The field is only
 // update if it has a value that matches and image reference and the name
-// of the image is a match with the provided ImageTag.
+// of the image is a match with the provided ImageTag.  The registry of
+// any image is then replaced by its mirror in RegistryMirrors, if any.
 type LegacyFilter struct {
-	ImageTag types.Image `json:"imageTag,omitempty" yaml:"imageTag,omitempty"`
+	ImageTag        types.Image       `json:"imageTag,omitempty" yaml:"imageTag,omitempty"`
+	RegistryMirrors map[string]string `json:"registryMirrors,omitempty" yaml:"registryMirrors,omitempty"`
 }
 
 var _ kio.Filter = LegacyFilter{}
@@ -39,7 +41,7 @@ func (lf LegacyFilter) filter(node *yaml.RNode) (*yaml.RNode, error) {
 
 	fff := findFieldsFilter{
 		fields:        []string{"containers", "initContainers"},
-		fieldCallback: checkImageTagsFn(lf.ImageTag),
+		fieldCallback: checkImageTagsFn(lf.ImageTag, lf.RegistryMirrors),
 	}
 	if err := node.PipeE(fff); err != nil {
 		return nil, err
@@ -96,7 +98,7 @@ func contains(slice []string, str string) bool {
 	return false
 }
 
-func checkImageTagsFn(imageTag types.Image) fieldCallback {
+func checkImageTagsFn(imageTag types.Image, mirrors map[string]string) fieldCallback {
 	return func(node *yaml.RNode) error {
 		if node.YNode().Kind != yaml.SequenceNode {
 			return nil
@@ -106,7 +108,8 @@ func checkImageTagsFn(imageTag types.Image) fieldCallback {
 			// Look up any fields on the provided node that is named
 			// image.
 			return n.PipeE(yaml.Get("image"), imageTagUpdater{
-				ImageTag: imageTag,
+				ImageTag:        imageTag,
+				RegistryMirrors: mirrors,
 			})
 		})
 	}
@@ -11,10 +11,12 @@ import (
 
 // imageTagUpdater is an implementation of the kio.Filter interface
 // that will update the value of the yaml node based on the provided
-// ImageTag if the current value matches the format of an image reference.
+// ImageTag if the current value matches the format of an image reference,
+// and then mirror its registry as given by RegistryMirrors.
 type imageTagUpdater struct {
-	Kind     string      `yaml:"kind,omitempty"`
-	ImageTag types.Image `yaml:"imageTag,omitempty"`
+	Kind            string            `yaml:"kind,omitempty"`
+	ImageTag        types.Image       `yaml:"imageTag,omitempty"`
+	RegistryMirrors map[string]string `yaml:"registryMirrors,omitempty"`
 }
 
 func (u imageTagUpdater) Filter(rn *yaml.RNode) (*yaml.RNode, error) {
@@ -23,21 +25,27 @@ func (u imageTagUpdater) Filter(rn *yaml.RNode) (*yaml.RNode, error) {
 	}
 
 	value := rn.YNode().Value
-
-	if !image.IsImageMatched(value, u.ImageTag.Name) {
-		return rn, nil
+	newValue := value
+
+	if u.ImageTag.Name != "" && image.IsImageMatched(value, u.ImageTag.Name) {
+		name, tag := image.Split(value)
+		if u.ImageTag.NewName != "" {
+			name = u.ImageTag.NewName
+		}
+		if u.ImageTag.NewTag != "" {
+			tag = ":" + u.ImageTag.NewTag
+		}
+		if u.ImageTag.Digest != "" {
+			tag = "@" + u.ImageTag.Digest
+		}
+		newValue = name + tag
 	}
-
-	name, tag := image.Split(value)
-	if u.ImageTag.NewName != "" {
-		name = u.ImageTag.NewName
+	if len(u.RegistryMirrors) > 0 {
+		newValue = image.MirrorRegistry(newValue, u.RegistryMirrors)
 	}
-	if u.ImageTag.NewTag != "" {
-		tag = ":" + u.ImageTag.NewTag
-	}
-	if u.ImageTag.Digest != "" {
-		tag = "@" + u.ImageTag.Digest
+	if newValue == value {
+		return rn, nil
 	}
 
-	return rn.Pipe(yaml.FieldSetter{StringValue: name + tag})
+	return rn.Pipe(yaml.FieldSetter{StringValue: newValue})
 }
@@ -20,6 +20,10 @@ type Filter struct {
 
 	// FsSlice identifies the label fields.
 	FsSlice types.FsSlice
+
+	// Options, if not nil, say whether the labels override
+	// the values the inputs already have for their keys.
+	Options *types.MetadataOptions
 }
 
 var _ kio.Filter = Filter{}
@@ -28,11 +32,28 @@ func (f Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 	keys := yaml.SortedMapKeys(f.Labels)
 	_, err := kio.FilterAll(yaml.FilterFunc(
 		func(node *yaml.RNode) (*yaml.RNode, error) {
+			labels, err := node.Pipe(yaml.Lookup(yaml.MetadataField, yaml.LabelsField))
+			if err != nil {
+				return nil, err
+			}
 			for _, k := range keys {
+				policy := f.Options.PolicyFor(k)
+				if policy == types.ConflictKeepExisting {
+					// Decided once for the resource, as by field
+					// the selectors of a workload could get the
+					// label and its template keep another value.
+					if labels != nil {
+						if v := labels.Field(k); v != nil &&
+							v.Value.YNode().Value != f.Labels[k] {
+							continue
+						}
+					}
+					policy = types.ConflictOverride
+				}
 				if err := node.PipeE(fsslice.Filter{
 					FsSlice: f.FsSlice,
-					SetValue: filtersutil.SetEntry(
-						k, f.Labels[k], yaml.NodeTagString),
+					SetValue: filtersutil.SetEntryWithPolicy(
+						k, f.Labels[k], yaml.NodeTagString, policy),
 					CreateKind: yaml.MappingNode, // Labels are MappingNodes.
 					CreateTag:  yaml.NodeTagMap,
 				}); err != nil {
@@ -36,6 +36,19 @@ type Filter struct {
 
 	// Set of resources to scan to find the ReferralTarget.
 	ReferralCandidates resmap.ResMap
+
+	// ClusterScopedReferralNamespace, if set, is the only
+	// namespace the referrals of a cluster-scoped Referrer, e.g.
+	// the service accounts of the subjects of a ClusterRoleBinding,
+	// may be in, when the reference doesn't name a namespace,
+	// e.g. default; if not set, they may be in any.
+	ClusterScopedReferralNamespace string
+
+	// RecordReferral, if not nil, is called with each referral
+	// found, instead of noting in the referral that Referrer
+	// refers to it, e.g. so that filters with the same
+	// candidates may run at once.
+	RecordReferral func(referral *resource.Resource)
 }
 
 // At time of writing, in practice this is called with a slice with only
@@ -109,12 +122,12 @@ func (f Filter) setMapping(node *yaml.RNode) error {
 		// field to update with a new name.
 		return fmt.Errorf("path config error; no 'name' field in node")
 	}
-	candidates, err := f.filterMapCandidatesByNamespace(node)
+	oldName := nameNode.YNode().Value
+	candidates, namespaced, err := f.filterMapCandidatesByNamespace(node, oldName)
 	if err != nil {
 		return err
 	}
-	oldName := nameNode.YNode().Value
-	referral, err := f.selectReferral(oldName, candidates)
+	referral, err := f.selectReferral(oldName, candidates, namespaced)
 	if err != nil || referral == nil {
 		// Nil referral means nothing to do.
 		return err
@@ -143,28 +156,40 @@ func (f Filter) setMapping(node *yaml.RNode) error {
 	})
 }
 
+// filterMapCandidatesByNamespace returns the candidates named
+// name, at some point, in the namespace given by the node, if any,
+// and whether the node gives one.
 func (f Filter) filterMapCandidatesByNamespace(
-	node *yaml.RNode) ([]*resource.Resource, error) {
+	node *yaml.RNode, name string) ([]*resource.Resource, bool, error) {
 	namespaceNode, err := node.Pipe(yaml.FieldMatcher{Name: "namespace"})
 	if err != nil {
-		return nil, errors.Wrap(err, "trying to match 'namespace' field")
+		return nil, false, errors.Wrap(err, "trying to match 'namespace' field")
 	}
+	candidates := f.ReferralCandidates.GetMatchingResourcesByAnyName(name)
 	if namespaceNode == nil {
-		return f.ReferralCandidates.Resources(), nil
+		return candidates, false, nil
 	}
 	namespace := namespaceNode.YNode().Value
-	nsMap := f.ReferralCandidates.GroupedByOriginalNamespace()
-	if candidates, ok := nsMap[namespace]; ok {
-		return candidates, nil
-	}
-	nsMap = f.ReferralCandidates.GroupedByCurrentNamespace()
-	// This could be nil, or an empty list.
-	return nsMap[namespace], nil
+	if namespace == resid.TotallyNotANamespace {
+		return nil, true, nil
+	}
+	// The original namespace is used if any candidate, by
+	// any name, is originally in it, else the current one.
+	if _, ok := f.ReferralCandidates.GroupedByOriginalNamespace()[namespace]; ok {
+		return doSieve(candidates, func(r *resource.Resource) bool {
+			return r.OrgId().EffectiveNamespace() == namespace
+		}), true, nil
+	}
+	return doSieve(candidates, func(r *resource.Resource) bool {
+		return r.CurId().EffectiveNamespace() == namespace
+	}), true, nil
 }
 
 func (f Filter) setScalar(node *yaml.RNode) error {
 	referral, err := f.selectReferral(
-		node.YNode().Value, f.ReferralCandidates.Resources())
+		node.YNode().Value,
+		f.ReferralCandidates.GetMatchingResourcesByAnyName(node.YNode().Value),
+		false)
 	if err != nil || referral == nil {
 		// Nil referral means nothing to do.
 		return err
@@ -179,6 +204,10 @@ func (f Filter) setScalar(node *yaml.RNode) error {
 
 // In the resource, make a note that it is referred to by the Referrer.
 func (f Filter) recordTheReferral(referral *resource.Resource) {
+	if f.RecordReferral != nil {
+		f.RecordReferral(referral)
+		return
+	}
 	referral.AppendRefBy(f.Referrer.CurId())
 }
 
@@ -283,11 +312,19 @@ func prefixSuffixEquals(other resource.ResCtx) sieveFunc {
 	}
 }
 
-func (f Filter) sameCurrentNamespaceAsReferrer() sieveFunc {
+func (f Filter) sameCurrentNamespaceAsReferrer(namespaced bool) sieveFunc {
 	referrerCurId := f.Referrer.CurId()
 	if !referrerCurId.IsNamespaceableKind() {
-		// If the referrer is cluster-scoped, let anything through.
-		return acceptAll
+		if f.ClusterScopedReferralNamespace == "" || namespaced {
+			// If the referrer is cluster-scoped, let anything
+			// through, or what's in the namespace the reference
+			// names, which the candidates already are.
+			return acceptAll
+		}
+		return func(r *resource.Resource) bool {
+			return !r.CurId().IsNamespaceableKind() ||
+				r.CurId().EffectiveNamespace() == f.ClusterScopedReferralNamespace
+		}
 	}
 	return func(r *resource.Resource) bool {
 		if !r.CurId().IsNamespaceableKind() {
@@ -308,11 +345,13 @@ func (f Filter) sameCurrentNamespaceAsReferrer() sieveFunc {
 func (f Filter) selectReferral(
 	// The name referral that may need to be updated.
 	oldName string,
-	candidates []*resource.Resource) (*resource.Resource, error) {
+	candidates []*resource.Resource,
+	// Whether the reference names the namespace of its referral.
+	namespaced bool) (*resource.Resource, error) {
 	candidates = doSieve(candidates, previousNameMatches(oldName))
 	candidates = doSieve(candidates, previousIdSelectedByGvk(&f.ReferralTarget))
 	candidates = doSieve(candidates, f.roleRefFilter())
-	candidates = doSieve(candidates, f.sameCurrentNamespaceAsReferrer())
+	candidates = doSieve(candidates, f.sameCurrentNamespaceAsReferrer(namespaced))
 	if len(candidates) == 1 {
 		return candidates[0], nil
 	}
@@ -7,6 +7,7 @@ import (
 	"sigs.k8s.io/kustomize/api/filters/fieldspec"
 	"sigs.k8s.io/kustomize/api/filters/filtersutil"
 	"sigs.k8s.io/kustomize/api/filters/fsslice"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -18,6 +19,11 @@ type Filter struct {
 
 	// FsSlice contains the FieldSpecs to locate the namespace field
 	FsSlice types.FsSlice `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+
+	// ClusterScoped lists kinds to treat as cluster-scoped in
+	// addition to those the openapi data says are, e.g. custom
+	// resources whose definitions are part of the build.
+	ClusterScoped []resid.Gvk `json:"clusterScoped,omitempty" yaml:"clusterScoped,omitempty"`
 }
 
 var _ kio.Filter = Filter{}
@@ -76,7 +82,7 @@ func (ns Filter) hacks(obj *yaml.RNode) error {
 // or through inlined OpenAPI on the resource as a YAML comment.
 func (ns Filter) metaNamespaceHack(obj *yaml.RNode, meta yaml.ResourceMeta) error {
 	gvk := fieldspec.GetGVK(meta)
-	if !gvk.IsNamespaceableKind() {
+	if !ns.IsNamespaceable(gvk) {
 		return nil
 	}
 	f := fsslice.Filter{
@@ -90,6 +96,20 @@ func (ns Filter) metaNamespaceHack(obj *yaml.RNode, meta yaml.ResourceMeta) erro
 	return err
 }
 
+// IsNamespaceable returns true if the filter would set
+// metadata.namespace on instances of the given kind.
+func (ns Filter) IsNamespaceable(gvk resid.Gvk) bool {
+	if !gvk.IsNamespaceableKind() {
+		return false
+	}
+	for i := range ns.ClusterScoped {
+		if gvk.IsSelected(&ns.ClusterScoped[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // roleBindingHack is a hack for implementing the namespace transform
 // for RoleBinding and ClusterRoleBinding resource types.
 // RoleBinding and ClusterRoleBinding have namespace set on
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"sigs.k8s.io/kustomize/api/filters/namespace"
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinconfig"
+	"sigs.k8s.io/kustomize/api/resid"
 	filtertest_test "sigs.k8s.io/kustomize/api/testutils/filtertest"
 	"sigs.k8s.io/kustomize/api/types"
 )
@@ -44,6 +45,37 @@ metadata:
 		filter: namespace.Filter{Namespace: "foo"},
 	},
 
+	{
+		name: "declared_cluster_scoped",
+		input: `
+apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: instance
+---
+apiVersion: example.com/v1
+kind: Bar
+metadata:
+  name: instance
+`,
+		expected: `
+apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: instance
+---
+apiVersion: example.com/v1
+kind: Bar
+metadata:
+  name: instance
+  namespace: foo
+`,
+		filter: namespace.Filter{
+			Namespace:     "foo",
+			ClusterScoped: []resid.Gvk{{Group: "example.com", Kind: "Foo"}},
+		},
+	},
+
 	{
 		name: "null_ns",
 		input: `
@@ -0,0 +1,7 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package patchembedded contains a kio.Filter implementation
+// that patches YAML or JSON documents held in string fields,
+// e.g. the config files held in the data of a ConfigMap.
+package patchembedded
@@ -0,0 +1,96 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package patchembedded
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/filters/filtersutil"
+	"sigs.k8s.io/kustomize/api/filters/patchjson6902"
+	"sigs.k8s.io/kustomize/api/filters/patchstrategicmerge"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	FormatYaml = "yaml"
+	FormatJson = "json"
+)
+
+type Filter struct {
+	// FieldPath locates the string field holding the document.
+	FieldPath []string
+
+	// Patch is either a strategic merge patch of the document,
+	// or a JSON 6902 patch (as a JSON or YAML list of operations).
+	Patch string
+
+	// Format of the document, FormatYaml (the default) or FormatJson.
+	Format string
+}
+
+var _ kio.Filter = Filter{}
+
+func (pf Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	patch, err := pf.makeDocumentFilter()
+	if err != nil {
+		return nil, err
+	}
+	return kio.FilterAll(yaml.FilterFunc(func(node *yaml.RNode) (*yaml.RNode, error) {
+		return node, pf.run(node, patch)
+	})).Filter(nodes)
+}
+
+// makeDocumentFilter returns the filter that applies the patch,
+// taking a patch whose content is a list to be a JSON 6902 patch.
+func (pf Filter) makeDocumentFilter() (kio.Filter, error) {
+	p, err := yaml.Parse(pf.Patch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse patch: %v", err)
+	}
+	if p.YNode().Kind == yaml.SequenceNode {
+		return patchjson6902.Filter{Patch: pf.Patch}, nil
+	}
+	return patchstrategicmerge.Filter{Patch: p}, nil
+}
+
+func (pf Filter) run(node *yaml.RNode, patch kio.Filter) error {
+	field, err := node.Pipe(yaml.Lookup(pf.FieldPath...))
+	if err != nil {
+		return err
+	}
+	if field == nil {
+		return fmt.Errorf(
+			"no field %s to patch", strings.Join(pf.FieldPath, "."))
+	}
+	if field.YNode().Kind != yaml.ScalarNode {
+		return fmt.Errorf(
+			"field %s doesn't hold a document", strings.Join(pf.FieldPath, "."))
+	}
+	original := field.YNode().Value
+	doc, err := yaml.Parse(original)
+	if err != nil {
+		return fmt.Errorf(
+			"unable to parse %s: %v", strings.Join(pf.FieldPath, "."), err)
+	}
+	result, err := patch.Filter([]*yaml.RNode{doc})
+	if err != nil {
+		return err
+	}
+	var s string
+	switch {
+	case len(result) == 0:
+		// The patch deleted the whole document.
+	case pf.Format == FormatJson:
+		s, err = filtersutil.MarshalJSONLike(result[0], original)
+	default:
+		s, err = result[0].String()
+	}
+	if err != nil {
+		return err
+	}
+	field.YNode().Value = s
+	return nil
+}
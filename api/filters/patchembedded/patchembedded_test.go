@@ -0,0 +1,97 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package patchembedded
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	filtertest "sigs.k8s.io/kustomize/api/testutils/filtertest"
+)
+
+func TestFilter(t *testing.T) {
+	testCases := []struct {
+		testName       string
+		input          string
+		filter         Filter
+		expectedOutput string
+	}{
+		{
+			testName: "strategic merge, yaml",
+			input: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  config.yaml: |
+    # application settings
+    database:
+      host: localhost # for development
+      port: 5432
+    debug: true
+`,
+			filter: Filter{
+				FieldPath: []string{"data", "config.yaml"},
+				Patch: `
+database:
+  host: db.prod.svc
+debug: null
+`,
+			},
+			expectedOutput: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  config.yaml: |
+    # application settings
+    database:
+      host: db.prod.svc
+      port: 5432
+`,
+		},
+		{
+			testName: "json 6902, json",
+			input: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  config.json: '{"database":{"host":"localhost","port":5432}}'
+`,
+			filter: Filter{
+				FieldPath: []string{"data", "config.json"},
+				Patch: `
+- op: replace
+  path: /database/port
+  value: 6432
+`,
+				Format: FormatJson,
+			},
+			expectedOutput: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  config.json: '{"database":{"host":"localhost","port":6432}}'
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if !assert.Equal(t,
+				strings.TrimSpace(tc.expectedOutput),
+				strings.TrimSpace(
+					filtertest.RunFilter(t, tc.input, tc.filter))) {
+				t.FailNow()
+			}
+		})
+	}
+}
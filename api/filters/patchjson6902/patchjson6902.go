@@ -12,6 +12,15 @@ import (
 	k8syaml "sigs.k8s.io/yaml"
 )
 
+// Filter applies a JSON patch (RFC 6902), in JSON or YAML.
+//
+// Besides a path, or a from, each a JSON pointer, an operation
+// may have a fieldPath, or a fromFieldPath, in the syntax of
+// yaml.SplitFieldPath shared by replacements and fieldSpecs, e.g.
+// spec.template.spec.containers.[name=app].image, which is
+// converted to the pointer of the field in each resource patched.
+// Each list entry selector of the field path must select a
+// single entry, and a wildcard isn't allowed.
 type Filter struct {
 	Patch string
 
@@ -25,6 +34,9 @@ func (pf Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = ValidatePaths(decodedPatch); err != nil {
+		return nil, err
+	}
 	pf.decodedPatch = decodedPatch
 	return kio.FilterAll(yaml.FilterFunc(pf.run)).Filter(nodes)
 }
@@ -56,9 +68,12 @@ func (pf Filter) run(node *yaml.RNode) (*yaml.RNode, error) {
 	if err != nil {
 		return nil, err
 	}
+	if pf.decodedPatch, err = pointToFields(pf.decodedPatch, node); err != nil {
+		return nil, err
+	}
 	res, err := pf.decodedPatch.Apply(b)
 	if err != nil {
-		return nil, err
+		return nil, pf.explain(b, err)
 	}
 	err = node.UnmarshalJSON(res)
 	return node, err
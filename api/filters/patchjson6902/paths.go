@@ -0,0 +1,381 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package patchjson6902
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// keyHoldingFields are the fields whose maps have keys
+// that commonly hold slashes, e.g. example.com/owner.
+var keyHoldingFields = map[string]bool{
+	"annotations":  true,
+	"labels":       true,
+	"matchLabels":  true,
+	"nodeSelector": true,
+}
+
+// ValidatePaths errors if the path, or the from, of an operation
+// of the patch isn't a JSON pointer (RFC 6901): empty, or starting
+// with a slash, with every ~ followed by 0 or 1, or if its
+// fieldPath, or its fromFieldPath, isn't a field path leading to a
+// single field (see Filter).
+func ValidatePaths(patch jsonpatch.Patch) error {
+	for i, op := range patch {
+		fields := []string{"path"}
+		if op.Kind() == "move" || op.Kind() == "copy" {
+			fields = append(fields, "from")
+		}
+		for _, f := range fields {
+			if _, ok := op[fieldPathOf[f]]; ok {
+				if _, ok = op[f]; ok {
+					return fmt.Errorf(
+						"operation %d has both a %s and a %s", i, f, fieldPathOf[f])
+				}
+				if _, err := splitOpFieldPath(op, fieldPathOf[f]); err != nil {
+					return fmt.Errorf("the %s of operation %d: %v", fieldPathOf[f], i, err)
+				}
+				continue
+			}
+			if _, ok := op[f]; !ok {
+				continue
+			}
+			p, err := op.Path()
+			if f == "from" {
+				p, err = op.From()
+			}
+			if err != nil {
+				return fmt.Errorf("the %s of operation %d isn't a string", f, i)
+			}
+			if err = validatePointer(p); err != nil {
+				return fmt.Errorf("the %s of operation %d: %v", f, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fieldPathOf names the fields of an operation holding the
+// field paths that stand for its pointers, by their names.
+var fieldPathOf = map[string]string{
+	"path": "fieldPath",
+	"from": "fromFieldPath",
+}
+
+// splitOpFieldPath returns the parts of the field path
+// held by the field f of the operation.
+func splitOpFieldPath(op jsonpatch.Operation, f string) ([]string, error) {
+	var p string
+	if err := json.Unmarshal(*op[f], &p); err != nil {
+		return nil, fmt.Errorf("isn't a string")
+	}
+	parts, err := yaml.SplitFieldPath(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("is empty")
+	}
+	for _, part := range parts {
+		if part == "*" {
+			return nil, fmt.Errorf(
+				"'%s' has a wildcard, but an operation applies to a single field", p)
+		}
+	}
+	return parts, nil
+}
+
+// pointToFields returns the patch, with the field paths of its
+// operations replaced by the pointers of their fields in node.
+func pointToFields(patch jsonpatch.Patch, node *yaml.RNode) (jsonpatch.Patch, error) {
+	var result jsonpatch.Patch
+	for i, op := range patch {
+		pointed := op
+		if hasFieldPath(op) {
+			pointed = make(jsonpatch.Operation, len(op))
+			for k, v := range op {
+				pointed[k] = v
+			}
+		}
+		for f, fp := range fieldPathOf {
+			if _, ok := op[fp]; !ok {
+				continue
+			}
+			parts, err := splitOpFieldPath(op, fp)
+			if err != nil {
+				return nil, fmt.Errorf("the %s of operation %d: %v", fp, i, err)
+			}
+			p, err := pointerTo(node, parts)
+			if err != nil {
+				return nil, fmt.Errorf("the %s of operation %d: %v", fp, i, err)
+			}
+			raw := json.RawMessage(strconv.Quote(p))
+			pointed[f] = &raw
+			delete(pointed, fp)
+		}
+		result = append(result, pointed)
+	}
+	return result, nil
+}
+
+func hasFieldPath(op jsonpatch.Operation) bool {
+	for _, fp := range fieldPathOf {
+		if _, ok := op[fp]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pointerTo returns the JSON pointer of the field at the parts
+// of a field path from node, each list entry selector replaced
+// by the index of the single entry it selects.
+func pointerTo(node *yaml.RNode, parts []string) (string, error) {
+	var keys []string
+	for _, part := range parts {
+		if !yaml.IsListEntryPart(part) ||
+			node != nil && !yaml.SelectsListEntries(node, part) {
+			// A field, maybe missing, e.g. to be added.
+			keys = append(keys, part)
+			node = fieldOf(node, part)
+			continue
+		}
+		if !yaml.IsListIndex(part) {
+			// A list index, or - for the end of the list.
+			keys = append(keys, part)
+			node = entryOf(node, part)
+			continue
+		}
+		if node == nil {
+			return "", fmt.Errorf(
+				"%s selects the entries of %s, which is missing", part, joinPointer(keys))
+		}
+		entries, err := yaml.SelectListEntries(node, part)
+		if err != nil {
+			return "", err
+		}
+		if len(entries) != 1 {
+			return "", fmt.Errorf(
+				"%s selects %d entries of %s, rather than one", part, len(entries), joinPointer(keys))
+		}
+		for j, e := range node.YNode().Content {
+			if e == entries[0].YNode() {
+				keys = append(keys, strconv.Itoa(j))
+			}
+		}
+		node = entries[0]
+	}
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("/" + escapeKey(k))
+	}
+	return b.String(), nil
+}
+
+// fieldOf returns the value of the field of node, if
+// node is a map having it, or else nil.
+func fieldOf(node *yaml.RNode, name string) *yaml.RNode {
+	if node == nil || node.YNode().Kind != yaml.MappingNode {
+		return nil
+	}
+	if f := node.Field(name); f != nil {
+		return f.Value
+	}
+	return nil
+}
+
+// entryOf returns the entry of node at the list index,
+// if node is a list having it, or else nil.
+func entryOf(node *yaml.RNode, index string) *yaml.RNode {
+	if node == nil || node.YNode().Kind != yaml.SequenceNode {
+		return nil
+	}
+	entries, err := yaml.SelectListEntries(node, index)
+	if err != nil || len(entries) != 1 {
+		return nil
+	}
+	return entries[0]
+}
+
+func validatePointer(p string) error {
+	if p == "" {
+		return nil
+	}
+	if p[0] != '/' {
+		return fmt.Errorf("%q doesn't start with a /", p)
+	}
+	for i := 0; i < len(p); i++ {
+		if p[i] == '~' && (i+1 == len(p) || p[i+1] != '0' && p[i+1] != '1') {
+			return fmt.Errorf(
+				"%q has a ~ not followed by 0 or 1; keys escape ~ as ~0 and / as ~1", p)
+		}
+	}
+	return nil
+}
+
+// explain returns err, the error of applying the patch to
+// doc, saying why the failing operation failed if it can tell,
+// e.g. a key with an unescaped slash, or an index out of bounds.
+func (pf Filter) explain(doc []byte, err error) error {
+	for i, op := range pf.decodedPatch {
+		next, opErr := jsonpatch.Patch{op}.Apply(doc)
+		if opErr == nil {
+			doc = next
+			continue
+		}
+		p, pathErr := op.Path()
+		if pathErr != nil {
+			return err
+		}
+		if hint := diagnose(doc, op.Kind(), p); hint != "" {
+			return fmt.Errorf("%v: operation %d, %s %s: %s", err, i, op.Kind(), p, hint)
+		}
+		return err
+	}
+	return err
+}
+
+// diagnose returns why the operation of the kind, at the
+// path, can't apply to doc, or "" if it can't tell.
+func diagnose(doc []byte, kind, path string) string {
+	var v interface{}
+	if json.Unmarshal(doc, &v) != nil || path == "" {
+		return ""
+	}
+	keys := splitPointer(path)
+	last := len(keys) - 1
+	for i, k := range keys {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			next, ok := t[k]
+			if !ok {
+				if i == last && kind == "add" {
+					return ""
+				}
+				if i < last {
+					return fmt.Sprintf(
+						"%s has no key %q; if %q is a single key, escape its slashes as in %s",
+						joinPointer(keys[:i]), k, strings.Join(keys[i:], "/"),
+						joinPointer(keys[:i])+"/"+escapeKey(strings.Join(keys[i:], "/")))
+				}
+				return fmt.Sprintf("%s has no key %q", joinPointer(keys[:i]), k)
+			}
+			v = next
+		case []interface{}:
+			if k == "-" && i == last && kind == "add" {
+				return ""
+			}
+			n, err := strconv.Atoi(k)
+			if err != nil {
+				return fmt.Sprintf(
+					"%s is a list, which %q doesn't index", joinPointer(keys[:i]), k)
+			}
+			if n < 0 || n > len(t) || n == len(t) && !(i == last && kind == "add") {
+				return fmt.Sprintf(
+					"index %d is out of the bounds of %s, a list of %d entries",
+					n, joinPointer(keys[:i]), len(t))
+			}
+			if n < len(t) {
+				v = t[n]
+			}
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// splitPointer returns the unescaped keys of the pointer.
+func splitPointer(p string) []string {
+	keys := strings.Split(p[1:], "/")
+	for i, k := range keys {
+		keys[i] = strings.ReplaceAll(strings.ReplaceAll(k, "~1", "/"), "~0", "~")
+	}
+	return keys
+}
+
+// joinPointer returns the pointer to the keys, escaping them.
+func joinPointer(keys []string) string {
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("/" + escapeKey(k))
+	}
+	if b.Len() == 0 {
+		return "the document"
+	}
+	return b.String()
+}
+
+func escapeKey(k string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+}
+
+// FixPaths returns the JSON patch, in JSON or YAML, with the
+// common mistakes in the paths of its operations fixed: a ~
+// not followed by 0 or 1 is escaped as ~0, and the slashes in
+// the keys of annotations, labels, and the like, as ~1.
+// It returns whether it fixed any path, and leaves the rest of
+// the patch as it is; a patch that isn't a JSON patch, e.g. a
+// strategic merge patch, is returned as it is.
+func FixPaths(patch string) (string, bool, error) {
+	decoded, err := Filter{Patch: patch}.decodePatch()
+	if err != nil {
+		return patch, false, nil
+	}
+	fixed := false
+	for _, op := range decoded {
+		for _, f := range []string{"path", "from"} {
+			raw, ok := op[f]
+			if !ok || raw == nil {
+				continue
+			}
+			var p string
+			if json.Unmarshal(*raw, &p) != nil {
+				continue
+			}
+			fp := fixPath(p)
+			if fp == p {
+				continue
+			}
+			re, err := regexp.Compile(`(?m)(["']?` + f + `["']?\s*:\s*["']?)` +
+				regexp.QuoteMeta(p) + `(["']?\s*(?:,|}|$))`)
+			if err != nil {
+				return patch, false, err
+			}
+			patch = re.ReplaceAllString(patch, "${1}"+strings.ReplaceAll(fp, "$", "$$")+"${2}")
+			fixed = true
+		}
+	}
+	return patch, fixed, nil
+}
+
+// fixPath returns the pointer with its stray ~s escaped, and
+// the slashes of the key after a keyHoldingFields key escaped.
+func fixPath(p string) string {
+	if p == "" || p[0] != '/' {
+		return p
+	}
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		b.WriteByte(p[i])
+		if p[i] == '~' && (i+1 == len(p) || p[i+1] != '0' && p[i+1] != '1') {
+			b.WriteByte('0')
+		}
+	}
+	keys := strings.Split(b.String()[1:], "/")
+	for i, k := range keys {
+		if keyHoldingFields[k] && i+2 < len(keys) {
+			keys = append(keys[:i+1], strings.Join(keys[i+1:], "~1"))
+			break
+		}
+	}
+	return "/" + strings.Join(keys, "/")
+}
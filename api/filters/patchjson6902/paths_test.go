@@ -0,0 +1,238 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package patchjson6902
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	filtertest "sigs.k8s.io/kustomize/api/testutils/filtertest"
+)
+
+func TestFilterErrors(t *testing.T) {
+	testCases := map[string]struct {
+		patch    string
+		expected string
+	}{
+		"unescaped tilde": {
+			patch: `[{"op": "add", "path": "/metadata/annotations/a~b", "value": "x"}]`,
+			expected: `the path of operation 0: "/metadata/annotations/a~b" ` +
+				`has a ~ not followed by 0 or 1; keys escape ~ as ~0 and / as ~1`,
+		},
+		"no leading slash": {
+			patch:    `[{"op": "remove", "path": "spec/replica"}]`,
+			expected: `the path of operation 0: "spec/replica" doesn't start with a /`,
+		},
+		"bad from": {
+			patch: `[{"op": "move", "from": "/spec/replica~", "path": "/spec/replicas"}]`,
+			expected: `the from of operation 0: "/spec/replica~" ` +
+				`has a ~ not followed by 0 or 1; keys escape ~ as ~0 and / as ~1`,
+		},
+		"unescaped slash": {
+			patch: `[{"op": "add", "path": "/spec/template/metadata/labels/example.com/tier", "value": "web"}]`,
+			expected: `add operation does not apply: doc is missing path: ` +
+				`"/spec/template/metadata/labels/example.com/tier": missing value: ` +
+				`operation 0, add /spec/template/metadata/labels/example.com/tier: ` +
+				`/spec/template/metadata/labels has no key "example.com"; ` +
+				`if "example.com/tier" is a single key, escape its slashes as in ` +
+				`/spec/template/metadata/labels/example.com~1tier`,
+		},
+		"index out of bounds": {
+			patch: `[
+{"op": "replace", "path": "/spec/replica", "value": 3},
+{"op": "replace", "path": "/spec/template/spec/containers/1/image", "value": "nginx:1.21"}
+]`,
+			expected: `replace operation does not apply: doc is missing path: ` +
+				`/spec/template/spec/containers/1/image: missing value: ` +
+				`operation 1, replace /spec/template/spec/containers/1/image: ` +
+				`index 1 is out of the bounds of /spec/template/spec/containers, a list of 1 entries`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := filtertest.RunFilterE(t, input, Filter{Patch: tc.patch})
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, err.Error())
+		})
+	}
+}
+
+func TestFieldPaths(t *testing.T) {
+	const deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    "8080": http
+spec:
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: envoy
+      - name: app
+        image: nginx
+`
+	testCases := map[string]struct {
+		patch    string
+		expected string
+		err      string
+	}{
+		"list entry selector": {
+			patch: `
+- op: replace
+  fieldPath: spec.template.spec.containers.[name=app].image
+  value: nginx:1.21
+- op: add
+  fieldPath: metadata.annotations.example\.com/owner
+  value: team-a
+- op: copy
+  fromFieldPath: metadata.annotations.8080
+  fieldPath: metadata.annotations.port
+`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    "8080": http
+    example.com/owner: team-a
+    port: http
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: envoy
+        name: sidecar
+      - image: nginx:1.21
+        name: app
+`,
+		},
+		"index and end": {
+			patch: `
+- op: remove
+  fieldPath: spec.template.spec.containers.0
+- op: add
+  fieldPath: spec.template.spec.containers.-
+  value: {name: proxy, image: haproxy}
+`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    "8080": http
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: app
+      - image: haproxy
+        name: proxy
+`,
+		},
+		"no entry": {
+			patch: `[{"op": "remove", "fieldPath": "spec.template.spec.containers.[name=db]"}]`,
+			err: "the fieldPath of operation 0: [name=db] selects 0 entries of " +
+				"/spec/template/spec/containers, rather than one",
+		},
+		"several entries": {
+			patch: `[{"op": "remove", "fieldPath": "spec.template.spec.containers.[image=*n*]"}]`,
+			err: "the fieldPath of operation 0: [image=*n*] selects 2 entries of " +
+				"/spec/template/spec/containers, rather than one",
+		},
+		"wildcard": {
+			patch: `[{"op": "remove", "fieldPath": "spec.template.spec.containers.*"}]`,
+			err: "the fieldPath of operation 0: 'spec.template.spec.containers.*' " +
+				"has a wildcard, but an operation applies to a single field",
+		},
+		"path and fieldPath": {
+			patch: `[{"op": "remove", "path": "/spec", "fieldPath": "spec"}]`,
+			err:   "operation 0 has both a path and a fieldPath",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := filtertest.RunFilterE(t, deployment, Filter{Patch: tc.patch})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestFixPaths(t *testing.T) {
+	testCases := map[string]struct {
+		patch    string
+		expected string
+		fixed    bool
+	}{
+		"json": {
+			patch: `[
+  {"op": "add", "path": "/metadata/annotations/example.com/owner", "value": "team-a"},
+  {"op": "replace", "path": "/spec/replicas", "value": 3}
+]`,
+			expected: `[
+  {"op": "add", "path": "/metadata/annotations/example.com~1owner", "value": "team-a"},
+  {"op": "replace", "path": "/spec/replicas", "value": 3}
+]`,
+			fixed: true,
+		},
+		"yaml": {
+			patch: `- op: add
+  path: /spec/selector/matchLabels/app.kubernetes.io/name
+  value: web
+- op: copy
+  from: /metadata/labels/a~b
+  path: "/metadata/labels/c"
+`,
+			expected: `- op: add
+  path: /spec/selector/matchLabels/app.kubernetes.io~1name
+  value: web
+- op: copy
+  from: /metadata/labels/a~0b
+  path: "/metadata/labels/c"
+`,
+			fixed: true,
+		},
+		"nothing to fix": {
+			patch:    `[{"op": "add", "path": "/metadata/labels/tier", "value": "web"}]`,
+			expected: `[{"op": "add", "path": "/metadata/labels/tier", "value": "web"}]`,
+		},
+		"strategic merge patch": {
+			patch: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, fixed, err := FixPaths(tc.patch)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.fixed, fixed)
+		})
+	}
+}
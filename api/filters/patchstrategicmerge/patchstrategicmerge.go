@@ -4,6 +4,7 @@
 package patchstrategicmerge
 
 import (
+	"sigs.k8s.io/kustomize/kyaml/comments"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 	"sigs.k8s.io/kustomize/kyaml/yaml/merge2"
@@ -11,6 +12,14 @@ import (
 
 type Filter struct {
 	Patch *yaml.RNode
+
+	// ListMerging is how the lists of the patch are
+	// merged into those of the nodes.
+	ListMerging yaml.MergeOptionsListMerging
+
+	// KeepComments, if true, copies the comments of the patch
+	// to the fields of the nodes that have none.
+	KeepComments bool
 }
 
 var _ kio.Filter = Filter{}
@@ -23,14 +32,21 @@ func (pf Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 			pf.Patch, nodes[i],
 			yaml.MergeOptions{
 				ListIncreaseDirection: yaml.MergeOptionsListPrepend,
+				ListMerging:           pf.ListMerging,
 			},
 		)
 		if err != nil {
 			return nil, err
 		}
-		if r != nil {
-			result = append(result, r)
+		if r == nil {
+			continue
+		}
+		if pf.KeepComments {
+			if err = comments.CopyComments(pf.Patch, r); err != nil {
+				return nil, err
+			}
 		}
+		result = append(result, r)
 	}
 	return result, nil
 }
@@ -747,3 +747,40 @@ spec:
 		})
 	}
 }
+
+func TestFilterKeepingComments(t *testing.T) {
+	patch := yaml.MustParse(`apiVersion: v1
+kind: Deployment
+metadata:
+  name: clown
+spec:
+  # Enough for the party.
+  numReplicas: 999
+  nose: red # Honks.
+`)
+	input := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: clown
+spec:
+  numReplicas: 1
+`
+	assert.Equal(t, `apiVersion: v1
+kind: Deployment
+metadata:
+  name: clown
+spec:
+  # Enough for the party.
+  numReplicas: 999
+  nose: red # Honks.`,
+		strings.TrimSpace(filtertest.RunFilter(
+			t, input, Filter{Patch: patch, KeepComments: true})))
+	assert.Equal(t, `apiVersion: v1
+kind: Deployment
+metadata:
+  name: clown
+spec:
+  numReplicas: 999
+  nose: red # Honks.`,
+		strings.TrimSpace(filtertest.RunFilter(t, input, Filter{Patch: patch})))
+}
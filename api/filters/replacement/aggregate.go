@@ -0,0 +1,106 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package replacement
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	aggregateJoin  = "join"
+	aggregateMin   = "min"
+	aggregateMax   = "max"
+	aggregateCount = "count"
+)
+
+// getAggregatedValue returns the value of the source's
+// field path in each of the nodes it selects, in the order
+// of nodes, made into one per the source's options.aggregate.
+// Nodes without the field are skipped.
+func getAggregatedValue(
+	nodes []*yaml.RNode, selector *types.SourceSelector) (*yaml.RNode, error) {
+	if selector.FieldPath == "" {
+		selector.FieldPath = types.DefaultReplacementFieldPath
+	}
+	fieldPath, err := yaml.SplitFieldPath(selector.FieldPath)
+	if err != nil {
+		return nil, err
+	}
+	var values []*yaml.RNode
+	for _, n := range nodes {
+		ok, err := sourceMatches(n, selector)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		v, err := n.Pipe(yaml.Lookup(fieldPath...))
+		if err != nil {
+			return nil, err
+		}
+		if v.IsNil() {
+			continue
+		}
+		if v.YNode().Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf(
+				"options.aggregate can only be used with scalar fields, "+
+					"but %s of %s isn't one", selector.FieldPath, n.GetName())
+		}
+		values = append(values, v)
+	}
+	options := selector.Options
+	switch options.Aggregate {
+	case aggregateJoin:
+		delimiter := options.Delimiter
+		if delimiter == "" {
+			delimiter = ","
+		}
+		var parts []string
+		for _, v := range values {
+			parts = append(parts, yaml.GetValue(v))
+		}
+		return yaml.NewStringRNode(strings.Join(parts, delimiter)), nil
+	case aggregateCount:
+		count := yaml.NewScalarRNode(strconv.Itoa(len(values)))
+		count.YNode().Tag = yaml.NodeTagInt
+		return count, nil
+	case aggregateMin, aggregateMax:
+		return extremeValue(values, options.Aggregate, selector)
+	}
+	return nil, fmt.Errorf(
+		"options.aggregate must be one of %s, %s, %s or %s, not %q",
+		aggregateJoin, aggregateMin, aggregateMax, aggregateCount, options.Aggregate)
+}
+
+// extremeValue returns the least or greatest of the values,
+// per aggregate, which must all be numbers.
+func extremeValue(values []*yaml.RNode, aggregate string,
+	selector *types.SourceSelector) (*yaml.RNode, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf(
+			"found no values at %s of source %v to take the %s of",
+			selector.FieldPath, selector.KrmId, aggregate)
+	}
+	var result *yaml.RNode
+	var extreme float64
+	for _, v := range values {
+		x, err := strconv.ParseFloat(yaml.GetValue(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"options.aggregate %s requires numbers, but %s of source %v has %q",
+				aggregate, selector.FieldPath, selector.KrmId, yaml.GetValue(v))
+		}
+		if result == nil || (aggregate == aggregateMin && x < extreme) ||
+			(aggregate == aggregateMax && x > extreme) {
+			result, extreme = v, x
+		}
+	}
+	return result.Copy(), nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package replacement
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/filters/filtersutil"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	formatYaml       = "yaml"
+	formatJson       = "json"
+	formatProperties = "properties"
+)
+
+// setInnerValue sets the field at the target's InnerFieldPath within
+// the document held by the string field t, re-serializing the
+// document in its format.
+func setInnerValue(target *types.TargetSelector, t *yaml.RNode, value *yaml.RNode) error {
+	options := target.Options
+	if options == nil || options.Format == "" {
+		return fmt.Errorf("innerFieldPath requires options.format")
+	}
+	if t.YNode().Kind != yaml.ScalarNode {
+		return fmt.Errorf("innerFieldPath can only be used with string fields")
+	}
+	if value.YNode().Kind != yaml.ScalarNode && options.Format == formatProperties {
+		return fmt.Errorf("only scalar values can be set in properties")
+	}
+	doc := t.YNode().Value
+	var result string
+	var err error
+	switch options.Format {
+	case formatYaml, formatJson:
+		result, err = setInStructured(
+			doc, options.Format, target.InnerFieldPath, value, options.Create)
+	case formatProperties:
+		result, err = setInProperties(
+			doc, target.InnerFieldPath, yaml.GetValue(value), options.Create)
+	default:
+		return fmt.Errorf(
+			"options.format must be one of %s, %s or %s",
+			formatYaml, formatJson, formatProperties)
+	}
+	if err != nil {
+		return fmt.Errorf("setting %s in %s: %v", target.InnerFieldPath, options.Format, err)
+	}
+	t.YNode().Value = result
+	return nil
+}
+
+func setInStructured(
+	doc, format, innerPath string, value *yaml.RNode, create bool) (string, error) {
+	rn, err := yaml.Parse(doc)
+	if err != nil {
+		return "", err
+	}
+	path, err := yaml.SplitFieldPath(innerPath)
+	if err != nil {
+		return "", err
+	}
+	var field *yaml.RNode
+	if create {
+		field, err = rn.Pipe(yaml.LookupCreate(value.YNode().Kind, path...))
+	} else {
+		field, err = rn.Pipe(yaml.Lookup(path...))
+	}
+	if err != nil || field == nil {
+		// Nothing to set, leave the document as it is.
+		return doc, err
+	}
+	field.SetYNode(value.Copy().YNode())
+	if format == formatJson {
+		return filtersutil.MarshalJSONLike(rn, doc)
+	}
+	return rn.String()
+}
+
+// setInProperties sets the value of key in a java-style properties
+// document, keeping each line's layout and separator.
+func setInProperties(doc, key, value string, create bool) (string, error) {
+	lines := strings.Split(doc, "\n")
+	found := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 || strings.TrimSpace(line[:sep]) != key {
+			continue
+		}
+		rest := line[sep+1:]
+		lines[i] = line[:sep+1] + rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))] + value
+		found = true
+	}
+	if found {
+		return strings.Join(lines, "\n"), nil
+	}
+	if !create {
+		return doc, nil
+	}
+	if doc != "" && !strings.HasSuffix(doc, "\n") {
+		doc += "\n"
+	}
+	return doc + key + "=" + value + "\n", nil
+}
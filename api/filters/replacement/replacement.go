@@ -6,7 +6,9 @@ package replacement
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -14,9 +16,24 @@ import (
 
 type Filter struct {
 	Replacements []types.Replacement `json:"replacements,omitempty" yaml:"replacements,omitempty"`
+
+	// OnCreate, if not nil, is called with each field path of
+	// a target that options.create creates, rather than updates,
+	// and the id of the resource it's created in.
+	OnCreate func(id resid.ResId, fieldPath string) `json:"-" yaml:"-"`
+
+	// CheckTarget, if not nil, is called with the value of each
+	// replacement before it's written to each field path of each
+	// target node, or, with an empty field path, to the file
+	// sources of the node; an error fails the replacement.
+	CheckTarget func(value *yaml.RNode, node *yaml.RNode,
+		target *types.TargetSelector, fieldPath string) error `json:"-" yaml:"-"`
 }
 
-// Filter replaces values of targets with values from sources
+// Filter replaces values of targets with values from sources.
+// The replacements, and their targets, are applied in the order
+// given, each to the selected nodes in the order of nodes, so the
+// result doesn't depend on anything but the order of the inputs.
 func (f Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 	for _, r := range f.Replacements {
 		if r.Source == nil || r.Targets == nil {
@@ -26,7 +43,7 @@ func (f Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 		if err != nil {
 			return nil, err
 		}
-		nodes, err = applyReplacement(nodes, value, r.Targets)
+		nodes, err = f.applyReplacement(nodes, value, r.Targets)
 		if err != nil {
 			return nil, err
 		}
@@ -34,21 +51,31 @@ func (f Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 	return nodes, nil
 }
 
-func applyReplacement(nodes []*yaml.RNode, value *yaml.RNode, targets []*types.TargetSelector) ([]*yaml.RNode, error) {
+func (f Filter) applyReplacement(nodes []*yaml.RNode, value *yaml.RNode, targets []*types.TargetSelector) ([]*yaml.RNode, error) {
 	for _, t := range targets {
 		if t.Select == nil {
 			return nil, fmt.Errorf("target must specify resources to select")
 		}
-		if len(t.FieldPaths) == 0 {
+		if t.Options != nil && t.Options.Aggregate != "" {
+			return nil, fmt.Errorf("options.aggregate only applies to sources")
+		}
+		toFiles := len(t.FieldPaths) == 0 && t.Options != nil && t.Options.Token != ""
+		if len(t.FieldPaths) == 0 && !toFiles {
 			t.FieldPaths = []string{types.DefaultReplacementFieldPath}
 		}
 		for _, n := range nodes {
 			nodeId := getKrmId(n)
-			if t.Select.KrmId.Match(nodeId) && !rejectId(t.Reject, nodeId) {
-				err := applyToNode(n, value, t)
-				if err != nil {
-					return nil, err
-				}
+			if !t.Select.KrmId.Match(nodeId) || rejectId(t.Reject, nodeId) {
+				continue
+			}
+			var err error
+			if toFiles {
+				err = f.applyToFiles(n, value, t)
+			} else {
+				err = f.applyToNode(n, value, t)
+			}
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -64,29 +91,213 @@ func rejectId(rejects []*types.Selector, nodeId *types.KrmId) bool {
 	return false
 }
 
-func applyToNode(node *yaml.RNode, value *yaml.RNode, target *types.TargetSelector) error {
+func (f Filter) applyToNode(node *yaml.RNode, value *yaml.RNode, target *types.TargetSelector) error {
 	for _, fp := range target.FieldPaths {
-		fieldPath := strings.Split(fp, ".")
+		fieldPath, err := yaml.SplitFieldPath(fp)
+		if err != nil {
+			return err
+		}
+		create := target.Options != nil && target.Options.Create
+		var existing []*yaml.RNode
+		if create && f.OnCreate != nil {
+			existing, err = lookupTargets(node, fieldPath, false, value.YNode().Kind)
+			if err != nil {
+				return err
+			}
+		}
+		if create && target.Options.Template != nil {
+			if err := createFromTemplate(node, fieldPath, target.Options.Template); err != nil {
+				return err
+			}
+		}
+		targets, err := lookupTargets(node, fieldPath, create, value.YNode().Kind)
+		if err != nil {
+			return err
+		}
+		if create && f.OnCreate != nil && len(existing) == 0 && len(targets) > 0 {
+			id := getKrmId(node)
+			f.OnCreate(resid.NewResIdWithNamespace(id.Gvk, id.Name, id.Namespace), fp)
+		}
+		if f.CheckTarget != nil && len(targets) > 0 {
+			if err = f.CheckTarget(value, node, target, fp); err != nil {
+				return err
+			}
+		}
+		for _, t := range targets {
+			// Copied, as setting it may modify it, e.g. per options.delimiter.
+			v := value.Copy()
+			if target.InnerFieldPath != "" {
+				err = setInnerValue(target, t, v)
+			} else {
+				err = setTargetValue(target.Options, t, v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookupTargets returns the fields at the field path from node.
+// A list entry selector in the path, e.g. [name=app], selects all
+// the entries matching it, and its value may be a glob, e.g.
+// [name=*config*].  If create is true, missing fields are created
+// as by yaml.LookupCreate, other than entries selected by a glob
+// or a wildcard.
+func lookupTargets(
+	node *yaml.RNode, fieldPath []string, create bool, kind yaml.Kind) ([]*yaml.RNode, error) {
+	i, err := nextListEntryPart(node, fieldPath)
+	if err != nil {
+		return nil, err
+	}
+	if i == len(fieldPath) {
 		var t *yaml.RNode
-		var err error
-		if target.Options != nil && target.Options.Create {
-			t, err = node.Pipe(yaml.LookupCreate(value.YNode().Kind, fieldPath...))
+		if create {
+			t, err = node.Pipe(yaml.LookupCreate(kind, fieldPath...))
 		} else {
 			t, err = node.Pipe(yaml.Lookup(fieldPath...))
 		}
+		if err != nil || t == nil {
+			return nil, err
+		}
+		return []*yaml.RNode{t}, nil
+	}
+	list, err := node.Pipe(yaml.Lookup(fieldPath[:i]...))
+	if err != nil {
+		return nil, err
+	}
+	var result []*yaml.RNode
+	if list != nil && list.YNode().Kind == yaml.SequenceNode {
+		entries, err := yaml.SelectListEntries(list, fieldPath[i])
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if t != nil {
-			if err = setTargetValue(target.Options, t, value); err != nil {
-				return err
+		for _, e := range entries {
+			targets, err := lookupTargets(e, fieldPath[i+1:], create, kind)
+			if err != nil {
+				return nil, err
 			}
+			result = append(result, targets...)
+		}
+	}
+	if len(result) == 0 && create && yaml.IsListIndex(fieldPath[i]) &&
+		!yaml.IsGlobSelector(fieldPath[i]) {
+		t, err := node.Pipe(yaml.LookupCreate(kind, fieldPath...))
+		if err != nil || t == nil {
+			return nil, err
+		}
+		return []*yaml.RNode{t}, nil
+	}
+	return result, nil
+}
+
+// nextListEntryPart returns the index of the first part of the field
+// path from node that selects list entries, or the length of the path
+// if none does.  A list index, i.e. a number, naming a field of a map,
+// e.g. 8080 in data.8080, doesn't select entries.
+func nextListEntryPart(node *yaml.RNode, fieldPath []string) (int, error) {
+	for i, part := range fieldPath {
+		if !yaml.IsListEntryPart(part) {
+			continue
+		}
+		parent, err := node.Pipe(yaml.Lookup(fieldPath[:i]...))
+		if err != nil {
+			return 0, err
+		}
+		if parent == nil || yaml.SelectsListEntries(parent, part) {
+			return i, nil
+		}
+	}
+	return len(fieldPath), nil
+}
+
+// createFromTemplate creates the last list entry selected
+// by the field path, if it's missing, from the template.
+func createFromTemplate(
+	node *yaml.RNode, fieldPath []string, template map[string]interface{}) error {
+	i := len(fieldPath) - 1
+	for i >= 0 && !yaml.IsListIndex(fieldPath[i]) {
+		i--
+	}
+	if i < 0 {
+		return fmt.Errorf(
+			"options.template requires a field path selecting a list entry, e.g. env.[name=FOO].value")
+	}
+	key, value, err := yaml.SplitIndexNameValue(fieldPath[i])
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return fmt.Errorf(
+			"options.template can't create the list entry %s, which isn't a map", fieldPath[i])
+	}
+	if yaml.IsGlobSelector(fieldPath[i]) {
+		return fmt.Errorf(
+			"options.template can't create the list entry %s, selected by a pattern", fieldPath[i])
+	}
+	entry, err := node.Pipe(yaml.Lookup(fieldPath[:i+1]...))
+	if err != nil || entry != nil {
+		return err
+	}
+	list, err := node.Pipe(yaml.LookupCreate(yaml.SequenceNode, fieldPath[:i]...))
+	if err != nil {
+		return err
+	}
+	fields, err := yaml.FromMap(template)
+	if err != nil {
+		return err
+	}
+	entry = yaml.NewMapRNode(&map[string]string{key: value})
+	err = fields.VisitFields(func(f *yaml.MapNode) error {
+		if f.Key.YNode().Value == key {
+			return nil
+		}
+		return entry.PipeE(yaml.SetField(f.Key.YNode().Value, f.Value))
+	})
+	if err != nil {
+		return err
+	}
+	return list.PipeE(yaml.Append(entry.YNode()))
+}
+
+// applyToFiles replaces the target's token in the content of
+// the file sources the node, a generated ConfigMap, lists in
+// its replacement files annotation.
+func (f Filter) applyToFiles(node *yaml.RNode, value *yaml.RNode, target *types.TargetSelector) error {
+	annotations, err := node.GetAnnotations()
+	if err != nil {
+		return err
+	}
+	keys := annotations[konfig.ReplacementFilesAnnotation]
+	if keys == "" {
+		return nil
+	}
+	if f.CheckTarget != nil {
+		if err = f.CheckTarget(value, node, target, ""); err != nil {
+			return err
+		}
+	}
+	for _, key := range strings.Split(keys, ",") {
+		t, err := node.Pipe(yaml.Lookup(yaml.DataField, key))
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			continue
+		}
+		if err = setTargetValue(target.Options, t, value.Copy()); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
 func setTargetValue(options *types.FieldOptions, t *yaml.RNode, value *yaml.RNode) error {
+	if options != nil && options.Token != "" {
+		return replaceToken(options, t, value)
+	}
+	n := *value.YNode()
 	if options != nil && options.Delimiter != "" {
 
 		if t.YNode().Kind != yaml.ScalarNode {
@@ -104,13 +315,56 @@ func setTargetValue(options *types.FieldOptions, t *yaml.RNode, value *yaml.RNod
 		default: // replace an element
 			tv[options.Index] = v
 		}
-		value.YNode().Value = strings.Join(tv, options.Delimiter)
+		n.Value = strings.Join(tv, options.Delimiter)
+	}
+	if options == nil || !options.SourceStyle {
+		keepTargetStyle(t.YNode(), &n)
+	}
+	t.SetYNode(&n)
+	return nil
+}
+
+// keepTargetStyle gives the node replacing the target
+// the target's comments, and, if of the same kind and tag,
+// its style, e.g. the quoting of a scalar or the flow style
+// of a list, so that replacements don't change how
+// committed output reads.  The style of a value of another
+// tag isn't kept, as quoting an int, say, would make it a
+// string.
+func keepTargetStyle(target *yaml.Node, n *yaml.Node) {
+	if target.Kind == n.Kind && target.ShortTag() == n.ShortTag() {
+		n.Style = target.Style
+	}
+	n.HeadComment = target.HeadComment
+	n.LineComment = target.LineComment
+	n.FootComment = target.FootComment
+}
+
+// replaceToken replaces each occurrence of the token of the
+// options in the string field t by the value.
+func replaceToken(options *types.FieldOptions, t *yaml.RNode, value *yaml.RNode) error {
+	if options.Delimiter != "" {
+		return fmt.Errorf("options.token and options.delimiter can't be used together")
+	}
+	if t.YNode().Kind != yaml.ScalarNode {
+		return fmt.Errorf("token option can only be used with scalar nodes")
+	}
+	if value.YNode().Kind != yaml.ScalarNode {
+		return fmt.Errorf("token option can only be used with a scalar value")
 	}
-	t.SetYNode(value.YNode())
+	t.YNode().Value = strings.ReplaceAll(
+		t.YNode().Value, options.Token, yaml.GetValue(value))
 	return nil
 }
 
 func getReplacement(nodes []*yaml.RNode, r *types.Replacement) (*yaml.RNode, error) {
+	if r.Source.Options != nil && r.Source.Options.Aggregate != "" {
+		if r.Source.Options.OnMultipleMatches != "" {
+			return nil, fmt.Errorf(
+				"options.onMultipleMatches doesn't apply to aggregated sources")
+		}
+		return getAggregatedValue(nodes, r.Source)
+	}
 	source, err := selectSourceNode(nodes, r.Source)
 	if err != nil {
 		return nil, err
@@ -119,7 +373,10 @@ func getReplacement(nodes []*yaml.RNode, r *types.Replacement) (*yaml.RNode, err
 	if r.Source.FieldPath == "" {
 		r.Source.FieldPath = types.DefaultReplacementFieldPath
 	}
-	fieldPath := strings.Split(r.Source.FieldPath, ".")
+	fieldPath, err := yaml.SplitFieldPath(r.Source.FieldPath)
+	if err != nil {
+		return nil, err
+	}
 
 	rn, err := source.Pipe(yaml.Lookup(fieldPath...))
 	if err != nil {
@@ -147,24 +404,91 @@ func getRefinedValue(options *types.FieldOptions, rn *yaml.RNode) (*yaml.RNode,
 	return n, nil
 }
 
+const (
+	onMultipleMatchesError  = "error"
+	onMultipleMatchesFirst  = "first"
+	onMultipleMatchesLatest = "latest"
+)
+
 // selectSourceNode finds the node that matches the selector, returning
-// an error if multiple or none are found
+// an error if none are found, or if multiple are and the selector's
+// options.onMultipleMatches doesn't say which to use.
 func selectSourceNode(nodes []*yaml.RNode, selector *types.SourceSelector) (*yaml.RNode, error) {
+	onMultiple := onMultipleMatchesError
+	if selector.Options != nil && selector.Options.OnMultipleMatches != "" {
+		onMultiple = selector.Options.OnMultipleMatches
+	}
+	switch onMultiple {
+	case onMultipleMatchesError, onMultipleMatchesFirst, onMultipleMatchesLatest:
+	default:
+		return nil, fmt.Errorf(
+			"unknown options.onMultipleMatches %q, expected %s, %s or %s", onMultiple,
+			onMultipleMatchesError, onMultipleMatchesFirst, onMultipleMatchesLatest)
+	}
 	var matches []*yaml.RNode
 	for _, n := range nodes {
-		if selector.KrmId.Match(getKrmId(n)) {
-			if len(matches) > 0 {
-				return nil, fmt.Errorf("more than one match for source %v", selector)
-			}
-			matches = append(matches, n)
+		ok, err := sourceMatches(n, selector)
+		if err != nil {
+			return nil, err
 		}
+		if !ok {
+			continue
+		}
+		if len(matches) > 0 && onMultiple == onMultipleMatchesError {
+			return nil, fmt.Errorf("more than one match for source %v", selector)
+		}
+		matches = append(matches, n)
 	}
 	if len(matches) == 0 {
 		return nil, fmt.Errorf("found no matches for source %v", selector)
 	}
+	if onMultiple == onMultipleMatchesLatest {
+		return latestNode(matches)
+	}
 	return matches[0], nil
 }
 
+// sourceMatches returns whether the selector selects n.
+func sourceMatches(n *yaml.RNode, selector *types.SourceSelector) (bool, error) {
+	if !selector.KrmId.Match(getKrmId(n)) {
+		return false, nil
+	}
+	if selector.LabelSelector == "" {
+		return true, nil
+	}
+	ok, err := n.MatchesLabelSelector(selector.LabelSelector)
+	if err != nil {
+		return false, fmt.Errorf(
+			"the labelSelector of source %v: %v", selector, err)
+	}
+	return ok, nil
+}
+
+// latestNode returns the node of the latest creation timestamp
+// annotation, the later in the order of nodes of those of the
+// same time.  Nodes without the annotation are the earliest.
+func latestNode(nodes []*yaml.RNode) (*yaml.RNode, error) {
+	var latest *yaml.RNode
+	var latestTime time.Time
+	for _, n := range nodes {
+		annotations, err := n.GetAnnotations()
+		if err != nil {
+			return nil, err
+		}
+		var t time.Time
+		if v := annotations[konfig.CreationTimestampAnnotation]; v != "" {
+			if t, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, fmt.Errorf("the %s annotation of %s: %v",
+					konfig.CreationTimestampAnnotation, n.GetName(), err)
+			}
+		}
+		if latest == nil || !t.Before(latestTime) {
+			latest, latestTime = n, t
+		}
+	}
+	return latest, nil
+}
+
 func getKrmId(n *yaml.RNode) *types.KrmId {
 	ns, err := n.GetNamespace()
 	if err != nil {
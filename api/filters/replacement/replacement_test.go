@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/resid"
 	filtertest "sigs.k8s.io/kustomize/api/testutils/filtertest"
 	"sigs.k8s.io/yaml"
 )
@@ -57,6 +58,36 @@ spec:
         name: nginx-tagged
       - image: nginx:1.7.9
         name: postgresdb
+`,
+		},
+		"numeric map keys": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ports
+data:
+  "8080": web
+  "9090": metrics
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: ports
+    fieldPath: data.8080
+  targets:
+  - select:
+      kind: ConfigMap
+      name: ports
+    fieldPaths:
+    - data.9090
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ports
+data:
+  "8080": web
+  "9090": web
 `,
 		},
 		"complex type": {
@@ -1338,28 +1369,1572 @@ spec:
 `,
 			expectedErr: "delimiter option can only be used with scalar nodes",
 		},
+		"inner yaml": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  config.yaml: |
+    # application settings
+    database:
+      host: localhost
+      port: 5432
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.host
+  targets:
+  - select:
+      name: app-config
+    fieldPaths:
+    - data.config\.yaml
+    innerFieldPath: database.host
+    options:
+      format: yaml
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  config.yaml: |
+    # application settings
+    database:
+      host: db.prod.svc
+      port: 5432
+`,
+		},
+		"inner json": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  config.json: '{"database":{"host":"localhost","port":5432}}'
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.host
+  targets:
+  - select:
+      name: app-config
+    fieldPaths:
+    - data.config\.json
+    innerFieldPath: database.host
+    options:
+      format: json
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  config.json: '{"database":{"host":"db.prod.svc","port":5432}}'
+`,
+		},
+		"inner json keeps the order of fields": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  config.json: |
+    {
+      "name": "app",
+      "database": {
+        "port": 5432,
+        "host": "localhost",
+        "enabled": true
+      }
+    }
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.host
+  targets:
+  - select:
+      name: app-config
+    fieldPaths:
+    - data.config\.json
+    innerFieldPath: database.host
+    options:
+      format: json
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  config.json: |
+    {
+      "name": "app",
+      "database": {
+        "port": 5432,
+        "host": "db.prod.svc",
+        "enabled": true
+      }
+    }
+`,
+		},
+		"inner properties": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  app.properties: |
+    # database
+    db.host = localhost
+    db.port=5432
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.host
+  targets:
+  - select:
+      name: app-config
+    fieldPaths:
+    - data.app\.properties
+    innerFieldPath: db.host
+    options:
+      format: properties
+  - select:
+      name: app-config
+    fieldPaths:
+    - data.app\.properties
+    innerFieldPath: db.replica
+    options:
+      format: properties
+      create: true
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  host: db.prod.svc
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  app.properties: |
+    # database
+    db.host = db.prod.svc
+    db.port=5432
+    db.replica=db.prod.svc
+`,
+		},
+		"create list entries from templates": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  image: proxy:1.2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.image
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=proxy].image
+    options:
+      create: true
+      template:
+        ports:
+        - containerPort: 8080
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=app].env.[name=PROXY_IMAGE].value
+    options:
+      create: true
+      template:
+        name: ignored
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: metadata.name
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=app].env.[name=PROXY_CONFIG].valueFrom.configMapKeyRef.name
+    options:
+      create: true
+      template:
+        valueFrom:
+          configMapKeyRef:
+            key: image
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  image: proxy:1.2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+        env:
+        - name: PROXY_IMAGE
+          value: proxy:1.2
+        - name: PROXY_CONFIG
+          valueFrom:
+            configMapKeyRef:
+              key: image
+              name: source
+      - name: proxy
+        ports:
+        - containerPort: 8080
+        image: proxy:1.2
+`,
+		},
+		"list entries selected by key and glob": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  image: app:2.0
+  config: app-config-v2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+      - name: sidecar
+        image: sidecar:1.0
+      - name: app
+        image: app:1.0
+      volumes:
+      - name: main-config
+        configMap:
+          name: app-config
+      - name: data
+        emptyDir: {}
+      - name: configfiles
+        configMap:
+          name: app-config
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.image
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=app].image
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.config
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.volumes.[name=*config*].configMap.name
+    options:
+      create: true
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  image: app:2.0
+  config: app-config-v2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:2.0
+      - name: sidecar
+        image: sidecar:1.0
+      - name: app
+        image: app:2.0
+      volumes:
+      - name: main-config
+        configMap:
+          name: app-config-v2
+      - name: data
+        emptyDir: {}
+      - name: configfiles
+        configMap:
+          name: app-config-v2
+`,
+		},
+		"glob selects no list entries": {
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+`,
+			replacements: `replacements:
+- source:
+    kind: Deployment
+    fieldPath: metadata.name
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=side*].image
+    options:
+      create: true
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+`,
+		},
+		"template with glob": {
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`,
+			replacements: `replacements:
+- source:
+    kind: Deployment
+    fieldPath: metadata.name
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=side*].image
+    options:
+      create: true
+      template:
+        a: b
+`,
+			expectedErr: "options.template can't create the list entry [name=side*], selected by a pattern",
+		},
+		"template without list entry": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    fieldPath: metadata.name
+  targets:
+  - select:
+      kind: ConfigMap
+    fieldPaths:
+    - data.name
+    options:
+      create: true
+      template:
+        a: b
+`,
+			expectedErr: "options.template requires a field path selecting a list entry, e.g. env.[name=FOO].value",
+		},
+		"inner field path without format": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  config.yaml: |
+    host: localhost
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: app-config
+    fieldPath: metadata.name
+  targets:
+  - select:
+      name: app-config
+    fieldPaths:
+    - data.config\.yaml
+    innerFieldPath: host
+`,
+			expectedErr: "innerFieldPath requires options.format",
+		},
+		"aggregate join": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    options:
+      aggregate: join
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: web,api,admin
+`,
+		},
+		"aggregate join with delimiter": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    options:
+      aggregate: join
+      delimiter: ' '
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: web api admin
+`,
+		},
+		"aggregate max, skipping resources without the field": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    fieldPath: spec.ports.0.port
+    options:
+      aggregate: max
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: 9090
+`,
+		},
+		"aggregate min": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    fieldPath: spec.ports.0.port
+    options:
+      aggregate: min
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: 8080
+`,
+		},
+		"aggregate of non-scalar fields": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    fieldPath: spec.ports
+    options:
+      aggregate: count
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+`,
+			expectedErr: "options.aggregate can only be used with scalar fields, but spec.ports of web isn't one",
+		},
+		"aggregate count of names": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    options:
+      aggregate: count
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: 3
+`,
+		},
+		"aggregate max of strings": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    options:
+      aggregate: max
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+`,
+			expectedErr: `options.aggregate max requires numbers, but metadata.name of source ~G_~V_Service has "web"`,
+		},
+		"aggregate in target": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 9090
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: admin
+---
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  upstreams: none
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    name: web
+  targets:
+  - select:
+      kind: Gateway
+    fieldPaths:
+    - spec.upstreams
+    options:
+      aggregate: join
+`,
+			expectedErr: "options.aggregate only applies to sources",
+		},
+		"token in field": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  url: jdbc:postgresql://DB_HOST:5432/app?fallback=DB_HOST
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    name: db
+  targets:
+  - select:
+      kind: ConfigMap
+    fieldPaths:
+    - data.url
+    options:
+      token: DB_HOST
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  url: jdbc:postgresql://db:5432/app?fallback=db
+`,
+		},
+		"token in replacement files": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  annotations:
+    config.kubernetes.io/replacementFiles: app.conf
+data:
+  app.conf: |
+    host ${DB_HOST}
+  other.conf: |
+    host ${DB_HOST}
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    name: db
+  targets:
+  - select:
+      kind: ConfigMap
+    options:
+      token: ${DB_HOST}
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  annotations:
+    config.kubernetes.io/replacementFiles: app.conf
+data:
+  app.conf: |
+    host db
+  other.conf: |
+    host ${DB_HOST}
+`,
+		},
+		"token with delimiter": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  url: DB_HOST
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: app
+  targets:
+  - select:
+      kind: ConfigMap
+    fieldPaths:
+    - data.url
+    options:
+      token: DB_HOST
+      delimiter: ':'
+`,
+			expectedErr: "options.token and options.delimiter can't be used together",
+		},
+		"keep target comments and style": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  port: '8080' # the source's port
+  hosts: [a, b]
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  # The port the app listens on.
+  port: "80" # default
+  hosts:
+  - localhost
+  image: app:v1 # pinned
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.port
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - data.port
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.hosts
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - data.hosts
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.port
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - data.image
+    options:
+      delimiter: ':'
+      index: 1
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  port: '8080' # the source's port
+  hosts: [a, b]
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  # The port the app listens on.
+  port: "8080" # default
+  hosts:
+  - a
+  - b
+  image: app:8080 # pinned
+`,
+		},
+		"keep the tag of the source": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  port: 7070
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        ports:
+        - containerPort: "PORT" # set by replacements
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.port
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - spec.template.spec.containers.0.ports.0.containerPort
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  port: 7070
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        ports:
+        - containerPort: 7070 # set by replacements
+`,
+		},
+		"source style": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  port: '8080' # the source's port
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  port: "80" # default
+`,
+			replacements: `replacements:
+- source:
+    kind: ConfigMap
+    name: source
+    fieldPath: data.port
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - data.port
+    options:
+      sourceStyle: true
+`,
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: source
+data:
+  port: '8080' # the source's port
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  port: '8080' # the source's port
+`,
+		},
+		"source by label selector": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: staging-db
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: staging-cache
+  labels:
+    role: cache
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  dbHost: DB
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    labelSelector: role=db
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - data.dbHost
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: staging-db
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: staging-cache
+  labels:
+    role: cache
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  dbHost: staging-db
+`,
+		},
+		"source by bad label selector": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    labelSelector: role==db==
+  targets:
+  - select:
+      kind: Service
+`,
+			expectedErr: "the labelSelector of source ~G_~V_Service: " +
+				"found '==', expected: ',' or 'end of string'",
+		},
+		"multiple matches of source, first": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: db-a
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db-b
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  dbHost: DB
+`,
+			replacements: `replacements:
+- source:
+    labelSelector: role=db
+    options:
+      onMultipleMatches: first
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - data.dbHost
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: db-a
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db-b
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  dbHost: db-a
+`,
+		},
+		"multiple matches of source, latest": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: db-a
+  labels:
+    role: db
+  annotations:
+    config.kubernetes.io/creationTimestamp: "2021-06-01T10:00:00Z"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db-b
+  labels:
+    role: db
+  annotations:
+    config.kubernetes.io/creationTimestamp: "2021-06-01T12:00:00+02:00"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db-c
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  dbHost: DB
+`,
+			// db-b is of the same time as db-a, and later.
+			replacements: `replacements:
+- source:
+    labelSelector: role=db
+    options:
+      onMultipleMatches: latest
+  targets:
+  - select:
+      name: app
+    fieldPaths:
+    - data.dbHost
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: db-a
+  labels:
+    role: db
+  annotations:
+    config.kubernetes.io/creationTimestamp: "2021-06-01T10:00:00Z"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db-b
+  labels:
+    role: db
+  annotations:
+    config.kubernetes.io/creationTimestamp: "2021-06-01T12:00:00+02:00"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db-c
+  labels:
+    role: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  dbHost: db-b
+`,
+		},
+		"multiple matches of source, bad creation timestamp": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: db-a
+  annotations:
+    config.kubernetes.io/creationTimestamp: yesterday
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db-b
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    options:
+      onMultipleMatches: latest
+  targets:
+  - select:
+      name: db-b
+`,
+			expectedErr: "the config.kubernetes.io/creationTimestamp annotation of db-a: " +
+				`parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": ` +
+				`cannot parse "yesterday" as "2006"`,
+		},
+		"multiple matches of source, unknown policy": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+`,
+			replacements: `replacements:
+- source:
+    kind: Service
+    options:
+      onMultipleMatches: any
+  targets:
+  - select:
+      name: db
+`,
+			expectedErr: `unknown options.onMultipleMatches "any", expected error, first or latest`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			f := Filter{}
+			err := yaml.Unmarshal([]byte(tc.replacements), &f)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			actual, err := filtertest.RunFilterE(t, tc.input, f)
+			if err != nil {
+				if tc.expectedErr == "" {
+					t.Errorf("unexpected error: %s\n", err.Error())
+					t.FailNow()
+				}
+				if !assert.Equal(t, tc.expectedErr, err.Error()) {
+					t.FailNow()
+				}
+			}
+			if !assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual)) {
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestFilterOnCreate(t *testing.T) {
+	f := Filter{}
+	err := yaml.Unmarshal([]byte(`
+replacements:
+- source:
+    kind: ConfigMap
+    fieldPath: data.image
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=app].image
+    - spec.template.spec.containers.[name=sidecar].image
+    - spec.template.spec.containers.[name=*proxy*].image
+    - spec.template.spec.containres.[name=app].image
+    options:
+      create: true
+`), &f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var created []string
+	f.OnCreate = func(id resid.ResId, fieldPath string) {
+		created = append(created, id.String()+" "+fieldPath)
+	}
+	_, err = filtertest.RunFilterE(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  image: app:2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1
+`, f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
 	}
-
-	for tn, tc := range testCases {
-		t.Run(tn, func(t *testing.T) {
-			f := Filter{}
-			err := yaml.Unmarshal([]byte(tc.replacements), &f)
-			if !assert.NoError(t, err) {
-				t.FailNow()
-			}
-			actual, err := filtertest.RunFilterE(t, tc.input, f)
-			if err != nil {
-				if tc.expectedErr == "" {
-					t.Errorf("unexpected error: %s\n", err.Error())
-					t.FailNow()
-				}
-				if !assert.Equal(t, tc.expectedErr, err.Error()) {
-					t.FailNow()
-				}
-			}
-			if !assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual)) {
-				t.FailNow()
-			}
-		})
-	}
+	// Nothing is created for the glob, so only the
+	// sidecar and the misspelled containers are.
+	assert.Equal(t, []string{
+		"apps_v1_Deployment|~X|web spec.template.spec.containers.[name=sidecar].image",
+		"apps_v1_Deployment|~X|web spec.template.spec.containres.[name=app].image",
+	}, created)
 }
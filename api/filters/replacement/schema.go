@@ -0,0 +1,182 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package replacement
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Schema is the JSON schema of replacement files, the
+// files listed by path in the replacements of kustomizations.
+//
+//go:embed schema.json
+var Schema []byte
+
+// Unmarshal returns the replacement in the content of a
+// replacement file, after validating it against Schema.  The
+// errors of the validation name the offending field, and are
+// yaml.PositionErrors, at its line.
+func Unmarshal(content []byte) (types.Replacement, error) {
+	var r types.Replacement
+	node, err := yaml.Parse(string(content))
+	if err != nil {
+		return r, err
+	}
+	s, err := parsedSchema()
+	if err != nil {
+		return r, err
+	}
+	if err = s.validate(s, node.YNode(), ""); err != nil {
+		return r, err
+	}
+	err = yaml.Unmarshal(content, &r)
+	return r, err
+}
+
+// schema is the subset of JSON schema that Schema uses.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Enum                 []string           `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Definitions          map[string]*schema `json:"definitions"`
+}
+
+func parsedSchema() (*schema, error) {
+	s := &schema{}
+	if err := json.Unmarshal(Schema, s); err != nil {
+		return nil, fmt.Errorf("the replacement schema: %v", err)
+	}
+	return s, nil
+}
+
+// nodeTypes are the JSON schema types of the
+// YAML tags of scalars.
+var nodeTypes = map[string]string{
+	yaml.NodeTagString: "string",
+	yaml.NodeTagInt:    "integer",
+	yaml.NodeTagFloat:  "number",
+	yaml.NodeTagBool:   "boolean",
+	yaml.NodeTagNull:   "null",
+}
+
+// validate validates n, at path, against s, resolving
+// references in the definitions of root.
+func (s *schema) validate(root *schema, n *yaml.Node, path string) error {
+	if s.Ref != "" {
+		d, ok := root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+		if !ok {
+			return fmt.Errorf("the replacement schema has no %s", s.Ref)
+		}
+		return d.validate(root, n, path)
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	nodeType := nodeTypes[n.Tag]
+	switch n.Kind {
+	case yaml.MappingNode:
+		nodeType = "object"
+	case yaml.SequenceNode:
+		nodeType = "array"
+	}
+	if nodeType == "null" || s.Type == "" {
+		return nil
+	}
+	if nodeType != s.Type && !(s.Type == "number" && nodeType == "integer") {
+		return errorAt(n, path, "must be %s, not %s", article(s.Type), article(nodeType))
+	}
+	if len(s.Enum) > 0 && !containsString(s.Enum, n.Value) {
+		return errorAt(n, path, "must be one of %s, not %q",
+			strings.Join(s.Enum, ", "), n.Value)
+	}
+	switch nodeType {
+	case "object":
+		return s.validateFields(root, n, path)
+	case "array":
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range n.Content {
+			if err := s.Items.validate(
+				root, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *schema) validateFields(root *schema, n *yaml.Node, path string) error {
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		present[key.Value] = true
+		fieldPath := key.Value
+		if path != "" {
+			fieldPath = path + "." + key.Value
+		}
+		p, ok := s.Properties[key.Value]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return errorAt(key, path, "unknown field %q, expected one of %s",
+					key.Value, strings.Join(s.propertyNames(), ", "))
+			}
+			continue
+		}
+		if err := p.validate(root, value, fieldPath); err != nil {
+			return err
+		}
+	}
+	for _, r := range s.Required {
+		if !present[r] {
+			return errorAt(n, path, "missing required field %q", r)
+		}
+	}
+	return nil
+}
+
+func (s *schema) propertyNames() []string {
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func errorAt(n *yaml.Node, path string, format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	if path != "" {
+		err = fmt.Errorf("%s: %v", path, err)
+	}
+	return yaml.ErrorAt(yaml.NewRNode(n), err)
+}
+
+func article(schemaType string) string {
+	switch schemaType {
+	case "integer", "object", "array":
+		return "an " + schemaType
+	}
+	return "a " + schemaType
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
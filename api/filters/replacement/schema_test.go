@@ -0,0 +1,158 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package replacement
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestUnmarshal(t *testing.T) {
+	testCases := map[string]struct {
+		content     string
+		expectedErr string
+	}{
+		"valid": {
+			content: `source:
+  kind: ConfigMap
+  name: settings
+  fieldPath: data.host
+targets:
+- select:
+    kind: Deployment
+    labelSelector: app=web
+  reject:
+  - name: legacy
+  fieldPaths:
+  - spec.template.spec.containers.[name=web].env.[name=HOST].value
+  options:
+    create: true
+    template:
+      name: HOST
+`,
+		},
+		"unknown field": {
+			content: `source:
+  kind: ConfigMap
+  fieldpath: data.host
+targets: []
+`,
+			expectedErr: `3: source: unknown field "fieldpath", expected one of ` +
+				`fieldPath, group, kind, labelSelector, name, namespace, options, version`,
+		},
+		"wrong type of index": {
+			content: `source:
+  kind: ConfigMap
+targets:
+- select:
+    kind: Deployment
+  options:
+    delimiter: ':'
+    index: "1"
+`,
+			expectedErr: "8: targets[0].options.index: must be an integer, not a string",
+		},
+		"list of field paths": {
+			content: `source:
+  kind: ConfigMap
+targets:
+- select:
+    kind: Deployment
+  fieldPaths: spec.replicas
+`,
+			expectedErr: "6: targets[0].fieldPaths: must be an array, not a string",
+		},
+		"unknown format": {
+			content: `source:
+  kind: ConfigMap
+targets:
+- select:
+    kind: ConfigMap
+  innerFieldPath: a.b
+  options:
+    format: toml
+`,
+			expectedErr: `8: targets[0].options.format: must be one of yaml, json, properties, not "toml"`,
+		},
+		"no select": {
+			content: `source:
+  kind: ConfigMap
+targets:
+- fieldPaths: [spec.replicas]
+`,
+			expectedErr: `4: targets[0]: missing required field "select"`,
+		},
+		"no targets": {
+			content: `source:
+  kind: ConfigMap
+`,
+			expectedErr: `1: missing required field "targets"`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tc.content))
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if !assert.Error(t, err) {
+				return
+			}
+			// Without a file, the errors read as those they wrap;
+			// the line is checked separately.
+			parts := strings.SplitN(tc.expectedErr, ": ", 2)
+			assert.Equal(t, parts[1], err.Error())
+			pe, found := yaml.AsPositionError(err)
+			if assert.True(t, found) {
+				assert.Equal(t, parts[0], strconv.Itoa(pe.Line))
+			}
+		})
+	}
+}
+
+// TestSchemaProperties checks that the schema has the
+// fields of the types, so that they don't drift apart.
+func TestSchemaProperties(t *testing.T) {
+	s, err := parsedSchema()
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, tc := range []struct {
+		schema *schema
+		value  interface{}
+	}{
+		{s, types.Replacement{}},
+		{s.Definitions["source"], types.SourceSelector{}},
+		{s.Definitions["target"], types.TargetSelector{}},
+		{s.Definitions["selector"], types.Selector{}},
+		{s.Definitions["options"], types.FieldOptions{}},
+	} {
+		assert.Equal(t, jsonFields(reflect.TypeOf(tc.value)), tc.schema.propertyNames(),
+			reflect.TypeOf(tc.value).Name())
+	}
+}
+
+// jsonFields returns the sorted JSON field names of
+// the struct type, including those of embedded structs.
+func jsonFields(st reflect.Type) []string {
+	var names []string
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if f.Anonymous {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		names = append(names, strings.Split(f.Tag.Get("json"), ",")[0])
+	}
+	sort.Strings(names)
+	return names
+}
@@ -38,6 +38,15 @@ type Loader interface {
 	Cleanup() error
 }
 
+// Globber is implemented by the Loaders that can list
+// what they load, e.g. those reading a file system, so
+// that a kustomization may select files by globs.
+type Globber interface {
+	// Glob returns the lexically sorted, loadable paths
+	// matching the given pattern, relative to Root.
+	Glob(pattern string) ([]string, error)
+}
+
 // KustHasher returns a hash of the argument
 // or an error.
 type KustHasher interface {
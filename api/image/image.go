@@ -48,3 +48,42 @@ func Split(imageName string) (name string, tag string) {
 	tag = imageName[i:]
 	return
 }
+
+// MirrorRegistry returns the image with its registry replaced by
+// the mirror the mirrors map it to, or the image itself if they
+// map it to none.  The keys of mirrors are registries, e.g.
+// docker.io, or registries with a path, e.g. gcr.io/my-project,
+// the longest of which matching the image is used.  As by
+// docker, images without a registry are taken to be from
+// docker.io, and those without a path from its library.
+func MirrorRegistry(imageName string, mirrors map[string]string) string {
+	name, tag := Split(imageName)
+	if name == "" {
+		return imageName
+	}
+	name = fullName(name)
+	var match, mirror string
+	for from, to := range mirrors {
+		from = strings.TrimSuffix(from, "/")
+		if (name == from || strings.HasPrefix(name, from+"/")) && len(from) > len(match) {
+			match, mirror = from, strings.TrimSuffix(to, "/")
+		}
+	}
+	if match == "" {
+		return imageName
+	}
+	return mirror + strings.TrimPrefix(name, match) + tag
+}
+
+// fullName returns the name of an image with its registry, as
+// docker takes it, e.g. docker.io/library/nginx for nginx.
+func fullName(name string) string {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return "docker.io/library/" + name
+	}
+	if first := name[:i]; strings.ContainsAny(first, ".:") || first == "localhost" {
+		return name
+	}
+	return "docker.io/" + name
+}
@@ -78,3 +78,33 @@ func TestSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestMirrorRegistry(t *testing.T) {
+	mirrors := map[string]string{
+		"docker.io":           "mirror.example.com/dockerhub",
+		"gcr.io/":             "mirror.example.com/gcr/",
+		"gcr.io/project-a":    "mirror.example.com/project-a",
+		"localhost:5000/apps": "mirror.example.com/local",
+	}
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"nginx", "mirror.example.com/dockerhub/library/nginx"},
+		{"nginx:1.2.3", "mirror.example.com/dockerhub/library/nginx:1.2.3"},
+		{"bitnami/redis@sha256:12345", "mirror.example.com/dockerhub/bitnami/redis@sha256:12345"},
+		{"docker.io/library/nginx", "mirror.example.com/dockerhub/library/nginx"},
+		{"gcr.io/project-b/app:v1", "mirror.example.com/gcr/project-b/app:v1"},
+		{"gcr.io/project-a/app:v1", "mirror.example.com/project-a/app:v1"},
+		{"gcr.io/project-ab/app", "mirror.example.com/gcr/project-ab/app"},
+		{"localhost:5000/apps/app", "mirror.example.com/local/app"},
+		{"localhost:5000/other/app", "localhost:5000/other/app"},
+		{"quay.io/app:v1", "quay.io/app:v1"},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MirrorRegistry(tc.value, mirrors))
+		})
+	}
+}
@@ -69,8 +69,13 @@ func makeConfigFromApiMap(m nameToApiMap) (*builtinconfig.TransformerConfig, err
 			continue
 		}
 		tc := builtinconfig.MakeEmptyConfig()
+		gvk := makeGvkFromTypeName(name)
+		if scope, ok := api.Schema.Extensions.GetString(xScope); ok &&
+			scope == clusterScope {
+			tc.AddClusterScopedKind(gvk)
+		}
 		err := loadCrdIntoConfig(
-			tc, makeGvkFromTypeName(name), m, name, []string{})
+			tc, gvk, m, name, []string{})
 		if err != nil {
 			return result, err
 		}
@@ -105,6 +110,12 @@ func looksLikeAk8sType(properties myProperties) bool {
 }
 
 const (
+	// "x-kubernetes-scope": "Cluster"
+	// on the definition of a type, as in the scope
+	// field of a CustomResourceDefinition.
+	xScope       = "x-kubernetes-scope"
+	clusterScope = "Cluster"
+
 	// "x-kubernetes-annotation": ""
 	xAnnotation = "x-kubernetes-annotation"
 
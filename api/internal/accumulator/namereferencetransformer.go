@@ -6,15 +6,24 @@ package accumulator
 import (
 	"fmt"
 	"log"
+	"runtime"
+	"sync"
 
 	"sigs.k8s.io/kustomize/api/filters/nameref"
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinconfig"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 type nameReferenceTransformer struct {
 	backRefs []builtinconfig.NameBackReferences
+
+	// clusterScopedReferralNamespace is that of the filters
+	// (see nameref.Filter.ClusterScopedReferralNamespace).
+	clusterScopedReferralNamespace string
 }
 
 const doDebug = false
@@ -50,19 +59,138 @@ func newNameReferenceTransformer(
 func (t *nameReferenceTransformer) Transform(m resmap.ResMap) error {
 	fMap := t.determineFilters(m.Resources())
 	debug(fMap)
-	for r, fList := range fMap {
-		c := m.SubsetThatCouldBeReferencedByResource(r)
-		for _, f := range fList {
-			f.Referrer = r
-			f.ReferralCandidates = c
-			if err := f.Referrer.ApplyFilter(f); err != nil {
-				return err
-			}
+	subsets := makeSubsetCache(m)
+	var updates []*referrerUpdate
+	for _, r := range m.Resources() {
+		if fList, ok := fMap[r]; ok {
+			updates = append(updates, &referrerUpdate{
+				referrer:   r,
+				filters:    fList,
+				candidates: subsets.couldBeReferencedBy(r),
+			})
+		}
+	}
+	runUpdates(updates)
+	for _, u := range updates {
+		if err := u.apply(); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// referrerUpdate updates the name references of a referrer.
+//
+// The filters run on a copy of the referrer's node, and the
+// referrals found are held rather than recorded, so that the
+// updates of all the referrers may run at once, reading the
+// resources of the ResMap but not changing them.  Then the
+// updates are applied, one after the other, in ResMap order.
+type referrerUpdate struct {
+	referrer   *resource.Resource
+	filters    []nameref.Filter
+	candidates resmap.ResMap
+
+	node      *yaml.RNode
+	referrals []*resource.Resource
+	err       error
+}
+
+func (u *referrerUpdate) run() {
+	u.node = u.referrer.AsRNode()
+	for _, f := range u.filters {
+		f.Referrer = u.referrer
+		f.ReferralCandidates = u.candidates
+		f.RecordReferral = func(referral *resource.Resource) {
+			u.referrals = append(u.referrals, referral)
+		}
+		if _, u.err = f.Filter([]*yaml.RNode{u.node}); u.err != nil {
+			return
+		}
+	}
+}
+
+func (u *referrerUpdate) apply() error {
+	if len(u.referrals) == 0 && u.err == nil {
+		// Names are only updated from referrals.
+		return nil
+	}
+	for _, referral := range u.referrals {
+		referral.AppendRefBy(u.referrer.CurId())
+	}
+	return u.referrer.ApplyFilter(kio.FilterFunc(
+		func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+			if u.err != nil {
+				return nodes, u.err
+			}
+			nodes[0].SetYNode(u.node.YNode())
+			return nodes, nil
+		}))
+}
+
+// runUpdates runs the updates in as many goroutines as
+// there are CPUs to run them.
+func runUpdates(updates []*referrerUpdate) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(updates) {
+		workers = len(updates)
+	}
+	if workers <= 1 {
+		for _, u := range updates {
+			u.run()
+		}
+		return
+	}
+	next := make(chan *referrerUpdate)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range next {
+				u.run()
+			}
+		}()
+	}
+	for _, u := range updates {
+		next <- u
+	}
+	close(next)
+	wg.Wait()
+}
+
+// subsetCache caches the subsets of a ResMap that could be
+// referenced by resources, which are the same for all the
+// resources in a namespace (but RoleBindings), so that they,
+// and the indexes of them, needn't be rebuilt per resource.
+type subsetCache struct {
+	m         resmap.ResMap
+	idChanges uint64
+	subsets   map[string]resmap.ResMap
+}
+
+func makeSubsetCache(m resmap.ResMap) *subsetCache {
+	return &subsetCache{m: m}
+}
+
+func (c *subsetCache) couldBeReferencedBy(r *resource.Resource) resmap.ResMap {
+	if r.GetKind() == "RoleBinding" {
+		return c.m.SubsetThatCouldBeReferencedByResource(r)
+	}
+	if c.subsets == nil || c.idChanges != resource.IdChanges() {
+		// Resources may have moved between namespaces.
+		c.idChanges = resource.IdChanges()
+		c.subsets = make(map[string]resmap.ResMap)
+	}
+	ns := r.CurId().EffectiveNamespace()
+	subset, ok := c.subsets[ns]
+	if !ok {
+		subset = c.m.SubsetThatCouldBeReferencedByResource(r)
+		c.subsets[ns] = subset
+	}
+	return subset
+}
+
 func debug(fMap filterMap) {
 	if !doDebug {
 		return
@@ -107,15 +235,23 @@ func debug(fMap filterMap) {
 // This means that a filter will need to hunt for the right Deployment,
 // obtain it's new name, and write that name into the HPA's
 // 'spec/scaleTargetRef/name' field. Return a filter that can do that.
+//
+// The referrer specs are matched against the kinds present in the
+// build, rather than against each resource, so that the many specs
+// of kinds absent from the build, e.g. those of CRDs, cost little.
 func (t *nameReferenceTransformer) determineFilters(
 	resources []*resource.Resource) (fMap filterMap) {
 	fMap = make(filterMap)
+	kinds, byKind := groupByOriginalGvk(resources)
 	for _, backReference := range t.backRefs {
 		for _, referrerSpec := range backReference.Referrers {
-			for _, res := range resources {
-				if res.OrgId().IsSelected(&referrerSpec.Gvk) {
-					// If this is true, the res might be a referrer, and if
-					// so, the name reference it holds might need an update.
+			for _, gvk := range kinds {
+				if !gvk.IsSelected(&referrerSpec.Gvk) {
+					continue
+				}
+				// The resources of this kind might be referrers, and if
+				// so, the name reference they hold might need an update.
+				for _, res := range byKind[gvk] {
 					if resHasField(res, referrerSpec.Path) {
 						// Optimization - the referrer has the field
 						// that might need updating.
@@ -128,6 +264,8 @@ func (t *nameReferenceTransformer) determineFilters(
 							// Specification of object class to read from.
 							// Always read from metadata/name field.
 							ReferralTarget: backReference.Gvk,
+
+							ClusterScopedReferralNamespace: t.clusterScopedReferralNamespace,
 						})
 					}
 				}
@@ -137,6 +275,22 @@ func (t *nameReferenceTransformer) determineFilters(
 	return fMap
 }
 
+// groupByOriginalGvk returns the Gvks of the original ids of the
+// resources, in the order they're first seen, and the resources
+// having each.
+func groupByOriginalGvk(resources []*resource.Resource) (
+	kinds []resid.Gvk, byKind map[resid.Gvk][]*resource.Resource) {
+	byKind = make(map[resid.Gvk][]*resource.Resource)
+	for _, res := range resources {
+		gvk := res.OrgId().Gvk
+		if _, ok := byKind[gvk]; !ok {
+			kinds = append(kinds, gvk)
+		}
+		byKind[gvk] = append(byKind[gvk], res)
+	}
+	return kinds, byKind
+}
+
 // TODO: check res for field existence here to avoid extra work.
 // res.GetFieldValue, which uses yaml.Lookup under the hood, doesn't know
 // how to parse fieldspec-style paths that make no distinction
@@ -148,7 +302,7 @@ func (t *nameReferenceTransformer) determineFilters(
 // extract that code and call it here?
 func resHasField(res *resource.Resource, path string) bool {
 	return true
-	// fld := strings.Join(utils.PathSplitter(path), ".")
+	// fld := strings.Join(yaml.SplitLegacyFieldPath(path), ".")
 	// _, e := res.GetFieldValue(fld)
 	// return e == nil
 }
@@ -4,6 +4,8 @@
 package accumulator
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -1066,3 +1068,89 @@ func TestNameReferenceCandidateSelection(t *testing.T) {
 		t.Fatalf(notEqualErrFmt, err)
 	}
 }
+
+func TestNameReferenceRefByOrder(t *testing.T) {
+	b := resmaptest_test.NewRmBuilderDefault(t).
+		AddWithName("cm1", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "p1-cm1-hash",
+			}})
+	var expected []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("deploy%d", i)
+		b.Add(deploymentMap("", name, "cm1", "secret1"))
+		expected = append(expected, "~G_v1_Deployment|~X|"+name)
+	}
+	m := b.ResMap()
+
+	nrt := newNameReferenceTransformer(builtinconfig.MakeDefaultConfig().NameReference)
+	if err := nrt.Transform(m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var refBy []string
+	for _, id := range m.GetByIndex(0).GetRefBy() {
+		refBy = append(refBy, id.String())
+	}
+	if !reflect.DeepEqual(refBy, expected) {
+		t.Fatalf("expected referrers %v, got %v", expected, refBy)
+	}
+}
+
+// BenchmarkNameReferenceTransformer transforms a build of many
+// custom resources, as CRD-heavy builds are, along with
+// Deployments referring to renamed ConfigMaps.
+func BenchmarkNameReferenceTransformer(b *testing.B) {
+	const n = 500
+	var yml strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&yml, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm%[1]d
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deploy%[1]d
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        envFrom:
+        - configMapRef:
+            name: cm%[1]d
+---
+apiVersion: example.com/v1
+kind: Widget%[2]d
+metadata:
+  name: widget%[1]d
+spec:
+  configMapName: cm%[1]d
+`, i, i%20)
+	}
+	rmF := resmap.NewFactory(provider.NewDefaultDepProvider().GetResourceFactory())
+	nrt := newNameReferenceTransformer(builtinconfig.MakeDefaultConfig().NameReference)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m, err := rmF.NewResMapFromBytes([]byte(yml.String()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, r := range m.Resources() {
+			if r.GetKind() == "ConfigMap" {
+				r.StorePreviousId()
+				r.SetName("p-" + r.GetName())
+			}
+		}
+		b.StartTimer()
+		if err = nrt.Transform(m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
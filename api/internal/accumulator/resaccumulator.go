@@ -9,8 +9,10 @@ import (
 	"strings"
 
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinconfig"
+	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
 	"sigs.k8s.io/kustomize/api/types"
 )
 
@@ -21,6 +23,15 @@ type ResAccumulator struct {
 	resMap  resmap.ResMap
 	tConfig *builtinconfig.TransformerConfig
 	varSet  types.VarSet
+
+	// warnOnFrozenChanges makes changes to frozen resources
+	// reverted, with a warning, rather than errors.
+	warnOnFrozenChanges bool
+
+	// clusterScopedReferralNamespace, if set, is the namespace
+	// the referrals of cluster-scoped referrers must be in,
+	// unless their references name one.
+	clusterScopedReferralNamespace string
 }
 
 func MakeEmptyAccumulator() *ResAccumulator {
@@ -49,6 +60,17 @@ func (ra *ResAccumulator) AbsorbAll(resources resmap.ResMap) error {
 	return ra.resMap.AbsorbAll(resources)
 }
 
+// Append adds the resource to the accumulated ones.
+func (ra *ResAccumulator) Append(r *resource.Resource) error {
+	return ra.resMap.Append(r)
+}
+
+// Remove removes the resource of the current id
+// from the accumulated ones.
+func (ra *ResAccumulator) Remove(id resid.ResId) error {
+	return ra.resMap.Remove(id)
+}
+
 func (ra *ResAccumulator) MergeConfig(
 	tConfig *builtinconfig.TransformerConfig) (err error) {
 	ra.tConfig, err = ra.tConfig.Merge(tConfig)
@@ -59,6 +81,11 @@ func (ra *ResAccumulator) GetTransformerConfig() *builtinconfig.TransformerConfi
 	return ra.tConfig
 }
 
+// SetTransformerConfig replaces the accumulated transformer config.
+func (ra *ResAccumulator) SetTransformerConfig(tConfig *builtinconfig.TransformerConfig) {
+	ra.tConfig = tConfig
+}
+
 // MergeVars accumulates vars into ResAccumulator.
 // A Var is a tuple of name, object reference and field reference.
 // This func takes a list of vars from the current kustomization file and
@@ -103,6 +130,43 @@ func (ra *ResAccumulator) MergeAccumulator(other *ResAccumulator) (err error) {
 	return ra.varSet.MergeSet(other.varSet)
 }
 
+// MergeAccumulatorDeduplicating merges other into ra, as
+// MergeAccumulator does, but leaves out the resources already
+// in ra with the same content, e.g. those of a base reached
+// through two others.  Resources with the same ids but other
+// content are still an error.  It returns the resources left
+// out, mapped to those of ra they duplicate.
+func (ra *ResAccumulator) MergeAccumulatorDeduplicating(
+	other *ResAccumulator) (map[*resource.Resource]*resource.Resource, error) {
+	dropped := make(map[*resource.Resource]*resource.Resource)
+	for _, r := range other.resMap.Resources() {
+		existing, err := ra.resMap.GetByCurrentId(r.CurId())
+		if err != nil {
+			if err = ra.resMap.Append(r); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		same, err := existing.ContentEquals(r)
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			return nil, fmt.Errorf(
+				"may not add resource with an already registered id: %s; "+
+					"its content differs from that of the one added", r.CurId())
+		}
+		for _, id := range r.GetRefBy() {
+			existing.AppendRefBy(id)
+		}
+		dropped[r] = existing
+	}
+	if err := ra.MergeConfig(other.tConfig); err != nil {
+		return nil, err
+	}
+	return dropped, ra.varSet.MergeSet(other.varSet)
+}
+
 func (ra *ResAccumulator) findVarValueFromResources(v types.Var) (interface{}, error) {
 	for _, res := range ra.resMap.Resources() {
 		for _, varName := range res.GetRefVarNames() {
@@ -137,8 +201,100 @@ func (ra *ResAccumulator) makeVarReplacementMap() (map[string]interface{}, error
 	return result, nil
 }
 
+// SetWarnOnFrozenChanges sets whether a transformation changing a
+// resource annotated as frozen is an error (the default) or, if warn
+// is true, only logged, with the change reverted.
+func (ra *ResAccumulator) SetWarnOnFrozenChanges(warn bool) {
+	ra.warnOnFrozenChanges = warn
+}
+
+// SetClusterScopedReferralNamespace sets the namespace the
+// referrals of cluster-scoped referrers, e.g. the service accounts
+// of the subjects of ClusterRoleBindings, must be in when their
+// references name none, for FixBackReferences; if empty, the
+// default, they may be in any.
+func (ra *ResAccumulator) SetClusterScopedReferralNamespace(ns string) {
+	ra.clusterScopedReferralNamespace = ns
+}
+
+// Transform applies t to the accumulated resources, other than
+// the frozen ones, which it must leave as is, and, unless it
+// handles them, the raw documents (see resmap.TransformResources).
 func (ra *ResAccumulator) Transform(t resmap.Transformer) error {
-	return t.Transform(ra.resMap)
+	frozen, err := ra.snapshotFrozen()
+	if err != nil {
+		return err
+	}
+	if err = resmap.TransformResources(ra.resMap, t); err != nil {
+		return err
+	}
+	return ra.checkFrozen(frozen)
+}
+
+type frozenResource struct {
+	res      *resource.Resource
+	original *resource.Resource
+	content  string
+}
+
+func (ra *ResAccumulator) snapshotFrozen() ([]frozenResource, error) {
+	var result []frozenResource
+	for _, r := range ra.resMap.Resources() {
+		if !r.IsFrozen() {
+			continue
+		}
+		content, err := frozenContent(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, frozenResource{
+			res: r, original: r.DeepCopy(), content: content})
+	}
+	return result, nil
+}
+
+// checkFrozen compares the frozen resources still in the
+// accumulator to their snapshot.  Removing them is allowed.
+func (ra *ResAccumulator) checkFrozen(frozen []frozenResource) error {
+	if len(frozen) == 0 {
+		return nil
+	}
+	present := make(map[*resource.Resource]bool)
+	for _, r := range ra.resMap.Resources() {
+		present[r] = true
+	}
+	for _, f := range frozen {
+		if !present[f.res] {
+			continue
+		}
+		content, err := frozenContent(f.res)
+		if err != nil {
+			return err
+		}
+		if content == f.content {
+			continue
+		}
+		id := f.original.CurId()
+		if !ra.warnOnFrozenChanges {
+			return fmt.Errorf(
+				"resource %s is annotated %s, but a transformer changed it",
+				id, konfig.FrozenAnnotation)
+		}
+		log.Printf(
+			"warning: reverting a transformer's change to resource %s, annotated %s",
+			id, konfig.FrozenAnnotation)
+		f.res.ResetPrimaryData(f.original)
+	}
+	return nil
+}
+
+// frozenContent returns the YAML of the resource without
+// the annotations kustomize uses to track name changes,
+// which may change even if the resource, as output, doesn't.
+func frozenContent(r *resource.Resource) (string, error) {
+	c := r.DeepCopy()
+	c.RemoveBuildAnnotations()
+	return c.Node().String()
 }
 
 func (ra *ResAccumulator) ResolveVars() error {
@@ -164,6 +320,8 @@ func (ra *ResAccumulator) FixBackReferences() (err error) {
 	if ra.tConfig.NameReference == nil {
 		return nil
 	}
-	return ra.Transform(
-		newNameReferenceTransformer(ra.tConfig.NameReference))
+	return ra.Transform(&nameReferenceTransformer{
+		backRefs:                       ra.tConfig.NameReference,
+		clusterScopedReferralNamespace: ra.clusterScopedReferralNamespace,
+	})
 }
@@ -4,7 +4,11 @@
 package generators
 
 import (
+	"sort"
+	"strings"
+
 	"sigs.k8s.io/kustomize/api/ifc"
+	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
@@ -42,5 +46,30 @@ func MakeConfigMap(
 	}
 	copyLabelsAndAnnotations(rn, args.Options)
 	setImmutable(rn, args.Options)
+	if err = setReplacementFiles(ldr, rn, args); err != nil {
+		return nil, err
+	}
 	return rn, nil
 }
+
+// setReplacementFiles annotates the configmap with the keys of its
+// file sources if its options let replacements write into them.
+func setReplacementFiles(
+	ldr ifc.KvLoader, rn *yaml.RNode, args *types.ConfigMapArgs) error {
+	if args.Options == nil || !args.Options.ApplyReplacements ||
+		len(args.FileSources) == 0 {
+		return nil
+	}
+	pairs, err := ldr.Load(types.KvPairSources{FileSources: args.FileSources})
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for _, p := range pairs {
+		keys = append(keys, p.Key)
+	}
+	sort.Strings(keys)
+	_, err = rn.Pipe(yaml.SetAnnotation(
+		konfig.ReplacementFilesAnnotation, strings.Join(keys, ",")))
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import "syscall"
+
+// processAlive returns whether the process pid runs.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"sync"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+// CloneCache clones each repository, at each ref, only once,
+// however many RepoSpecs its Cloner is used for, e.g. to
+// share the clones of remote bases between builds.
+//
+// The RepoSpecs' cleaners leave the clones in place;
+// Cleanup removes them, unless they're kept on disk (see
+// NewDiskCloneCache).  Failures to clone are cached too.
+type CloneCache struct {
+	fSys   filesys.FileSystem
+	cloner Cloner
+	mu     sync.Mutex
+	clones map[string]*cachedClone
+	// disk, if not nil, keeps the clones for later processes.
+	disk *diskCache
+}
+
+type cachedClone struct {
+	once sync.Once
+	dir  filesys.ConfirmedDir
+	err  error
+}
+
+// NewCloneCache returns a CloneCache cloning with cloner.
+func NewCloneCache(fSys filesys.FileSystem, cloner Cloner) *CloneCache {
+	return &CloneCache{
+		fSys:   fSys,
+		cloner: cloner,
+		clones: make(map[string]*cachedClone),
+	}
+}
+
+// Cloner returns a Cloner getting its clones from the cache.
+// It may be used from several goroutines at once.
+func (c *CloneCache) Cloner() Cloner {
+	return c.clone
+}
+
+func (c *CloneCache) clone(repoSpec *RepoSpec) error {
+	key := repoSpec.CloneSpec() + "?ref=" + repoSpec.Ref
+	c.mu.Lock()
+	cc, ok := c.clones[key]
+	if !ok {
+		cc = &cachedClone{}
+		c.clones[key] = cc
+	}
+	c.mu.Unlock()
+	cc.once.Do(func() {
+		rs := *repoSpec
+		if cc.err = c.cloner(&rs); cc.err != nil {
+			if rs.Dir != "" {
+				c.fSys.RemoveAll(rs.Dir.String())
+			}
+			return
+		}
+		cc.dir = rs.Dir
+	})
+	repoSpec.shared = true
+	if cc.err != nil {
+		return cc.err
+	}
+	repoSpec.Dir = cc.dir
+	return nil
+}
+
+// Cleanup removes the clones, or unlocks them if they're
+// kept on disk, returning the first error met, and empties
+// the cache.
+func (c *CloneCache) Cleanup() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disk != nil {
+		c.clones = make(map[string]*cachedClone)
+		return c.disk.release()
+	}
+	var result error
+	for _, cc := range c.clones {
+		if cc.dir == "" {
+			continue
+		}
+		if err := c.fSys.RemoveAll(cc.dir.String()); err != nil && result == nil {
+			result = err
+		}
+	}
+	c.clones = make(map[string]*cachedClone)
+	return result
+}
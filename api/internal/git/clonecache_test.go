@@ -0,0 +1,71 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+func TestCloneCache(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	var mu sync.Mutex
+	clones := 0
+	cache := NewCloneCache(fSys, func(rs *RepoSpec) error {
+		mu.Lock()
+		defer mu.Unlock()
+		clones++
+		rs.Dir = filesys.ConfirmedDir(fmt.Sprintf("/clone%d", clones))
+		return fSys.MkdirAll(rs.Dir.String())
+	})
+	specs := []string{
+		"github.com/someOrg/someRepo/a?ref=v1",
+		"github.com/someOrg/someRepo/b?ref=v1",
+		"github.com/someOrg/someRepo/a?ref=v2",
+	}
+	var wg sync.WaitGroup
+	dirs := make([]filesys.ConfirmedDir, 2*len(specs))
+	for i := range dirs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rs, err := NewRepoSpecFromUrl(specs[i%len(specs)])
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err = cache.Cloner()(rs); err != nil {
+				t.Error(err)
+				return
+			}
+			if err = rs.Cleaner(fSys)(); err != nil {
+				t.Error(err)
+			}
+			dirs[i] = rs.Dir
+		}(i)
+	}
+	wg.Wait()
+	if clones != 2 {
+		t.Fatalf("expected 2 clones, got %d", clones)
+	}
+	if dirs[0] != dirs[1] || dirs[0] == dirs[2] {
+		t.Fatalf("unexpected clone dirs %v", dirs)
+	}
+	for _, d := range dirs {
+		if !fSys.Exists(d.String()) {
+			t.Fatalf("expected the clone %s to be kept", d)
+		}
+	}
+	if err := cache.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range dirs {
+		if fSys.Exists(d.String()) {
+			t.Fatalf("expected the clone %s to be removed", d)
+		}
+	}
+}
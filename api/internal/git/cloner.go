@@ -14,7 +14,20 @@ type Cloner func(repoSpec *RepoSpec) error
 // to say, some remote API, to obtain a local clone of
 // a remote repo.
 func ClonerUsingGitExec(repoSpec *RepoSpec) error {
-	r, err := newCmdRunner()
+	return cloneUsingGitExec(repoSpec, "")
+}
+
+// ClonerUsingGitExecIn returns a Cloner like ClonerUsingGitExec,
+// cloning into new directories of parent, rather than of the
+// temporary directory.
+func ClonerUsingGitExecIn(parent string) Cloner {
+	return func(repoSpec *RepoSpec) error {
+		return cloneUsingGitExec(repoSpec, parent)
+	}
+}
+
+func cloneUsingGitExec(repoSpec *RepoSpec, parent string) error {
+	r, err := newCmdRunner(parent)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,436 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+// A disk cache keeps the clones of remote repositories in a
+// directory, for the processes run after the one cloning them
+// to reuse:
+//
+//	<dir>/<id>/              the clone of a repository at a ref
+//	<dir>/<id>.json          its entry, see DiskEntry
+//	<dir>/<id>.<owner>.lock  held by each process using the
+//	                         clone, owner being its host and pid
+//
+// where id is a hash of the repository and ref.  Clones are made
+// in temporary directories of dir, tmp-<id>-*, renamed into place
+// once complete, so that <id> is always a complete clone.  A
+// process locks a clone before looking it up or making it, and the
+// collection of the cache leaves the locked ones, and their
+// temporary directories, alone.
+const (
+	entrySuffix = ".json"
+	lockSuffix  = ".lock"
+	tmpPrefix   = "tmp-"
+
+	// staleLockAge is the age after which the lock of a process
+	// of another host is taken to be stale, it not being known
+	// whether the process still runs.
+	staleLockAge = 24 * time.Hour
+
+	// staleCloneAge is the age after which a temporary clone
+	// whose clone isn't locked is taken to be abandoned by a
+	// process that failed.
+	staleCloneAge = time.Hour
+)
+
+// DiskEntry describes a clone of a disk cache.
+type DiskEntry struct {
+	// ID is the name of the clone in the directory of the cache.
+	ID string `json:"-"`
+
+	// Repo and Ref are those cloned.
+	Repo string `json:"repo"`
+	Ref  string `json:"ref,omitempty"`
+
+	// Created and LastUsed are when the clone was
+	// made and when a process last looked it up.
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"lastUsed"`
+
+	// Size is the size of the files of the clone, in bytes.
+	Size int64 `json:"-"`
+
+	// Users are the processes using the clone,
+	// as host:pid, e.g. ci-runner:4242.
+	Users []string `json:"-"`
+}
+
+// diskLock is the content of a lock file.
+type diskLock struct {
+	Host  string    `json:"host"`
+	Pid   int       `json:"pid"`
+	Since time.Time `json:"since"`
+}
+
+func (l diskLock) owner() string {
+	return fmt.Sprintf("%s:%d", l.Host, l.Pid)
+}
+
+// diskCache is the Cloner of a CloneCache keeping
+// its clones in a directory, for later processes.
+type diskCache struct {
+	dir     string
+	cloneIn func(parent string) Cloner
+	mu      sync.Mutex
+	locks   []string
+}
+
+// NewDiskCloneCache returns a CloneCache keeping its clones in
+// dir, made with the Cloner that cloneIn returns for a parent
+// directory, the clones of which it must make there.  Its Cleanup
+// unlocks the clones, rather than removing them.
+func NewDiskCloneCache(dir string, cloneIn func(parent string) Cloner) (*CloneCache, error) {
+	dir, err := cacheDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	d := &diskCache{dir: dir, cloneIn: cloneIn}
+	c := NewCloneCache(filesys.MakeFsOnDisk(), d.clone)
+	c.disk = d
+	return c, nil
+}
+
+// cacheDir makes the directory of a disk cache, if
+// need be, returning its absolute path, without links.
+func cacheDir(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("making the clone cache %s: %v", dir, err)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(dir)
+}
+
+// entryID returns the id of the clone of repo at ref.
+func entryID(repo, ref string) string {
+	sum := sha256.Sum256([]byte(repo + "?ref=" + ref))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (d *diskCache) clone(repoSpec *RepoSpec) error {
+	repo := repoSpec.CloneSpec()
+	id := entryID(repo, repoSpec.Ref)
+	if err := d.lock(id); err != nil {
+		return err
+	}
+	dir := filepath.Join(d.dir, id)
+	entry := DiskEntry{Repo: repo, Ref: repoSpec.Ref, Created: time.Now()}
+	if !isDir(dir) {
+		// The clone is made in a directory named after it, which
+		// the collection of the cache leaves alone while it's locked.
+		parent, err := ioutil.TempDir(d.dir, tmpPrefix+id+"-")
+		if err != nil {
+			return fmt.Errorf("caching the clone of %s: %v", repo, err)
+		}
+		defer os.RemoveAll(parent)
+		rs := *repoSpec
+		rs.Dir = ""
+		if err = d.cloneIn(parent)(&rs); err != nil {
+			return err
+		}
+		// Another process may have made the clone meanwhile.
+		if err = os.Rename(rs.Dir.String(), dir); err != nil && !isDir(dir) {
+			return fmt.Errorf("caching the clone of %s: %v", repo, err)
+		}
+	} else if old, err := readEntry(d.dir, id); err == nil {
+		entry.Created = old.Created
+	}
+	entry.LastUsed = time.Now()
+	if err := writeEntry(d.dir, id, entry); err != nil {
+		return err
+	}
+	repoSpec.Dir = filesys.ConfirmedDir(dir)
+	return nil
+}
+
+// lock locks the clone id for this process.
+func (d *diskCache) lock(id string) error {
+	host, _ := os.Hostname()
+	l := diskLock{Host: host, Pid: os.Getpid(), Since: time.Now()}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(d.dir,
+		fmt.Sprintf("%s.%s-%d%s", id, sanitize(host), l.Pid, lockSuffix))
+	if err = ioutil.WriteFile(path, b, 0600); err != nil {
+		return fmt.Errorf("locking the cached clone %s: %v", id, err)
+	}
+	d.mu.Lock()
+	d.locks = append(d.locks, path)
+	d.mu.Unlock()
+	return nil
+}
+
+// release unlocks the clones, returning the first error met.
+func (d *diskCache) release() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var result error
+	for _, path := range d.locks {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) && result == nil {
+			result = err
+		}
+	}
+	d.locks = nil
+	return result
+}
+
+// sanitize makes a host name fit in a file name.
+func sanitize(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == '.' {
+			return '_'
+		}
+		return r
+	}, host)
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+func readEntry(dir, id string) (DiskEntry, error) {
+	var e DiskEntry
+	b, err := ioutil.ReadFile(filepath.Join(dir, id+entrySuffix))
+	if err != nil {
+		return e, err
+	}
+	if err = json.Unmarshal(b, &e); err != nil {
+		return e, fmt.Errorf("reading the entry of cached clone %s: %v", id, err)
+	}
+	e.ID = id
+	return e, nil
+}
+
+// writeEntry writes the entry of the clone id, through a
+// temporary file, so that it's never read half written.
+func writeEntry(dir, id string, e DiskEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, tmpPrefix+id+"-*"+entrySuffix)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, id+entrySuffix))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("writing the entry of cached clone %s: %v", id, err)
+	}
+	return nil
+}
+
+// ListDiskCache returns the clones of the disk cache in dir,
+// most recently used first, with the processes using them.
+func ListDiskCache(dir string) ([]DiskEntry, error) {
+	entries, _, err := scanDiskCache(dir, time.Now())
+	return entries, err
+}
+
+// scanDiskCache returns the clones of the disk cache in dir, most
+// recently used first, and the paths of the stale locks, those of
+// processes that aren't running, of the temporary files of clones
+// no process locks, left by processes that failed, and of the
+// entries left by clones removed, as of now.
+func scanDiskCache(dir string, now time.Time) ([]DiskEntry, []string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		// Nothing was cached yet.
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading the clone cache %s: %v", dir, err)
+	}
+	host, _ := os.Hostname()
+	users := make(map[string][]string)
+	clones := make(map[string]bool)
+	var entries []DiskEntry
+	var stale []string
+	for _, fi := range infos {
+		name := fi.Name()
+		path := filepath.Join(dir, name)
+		switch {
+		case strings.HasSuffix(name, lockSuffix):
+			l, live := readLock(path, host, now)
+			if !live {
+				stale = append(stale, path)
+				continue
+			}
+			id := strings.SplitN(name, ".", 2)[0]
+			users[id] = append(users[id], l.owner())
+		case fi.IsDir() && !strings.HasPrefix(name, tmpPrefix):
+			clones[name] = true
+		}
+	}
+	for _, fi := range infos {
+		name := fi.Name()
+		path := filepath.Join(dir, name)
+		switch {
+		case strings.HasPrefix(name, tmpPrefix):
+			id := strings.SplitN(strings.TrimPrefix(name, tmpPrefix), "-", 2)[0]
+			if len(users[id]) == 0 && now.Sub(fi.ModTime()) > staleCloneAge {
+				stale = append(stale, path)
+			}
+		case strings.HasSuffix(name, entrySuffix):
+			if !clones[strings.TrimSuffix(name, entrySuffix)] {
+				// The entry of a clone removed.
+				stale = append(stale, path)
+			}
+		case fi.IsDir():
+			e, err := readEntry(dir, name)
+			if err != nil {
+				// A clone whose entry its process didn't
+				// get to write, before it failed.
+				e = DiskEntry{ID: name, Created: fi.ModTime(), LastUsed: fi.ModTime()}
+			}
+			if e.Size, err = dirSize(path); err != nil {
+				return nil, nil, err
+			}
+			entries = append(entries, e)
+		}
+	}
+	for i := range entries {
+		entries[i].Users = users[entries[i].ID]
+		sort.Strings(entries[i].Users)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, stale, nil
+}
+
+// readLock returns the lock at path, and whether it's live: held
+// by a process of this host that's running, or, if of another
+// host, not older than staleLockAge.
+func readLock(path, host string, now time.Time) (diskLock, bool) {
+	var l diskLock
+	b, err := ioutil.ReadFile(path)
+	if err != nil || json.Unmarshal(b, &l) != nil {
+		// A lock being written, unless it's old.
+		fi, err := os.Stat(path)
+		return l, err == nil && now.Sub(fi.ModTime()) <= staleLockAge
+	}
+	if l.Host == host {
+		return l, processAlive(l.Pid)
+	}
+	return l, now.Sub(l.Since) <= staleLockAge
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("sizing the cached clone %s: %v", dir, err)
+	}
+	return size, nil
+}
+
+// CollectDiskCache removes, from the disk cache in dir, the stale
+// locks and temporary files, and then the clones no process uses
+// that were last used more than maxAge ago, if maxAge is above
+// zero, and, least recently used first, while the clones are
+// larger than maxSize, if above zero.  It returns the clones
+// removed, most recently used first.
+func CollectDiskCache(dir string, maxAge time.Duration, maxSize int64, now time.Time) (
+	[]DiskEntry, error) {
+	entries, stale, err := scanDiskCache(dir, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range stale {
+		if err = os.RemoveAll(path); err != nil {
+			return nil, err
+		}
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	var removed []DiskEntry
+	// Least recently used first.
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		expired := maxAge > 0 && now.Sub(e.LastUsed) > maxAge
+		if len(e.Users) > 0 || !expired && (maxSize <= 0 || size <= maxSize) {
+			continue
+		}
+		ok, err := removeClone(dir, e.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			size -= e.Size
+			removed = append([]DiskEntry{e}, removed...)
+		}
+	}
+	return removed, nil
+}
+
+// removeClone removes the clone id, unless a process locked it
+// meanwhile.  The clone is first moved aside, so that processes
+// locking it later don't find it, and then put back if one had
+// locked it before; it returns whether it was removed.
+func removeClone(dir, id string) (bool, error) {
+	aside := filepath.Join(dir, tmpPrefix+id+"-removed")
+	if err := os.Rename(filepath.Join(dir, id), aside); err != nil {
+		if os.IsNotExist(err) {
+			// Another collection removed it.
+			return false, nil
+		}
+		return false, fmt.Errorf("removing the cached clone %s: %v", id, err)
+	}
+	host, _ := os.Hostname()
+	locks, err := filepath.Glob(filepath.Join(dir, id+".*"+lockSuffix))
+	if err != nil {
+		return false, err
+	}
+	for _, path := range locks {
+		if _, live := readLock(path, host, time.Now()); live {
+			// A process made the clone again meanwhile, if it's there.
+			if err = os.Rename(aside, filepath.Join(dir, id)); err != nil {
+				err = os.RemoveAll(aside)
+			}
+			return false, err
+		}
+	}
+	if err = os.Remove(filepath.Join(dir, id+entrySuffix)); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, os.RemoveAll(aside)
+}
@@ -0,0 +1,170 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+// fakeCloneIn returns a cloneIn making clones holding
+// a file of size bytes, counting them.
+func fakeCloneIn(t *testing.T, clones *int, size int) func(string) Cloner {
+	t.Helper()
+	return func(parent string) Cloner {
+		return func(rs *RepoSpec) error {
+			*clones++
+			dir, err := newTmpDirIn(parent)
+			if err != nil {
+				return err
+			}
+			rs.Dir = dir
+			return ioutil.WriteFile(
+				filepath.Join(dir.String(), "kustomization.yaml"),
+				make([]byte, size), 0600)
+		}
+	}
+}
+
+func cloneWith(t *testing.T, c *CloneCache, url string) *RepoSpec {
+	t.Helper()
+	rs, err := NewRepoSpecFromUrl(url)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, c.Cloner()(rs)) {
+		t.FailNow()
+	}
+	return rs
+}
+
+func TestDiskCloneCache(t *testing.T) {
+	dir := t.TempDir()
+	clones := 0
+	first, err := NewDiskCloneCache(dir, fakeCloneIn(t, &clones, 10))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	rs := cloneWith(t, first, "github.com/someOrg/someRepo/a?ref=v1")
+	assert.NoError(t, rs.Cleaner(filesys.MakeFsOnDisk())())
+	assert.True(t, isDir(rs.Dir.String()))
+	entries, err := ListDiskCache(dir)
+	if assert.NoError(t, err) && assert.Len(t, entries, 1) {
+		host, _ := os.Hostname()
+		assert.Equal(t, "https://github.com/someOrg/someRepo.git", entries[0].Repo)
+		assert.Equal(t, "v1", entries[0].Ref)
+		assert.Equal(t, int64(10), entries[0].Size)
+		assert.Equal(t, []string{host + ":" + strconv.Itoa(os.Getpid())}, entries[0].Users)
+	}
+	assert.NoError(t, first.Cleanup())
+	assert.True(t, isDir(rs.Dir.String()))
+
+	// Another process reuses the clone.
+	second, err := NewDiskCloneCache(dir, fakeCloneIn(t, &clones, 10))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	again := cloneWith(t, second, "github.com/someOrg/someRepo/b?ref=v1")
+	assert.Equal(t, rs.Dir, again.Dir)
+	cloneWith(t, second, "github.com/someOrg/someRepo/a?ref=v2")
+	assert.Equal(t, 2, clones)
+	assert.NoError(t, second.Cleanup())
+	entries, err = ListDiskCache(dir)
+	if assert.NoError(t, err) && assert.Len(t, entries, 2) {
+		assert.Equal(t, "v2", entries[0].Ref)
+		assert.Empty(t, entries[0].Users)
+		assert.Empty(t, entries[1].Users)
+	}
+}
+
+func TestCollectDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	clones := 0
+	c, err := NewDiskCloneCache(dir, fakeCloneIn(t, &clones, 100))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	inUse := cloneWith(t, c, "github.com/someOrg/someRepo?ref=v1")
+	old := cloneWith(t, c, "github.com/someOrg/someRepo?ref=v2")
+	recent := cloneWith(t, c, "github.com/someOrg/someRepo?ref=v3")
+	assert.NoError(t, c.Cleanup())
+	_ = cloneWith(t, c, "github.com/someOrg/someRepo?ref=v1")
+	defer c.Cleanup()
+
+	// A lock left by a process that's gone, of this host.
+	host, _ := os.Hostname()
+	b, _ := json.Marshal(diskLock{Host: host, Pid: 1 << 30, Since: time.Now()})
+	staleLock := filepath.Join(filepath.Dir(old.Dir.String()),
+		filepath.Base(old.Dir.String())+".gone-1.lock")
+	assert.NoError(t, ioutil.WriteFile(staleLock, b, 0600))
+
+	now := time.Now()
+	for rs, lastUsed := range map[*RepoSpec]time.Time{
+		inUse:  now.Add(-72 * time.Hour),
+		old:    now.Add(-48 * time.Hour),
+		recent: now.Add(-time.Hour),
+	} {
+		id := filepath.Base(rs.Dir.String())
+		e, err := readEntry(filepath.Dir(rs.Dir.String()), id)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		e.LastUsed = lastUsed
+		assert.NoError(t, writeEntry(filepath.Dir(rs.Dir.String()), id, e))
+	}
+
+	removed, err := CollectDiskCache(dir, 24*time.Hour, 0, now)
+	if assert.NoError(t, err) && assert.Len(t, removed, 1) {
+		assert.Equal(t, "v2", removed[0].Ref)
+	}
+	assert.False(t, isDir(old.Dir.String()))
+	_, err = os.Stat(staleLock)
+	assert.True(t, os.IsNotExist(err))
+	assert.True(t, isDir(inUse.Dir.String()))
+	assert.True(t, isDir(recent.Dir.String()))
+
+	// The clone in use stays, however large the cache.
+	removed, err = CollectDiskCache(dir, 0, 1, now)
+	if assert.NoError(t, err) && assert.Len(t, removed, 1) {
+		assert.Equal(t, "v3", removed[0].Ref)
+	}
+	entries, err := ListDiskCache(dir)
+	if assert.NoError(t, err) && assert.Len(t, entries, 1) {
+		assert.Equal(t, "v1", entries[0].Ref)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+entrySuffix))
+	assert.Len(t, matches, 1)
+}
+
+func TestCollectDiskCacheKeepsLockedTmpClones(t *testing.T) {
+	dir := t.TempDir()
+	clones := 0
+	c, err := NewDiskCloneCache(dir, fakeCloneIn(t, &clones, 10))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer c.Cleanup()
+	cloning := entryID("https://github.com/someOrg/someRepo.git", "v1")
+	assert.NoError(t, c.disk.lock(cloning))
+	inProgress := filepath.Join(dir, tmpPrefix+cloning+"-1")
+	abandoned := filepath.Join(dir, tmpPrefix+entryID("https://github.com/someOrg/someRepo.git", "v2")+"-2")
+	old := time.Now().Add(-2 * staleCloneAge)
+	for _, path := range []string{inProgress, abandoned} {
+		assert.NoError(t, os.Mkdir(path, 0700))
+		assert.NoError(t, os.Chtimes(path, old, old))
+	}
+
+	_, err = CollectDiskCache(dir, 0, 0, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, isDir(inProgress))
+	assert.False(t, isDir(abandoned))
+}
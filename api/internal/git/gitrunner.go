@@ -4,7 +4,9 @@
 package git
 
 import (
+	"io/ioutil"
 	"os/exec"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
@@ -23,13 +25,19 @@ type gitRunner struct {
 }
 
 // newCmdRunner returns a gitRunner if it can find the binary.
-// It also creats a temp directory for cloning repos.
-func newCmdRunner() (*gitRunner, error) {
+// It also creats a temp directory for cloning repos, in parent
+// if set.
+func newCmdRunner(parent string) (*gitRunner, error) {
 	gitProgram, err := exec.LookPath("git")
 	if err != nil {
 		return nil, errors.Wrap(err, "no 'git' program on path")
 	}
-	dir, err := filesys.NewTmpConfirmedDir()
+	var dir filesys.ConfirmedDir
+	if parent == "" {
+		dir, err = filesys.NewTmpConfirmedDir()
+	} else {
+		dir, err = newTmpDirIn(parent)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -56,3 +64,14 @@ func (r gitRunner) run(args ...string) error {
 			return err
 		})
 }
+
+// newTmpDirIn makes a temporary directory in parent,
+// returning its path without links.
+func newTmpDirIn(parent string) (filesys.ConfirmedDir, error) {
+	dir, err := ioutil.TempDir(parent, tmpPrefix)
+	if err != nil {
+		return "", err
+	}
+	deLinked, err := filepath.EvalSymlinks(dir)
+	return filesys.ConfirmedDir(deLinked), err
+}
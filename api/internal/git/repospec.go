@@ -44,6 +44,10 @@ type RepoSpec struct {
 
 	// e.g. .git or empty in case of _git is present
 	GitSuffix string
+
+	// shared is true if Dir is a clone held by a
+	// CloneCache, which is left to remove it.
+	shared bool
 }
 
 // CloneSpec returns a string suitable for "git clone {spec}".
@@ -67,7 +71,12 @@ func (x *RepoSpec) AbsPath() string {
 }
 
 func (x *RepoSpec) Cleaner(fSys filesys.FileSystem) func() error {
-	return func() error { return fSys.RemoveAll(x.Dir.String()) }
+	return func() error {
+		if x.shared {
+			return nil
+		}
+		return fSys.RemoveAll(x.Dir.String())
+	}
 }
 
 // From strings like git@github.com:someOrg/someRepo.git or
@@ -0,0 +1,52 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kusterr
+
+// InfrastructureError is a failure to fetch remote content, e.g.
+// to clone a repository, as opposed to an error in the content.
+// It may not recur.
+type InfrastructureError struct {
+	Err error
+}
+
+func (e *InfrastructureError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InfrastructureError) Unwrap() error {
+	return e.Err
+}
+
+// InternalError is a failure of kustomize itself, e.g. a panic.
+type InternalError struct {
+	Err error
+}
+
+func (e *InternalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+// WithCause returns an error reading as err, which
+// unwraps to cause, e.g. to keep the cause of an
+// error formatted with it, for errors.As.
+func WithCause(err, cause error) error {
+	return &causedError{err: err, cause: cause}
+}
+
+type causedError struct {
+	err   error
+	cause error
+}
+
+func (e *causedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *causedError) Unwrap() error {
+	return e.cause
+}
@@ -4,32 +4,130 @@
 package builtinconfig
 
 import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
 	"sigs.k8s.io/kustomize/api/ifc"
+	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/yaml"
 )
 
 // loadDefaultConfig returns a TranformerConfig
-// object from a list of files.
+// object from a list of files, directories or remote
+// refs of them, e.g. a bundle published for overlays.
+//
+// The entries are merged in order, each overriding the
+// field specs it conflicts with of those before it, so
+// an overlay can list a bundle and then adjust it. The
+// files of a directory are merged in lexical order, and
+// a conflict between two of them is an error.
 func loadDefaultConfig(
 	ldr ifc.Loader, paths []string) (*TransformerConfig, error) {
 	result := &TransformerConfig{}
 	for _, path := range paths {
-		data, err := ldr.Load(path)
+		t, err := loadConfigEntry(ldr, path)
 		if err != nil {
 			return nil, err
 		}
+		result, err = result.Override(t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "merging configuration '%s'", path)
+		}
+	}
+	return result, nil
+}
+
+// configFile is a file of transformer config.
+type configFile struct {
+	path   string
+	config *TransformerConfig
+}
+
+// loadConfigEntry returns the merger of the transformer configs
+// of the file, or of the files of the directory, at the path.
+func loadConfigEntry(ldr ifc.Loader, entry string) (*TransformerConfig, error) {
+	data, errF := ldr.Load(entry)
+	if errF == nil {
 		t, err := makeTransformerConfigFromBytes(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "configuration '%s'", entry)
+		}
+		return t, nil
+	}
+	dir, err := ldr.New(entry)
+	if err != nil {
+		return nil, errors.Wrapf(err, "configuration err='%s'", errF.Error())
+	}
+	defer dir.Cleanup()
+	files, err := loadConfigFiles(dir, entry)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf(
+			"the configuration directory '%s' has no yaml files", entry)
+	}
+	result := &TransformerConfig{}
+	for i, f := range files {
+		merged, err := result.Merge(f.config)
+		if err != nil {
+			return nil, conflictError(files[:i], f, err)
+		}
+		result = merged
+	}
+	return result, nil
+}
+
+// loadConfigFiles returns the transformer configs of the yaml
+// files of the directory of dir, other than any kustomization.
+func loadConfigFiles(dir ifc.Loader, entry string) ([]configFile, error) {
+	g, ok := dir.(ifc.Globber)
+	if !ok {
+		return nil, fmt.Errorf(
+			"the configuration '%s' is a directory, but its loader can't list it", entry)
+	}
+	var names []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := g.Glob(pattern)
 		if err != nil {
 			return nil, err
 		}
-		result, err = result.Merge(t)
+		names = append(names, matches...)
+	}
+	sort.Strings(names)
+	var result []configFile
+	for _, name := range names {
+		if konfig.IsKustomizationFile(name) {
+			continue
+		}
+		data, err := dir.Load(name)
 		if err != nil {
 			return nil, err
 		}
+		filePath := path.Join(entry, name)
+		t, err := makeTransformerConfigFromBytes(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "configuration '%s'", filePath)
+		}
+		result = append(result, configFile{path: filePath, config: t})
 	}
 	return result, nil
 }
 
+// conflictError reports the earlier file f conflicts with,
+// failing to merge with err.
+func conflictError(earlier []configFile, f configFile, err error) error {
+	for _, e := range earlier {
+		if _, mergeErr := e.config.Merge(f.config); mergeErr != nil {
+			return fmt.Errorf("the configurations '%s' and '%s' conflict: %v",
+				e.path, f.path, mergeErr)
+		}
+	}
+	return errors.Wrapf(err, "configuration '%s'", f.path)
+}
+
 // makeTransformerConfigFromBytes returns a TransformerConfig object from bytes
 func makeTransformerConfigFromBytes(data []byte) (*TransformerConfig, error) {
 	var t TransformerConfig
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/ifc"
 	"sigs.k8s.io/kustomize/api/loader"
 	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/types"
@@ -44,3 +45,109 @@ namePrefix:
 		t.Fatalf("expected %v\n but go6t %v\n", expected, tCfg)
 	}
 }
+
+func makeBundleLoader(t *testing.T, files map[string]string) ifc.Loader {
+	t.Helper()
+	fSys := filesys.MakeFsInMemory()
+	for name, content := range files {
+		if err := fSys.WriteFile(name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ldr, err := loader.NewLoader(
+		loader.RestrictionRootOnly, filesys.Separator, fSys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ldr
+}
+
+func TestLoadDefaultConfigsFromDirectory(t *testing.T) {
+	ldr := makeBundleLoader(t, map[string]string{
+		"bundle/labels.yaml": `
+commonLabels:
+- path: spec/selector
+  kind: Widget
+  create: true
+`,
+		"bundle/prefix.yml": `
+namePrefix:
+- path: spec/owner
+  kind: Widget
+`,
+		"bundle/kustomization.yaml": `
+resources:
+- widget.yaml
+`,
+		"bundle/README.md": `Not a config.`,
+		"overrides.yaml": `
+commonLabels:
+- path: spec/selector
+  kind: Widget
+`,
+	})
+	tCfg, err := loadDefaultConfig(ldr, []string{"bundle", "overrides.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The later entry overrides the field spec of the bundle.
+	expected := &TransformerConfig{
+		NamePrefix: []types.FieldSpec{
+			{
+				Gvk:  resid.Gvk{Kind: "Widget"},
+				Path: "spec/owner",
+			},
+		},
+		CommonLabels: []types.FieldSpec{
+			{
+				Gvk:  resid.Gvk{Kind: "Widget"},
+				Path: "spec/selector",
+			},
+		},
+	}
+	if !reflect.DeepEqual(tCfg, expected) {
+		t.Fatalf("expected %v\n but got %v\n", expected, tCfg)
+	}
+}
+
+func TestLoadDefaultConfigsConflictingInDirectory(t *testing.T) {
+	ldr := makeBundleLoader(t, map[string]string{
+		"bundle/a.yaml": `
+commonLabels:
+- path: spec/selector
+  kind: Widget
+  create: true
+`,
+		"bundle/b.yaml": `
+namePrefix:
+- path: spec/owner
+  kind: Widget
+`,
+		"bundle/c.yaml": `
+commonLabels:
+- path: spec/selector
+  kind: Widget
+`,
+	})
+	_, err := loadDefaultConfig(ldr, []string{"bundle"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := "the configurations 'bundle/a.yaml' and 'bundle/c.yaml' conflict: " +
+		"conflicting fieldspecs ~G_~V_Widget:true:spec/selector and " +
+		"~G_~V_Widget:false:spec/selector"
+	if err.Error() != expected {
+		t.Fatalf("expected %q\n but got %q\n", expected, err.Error())
+	}
+}
+
+func TestLoadDefaultConfigsFromEmptyDirectory(t *testing.T) {
+	ldr := makeBundleLoader(t, map[string]string{
+		"bundle/README.md": `Not a config.`,
+	})
+	_, err := loadDefaultConfig(ldr, []string{"bundle"})
+	if err == nil || err.Error() !=
+		"the configuration directory 'bundle' has no yaml files" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"sigs.k8s.io/kustomize/api/ifc"
 	"sigs.k8s.io/kustomize/api/konfig/builtinpluginconsts"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/types"
 )
 
@@ -23,6 +24,11 @@ type TransformerConfig struct {
 	VarReference      types.FsSlice `json:"varReference,omitempty" yaml:"varReference,omitempty"`
 	Images            types.FsSlice `json:"images,omitempty" yaml:"images,omitempty"`
 	Replicas          types.FsSlice `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+
+	// ClusterScoped lists kinds that, though unknown to the
+	// openapi data, are cluster-scoped, i.e. never namespaced.
+	// An empty group or version matches any.
+	ClusterScoped []resid.Gvk `json:"clusterScoped,omitempty" yaml:"clusterScoped,omitempty"`
 }
 
 // MakeEmptyConfig returns an empty TransformerConfig object
@@ -65,6 +71,9 @@ func (t *TransformerConfig) sortFields() {
 	sort.Sort(t.VarReference)
 	sort.Sort(t.Images)
 	sort.Sort(t.Replicas)
+	sort.Slice(t.ClusterScoped, func(i, j int) bool {
+		return t.ClusterScoped[i].IsLessThan(t.ClusterScoped[j])
+	})
 }
 
 // AddPrefixFieldSpec adds a FieldSpec to NamePrefix
@@ -98,6 +107,39 @@ func (t *TransformerConfig) AddNamereferenceFieldSpec(
 	return err
 }
 
+// WithoutFieldSpecs returns a copy of the TransformerConfig
+// holding none of the field specs, including name reference
+// referrers, for which the given function returns true.
+func (t *TransformerConfig) WithoutFieldSpecs(
+	drop func(types.FieldSpec) bool) *TransformerConfig {
+	filter := func(fss types.FsSlice) (result types.FsSlice) {
+		for _, fs := range fss {
+			if !drop(fs) {
+				result = append(result, fs)
+			}
+		}
+		return result
+	}
+	result := &TransformerConfig{
+		NamePrefix:        filter(t.NamePrefix),
+		NameSuffix:        filter(t.NameSuffix),
+		NameSpace:         filter(t.NameSpace),
+		CommonLabels:      filter(t.CommonLabels),
+		CommonAnnotations: filter(t.CommonAnnotations),
+		VarReference:      filter(t.VarReference),
+		Images:            filter(t.Images),
+		Replicas:          filter(t.Replicas),
+		ClusterScoped:     t.ClusterScoped,
+	}
+	for _, nbr := range t.NameReference {
+		result.NameReference = append(result.NameReference, NameBackReferences{
+			Gvk:       nbr.Gvk,
+			Referrers: filter(nbr.Referrers),
+		})
+	}
+	return result
+}
+
 // Merge merges two TransformerConfigs objects into
 // a new TransformerConfig object
 func (t *TransformerConfig) Merge(input *TransformerConfig) (
@@ -143,6 +185,78 @@ func (t *TransformerConfig) Merge(input *TransformerConfig) (
 	if err != nil {
 		return nil, err
 	}
+	merged.ClusterScoped = mergeGvks(t.ClusterScoped, input.ClusterScoped)
 	merged.sortFields()
 	return merged, nil
 }
+
+// Override merges the input into a new TransformerConfig object,
+// its field specs replacing those of t they conflict with, rather
+// than failing the merge.
+func (t *TransformerConfig) Override(input *TransformerConfig) (
+	*TransformerConfig, error) {
+	if input == nil {
+		return t, nil
+	}
+	base := &TransformerConfig{
+		NamePrefix:        withoutConflicts(t.NamePrefix, input.NamePrefix),
+		NameSuffix:        withoutConflicts(t.NameSuffix, input.NameSuffix),
+		NameSpace:         withoutConflicts(t.NameSpace, input.NameSpace),
+		CommonLabels:      withoutConflicts(t.CommonLabels, input.CommonLabels),
+		CommonAnnotations: withoutConflicts(t.CommonAnnotations, input.CommonAnnotations),
+		VarReference:      withoutConflicts(t.VarReference, input.VarReference),
+		Images:            withoutConflicts(t.Images, input.Images),
+		Replicas:          withoutConflicts(t.Replicas, input.Replicas),
+		ClusterScoped:     t.ClusterScoped,
+	}
+	for _, nbr := range t.NameReference {
+		referrers := nbr.Referrers
+		for _, other := range input.NameReference {
+			if other.Gvk.Equals(nbr.Gvk) {
+				referrers = withoutConflicts(referrers, other.Referrers)
+			}
+		}
+		base.NameReference = append(base.NameReference, NameBackReferences{
+			Gvk:       nbr.Gvk,
+			Referrers: referrers,
+		})
+	}
+	return base.Merge(input)
+}
+
+// withoutConflicts returns the field specs of s that
+// merge with all those of incoming.
+func withoutConflicts(s, incoming types.FsSlice) (result types.FsSlice) {
+	for _, fs := range s {
+		if _, err := (types.FsSlice{fs}).MergeAll(incoming); err == nil {
+			result = append(result, fs)
+		}
+	}
+	return result
+}
+
+// AddClusterScopedKind records that instances of the given kind
+// are cluster-scoped.
+func (t *TransformerConfig) AddClusterScopedKind(gvk resid.Gvk) {
+	t.ClusterScoped = mergeGvks(t.ClusterScoped, []resid.Gvk{gvk})
+}
+
+func mergeGvks(a, b []resid.Gvk) []resid.Gvk {
+	result := append([]resid.Gvk{}, a...)
+	for _, x := range b {
+		found := false
+		for _, y := range result {
+			if x.Equals(y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, x)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
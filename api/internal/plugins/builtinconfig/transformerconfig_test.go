@@ -173,3 +173,44 @@ func TestMerge(t *testing.T) {
 		t.Fatalf("expected: %v\n but got: %v\n", cfga, actual)
 	}
 }
+
+func TestWithoutFieldSpecs(t *testing.T) {
+	cfg := &TransformerConfig{
+		CommonLabels: []types.FieldSpec{
+			{Path: "metadata/labels", CreateIfNotPresent: true},
+			{
+				Gvk:  resid.Gvk{Kind: "StatefulSet"},
+				Path: "spec/volumeClaimTemplates[]/metadata/labels",
+			},
+		},
+	}
+	err := cfg.AddNamereferenceFieldSpec(NameBackReferences{
+		Gvk: resid.Gvk{Kind: "StorageClass"},
+		Referrers: []types.FieldSpec{
+			{Gvk: resid.Gvk{Kind: "PersistentVolumeClaim"}, Path: "spec/storageClassName"},
+			{Gvk: resid.Gvk{Kind: "StatefulSet"}, Path: "spec/volumeClaimTemplates/spec/storageClassName"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	actual := cfg.WithoutFieldSpecs(func(fs types.FieldSpec) bool {
+		return fs.Kind == "StatefulSet"
+	})
+	expectedLabels := types.FsSlice{
+		{Path: "metadata/labels", CreateIfNotPresent: true},
+	}
+	if !reflect.DeepEqual(actual.CommonLabels, expectedLabels) {
+		t.Fatalf("expected\n%v\nbut got\n%v", expectedLabels, actual.CommonLabels)
+	}
+	expectedReferrers := types.FsSlice{
+		{Gvk: resid.Gvk{Kind: "PersistentVolumeClaim"}, Path: "spec/storageClassName"},
+	}
+	if len(actual.NameReference) != 1 ||
+		!reflect.DeepEqual(actual.NameReference[0].Referrers, expectedReferrers) {
+		t.Fatalf("expected referrers\n%v\nbut got\n%v", expectedReferrers, actual.NameReference)
+	}
+	if len(cfg.CommonLabels) != 2 || len(cfg.NameReference[0].Referrers) != 2 {
+		t.Fatalf("original config modified: %v", cfg)
+	}
+}
@@ -25,11 +25,20 @@ func _() {
 	_ = x[ValueAddTransformer-14]
 	_ = x[HelmChartInflationGenerator-15]
 	_ = x[ReplacementTransformer-16]
+	_ = x[ChecksumTransformer-17]
+	_ = x[ServiceAccountGenerator-18]
+	_ = x[NetworkPolicyBaselineTransformer-19]
+	_ = x[PodDisruptionBudgetTransformer-20]
+	_ = x[IngressToGatewayTransformer-21]
+	_ = x[ApiVersionMigrationTransformer-22]
+	_ = x[OwnershipTransformer-23]
+	_ = x[TLSSecretGenerator-24]
+	_ = x[ApplyHintsTransformer-25]
 }
 
-const _BuiltinPluginType_name = "UnknownAnnotationsTransformerConfigMapGeneratorHashTransformerImageTagTransformerLabelTransformerLegacyOrderTransformerNamespaceTransformerPatchJson6902TransformerPatchStrategicMergeTransformerPatchTransformerPrefixSuffixTransformerReplicaCountTransformerSecretGeneratorValueAddTransformerHelmChartInflationGeneratorReplacementTransformer"
+const _BuiltinPluginType_name = "UnknownAnnotationsTransformerConfigMapGeneratorHashTransformerImageTagTransformerLabelTransformerLegacyOrderTransformerNamespaceTransformerPatchJson6902TransformerPatchStrategicMergeTransformerPatchTransformerPrefixSuffixTransformerReplicaCountTransformerSecretGeneratorValueAddTransformerHelmChartInflationGeneratorReplacementTransformerChecksumTransformerServiceAccountGeneratorNetworkPolicyBaselineTransformerPodDisruptionBudgetTransformerIngressToGatewayTransformerApiVersionMigrationTransformerOwnershipTransformerTLSSecretGeneratorApplyHintsTransformer"
 
-var _BuiltinPluginType_index = [...]uint16{0, 7, 29, 47, 62, 81, 97, 119, 139, 163, 193, 209, 232, 255, 270, 289, 316, 338}
+var _BuiltinPluginType_index = [...]uint16{0, 7, 29, 47, 62, 81, 97, 119, 139, 163, 193, 209, 232, 255, 270, 289, 316, 338, 357, 380, 412, 442, 469, 499, 519, 537, 558}
 
 func (i BuiltinPluginType) String() string {
 	if i < 0 || i >= BuiltinPluginType(len(_BuiltinPluginType_index)-1) {
@@ -29,6 +29,15 @@ const (
 	ValueAddTransformer
 	HelmChartInflationGenerator
 	ReplacementTransformer
+	ChecksumTransformer
+	ServiceAccountGenerator
+	NetworkPolicyBaselineTransformer
+	PodDisruptionBudgetTransformer
+	IngressToGatewayTransformer
+	ApiVersionMigrationTransformer
+	OwnershipTransformer
+	TLSSecretGenerator
+	ApplyHintsTransformer
 )
 
 var stringToBuiltinPluginTypeMap map[string]BuiltinPluginType
@@ -38,7 +47,7 @@ func init() {
 }
 
 func makeStringToBuiltinPluginTypeMap() (result map[string]BuiltinPluginType) {
-	result = make(map[string]BuiltinPluginType, 23)
+	result = make(map[string]BuiltinPluginType, 25)
 	for k := range GeneratorFactories {
 		result[k.String()] = k
 	}
@@ -60,20 +69,29 @@ var GeneratorFactories = map[BuiltinPluginType]func() resmap.GeneratorPlugin{
 	ConfigMapGenerator:          builtins.NewConfigMapGeneratorPlugin,
 	SecretGenerator:             builtins.NewSecretGeneratorPlugin,
 	HelmChartInflationGenerator: builtins.NewHelmChartInflationGeneratorPlugin,
+	ServiceAccountGenerator:     builtins.NewServiceAccountGeneratorPlugin,
+	TLSSecretGenerator:          builtins.NewTLSSecretGeneratorPlugin,
 }
 
 var TransformerFactories = map[BuiltinPluginType]func() resmap.TransformerPlugin{
-	AnnotationsTransformer:         builtins.NewAnnotationsTransformerPlugin,
-	HashTransformer:                builtins.NewHashTransformerPlugin,
-	ImageTagTransformer:            builtins.NewImageTagTransformerPlugin,
-	LabelTransformer:               builtins.NewLabelTransformerPlugin,
-	LegacyOrderTransformer:         builtins.NewLegacyOrderTransformerPlugin,
-	NamespaceTransformer:           builtins.NewNamespaceTransformerPlugin,
-	PatchJson6902Transformer:       builtins.NewPatchJson6902TransformerPlugin,
-	PatchStrategicMergeTransformer: builtins.NewPatchStrategicMergeTransformerPlugin,
-	PatchTransformer:               builtins.NewPatchTransformerPlugin,
-	PrefixSuffixTransformer:        builtins.NewPrefixSuffixTransformerPlugin,
-	ReplacementTransformer:         builtins.NewReplacementTransformerPlugin,
-	ReplicaCountTransformer:        builtins.NewReplicaCountTransformerPlugin,
-	ValueAddTransformer:            builtins.NewValueAddTransformerPlugin,
+	ApiVersionMigrationTransformer:   builtins.NewApiVersionMigrationTransformerPlugin,
+	ApplyHintsTransformer:            builtins.NewApplyHintsTransformerPlugin,
+	AnnotationsTransformer:           builtins.NewAnnotationsTransformerPlugin,
+	ChecksumTransformer:              builtins.NewChecksumTransformerPlugin,
+	HashTransformer:                  builtins.NewHashTransformerPlugin,
+	IngressToGatewayTransformer:      builtins.NewIngressToGatewayTransformerPlugin,
+	ImageTagTransformer:              builtins.NewImageTagTransformerPlugin,
+	LabelTransformer:                 builtins.NewLabelTransformerPlugin,
+	LegacyOrderTransformer:           builtins.NewLegacyOrderTransformerPlugin,
+	NamespaceTransformer:             builtins.NewNamespaceTransformerPlugin,
+	NetworkPolicyBaselineTransformer: builtins.NewNetworkPolicyBaselineTransformerPlugin,
+	OwnershipTransformer:             builtins.NewOwnershipTransformerPlugin,
+	PatchJson6902Transformer:         builtins.NewPatchJson6902TransformerPlugin,
+	PatchStrategicMergeTransformer:   builtins.NewPatchStrategicMergeTransformerPlugin,
+	PatchTransformer:                 builtins.NewPatchTransformerPlugin,
+	PodDisruptionBudgetTransformer:   builtins.NewPodDisruptionBudgetTransformerPlugin,
+	PrefixSuffixTransformer:          builtins.NewPrefixSuffixTransformerPlugin,
+	ReplacementTransformer:           builtins.NewReplacementTransformerPlugin,
+	ReplicaCountTransformer:          builtins.NewReplicaCountTransformerPlugin,
+	ValueAddTransformer:              builtins.NewValueAddTransformerPlugin,
 }
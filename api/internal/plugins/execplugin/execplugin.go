@@ -143,6 +143,11 @@ func (p *ExecPlugin) Transform(rm resmap.ResMap) error {
 	return utils.UpdateResMapValues(p.path, p.h, output, rm)
 }
 
+// TransformsRawDocuments implements resmap.RawDocumentsTransformer:
+// the plugin gets the raw documents, e.g. data it's to read,
+// along with the resources.
+func (p *ExecPlugin) TransformsRawDocuments() {}
+
 // invokePlugin writes plugin config to a temp file, then
 // passes the full temp file path as the first arg to a process
 // running the plugin binary.  Process output is returned.
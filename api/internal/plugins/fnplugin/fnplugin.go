@@ -6,14 +6,20 @@ package fnplugin
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 
 	"sigs.k8s.io/kustomize/api/internal/plugins/utils"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/signature"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kyaml/fn/runtime/runtimeutil"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/sidecar"
 	"sigs.k8s.io/kustomize/kyaml/runfn"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
@@ -82,6 +88,13 @@ func NewFnPlugin(o *types.FnPluginLoadingOptions) *FnPlugin {
 	}
 }
 
+// SetSidecars makes the plugin run a persistent exec
+// function in a process of the pool, reused by the other
+// invocations of the function, rather than in one of its own.
+func (p *FnPlugin) SetSidecars(pool *sidecar.Pool) {
+	p.runFns.Sidecars = pool
+}
+
 // Cfg returns function config
 func (p *FnPlugin) Cfg() []byte {
 	return p.cfg
@@ -105,9 +118,127 @@ func (p *FnPlugin) Config(h *resmap.PluginHelpers, config []byte) error {
 	p.pluginName = fmt.Sprintf("api: %s, kind: %s, name: %s",
 		meta.APIVersion, meta.Kind, meta.Name)
 
+	spec := runtimeutil.GetFunctionSpec(fn)
+	if err = p.errIfForbidden(spec); err != nil {
+		return err
+	}
+	if err = p.rewriteImage(fn, spec); err != nil {
+		return err
+	}
+	return p.verifyImage(fn, spec)
+}
+
+// errIfForbidden errors if the policy forbids
+// running the function of the spec.
+func (p *FnPlugin) errIfForbidden(spec *runtimeutil.FunctionSpec) error {
+	policy := p.h.GeneralConfig().Policy
+	if spec == nil || policy == nil {
+		return nil
+	}
+	root := p.h.Loader().Root()
+	if spec.Container.Image != "" {
+		err := policy.CheckFunction(
+			root, spec.Container.Image, spec.Container.Network)
+		if err != nil {
+			return err
+		}
+	}
+	if spec.Starlark.URL != "" {
+		return policy.CheckURL(root, spec.Starlark.URL)
+	}
 	return nil
 }
 
+// verifyImage errors if the policy requires the image
+// of the function of the spec, configured by fn, to be
+// signed and it isn't, and otherwise has the function run
+// the image by the digest whose signatures were verified.
+func (p *FnPlugin) verifyImage(fn *yaml.RNode, spec *runtimeutil.FunctionSpec) error {
+	policy := p.h.GeneralConfig().Policy
+	if spec == nil || policy == nil || spec.Container.Image == "" {
+		return nil
+	}
+	image, err := signature.Verify(
+		policy, p.h.Loader().Root(), "function image", spec.Container.Image)
+	if err != nil || image == spec.Container.Image {
+		return err
+	}
+	return p.setImage(fn, spec, image)
+}
+
+// rewriteImage has the function of the spec, configured
+// by fn, run the image the rewriter of the plugin config
+// has it run, if any, in place of its own.
+func (p *FnPlugin) rewriteImage(fn *yaml.RNode, spec *runtimeutil.FunctionSpec) error {
+	rewriter := p.h.GeneralConfig().RemoteRefRewriter
+	if spec == nil || rewriter == nil || spec.Container.Image == "" {
+		return nil
+	}
+	ref, err := rewriter.Rewrite(types.RemoteRef{
+		Kind:     types.RemoteRefImage,
+		Referrer: p.h.Loader().Root(),
+		URL:      spec.Container.Image,
+	})
+	if err != nil || ref.URL == spec.Container.Image {
+		return err
+	}
+	return p.setImage(fn, spec, ref.URL)
+}
+
+// setImage has the function of the spec, configured by fn,
+// run the image.
+func (p *FnPlugin) setImage(
+	fn *yaml.RNode, spec *runtimeutil.FunctionSpec, image string) error {
+	if err := setFunctionImage(fn, image); err != nil {
+		return err
+	}
+	spec.Container.Image = image
+	cfg, err := fn.String()
+	p.cfg = []byte(cfg)
+	return err
+}
+
+// setFunctionImage sets the image of the container of the
+// function fn configures, wherever fn specifies it, as
+// runtimeutil.GetFunctionSpec looks for it.
+func setFunctionImage(fn *yaml.RNode, image string) error {
+	annotations, err := fn.GetAnnotations()
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{
+		runtimeutil.FunctionAnnotationKey, "config.k8s.io/function"} {
+		if annotations[key] == "" {
+			continue
+		}
+		spec, err := yaml.Parse(annotations[key])
+		if err != nil {
+			return err
+		}
+		err = spec.PipeE(
+			yaml.LookupCreate(yaml.MappingNode, "container"),
+			yaml.SetField("image", yaml.NewScalarRNode(image)))
+		if err != nil {
+			return err
+		}
+		value, err := spec.String()
+		if err != nil {
+			return err
+		}
+		return fn.PipeE(yaml.SetAnnotation(key, value))
+	}
+	configFn, err := fn.Pipe(yaml.Lookup("metadata", "configFn"))
+	if err != nil {
+		return err
+	}
+	if configFn != nil {
+		return configFn.PipeE(
+			yaml.LookupCreate(yaml.MappingNode, "container"),
+			yaml.SetField("image", yaml.NewScalarRNode(image)))
+	}
+	return fn.PipeE(yaml.SetAnnotation("config.kubernetes.io/container", image))
+}
+
 // Generate is called when run as generator
 func (p *FnPlugin) Generate() (resmap.ResMap, error) {
 	output, err := p.invokePlugin(nil)
@@ -145,6 +276,101 @@ func (p *FnPlugin) Transform(rm resmap.ResMap) error {
 	return utils.UpdateResMapValues(p.pluginName, p.h, output, rm)
 }
 
+// Validate implements resmap.ResultsValidator: the function runs
+// on the resources as a transformer would, but what it reports
+// are the results of its ResourceList, its output being dropped.
+// A function failing because of the errors it reports doesn't
+// fail to validate; what to do about them is for the build to say.
+func (p *FnPlugin) Validate(rm resmap.ResMap) ([]types.ValidationResult, error) {
+	resources, err := rm.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "kustomize-fn-results-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	p.runFns.ResultsDir = dir
+	output, runErr := p.invokePlugin(resources)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "results-0.yaml"))
+	if os.IsNotExist(err) {
+		if runErr != nil {
+			return nil, fmt.Errorf("%v %s", runErr, string(output))
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	results, err := p.parseResults(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the results of %s", p.pluginName)
+	}
+	if runErr != nil && !hasErrorResult(results) {
+		return nil, fmt.Errorf("%v %s", runErr, string(output))
+	}
+	return results, nil
+}
+
+// resultItem is an item of the results of a ResourceList,
+// as a kyaml framework.ResultItem.
+type resultItem struct {
+	Message     string            `yaml:"message,omitempty"`
+	Severity    string            `yaml:"severity,omitempty"`
+	ResourceRef yaml.ResourceMeta `yaml:"resourceRef,omitempty"`
+	Field       struct {
+		Path string `yaml:"path,omitempty"`
+	} `yaml:"field,omitempty"`
+}
+
+// parseResults reads the results of a ResourceList, which
+// are a list of items, or a framework.Result holding them.
+func (p *FnPlugin) parseResults(b []byte) ([]types.ValidationResult, error) {
+	var items []resultItem
+	if err := yaml.Unmarshal(b, &items); err != nil {
+		var result struct {
+			Items []resultItem `yaml:"items,omitempty"`
+		}
+		if err = yaml.Unmarshal(b, &result); err != nil {
+			return nil, err
+		}
+		items = result.Items
+	}
+	var results []types.ValidationResult
+	for _, i := range items {
+		r := types.ValidationResult{
+			Validator: p.pluginName,
+			Severity:  i.Severity,
+			Message:   i.Message,
+			Field:     i.Field.Path,
+		}
+		if ref := i.ResourceRef; ref.Kind != "" || ref.Name != "" {
+			r.Resource = strings.TrimSpace(
+				strings.Join([]string{ref.APIVersion, ref.Kind, ref.Name}, " "))
+			if ref.Namespace != "" {
+				r.Resource += " in namespace " + ref.Namespace
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func hasErrorResult(results []types.ValidationResult) bool {
+	for _, r := range results {
+		if r.Severity == "" || r.Severity == types.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// TransformsRawDocuments implements resmap.RawDocumentsTransformer:
+// the plugin gets the raw documents, e.g. data it's to read,
+// along with the resources.
+func (p *FnPlugin) TransformsRawDocuments() {}
+
 func injectAnnotation(input *yaml.RNode, k, v string) error {
 	err := input.PipeE(yaml.SetAnnotation(k, v))
 	if err != nil {
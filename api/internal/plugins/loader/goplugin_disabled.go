@@ -0,0 +1,29 @@
+// +build !goplugins
+
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/resmap"
+)
+
+// GoPluginsSupported is true if this build can load
+// Go plugins, i.e. was built with the goplugins tag.
+const GoPluginsSupported = false
+
+// loadGoPlugin fails, as loading Go plugins requires cgo and
+// the plugin package, which are only used by builds with the
+// goplugins tag.  Statically compiled plugins may be registered
+// with krusty.Options.RegisterPlugin instead.
+func (l *Loader) loadGoPlugin(id resid.ResId, absPath string) (resmap.Configurable, error) {
+	return nil, fmt.Errorf(
+		"plugin %s: no executable found, and this build of kustomize "+
+			"can't load the Go plugin %s; build kustomize with -tags goplugins "+
+			"to load it, or register the plugin with krusty.Options.RegisterPlugin",
+		relativePluginPath(id), absPath)
+}
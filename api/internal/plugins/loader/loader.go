@@ -5,11 +5,8 @@ package loader
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
-	"plugin"
-	"reflect"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -18,12 +15,12 @@ import (
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinhelpers"
 	"sigs.k8s.io/kustomize/api/internal/plugins/execplugin"
 	"sigs.k8s.io/kustomize/api/internal/plugins/fnplugin"
-	"sigs.k8s.io/kustomize/api/internal/plugins/utils"
 	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/resource"
 	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/sidecar"
 )
 
 // Loader loads plugins using a file loader (a different loader).
@@ -35,6 +32,17 @@ type Loader struct {
 	// absolutePluginHome caches the location of a valid plugin root directory.
 	// It should only be set once the directory's existence has been confirmed.
 	absolutePluginHome string
+
+	// registered holds the factories of statically compiled
+	// plugins that aren't builtins, by kind.
+	registered map[string]func() resmap.Configurable
+
+	// usage, if not nil, counts the plugins loaded.
+	usage *types.UsageReport
+
+	// sidecars, if not nil, holds the processes of the
+	// persistent exec functions of the fn plugins loaded.
+	sidecars *sidecar.Pool
 }
 
 func NewLoader(
@@ -42,6 +50,30 @@ func NewLoader(
 	return &Loader{pc: pc, rf: rf, fs: fs}
 }
 
+// RegisterPlugin makes the loader instantiate plugins of the given
+// kind, whatever their apiVersion, with f instead of looking for
+// them in the plugin home.  Being statically compiled, these are
+// allowed even when only builtin plugins are.
+func (l *Loader) RegisterPlugin(kind string, f func() resmap.Configurable) {
+	if l.registered == nil {
+		l.registered = make(map[string]func() resmap.Configurable)
+	}
+	l.registered[kind] = f
+}
+
+// SetUsageReport makes the loader count the plugins
+// it loads, by builtin kind or plugin type, in r.
+func (l *Loader) SetUsageReport(r *types.UsageReport) {
+	l.usage = r
+}
+
+// SetSidecars makes the fn plugins the loader loads run
+// their persistent exec functions in the processes of the
+// pool, which the caller closes once done with the plugins.
+func (l *Loader) SetSidecars(pool *sidecar.Pool) {
+	l.sidecars = pool
+}
+
 // Config provides the global (not plugin specific) PluginConfig data.
 func (l *Loader) Config() *types.PluginConfig {
 	return l.pc
@@ -159,7 +191,9 @@ func (l *Loader) loadAndConfigurePlugin(
 	ldr ifc.Loader,
 	v ifc.Validator,
 	res *resource.Resource) (c resmap.Configurable, err error) {
-	if isBuiltinPlugin(res) {
+	if f, ok := l.registered[res.GetKind()]; ok {
+		c = f()
+	} else if isBuiltinPlugin(res) {
 		switch l.pc.BpLoadingOptions {
 		case types.BploLoadFromFileSys:
 			c, err = l.loadPlugin(res)
@@ -198,9 +232,35 @@ func (l *Loader) loadAndConfigurePlugin(
 		return nil, errors.Wrapf(
 			err, "plugin %s fails configuration", res.OrgId())
 	}
+	l.recordUsage(res, c)
 	return c, nil
 }
 
+// recordUsage counts the plugin c, loaded for
+// res, in the usage report, if there's one.
+func (l *Loader) recordUsage(res *resource.Resource, c resmap.Configurable) {
+	if l.usage == nil {
+		return
+	}
+	kind := res.GetKind()
+	switch {
+	case l.registered[kind] != nil:
+		l.usage.RecordPlugin(types.UsagePluginRegistered)
+	case isBuiltinPlugin(res) &&
+		builtinhelpers.GetBuiltinPluginType(kind) != builtinhelpers.Unknown:
+		l.usage.RecordBuiltin(kind, 1)
+	default:
+		switch c.(type) {
+		case *fnplugin.FnPlugin:
+			l.usage.RecordPlugin(types.UsagePluginFn)
+		case *execplugin.ExecPlugin:
+			l.usage.RecordPlugin(types.UsagePluginExec)
+		default:
+			l.usage.RecordPlugin(types.UsagePluginGo)
+		}
+	}
+}
+
 func (l *Loader) makeBuiltinPlugin(r resid.Gvk) (resmap.Configurable, error) {
 	bpt := builtinhelpers.GetBuiltinPluginType(r.Kind)
 	if f, ok := builtinhelpers.GeneratorFactories[bpt]; ok {
@@ -215,7 +275,9 @@ func (l *Loader) makeBuiltinPlugin(r resid.Gvk) (resmap.Configurable, error) {
 func (l *Loader) loadPlugin(res *resource.Resource) (resmap.Configurable, error) {
 	spec := fnplugin.GetFunctionSpec(res)
 	if spec != nil {
-		return fnplugin.NewFnPlugin(&l.pc.FnpLoadingOptions), nil
+		p := fnplugin.NewFnPlugin(&l.pc.FnpLoadingOptions)
+		p.SetSidecars(l.sidecars)
+		return p, nil
 	}
 	return l.loadExecOrGoPlugin(res.OrgId())
 }
@@ -248,46 +310,3 @@ func (l *Loader) loadExecOrGoPlugin(resId resid.ResId) (resmap.Configurable, err
 	return c, nil
 }
 
-// registry is a means to avoid trying to load the same .so file
-// into memory more than once, which results in an error.
-// Each test makes its own loader, and tries to load its own plugins,
-// but the loaded .so files are in shared memory, so one will get
-// "this plugin already loaded" errors if the registry is maintained
-// as a Loader instance variable.  So make it a package variable.
-var registry = make(map[string]resmap.Configurable)
-
-func (l *Loader) loadGoPlugin(id resid.ResId, absPath string) (resmap.Configurable, error) {
-	regId := relativePluginPath(id)
-	if c, ok := registry[regId]; ok {
-		return copyPlugin(c), nil
-	}
-	if !utils.FileExists(absPath) {
-		return nil, fmt.Errorf(
-			"expected file with Go object code at: %s", absPath)
-	}
-	log.Printf("Attempting plugin load from '%s'", absPath)
-	p, err := plugin.Open(absPath)
-	if err != nil {
-		return nil, errors.Wrapf(err, "plugin %s fails to load", absPath)
-	}
-	symbol, err := p.Lookup(konfig.PluginSymbol)
-	if err != nil {
-		return nil, errors.Wrapf(
-			err, "plugin %s doesn't have symbol %s",
-			regId, konfig.PluginSymbol)
-	}
-	c, ok := symbol.(resmap.Configurable)
-	if !ok {
-		return nil, fmt.Errorf("plugin '%s' not configurable", regId)
-	}
-	registry[regId] = c
-	return copyPlugin(c), nil
-}
-
-func copyPlugin(c resmap.Configurable) resmap.Configurable {
-	indirect := reflect.Indirect(reflect.ValueOf(c))
-	newIndirect := reflect.New(indirect.Type())
-	newIndirect.Elem().Set(reflect.ValueOf(indirect.Interface()))
-	newNamed := newIndirect.Interface()
-	return newNamed.(resmap.Configurable)
-}
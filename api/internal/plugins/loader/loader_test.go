@@ -1,3 +1,5 @@
+// +build goplugins
+
 // Copyright 2019 The Kubernetes Authors.
 // SPDX-License-Identifier: Apache-2.0
 
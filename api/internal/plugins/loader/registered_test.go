@@ -0,0 +1,51 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+	. "sigs.k8s.io/kustomize/api/internal/plugins/loader"
+	"sigs.k8s.io/kustomize/api/loader"
+	"sigs.k8s.io/kustomize/api/provider"
+	"sigs.k8s.io/kustomize/api/resmap"
+	valtest_test "sigs.k8s.io/kustomize/api/testutils/valtest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+type nopTransformer struct{}
+
+func (p *nopTransformer) Config(*resmap.PluginHelpers, []byte) error { return nil }
+func (p *nopTransformer) Transform(resmap.ResMap) error              { return nil }
+
+func TestLoadRegisteredPlugins(t *testing.T) {
+	rmF := resmap.NewFactory(provider.NewDefaultDepProvider().GetResourceFactory())
+	fsys := filesys.MakeFsInMemory()
+	fLdr, err := loader.NewLoader(loader.RestrictionRootOnly, filesys.Separator, fsys)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	pLdr := NewLoader(types.DisabledPluginConfig(), rmF, fsys)
+	for _, kind := range []string{"NopTransformer", "OtherTransformer"} {
+		pLdr.RegisterPlugin(kind, func() resmap.Configurable {
+			return &nopTransformer{}
+		})
+	}
+
+	for _, kind := range []string{"NopTransformer", "OtherTransformer"} {
+		configs, err := rmF.NewResMapFromBytes([]byte(`
+apiVersion: someteam.example.com/v1
+kind: ` + kind + `
+metadata:
+  name: x
+`))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, err = pLdr.LoadTransformers(fLdr, valtest_test.MakeFakeValidator(), configs)
+		assert.NoError(t, err, kind)
+	}
+}
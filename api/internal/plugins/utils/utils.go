@@ -131,6 +131,10 @@ func FileExists(path string) bool {
 func GetResMapWithIDAnnotation(rm resmap.ResMap) (resmap.ResMap, error) {
 	inputRM := rm.DeepCopy()
 	for _, r := range inputRM.Resources() {
+		if r.IsRaw() {
+			// Passed to the plugin as is.
+			continue
+		}
 		idString, err := yaml.Marshal(r.CurId())
 		if err != nil {
 			return nil, err
@@ -144,10 +148,27 @@ func GetResMapWithIDAnnotation(rm resmap.ResMap) (resmap.ResMap, error) {
 
 // UpdateResMapValues updates the Resource value in the given ResMap
 // with the emitted Resource values in output.
+//
+// If the ResMap holds raw documents (see resource.IsRaw), so may
+// the output, its raw documents taking the place of those of the
+// ResMap in order.
 func UpdateResMapValues(pluginName string, h *resmap.PluginHelpers, output []byte, rm resmap.ResMap) error {
 	mapFactory := h.ResmapFactory()
 	resFactory := mapFactory.RF()
-	resources, err := resFactory.SliceFromBytes(output)
+	var rawNames []string
+	for _, r := range rm.Resources() {
+		if r.IsRaw() {
+			rawNames = append(rawNames, r.GetName())
+		}
+	}
+	var resources []*resource.Resource
+	var err error
+	if rawNames == nil {
+		resources, err = resFactory.SliceFromBytes(output)
+	} else {
+		resources, err = resFactory.SliceFromBytesAllowingRaw(
+			output, outputRawNames(pluginName, rawNames))
+	}
 	if err != nil {
 		return err
 	}
@@ -158,7 +179,9 @@ func UpdateResMapValues(pluginName string, h *resmap.PluginHelpers, output []byt
 	}
 
 	for _, r := range resources {
-		removeIDAnnotation(r) // stale--not manipulated by plugin transformers
+		if !r.IsRaw() {
+			removeIDAnnotation(r) // stale--not manipulated by plugin transformers
+		}
 
 		// Add to the new map, checking for duplicates
 		if err := newMap.Append(r); err != nil {
@@ -194,6 +217,20 @@ func UpdateResMapValues(pluginName string, h *resmap.PluginHelpers, output []byt
 	return nil
 }
 
+// outputRawNames returns the function naming the raw documents
+// of a plugin's output: the n-th gets the name of the n-th of its
+// input, and those beyond them are named after the plugin.
+func outputRawNames(pluginName string, names []string) func(int) string {
+	n := 0
+	return func(int) string {
+		defer func() { n++ }()
+		if n < len(names) {
+			return names[n]
+		}
+		return fmt.Sprintf("%s#%d", pluginName, n)
+	}
+}
+
 func removeIDAnnotation(r *resource.Resource) {
 	// remove the annotation set by Kustomize to track the resource
 	annotations := r.GetAnnotations()
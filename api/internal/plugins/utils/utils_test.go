@@ -110,3 +110,47 @@ func TestUpdateResourceOptionsWithInvalidHashAnnotationValues(t *testing.T) {
 		}
 	}
 }
+
+func TestUpdateResMapValuesWithRawDocuments(t *testing.T) {
+	rf := provider.NewDefaultDepProvider().GetResourceFactory()
+	h := resmap.NewPluginHelpers(nil, nil, resmap.NewFactory(rf), nil)
+	resources, err := rf.SliceFromBytesAllowingRaw([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+---
+regions: [us-east1]
+`), func(i int) string { return fmt.Sprintf("data.yaml#%d", i) })
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	m := resmap.NewFactory(rf).FromResourceSlice(resources)
+
+	err = UpdateResMapValues("fn", h, []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  region: us-east1
+---
+regions: [us-east1, europe-west4]
+---
+zones: 3
+`), m)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var names []string
+	for _, r := range m.Resources() {
+		names = append(names, r.GetName())
+	}
+	assert.Equal(t, []string{"cm", "data.yaml#1", "fn#1"}, names)
+	assert.Equal(t, "regions:\n- us-east1\n- europe-west4\n", m.GetByIndex(1).MustYaml())
+	assert.True(t, m.GetByIndex(2).IsRaw())
+
+	// Without raw documents in, none may come out.
+	m = resmap.NewFactory(rf).FromResourceSlice(resources[:1])
+	assert.Error(t, UpdateResMapValues("fn", h, []byte("zones: 3\n"), m))
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/api/internal/accumulator"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// componentValues returns the resource holding the values
+// to instantiate the component with, or nil if it has none.
+func (kt *KustTarget) componentValues(c types.Component) (*resource.Resource, error) {
+	if !c.HasValues() {
+		return nil, nil
+	}
+	values := make(map[string]interface{})
+	if c.ValuesFile != "" {
+		content, err := kt.ldr.Load(c.ValuesFile)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "loading the valuesFile of component '%s'", c.Path)
+		}
+		if err = yaml.Unmarshal(content, &values); err != nil {
+			return nil, errors.Wrapf(
+				err, "valuesFile '%s' of component '%s' isn't a map of values",
+				c.ValuesFile, c.Path)
+		}
+	}
+	for k, v := range c.ValuesInline {
+		values[k] = v
+	}
+	return kt.rFactory.RF().FromMap(map[string]interface{}{
+		"apiVersion": types.ComponentVersion,
+		"kind":       types.ComponentValuesKind,
+		"metadata": map[string]interface{}{
+			"name": types.ComponentValuesName,
+		},
+		"values": values,
+	}), nil
+}
+
+// swapComponentValues removes the resources holding component
+// values from ra, whatever transformations they went through,
+// returning them, and adds the values, if not nil, so that a
+// component only sees its own values, not those of a component
+// including it.
+func swapComponentValues(
+	ra *accumulator.ResAccumulator, values ...*resource.Resource) ([]*resource.Resource, error) {
+	var removed []*resource.Resource
+	for _, r := range ra.ResMap().Resources() {
+		if r.GetGvk().ApiVersion() != types.ComponentVersion ||
+			r.GetKind() != types.ComponentValuesKind {
+			continue
+		}
+		if err := ra.Remove(r.CurId()); err != nil {
+			return nil, err
+		}
+		removed = append(removed, r)
+	}
+	for _, r := range values {
+		if r == nil {
+			continue
+		}
+		if err := ra.Append(r); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/runtimeutil"
+)
+
+// resolveEnvVars replaces the envVars of the generators of the
+// kustomization by literals holding the variables' values.
+func (kt *KustTarget) resolveEnvVars() error {
+	k := kt.kustomization
+	for i := range k.ConfigMapGenerator {
+		if err := kt.resolveEnvVarSources(
+			&k.ConfigMapGenerator[i].KvPairSources, false); err != nil {
+			return fmt.Errorf(
+				"configMapGenerator %s: %v", k.ConfigMapGenerator[i].Name, err)
+		}
+	}
+	for i := range k.SecretGenerator {
+		if err := kt.resolveEnvVarSources(
+			&k.SecretGenerator[i].KvPairSources, true); err != nil {
+			return fmt.Errorf(
+				"secretGenerator %s: %v", k.SecretGenerator[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (kt *KustTarget) resolveEnvVarSources(
+	s *types.KvPairSources, secret bool) error {
+	for _, source := range s.EnvVarSources {
+		key, name := source, source
+		if i := strings.Index(source, "="); i >= 0 {
+			key, name = source[:i], source[i+1:]
+		}
+		value, err := kt.readEnv(name, secret)
+		if err != nil {
+			return err
+		}
+		s.LiteralSources = append(s.LiteralSources, key+"="+value)
+	}
+	s.EnvVarSources = nil
+	return nil
+}
+
+// errIfUndeclaredFunctionEnvs errors if a function configured
+// by the configs exports an environment variable to its
+// container that the kustomization, if it sets envSources,
+// doesn't declare.
+func (kt *KustTarget) errIfUndeclaredFunctionEnvs(configs resmap.ResMap) error {
+	if kt.kustomization.EnvSources == nil {
+		return nil
+	}
+	for _, r := range configs.Resources() {
+		spec := runtimeutil.GetFunctionSpec(r.AsRNode())
+		if spec == nil {
+			continue
+		}
+		for _, e := range spec.Container.Env {
+			if strings.Contains(e, "=") {
+				continue
+			}
+			if _, err := kt.readEnv(e, true); err != nil {
+				return fmt.Errorf("function %s: %v", r.CurId(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// readEnv returns the value of the environment variable,
+// which the kustomization must declare, recording it, or,
+// if secret, i.e. not to be written to the output, its
+// digest.
+func (kt *KustTarget) readEnv(name string, secret bool) (string, error) {
+	declared := false
+	for _, n := range kt.kustomization.EnvSources {
+		declared = declared || n == name
+	}
+	if !declared {
+		return "", fmt.Errorf(
+			"environment variable %s isn't declared in the envSources "+
+				"of the kustomization in %s", name, kt.ldr.Root())
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf(
+			"environment variable %s, declared in the envSources of the "+
+				"kustomization in %s, isn't set", name, kt.ldr.Root())
+	}
+	if secret {
+		kt.options.EnvCapture.RecordRedacted(name, value)
+	} else {
+		kt.options.EnvCapture.Record(name, value)
+	}
+	return value, nil
+}
@@ -7,17 +7,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/kustomize/api/builtins"
+	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/ifc"
 	"sigs.k8s.io/kustomize/api/internal/accumulator"
+	"sigs.k8s.io/kustomize/api/internal/kusterr"
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinconfig"
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinhelpers"
 	"sigs.k8s.io/kustomize/api/internal/plugins/loader"
 	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kyaml/openapi"
 	"sigs.k8s.io/yaml"
@@ -30,6 +35,28 @@ type KustTarget struct {
 	validator     ifc.Validator
 	rFactory      *resmap.Factory
 	pLdr          *loader.Loader
+	input         resmap.ResMap
+	options       Options
+	// imageFieldSpecs are those of the image transformer
+	// in the last build, including any from configurations.
+	imageFieldSpecs types.FsSlice
+	// nameReferences are the name reference fields of the
+	// last build, including any from configurations.
+	nameReferences []builtinconfig.NameBackReferences
+	// kustFile is the path, in the root of ldr, of the
+	// kustomization file to load, if not one of the
+	// recognized names.
+	kustFile string
+	// parents are the ids (see id) of the targets that loaded
+	// this one, as a base or component, outermost first.
+	parents []string
+	// assertions are those of this kustomization and of the
+	// ones it loaded, checked once the build is done.
+	assertions []pendingAssertion
+	// parentPreservesVolumeClaimTemplates is true if one of the
+	// parents sets statefulSetOptions.preserveVolumeClaimTemplates,
+	// which holds for the bases and components it loads too.
+	parentPreservesVolumeClaimTemplates bool
 }
 
 // NewKustTarget returns a new instance of KustTarget.
@@ -46,9 +73,26 @@ func NewKustTarget(
 	}
 }
 
+// SetKustomizationFile sets the path, in the root of the
+// target's loader, of the kustomization file to load, e.g.
+// other.kustomization.yaml, rather than the one of the
+// recognized names the root has.
+func (kt *KustTarget) SetKustomizationFile(path string) {
+	kt.kustFile = path
+}
+
+// id identifies the target by its root, and the
+// kustomization file in it, if set.
+func (kt *KustTarget) id() string {
+	if kt.kustFile == "" {
+		return kt.ldr.Root()
+	}
+	return filepath.Join(kt.ldr.Root(), kt.kustFile)
+}
+
 // Load attempts to load the target's kustomization file.
 func (kt *KustTarget) Load() error {
-	content, err := loadKustFile(kt.ldr)
+	content, err := kt.loadKustFile()
 	if err != nil {
 		return err
 	}
@@ -57,10 +101,21 @@ func (kt *KustTarget) Load() error {
 		return err
 	}
 	var k types.Kustomization
-	err = k.Unmarshal(content)
+	err = k.UnmarshalWithExtensions(content)
 	if err != nil {
 		return err
 	}
+	if names := k.UnknownFields(); len(names) > 0 {
+		kt.options.Warnings.Add(types.UnknownFieldsWarning(kt.id(), names))
+	}
+	kt.options.Usage.RecordKustomization(&k)
+	if kt.options.StrictDeprecations {
+		if msgs := k.DeprecatedFields(); len(msgs) > 0 {
+			return fmt.Errorf(
+				"deprecated fields in kustomization file under %s:\n  %s",
+				kt.ldr.Root(), strings.Join(msgs, "\n  "))
+		}
+	}
 	k.FixKustomizationPostUnmarshalling()
 	errs := k.EnforceFields()
 	if len(errs) > 0 {
@@ -68,8 +123,29 @@ func (kt *KustTarget) Load() error {
 			"Failed to read kustomization file under %s:\n"+
 				strings.Join(errs, "\n"), kt.ldr.Root())
 	}
+	if k.Requirements != nil && kt.options.CheckRequirements != nil {
+		if err = kt.options.CheckRequirements(k.Requirements, kt.ldr.Root()); err != nil {
+			return err
+		}
+	}
 	kt.kustomization = &k
-	return nil
+	if err = kt.resolveParameters(); err != nil {
+		return err
+	}
+	return kt.resolveEnvVars()
+}
+
+// SetOptions sets the options used to build the target.
+// They're passed on to every target loaded from it.
+func (kt *KustTarget) SetOptions(o Options) {
+	kt.options = o
+}
+
+// SetInput holds resources that don't come from any file
+// (e.g. a stream read from stdin). They are added to the
+// target's resources before any generation or transformation.
+func (kt *KustTarget) SetInput(m resmap.ResMap) {
+	kt.input = m
 }
 
 // Kustomization returns a copy of the immutable, internal kustomization object.
@@ -80,7 +156,11 @@ func (kt *KustTarget) Kustomization() types.Kustomization {
 	return result
 }
 
-func loadKustFile(ldr ifc.Loader) ([]byte, error) {
+func (kt *KustTarget) loadKustFile() ([]byte, error) {
+	ldr := kt.ldr
+	if kt.kustFile != "" {
+		return ldr.Load(kt.kustFile)
+	}
 	var content []byte
 	match := 0
 	for _, kf := range konfig.RecognizedKustomizationFileNames() {
@@ -101,6 +181,13 @@ func loadKustFile(ldr ifc.Loader) ([]byte, error) {
 	}
 }
 
+// ImageFieldSpecs returns the fieldSpecs locating the images
+// changed by the image transformer, as configured by the
+// kustomizations of the last build, or nil before a build.
+func (kt *KustTarget) ImageFieldSpecs() types.FsSlice {
+	return kt.imageFieldSpecs
+}
+
 // MakeCustomizedResMap creates a fully customized ResMap
 // per the instructions contained in its kustomization instance.
 func (kt *KustTarget) MakeCustomizedResMap() (resmap.ResMap, error) {
@@ -108,6 +195,7 @@ func (kt *KustTarget) MakeCustomizedResMap() (resmap.ResMap, error) {
 }
 
 func (kt *KustTarget) makeCustomizedResMap() (resmap.ResMap, error) {
+	kt.assertions = nil
 	ra, err := kt.AccumulateTarget()
 	if err != nil {
 		return nil, err
@@ -116,16 +204,37 @@ func (kt *KustTarget) makeCustomizedResMap() (resmap.ResMap, error) {
 	// The following steps must be done last, not as part of
 	// the recursion implicit in AccumulateTarget.
 
+	kt.imageFieldSpecs = ra.GetTransformerConfig().Images
+	kt.nameReferences = ra.GetTransformerConfig().NameReference
+
 	err = kt.addHashesToNames(ra)
 	if err != nil {
 		return nil, err
 	}
 
+	if kt.preservesVolumeClaimTemplates() {
+		ra.SetTransformerConfig(
+			ra.GetTransformerConfig().WithoutFieldSpecs(
+				types.IsVolumeClaimTemplatesFieldSpec))
+	}
+
 	// Given that names have changed (prefixs/suffixes added),
 	// fix all the back references to those names.
-	err = ra.FixBackReferences()
-	if err != nil {
-		return nil, err
+	if !kt.options.DisableNameReferences {
+		if kt.options.TargetNsReferrals {
+			ns := kt.kustomization.Namespace
+			if ns == "" {
+				ns = resid.DefaultNamespace
+			}
+			ra.SetClusterScopedReferralNamespace(ns)
+		}
+		err = ra.FixBackReferences()
+		if err != nil {
+			return nil, err
+		}
+		if err = kt.snapshot("NameReferenceTransformer", ra); err != nil {
+			return nil, err
+		}
 	}
 
 	// With all the back references fixed, it's OK to resolve Vars.
@@ -133,18 +242,35 @@ func (kt *KustTarget) makeCustomizedResMap() (resmap.ResMap, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(ra.Vars()) > 0 {
+		if err = kt.snapshot("vars", ra); err != nil {
+			return nil, err
+		}
+	}
+
+	err = kt.runAssertions(ra.ResMap())
+	if err != nil {
+		return nil, err
+	}
 
 	return ra.ResMap(), nil
 }
 
 func (kt *KustTarget) addHashesToNames(
 	ra *accumulator.ResAccumulator) error {
+	if kt.options.DisabledBuiltins[builtinhelpers.HashTransformer] {
+		return nil
+	}
 	p := builtins.NewHashTransformerPlugin()
 	err := kt.configureBuiltinPlugin(p, nil, builtinhelpers.HashTransformer)
 	if err != nil {
 		return err
 	}
-	return ra.Transform(p)
+	kt.options.Usage.RecordBuiltin(builtinhelpers.HashTransformer.String(), 1)
+	if err = ra.Transform(p); err != nil {
+		return err
+	}
+	return kt.snapshot(builtinhelpers.HashTransformer.String(), ra)
 }
 
 // AccumulateTarget returns a new ResAccumulator,
@@ -160,14 +286,25 @@ func (kt *KustTarget) AccumulateTarget() (
 // (or empty if the Component does not have a parent).
 func (kt *KustTarget) accumulateTarget(ra *accumulator.ResAccumulator) (
 	resRa *accumulator.ResAccumulator, err error) {
-	ra, err = kt.accumulateResources(ra, kt.kustomization.Resources)
+	ra.SetWarnOnFrozenChanges(kt.options.WarnOnFrozenChanges)
+	kt.startStage(types.StageResources)
+	ra, err = kt.accumulateOwnResources(ra)
 	if err != nil {
 		return nil, errors.Wrap(err, "accumulating resources")
 	}
+	if kt.input != nil {
+		if err = ra.AppendAll(kt.input); err != nil {
+			return nil, errors.Wrap(err, "accumulating input resources")
+		}
+	}
 	ra, err = kt.accumulateComponents(ra, kt.kustomization.Components)
 	if err != nil {
 		return nil, errors.Wrap(err, "accumulating components")
 	}
+	kt.finishStage(types.StageResources, ra)
+	if err = kt.snapshot(types.StageResources, ra); err != nil {
+		return nil, err
+	}
 	tConfig, err := builtinconfig.MakeTransformerConfig(
 		kt.ldr, kt.kustomization.Configurations)
 	if err != nil {
@@ -188,18 +325,25 @@ func (kt *KustTarget) accumulateTarget(ra *accumulator.ResAccumulator) (
 		return nil, errors.Wrapf(
 			err, "merging CRDs %v", crdTc)
 	}
+	kt.startStage(types.StageGenerators)
 	err = kt.runGenerators(ra)
 	if err != nil {
 		return nil, err
 	}
+	kt.finishStage(types.StageGenerators, ra)
+	kt.startStage(types.StageTransformers)
 	err = kt.runTransformers(ra)
 	if err != nil {
 		return nil, err
 	}
+	kt.finishStage(types.StageTransformers, ra)
+	kt.startStage(types.StageValidators)
 	err = kt.runValidators(ra)
 	if err != nil {
 		return nil, err
 	}
+	kt.finishStage(types.StageValidators, ra)
+	kt.collectAssertions(ra)
 	err = ra.MergeVars(kt.kustomization.Vars)
 	if err != nil {
 		return nil, errors.Wrapf(
@@ -208,6 +352,49 @@ func (kt *KustTarget) accumulateTarget(ra *accumulator.ResAccumulator) (
 	return ra, nil
 }
 
+// startStage reports that the target started the stage.
+func (kt *KustTarget) startStage(stage string) {
+	kt.options.Progress.Report(types.ProgressEvent{
+		Kind:  types.ProgressStageStarted,
+		Stage: stage,
+		Dir:   kt.ldr.Root(),
+	})
+}
+
+// finishStage reports that the target finished the stage,
+// with the resources accumulated in ra.
+func (kt *KustTarget) finishStage(stage string, ra *accumulator.ResAccumulator) {
+	if kt.options.Progress == nil {
+		return
+	}
+	kt.options.Progress.Report(types.ProgressEvent{
+		Kind:      types.ProgressStageFinished,
+		Stage:     stage,
+		Dir:       kt.ldr.Root(),
+		Resources: ra.ResMap().Size(),
+	})
+}
+
+// snapshot passes the resources of ra,
+// after the step, to the Snapshot option.
+func (kt *KustTarget) snapshot(step string, ra *accumulator.ResAccumulator) error {
+	if kt.options.Snapshot == nil {
+		return nil
+	}
+	return kt.options.Snapshot(kt.ldr.Root(), step, ra.ResMap())
+}
+
+// pluginName returns the name of the plugin's step in
+// snapshots: the kind of a builtin, e.g. PatchTransformer,
+// or else its type, e.g. FnPlugin.
+func pluginName(p interface{}) string {
+	n := fmt.Sprintf("%T", p)
+	if strings.HasPrefix(n, "*builtins.") {
+		return strings.TrimSuffix(strings.TrimPrefix(n, "*builtins."), "Plugin")
+	}
+	return n[strings.LastIndex(n, ".")+1:]
+}
+
 func (kt *KustTarget) runGenerators(
 	ra *accumulator.ResAccumulator) error {
 	var generators []resmap.Generator
@@ -230,6 +417,9 @@ func (kt *KustTarget) runGenerators(
 		if err != nil {
 			return errors.Wrapf(err, "merging from generator %v", g)
 		}
+		if err = kt.snapshot(pluginName(g), ra); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -247,16 +437,25 @@ func (kt *KustTarget) configureExternalGenerators() ([]resmap.Generator, error)
 		}
 		ra.AppendAll(rm)
 	}
-	ra, err := kt.accumulateResources(ra, generatorPaths)
+	ra, err := kt.accumulateResources(ra, generatorPaths, nil)
 	if err != nil {
 		return nil, err
 	}
+	if err = kt.errIfDisabledBuiltinConfigs(ra.ResMap()); err != nil {
+		return nil, err
+	}
+	if err = kt.errIfUndeclaredFunctionEnvs(ra.ResMap()); err != nil {
+		return nil, err
+	}
 	return kt.pLdr.LoadGenerators(kt.ldr, kt.validator, ra.ResMap())
 }
 
 func (kt *KustTarget) runTransformers(ra *accumulator.ResAccumulator) error {
 	var r []resmap.Transformer
 	tConfig := ra.GetTransformerConfig()
+	if kt.preservesVolumeClaimTemplates() {
+		tConfig = tConfig.WithoutFieldSpecs(types.IsVolumeClaimTemplatesFieldSpec)
+	}
 	lts, err := kt.configureBuiltinTransformers(tConfig)
 	if err != nil {
 		return err
@@ -267,7 +466,19 @@ func (kt *KustTarget) runTransformers(ra *accumulator.ResAccumulator) error {
 		return err
 	}
 	r = append(r, lts...)
-	return ra.Transform(newMultiTransformer(r))
+	mt := newMultiTransformer(r)
+	if kt.options.Snapshot != nil {
+		mt.after = func(t resmap.Transformer, m resmap.ResMap) error {
+			return kt.options.Snapshot(kt.ldr.Root(), pluginName(t), m)
+		}
+	}
+	return ra.Transform(mt)
+}
+
+func (kt *KustTarget) preservesVolumeClaimTemplates() bool {
+	o := kt.kustomization.StatefulSetOptions
+	return kt.parentPreservesVolumeClaimTemplates ||
+		(o != nil && o.PreserveVolumeClaimTemplates)
 }
 
 func (kt *KustTarget) configureExternalTransformers(transformers []string) ([]resmap.Transformer, error) {
@@ -283,62 +494,51 @@ func (kt *KustTarget) configureExternalTransformers(transformers []string) ([]re
 		}
 		ra.AppendAll(rm)
 	}
-	ra, err := kt.accumulateResources(ra, transformerPaths)
+	ra, err := kt.accumulateResources(ra, transformerPaths, nil)
 
 	if err != nil {
 		return nil, err
 	}
-	return kt.pLdr.LoadTransformers(kt.ldr, kt.validator, ra.ResMap())
-}
-
-func (kt *KustTarget) runValidators(ra *accumulator.ResAccumulator) error {
-	validators, err := kt.configureExternalTransformers(kt.kustomization.Validators)
-	if err != nil {
-		return err
-	}
-	for _, v := range validators {
-		// Validators shouldn't modify the resource map
-		orignal := ra.ResMap().DeepCopy()
-		err = v.Transform(ra.ResMap())
-		if err != nil {
-			return err
-		}
-		new := ra.ResMap().DeepCopy()
-		kt.removeValidatedByLabel(new)
-		if err = orignal.ErrorIfNotEqualSets(new); err != nil {
-			return fmt.Errorf("validator shouldn't modify the resource map: %v", err)
-		}
+	if err = kt.errIfDisabledBuiltinConfigs(ra.ResMap()); err != nil {
+		return nil, err
 	}
-	return nil
-}
-
-func (kt *KustTarget) removeValidatedByLabel(rm resmap.ResMap) {
-	resources := rm.Resources()
-	for _, r := range resources {
-		labels := r.GetLabels()
-		if _, found := labels[konfig.ValidatedByLabelKey]; !found {
-			continue
-		}
-		delete(labels, konfig.ValidatedByLabelKey)
-		if len(labels) == 0 {
-			r.SetLabels(nil)
-		} else {
-			r.SetLabels(labels)
-		}
+	if err = kt.errIfUndeclaredFunctionEnvs(ra.ResMap()); err != nil {
+		return nil, err
 	}
+	return kt.pLdr.LoadTransformers(kt.ldr, kt.validator, ra.ResMap())
 }
 
 // accumulateResources fills the given resourceAccumulator
-// with resources read from the given list of paths.
+// with resources read from the given list of paths, reading
+// the directories of the given filters through them.
 func (kt *KustTarget) accumulateResources(
-	ra *accumulator.ResAccumulator, paths []string) (*accumulator.ResAccumulator, error) {
+	ra *accumulator.ResAccumulator, paths []string,
+	filters []types.ResourceFilter) (*accumulator.ResAccumulator, error) {
 	for _, path := range paths {
+		if f, ok := findResourceFilter(filters, path); ok {
+			if err := kt.accumulateFilteredDirectory(ra, f); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if konfig.IsNamedKustomizationFile(path) {
+			var err error
+			if ra, err = kt.accumulateKustomizationFile(ra, path); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		// try loading resource as file then as base (directory or git repository)
 		if errF := kt.accumulateFile(ra, path); errF != nil {
 			ldr, err := kt.ldr.New(path)
 			if err != nil {
-				return nil, errors.Wrapf(
-					err, "accumulation err='%s'", errF.Error())
+				err = errors.Wrapf(err, "accumulation err='%s'", errF.Error())
+				var infra *kusterr.InfrastructureError
+				if errors.As(errF, &infra) {
+					// The file couldn't be fetched, rather than isn't a file.
+					return nil, kusterr.WithCause(err, errF)
+				}
+				return nil, err
 			}
 			ra, err = kt.accumulateDirectory(ra, ldr, false)
 			if err != nil {
@@ -350,29 +550,164 @@ func (kt *KustTarget) accumulateResources(
 	return ra, nil
 }
 
-// accumulateResources fills the given resourceAccumulator
-// with resources read from the given list of paths.
-func (kt *KustTarget) accumulateComponents(
-	ra *accumulator.ResAccumulator, paths []string) (*accumulator.ResAccumulator, error) {
+// accumulateFilteredDirectory accumulates the files of the
+// directory of the filter that its globs include and don't
+// exclude, in order of path.  Kustomization files among them
+// are left out: the directory isn't built, just read.
+func (kt *KustTarget) accumulateFilteredDirectory(
+	ra *accumulator.ResAccumulator, r types.ResourceFilter) error {
+	ldr, err := kt.ldr.New(r.Path)
+	if err != nil {
+		return errors.Wrapf(err, "loading the filtered directory '%s'", r.Path)
+	}
+	defer ldr.Cleanup()
+	includes := r.Include
+	if len(includes) == 0 {
+		includes = types.DefaultResourceIncludes
+	}
+	for _, pattern := range append(includes, r.Exclude...) {
+		if _, err = filepath.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "the glob '%s' of resource '%s'", pattern, r.Path)
+		}
+	}
+	g, ok := ldr.(ifc.Globber)
+	if !ok {
+		return fmt.Errorf(
+			"resource '%s' has a filter, but its loader can't expand globs", r.Path)
+	}
+	var paths []string
+	seen := make(map[string]bool)
+	for _, pattern := range includes {
+		matches, err := g.Glob(pattern)
+		if err != nil {
+			return errors.Wrapf(err, "the glob '%s' of resource '%s'", pattern, r.Path)
+		}
+		for _, m := range matches {
+			if !seen[m] && !isExcluded(m, r.Exclude) &&
+				!konfig.IsKustomizationFile(m) {
+				seen[m] = true
+				paths = append(paths, m)
+			}
+		}
+	}
+	sort.Strings(paths)
+	sub := *kt
+	sub.ldr = ldr
 	for _, path := range paths {
+		if err = sub.accumulateFile(ra, path); err != nil {
+			return errors.Wrapf(err, "in the filtered directory '%s'", r.Path)
+		}
+	}
+	return nil
+}
+
+func findResourceFilter(
+	filters []types.ResourceFilter, path string) (types.ResourceFilter, bool) {
+	for _, f := range filters {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return types.ResourceFilter{}, false
+}
+
+// isExcluded returns whether path, or its base name, matches
+// any of the globs, which are valid.
+func isExcluded(path string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(g, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// accumulateComponents fills the given resourceAccumulator
+// with resources read from the given list of components,
+// instantiated with their values.
+func (kt *KustTarget) accumulateComponents(
+	ra *accumulator.ResAccumulator, components []types.Component) (*accumulator.ResAccumulator, error) {
+	for _, c := range components {
+		values, err := kt.componentValues(c)
+		if err != nil {
+			return nil, err
+		}
 		// Components always refer to directories
-		ldr, errL := kt.ldr.New(path)
+		ldr, errL := kt.ldr.New(c.Path)
 		if errL != nil {
-			return nil, fmt.Errorf("loader.New %q", errL)
+			return nil, kusterr.WithCause(
+				fmt.Errorf("loader.New %q", errL), errL)
+		}
+		outer, err := swapComponentValues(ra, values)
+		if err != nil {
+			return nil, err
 		}
 		var errD error
 		ra, errD = kt.accumulateDirectory(ra, ldr, true)
 		if errD != nil {
-			return nil, fmt.Errorf("accumulateDirectory: %q", errD)
+			return nil, kusterr.WithCause(
+				fmt.Errorf("accumulateDirectory: %q", errD), errD)
+		}
+		if _, err = swapComponentValues(ra, outer...); err != nil {
+			return nil, err
+		}
+	}
+	return ra, nil
+}
+
+// accumulateKustomizationFile accumulates the kustomization of
+// the file at path, e.g. other.kustomization.yaml, as a base;
+// the file may be in the root of this target.
+func (kt *KustTarget) accumulateKustomizationFile(
+	ra *accumulator.ResAccumulator, path string) (*accumulator.ResAccumulator, error) {
+	dir, file := filepath.Split(path)
+	ldr := kt.ldr
+	if dir = filepath.Clean(dir); dir != filesys.SelfDir {
+		var err error
+		if ldr, err = kt.ldr.New(dir); err != nil {
+			return nil, errors.Wrapf(err, "loading kustomization file '%s'", path)
 		}
 	}
+	ra, err := kt.accumulateKustomization(ra, ldr, file, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "accumulating kustomization file '%s'", path)
+	}
 	return ra, nil
 }
 
 func (kt *KustTarget) accumulateDirectory(
 	ra *accumulator.ResAccumulator, ldr ifc.Loader, isComponent bool) (*accumulator.ResAccumulator, error) {
-	defer ldr.Cleanup()
+	return kt.accumulateKustomization(ra, ldr, "", isComponent)
+}
+
+// accumulateKustomization accumulates the target of the
+// kustomization file in the root of ldr, kustFile if set,
+// cleaning ldr up, unless it's this target's.
+func (kt *KustTarget) accumulateKustomization(
+	ra *accumulator.ResAccumulator, ldr ifc.Loader, kustFile string,
+	isComponent bool) (*accumulator.ResAccumulator, error) {
+	if ldr != kt.ldr {
+		defer ldr.Cleanup()
+	}
 	subKt := NewKustTarget(ldr, kt.validator, kt.rFactory, kt.pLdr)
+	subKt.SetOptions(kt.options)
+	subKt.SetKustomizationFile(kustFile)
+	subKt.parents = append(kt.parents[:len(kt.parents):len(kt.parents)], kt.id())
+	subKt.parentPreservesVolumeClaimTemplates = kt.preservesVolumeClaimTemplates()
+	for _, p := range subKt.parents {
+		if p == subKt.id() {
+			return nil, fmt.Errorf("cycle detected: %s",
+				strings.Join(append(subKt.parents, subKt.id()), " -> "))
+		}
+	}
+	if max := kt.options.MaxDepth; max > 0 && len(subKt.parents) > max {
+		return nil, fmt.Errorf(
+			"bases and components nest deeper than the maximum depth of %d, in %s",
+			max, strings.Join(append(subKt.parents, subKt.id()), " -> "))
+	}
 	err := subKt.Load()
 	if err != nil {
 		return nil, errors.Wrapf(
@@ -386,9 +721,15 @@ func (kt *KustTarget) accumulateDirectory(
 			return nil, err
 		}
 	}
-	err = openapi.SetSchema(subKt.Kustomization().OpenAPI, bytes, false)
-	if err != nil {
-		return nil, err
+	if openAPI := subKt.Kustomization().OpenAPI; len(openAPI) > 0 {
+		if kt.options.UseBaseSchema != nil {
+			err = kt.options.UseBaseSchema(openAPI, bytes)
+		} else {
+			err = openapi.SetSchema(openAPI, bytes, false)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 	if isComponent && subKt.kustomization.Kind != types.ComponentKind {
 		return nil, fmt.Errorf(
@@ -412,7 +753,14 @@ func (kt *KustTarget) accumulateDirectory(
 		return nil, errors.Wrapf(
 			err, "recursed accumulation of path '%s'", ldr.Root())
 	}
-	err = ra.MergeAccumulator(subRa)
+	kt.assertions = append(kt.assertions, subKt.assertions...)
+	if kt.kustomization.DeduplicateIdenticalResources {
+		var dropped map[*resource.Resource]*resource.Resource
+		dropped, err = ra.MergeAccumulatorDeduplicating(subRa)
+		kt.rescopeAssertions(dropped)
+	} else {
+		err = ra.MergeAccumulator(subRa)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(
 			err, "recursed merging from path '%s'", ldr.Root())
@@ -422,7 +770,13 @@ func (kt *KustTarget) accumulateDirectory(
 
 func (kt *KustTarget) accumulateFile(
 	ra *accumulator.ResAccumulator, path string) error {
-	resources, err := kt.rFactory.FromFile(kt.ldr, path)
+	var resources resmap.ResMap
+	var err error
+	if kt.options.AllowNonKRM {
+		resources, err = kt.rFactory.FromFileAllowingRaw(kt.ldr, path)
+	} else {
+		resources, err = kt.rFactory.FromFile(kt.ldr, path)
+	}
 	if err != nil {
 		return errors.Wrapf(err, "accumulating resources from '%s'", path)
 	}
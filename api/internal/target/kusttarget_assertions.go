@@ -0,0 +1,237 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/internal/accumulator"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// pendingAssertion is an assertion of a kustomization of
+// the build, checked once the build is done.
+type pendingAssertion struct {
+	types.Assertion
+	// root is the root of the kustomization declaring it.
+	root string
+	// scope holds the resources of the kustomization declaring
+	// it, for a base to check only its own, or nil to check
+	// every resource of the build.
+	scope map[*resource.Resource]bool
+}
+
+// collectAssertions saves the kustomization's assertions, to
+// be checked against the resources built once all the
+// transformations of the build, including those of the
+// kustomizations using this one, are done.
+func (kt *KustTarget) collectAssertions(ra *accumulator.ResAccumulator) {
+	if len(kt.kustomization.Assertions) == 0 {
+		return
+	}
+	var scope map[*resource.Resource]bool
+	if len(kt.parents) > 0 {
+		scope = make(map[*resource.Resource]bool)
+		for _, r := range ra.ResMap().Resources() {
+			scope[r] = true
+		}
+	}
+	for _, a := range kt.kustomization.Assertions {
+		kt.assertions = append(kt.assertions,
+			pendingAssertion{Assertion: a, root: kt.ldr.Root(), scope: scope})
+	}
+}
+
+// rescopeAssertions puts in the scopes of the assertions the
+// resources that those of their scopes dropped as duplicates
+// were merged into, for the copies kept to be checked.
+func (kt *KustTarget) rescopeAssertions(
+	dropped map[*resource.Resource]*resource.Resource) {
+	for _, a := range kt.assertions {
+		for r, kept := range dropped {
+			if a.scope[r] {
+				a.scope[kept] = true
+			}
+		}
+	}
+}
+
+// runAssertions checks the assertions of the kustomizations
+// of the build against the resources built, returning an
+// error listing every resource that violates the first
+// assertion failing.
+func (kt *KustTarget) runAssertions(m resmap.ResMap) error {
+	for _, a := range kt.assertions {
+		violations, err := checkAssertion(a, m)
+		if err != nil {
+			return err
+		}
+		if len(violations) == 0 {
+			continue
+		}
+		name := a.Name
+		if name == "" {
+			name = a.FieldPath
+		}
+		return fmt.Errorf(
+			"assertion '%s' of kustomization '%s' failed:\n  %s",
+			name, a.root, strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+func checkAssertion(
+	a pendingAssertion, m resmap.ResMap) ([]string, error) {
+	if a.FieldPath == "" {
+		return nil, fmt.Errorf("assertion '%s' must specify a fieldPath", a.Name)
+	}
+	var re *regexp.Regexp
+	if a.Matches != "" {
+		var err error
+		if re, err = regexp.Compile(a.Matches); err != nil {
+			return nil, fmt.Errorf(
+				"assertion '%s' has an invalid regular expression: %v", a.Name, err)
+		}
+	}
+	resources := m.Resources()
+	if a.Select != nil {
+		var err error
+		if resources, err = m.Select(*a.Select); err != nil {
+			return nil, err
+		}
+	}
+	path, err := yaml.SplitFieldPath(a.FieldPath)
+	if err != nil {
+		return nil, fmt.Errorf("assertion '%s': %v", a.Name, err)
+	}
+	var violations []string
+	for _, r := range resources {
+		if a.scope != nil && !a.scope[r] {
+			continue
+		}
+		found, missing := lookupFields(r.Node(), path, "")
+		var problems []string
+		for _, p := range missing {
+			problems = append(problems, p+" is missing")
+		}
+		for _, f := range found {
+			v := valueOf(f.node)
+			switch {
+			case a.Equals != "" && v != a.Equals:
+				problems = append(problems,
+					fmt.Sprintf("%s is '%s', not '%s'", f.path, v, a.Equals))
+			case re != nil && !re.MatchString(v):
+				problems = append(problems,
+					fmt.Sprintf("%s is '%s', which doesn't match '%s'", f.path, v, a.Matches))
+			}
+		}
+		for _, p := range problems {
+			violations = append(violations, describe(r)+": "+p)
+		}
+	}
+	return violations, nil
+}
+
+// describe identifies a resource by its current id and,
+// if it was renamed or moved, by the id it was loaded with,
+// followed by the file it was loaded from, if any.
+func describe(r *resource.Resource) string {
+	s := r.CurId().String()
+	if !r.OrgId().Equals(r.CurId()) {
+		s += fmt.Sprintf(" (originally %s)", r.OrgId())
+	}
+	if origin := r.Origin(); origin != "" {
+		s += fmt.Sprintf(" from '%s'", origin)
+	}
+	return s
+}
+
+type fieldAt struct {
+	path string
+	node *yaml.RNode
+}
+
+// lookupFields follows path from n, returning the fields found
+// and the paths of those that are missing, each relative to n
+// and prefixed by at.
+func lookupFields(
+	n *yaml.RNode, path []string, at string) (found []fieldAt, missing []string) {
+	if len(path) == 0 {
+		return []fieldAt{{path: at, node: n}}, nil
+	}
+	p := path[0]
+	switch {
+	case p == "*" || yaml.IsListIndex(p):
+		if n.YNode().Kind != yaml.SequenceNode {
+			return nil, []string{join(at, p)}
+		}
+		var field, value string
+		if p != "*" {
+			var err error
+			if field, value, err = yaml.SplitIndexNameValue(p); err != nil {
+				return nil, []string{join(at, p)}
+			}
+		}
+		matched := false
+		for i, elem := range n.YNode().Content {
+			e := yaml.NewRNode(elem)
+			if field != "" {
+				f := e.Field(field)
+				if f == nil || valueOf(f.Value) != value {
+					continue
+				}
+			}
+			matched = true
+			fs, ms := lookupFields(e, path[1:], fmt.Sprintf("%s[%d]", at, i))
+			found = append(found, fs...)
+			missing = append(missing, ms...)
+		}
+		if !matched && field != "" {
+			missing = append(missing, join(at, p))
+		}
+		return found, missing
+	case n.YNode().Kind == yaml.SequenceNode && yaml.IsListEntryPart(p):
+		// A list index, e.g. 0, or - for the last entry.
+		entries := n.YNode().Content
+		i := len(entries) - 1
+		if p != "-" {
+			i, _ = strconv.Atoi(p)
+		}
+		if i < 0 || i >= len(entries) {
+			return nil, []string{join(at, p)}
+		}
+		return lookupFields(
+			yaml.NewRNode(entries[i]), path[1:], fmt.Sprintf("%s[%d]", at, i))
+	default:
+		if n.YNode().Kind != yaml.MappingNode {
+			return nil, []string{join(at, p)}
+		}
+		f := n.Field(p)
+		if f == nil || yaml.IsMissingOrNull(f.Value) {
+			return nil, []string{join(at, p)}
+		}
+		return lookupFields(f.Value, path[1:], join(at, p))
+	}
+}
+
+func join(at, p string) string {
+	if at == "" {
+		return p
+	}
+	return at + "." + p
+}
+
+func valueOf(n *yaml.RNode) string {
+	if n.YNode().Kind == yaml.ScalarNode {
+		return n.YNode().Value
+	}
+	s, _ := n.String()
+	return strings.TrimSpace(s)
+}
@@ -5,9 +5,15 @@ package target
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/kustomize/api/ifc"
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinconfig"
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinhelpers"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/types"
 )
@@ -32,6 +38,7 @@ func (kt *KustTarget) configureBuiltinGenerators() (
 	for _, bpt := range []builtinhelpers.BuiltinPluginType{
 		builtinhelpers.ConfigMapGenerator,
 		builtinhelpers.SecretGenerator,
+		builtinhelpers.TLSSecretGenerator,
 		builtinhelpers.HelmChartInflationGenerator,
 	} {
 		r, err := generatorConfigurators[bpt](
@@ -39,6 +46,10 @@ func (kt *KustTarget) configureBuiltinGenerators() (
 		if err != nil {
 			return nil, err
 		}
+		if len(r) > 0 && kt.options.DisabledBuiltins[bpt] {
+			return nil, kt.errDisabledBuiltin(bpt)
+		}
+		kt.options.Usage.RecordBuiltin(bpt.String(), len(r))
 		result = append(result, r...)
 	}
 	return result, nil
@@ -64,11 +75,77 @@ func (kt *KustTarget) configureBuiltinTransformers(
 		if err != nil {
 			return nil, err
 		}
+		if kt.options.DisabledBuiltins[bpt] {
+			if kt.effectiveInstances(bpt, len(r)) > 0 {
+				return nil, kt.errDisabledBuiltin(bpt)
+			}
+			continue
+		}
+		kt.options.Usage.RecordBuiltin(
+			bpt.String(), kt.effectiveInstances(bpt, len(r)))
+		if bpt == builtinhelpers.PatchTransformer {
+			r = newPatchTransformers(r, kt.options.MaxParallelism)
+		}
 		result = append(result, r...)
 	}
 	return result, nil
 }
 
+// effectiveInstances returns how many of the n instances of bpt
+// configured from the kustomization do something, as the
+// configurators of the builtins applying fields such as namePrefix
+// return an instance even if the kustomization doesn't set them.
+func (kt *KustTarget) effectiveInstances(
+	bpt builtinhelpers.BuiltinPluginType, n int) int {
+	k := kt.kustomization
+	switch bpt {
+	case builtinhelpers.NamespaceTransformer:
+		if k.Namespace == "" {
+			return 0
+		}
+	case builtinhelpers.PrefixSuffixTransformer:
+		if k.NamePrefix == "" && k.NameSuffix == "" {
+			return 0
+		}
+	case builtinhelpers.AnnotationsTransformer:
+		if len(k.CommonAnnotations) == 0 {
+			return 0
+		}
+	case builtinhelpers.ReplacementTransformer:
+		if len(k.Replacements) == 0 {
+			return 0
+		}
+	case builtinhelpers.LabelTransformer:
+		// One instance applies commonLabels, the others labels.
+		if len(k.CommonLabels) == 0 && n > 0 {
+			return n - 1
+		}
+	}
+	return n
+}
+
+// errIfDisabledBuiltinConfigs returns an error if one
+// of the plugin configs is of a disabled builtin.
+func (kt *KustTarget) errIfDisabledBuiltinConfigs(configs resmap.ResMap) error {
+	for _, r := range configs.Resources() {
+		gvk := r.GetGvk()
+		if gvk.Group != "" || gvk.Version != konfig.BuiltinPluginApiVersion {
+			continue
+		}
+		bpt := builtinhelpers.GetBuiltinPluginType(gvk.Kind)
+		if kt.options.DisabledBuiltins[bpt] {
+			return kt.errDisabledBuiltin(bpt)
+		}
+	}
+	return nil
+}
+
+func (kt *KustTarget) errDisabledBuiltin(bpt builtinhelpers.BuiltinPluginType) error {
+	return fmt.Errorf(
+		"kustomization in '%s' requires the builtin %s, which is disabled",
+		kt.ldr.Root(), bpt)
+}
+
 type gFactory func() resmap.GeneratorPlugin
 
 var generatorConfigurators = map[builtinhelpers.BuiltinPluginType]func(
@@ -94,6 +171,25 @@ var generatorConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 		return
 	},
 
+	builtinhelpers.TLSSecretGenerator: func(kt *KustTarget, bpt builtinhelpers.BuiltinPluginType, f gFactory) (
+		result []resmap.Generator, err error) {
+		var c struct {
+			types.TLSSecretArgs
+		}
+		for _, args := range kt.kustomization.TLSSecretGenerator {
+			c.TLSSecretArgs = args
+			c.TLSSecretArgs.Options = types.MergeGlobalOptionsIntoLocal(
+				c.TLSSecretArgs.Options, kt.kustomization.GeneratorOptions)
+			p := f()
+			err := kt.configureBuiltinPlugin(p, c, bpt)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, p)
+		}
+		return
+	},
+
 	builtinhelpers.ConfigMapGenerator: func(kt *KustTarget, bpt builtinhelpers.BuiltinPluginType, f gFactory) (
 		result []resmap.Generator, err error) {
 		var c struct {
@@ -137,6 +233,29 @@ var generatorConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 	},
 }
 
+// expandPatchPath returns the patch file paths denoted
+// by the given path, which may be a glob.  A path that isn't
+// a glob (including the empty path of an inline patch)
+// is returned as is.
+func (kt *KustTarget) expandPatchPath(path string) ([]string, error) {
+	if !strings.ContainsAny(path, "*?[") {
+		return []string{path}, nil
+	}
+	g, ok := kt.ldr.(ifc.Globber)
+	if !ok {
+		return nil, fmt.Errorf(
+			"patch path '%s' is a glob, but its loader can't expand globs", path)
+	}
+	paths, err := g.Glob(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "expanding patch path '%s'", path)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("patch path '%s' matches no files", path)
+	}
+	return paths, nil
+}
+
 type tFactory func() resmap.TransformerPlugin
 
 var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
@@ -150,9 +269,15 @@ var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 		var c struct {
 			types.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 			FieldSpecs       []types.FieldSpec
+			ClusterScoped    []resid.Gvk
+			Exclude          []types.Selector
 		}
 		c.Namespace = kt.kustomization.Namespace
 		c.FieldSpecs = tc.NameSpace
+		c.ClusterScoped = tc.ClusterScoped
+		if o := kt.kustomization.NamespaceOptions; o != nil {
+			c.Exclude = o.Exclude
+		}
 		p := f()
 		err = kt.configureBuiltinPlugin(p, c, bpt)
 		if err != nil {
@@ -190,9 +315,11 @@ var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 			return
 		}
 		var c struct {
-			Paths []types.PatchStrategicMerge `json:"paths,omitempty" yaml:"paths,omitempty"`
+			Paths        []types.PatchStrategicMerge `json:"paths,omitempty" yaml:"paths,omitempty"`
+			KeepComments bool                        `json:"keepComments,omitempty" yaml:"keepComments,omitempty"`
 		}
 		c.Paths = kt.kustomization.PatchesStrategicMerge
+		c.KeepComments = kt.options.KeepComments
 		p := f()
 		err = kt.configureBuiltinPlugin(p, c, bpt)
 		if err != nil {
@@ -208,22 +335,37 @@ var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 			return
 		}
 		var c struct {
-			Path    string          `json:"path,omitempty" yaml:"path,omitempty"`
-			Patch   string          `json:"patch,omitempty" yaml:"patch,omitempty"`
-			Target  *types.Selector `json:"target,omitempty" yaml:"target,omitempty"`
-			Options map[string]bool `json:"options,omitempty" yaml:"options,omitempty"`
+			Path       string           `json:"path,omitempty" yaml:"path,omitempty"`
+			Patch      string           `json:"patch,omitempty" yaml:"patch,omitempty"`
+			Target     *types.Selector  `json:"target,omitempty" yaml:"target,omitempty"`
+			Options    map[string]bool  `json:"options,omitempty" yaml:"options,omitempty"`
+			MergeLists types.MergeLists `json:"mergeLists,omitempty" yaml:"mergeLists,omitempty"`
+			Delete     []string         `json:"delete,omitempty" yaml:"delete,omitempty"`
+			DataKey    string           `json:"dataKey,omitempty" yaml:"dataKey,omitempty"`
+
+			KeepComments bool `json:"keepComments,omitempty" yaml:"keepComments,omitempty"`
 		}
+		c.KeepComments = kt.options.KeepComments
 		for _, pc := range kt.kustomization.Patches {
-			c.Target = pc.Target
-			c.Patch = pc.Patch
-			c.Path = pc.Path
-			c.Options = pc.Options
-			p := f()
-			err = kt.configureBuiltinPlugin(p, c, bpt)
+			paths, err := kt.expandPatchPath(pc.Path)
 			if err != nil {
 				return nil, err
 			}
-			result = append(result, p)
+			for _, path := range paths {
+				c.Target = pc.Target
+				c.Patch = pc.Patch
+				c.Path = path
+				c.Options = pc.Options
+				c.MergeLists = pc.MergeLists
+				c.Delete = pc.Delete
+				c.DataKey = pc.DataKey
+				p := f()
+				err = kt.configureBuiltinPlugin(p, c, bpt)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, p)
+			}
 		}
 		return
 	},
@@ -258,9 +400,11 @@ var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 		var c struct {
 			Labels     map[string]string
 			FieldSpecs []types.FieldSpec
+			Options    *types.MetadataOptions
 		}
 		c.Labels = kt.kustomization.CommonLabels
 		c.FieldSpecs = tc.CommonLabels
+		c.Options = kt.kustomization.CommonLabelsOptions
 		p := f()
 		err = kt.configureBuiltinPlugin(p, c, bpt)
 		if err != nil {
@@ -275,9 +419,11 @@ var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 		var c struct {
 			Annotations map[string]string
 			FieldSpecs  []types.FieldSpec
+			Options     *types.MetadataOptions
 		}
 		c.Annotations = kt.kustomization.CommonAnnotations
 		c.FieldSpecs = tc.CommonAnnotations
+		c.Options = kt.kustomization.CommonAnnotationsOptions
 		p := f()
 		err = kt.configureBuiltinPlugin(p, c, bpt)
 		if err != nil {
@@ -309,8 +455,9 @@ var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 		kt *KustTarget, bpt builtinhelpers.BuiltinPluginType, f tFactory, tc *builtinconfig.TransformerConfig) (
 		result []resmap.Transformer, err error) {
 		var c struct {
-			ImageTag   types.Image
-			FieldSpecs []types.FieldSpec
+			ImageTag        types.Image
+			RegistryMirrors map[string]string
+			FieldSpecs      []types.FieldSpec
 		}
 		for _, args := range kt.kustomization.Images {
 			c.ImageTag = args
@@ -322,15 +469,38 @@ var transformerConfigurators = map[builtinhelpers.BuiltinPluginType]func(
 			}
 			result = append(result, p)
 		}
+		if len(kt.kustomization.RegistryMirrors) > 0 {
+			// Mirror after the images are changed,
+			// as their new names may need mirroring.
+			c.ImageTag = types.Image{}
+			c.RegistryMirrors = kt.kustomization.RegistryMirrors
+			c.FieldSpecs = tc.Images
+			p := f()
+			err = kt.configureBuiltinPlugin(p, c, bpt)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, p)
+		}
 		return
 	},
 	builtinhelpers.ReplacementTransformer: func(
-		kt *KustTarget, bpt builtinhelpers.BuiltinPluginType, f tFactory, _ *builtinconfig.TransformerConfig) (
+		kt *KustTarget, bpt builtinhelpers.BuiltinPluginType, f tFactory, tc *builtinconfig.TransformerConfig) (
 		result []resmap.Transformer, err error) {
 		var c struct {
-			Replacements []types.ReplacementField
+			Replacements         []types.ReplacementField
+			LogCreatedFields     bool
+			StrictGeneratedNames bool
+			NameReferences       []builtinconfig.NameBackReferences
 		}
 		c.Replacements = kt.kustomization.Replacements
+		c.LogCreatedFields = kt.options.LogCreatedFields
+		// Names only get hash suffixes if the HashTransformer runs.
+		c.StrictGeneratedNames = kt.options.StrictGeneratedNames &&
+			!kt.options.DisabledBuiltins[builtinhelpers.HashTransformer]
+		if c.StrictGeneratedNames {
+			c.NameReferences = tc.NameReference
+		}
 		p := f()
 		err = kt.configureBuiltinPlugin(p, c, bpt)
 		if err != nil {
@@ -0,0 +1,121 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/kustomize/api/filters/fieldspec"
+	"sigs.k8s.io/kustomize/api/internal/accumulator"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// accumulateOwnResources accumulates the resources of the
+// kustomization.  Those of the kustomization built record the
+// entry they come from as their source, and the entries the
+// SkipSource option returns true for are left out.
+func (kt *KustTarget) accumulateOwnResources(
+	ra *accumulator.ResAccumulator) (*accumulator.ResAccumulator, error) {
+	k := kt.kustomization
+	if len(kt.parents) > 0 {
+		return kt.accumulateResources(ra, k.Resources, k.ResourceFilters)
+	}
+	skip := kt.options.SkipSource
+	if len(k.Assertions) > 0 || len(k.Validators) > 0 {
+		skip = nil
+	}
+	for _, entry := range k.Resources {
+		if skip != nil && skip(entry) {
+			continue
+		}
+		n := ra.ResMap().Size()
+		var err error
+		ra, err = kt.accumulateResources(ra, []string{entry}, k.ResourceFilters)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range ra.ResMap().Resources()[n:] {
+			r.SetSource(entry)
+		}
+	}
+	return ra, nil
+}
+
+// UnresolvedReferences returns the references of the resources,
+// through the name reference fields of the last build, to
+// resources that m lacks, e.g. to a ConfigMap left out with its
+// source, as "ConfigMap cfg".  Their names weren't fixed, so the
+// resources may differ from those of a build with the source.
+func (kt *KustTarget) UnresolvedReferences(
+	resources []*resource.Resource, m resmap.ResMap) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, nbr := range kt.nameReferences {
+		names := make(map[string]bool)
+		for _, r := range m.Resources() {
+			if r.GetGvk().IsSelected(&nbr.Gvk) {
+				names[r.GetName()] = true
+			}
+		}
+		for _, fs := range nbr.Referrers {
+			fs.CreateIfNotPresent = false
+			for _, r := range resources {
+				if r.IsRaw() {
+					continue
+				}
+				var referred []string
+				_, err := fieldspec.Filter{
+					FieldSpec: fs,
+					SetValue: func(n *yaml.RNode) error {
+						referred = append(referred, referredNames(n, nbr.Kind)...)
+						return nil
+					},
+				}.Filter(r.Node())
+				if err != nil {
+					return nil, fmt.Errorf(
+						"looking up the references of %s: %v", r.CurId(), err)
+				}
+				for _, name := range referred {
+					ref := nbr.Kind + " " + name
+					if !names[name] && !seen[ref] {
+						seen[ref] = true
+						result = append(result, ref)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// referredNames returns the names of resources of the kind
+// that a name reference field holds: its value, or the names of
+// the entries of a list, or of a map, unless of another kind, as
+// the subjects of a RoleBinding may be.
+func referredNames(n *yaml.RNode, kind string) []string {
+	switch n.YNode().Kind {
+	case yaml.ScalarNode:
+		if v := n.YNode().Value; v != "" {
+			return []string{v}
+		}
+	case yaml.SequenceNode:
+		var result []string
+		for _, elem := range n.YNode().Content {
+			result = append(result, referredNames(yaml.NewRNode(elem), kind)...)
+		}
+		return result
+	case yaml.MappingNode:
+		if k := n.Field("kind"); k != nil && k.Value.YNode().Value != kind {
+			return nil
+		}
+		if f := n.Field("name"); f != nil && f.Value.YNode().Kind == yaml.ScalarNode {
+			return referredNames(f.Value, kind)
+		}
+	}
+	return nil
+}
@@ -10,12 +10,15 @@ import (
 // multiTransformer contains a list of transformers.
 type multiTransformer struct {
 	transformers []resmap.Transformer
+	// after, if not nil, is called after each transformer
+	// with the resources it transformed.
+	after func(t resmap.Transformer, m resmap.ResMap) error
 }
 
-var _ resmap.Transformer = &multiTransformer{}
+var _ resmap.RawDocumentsTransformer = &multiTransformer{}
 
 // newMultiTransformer constructs a multiTransformer.
-func newMultiTransformer(t []resmap.Transformer) resmap.Transformer {
+func newMultiTransformer(t []resmap.Transformer) *multiTransformer {
 	r := &multiTransformer{
 		transformers: make([]resmap.Transformer, len(t)),
 	}
@@ -27,10 +30,19 @@ func newMultiTransformer(t []resmap.Transformer) resmap.Transformer {
 // optionally detecting and erroring on commutation conflict.
 func (o *multiTransformer) Transform(m resmap.ResMap) error {
 	for _, t := range o.transformers {
-		if err := t.Transform(m); err != nil {
+		if err := resmap.TransformResources(m, t); err != nil {
 			return err
 		}
 		m.DropEmpties()
+		if o.after != nil {
+			if err := o.after(t, m); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
+
+// TransformsRawDocuments implements resmap.RawDocumentsTransformer,
+// leaving it to each member whether it sees the raw documents.
+func (o *multiTransformer) TransformsRawDocuments() {}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"sigs.k8s.io/kustomize/api/internal/plugins/builtinhelpers"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// Options holds settings that affect how a KustTarget, and
+// every target it loads (bases, components), is built.
+type Options struct {
+	// When true, loading a kustomization that uses
+	// deprecated fields is an error rather than allowed.
+	StrictDeprecations bool
+
+	// DisabledBuiltins holds the builtin plugins not to run.
+	// Loading a kustomization that needs one is an error,
+	// other than for the HashTransformer, whose disabling
+	// just leaves the names of generated objects unhashed.
+	DisabledBuiltins map[builtinhelpers.BuiltinPluginType]bool
+
+	// When true, references to objects whose names change
+	// during the build, e.g. by a namePrefix, are left as is.
+	DisableNameReferences bool
+
+	// When true, references from cluster-scoped objects, e.g.
+	// the subjects of ClusterRoleBindings, that name no namespace
+	// follow only the renamed objects of the namespace of the
+	// kustomization built, rather than of any namespace.
+	TargetNsReferrals bool
+
+	// When true, a transformer changing a resource annotated
+	// as frozen is only warned about, and the change reverted.
+	WarnOnFrozenChanges bool
+
+	// When true, the fields replacements create, per
+	// options.create, rather than update, are logged.
+	LogCreatedFields bool
+
+	// When true, strategic merge patches copy their comments
+	// to the fields of their targets that have none.
+	KeepComments bool
+
+	// When true, replacements copying the name of a generated
+	// resource before it gets its hash suffix fail the build.
+	StrictGeneratedNames bool
+
+	// When true, the documents of resource files that aren't
+	// Kubernetes resources are passed through as raw ones.
+	AllowNonKRM bool
+
+	// MaxDepth, if above 0, is how deeply bases and
+	// components may nest; one loaded by the kustomization
+	// built is at depth 1.
+	MaxDepth int
+
+	// MaxParallelism is how many of the patches of a
+	// kustomization may be applied at once; below 2,
+	// they're applied one after the other.
+	MaxParallelism int
+
+	// Usage, if not nil, records the features the build uses.
+	Usage *types.UsageReport
+
+	// Warnings, if not nil, collects the warnings of the build.
+	Warnings *types.Warnings
+
+	// Progress, if not nil, is reported the stages
+	// of the builds of the target and what it loads.
+	Progress types.ProgressFunc
+
+	// Snapshot, if not nil, is called with the resources of
+	// the target, and of what it loads, once they're
+	// accumulated and after each generator and transformer
+	// runs, with the step, e.g. resources or PatchTransformer.
+	Snapshot func(dir, step string, m resmap.ResMap) error
+
+	// EnvCapture, if not nil, records the environment variables
+	// read through the envSources of kustomizations.
+	EnvCapture *types.EnvCapture
+
+	// CheckRequirements, if not nil, is called with the
+	// requirements of the target, and of the targets it
+	// loads, and their roots; an error fails their loading.
+	CheckRequirements func(r *types.Requirements, dir string) error
+
+	// UseBaseSchema, if not nil, is called with the openapi field
+	// of each base or component specifying one, and the custom
+	// schema it loads, if any, to select it, unless the build
+	// uses another already; if nil, the schema is set directly.
+	UseBaseSchema func(openAPI map[string]string, custom []byte) error
+
+	// SkipSource, if not nil, is called with the entries of the
+	// resources of the target built, not of those it loads, and
+	// those it returns true for are left out, neither read nor
+	// fetched.  It's not called if the kustomization has assertions
+	// or validators, which check every resource.
+	SkipSource func(entry string) bool
+
+	// Parameters holds the values of the build parameters,
+	// by name, which placeholders such as ${TENANT} in the
+	// namespace, namePrefix and commonLabels values of
+	// kustomizations refer to.  If nil, the placeholders
+	// are left as is.
+	Parameters map[string]string
+}
@@ -0,0 +1,200 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// resourcePatcher is a patch, e.g. a PatchTransformer,
+// that may be applied to each of its targets on its own.
+type resourcePatcher interface {
+	resmap.Transformer
+	// Targets returns the resources of m the patch applies to.
+	Targets(m resmap.ResMap) ([]*resource.Resource, error)
+	// PatchResource applies the patch to one of its targets,
+	// only reading the patch.
+	PatchResource(res *resource.Resource) error
+}
+
+// parallelPatchTransformer applies patches, in as many
+// goroutines as its parallelism, to the effect of applying
+// them one after the other.
+//
+// Each patch selects its targets before any is applied, and
+// the patches of a resource are applied to a copy of it, in
+// order, by one goroutine, so that resources are only written
+// by one goroutine at a time.  Patches changing what the
+// patches select, e.g. by renaming resources, are detected
+// and fail the transformation, as applying them one after
+// the other could have given another result.
+type parallelPatchTransformer struct {
+	patches     []resourcePatcher
+	parallelism int
+}
+
+// newPatchTransformers returns the transformers applying the
+// patches, applying them in parallel if parallelism is above
+// one and all of them allow it.
+func newPatchTransformers(
+	patches []resmap.Transformer, parallelism int) []resmap.Transformer {
+	if parallelism < 2 || len(patches) < 2 {
+		return patches
+	}
+	var patchers []resourcePatcher
+	for _, t := range patches {
+		p, ok := t.(resourcePatcher)
+		if !ok {
+			return patches
+		}
+		patchers = append(patchers, p)
+	}
+	return []resmap.Transformer{
+		&parallelPatchTransformer{patches: patchers, parallelism: parallelism}}
+}
+
+// patchedResource is a resource, the patches selecting
+// it, and the copy of it they're applied to.
+type patchedResource struct {
+	res     *resource.Resource
+	patches []resourcePatcher
+	result  *resource.Resource
+	err     error
+}
+
+func (pr *patchedResource) patch() {
+	pr.result = pr.res.DeepCopy()
+	for i, p := range pr.patches {
+		if pr.result.IsEmpty() {
+			pr.err = fmt.Errorf(
+				"a patch deletes %s, which %d later patches select, so the "+
+					"patches cannot be applied in parallel; set a max parallelism of 1",
+				pr.res.CurId(), len(pr.patches)-i)
+			return
+		}
+		if pr.err = p.PatchResource(pr.result); pr.err != nil {
+			return
+		}
+	}
+}
+
+func (pr *patchedResource) commit() error {
+	return pr.res.ApplyFilter(kio.FilterFunc(
+		func(nodes []*kyaml.RNode) ([]*kyaml.RNode, error) {
+			if pr.result.IsEmpty() {
+				return nil, nil
+			}
+			nodes[0].SetYNode(pr.result.Node().YNode())
+			return nodes, nil
+		}))
+}
+
+// Transform implements resmap.Transformer.
+func (t *parallelPatchTransformer) Transform(m resmap.ResMap) error {
+	selected := make([][]*resource.Resource, len(t.patches))
+	byResource := make(map[*resource.Resource]*patchedResource)
+	var patched []*patchedResource
+	for i, p := range t.patches {
+		targets, err := p.Targets(m)
+		if err != nil {
+			return err
+		}
+		selected[i] = targets
+		for _, res := range targets {
+			pr, ok := byResource[res]
+			if !ok {
+				pr = &patchedResource{res: res}
+				byResource[res] = pr
+				patched = append(patched, pr)
+			}
+			pr.patches = append(pr.patches, p)
+		}
+	}
+	t.run(patched)
+	for _, pr := range patched {
+		if pr.err != nil {
+			return pr.err
+		}
+	}
+	for _, pr := range patched {
+		if err := pr.commit(); err != nil {
+			return err
+		}
+	}
+	m.DropEmpties()
+	for _, pr := range patched {
+		if pr.res.IsEmpty() {
+			continue
+		}
+		if _, err := m.GetByCurrentId(pr.res.CurId()); err != nil {
+			return fmt.Errorf("patches produce an ID conflict: %v", err)
+		}
+	}
+	return t.checkSelections(m, selected)
+}
+
+// run patches the resources in parallel.
+func (t *parallelPatchTransformer) run(patched []*patchedResource) {
+	next := make(chan *patchedResource)
+	var wg sync.WaitGroup
+	for i := 0; i < t.parallelism && i < len(patched); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pr := range next {
+				pr.patch()
+			}
+		}()
+	}
+	for _, pr := range patched {
+		next <- pr
+	}
+	close(next)
+	wg.Wait()
+}
+
+// checkSelections errors if a patch doesn't select the same
+// resources, but those deleted, as it did before the patches
+// were applied.
+func (t *parallelPatchTransformer) checkSelections(
+	m resmap.ResMap, selected [][]*resource.Resource) error {
+	for i, p := range t.patches {
+		var before []*resource.Resource
+		for _, res := range selected[i] {
+			if !res.IsEmpty() {
+				before = append(before, res)
+			}
+		}
+		after, err := p.Targets(m)
+		if err != nil && len(before) == 0 {
+			// The patch's only target is deleted.
+			continue
+		}
+		if err != nil || !sameResources(before, after) {
+			return fmt.Errorf(
+				"patches change which resources patch %d selects, so they "+
+					"cannot be applied in parallel; set a max parallelism of 1",
+				i+1)
+		}
+	}
+	return nil
+}
+
+func sameResources(a, b []*resource.Resource) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"regexp"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// placeholder matches the placeholders of build parameters,
+// e.g. ${TENANT}, and what looks like one, e.g. ${}.
+var placeholder = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// parameterName matches the names of build parameters.
+var parameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// resolveParameters replaces the placeholders of build
+// parameters in the namespace, namePrefix and commonLabels
+// values of the kustomization by the parameters' values.
+// Without parameters, they're left as is, e.g. for envsubst
+// or the postBuild substitutions of Flux to expand later.
+func (kt *KustTarget) resolveParameters() error {
+	if kt.options.Parameters == nil {
+		return nil
+	}
+	k := kt.kustomization
+	var err error
+	if k.Namespace, err = kt.resolvePlaceholders("namespace", k.Namespace); err != nil {
+		return err
+	}
+	if k.NamePrefix, err = kt.resolvePlaceholders("namePrefix", k.NamePrefix); err != nil {
+		return err
+	}
+	for _, key := range yaml.SortedMapKeys(k.CommonLabels) {
+		if k.CommonLabels[key], err = kt.resolvePlaceholders(
+			"commonLabels."+key, k.CommonLabels[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePlaceholders returns the value of the field with
+// its placeholders replaced, erroring if a parameter one
+// refers to isn't set.
+func (kt *KustTarget) resolvePlaceholders(field, value string) (string, error) {
+	var err error
+	result := placeholder.ReplaceAllStringFunc(value, func(p string) string {
+		name := placeholder.FindStringSubmatch(p)[1]
+		if err != nil {
+			return p
+		}
+		if !parameterName.MatchString(name) {
+			err = fmt.Errorf(
+				"the %s of the kustomization in %s holds %s, "+
+					"and %q isn't the name of a build parameter",
+				field, kt.ldr.Root(), p, name)
+			return p
+		}
+		v, ok := kt.options.Parameters[name]
+		if !ok {
+			err = fmt.Errorf(
+				"the %s of the kustomization in %s refers to the "+
+					"build parameter %s, which isn't set",
+				field, kt.ldr.Root(), name)
+			return p
+		}
+		return v
+	})
+	return result, err
+}
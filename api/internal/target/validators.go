@@ -0,0 +1,79 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/internal/accumulator"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// runValidators runs the validators of the kustomization, each
+// on a copy of the resources, so that they're left as they are.
+// The results validators report are failed on, warned about or
+// ignored per the validatorsOptions of the kustomization; other
+// validators fail the build by erring, or by modifying the copy.
+func (kt *KustTarget) runValidators(ra *accumulator.ResAccumulator) error {
+	opts := kt.kustomization.ValidatorsOptions
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("validatorsOptions: %v", err)
+	}
+	validators, err := kt.configureExternalTransformers(kt.kustomization.Validators)
+	if err != nil {
+		return err
+	}
+	var failures []string
+	for _, v := range validators {
+		m := ra.ResMap().DeepCopy()
+		rv, ok := v.(resmap.ResultsValidator)
+		if !ok {
+			if err = resmap.TransformResources(m, v); err != nil {
+				return err
+			}
+			kt.removeValidatedByLabel(m)
+			if err = ra.ResMap().ErrorIfNotEqualSets(m); err != nil {
+				return fmt.Errorf("validator shouldn't modify the resource map: %v", err)
+			}
+			continue
+		}
+		results, err := rv.Validate(m)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			switch opts.ActionFor(r.Severity) {
+			case types.ResultFail:
+				failures = append(failures, r.String())
+			case types.ResultWarn:
+				log.Printf("warning: validating %s: %s", kt.ldr.Root(), r)
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("validating %s failed:\n%s",
+			kt.ldr.Root(), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func (kt *KustTarget) removeValidatedByLabel(rm resmap.ResMap) {
+	resources := rm.Resources()
+	for _, r := range resources {
+		labels := r.GetLabels()
+		if _, found := labels[konfig.ValidatedByLabelKey]; !found {
+			continue
+		}
+		delete(labels, konfig.ValidatedByLabelKey)
+		if len(labels) == 0 {
+			r.SetLabels(nil)
+		} else {
+			r.SetLabels(labels)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package konfig
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	// Name of environment variable used to set the
+	// directory that DefaultCacheHome returns.
+	KustomizeCacheHomeEnv = "KUSTOMIZE_CACHE_HOME"
+
+	// Relative path below the cache home of the
+	// clones of remote repositories kept between runs.
+	RelClonesCacheHome = "clones"
+)
+
+// DefaultCacheHome returns the directory kustomize keeps the
+// files it caches between runs in: $KUSTOMIZE_CACHE_HOME, if
+// set, else the kustomize directory of the user's cache
+// directory, e.g. $XDG_CACHE_HOME/kustomize.
+func DefaultCacheHome() string {
+	if dir := os.Getenv(KustomizeCacheHomeEnv); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, ProgramName)
+	}
+	return filepath.Join(HomeDir(), ".cache", ProgramName)
+}
@@ -3,6 +3,11 @@
 
 package konfig
 
+import (
+	"path/filepath"
+	"strings"
+)
+
 // RecognizedKustomizationFileNames is a list of file names
 // that kustomize recognizes.
 // To avoid ambiguity, a kustomization directory may not
@@ -19,6 +24,35 @@ func DefaultKustomizationFileName() string {
 	return RecognizedKustomizationFileNames()[0]
 }
 
+// IsKustomizationFile returns whether the base of the path is a
+// recognized kustomization file name, or one with a prefix (see
+// IsNamedKustomizationFile), i.e. whether the path names a file
+// a kustomization may be in, rather than one of its resources.
+func IsKustomizationFile(path string) bool {
+	base := filepath.Base(path)
+	for _, n := range RecognizedKustomizationFileNames() {
+		if base == n {
+			return true
+		}
+	}
+	return IsNamedKustomizationFile(path)
+}
+
+// IsNamedKustomizationFile returns whether the base of the path
+// is a recognized kustomization file name with a prefix ended by
+// a dot, e.g. other.kustomization.yaml, which a kustomization's
+// resources may name, for several kustomizations to share a
+// directory.
+func IsNamedKustomizationFile(path string) bool {
+	base := filepath.Base(path)
+	for _, n := range RecognizedKustomizationFileNames() {
+		if len(base) > len(n)+1 && strings.HasSuffix(base, "."+n) {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	// An environment variable to consult for kustomization
 	// configuration data.  See:
@@ -37,6 +71,22 @@ const (
 	// If a resource has this annotation, kustomize will drop it.
 	IgnoredByKustomizeAnnotation = ConfigAnnoDomain + "/local-config"
 
+	// If a resource has this annotation set to "true", transformers
+	// may not change it: they must leave it as it was loaded or
+	// generated.  Kustomize removes the annotation from its output.
+	FrozenAnnotation = "kustomize.config.k8s.io/frozen"
+
+	// A ConfigMap generated with options.applyReplacements lists,
+	// in this annotation, the keys of its file sources, which
+	// replacements setting options.token write into.  Kustomize
+	// removes the annotation from its output.
+	ReplacementFilesAnnotation = ConfigAnnoDomain + "/replacementFiles"
+
+	// A replacement source setting options.onMultipleMatches to
+	// latest reads the resource it selects of the latest RFC 3339
+	// time in this annotation.
+	CreationTimestampAnnotation = ConfigAnnoDomain + "/creationTimestamp"
+
 	// Label key that indicates the resources are built from Kustomize
 	ManagedbyLabelKey = "app.kubernetes.io/managed-by"
 
@@ -0,0 +1,286 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
+)
+
+func TestApplyDefaultsOfCRDs(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("crd.yaml", crontabsCRD)
+	th.WriteF("crontab.yaml", `
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+spec:
+  schedule: "0 0 * * *"
+  jobs:
+  - image: backup
+  - image: report
+    pullPolicy: Always
+`)
+	th.WriteK(".", `
+resources:
+- crd.yaml
+- crontab.yaml
+`)
+	opts := th.MakeDefaultOptions()
+	opts.ApplyDefaults = true
+	opts.Experimental = understanding(krusty.ExperimentalDefaults)
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, crontabsCRD+`
+---
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+spec:
+  jobs:
+  - image: backup
+    pullPolicy: IfNotPresent
+  - image: report
+    pullPolicy: Always
+  retry:
+    limit: 3
+  schedule: 0 0 * * *
+  suspend: false
+`)
+	m = th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, crontabsCRD+`
+---
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+spec:
+  jobs:
+  - image: backup
+  - image: report
+    pullPolicy: Always
+  schedule: 0 0 * * *
+`)
+}
+
+func TestStripDefaults(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("crd.yaml", crontabsCRD)
+	th.WriteF("crontab.yaml", `
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+spec:
+  schedule: "0 0 * * *"
+  jobs:
+  - image: backup
+    pullPolicy: IfNotPresent
+  retry:
+    limit: 3
+  suspend: false
+`)
+	th.WriteK(".", `
+resources:
+- crd.yaml
+- crontab.yaml
+`)
+	opts := th.MakeDefaultOptions()
+	opts.StripDefaults = true
+	opts.Experimental = understanding(krusty.ExperimentalDefaults)
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, crontabsCRD+`
+---
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+spec:
+  jobs:
+  - image: backup
+  schedule: 0 0 * * *
+`)
+	opts.KeepDefaultsOf = []string{"CronTab"}
+	m = th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, crontabsCRD+`
+---
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+spec:
+  jobs:
+  - image: backup
+    pullPolicy: IfNotPresent
+  retry:
+    limit: 3
+  schedule: 0 0 * * *
+  suspend: false
+`)
+	opts.ApplyDefaults = true
+	err := th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "defaults can't be both applied and stripped")
+	}
+}
+
+const crontabsCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  names:
+    kind: CronTab
+    plural: crontabs
+  scope: Namespaced
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        properties:
+          spec:
+            properties:
+              jobs:
+                items:
+                  properties:
+                    image:
+                      type: string
+                    pullPolicy:
+                      default: IfNotPresent
+                      type: string
+                  type: object
+                type: array
+              retry:
+                default: {}
+                properties:
+                  limit:
+                    default: 3
+                    type: integer
+                type: object
+              schedule:
+                type: string
+              suspend:
+                default: false
+                type: boolean
+            type: object
+        type: object
+    served: true
+    storage: true`
+
+func TestApplyDefaultsOfCustomSchema(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("schema.json", `{
+  "definitions": {
+    "io.k8s.api.apps.v1.Deployment": {
+      "type": "object",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.api.apps.v1.DeploymentSpec"}
+      },
+      "x-kubernetes-group-version-kind": [
+        {"group": "apps", "kind": "Deployment", "version": "v1"}
+      ]
+    },
+    "io.k8s.api.apps.v1.DeploymentSpec": {
+      "type": "object",
+      "properties": {
+        "replicas": {"type": "integer", "default": 1},
+        "revisionHistoryLimit": {"type": "integer", "default": 10}
+      }
+    }
+  }
+}`)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+`)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+openapi:
+  path: schema.json
+`)
+	openapi.ResetOpenAPI()
+	defer func() {
+		// Put the builtin schema back for the tests that follow.
+		assert.NoError(t, openapi.SetSchema(
+			map[string]string{"version": kubernetesapi.DefaultOpenAPI}, nil, true))
+		openapi.ResetOpenAPI()
+	}()
+	opts := th.MakeDefaultOptions()
+	opts.ApplyDefaults = true
+	opts.Experimental = understanding(krusty.ExperimentalDefaults)
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  revisionHistoryLimit: 10
+`)
+}
+
+func TestApplyDefaultsOfBuiltinKindsWarned(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("crd.yaml", crontabsCRD)
+	th.WriteF("resources.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`)
+	th.WriteK(".", `
+resources:
+- crd.yaml
+- resources.yaml
+`)
+	opts := th.MakeDefaultOptions()
+	opts.ApplyDefaults = true
+	opts.Experimental = understanding(krusty.ExperimentalDefaults)
+	opts.Warnings = &types.Warnings{}
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, crontabsCRD+`
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`)
+	if assert.Len(t, opts.Warnings.List, 1) {
+		assert.Equal(t,
+			"warning: .: leaving the defaults of CustomResourceDefinition, "+
+				"Deployment, Service as is, as only those of CRDs and custom "+
+				"schemas are known, not those the API server sets for builtin kinds",
+			opts.Warnings.List[0].String())
+	}
+}
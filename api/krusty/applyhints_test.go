@@ -0,0 +1,174 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestApplyHints(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("base/webhook.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: webhook
+spec:
+  template:
+    metadata:
+      labels:
+        app: webhook
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: webhook
+spec:
+  selector:
+    app: webhook
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validating
+webhooks:
+- name: validate.example.com
+  clientConfig:
+    service:
+      name: webhook
+      namespace: default
+`)
+	th.WriteK("base", `
+resources:
+- webhook.yaml
+applyHints:
+  customResources: true
+`)
+	th.WriteK("prod", `
+resources:
+- ../base
+namespace: system
+namePrefix: prod-
+applyHints:
+  webhooks: true
+`)
+	m := th.Run("prod", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-webhook
+  namespace: system
+spec:
+  template:
+    metadata:
+      labels:
+        app: webhook
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: prod-webhook
+  namespace: system
+spec:
+  selector:
+    app: webhook
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  annotations:
+    config.kubernetes.io/depends-on: apps/namespaces/system/Deployment/prod-webhook
+  name: prod-validating
+webhooks:
+- clientConfig:
+    service:
+      name: prod-webhook
+      namespace: system
+  name: validate.example.com
+`)
+	// The hints of a base aren't used.
+	m = th.Run("base", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: webhook
+spec:
+  template:
+    metadata:
+      labels:
+        app: webhook
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: webhook
+spec:
+  selector:
+    app: webhook
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validating
+webhooks:
+- clientConfig:
+    service:
+      name: webhook
+      namespace: default
+  name: validate.example.com
+`)
+}
+
+func TestApplyHintsCustomResources(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("crd.yaml", `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  names:
+    kind: CronTab
+    plural: crontabs
+  scope: Namespaced
+`)
+	th.WriteF("crontab.yaml", `
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+`)
+	th.WriteK(".", `
+resources:
+- crd.yaml
+- crontab.yaml
+applyHints:
+  customResources: true
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  names:
+    kind: CronTab
+    plural: crontabs
+  scope: Namespaced
+---
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  annotations:
+    config.kubernetes.io/depends-on: apiextensions.k8s.io/CustomResourceDefinition/crontabs.stable.example.com
+  name: nightly
+`)
+}
@@ -0,0 +1,229 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeAssertionsBase(th kusttest_test.Harness) {
+	th.WriteF("base/deployments.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+        resources:
+          limits:
+            memory: 128Mi
+      - name: sidecar
+        image: envoy
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  template:
+    spec:
+      containers:
+      - name: worker
+        image: busybox:1.33
+        resources:
+          limits:
+            memory: 64Mi
+`)
+	th.WriteK("base", `
+resources:
+- deployments.yaml
+`)
+}
+
+func TestAssertionsPass(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeAssertionsBase(th)
+	th.WriteK("overlay", `
+resources:
+- ../base
+namePrefix: prod-
+assertions:
+- name: images are pinned
+  select:
+    name: web
+  fieldPath: spec.template.spec.containers.[name=web].image
+  matches: ':[0-9.]+$'
+- fieldPath: metadata.name
+  select:
+    name: web
+  equals: prod-web
+- name: the sidecar comes last
+  select:
+    name: web
+  fieldPath: spec.template.spec.containers.-.name
+  equals: sidecar
+- name: the workload comes first
+  fieldPath: spec.template.spec.containers.0.resources.limits.memory
+  matches: '^[0-9]+Mi$'
+`)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	if m.Size() != 2 {
+		t.Fatalf("unexpected resources: %v", m.AllIds())
+	}
+}
+
+func TestAssertionsFail(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeAssertionsBase(th)
+	th.WriteK("overlay", `
+resources:
+- ../base
+namePrefix: prod-
+assertions:
+- name: every container has limits
+  select:
+    kind: Deployment
+  fieldPath: spec.template.spec.containers.*.resources.limits
+- name: replicas are set
+  fieldPath: spec.replicas
+`)
+	err := th.RunWithErr("overlay", th.MakeDefaultOptions())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	expected := `assertion 'every container has limits' of kustomization '/overlay' failed:
+  apps_v1_Deployment|~X|prod-web (originally apps_v1_Deployment|default|web) ` +
+		`from '/base/deployments.yaml': ` +
+		`spec.template.spec.containers[1].resources is missing`
+	if err.Error() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, err.Error())
+	}
+}
+
+func TestAssertionsOfBaseCheckBaseOutput(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeAssertionsBase(th)
+	th.WriteK("base", `
+resources:
+- deployments.yaml
+assertions:
+- select:
+    name: worker
+  fieldPath: spec.replicas
+`)
+	th.WriteK("overlay", `
+resources:
+- ../base
+`)
+	err := th.RunWithErr("overlay", th.MakeDefaultOptions())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	expected := `assertion 'spec.replicas' of kustomization '/base' failed:
+  apps_v1_Deployment|~X|worker from '/base/deployments.yaml': spec.replicas is missing`
+	if !strings.HasSuffix(err.Error(), expected) {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, err.Error())
+	}
+}
+
+func TestAssertionsOfBaseCheckOnlyItsResources(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeAssertionsBase(th)
+	th.WriteK("base", `
+resources:
+- deployments.yaml
+assertions:
+- fieldPath: metadata.name
+  matches: '^(prod-)?(web|worker)$'
+`)
+	th.WriteF("overlay/job.yaml", `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: busybox:1.33
+`)
+	th.WriteK("overlay", `
+resources:
+- ../base
+- job.yaml
+namePrefix: prod-
+`)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	if m.Size() != 3 {
+		t.Fatalf("unexpected resources: %v", m.AllIds())
+	}
+}
+
+func TestAssertionsCheckNamesOfFinalOutput(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+      volumes:
+      - name: config
+        configMap:
+          name: settings
+`)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+namePrefix: prod-
+configMapGenerator:
+- name: settings
+  literals:
+  - color=blue
+assertions:
+- name: the volume holds the generated settings
+  select:
+    kind: Deployment
+  fieldPath: spec.template.spec.volumes.[name=config].configMap.name
+  matches: '^prod-settings-[a-z0-9]{10}$'
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-web
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx:1.21
+        name: web
+      volumes:
+      - configMap:
+          name: prod-settings-747dfcb89d
+        name: config
+---
+apiVersion: v1
+data:
+  color: blue
+kind: ConfigMap
+metadata:
+  name: prod-settings-747dfcb89d
+`)
+}
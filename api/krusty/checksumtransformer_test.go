@@ -0,0 +1,84 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestChecksumTransformerInPrefixedOverlay(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("base", `
+resources:
+- resources.yaml
+`)
+	th.WriteF("base/resources.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  level: debug
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app
+        envFrom:
+        - configMapRef:
+            name: app-config
+`)
+	th.WriteF("overlay/checksum.yaml", `
+apiVersion: builtin
+kind: ChecksumTransformer
+metadata:
+  name: checksum
+`)
+	// The transformers run after the names are prefixed,
+	// but before the references are updated to match.
+	th.WriteK("overlay", `
+namePrefix: dev-
+namespace: dev
+resources:
+- ../base
+transformers:
+- checksum.yaml
+`)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  level: debug
+kind: ConfigMap
+metadata:
+  name: dev-app-config
+  namespace: dev
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: dev-app
+  namespace: dev
+spec:
+  template:
+    metadata:
+      annotations:
+        checksum/config: hc4mg5289h
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: dev-app-config
+        image: app
+        name: app
+`)
+}
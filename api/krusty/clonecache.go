@@ -0,0 +1,108 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"time"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/internal/git"
+)
+
+// CloneCache holds the clones of remote repositories for the
+// Kustomizers whose options name it, so that each repository,
+// at each ref, is cloned once for all of them, e.g. for the
+// builds of a long-running process.
+//
+// It may be used by several Kustomizers at once.
+type CloneCache struct {
+	cache  *git.CloneCache
+	onDisk bool
+}
+
+// NewCloneCache returns an empty CloneCache.
+func NewCloneCache() *CloneCache {
+	return &CloneCache{
+		cache: git.NewCloneCache(
+			filesys.MakeFsOnDisk(), git.ClonerUsingGitExec),
+	}
+}
+
+// NewDiskCloneCache returns a CloneCache keeping its clones in
+// dir, e.g. konfig.DefaultCacheHome()/clones, for the CloneCaches
+// of the processes run later with the same dir to reuse, until
+// they're removed by CollectCachedClones.  A clone is then kept
+// at the commit it was first cloned at, even if the ref is a
+// branch.  The clones the CloneCache uses are locked until
+// its Cleanup, which leaves them in place.
+// It's experimental, needing ExperimentalDiskClones.
+func NewDiskCloneCache(dir string) (*CloneCache, error) {
+	c, err := git.NewDiskCloneCache(dir, git.ClonerUsingGitExecIn)
+	if err != nil {
+		return nil, err
+	}
+	return &CloneCache{cache: c, onDisk: true}, nil
+}
+
+// Cleanup removes the clones, emptying the cache, or, if
+// they're kept on disk, unlocks them.  It must not be
+// called while runs using the cache are made.
+func (c *CloneCache) Cleanup() error {
+	return c.cache.Cleanup()
+}
+
+// CachedClone is a clone kept by disk CloneCaches.
+type CachedClone struct {
+	// Repo and Ref are the repository, as given to
+	// git clone, and the ref of the clone, if any.
+	Repo string
+	Ref  string
+
+	// Created and LastUsed are when the clone was
+	// made and when a build last used it.
+	Created  time.Time
+	LastUsed time.Time
+
+	// Size is the size of the files of the clone, in bytes.
+	Size int64
+
+	// Users are the processes using the clone, as host:pid.
+	Users []string
+}
+
+// ListCachedClones returns the clones that disk CloneCaches
+// keep in dir, most recently used first.
+func ListCachedClones(dir string) ([]CachedClone, error) {
+	entries, err := git.ListDiskCache(dir)
+	return cachedClones(entries), err
+}
+
+// CollectCachedClones removes, of the clones that disk CloneCaches
+// keep in dir, those last used more than maxAge ago, if maxAge is
+// above zero, and then the least recently used ones while the
+// clones take more than maxSize bytes, if above zero, returning
+// those removed.  Clones in use, locked by a process, are left
+// alone, and the locks of processes that aren't running any more,
+// and the partial clones of processes that failed, are removed.
+// It may be run while builds use the clones.
+func CollectCachedClones(dir string, maxAge time.Duration, maxSize int64) (
+	[]CachedClone, error) {
+	entries, err := git.CollectDiskCache(dir, maxAge, maxSize, time.Now())
+	return cachedClones(entries), err
+}
+
+func cachedClones(entries []git.DiskEntry) []CachedClone {
+	var result []CachedClone
+	for _, e := range entries {
+		result = append(result, CachedClone{
+			Repo:     e.Repo,
+			Ref:      e.Ref,
+			Created:  e.Created,
+			LastUsed: e.LastUsed,
+			Size:     e.Size,
+			Users:    e.Users,
+		})
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestNamespaceSkipsKindsOfClusterScopedCrdResources(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+namespace: apps
+resources:
+- crds.yaml
+- instances.yaml
+`)
+	th.WriteF("crds.yaml", `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: tenants.example.com
+spec:
+  group: example.com
+  scope: Cluster
+  names:
+    kind: Tenant
+    plural: tenants
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names:
+    kind: Widget
+    plural: widgets
+`)
+	th.WriteF("instances.yaml", `
+apiVersion: example.com/v1
+kind: Tenant
+metadata:
+  name: acme
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: gear
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: tenants.example.com
+spec:
+  group: example.com
+  names:
+    kind: Tenant
+    plural: tenants
+  scope: Cluster
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+---
+apiVersion: example.com/v1
+kind: Tenant
+metadata:
+  name: acme
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: gear
+  namespace: apps
+`)
+}
+
+func TestNamespaceSkipsKindsDeclaredClusterScopedInCrdsField(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+namespace: apps
+crds:
+- tenant.json
+resources:
+- tenant.yaml
+`)
+	th.WriteF("tenant.json", `
+{
+  "github.com/example/pkg/apis/v1.Tenant": {
+    "Schema": {
+      "x-kubernetes-scope": "Cluster",
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "metadata": {
+          "$ref": "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"
+        }
+      }
+    }
+  }
+}
+`)
+	th.WriteF("tenant.yaml", `
+apiVersion: example.com/v1
+kind: Tenant
+metadata:
+  name: acme
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: example.com/v1
+kind: Tenant
+metadata:
+  name: acme
+`)
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import "fmt"
+
+// ClusterScopedReferrals is which renamed objects the name
+// references of cluster-scoped objects follow, when they name no
+// namespace, e.g. the service accounts of the subjects of
+// ClusterRoleBindings, or the services of webhook configurations.
+type ClusterScopedReferrals string
+
+const (
+	// ClusterScopedReferralsAny, the default, or empty, follows
+	// the renamed objects of any namespace, which, for builds
+	// spanning namespaces holding objects of the same name,
+	// may be the wrong ones.
+	ClusterScopedReferralsAny ClusterScopedReferrals = "any"
+
+	// ClusterScopedReferralsTarget follows only those of the
+	// namespace of the kustomization built, default if it sets
+	// none, and the cluster-scoped ones.
+	ClusterScopedReferralsTarget ClusterScopedReferrals = "target"
+)
+
+// clusterScopedReferralsInTargetNamespace returns whether the
+// ClusterScopedReferrals option is ClusterScopedReferralsTarget.
+func (b *Kustomizer) clusterScopedReferralsInTargetNamespace() (bool, error) {
+	switch b.options.ClusterScopedReferrals {
+	case "", ClusterScopedReferralsAny:
+		return false, nil
+	case ClusterScopedReferralsTarget:
+		return true, nil
+	}
+	return false, fmt.Errorf(
+		"unknown cluster-scoped referrals %q, expected %s or %s",
+		b.options.ClusterScopedReferrals,
+		ClusterScopedReferralsAny, ClusterScopedReferralsTarget)
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+// writeTwoNamespaces writes an overlay in namespace prod, also
+// building a base it leaves in namespace staging, both holding a
+// service account and a service renamed apart, referred to by a
+// ClusterRoleBinding and a webhook configuration of the overlay.
+func writeTwoNamespaces(th kusttest_test.Harness) {
+	th.WriteK("app", `
+resources:
+- objects.yaml
+`)
+	th.WriteF("app/objects.yaml", `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: webhook
+`)
+	th.WriteK("staging", `
+namespace: staging
+namePrefix: staging-
+resources:
+- ../app
+`)
+	th.WriteK("prod/app", `
+namePrefix: prod-
+resources:
+- ../../app
+`)
+	th.WriteF("prod/cluster.yaml", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: app
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- kind: ServiceAccount
+  name: app
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: app
+webhooks:
+- name: app.example.com
+  clientConfig:
+    service:
+      name: webhook
+`)
+	th.WriteK("prod", `
+namespace: prod
+namespaceOptions:
+  exclude:
+  - namespace: staging
+resources:
+- ../staging
+- app
+- cluster.yaml
+`)
+}
+
+func TestClusterScopedReferralsInTargetNamespace(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTwoNamespaces(th)
+	opts := th.MakeDefaultOptions()
+	opts.ClusterScopedReferrals = krusty.ClusterScopedReferralsTarget
+	opts.Experimental = understanding(krusty.ExperimentalClusterScopedReferrals)
+	m := th.Run("prod", opts)
+	// The staging objects, first, aren't taken for the referrals.
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: staging-app
+  namespace: staging
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: staging-webhook
+  namespace: staging
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: prod-app
+  namespace: prod
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: prod-webhook
+  namespace: prod
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: app
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- kind: ServiceAccount
+  name: prod-app
+  namespace: prod
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: app
+webhooks:
+- clientConfig:
+    service:
+      name: prod-webhook
+      namespace: prod
+  name: app.example.com
+`)
+}
+
+func TestClusterScopedReferralsInAnyNamespace(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTwoNamespaces(th)
+	m := th.Run("prod", th.MakeDefaultOptions())
+	// Each reference has two candidates, so is left as is.
+	yml, err := m.AsYaml()
+	assert.NoError(t, err)
+	assert.Contains(t, string(yml), `subjects:
+- kind: ServiceAccount
+  name: app
+`)
+	assert.Contains(t, string(yml), `    service:
+      name: webhook
+`)
+}
+
+func TestUnknownClusterScopedReferrals(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTwoNamespaces(th)
+	opts := th.MakeDefaultOptions()
+	opts.ClusterScopedReferrals = "namespace"
+	opts.Experimental = understanding(krusty.ExperimentalClusterScopedReferrals)
+	err := th.RunWithErr("prod", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`unknown cluster-scoped referrals "namespace", expected any or target`)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeScalingComponent(th kusttest_test.Harness) {
+	th.WriteF("base/deploy.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`)
+	th.WriteK("base", `
+resources:
+- deploy.yaml
+`)
+	th.WriteC("components/scaling", `
+replacements:
+- source:
+    kind: ComponentValues
+    fieldPath: values.replicas
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.replicas
+- source:
+    kind: ComponentValues
+    fieldPath: values.tier
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - metadata.labels.tier
+    options:
+      create: true
+`)
+}
+
+func TestComponentValues(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeScalingComponent(th)
+	th.WriteF("prod/values.yaml", `
+replicas: 5
+tier: backend
+`)
+	th.WriteK("prod", `
+resources:
+- ../base
+components:
+- path: ../components/scaling
+  valuesFile: values.yaml
+  valuesInline:
+    replicas: 10
+`)
+	th.WriteK("staging", `
+resources:
+- ../base
+components:
+- path: ../components/scaling
+  valuesInline:
+    replicas: 2
+    tier: frontend
+`)
+	m := th.Run("prod", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: backend
+  name: web
+spec:
+  replicas: 10
+`)
+	m = th.Run("staging", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: frontend
+  name: web
+spec:
+  replicas: 2
+`)
+}
+
+func TestComponentValuesOfNestedComponents(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeScalingComponent(th)
+	th.WriteC("components/large", `
+components:
+- path: ../scaling
+  valuesInline:
+    replicas: 20
+    tier: backend
+replacements:
+- source:
+    kind: ComponentValues
+    fieldPath: values.size
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - metadata.annotations.size
+    options:
+      create: true
+`)
+	th.WriteK("prod", `
+resources:
+- ../base
+components:
+- path: ../components/large
+  valuesInline:
+    size: xl
+`)
+	m := th.Run("prod", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    size: xl
+  labels:
+    tier: backend
+  name: web
+spec:
+  replicas: 20
+`)
+}
+
+func TestComponentWithoutPath(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+components:
+- valuesInline:
+    replicas: 2
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		"a component given as an object must have a path")
+}
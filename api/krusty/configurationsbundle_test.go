@@ -0,0 +1,111 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+// A platform team publishes a directory of transformer configs,
+// which every app overlay lists, adjusting it as needed.
+func TestConfigurationsBundle(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("platform/transformers/labels.yaml", `
+commonLabels:
+- path: spec/selector
+  kind: Widget
+  create: true
+`)
+	th.WriteF("platform/transformers/references.yaml", `
+nameReference:
+- kind: ConfigMap
+  fieldSpecs:
+  - path: spec/configRef
+    kind: Widget
+`)
+	th.WriteF("app/widget.yaml", `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: shop
+spec:
+  configRef: settings
+`)
+	th.WriteK("app", `
+namePrefix: app-
+commonLabels:
+  team: shop
+resources:
+- widget.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - color=blue
+configurations:
+- ../platform/transformers
+`)
+	th.AssertActualEqualsExpected(th.Run("app", th.MakeDefaultOptions()), `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  labels:
+    team: shop
+  name: app-shop
+spec:
+  configRef: app-settings-747dfcb89d
+  selector:
+    team: shop
+---
+apiVersion: v1
+data:
+  color: blue
+kind: ConfigMap
+metadata:
+  labels:
+    team: shop
+  name: app-settings-747dfcb89d
+`)
+
+	// The overlay doesn't want the selector created.
+	th.WriteF("app/labels.yaml", `
+commonLabels:
+- path: spec/selector
+  kind: Widget
+`)
+	th.WriteK("app", `
+namePrefix: app-
+commonLabels:
+  team: shop
+resources:
+- widget.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - color=blue
+configurations:
+- ../platform/transformers
+- labels.yaml
+`)
+	th.AssertActualEqualsExpected(th.Run("app", th.MakeDefaultOptions()), `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  labels:
+    team: shop
+  name: app-shop
+spec:
+  configRef: app-settings-747dfcb89d
+---
+apiVersion: v1
+data:
+  color: blue
+kind: ConfigMap
+metadata:
+  labels:
+    team: shop
+  name: app-settings-747dfcb89d
+`)
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/internal/git"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// Cycles returns the cycles of the local kustomizations reachable from
+// the one at path through their resources, bases and components,
+// without building them, or fetching remote ones.  Each cycle is
+// the directories in it, relative to path, its first also its last,
+// e.g. [overlay base overlay].
+func Cycles(fSys filesys.FileSystem, path string) ([][]string, error) {
+	top, f, err := fSys.CleanedAbs(path)
+	if err != nil {
+		return nil, err
+	}
+	if f != "" {
+		return nil, fmt.Errorf("'%s' must be a directory", path)
+	}
+	c := &cycleFinder{
+		fSys:    fSys,
+		top:     top,
+		done:    make(map[filesys.ConfirmedDir]bool),
+		onStack: make(map[filesys.ConfirmedDir]int),
+	}
+	if err = c.visit(top); err != nil {
+		return nil, err
+	}
+	return c.cycles, nil
+}
+
+// cycleFinder searches the kustomizations depth first,
+// finding a cycle in each reference to one on the stack.
+type cycleFinder struct {
+	fSys    filesys.FileSystem
+	top     filesys.ConfirmedDir
+	stack   []filesys.ConfirmedDir
+	onStack map[filesys.ConfirmedDir]int
+	done    map[filesys.ConfirmedDir]bool
+	cycles  [][]string
+}
+
+func (c *cycleFinder) visit(dir filesys.ConfirmedDir) error {
+	k, err := c.readKustomization(dir)
+	if err != nil || k == nil {
+		return err
+	}
+	c.onStack[dir] = len(c.stack)
+	c.stack = append(c.stack, dir)
+	var entries []string
+	for _, r := range k.Resources {
+		// Filtered directories are read as files, not built.
+		if _, ok := k.ResourceFilterOf(r); !ok {
+			entries = append(entries, r)
+		}
+	}
+	for _, c := range k.Components {
+		entries = append(entries, c.Path)
+	}
+	for _, entry := range entries {
+		if _, err := git.NewRepoSpecFromUrl(entry); err == nil {
+			continue
+		}
+		d, f, err := c.fSys.CleanedAbs(dir.Join(entry))
+		if err != nil || f != "" {
+			// A file, or missing, which the build reports.
+			continue
+		}
+		if i, ok := c.onStack[d]; ok {
+			c.recordCycle(append(c.stack[i:len(c.stack):len(c.stack)], d))
+			continue
+		}
+		if !c.done[d] {
+			if err = c.visit(d); err != nil {
+				return err
+			}
+		}
+	}
+	c.stack = c.stack[:len(c.stack)-1]
+	delete(c.onStack, dir)
+	c.done[dir] = true
+	return nil
+}
+
+func (c *cycleFinder) recordCycle(dirs []filesys.ConfirmedDir) {
+	var cycle []string
+	for _, d := range dirs {
+		rel, err := filepath.Rel(c.top.String(), d.String())
+		if err != nil {
+			rel = d.String()
+		}
+		cycle = append(cycle, rel)
+	}
+	c.cycles = append(c.cycles, cycle)
+}
+
+// readKustomization reads the kustomization in dir,
+// returning nil if dir has none.
+func (c *cycleFinder) readKustomization(
+	dir filesys.ConfirmedDir) (*types.Kustomization, error) {
+	for _, n := range konfig.RecognizedKustomizationFileNames() {
+		p := dir.Join(n)
+		if !c.fSys.Exists(p) {
+			continue
+		}
+		content, err := c.fSys.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = types.FixKustomizationPreUnmarshalling(content); err != nil {
+			return nil, fmt.Errorf("reading %s: %v", p, err)
+		}
+		k := &types.Kustomization{}
+		if err = k.UnmarshalWithExtensions(content); err != nil {
+			return nil, fmt.Errorf("reading %s: %v", p, err)
+		}
+		k.FixKustomizationPostUnmarshalling()
+		return k, nil
+	}
+	return nil, nil
+}
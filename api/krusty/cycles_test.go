@@ -0,0 +1,112 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestCycles(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("/app/overlay/kustomization.yaml", []byte(`
+resources:
+- ../base
+- ../shared
+- service.yaml
+components:
+- ../component
+`))
+	fSys.WriteFile("/app/overlay/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	fSys.WriteFile("/app/base/kustomization.yaml", []byte(`
+resources:
+- ../shared
+- github.com/example/repo//base
+`))
+	fSys.WriteFile("/app/shared/kustomization.yaml", []byte(`
+bases:
+- ../overlay
+`))
+	fSys.WriteFile("/app/component/kustomization.yaml", []byte(`
+apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+components:
+- ../component
+`))
+	cycles, err := krusty.Cycles(fSys, "/app/overlay")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, [][]string{
+		{".", "../base", "../shared", "."},
+		{"../component", "../component"},
+	}, cycles)
+
+	cycles, err = krusty.Cycles(fSys, "/app/component/..")
+	assert.NoError(t, err)
+	assert.Empty(t, cycles)
+}
+
+func TestCycleErrorGivesPath(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("overlay", `
+resources:
+- ../base
+`)
+	th.WriteK("base", `
+resources:
+- ../overlay
+`)
+	err := th.RunWithErr("overlay", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"cycle detected: /overlay -> /base -> /overlay")
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("overlay", `
+resources:
+- ../middle
+`)
+	th.WriteK("middle", `
+resources:
+- ../base
+`)
+	th.WriteK("base", `
+resources:
+- configmap.yaml
+`)
+	th.WriteF("base/configmap.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`)
+	opts := th.MakeDefaultOptions()
+	opts.MaxDepth = 2
+	th.AssertActualEqualsExpected(th.Run("overlay", opts), `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`)
+	opts.MaxDepth = 1
+	err := th.RunWithErr("overlay", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"bases and components nest deeper than the maximum depth of 1, "+
+				"in /overlay -> /middle -> /base")
+	}
+}
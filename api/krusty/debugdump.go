@@ -0,0 +1,102 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// redactedValue replaces the values of secrets in dumps.
+const redactedValue = "REDACTED"
+
+// debugDumper writes the snapshots of a build to numbered
+// files in a directory, e.g. 003-overlay-PatchTransformer.yaml,
+// so that diffing consecutive files shows what each step did.
+// Steps changing nothing, e.g. the builtin transformers of
+// kustomizations not using them, are left out.
+type debugDumper struct {
+	fSys filesys.FileSystem
+	dir  string
+	n    int
+	// last holds the last snapshot of each kustomization.
+	last map[string]string
+}
+
+// newDebugDumper returns a dumper writing to dir, which
+// mustn't hold files, lest they be mixed up with the dumps.
+func newDebugDumper(fSys filesys.FileSystem, dir string) (*debugDumper, error) {
+	if fSys.Exists(dir) {
+		if !fSys.IsDir(dir) {
+			return nil, fmt.Errorf("debug dump directory %s is a file", dir)
+		}
+		files, err := fSys.Glob(filepath.Join(dir, "*"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			return nil, fmt.Errorf("debug dump directory %s isn't empty", dir)
+		}
+	} else if err := fSys.MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	return &debugDumper{fSys: fSys, dir: dir, last: make(map[string]string)}, nil
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// dump writes the resources of the kustomization in
+// kustDir, after the step, to the next file.
+func (d *debugDumper) dump(kustDir, step string, m resmap.ResMap) error {
+	m = m.DeepCopy()
+	m.RemoveBuildAnnotations()
+	if err := redactSecrets(m); err != nil {
+		return err
+	}
+	b, err := m.AsYaml()
+	if err != nil {
+		return err
+	}
+	if last, ok := d.last[kustDir]; ok && last == string(b) {
+		return nil
+	}
+	d.last[kustDir] = string(b)
+	d.n++
+	name := fmt.Sprintf("%03d-%s-%s.yaml", d.n,
+		unsafeFileNameChars.ReplaceAllString(filepath.Base(kustDir), "_"), step)
+	return d.fSys.WriteFile(filepath.Join(d.dir, name), b)
+}
+
+// redactSecrets replaces the values of the secrets of m.
+func redactSecrets(m resmap.ResMap) error {
+	for _, r := range m.Resources() {
+		if r.GetGvk().Group != "" || r.GetKind() != "Secret" {
+			continue
+		}
+		for _, field := range []string{"data", "stringData"} {
+			values, err := r.Node().Pipe(yaml.Lookup(field))
+			if err != nil {
+				return err
+			}
+			if values == nil || values.YNode().Kind != yaml.MappingNode {
+				continue
+			}
+			err = values.VisitFields(func(node *yaml.MapNode) error {
+				node.Value.YNode().Value = redactedValue
+				node.Value.YNode().Tag = yaml.NodeTagString
+				node.Value.YNode().Style = 0
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
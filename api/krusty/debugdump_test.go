@@ -0,0 +1,107 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeDebugDumpOverlay(th kusttest_test.Harness) {
+	th.WriteK("base", `
+resources:
+- deployment.yaml
+`)
+	th.WriteF("base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web
+        envFrom:
+        - secretRef:
+            name: creds
+`)
+	th.WriteK("overlay", `
+namePrefix: prod-
+resources:
+- ../base
+secretGenerator:
+- name: creds
+  literals:
+  - password=hunter2
+`)
+}
+
+func TestDebugDump(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeDebugDumpOverlay(th)
+	opts := th.MakeDefaultOptions()
+	opts.DebugDumpDir = "/dump"
+	th.Run("overlay", opts)
+	fSys := th.GetFSys()
+	files, err := fSys.Glob("/dump/*")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	assert.Equal(t, []string{
+		"001-base-resources.yaml",
+		"002-overlay-resources.yaml",
+		"003-overlay-SecretGenerator.yaml",
+		"004-overlay-PrefixSuffixTransformer.yaml",
+		"005-overlay-HashTransformer.yaml",
+		"006-overlay-NameReferenceTransformer.yaml",
+	}, names)
+	b, err := fSys.ReadFile("/dump/004-overlay-PrefixSuffixTransformer.yaml")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-web
+spec:
+  template:
+    spec:
+      containers:
+      - envFrom:
+        - secretRef:
+            name: creds
+        image: web
+        name: web
+---
+apiVersion: v1
+data:
+  password: REDACTED
+kind: Secret
+metadata:
+  name: prod-creds
+type: Opaque
+`, string(b))
+}
+
+func TestDebugDumpDirNotEmpty(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeDebugDumpOverlay(th)
+	th.WriteF("/dump/notes.txt", "keep")
+	opts := th.MakeDefaultOptions()
+	opts.DebugDumpDir = "/dump"
+	err := th.RunWithErr("overlay", opts)
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, err.Error(), "debug dump directory /dump isn't empty")
+}
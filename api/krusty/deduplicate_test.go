@@ -0,0 +1,162 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+// writeSharedLibrary writes a diamond, app, reaching the
+// shared library base through both frontend and backend.
+func writeSharedLibrary(th kusttest_test.Harness, backendPatch string) {
+	th.WriteK("library", `
+resources:
+- namespace.yaml
+`)
+	th.WriteF("library/namespace.yaml", `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+  labels:
+    team: shop
+`)
+	th.WriteK("frontend", `
+resources:
+- ../library
+- deployment.yaml
+`)
+	th.WriteF("frontend/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+`)
+	th.WriteK("backend", `
+resources:
+- ../library
+- deployment.yaml
+`+backendPatch)
+	th.WriteF("backend/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+`)
+	th.WriteK("app", `
+deduplicateIdenticalResources: true
+resources:
+- ../frontend
+- ../backend
+`)
+}
+
+func TestDeduplicateIdenticalResources(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedLibrary(th, "")
+	th.AssertActualEqualsExpected(th.Run("app", th.MakeDefaultOptions()), `
+apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    team: shop
+  name: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+`)
+}
+
+func TestDeduplicateConflictingResources(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedLibrary(th, `
+commonAnnotations:
+  owner: backend
+`)
+	err := th.RunWithErr("app", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"may not add resource with an already registered id: "+
+				"~G_v1_Namespace|~X|shop; its content differs")
+	}
+}
+
+func TestDeduplicateResourcesFrozenOnOnePath(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedLibrary(th, `
+patches:
+- target:
+    kind: Namespace
+  patch: |-
+    - op: add
+      path: /metadata/annotations
+      value:
+        kustomize.config.k8s.io/frozen: "true"
+`)
+	err := th.RunWithErr("app", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"may not add resource with an already registered id: "+
+				"~G_v1_Namespace|~X|shop; its content differs")
+	}
+}
+
+func TestWithoutDeduplicatingResources(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedLibrary(th, "")
+	th.WriteK("app", `
+resources:
+- ../frontend
+- ../backend
+`)
+	err := th.RunWithErr("app", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"may not add resource with an already registered id: "+
+				"~G_v1_Namespace|~X|shop")
+	}
+}
+
+func TestDeduplicatedResourcesCheckedByBaseAssertions(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedLibrary(th, `
+assertions:
+- name: the namespace is the shop's
+  select:
+    kind: Namespace
+  fieldPath: metadata.labels.team
+  equals: shop
+`)
+	th.WriteK("app", `
+deduplicateIdenticalResources: true
+resources:
+- ../frontend
+- ../backend
+patches:
+- patch: |-
+    apiVersion: v1
+    kind: Namespace
+    metadata:
+      name: shop
+      labels:
+        team: payments
+`)
+	// The copy of the namespace kept is that of frontend,
+	// which backend's assertion checks all the same.
+	err := th.RunWithErr("app", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "assertion 'the namespace is the shop's'")
+		assert.Contains(t, err.Error(), "metadata.labels.team is 'payments', not 'shop'")
+	}
+}
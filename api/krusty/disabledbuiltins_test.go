@@ -0,0 +1,157 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeDisabledBuiltinsBase(th kusttest_test.Harness) {
+	th.WriteK(".", `
+namePrefix: p-
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: cm
+  literals:
+  - a=b
+`)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      volumes:
+      - name: config
+        configMap:
+          name: cm
+`)
+}
+
+func TestDisabledBuiltinsHashAndNameReferences(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeDisabledBuiltinsBase(th)
+	opts := th.MakeDefaultOptions()
+	opts.DisabledBuiltins = []string{"HashTransformer", "NameReferenceTransformer"}
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: p-web
+spec:
+  template:
+    spec:
+      volumes:
+      - configMap:
+          name: cm
+        name: config
+---
+apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  name: p-cm
+`)
+}
+
+func TestDisabledBuiltinRequiredByField(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeDisabledBuiltinsBase(th)
+	opts := th.MakeDefaultOptions()
+	opts.DisabledBuiltins = []string{"PrefixSuffixTransformer"}
+	err := th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"kustomization in '/' requires the builtin PrefixSuffixTransformer, which is disabled")
+	}
+}
+
+func TestDisabledBuiltinsNotRequired(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeDisabledBuiltinsBase(th)
+	opts := th.MakeDefaultOptions()
+	opts.DisabledBuiltins = []string{
+		"NamespaceTransformer", "LabelTransformer",
+		"AnnotationsTransformer", "ReplacementTransformer",
+	}
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: p-web
+spec:
+  template:
+    spec:
+      volumes:
+      - configMap:
+          name: p-cm-4h2mbtbbt6
+        name: config
+---
+apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  name: p-cm-4h2mbtbbt6
+`)
+}
+
+func TestDisabledBuiltinRequiredByConfig(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+transformers:
+- labels.yaml
+`)
+	th.WriteF("labels.yaml", `
+apiVersion: builtin
+kind: LabelTransformer
+metadata:
+  name: labels
+labels:
+  app: web
+fieldSpecs:
+- path: metadata/labels
+  create: true
+`)
+	opts := th.MakeDefaultOptions()
+	opts.DisabledBuiltins = []string{"LabelTransformer"}
+	err := th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"kustomization in '/' requires the builtin LabelTransformer, which is disabled")
+	}
+}
+
+func TestDisabledBuiltinsErrors(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", ``)
+
+	opts := th.MakeDefaultOptions()
+	opts.DisabledBuiltins = []string{"NoSuchTransformer"}
+	err := th.RunWithErr(".", opts)
+	assert.EqualError(t, err, "cannot disable unknown builtin 'NoSuchTransformer'")
+
+	opts = th.MakeDefaultOptions()
+	opts.AddManagedbyLabel = true
+	opts.DisabledBuiltins = []string{"LabelTransformer"}
+	err = th.RunWithErr(".", opts)
+	assert.EqualError(t, err,
+		"the managed-by label requires the builtin LabelTransformer, which is disabled")
+
+	opts = th.MakeDefaultOptions()
+	opts.DoLegacyResourceSort = true
+	opts.DisabledBuiltins = []string{"LegacyOrderTransformer"}
+	err = th.RunWithErr(".", opts)
+	assert.EqualError(t, err,
+		"the legacy resource sort requires the builtin LegacyOrderTransformer, which is disabled")
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// The builtin formats of Emit.
+const (
+	// FormatYaml is the output of builds as YAML documents,
+	// with the fields of each sorted, as ResMap.AsYaml has it.
+	FormatYaml = "yaml"
+
+	// FormatJson is the output of builds as a v1 List.
+	FormatJson = "json"
+)
+
+var builtinEmitters = map[string]kio.Emitter{
+	FormatYaml: kio.EmitterFunc(emitYaml),
+	FormatJson: kio.JSONEmitter{Indent: "  ", KeepReaderAnnotations: true},
+}
+
+// Emit writes the resources, e.g. the output of Run, to w,
+// encoded in the format: one of the builtin formats, yaml, the
+// default, or json, or one registered with RegisterEmitter.
+// The yaml keeps the comments of the resources if the option
+// KeepComments is set.
+func (b *Kustomizer) Emit(w io.Writer, m resmap.ResMap, format string) error {
+	if format == "" {
+		format = FormatYaml
+	}
+	e, ok := builtinEmitters[format]
+	if format == FormatYaml && b.options.KeepComments {
+		e = kio.EmitterFunc(emitYamlKeepingComments)
+	}
+	if !ok {
+		e, ok = b.options.registeredEmitters[format]
+		if ok && !b.options.Experimental.understands(ExperimentalRegisteredEmitters) {
+			return notUnderstood("RegisterEmitter", ExperimentalRegisteredEmitters)
+		}
+	}
+	if !ok {
+		return fmt.Errorf(
+			"unknown output format %q, expected one of %s",
+			format, strings.Join(b.EmitFormats(), ", "))
+	}
+	return e.Emit(w, m.ToRNodeSlice())
+}
+
+// EmitFormats returns the formats Emit may encode output in,
+// the builtin ones and those registered, sorted.
+func (b *Kustomizer) EmitFormats() []string {
+	var result []string
+	for format := range builtinEmitters {
+		result = append(result, format)
+	}
+	for format := range b.options.registeredEmitters {
+		result = append(result, format)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// emitYaml emits the nodes as ResMap.AsYaml does.
+func emitYaml(w io.Writer, nodes []*kyaml.RNode) error {
+	for i, n := range nodes {
+		j, err := n.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		out, err := yaml.JSONToYAML(j)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err = io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err = w.Write(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitYamlKeepingComments emits the nodes with their fields
+// sorted, as emitYaml does, but keeping their comments, and
+// the styles of their values.
+func emitYamlKeepingComments(w io.Writer, nodes []*kyaml.RNode) error {
+	for i, n := range nodes {
+		n = n.Copy()
+		sortFields(n.YNode())
+		out, err := n.String()
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err = io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err = io.WriteString(w, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortFields sorts the fields of the maps of n by key.  The
+// head comment of the first field of a map in a list is moved
+// to the map, as yaml puts it after the dash otherwise.
+func sortFields(n *kyaml.Node) {
+	switch n.Kind {
+	case kyaml.SequenceNode:
+		for _, c := range n.Content {
+			sortFields(c)
+			if c.Kind == kyaml.MappingNode && len(c.Content) > 0 &&
+				c.HeadComment == "" {
+				c.HeadComment, c.Content[0].HeadComment = c.Content[0].HeadComment, ""
+			}
+		}
+		return
+	case kyaml.MappingNode:
+		pairs := make([][2]*kyaml.Node, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			pairs = append(pairs, [2]*kyaml.Node{n.Content[i], n.Content[i+1]})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return pairs[i][0].Value < pairs[j][0].Value
+		})
+		for i, p := range pairs {
+			n.Content[2*i], n.Content[2*i+1] = p[0], p[1]
+		}
+	}
+	for _, c := range n.Content {
+		sortFields(c)
+	}
+}
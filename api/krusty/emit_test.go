@@ -0,0 +1,106 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestEmit(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+resources:
+- service.yaml
+`)
+	th.WriteF("service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+spec:
+  ports:
+  - port: 80
+`)
+	opts := th.MakeDefaultOptions()
+	opts.Experimental = understanding(krusty.ExperimentalRegisteredEmitters)
+	assert.NoError(t, opts.RegisterEmitter("names", kio.EmitterFunc(
+		func(w io.Writer, nodes []*yaml.RNode) error {
+			for _, n := range nodes {
+				if _, err := io.WriteString(w, n.GetName()+"\n"); err != nil {
+					return err
+				}
+			}
+			return nil
+		})))
+	k := krusty.MakeKustomizer(&opts)
+	m, err := k.Run(th.GetFSys(), ".")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, k.Emit(&out, m, ""))
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+spec:
+  ports:
+  - port: 80
+`, out.String())
+
+	out.Reset()
+	assert.NoError(t, k.Emit(&out, m, krusty.FormatJson))
+	assert.Equal(t, `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Service",
+      "metadata": {
+        "name": "svc"
+      },
+      "spec": {
+        "ports": [
+          {
+            "port": 80
+          }
+        ]
+      }
+    }
+  ]
+}
+`, out.String())
+
+	out.Reset()
+	assert.NoError(t, k.Emit(&out, m, "names"))
+	assert.Equal(t, "svc\n", out.String())
+
+	err = k.Emit(&out, m, "toml")
+	assert.EqualError(t, err,
+		`unknown output format "toml", expected one of json, names, yaml`)
+}
+
+func TestRegisterEmitter(t *testing.T) {
+	opts := krusty.MakeDefaultOptions()
+	e := kio.JSONEmitter{}
+	assert.EqualError(t, opts.RegisterEmitter("", e),
+		"a registered emitter must have a format")
+	assert.EqualError(t, opts.RegisterEmitter("j", nil),
+		"emitter of format j is nil")
+	assert.EqualError(t, opts.RegisterEmitter(krusty.FormatYaml, e),
+		"format yaml is a builtin format")
+	assert.NoError(t, opts.RegisterEmitter("j", e))
+	assert.EqualError(t, opts.RegisterEmitter("j", e),
+		"an emitter of format j is already registered")
+}
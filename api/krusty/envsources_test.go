@@ -0,0 +1,149 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func TestEnvSources(t *testing.T) {
+	os.Setenv("KUSTOMIZE_TEST_IMAGE_TAG", "v1.2.3")
+	defer os.Unsetenv("KUSTOMIZE_TEST_IMAGE_TAG")
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+envSources:
+- KUSTOMIZE_TEST_IMAGE_TAG
+configMapGenerator:
+- name: build-info
+  literals:
+  - team=web
+  envVars:
+  - KUSTOMIZE_TEST_IMAGE_TAG
+  - tag=KUSTOMIZE_TEST_IMAGE_TAG
+generatorOptions:
+  disableNameSuffixHash: true
+`)
+	opts := th.MakeDefaultOptions()
+	opts.EnvCapture = &types.EnvCapture{}
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  KUSTOMIZE_TEST_IMAGE_TAG: v1.2.3
+  tag: v1.2.3
+  team: web
+kind: ConfigMap
+metadata:
+  name: build-info
+`)
+	assert.Equal(t,
+		map[string]string{"KUSTOMIZE_TEST_IMAGE_TAG": "v1.2.3"},
+		opts.EnvCapture.Values)
+}
+
+func TestEnvSourcesOfSecretsRedacted(t *testing.T) {
+	os.Setenv("KUSTOMIZE_TEST_PASSWORD", "s3cret")
+	defer os.Unsetenv("KUSTOMIZE_TEST_PASSWORD")
+	os.Setenv("KUSTOMIZE_TEST_IMAGE_TAG", "v1.2.3")
+	defer os.Unsetenv("KUSTOMIZE_TEST_IMAGE_TAG")
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+envSources:
+- KUSTOMIZE_TEST_PASSWORD
+- KUSTOMIZE_TEST_IMAGE_TAG
+configMapGenerator:
+- name: build-info
+  envVars:
+  - KUSTOMIZE_TEST_IMAGE_TAG
+  - KUSTOMIZE_TEST_PASSWORD
+secretGenerator:
+- name: creds
+  envVars:
+  - password=KUSTOMIZE_TEST_PASSWORD
+generatorOptions:
+  disableNameSuffixHash: true
+`)
+	opts := th.MakeDefaultOptions()
+	opts.EnvCapture = &types.EnvCapture{}
+	th.Run(".", opts)
+	// The password is redacted, though a ConfigMap holds it too.
+	assert.Equal(t, map[string]string{
+		"KUSTOMIZE_TEST_IMAGE_TAG": "v1.2.3",
+		"KUSTOMIZE_TEST_PASSWORD": "sha256:" +
+			"1ec1c26b50d5d3c58d9583181af8076655fe00756bf7285940ba3670f99fcba0",
+	}, opts.EnvCapture.Values)
+}
+
+func TestEnvSourcesUndeclared(t *testing.T) {
+	os.Setenv("KUSTOMIZE_TEST_IMAGE_TAG", "v1.2.3")
+	defer os.Unsetenv("KUSTOMIZE_TEST_IMAGE_TAG")
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+secretGenerator:
+- name: creds
+  envVars:
+  - KUSTOMIZE_TEST_IMAGE_TAG
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		"secretGenerator creds: environment variable KUSTOMIZE_TEST_IMAGE_TAG "+
+			"isn't declared in the envSources of the kustomization in /")
+}
+
+func TestEnvSourcesUnset(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+envSources:
+- KUSTOMIZE_TEST_UNSET
+configMapGenerator:
+- name: build-info
+  envVars:
+  - KUSTOMIZE_TEST_UNSET
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		"environment variable KUSTOMIZE_TEST_UNSET, declared in the envSources "+
+			"of the kustomization in /, isn't set")
+}
+
+func TestEnvSourcesFunctionEnvs(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+envSources:
+- KUSTOMIZE_TEST_REGION
+transformers:
+- fn.yaml
+`)
+	th.WriteF("fn.yaml", `
+apiVersion: example.com/v1
+kind: SetRegion
+metadata:
+  name: set-region
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: example.com/set-region:v1
+        envs:
+        - KUSTOMIZE_TEST_TOKEN
+`)
+	err := th.RunWithErr(".", th.MakeOptionsPluginsEnabled())
+	assert.Contains(t, err.Error(),
+		"environment variable KUSTOMIZE_TEST_TOKEN isn't declared in the envSources")
+}
+
+func TestEnvSourcesBadName(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+envSources:
+- IMAGE-TAG
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		"envSources holds IMAGE-TAG, which isn't the name of an environment variable")
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestErrorGivesPositionOfField(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  selector: app
+  template:
+    metadata:
+      labels:
+        app: app
+`)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+commonLabels:
+  team: a
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		// The position is that of the selector, which,
+		// being a string, the labels can't be added to.
+		assert.Regexp(t,
+			"^/deployment.yaml:7: considering field 'spec/selector/matchLabels'",
+			err.Error())
+		assert.Contains(t, err.Error(), "expected sequence or mapping node")
+	}
+}
+
+func TestErrorGivesPositionOfFieldInLaterDocument(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("resources.yaml", `apiVersion: v1
+kind: Service
+metadata:
+  name: app
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  selector: app
+  template:
+    metadata:
+      labels:
+        app: app
+`)
+	th.WriteK(".", `
+resources:
+- resources.yaml
+commonLabels:
+  team: a
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Regexp(t,
+			"^/resources.yaml:11: considering field 'spec/selector/matchLabels'",
+			err.Error())
+	}
+}
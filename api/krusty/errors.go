@@ -0,0 +1,64 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"sigs.k8s.io/kustomize/api/internal/kusterr"
+)
+
+// ErrorKind tells what an error of a run is due to, e.g.
+// for CI to retry only the builds that may then succeed.
+type ErrorKind int
+
+const (
+	// ConfigError is an error in the kustomizations built,
+	// or the files they refer to, e.g. a missing file.
+	ConfigError ErrorKind = iota
+
+	// InfrastructureError is a failure to fetch remote bases
+	// or files, e.g. as the network is down, which retrying
+	// the run may get past.
+	InfrastructureError
+
+	// InternalError is a failure of kustomize itself,
+	// e.g. a panic of the goroutine making the run.
+	InternalError
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case InfrastructureError:
+		return "infrastructure"
+	case InternalError:
+		return "internal"
+	default:
+		return "config"
+	}
+}
+
+// KindOf returns the kind of an error returned by a run.
+func KindOf(err error) ErrorKind {
+	var internal *kusterr.InternalError
+	if errors.As(err, &internal) {
+		return InternalError
+	}
+	var infra *kusterr.InfrastructureError
+	if errors.As(err, &infra) {
+		return InfrastructureError
+	}
+	return ConfigError
+}
+
+// recoverInternalError sets *err to an InternalError
+// if the goroutine panics.
+func recoverInternalError(err *error) {
+	if r := recover(); r != nil {
+		*err = &kusterr.InternalError{
+			Err: fmt.Errorf("kustomize panicked: %v\n%s", r, debug.Stack())}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+// panicker is a transformer that panics.
+type panicker struct{}
+
+func (p *panicker) Config(_ *resmap.PluginHelpers, _ []byte) error {
+	return nil
+}
+
+func (p *panicker) Transform(_ resmap.ResMap) error {
+	panic("no way")
+}
+
+func TestKindOf(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("missing", `
+resources:
+- cm.yaml
+`)
+	th.WriteK("unreachable", `
+resources:
+- http://127.0.0.1:1/cm.yaml
+`)
+	th.WriteK("panicking", `
+transformers:
+- panicker.yaml
+`)
+	th.WriteF("panicking/panicker.yaml", `
+apiVersion: example.com/v1
+kind: Panicker
+metadata:
+  name: panicker
+`)
+	opts := th.MakeDefaultOptions()
+	opts.Experimental = understanding(krusty.ExperimentalRegisteredPlugins)
+	assert.NoError(t, opts.RegisterPlugin("Panicker", func() resmap.Configurable {
+		return &panicker{}
+	}))
+	for dir, kind := range map[string]krusty.ErrorKind{
+		"missing":     krusty.ConfigError,
+		"unreachable": krusty.InfrastructureError,
+		"panicking":   krusty.InternalError,
+	} {
+		err := th.RunWithErr(dir, opts)
+		if assert.Error(t, err, dir) {
+			assert.Equal(t, kind, krusty.KindOf(err), "%s: %v", dir, err)
+		}
+	}
+	err := th.RunWithErr("panicking", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "kustomize panicked: no way")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// emitCue emits the resources as CUE, a struct of them
+// by kind and name; being JSON, each resource is CUE.
+func emitCue(w io.Writer, nodes []*yaml.RNode) error {
+	for _, n := range nodes {
+		j, err := n.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "resources: %q: %q: %s\n",
+			n.GetKind(), n.GetName(), j)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ExampleOptions_RegisterEmitter() {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("kustomization.yaml", []byte(`
+resources:
+- configmap.yaml
+`))
+	fSys.WriteFile("configmap.yaml", []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  color: blue
+`))
+	opts := krusty.MakeDefaultOptions()
+	opts.Experimental = &krusty.ExperimentalOptions{
+		Understands: map[krusty.ExperimentalFeature]int{
+			krusty.ExperimentalRegisteredEmitters: 1,
+		},
+	}
+	if err := opts.RegisterEmitter("cue", kio.EmitterFunc(emitCue)); err != nil {
+		log.Fatal(err)
+	}
+	k := krusty.MakeKustomizer(opts)
+	m, err := k.Run(fSys, ".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = k.Emit(os.Stdout, m, "cue"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// resources: "ConfigMap": "settings": {"apiVersion":"v1","data":{"color":"blue"},"kind":"ConfigMap","metadata":{"name":"settings"}}
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExperimentalFeature names a capability of builds that may
+// still change incompatibly, or go, in any release.  An embedder
+// of the api, e.g. a controller building the kustomizations of
+// its users, uses one only by stating, in the Experimental
+// options, that it understands the feature, at the version it
+// was written against, so that a release changing the feature
+// fails its builds plainly rather than building differently.
+type ExperimentalFeature string
+
+const (
+	// ExperimentalParameters is the Parameters option, with
+	// the placeholders of kustomizations referring to them.
+	ExperimentalParameters ExperimentalFeature = "parameters"
+
+	// ExperimentalRegisteredPlugins is Options.RegisterPlugin.
+	ExperimentalRegisteredPlugins ExperimentalFeature = "registeredPlugins"
+
+	// ExperimentalRegisteredEmitters is Options.RegisterEmitter.
+	ExperimentalRegisteredEmitters ExperimentalFeature = "registeredEmitters"
+
+	// ExperimentalClusterScopedReferrals is the
+	// ClusterScopedReferrals option.
+	ExperimentalClusterScopedReferrals ExperimentalFeature = "clusterScopedReferrals"
+
+	// ExperimentalDefaults is the ApplyDefaults, StripDefaults
+	// and KeepDefaultsOf options.
+	ExperimentalDefaults ExperimentalFeature = "defaults"
+
+	// ExperimentalKeepComments is the KeepComments option.
+	ExperimentalKeepComments ExperimentalFeature = "keepComments"
+
+	// ExperimentalParallelPatches is the MaxParallelism option.
+	ExperimentalParallelPatches ExperimentalFeature = "parallelPatches"
+
+	// ExperimentalNonKRM is the AllowNonKRM option.
+	ExperimentalNonKRM ExperimentalFeature = "nonKRM"
+
+	// ExperimentalSelect is the Select option, with the
+	// @source field of queries.
+	ExperimentalSelect ExperimentalFeature = "select"
+
+	// ExperimentalDiskClones is the Clones option naming a
+	// CloneCache made by NewDiskCloneCache, with the layout of
+	// the clones it keeps, which ListCachedClones and
+	// CollectCachedClones read.
+	ExperimentalDiskClones ExperimentalFeature = "diskClones"
+)
+
+// experimentalVersions holds the version of each experimental
+// feature, which goes up with every incompatible change to it.
+var experimentalVersions = map[ExperimentalFeature]int{
+	ExperimentalParameters:             1,
+	ExperimentalRegisteredPlugins:      1,
+	ExperimentalRegisteredEmitters:     1,
+	ExperimentalClusterScopedReferrals: 1,
+	ExperimentalDefaults:               1,
+	ExperimentalKeepComments:           1,
+	ExperimentalParallelPatches:        1,
+	ExperimentalNonKRM:                 1,
+	ExperimentalSelect:                 1,
+	ExperimentalDiskClones:             1,
+}
+
+// experimentalOptions holds, for each experimental feature
+// but that of the registered emitters, which Emit checks,
+// the options of the feature and whether they're used.
+var experimentalOptions = []struct {
+	feature ExperimentalFeature
+	options string
+	used    func(o *Options) bool
+}{
+	{ExperimentalParameters, "Parameters",
+		func(o *Options) bool { return o.Parameters != nil }},
+	{ExperimentalRegisteredPlugins, "RegisterPlugin",
+		func(o *Options) bool { return len(o.registeredPlugins) > 0 }},
+	{ExperimentalClusterScopedReferrals, "ClusterScopedReferrals",
+		func(o *Options) bool { return o.ClusterScopedReferrals != "" }},
+	{ExperimentalDefaults, "ApplyDefaults, StripDefaults or KeepDefaultsOf",
+		func(o *Options) bool {
+			return o.ApplyDefaults || o.StripDefaults || len(o.KeepDefaultsOf) > 0
+		}},
+	{ExperimentalKeepComments, "KeepComments",
+		func(o *Options) bool { return o.KeepComments }},
+	{ExperimentalParallelPatches, "MaxParallelism",
+		func(o *Options) bool { return o.MaxParallelism > 1 }},
+	{ExperimentalNonKRM, "AllowNonKRM",
+		func(o *Options) bool { return o.AllowNonKRM }},
+	{ExperimentalSelect, "Select",
+		func(o *Options) bool { return o.Select != nil }},
+	{ExperimentalDiskClones, "Clones",
+		func(o *Options) bool { return o.Clones != nil && o.Clones.onDisk }},
+}
+
+// ExperimentalFeatures returns the experimental features of this
+// release, with their versions, i.e. those ExperimentalOptions
+// may understand.
+func ExperimentalFeatures() map[ExperimentalFeature]int {
+	result := make(map[ExperimentalFeature]int, len(experimentalVersions))
+	for f, v := range experimentalVersions {
+		result[f] = v
+	}
+	return result
+}
+
+// ExperimentalOptions holds what an embedder negotiates of the
+// experimental features, e.g.
+//
+//	opts.Experimental = &krusty.ExperimentalOptions{
+//		Understands: map[krusty.ExperimentalFeature]int{
+//			krusty.ExperimentalParameters: 1,
+//		},
+//	}
+type ExperimentalOptions struct {
+	// Understands holds the experimental features the embedder
+	// understands, with the versions it was written against.
+	// A build fails if any isn't at that version in this
+	// release, or isn't in it at all.
+	Understands map[ExperimentalFeature]int
+}
+
+// understands returns whether o understands the feature f;
+// it doesn't if nil.
+func (o *ExperimentalOptions) understands(f ExperimentalFeature) bool {
+	if o == nil {
+		return false
+	}
+	_, ok := o.Understands[f]
+	return ok
+}
+
+// negotiate checks that the experimental features o understands
+// are those of this release, at the same versions.
+func (o *ExperimentalOptions) negotiate() error {
+	if o == nil {
+		return nil
+	}
+	for _, f := range sortedFeatures(o.Understands) {
+		v, ok := experimentalVersions[f]
+		if !ok {
+			return fmt.Errorf(
+				"unknown experimental feature %q, expected one of %s",
+				f, strings.Join(featureNames(), ", "))
+		}
+		if o.Understands[f] != v {
+			return fmt.Errorf(
+				"the experimental feature %q is understood at version %d, "+
+					"but this release has version %d",
+				f, o.Understands[f], v)
+		}
+	}
+	return nil
+}
+
+// checkExperimental negotiates the experimental features, and
+// checks that the options of those the embedder uses are of
+// features it understands.
+func (b *Kustomizer) checkExperimental() error {
+	e := b.options.Experimental
+	if err := e.negotiate(); err != nil {
+		return err
+	}
+	for _, x := range experimentalOptions {
+		if x.used(b.options) && !e.understands(x.feature) {
+			return notUnderstood(x.options, x.feature)
+		}
+	}
+	return nil
+}
+
+func notUnderstood(options string, f ExperimentalFeature) error {
+	return fmt.Errorf(
+		"the %s option needs the experimental feature %q "+
+			"to be understood, per the Experimental options", options, f)
+}
+
+func sortedFeatures(m map[ExperimentalFeature]int) []ExperimentalFeature {
+	result := make([]ExperimentalFeature, 0, len(m))
+	for f := range m {
+		result = append(result, f)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func featureNames() []string {
+	var result []string
+	for _, f := range sortedFeatures(experimentalVersions) {
+		result = append(result, string(f))
+	}
+	return result
+}
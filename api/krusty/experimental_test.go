@@ -0,0 +1,201 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// understanding returns the Experimental options of an
+// embedder understanding the features, at their versions
+// in this release.
+func understanding(features ...krusty.ExperimentalFeature) *krusty.ExperimentalOptions {
+	versions := krusty.ExperimentalFeatures()
+	o := &krusty.ExperimentalOptions{
+		Understands: make(map[krusty.ExperimentalFeature]int),
+	}
+	for _, f := range features {
+		o.Understands[f] = versions[f]
+	}
+	return o
+}
+
+func TestExperimentalFeatures(t *testing.T) {
+	assert.Equal(t,
+		map[krusty.ExperimentalFeature]int{
+			krusty.ExperimentalParameters:             1,
+			krusty.ExperimentalRegisteredPlugins:      1,
+			krusty.ExperimentalRegisteredEmitters:     1,
+			krusty.ExperimentalClusterScopedReferrals: 1,
+			krusty.ExperimentalDefaults:               1,
+			krusty.ExperimentalKeepComments:           1,
+			krusty.ExperimentalParallelPatches:        1,
+			krusty.ExperimentalNonKRM:                 1,
+			krusty.ExperimentalSelect:                 1,
+			krusty.ExperimentalDiskClones:             1,
+		},
+		krusty.ExperimentalFeatures())
+}
+
+func TestExperimentalNegotiation(t *testing.T) {
+	testCases := map[string]struct {
+		understands map[krusty.ExperimentalFeature]int
+		errMsg      string
+	}{
+		"not understood": {
+			errMsg: `the Parameters option needs the experimental ` +
+				`feature "parameters" to be understood`,
+		},
+		"other version": {
+			understands: map[krusty.ExperimentalFeature]int{
+				krusty.ExperimentalParameters: 2,
+			},
+			errMsg: `the experimental feature "parameters" is understood ` +
+				`at version 2, but this release has version 1`,
+		},
+		"unknown": {
+			understands: map[krusty.ExperimentalFeature]int{
+				krusty.ExperimentalParameters: 1,
+				"pipelines":                   1,
+			},
+			errMsg: `unknown experimental feature "pipelines", ` +
+				`expected one of clusterScopedReferrals, defaults, diskClones, ` +
+				`keepComments, nonKRM, parallelPatches, parameters, ` +
+				`registeredEmitters, registeredPlugins, select`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			th := kusttest_test.MakeHarness(t)
+			writeTenantOverlay(th)
+			opts := th.MakeDefaultOptions()
+			opts.Parameters = map[string]string{"TENANT": "acme"}
+			if tc.understands != nil {
+				opts.Experimental = &krusty.ExperimentalOptions{
+					Understands: tc.understands,
+				}
+			}
+			err := th.RunWithErr("tenant", opts)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestExperimentalUnused(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTenantOverlay(th)
+	th.WriteK("tenant", `
+resources:
+- ../base
+`)
+	// Understanding a feature doesn't require using it.
+	opts := th.MakeDefaultOptions()
+	opts.Experimental = &krusty.ExperimentalOptions{
+		Understands: krusty.ExperimentalFeatures(),
+	}
+	th.AssertActualEqualsExpected(th.Run("tenant", opts), `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+}
+
+func TestExperimentalOptionsNotUnderstood(t *testing.T) {
+	testCases := map[string]struct {
+		set    func(o *krusty.Options)
+		errMsg string
+	}{
+		"emptyParameters": {
+			set: func(o *krusty.Options) { o.Parameters = map[string]string{} },
+			errMsg: `the Parameters option needs the experimental ` +
+				`feature "parameters" to be understood`,
+		},
+		"registeredPlugin": {
+			set: func(o *krusty.Options) {
+				_ = o.RegisterPlugin("Annotator", func() resmap.Configurable {
+					return &annotator{}
+				})
+			},
+			errMsg: `the RegisterPlugin option needs the experimental ` +
+				`feature "registeredPlugins" to be understood`,
+		},
+		"clusterScopedReferrals": {
+			set: func(o *krusty.Options) {
+				o.ClusterScopedReferrals = krusty.ClusterScopedReferralsTarget
+			},
+			errMsg: `the ClusterScopedReferrals option needs the experimental ` +
+				`feature "clusterScopedReferrals" to be understood`,
+		},
+		"stripDefaults": {
+			set: func(o *krusty.Options) { o.StripDefaults = true },
+			errMsg: `the ApplyDefaults, StripDefaults or KeepDefaultsOf option ` +
+				`needs the experimental feature "defaults" to be understood`,
+		},
+		"keepComments": {
+			set: func(o *krusty.Options) { o.KeepComments = true },
+			errMsg: `the KeepComments option needs the experimental ` +
+				`feature "keepComments" to be understood`,
+		},
+		"maxParallelism": {
+			set: func(o *krusty.Options) { o.MaxParallelism = 2 },
+			errMsg: `the MaxParallelism option needs the experimental ` +
+				`feature "parallelPatches" to be understood`,
+		},
+		"allowNonKRM": {
+			set: func(o *krusty.Options) { o.AllowNonKRM = true },
+			errMsg: `the AllowNonKRM option needs the experimental ` +
+				`feature "nonKRM" to be understood`,
+		},
+		"select": {
+			set: func(o *krusty.Options) {
+				o.Select, _ = resmap.Query("kind=Service")
+			},
+			errMsg: `the Select option needs the experimental ` +
+				`feature "select" to be understood`,
+		},
+		"diskClones": {
+			set: func(o *krusty.Options) {
+				o.Clones, _ = krusty.NewDiskCloneCache(t.TempDir())
+			},
+			errMsg: `the Clones option needs the experimental ` +
+				`feature "diskClones" to be understood`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			th := kusttest_test.MakeHarness(t)
+			writeTenantOverlay(th)
+			opts := th.MakeDefaultOptions()
+			// Understanding others doesn't do.
+			opts.Experimental = understanding(krusty.ExperimentalRegisteredEmitters)
+			tc.set(&opts)
+			err := th.RunWithErr("tenant", opts)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestExperimentalRegisteredEmitterNotUnderstood(t *testing.T) {
+	opts := krusty.MakeDefaultOptions()
+	assert.NoError(t, opts.RegisterEmitter("names", kio.EmitterFunc(
+		func(w io.Writer, nodes []*yaml.RNode) error { return nil })))
+	err := krusty.MakeKustomizer(opts).Emit(ioutil.Discard, resmap.New(), "names")
+	assert.EqualError(t, err,
+		`the RegisterEmitter option needs the experimental feature `+
+			`"registeredEmitters" to be understood, per the Experimental options`)
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+// Two operators define a Database kind, with their names
+// at different paths; the configurations tell them apart
+// by the operator label.
+func TestFieldSpecSelectors(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+namePrefix: prod-
+commonLabels:
+  env: prod
+resources:
+- databases.yaml
+- secret.yaml
+configurations:
+- config.yaml
+`)
+	th.WriteF("databases.yaml", `
+apiVersion: acme.example.com/v1
+kind: Database
+metadata:
+  name: orders
+  labels:
+    operator: acme
+spec:
+  credentials:
+    secretName: creds
+  podLabels: {}
+---
+apiVersion: other.example.com/v1
+kind: Database
+metadata:
+  name: users
+  labels:
+    operator: other
+spec:
+  auth:
+    secret: creds
+  podLabels: {}
+`)
+	th.WriteF("secret.yaml", `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+`)
+	th.WriteF("config.yaml", `
+nameReference:
+- kind: Secret
+  fieldSpecs:
+  - kind: Database
+    path: spec/credentials/secretName
+    labelSelector: operator=acme
+  - kind: Database
+    path: spec/auth/secret
+    labelSelector: operator=other
+commonLabels:
+- kind: Database
+  path: spec/podLabels
+  create: true
+  labelSelector: operator=acme
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: acme.example.com/v1
+kind: Database
+metadata:
+  labels:
+    env: prod
+    operator: acme
+  name: prod-orders
+spec:
+  credentials:
+    secretName: prod-creds
+  podLabels:
+    env: prod
+---
+apiVersion: other.example.com/v1
+kind: Database
+metadata:
+  labels:
+    env: prod
+    operator: other
+  name: prod-users
+spec:
+  auth:
+    secret: prod-creds
+  podLabels: {}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  labels:
+    env: prod
+  name: prod-creds
+`)
+}
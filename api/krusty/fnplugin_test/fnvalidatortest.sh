@@ -0,0 +1,31 @@
+#!/bin/sh
+
+cat <<EOF
+apiVersion: config.kubernetes.io/v1alpha1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: junk
+results:
+  name: check-replicas
+  items:
+  - message: has a single replica
+    severity: warning
+    resourceRef:
+      apiVersion: apps/v1
+      kind: Deployment
+      metadata:
+        name: web
+    field:
+      path: spec.replicas
+  - message: has no owner label
+    severity: error
+    resourceRef:
+      apiVersion: apps/v1
+      kind: Deployment
+      metadata:
+        name: web
+EOF
+exit 1
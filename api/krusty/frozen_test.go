@@ -0,0 +1,99 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeFrozenBase(th kusttest_test.Harness) {
+	th.WriteK("base", `
+resources:
+- vendored.yaml
+- deployment.yaml
+`)
+	th.WriteF("base/vendored.yaml", `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+  annotations:
+    kustomize.config.k8s.io/frozen: "true"
+`)
+	th.WriteF("base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+}
+
+func TestFrozenResourceLeftAlone(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeFrozenBase(th)
+	th.WriteK("overlay", `
+resources:
+- ../base
+patches:
+- target:
+    kind: Deployment
+  patch: |-
+    - op: add
+      path: /spec
+      value:
+        replicas: 2
+`)
+	opts := th.MakeDefaultOptions()
+	opts.AddManagedbyLabel = true
+	m := th.Run("overlay", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize-unknown
+  name: web
+spec:
+  replicas: 2
+`)
+}
+
+func TestFrozenResourceChanged(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeFrozenBase(th)
+	th.WriteK("overlay", `
+namePrefix: p-
+resources:
+- ../base
+`)
+	err := th.RunWithErr("overlay", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"resource ~G_v1_ServiceAccount|~X|operator is annotated "+
+				"kustomize.config.k8s.io/frozen, but a transformer changed it")
+	}
+
+	opts := th.MakeDefaultOptions()
+	opts.WarnOnFrozenChanges = true
+	m := th.Run("overlay", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: p-web
+`)
+}
@@ -0,0 +1,174 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+// fakeHelm reports, as the data of a ConfigMap, the
+// arguments 'helm template' was called with, less
+// those that are file paths.
+const fakeHelm = `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo "v3.6.0"
+  exit 0
+fi
+args=""
+for a in "$@"; do
+  case "$a" in
+  /*) ;;
+  *) args="$args $a" ;;
+  esac
+done
+cat <<END
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: helm-args
+data:
+  args: "$args"
+END
+`
+
+func TestHelmChartTemplateOptions(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarnessWithTmpRoot(t)
+	defer th.Reset()
+	helm := filepath.Join(th.GetRoot(), "helm")
+	if err := ioutil.WriteFile(helm, []byte(fakeHelm), 0755); err != nil {
+		t.Fatal(err)
+	}
+	chart := filepath.Join(th.GetRoot(), "charts", "app")
+	if err := os.MkdirAll(chart, 0755); err != nil {
+		t.Fatal(err)
+	}
+	th.WriteF(filepath.Join(chart, "values.yaml"), `
+replicas: 1
+`)
+	th.WriteK(th.GetRoot(), `
+helmCharts:
+- name: app
+  releaseName: app
+  includeCRDs: true
+  skipTests: true
+  skipSchemaValidation: true
+  kubeVersion: 1.21.0
+  apiVersions:
+  - monitoring.coreos.com/v1
+  - cert-manager.io/v1
+`)
+	opts := th.MakeOptionsPluginsEnabled()
+	opts.PluginConfig.HelmConfig.Command = helm
+	m := th.Run(th.GetRoot(), opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  args: ' template app --values --include-crds --skip-tests --skip-schema-validation
+    --kube-version 1.21.0 --api-versions monitoring.coreos.com/v1 --api-versions cert-manager.io/v1'
+kind: ConfigMap
+metadata:
+  name: helm-args
+`)
+}
+
+func TestHelmChartSignedPulledByDigest(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarnessWithTmpRoot(t)
+	defer th.Reset()
+	log := filepath.Join(th.GetRoot(), "pulled")
+	helm := filepath.Join(th.GetRoot(), "helm")
+	err := ioutil.WriteFile(helm, []byte(`#!/bin/sh
+if [ "$1" = "pull" ]; then
+  echo "$@" >`+log+`
+  mkdir -p "$4/app"
+  echo "replicas: 2" >"$4/app/values.yaml"
+  exit 0
+fi
+`+fakeHelm[len("#!/bin/sh\n"):]), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writeFakeCommands(t, map[string]string{"cosign": `#!/bin/sh
+echo '[{"critical":{"image":{"docker-manifest-digest":"sha256:1234"}}}]'
+`})()
+	// Not the chart signed, so not the one used.
+	if err = os.MkdirAll(filepath.Join(th.GetRoot(), "charts", "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	th.WriteF(filepath.Join(th.GetRoot(), "charts", "app", "values.yaml"), `
+replicas: 1
+`)
+	th.WriteF(filepath.Join(th.GetRoot(), ".kustomize-policy.yaml"), `
+signatures:
+  key: cosign.pub
+`)
+	th.WriteK(th.GetRoot(), `
+helmCharts:
+- name: app
+  repo: oci://example.com/charts
+  version: 1.0.0
+  releaseName: app
+  valuesInline:
+    image: app:v1
+`)
+	opts := th.MakeOptionsPluginsEnabled()
+	opts.PluginConfig.HelmConfig.Command = helm
+	m := th.Run(th.GetRoot(), opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  args: ' template app --values'
+kind: ConfigMap
+metadata:
+  name: helm-args
+`)
+	b, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(
+		strings.TrimSpace(string(b)), " oci://example.com/charts/app@sha256:1234") {
+		t.Fatalf("pulled with %s", b)
+	}
+	if strings.Contains(string(b), th.GetRoot()) {
+		t.Fatalf("pulled to the chart home: %s", b)
+	}
+}
+
+func TestHelmChartSignedLocalOnly(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarnessWithTmpRoot(t)
+	defer th.Reset()
+	helm := filepath.Join(th.GetRoot(), "helm")
+	if err := ioutil.WriteFile(helm, []byte(fakeHelm), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(th.GetRoot(), "charts", "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	th.WriteF(filepath.Join(th.GetRoot(), "charts", "app", "values.yaml"), `
+replicas: 1
+`)
+	th.WriteF(filepath.Join(th.GetRoot(), ".kustomize-policy.yaml"), `
+signatures:
+  key: cosign.pub
+`)
+	th.WriteK(th.GetRoot(), `
+helmCharts:
+- name: app
+  releaseName: app
+`)
+	opts := th.MakeOptionsPluginsEnabled()
+	opts.PluginConfig.HelmConfig.Command = helm
+	err := th.RunWithErr(th.GetRoot(), opts)
+	if err == nil || !strings.Contains(err.Error(),
+		"charts must be signed, which only charts in OCI registries can be, "+
+			"but chart app is local, with no repo to pull it from") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
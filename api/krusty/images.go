@@ -0,0 +1,51 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/filters/imagetag"
+)
+
+// ImageReference is a reference to a container image
+// by a resource in the output of a build.
+type ImageReference struct {
+	// Image is the reference, e.g. nginx:1.21.
+	Image string `json:"image"`
+
+	// Resource is the id of the resource referring to the image.
+	Resource string `json:"resource"`
+
+	// Origin is the file the resource was loaded from, if any,
+	// i.e. unless it was generated.
+	Origin string `json:"origin,omitempty"`
+}
+
+// Images performs a kustomization, as Run does, and returns the
+// references to container images by the resources built: those
+// in containers and initContainers fields, and those in the fields
+// the image transformer is configured to change, including those
+// of custom resources added by configurations.
+func (b *Kustomizer) Images(
+	fSys filesys.FileSystem, path string) ([]ImageReference, error) {
+	m, kt, err := b.build(fSys, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []ImageReference
+	for _, r := range m.Resources() {
+		images, err := imagetag.Images(r.Node(), kt.ImageFieldSpecs())
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range images {
+			result = append(result, ImageReference{
+				Image:    image,
+				Resource: r.CurId().String(),
+				Origin:   r.Origin(),
+			})
+		}
+	}
+	return result, nil
+}
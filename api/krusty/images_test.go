@@ -0,0 +1,82 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestImages(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("base", `
+resources:
+- deployment.yaml
+- runner.yaml
+configurations:
+- config.yaml
+`)
+	th.WriteF("base/config.yaml", `
+images:
+- path: spec/runner/image
+  kind: Runner
+`)
+	th.WriteF("base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      initContainers:
+      - name: init
+        image: init:v1
+`)
+	th.WriteF("base/runner.yaml", `
+apiVersion: example.com/v1
+kind: Runner
+metadata:
+  name: runner
+spec:
+  runner:
+    image: runner:v1
+`)
+	th.WriteK("overlay", `
+resources:
+- ../base
+namePrefix: p-
+images:
+- name: app
+  newTag: v2
+`)
+	opts := th.MakeDefaultOptions()
+	images, err := krusty.MakeKustomizer(&opts).Images(th.GetFSys(), "overlay")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []krusty.ImageReference{
+		{
+			Image:    "app:v2",
+			Resource: "apps_v1_Deployment|~X|p-app",
+			Origin:   "/base/deployment.yaml",
+		},
+		{
+			Image:    "init:v1",
+			Resource: "apps_v1_Deployment|~X|p-app",
+			Origin:   "/base/deployment.yaml",
+		},
+		{
+			Image:    "runner:v1",
+			Resource: "example.com_v1_Runner|~X|p-runner",
+			Origin:   "/base/runner.yaml",
+		},
+	}, images)
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestInputLimits(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+resources:
+- cm.yaml
+`)
+	th.WriteF("cm.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  a: &a [x, x, x, x]
+  b: [*a, *a, *a, *a]
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  a:
+  - x
+  - x
+  - x
+  - x
+  b:
+  - - x
+    - x
+    - x
+    - x
+  - - x
+    - x
+    - x
+    - x
+  - - x
+    - x
+    - x
+    - x
+  - - x
+    - x
+    - x
+    - x
+kind: ConfigMap
+metadata:
+  name: cm
+`)
+
+	opts := th.MakeDefaultOptions()
+	opts.InputLimits = &kio.InputLimits{MaxAliasExpansion: 10}
+	err := th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"the aliases of document 0 expand to more than 10 nodes")
+	}
+
+	opts.InputLimits = &kio.InputLimits{MaxInputSize: 50}
+	err = th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the input exceeds the limit of 50 bytes")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestKeepComments(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1 # the default
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`)
+	th.WriteF("patch.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  # Three, for availability.
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        # Pinned until the upgrade.
+        image: nginx:1.21
+      - name: logs # Ships the logs of web.
+        image: fluentd
+`)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+patchesStrategicMerge:
+- patch.yaml
+patches:
+- patch: |-
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: web
+    spec:
+      # So that it isn't restarted too soon.
+      minReadySeconds: 10
+`)
+	opts := th.MakeDefaultOptions()
+	opts.KeepComments = true
+	opts.Experimental = understanding(krusty.ExperimentalKeepComments)
+	k := krusty.MakeKustomizer(&opts)
+	m, err := k.Run(th.GetFSys(), ".")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var out bytes.Buffer
+	assert.NoError(t, k.Emit(&out, m, ""))
+	// The value the patch changes loses its comment, which
+	// would be stale, for that of the patch.
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  # So that it isn't restarted too soon.
+  minReadySeconds: 10
+  # Three, for availability.
+  replicas: 3
+  template:
+    spec:
+      containers:
+      # Pinned until the upgrade.
+      - image: nginx:1.21
+        name: web
+      - image: fluentd
+        name: logs # Ships the logs of web.
+`, out.String())
+
+	// Without the option, the output has no comments.
+	th.AssertActualEqualsExpected(th.Run(".", th.MakeDefaultOptions()), `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  minReadySeconds: 10
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - image: nginx:1.21
+        name: web
+      - image: fluentd
+        name: logs
+`)
+}
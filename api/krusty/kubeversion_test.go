@@ -0,0 +1,106 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+)
+
+func TestKubeVersionPassedToHelm(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarnessWithTmpRoot(t)
+	defer th.Reset()
+	helm := filepath.Join(th.GetRoot(), "helm")
+	if err := ioutil.WriteFile(helm, []byte(fakeHelm), 0755); err != nil {
+		t.Fatal(err)
+	}
+	chart := filepath.Join(th.GetRoot(), "charts", "app")
+	if err := os.MkdirAll(chart, 0755); err != nil {
+		t.Fatal(err)
+	}
+	th.WriteF(filepath.Join(chart, "values.yaml"), `
+replicas: 1
+`)
+	th.WriteK(th.GetRoot(), `
+kubeVersion: "1.20"
+helmCharts:
+- name: app
+  releaseName: app
+`)
+	opts := th.MakeOptionsPluginsEnabled()
+	opts.PluginConfig.HelmConfig.Command = helm
+	m := th.Run(th.GetRoot(), opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  args: ' template app --values --kube-version 1.20'
+kind: ConfigMap
+metadata:
+  name: helm-args
+`)
+}
+
+func TestKubeVersionOption(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	defer openapi.ResetOpenAPI()
+	th.WriteK(".", `
+kubeVersion: "1.20"
+transformers:
+- migrate.yaml
+resources:
+- pdb.yaml
+`)
+	th.WriteF("migrate.yaml", `
+apiVersion: builtin
+kind: ApiVersionMigrationTransformer
+metadata:
+  name: migrate
+`)
+	th.WriteF("pdb.yaml", `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+`)
+	// Kubernetes 1.20 doesn't serve policy/v1.
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+`)
+	if v := openapi.GetSchemaVersion(); v != "v1204" {
+		t.Fatalf("unexpected schema version %s", v)
+	}
+
+	opts := th.MakeDefaultOptions()
+	opts.KubeVersion = "1.25"
+	m = th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+`)
+}
+
+func TestKubeVersionInvalid(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+kubeVersion: latest
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "invalid Kubernetes version 'latest', expected e.g. 1.29" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
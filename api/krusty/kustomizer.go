@@ -6,9 +6,13 @@ package krusty
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 
 	"sigs.k8s.io/kustomize/api/builtins"
 	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/filters/defaults"
+	"sigs.k8s.io/kustomize/api/internal/git"
+	"sigs.k8s.io/kustomize/api/internal/plugins/builtinhelpers"
 	pLdr "sigs.k8s.io/kustomize/api/internal/plugins/loader"
 	"sigs.k8s.io/kustomize/api/internal/target"
 	"sigs.k8s.io/kustomize/api/konfig"
@@ -17,7 +21,9 @@ import (
 	"sigs.k8s.io/kustomize/api/provider"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/sidecar"
 	"sigs.k8s.io/kustomize/kyaml/openapi"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 // Kustomizer performs kustomizations.
@@ -27,24 +33,48 @@ import (
 // To use, load a filesystem with kustomization files (any
 // number of overlays and bases), then make a Kustomizer
 // injected with the given fileystem, then call Run.
+//
+// Runs may be made from several goroutines at once, unless
+// the options hold a UsageReport.  Those of kustomizations
+// using distinct OpenAPI schemas are made one at a time.
 type Kustomizer struct {
 	options     *Options
 	depProvider *provider.DepProvider
+	clones      *git.CloneCache
 }
 
 // MakeKustomizer returns an instance of Kustomizer.
 func MakeKustomizer(o *Options) *Kustomizer {
-	return &Kustomizer{
+	b := &Kustomizer{
 		options:     o,
 		depProvider: provider.NewDepProvider(),
 	}
+	b.depProvider.GetResourceFactory().SetInputLimits(o.InputLimits)
+	if o.Clones != nil {
+		b.clones = o.Clones.cache
+	} else if o.ReuseClones {
+		b.clones = git.NewCloneCache(
+			filesys.MakeFsOnDisk(), git.ClonerUsingGitExec)
+	}
+	return b
+}
+
+// Cleanup removes the clones kept for the ReuseClones option,
+// but not those of the Clones option's cache.
+func (b *Kustomizer) Cleanup() error {
+	if b.clones == nil || b.options.Clones != nil {
+		return nil
+	}
+	return b.clones.Cleanup()
 }
 
 // Run performs a kustomization.
 //
 // It reads given path from the given file system, interprets it as
 // a kustomization.yaml file, perform the kustomization it represents,
-// and return the resulting resources.
+// and return the resulting resources.  The path may be that of the
+// directory holding the file, or of the file itself, which may then
+// be named otherwise, e.g. prod.kustomization.yaml.
 //
 // Any files referenced by the kustomization must be present on the
 // filesystem.  One may call Run any number of times, on any number
@@ -52,45 +82,235 @@ func MakeKustomizer(o *Options) *Kustomizer {
 // and Run can be called on each of them).
 func (b *Kustomizer) Run(
 	fSys filesys.FileSystem, path string) (resmap.ResMap, error) {
+	return b.run(fSys, path, nil)
+}
+
+// RunWithInput is like Run, but additionally treats the given
+// YAML stream as if its resources were listed in the
+// resources field of the kustomization found at path.
+//
+// This allows, for example, the output of another tool
+// to be piped into a build without writing it to a file.
+func (b *Kustomizer) RunWithInput(
+	fSys filesys.FileSystem, path string, input []byte) (resmap.ResMap, error) {
+	return b.run(fSys, path, input)
+}
+
+func (b *Kustomizer) run(
+	fSys filesys.FileSystem, path string, input []byte) (resmap.ResMap, error) {
+	m, _, err := b.build(fSys, path, input)
+	return m, err
+}
+
+// build is run, also returning the target built.
+func (b *Kustomizer) build(fSys filesys.FileSystem, path string, input []byte) (
+	m resmap.ResMap, kt *target.KustTarget, err error) {
+	defer recoverInternalError(&err)
+	if err = b.checkExperimental(); err != nil {
+		return nil, nil, err
+	}
+	if m, kt, err = b.buildSelected(fSys, path, input); err != nil {
+		return nil, nil, err
+	}
+	m.RemoveBuildAnnotations()
+	if err = b.checkPlaceholders(m); err != nil {
+		return nil, nil, err
+	}
+	return m, kt, nil
+}
+
+// buildSelected is buildSkipping, keeping only the resources the
+// Select option matches, if set, and leaving out the entries of
+// the kustomization that none of them can come from.  If the build
+// then fails, or the resources selected refer to resources it
+// lacks, it's made again with every entry.
+func (b *Kustomizer) buildSelected(
+	fSys filesys.FileSystem, path string, input []byte) (
+	resmap.ResMap, *target.KustTarget, error) {
+	q := b.options.Select
+	if q == nil {
+		return b.buildSkipping(fSys, path, input, nil)
+	}
+	var skipped []string
+	skip := func(entry string) bool {
+		if b.options.DebugDumpDir != "" || !q.ExcludesSource(entry) {
+			return false
+		}
+		skipped = append(skipped, entry)
+		return true
+	}
+	m, kt, err := b.buildSkipping(fSys, path, input, skip)
+	if err != nil && len(skipped) == 0 {
+		return nil, nil, err
+	}
+	if err == nil {
+		selected, err := selectResources(m, q)
+		if err != nil || len(skipped) == 0 {
+			return selected, kt, err
+		}
+		refs, err := kt.UnresolvedReferences(selected.Resources(), m)
+		if err != nil || len(refs) == 0 {
+			return selected, kt, err
+		}
+		b.options.Warnings.Add(types.SkippedSourcesWarning(path, refs))
+	} else {
+		b.options.Warnings.Add(types.SkippedSourcesWarning(path, nil))
+	}
+	if m, kt, err = b.buildSkipping(fSys, path, input, nil); err != nil {
+		return nil, nil, err
+	}
+	m, err = selectResources(m, q)
+	return m, kt, err
+}
+
+// selectResources returns the resources of m the query matches.
+func selectResources(m resmap.ResMap, q *resmap.ResQuery) (resmap.ResMap, error) {
+	resources, err := q.Select(m)
+	if err != nil {
+		return nil, err
+	}
+	result := resmap.New()
+	for _, r := range resources {
+		if err = result.Append(r); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// buildSkipping is build, leaving the build annotations in and the
+// placeholders unchecked, and leaving out the entries of the
+// resources of the kustomization that skip, if not nil, returns
+// true for.
+func (b *Kustomizer) buildSkipping(
+	fSys filesys.FileSystem, path string, input []byte,
+	skip func(entry string) bool) (
+	m resmap.ResMap, kt *target.KustTarget, err error) {
+	b = b.withRequirements(fSys, path)
 	resmapFactory := resmap.NewFactory(b.depProvider.GetResourceFactory())
 	lr := fLdr.RestrictionNone
 	if b.options.LoadRestrictions == types.LoadRestrictionsRootOnly {
 		lr = fLdr.RestrictionRootOnly
 	}
-	ldr, err := fLdr.NewLoader(lr, path, fSys)
+	cloner := git.ClonerUsingGitExec
+	if b.clones != nil {
+		cloner = b.clones.Cloner()
+	}
+	cloner = reportingFetches(cloner, b.options.Progress)
+	policy, err := b.signaturePolicy(findPolicy(fSys, path))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	// A path to a file, rather than a directory, names
+	// the kustomization file to build, by any name.
+	dir, kustFile := path, ""
+	if fSys.Exists(path) && !fSys.IsDir(path) {
+		dir, kustFile = filepath.Split(path)
+		if dir == "" {
+			dir = filesys.SelfDir
+		}
+	}
+	ldr, err := fLdr.NewLoaderUsingRewriter(
+		lr, dir, fSys, cloner, policy, b.options.RemoteRefRewriter)
+	if err != nil {
+		return nil, nil, err
 	}
 	defer ldr.Cleanup()
-	kt := target.NewKustTarget(
+	// Copied, as the kustomization may set the kubeVersion it holds.
+	pc := *b.options.PluginConfig
+	pc.Policy = policy
+	pc.RemoteRefRewriter = b.options.RemoteRefRewriter
+	// The plugin configs are always located on disk, regardless of the fSys passed in
+	pl := pLdr.NewLoader(&pc, resmapFactory, filesys.MakeFsOnDisk())
+	for name, f := range b.options.registeredPlugins {
+		pl.RegisterPlugin(name, f)
+	}
+	pl.SetUsageReport(b.options.UsageReport)
+	// The persistent exec functions of the build are
+	// stopped once it ends.
+	sidecars := &sidecar.Pool{}
+	defer func() {
+		if closeErr := sidecars.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	pl.SetSidecars(sidecars)
+	kt = target.NewKustTarget(
 		ldr,
 		b.depProvider.GetFieldValidator(),
 		resmapFactory,
-		// The plugin configs are always located on disk, regardless of the fSys passed in
-		pLdr.NewLoader(b.options.PluginConfig, resmapFactory, filesys.MakeFsOnDisk()),
+		pl,
 	)
+	kt.SetKustomizationFile(kustFile)
+	disabled, disableNameRefs, err := b.disabledBuiltins()
+	if err != nil {
+		return nil, nil, err
+	}
+	inTargetNs, err := b.clusterScopedReferralsInTargetNamespace()
+	if err != nil {
+		return nil, nil, err
+	}
+	var snapshot func(dir, step string, m resmap.ResMap) error
+	if b.options.DebugDumpDir != "" {
+		d, err := newDebugDumper(fSys, b.options.DebugDumpDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		snapshot = d.dump
+	}
+	schema := &buildSchema{kustomizer: b, pc: &pc}
+	kt.SetOptions(target.Options{
+		StrictDeprecations:    b.options.StrictDeprecations,
+		DisabledBuiltins:      disabled,
+		DisableNameReferences: disableNameRefs,
+		TargetNsReferrals:     inTargetNs,
+		WarnOnFrozenChanges:   b.options.WarnOnFrozenChanges,
+		LogCreatedFields:      b.options.LogCreatedFields,
+		KeepComments:          b.options.KeepComments,
+		StrictGeneratedNames:  b.options.StrictGeneratedNames,
+		AllowNonKRM:           b.options.AllowNonKRM,
+		MaxDepth:              b.maxDepth(),
+		MaxParallelism:        b.options.MaxParallelism,
+		Usage:                 b.options.UsageReport,
+		Warnings:              b.options.Warnings,
+		Progress:              b.options.Progress,
+		Snapshot:              snapshot,
+		EnvCapture:            b.options.EnvCapture,
+		CheckRequirements:     b.checkRequirements,
+		Parameters:            b.options.Parameters,
+		UseBaseSchema:         schema.useBase,
+		SkipSource:            skip,
+	})
 	err = kt.Load()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if input != nil {
+		in, err := resmapFactory.NewResMapFromBytes(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		kt.SetInput(in)
 	}
 	var bytes []byte
 	if openApiPath, exists := kt.Kustomization().OpenAPI["path"]; exists {
 		bytes, err = ldr.Load(filepath.Join(ldr.Root(), openApiPath))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
-	err = openapi.SetSchema(kt.Kustomization().OpenAPI, bytes, true)
-	if err != nil {
-		return nil, err
+	if err = schema.use(kt.Kustomization(), bytes); err != nil {
+		return nil, nil, err
 	}
-	var m resmap.ResMap
+	defer schema.release()
 	m, err = kt.MakeCustomizedResMap()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if b.options.DoLegacyResourceSort {
 		builtins.NewLegacyOrderTransformerPlugin().Transform(m)
+		b.options.UsageReport.RecordBuiltin(
+			builtinhelpers.LegacyOrderTransformer.String(), 1)
 	}
 	if b.options.AddManagedbyLabel {
 		t := builtins.LabelTransformerPlugin{
@@ -102,8 +322,167 @@ func (b *Kustomizer) Run(
 				CreateIfNotPresent: true,
 			}},
 		}
-		t.Transform(m)
+		t.Transform(unfrozen(m))
+		b.options.UsageReport.RecordBuiltin(
+			builtinhelpers.LabelTransformer.String(), 1)
 	}
-	m.RemoveBuildAnnotations()
-	return m, nil
+	if b.options.ApplyDefaults || b.options.StripDefaults {
+		if err = b.applyDefaults(m, path); err != nil {
+			return nil, nil, err
+		}
+	}
+	// The hints hold the final ids, so they're made last,
+	// and only for the kustomization built.
+	if h := kt.Kustomization().ApplyHints; h != nil {
+		t := builtins.ApplyHintsTransformerPlugin{
+			CustomResources: h.CustomResources,
+			Webhooks:        h.Webhooks,
+		}
+		if err = t.Transform(m); err != nil {
+			return nil, nil, err
+		}
+		b.options.UsageReport.RecordBuiltin(
+			builtinhelpers.ApplyHintsTransformer.String(), 1)
+	}
+	return m, kt, nil
+}
+
+// applyDefaults sets the defaults of the schemas of the
+// resources of m, other than the raw documents, to the fields
+// they omit, or strips the fields equal to them, as the options
+// say, warning of the kinds it can't, having builtin schemas.
+// It's to run while the schema of the build is in use.
+func (b *Kustomizer) applyDefaults(m resmap.ResMap, path string) error {
+	if b.options.ApplyDefaults && b.options.StripDefaults {
+		return fmt.Errorf("defaults can't be both applied and stripped")
+	}
+	crds, err := defaults.SchemasOfCRDs(m.ToRNodeSlice())
+	if err != nil {
+		return err
+	}
+	leftAsIs := make(map[string]bool)
+	f := defaults.Filter{
+		CRDs:  crds,
+		Strip: b.options.StripDefaults,
+		LeftAsIs: func(t kyaml.TypeMeta) {
+			leftAsIs[t.Kind] = true
+		},
+	}
+	kept := make(map[string]bool)
+	for _, kind := range b.options.KeepDefaultsOf {
+		kept[kind] = f.Strip
+	}
+	for _, r := range m.Resources() {
+		if r.IsRaw() || kept[r.GetKind()] {
+			continue
+		}
+		if err = r.ApplyFilter(f); err != nil {
+			if f.Strip {
+				return fmt.Errorf("stripping the defaults of %s: %v", r.CurId(), err)
+			}
+			return fmt.Errorf("setting the defaults of %s: %v", r.CurId(), err)
+		}
+	}
+	if len(leftAsIs) > 0 {
+		kinds := make([]string, 0, len(leftAsIs))
+		for kind := range leftAsIs {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		b.options.Warnings.Add(types.BuiltinDefaultsWarning(path, kinds))
+	}
+	return nil
+}
+
+// unfrozen returns the resources of m not annotated as frozen,
+// other than the raw documents.
+func unfrozen(m resmap.ResMap) resmap.ResMap {
+	result := resmap.New()
+	for _, r := range m.Resources() {
+		if !r.IsFrozen() && !r.IsRaw() {
+			result.Append(r)
+		}
+	}
+	return result
+}
+
+// reportingFetches returns the cloner reporting the
+// fetches it makes to progress, if it isn't nil.
+func reportingFetches(cloner git.Cloner, progress types.ProgressFunc) git.Cloner {
+	if progress == nil {
+		return cloner
+	}
+	return func(rs *git.RepoSpec) error {
+		repo := rs.CloneSpec()
+		if rs.Ref != "" {
+			repo += "?ref=" + rs.Ref
+		}
+		progress(types.ProgressEvent{Kind: types.ProgressFetchStarted, Repo: repo})
+		err := cloner(rs)
+		progress(types.ProgressEvent{
+			Kind: types.ProgressFetchFinished, Repo: repo, Err: err})
+		return err
+	}
+}
+
+// maxDepth returns the MaxDepth option, its zero value
+// meaning DefaultMaxDepth.
+func (b *Kustomizer) maxDepth() int {
+	if b.options.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return b.options.MaxDepth
+}
+
+// disabledBuiltins returns the builtin plugins named by the
+// DisabledBuiltins option, and whether it names the
+// NameReferenceTransformer.
+func (b *Kustomizer) disabledBuiltins() (
+	map[builtinhelpers.BuiltinPluginType]bool, bool, error) {
+	result := make(map[builtinhelpers.BuiltinPluginType]bool)
+	nameRefs := false
+	for _, n := range b.options.DisabledBuiltins {
+		if n == NameReferenceTransformer {
+			nameRefs = true
+			continue
+		}
+		bpt := builtinhelpers.GetBuiltinPluginType(n)
+		if bpt == builtinhelpers.Unknown {
+			return nil, false, fmt.Errorf("cannot disable unknown builtin '%s'", n)
+		}
+		result[bpt] = true
+	}
+	if b.options.DoLegacyResourceSort && result[builtinhelpers.LegacyOrderTransformer] {
+		return nil, false, fmt.Errorf(
+			"the legacy resource sort requires the builtin %s, which is disabled",
+			builtinhelpers.LegacyOrderTransformer)
+	}
+	if b.options.AddManagedbyLabel && result[builtinhelpers.LabelTransformer] {
+		return nil, false, fmt.Errorf(
+			"the managed-by label requires the builtin %s, which is disabled",
+			builtinhelpers.LabelTransformer)
+	}
+	return result, nameRefs, nil
+}
+
+// honorKubeVersion passes the version of Kubernetes the build targets
+// on to plugins and, unless the kustomization specifies the schema
+// to use, returns the version of the builtin schema matching it.
+func (b *Kustomizer) honorKubeVersion(
+	k types.Kustomization, pc *types.PluginConfig) (string, error) {
+	kubeVersion := b.options.KubeVersion
+	if kubeVersion == "" {
+		kubeVersion = k.KubeVersion
+	}
+	if kubeVersion == "" {
+		return "", nil
+	}
+	if _, err := openapi.ParseKubeVersion(kubeVersion); err != nil {
+		return "", err
+	}
+	pc.KubeVersion = kubeVersion
+	if len(k.OpenAPI) > 0 {
+		return "", nil
+	}
+	return openapi.SchemaVersionFor(kubeVersion)
 }
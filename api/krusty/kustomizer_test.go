@@ -5,6 +5,7 @@ package krusty_test
 
 import (
 	"strings"
+	"sync"
 	"testing"
 
 	"sigs.k8s.io/kustomize/api/filesys"
@@ -25,3 +26,84 @@ func TestEmptyFileSystem(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestConcurrentRuns(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	deployment := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: sidecar:v1
+`
+	patch := `
+patchesStrategicMerge:
+- |-
+  apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: app
+  spec:
+    template:
+      spec:
+        containers:
+        - name: sidecar
+          image: sidecar:v2
+`
+	roots := map[string]string{
+		"plain":   "namePrefix: plain-\n",
+		"kube120": "kubeVersion: \"1.20\"\n",
+		"kube121": "kubeVersion: \"1.21\"\nnamePrefix: kube-\n",
+	}
+	for root, k := range roots {
+		fSys.WriteFile(root+"/kustomization.yaml",
+			[]byte("resources:\n- deployment.yaml\n"+k+patch))
+		fSys.WriteFile(root+"/deployment.yaml", []byte(deployment))
+	}
+	b := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	expected := make(map[string]string)
+	for root := range roots {
+		m, err := b.Run(fSys, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		yml, err := m.AsYaml()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(yml), "sidecar:v2") {
+			t.Fatalf("expected the patch to be applied in %s, got:\n%s", root, yml)
+		}
+		expected[root] = string(yml)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		for root := range roots {
+			wg.Add(1)
+			go func(root string) {
+				defer wg.Done()
+				m, err := b.Run(fSys, root)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				yml, err := m.AsYaml()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if string(yml) != expected[root] {
+					t.Errorf("unexpected output of %s:\n%s", root, yml)
+				}
+			}(root)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeIssuedIngress(th kusttest_test.Harness) {
+	th.WriteF("ingress.yaml", `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  annotations:
+    cert-manager.io/cluster-issuer: letsencrypt-prod
+    team: payments
+  labels:
+    tier: edge
+`)
+}
+
+func TestCommonAnnotationsKeepExisting(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeIssuedIngress(th)
+	th.WriteK(".", `
+resources:
+- ingress.yaml
+commonAnnotations:
+  cert-manager.io/cluster-issuer: letsencrypt-staging
+  team: web
+  owner: platform
+commonAnnotationsOptions:
+  keys:
+    cert-manager.io/cluster-issuer: keep-existing
+commonLabels:
+  tier: web
+commonLabelsOptions:
+  onConflict: keep-existing
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  annotations:
+    cert-manager.io/cluster-issuer: letsencrypt-prod
+    owner: platform
+    team: web
+  labels:
+    tier: edge
+  name: web
+`)
+}
+
+func TestCommonAnnotationsConflictError(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeIssuedIngress(th)
+	th.WriteK(".", `
+resources:
+- ingress.yaml
+commonAnnotations:
+  cert-manager.io/cluster-issuer: letsencrypt-prod
+  team: web
+commonAnnotationsOptions:
+  onConflict: error
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		`: team is already "payments", not "web"`)
+}
+
+func TestCommonLabelsUnknownConflictPolicy(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeIssuedIngress(th)
+	th.WriteK(".", `
+resources:
+- ingress.yaml
+commonLabels:
+  tier: web
+commonLabelsOptions:
+  keys:
+    tier: ignore
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		`the policy of key tier: unknown conflict policy "ignore", `+
+			`expected one of override, keep-existing or error`)
+}
+
+// The policy is decided once by resource, from its labels, so
+// that the selector of a workload and the labels of its pod
+// template don't diverge.
+func TestCommonLabelsKeepExistingInWorkload(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: old
+spec:
+  template:
+    metadata:
+      labels:
+        app: old
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  selector:
+    matchLabels:
+      app: worker
+  template:
+    metadata:
+      labels:
+        app: worker
+`)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+commonLabels:
+  app: new
+commonLabelsOptions:
+  onConflict: keep-existing
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: old
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: old
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: new
+  name: worker
+spec:
+  selector:
+    matchLabels:
+      app: new
+  template:
+    metadata:
+      labels:
+        app: new
+`)
+}
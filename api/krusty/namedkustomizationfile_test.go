@@ -0,0 +1,93 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeSharedDirectory(th kusttest_test.Harness) {
+	th.WriteF("app/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteF("app/base.kustomization.yaml", `
+resources:
+- service.yaml
+commonAnnotations:
+  app: web
+`)
+}
+
+func TestNamedKustomizationFileResource(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedDirectory(th)
+	th.WriteF("app/prod.kustomization.yaml", `
+resources:
+- ./base.kustomization.yaml
+namePrefix: prod-
+`)
+	th.WriteK("app", `
+resources:
+- ./prod.kustomization.yaml
+namespace: prod
+`)
+	m := th.Run("app", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    app: web
+  name: prod-web
+  namespace: prod
+`)
+	m = th.Run("app/prod.kustomization.yaml", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    app: web
+  name: prod-web
+`)
+}
+
+func TestNamedKustomizationFileInSubdirectory(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedDirectory(th)
+	th.WriteK(".", `
+resources:
+- app/base.kustomization.yaml
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    app: web
+  name: web
+`)
+}
+
+func TestNamedKustomizationFileCycle(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("a.kustomization.yaml", `
+resources:
+- b.kustomization.yaml
+`)
+	th.WriteF("b.kustomization.yaml", `
+resources:
+- a.kustomization.yaml
+`)
+	err := th.RunWithErr("a.kustomization.yaml", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(), "cycle detected: /a.kustomization.yaml -> "+
+		"/b.kustomization.yaml -> /a.kustomization.yaml")
+}
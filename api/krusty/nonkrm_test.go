@@ -0,0 +1,101 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeNonKRMBase(th kusttest_test.Harness) {
+	th.WriteK("base", `
+namePrefix: p-
+namespace: apps
+commonLabels:
+  app: web
+resources:
+- deployment.yaml
+- data.yaml
+`)
+	th.WriteF("base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	th.WriteF("base/data.yaml", `
+regions:
+- us-east1
+- europe-west4
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+metadata:
+  name: no-kind
+`)
+}
+
+func TestNonKRMDocuments(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeNonKRMBase(th)
+	th.WriteK("overlay", `
+nameSuffix: -prod
+resources:
+- ../base
+`)
+	opts := th.MakeDefaultOptions()
+	opts.AllowNonKRM = true
+	opts.Experimental = understanding(krusty.ExperimentalNonKRM)
+	opts.AddManagedbyLabel = true
+	m := th.Run("overlay", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: web
+    app.kubernetes.io/managed-by: kustomize-unknown
+  name: p-web-prod
+  namespace: apps
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+---
+regions:
+- us-east1
+- europe-west4
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    app: web
+    app.kubernetes.io/managed-by: kustomize-unknown
+  name: p-settings-prod
+  namespace: apps
+---
+metadata:
+  name: no-kind
+`)
+}
+
+func TestNonKRMDocumentsNotAllowed(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeNonKRMBase(th)
+	err := th.RunWithErr("base", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "missing Resource metadata")
+	}
+}
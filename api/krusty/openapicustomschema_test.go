@@ -232,3 +232,49 @@ func TestCustomOpenAPIFieldFromComponent(t *testing.T) {
 	th.Run("prod", th.MakeDefaultOptions())
 	assert.Equal(t, "using custom schema from file provided", openapi.GetSchemaVersion())
 }
+
+// The schema of a build doesn't depend on those of the builds
+// preceding it, with or without one.
+func TestCustomOpenApiFieldInterleavedBuilds(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("a", `
+resources:
+- mycrd.yaml
+openapi:
+  path: mycrd_schema.json
+`+customSchemaPatch)
+	writeCustomResource(th, "a/mycrd.yaml")
+	writeTestSchema(th, "a/")
+	th.WriteK("b", `
+resources:
+- mycrd.yaml
+`+customSchemaPatch)
+	writeCustomResource(th, "b/mycrd.yaml")
+	th.WriteK("c", `
+resources:
+- ../a
+`+customSchemaPatch)
+	// Without the schema, the list of containers is replaced.
+	replaced := `
+apiVersion: example.com/v1alpha1
+kind: MyCRD
+metadata:
+  name: service
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: server
+`
+	for _, order := range [][]string{{"b", "a", "b", "a"}, {"a", "b", "b"}, {"c", "b", "c"}} {
+		for _, dir := range order {
+			m := th.Run(dir, th.MakeDefaultOptions())
+			if dir == "b" {
+				th.AssertActualEqualsExpected(m, replaced)
+			} else {
+				th.AssertActualEqualsExpected(m, patchedCustomResource)
+			}
+		}
+	}
+}
@@ -4,8 +4,12 @@
 package krusty
 
 import (
+	"fmt"
+
 	"sigs.k8s.io/kustomize/api/internal/plugins/builtinhelpers"
+	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/kio"
 )
 
 // Options holds high-level kustomize configuration options,
@@ -32,6 +36,296 @@ type Options struct {
 
 	// Options related to kustomize plugins.
 	PluginConfig *types.PluginConfig
+
+	// When true, a kustomization (or any base or component
+	// it refers to) using deprecated fields, e.g. vars or
+	// patchesStrategicMerge, fails the build.
+	StrictDeprecations bool
+
+	// KubeVersion is the version of Kubernetes the build targets,
+	// e.g. 1.29.  If set, it overrides the kubeVersion field of
+	// the kustomization.
+	KubeVersion string
+
+	// DisabledBuiltins names builtin plugins not to run, e.g.
+	// LabelTransformer, as listed by GetBuiltinPluginNames, or
+	// NameReferenceTransformer, to leave references to renamed
+	// objects as is.  A build of a kustomization that needs a
+	// disabled builtin fails, other than for the HashTransformer,
+	// whose disabling just leaves the names of generated objects
+	// unhashed.
+	DisabledBuiltins []string
+
+	// ClusterScopedReferrals is whether the name references of
+	// cluster-scoped objects that name no namespace, e.g. the
+	// subjects of ClusterRoleBindings, follow the renamed objects
+	// of any namespace, the default, or only those of the
+	// namespace of the kustomization built.
+	// It's experimental, needing ExperimentalClusterScopedReferrals.
+	ClusterScopedReferrals ClusterScopedReferrals
+
+	// When true, a transformer changing a resource annotated
+	// kustomize.config.k8s.io/frozen: "true" doesn't fail the
+	// build; the change is reverted and a warning logged.
+	WarnOnFrozenChanges bool
+
+	// When true, each field that a replacement with
+	// options.create creates, rather than updates, is
+	// logged, so that typos in field paths, creating junk
+	// fields, get noticed.
+	LogCreatedFields bool
+
+	// When true, strategic merge patches copy their comments to
+	// the fields of their targets that have none, and the yaml
+	// output of Emit keeps the comments of the resources, e.g. for
+	// reviewers of rendered manifests committed to a repository.
+	// It's experimental, needing ExperimentalKeepComments.
+	KeepComments bool
+
+	// When true, the fields the output resources omit get the
+	// defaults of their OpenAPI schemas, those of the
+	// CustomResourceDefinitions of the output, or of the custom
+	// schema of the kustomization, as the API server sets them,
+	// so that diffs against live objects don't show them as
+	// changes.  The defaults the API server sets for builtin
+	// kinds aren't known, as their builtin schemas hardly hold
+	// any; the kinds left as is are warned of in Warnings.
+	// It's experimental, needing ExperimentalDefaults.
+	ApplyDefaults bool
+
+	// When true, the fields of the output resources equal to the
+	// defaults of their schemas, as for ApplyDefaults, are removed,
+	// leaving minimal manifests to review.  It can't be combined
+	// with ApplyDefaults.
+	// It's experimental, needing ExperimentalDefaults.
+	StripDefaults bool
+
+	// KeepDefaultsOf names the kinds, e.g. Deployment, whose
+	// resources StripDefaults leaves as is.
+	// It's experimental, needing ExperimentalDefaults.
+	KeepDefaultsOf []string
+
+	// When true, a replacement copying the name of a generated
+	// resource, e.g. of a configMapGenerator, fails the build
+	// if the name is to get a hash suffix, as the replacement
+	// runs before it does, so the copies wouldn't match the
+	// final name.
+	StrictGeneratedNames bool
+
+	// When true, the documents of resource files that aren't
+	// Kubernetes resources, lacking an apiVersion or kind,
+	// e.g. data for functions, are passed through the build
+	// as is.  Only function and exec plugins see them, other
+	// transformers leave them untouched.
+	// It's experimental, needing ExperimentalNonKRM.
+	AllowNonKRM bool
+
+	// MaxDepth is how deeply bases and components may
+	// nest, e.g. 1 allows bases, but not bases of bases.
+	// Zero means DefaultMaxDepth, and a negative value
+	// no limit.
+	MaxDepth int
+
+	// InputLimits, if not nil, bound the YAML of the resources
+	// a build reads, e.g. to lower or lift the bounds on the size
+	// and alias expansion of those of remote bases, instead of
+	// kio.DefaultInputLimits.
+	InputLimits *kio.InputLimits
+
+	// MaxParallelism is how many of the patches of a
+	// kustomization a build may apply at once, to patches
+	// of distinct resources.  Below 2, the default, patches
+	// are applied one after the other.  Patches changing
+	// which resources patches select, e.g. renaming them,
+	// fail a build applying them in parallel.
+	// It's experimental, needing ExperimentalParallelPatches.
+	MaxParallelism int
+
+	// When true, the Kustomizer clones each remote repository,
+	// at each ref, only once for all its runs, e.g. to build
+	// several kustomizations sharing remote bases, keeping
+	// the clones until its Cleanup is called.
+	ReuseClones bool
+
+	// Clones, if not nil, is where the Kustomizer gets its
+	// clones of remote repositories, as with ReuseClones,
+	// but sharing them with the other Kustomizers using it,
+	// and leaving them to its Cleanup.
+	// A CloneCache made by NewDiskCloneCache is experimental,
+	// needing ExperimentalDiskClones.
+	Clones *CloneCache
+
+	// UsageReport, if not nil, is where builds record which
+	// features they use: the kustomization fields, builtin
+	// plugins and plugin types.  Successive builds add to it.
+	UsageReport *types.UsageReport
+
+	// Warnings, if not nil, is where builds add their
+	// warnings, e.g. of the fields of kustomization files
+	// unknown to kustomize, kept as extensions and ignored.
+	// Successive builds add to it.
+	Warnings *types.Warnings
+
+	// Progress, if not nil, is reported the events of builds:
+	// the stages of each kustomization, with the resources
+	// it holds after each, and the fetches of remote
+	// repositories, e.g. to show the progress of long builds.
+	Progress types.ProgressFunc
+
+	// DebugDumpDir, if set, is the directory each build writes
+	// the snapshots of its resources to, after each generator
+	// and transformer, to numbered files, so that diffing them
+	// shows which step made a change.  The values of secrets
+	// are redacted.  The directory must not exist or be empty.
+	DebugDumpDir string
+
+	// EnvCapture, if not nil, is where builds record the
+	// environment variables they read through the envSources
+	// of kustomizations, with their values, e.g. to audit or
+	// reproduce builds.  Successive builds add to it.
+	EnvCapture *types.EnvCapture
+
+	// Signatures, if not nil, requires the function images
+	// builds run, and the helm charts they pull, to be signed
+	// as it says, in place of the signatures of the policy
+	// file of the kustomization, if any.
+	Signatures *types.SignaturePolicy
+
+	// When true, a build whose kustomization has no signature
+	// policy, neither from Signatures nor its policy file, fails.
+	RequireSignatures bool
+
+	// When true, the build of a kustomization on the file
+	// system enables what its requirements need, other than
+	// exec functions, which must be enabled explicitly.  A
+	// requirement the options don't meet, e.g. of a remote
+	// kustomization or a base, fails the build.
+	EnableRequirements bool
+
+	// RemoteRefRewriter, if not nil, is given every reference
+	// of the build to remote content, remote bases, files
+	// loaded from URLs, helm charts and the images of
+	// functions, before it's fetched, to rewrite, e.g. to
+	// point at a mirror, or reject.  The policy file, if any,
+	// is checked against the references as written.  As a
+	// resource is loaded as a file, then as a base, a remote
+	// one is given as a URL, then as a remote base.
+	RemoteRefRewriter types.RemoteRefRewriter
+
+	// Parameters holds the values of the build parameters, by
+	// name.  A placeholder such as ${TENANT} in the namespace,
+	// namePrefix or the values of the commonLabels of a
+	// kustomization is replaced by the parameter's value; one
+	// referring to a parameter that isn't set fails the build.
+	// If nil, the placeholders are left as is, e.g. for envsubst
+	// to expand later.
+	// It's experimental, needing ExperimentalParameters, even
+	// if empty.
+	Parameters map[string]string
+
+	// Select, if not nil, is the query the resources output by
+	// builds must match, e.g. @source=../apps/web && kind=Deployment.
+	// The entries of the resources of the kustomization built that
+	// none of them can come from, by their @source (see
+	// resmap.ResQuery), are left out, not loaded or fetched, unless
+	// the kustomization has assertions or validators, or DebugDumpDir
+	// is set.  Should the build then fail, or the resources selected
+	// refer to resources the build lacks, whose names may then not
+	// have been fixed, it's made again with every entry, as warned of
+	// in Warnings, and reported to the UsageReport twice.
+	// It's experimental, needing ExperimentalSelect.
+	Select *resmap.ResQuery
+
+	// PlaceholderCheck is whether the values of the fields of
+	// the output are checked for unexpanded placeholders, such as
+	// ${TAG}, {{ .Values.tag }} or $(TAG), which are then logged,
+	// or fail the build.
+	PlaceholderCheck PlaceholderCheck
+
+	// AllowedPlaceholders holds globs of the placeholders
+	// the check passes, e.g. $(POD_NAME), for Kubernetes to
+	// expand in the command of a container.
+	AllowedPlaceholders []string
+
+	// Experimental, if not nil, is what the embedder negotiates
+	// of the experimental features, which a build may use only
+	// if it states it understands them (see ExperimentalFeature).
+	Experimental *ExperimentalOptions
+
+	// registeredPlugins holds the plugins registered
+	// with RegisterPlugin, by kind.
+	registeredPlugins map[string]PluginFactory
+
+	// registeredEmitters holds the emitters registered
+	// with RegisterEmitter, by format.
+	registeredEmitters map[string]kio.Emitter
+}
+
+// DefaultMaxDepth is the MaxDepth of builds not setting it, deep
+// enough for any layout of overlays, but stopping runaway nesting,
+// e.g. of remote bases referring to each other by varying URIs.
+const DefaultMaxDepth = 100
+
+// NameReferenceTransformer names the transformer, run by every
+// build, that fixes references to objects whose names change, so
+// that it may be listed in DisabledBuiltins.
+const NameReferenceTransformer = "NameReferenceTransformer"
+
+// PluginFactory makes an unconfigured instance of a plugin,
+// i.e. a resmap.Configurable that is also a resmap.Generator
+// or a resmap.Transformer.
+type PluginFactory func() resmap.Configurable
+
+// RegisterPlugin registers a statically compiled plugin, so that
+// kustomizations may use it as they use the builtin plugins, by
+// naming its kind in a generator or transformer config of any
+// apiVersion.  Unlike Go plugins, which are only loaded by builds
+// with the goplugins tag, registered plugins need neither cgo nor
+// .so files, and are allowed even if PluginConfig only allows
+// builtin plugins.
+// It's experimental, needing ExperimentalRegisteredPlugins.
+func (o *Options) RegisterPlugin(name string, f PluginFactory) error {
+	if name == "" {
+		return fmt.Errorf("a registered plugin must have a name")
+	}
+	if f == nil {
+		return fmt.Errorf("plugin %s has no factory", name)
+	}
+	if builtinhelpers.GetBuiltinPluginType(name) != builtinhelpers.Unknown {
+		return fmt.Errorf("plugin %s is a builtin plugin", name)
+	}
+	if _, ok := o.registeredPlugins[name]; ok {
+		return fmt.Errorf("plugin %s is already registered", name)
+	}
+	if o.registeredPlugins == nil {
+		o.registeredPlugins = make(map[string]PluginFactory)
+	}
+	o.registeredPlugins[name] = f
+	return nil
+}
+
+// RegisterEmitter registers an emitter, so that Kustomizer.Emit
+// may encode the output of builds in its format, e.g. cue, besides
+// the builtin formats, yaml and json.
+// It's experimental, needing ExperimentalRegisteredEmitters.
+func (o *Options) RegisterEmitter(format string, e kio.Emitter) error {
+	if format == "" {
+		return fmt.Errorf("a registered emitter must have a format")
+	}
+	if e == nil {
+		return fmt.Errorf("emitter of format %s is nil", format)
+	}
+	if _, ok := builtinEmitters[format]; ok {
+		return fmt.Errorf("format %s is a builtin format", format)
+	}
+	if _, ok := o.registeredEmitters[format]; ok {
+		return fmt.Errorf("an emitter of format %s is already registered", format)
+	}
+	if o.registeredEmitters == nil {
+		o.registeredEmitters = make(map[string]kio.Emitter)
+	}
+	o.registeredEmitters[format] = e
+	return nil
 }
 
 // MakeDefaultOptions returns a default instance of Options.
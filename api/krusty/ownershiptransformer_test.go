@@ -0,0 +1,75 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestOwnershipTransformerLabelsBases(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("services/payments", `
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - mode=live
+`)
+	th.WriteF("services/payments/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: payments
+`)
+	th.WriteK("overlays/prod", `
+resources:
+- ../../services/payments
+- namespace.yaml
+transformers:
+- owners.yaml
+`)
+	th.WriteF("overlays/prod/namespace.yaml", `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: prod
+`)
+	th.WriteF("overlays/prod/owners.yaml", `
+apiVersion: builtin
+kind: OwnershipTransformer
+metadata:
+  name: owners
+owners:
+- path: "*"
+  team: platform
+- path: ../../services/payments
+  team: payments
+`)
+	m := th.Run("overlays/prod", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: payments
+  name: payments
+---
+apiVersion: v1
+data:
+  mode: live
+kind: ConfigMap
+metadata:
+  name: settings-9974d256fk
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    team: platform
+  name: prod
+`)
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeParallelPatchesBase(th kusttest_test.Harness) {
+	th.WriteK("base", `
+resources:
+- resources.yaml
+`)
+	th.WriteF("base/resources.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: a
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unused
+`)
+}
+
+func TestParallelPatches(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeParallelPatchesBase(th)
+	th.WriteK("overlay", `
+resources:
+- ../base
+patches:
+- patch: |-
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: a
+    spec:
+      replicas: 2
+- target:
+    kind: Deployment
+  patch: |-
+    - op: add
+      path: /metadata/labels
+      value:
+        team: web
+- target:
+    name: b
+  patch: |-
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: whatever
+    spec:
+      replicas: 3
+- patch: |-
+    $patch: delete
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: unused
+`)
+	expected := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: web
+  name: a
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: web
+  name: b
+spec:
+  replicas: 3
+`
+	opts := th.MakeDefaultOptions()
+	th.AssertActualEqualsExpected(th.Run("overlay", opts), expected)
+	opts.MaxParallelism = 4
+	opts.Experimental = understanding(krusty.ExperimentalParallelPatches)
+	th.AssertActualEqualsExpected(th.Run("overlay", opts), expected)
+}
+
+func TestParallelMultiDocumentPatches(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeParallelPatchesBase(th)
+	th.WriteF("overlay/patches.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: a
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+spec:
+  replicas: 3
+`)
+	th.WriteK("overlay", `
+resources:
+- ../base
+patches:
+- path: patches.yaml
+- target:
+    kind: ConfigMap
+  patch: |-
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: whatever
+    data:
+      a: "1"
+    ---
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: whatever
+    data:
+      b: "2"
+`)
+	expected := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: a
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+spec:
+  replicas: 3
+---
+apiVersion: v1
+data:
+  a: "1"
+  b: "2"
+kind: ConfigMap
+metadata:
+  name: unused
+`
+	opts := th.MakeDefaultOptions()
+	th.AssertActualEqualsExpected(th.Run("overlay", opts), expected)
+	opts.MaxParallelism = 4
+	opts.Experimental = understanding(krusty.ExperimentalParallelPatches)
+	th.AssertActualEqualsExpected(th.Run("overlay", opts), expected)
+}
+
+func TestParallelPatchesChangingSelections(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeParallelPatchesBase(th)
+	th.WriteK("overlay", `
+resources:
+- ../base
+patches:
+- target:
+    name: a
+  patch: |-
+    - op: add
+      path: /metadata/labels
+      value:
+        team: web
+- target:
+    labelSelector: team=web
+  patch: |-
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: whatever
+    spec:
+      replicas: 2
+`)
+	opts := th.MakeDefaultOptions()
+	th.AssertActualEqualsExpected(th.Run("overlay", opts), `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: web
+  name: a
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unused
+`)
+	opts.MaxParallelism = 4
+	opts.Experimental = understanding(krusty.ExperimentalParallelPatches)
+	err := th.RunWithErr("overlay", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"patches change which resources patch 2 selects, "+
+				"so they cannot be applied in parallel")
+	}
+}
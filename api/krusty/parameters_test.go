@@ -0,0 +1,114 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeTenantOverlay(th kusttest_test.Harness) {
+	th.WriteK("base", `
+resources:
+- service.yaml
+`)
+	th.WriteF("base/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteK("tenant", `
+namespace: tenant-${TENANT}
+namePrefix: ${TENANT}-
+commonLabels:
+  tenant: ${TENANT}
+  tier: web
+resources:
+- ../base
+`)
+}
+
+func TestParameters(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTenantOverlay(th)
+	opts := th.MakeDefaultOptions()
+	opts.Parameters = map[string]string{"TENANT": "acme"}
+	opts.Experimental = understanding(krusty.ExperimentalParameters)
+	m := th.Run("tenant", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    tenant: acme
+    tier: web
+  name: acme-web
+  namespace: tenant-acme
+spec:
+  selector:
+    tenant: acme
+    tier: web
+`)
+}
+
+func TestPlaceholdersWithoutParameters(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("app", `
+namespace: ${NS}
+commonLabels:
+  env: ${cluster_env}
+resources:
+- service.yaml
+`)
+	th.WriteF("app/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	// Left for envsubst, or the postBuild substitutions of Flux.
+	m := th.Run("app", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    env: ${cluster_env}
+  name: web
+  namespace: ${NS}
+spec:
+  selector:
+    env: ${cluster_env}
+`)
+}
+
+func TestParametersUnset(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTenantOverlay(th)
+	opts := th.MakeDefaultOptions()
+	opts.Parameters = map[string]string{"TIER": "web"}
+	opts.Experimental = understanding(krusty.ExperimentalParameters)
+	err := th.RunWithErr("tenant", opts)
+	assert.Contains(t, err.Error(),
+		"the namespace of the kustomization in /tenant refers to the "+
+			"build parameter TENANT, which isn't set")
+}
+
+func TestParametersBadName(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("app", `
+namePrefix: ${TENANT-NAME}-
+`)
+	opts := th.MakeDefaultOptions()
+	opts.Parameters = map[string]string{}
+	opts.Experimental = understanding(krusty.ExperimentalParameters)
+	err := th.RunWithErr("app", opts)
+	assert.Contains(t, err.Error(),
+		`the namePrefix of the kustomization in /app holds ${TENANT-NAME}, `+
+			`and "TENANT-NAME" isn't the name of a build parameter`)
+}
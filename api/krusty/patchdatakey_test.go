@@ -0,0 +1,65 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestPatchDataKey(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("base/settings.yaml", `
+# application settings
+database:
+  host: localhost
+  port: 5432
+`)
+	th.WriteK("base", `
+configMapGenerator:
+- name: app
+  files:
+  - settings.yaml
+`)
+	th.WriteK("overlay", `
+resources:
+- ../base
+patches:
+- target:
+    kind: ConfigMap
+    name: app
+  dataKey: settings.yaml
+  patch: |-
+    database:
+      host: db.prod.svc
+`)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  settings.yaml: |
+    # application settings
+    database:
+      host: db.prod.svc
+      port: 5432
+kind: ConfigMap
+metadata:
+  name: app-754fmg55gt
+`)
+}
+
+func TestPatchDataKeyRequiresTarget(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+patches:
+- dataKey: settings.yaml
+  patch: |-
+    debug: true
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
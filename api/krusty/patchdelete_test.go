@@ -72,3 +72,159 @@ spec:
 	m := th.Run(".", th.MakeDefaultOptions())
 	th.AssertActualEqualsExpected(m, expected)
 }
+
+func writePatchDeleteResources(th kusttest_test.Harness) {
+	th.WriteF("resources.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    example.com/owner: team-a
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: dedicated
+        operator: Exists
+      - key: gpu
+        operator: Exists
+      containers:
+      - name: app
+        image: app
+        env:
+        - name: DEBUG
+          value: "true"
+        - name: REGION
+          value: us-east1
+---
+apiVersion: ci.example.com/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  env:
+  - name: DEBUG
+    value: "true"
+  - name: REGION
+    value: us-east1
+`)
+}
+
+func TestPatchDeleteListEntriesWithoutMergeKeys(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writePatchDeleteResources(th)
+	th.WriteK(".", `
+resources:
+- resources.yaml
+patches:
+- patch: |-
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: web
+    spec:
+      template:
+        spec:
+          tolerations:
+          - key: gpu
+            $patch: delete
+- patch: |-
+    apiVersion: ci.example.com/v1
+    kind: Pipeline
+    metadata:
+      name: build
+    spec:
+      env:
+      - name: DEBUG
+        $patch: delete
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    example.com/owner: team-a
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: DEBUG
+          value: "true"
+        - name: REGION
+          value: us-east1
+        image: app
+        name: app
+      tolerations:
+      - key: dedicated
+        operator: Exists
+---
+apiVersion: ci.example.com/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  env:
+  - name: REGION
+    value: us-east1
+`)
+}
+
+func TestPatchDeleteOption(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writePatchDeleteResources(th)
+	th.WriteK(".", `
+resources:
+- resources.yaml
+patches:
+- target:
+    kind: Deployment
+  delete:
+  - metadata.annotations.example\.com/owner
+  - spec.template.spec.tolerations.[key=gpu]
+  - spec.template.spec.containers.*.env.[name=DEBUG]
+- target:
+    kind: Pipeline
+  patch: |-
+    - op: add
+      path: /spec/env/-
+      value:
+        name: LEVEL
+        value: info
+  delete:
+  - spec.env.[name=DEBUG]
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: REGION
+          value: us-east1
+        image: app
+        name: app
+      tolerations:
+      - key: dedicated
+        operator: Exists
+---
+apiVersion: ci.example.com/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  env:
+  - name: REGION
+    value: us-east1
+  - name: LEVEL
+    value: info
+`)
+}
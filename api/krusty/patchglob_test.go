@@ -0,0 +1,156 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writePatchGlobBase(th kusttest_test.Harness) {
+	th.WriteF("deploy.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`)
+	th.WriteF("service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  type: ClusterIP
+`)
+}
+
+func TestPatchPathGlob(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writePatchGlobBase(th)
+	th.WriteF("patches/b-replicas.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+`)
+	// Lexically first, so the later patch wins.
+	th.WriteF("patches/a-replicas.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+`)
+	th.WriteF("patches/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  type: NodePort
+`)
+	th.WriteF("patches/notapatch.txt", `
+junk
+`)
+	th.WriteK(".", `
+resources:
+- deploy.yaml
+- service.yaml
+patches:
+- path: patches/*.yaml
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  type: NodePort
+`)
+}
+
+func TestPatchPathGlobWithTarget(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writePatchGlobBase(th)
+	th.WriteF("patches/image.yaml", `
+- op: replace
+  path: /spec/template/spec/containers/0/image
+  value: nginx:1.21
+`)
+	th.WriteF("patches/replicas.yaml", `
+- op: replace
+  path: /spec/replicas
+  value: 5
+`)
+	th.WriteK(".", `
+resources:
+- deploy.yaml
+- service.yaml
+patches:
+- path: patches/*.yaml
+  target:
+    kind: Deployment
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - image: nginx:1.21
+        name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  type: ClusterIP
+`)
+}
+
+func TestPatchPathGlobNoMatch(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writePatchGlobBase(th)
+	th.WriteK(".", `
+resources:
+- deploy.yaml
+patches:
+- path: patches/*.yaml
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	if !strings.Contains(err.Error(), "patch path 'patches/*.yaml' matches no files") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
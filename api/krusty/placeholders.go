@@ -0,0 +1,123 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// PlaceholderCheck is how a build treats the placeholders left
+// unexpanded in its output, such as ${TAG}, {{ .Values.tag }}
+// or $(TAG), e.g. of half-migrated helm charts or vars.
+type PlaceholderCheck string
+
+const (
+	// PlaceholdersIgnored leaves the output unchecked.
+	PlaceholdersIgnored PlaceholderCheck = ""
+
+	// PlaceholdersWarned logs the placeholders found.
+	PlaceholdersWarned PlaceholderCheck = "warn"
+
+	// PlaceholdersFail fails the build if any are found.
+	PlaceholdersFail PlaceholderCheck = "fail"
+)
+
+// placeholderRegexp matches the placeholders of shells and
+// envsubst, of go templates, e.g. of helm, and of vars.
+var placeholderRegexp = regexp.MustCompile(
+	`\$\{[^{}]*\}|\{\{[^{}]*\}\}|\$\([A-Za-z_][A-Za-z0-9_]*\)`)
+
+// placeholder is one found in the value of a field.
+type placeholder struct {
+	text  string
+	field string
+}
+
+// checkPlaceholders checks the values of the fields of the
+// resources of m for placeholders, other than those matching
+// the AllowedPlaceholders globs, as the PlaceholderCheck says.
+func (b *Kustomizer) checkPlaceholders(m resmap.ResMap) error {
+	switch b.options.PlaceholderCheck {
+	case PlaceholdersIgnored:
+		return nil
+	case PlaceholdersWarned, PlaceholdersFail:
+	default:
+		return fmt.Errorf(
+			"unknown placeholder check %q, expected %s or %s",
+			b.options.PlaceholderCheck, PlaceholdersWarned, PlaceholdersFail)
+	}
+	for _, g := range b.options.AllowedPlaceholders {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("the allowed placeholder %q: %v", g, err)
+		}
+	}
+	var found []string
+	for _, r := range m.Resources() {
+		for _, p := range placeholdersIn(r.AsRNode().YNode(), "") {
+			if b.allowsPlaceholder(p.text) {
+				continue
+			}
+			found = append(found, fmt.Sprintf(
+				"%s in %s of %s", p.text, p.field, r.CurId()))
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	if b.options.PlaceholderCheck == PlaceholdersWarned {
+		for _, f := range found {
+			log.Printf("warning: unexpanded placeholder %s", f)
+		}
+		return nil
+	}
+	return fmt.Errorf(
+		"the output has unexpanded placeholders:\n  %s",
+		strings.Join(found, "\n  "))
+}
+
+func (b *Kustomizer) allowsPlaceholder(text string) bool {
+	for _, g := range b.options.AllowedPlaceholders {
+		if ok, _ := path.Match(g, text); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholdersIn returns the placeholders in the scalars of n,
+// at the field path prefix, with those of its fields or elements.
+func placeholdersIn(n *kyaml.Node, prefix string) []placeholder {
+	var result []placeholder
+	switch n.Kind {
+	case kyaml.ScalarNode:
+		for _, text := range placeholderRegexp.FindAllString(n.Value, -1) {
+			result = append(result, placeholder{text: text, field: prefix})
+		}
+	case kyaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			name := n.Content[i].Value
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			result = append(result, placeholdersIn(n.Content[i+1], name)...)
+		}
+	case kyaml.SequenceNode:
+		for i, c := range n.Content {
+			result = append(result,
+				placeholdersIn(c, fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+	case kyaml.DocumentNode:
+		for _, c := range n.Content {
+			result = append(result, placeholdersIn(c, prefix)...)
+		}
+	}
+	return result
+}
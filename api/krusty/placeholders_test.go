@@ -0,0 +1,85 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeHalfMigrated(th kusttest_test.Harness) {
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    chart: '{{ .Chart.Name }}'
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:${TAG}
+        args:
+        - --host=$(POD_NAME)
+        - --cost=$5
+`)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+`)
+}
+
+func TestPlaceholdersFail(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeHalfMigrated(th)
+	opts := th.MakeDefaultOptions()
+	opts.PlaceholderCheck = krusty.PlaceholdersFail
+	err := th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `the output has unexpanded placeholders:
+  {{ .Chart.Name }} in metadata.labels.chart of apps_v1_Deployment|~X|web
+  ${TAG} in spec.template.spec.containers[0].image of apps_v1_Deployment|~X|web
+  $(POD_NAME) in spec.template.spec.containers[0].args[0] of apps_v1_Deployment|~X|web`)
+	}
+
+	opts.AllowedPlaceholders = []string{"$(POD_*)", "{{*}}", "${TAG}"}
+	th.Run(".", opts)
+
+	opts.AllowedPlaceholders = []string{"[POD"}
+	err = th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `the allowed placeholder "[POD"`)
+	}
+}
+
+func TestPlaceholdersWarned(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeHalfMigrated(th)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	opts := th.MakeDefaultOptions()
+	opts.PlaceholderCheck = krusty.PlaceholdersWarned
+	opts.AllowedPlaceholders = []string{"$(POD_NAME)", "{{*}}"}
+	th.Run(".", opts)
+	assert.Contains(t, logs.String(),
+		"warning: unexpanded placeholder ${TAG} in "+
+			"spec.template.spec.containers[0].image of apps_v1_Deployment|~X|web")
+	assert.NotContains(t, logs.String(), "POD_NAME")
+
+	opts.PlaceholderCheck = "strict"
+	err := th.RunWithErr(".", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`unknown placeholder check "strict", expected warn or fail`)
+	}
+}
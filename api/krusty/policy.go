@@ -0,0 +1,89 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/internal/git"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// findPolicy returns the policy of the kustomization at path,
+// read from the nearest policy file in its directory or above
+// it, or nil if there's none.  The policy of a remote
+// kustomization is that of the current directory.
+func findPolicy(fSys filesys.FileSystem, path string) (*types.Policy, error) {
+	if _, err := git.NewRepoSpecFromUrl(path); err == nil {
+		path = filesys.SelfDir
+	}
+	dir, _, err := fSys.CleanedAbs(path)
+	if err != nil {
+		// Reported by the loader.
+		return nil, nil
+	}
+	for d := dir.String(); ; d = filepath.Dir(d) {
+		p := filepath.Join(d, types.PolicyFileName)
+		if fSys.Exists(p) {
+			return readPolicy(fSys, p)
+		}
+		if filepath.Dir(d) == d {
+			return nil, nil
+		}
+	}
+}
+
+// signaturePolicy returns the policy, with the Signatures
+// option in place of its own, if set, erring if the
+// RequireSignatures option is set and it has none.
+func (b *Kustomizer) signaturePolicy(
+	policy *types.Policy, err error) (*types.Policy, error) {
+	if err != nil {
+		return nil, err
+	}
+	if s := b.options.Signatures; s != nil {
+		if err = s.Validate(); err != nil {
+			return nil, err
+		}
+		p := types.Policy{}
+		if policy != nil {
+			p = *policy
+		}
+		p.Signatures = s
+		policy = &p
+	}
+	if b.options.RequireSignatures &&
+		(policy == nil || policy.Signatures == nil) {
+		return nil, fmt.Errorf(
+			"signatures are required, but no signature policy is set, " +
+				"neither in the options nor in a policy file")
+	}
+	return policy, nil
+}
+
+func readPolicy(fSys filesys.FileSystem, path string) (*types.Policy, error) {
+	content, err := fSys.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &types.Policy{}
+	if err = yaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, fmt.Errorf("reading the policy in %s: %v", path, err)
+	}
+	if s := policy.Signatures; s != nil {
+		if err = s.Validate(); err != nil {
+			return nil, fmt.Errorf("the policy in %s: %v", path, err)
+		}
+		if s.Key != "" && !strings.Contains(s.Key, "://") &&
+			!filepath.IsAbs(s.Key) {
+			s.Key = filepath.Join(filepath.Dir(path), s.Key)
+		}
+	}
+	policy.Path = path
+	return policy, nil
+}
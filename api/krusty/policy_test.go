@@ -0,0 +1,260 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func TestPolicy(t *testing.T) {
+	testCases := map[string]struct {
+		policy        string
+		kustomization string
+		expectedErr   string
+	}{
+		"forbiddenGitRepo": {
+			policy: `
+allowedGitRepos:
+- github.com/kubernetes-sigs
+`,
+			kustomization: `
+resources:
+- github.com/example/configs/base?ref=v1
+`,
+			expectedErr: "the kustomization in /repo/app refers to git repository " +
+				"github.com/example/configs, which the policy in " +
+				"/repo/.kustomize-policy.yaml forbids",
+		},
+		"denyNetworkURL": {
+			policy: `
+denyNetwork: true
+`,
+			kustomization: `
+resources:
+- https://example.com/deployment.yaml
+`,
+			expectedErr: "the kustomization in /repo/app refers to the URL " +
+				"https://example.com/deployment.yaml, which the policy in " +
+				"/repo/.kustomize-policy.yaml forbids",
+		},
+		"forbiddenFunctionImage": {
+			policy: `
+allowedFunctionImages:
+- gcr.io/kpt-fn
+`,
+			kustomization: `
+transformers:
+- fn.yaml
+`,
+			expectedErr: "the kustomization in /repo/app refers to function image " +
+				"example.com/fn:v1, which the policy in " +
+				"/repo/.kustomize-policy.yaml forbids",
+		},
+		"allowed": {
+			policy: `
+allowedGitRepos:
+- github.com/kubernetes-sigs
+allowedFunctionImages:
+- example.com/fn
+`,
+			kustomization: `
+resources:
+- deployment.yaml
+`,
+		},
+		"unknownField": {
+			policy: `
+allowedGitHosts:
+- github.com
+`,
+			kustomization: `
+resources:
+- deployment.yaml
+`,
+			expectedErr: "reading the policy in /repo/.kustomize-policy.yaml",
+		},
+		"ambiguousSignatures": {
+			policy: `
+signatures:
+  key: cosign.pub
+  identity: release@example.com
+`,
+			kustomization: `
+resources:
+- deployment.yaml
+`,
+			expectedErr: "the policy in /repo/.kustomize-policy.yaml: " +
+				"signatures set both a key and an identity",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			th := kusttest_test.MakeHarness(t)
+			th.WriteF("/repo/"+types.PolicyFileName, tc.policy)
+			th.WriteK("/repo/app", tc.kustomization)
+			th.WriteF("/repo/app/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`)
+			th.WriteF("/repo/app/fn.yaml", `
+apiVersion: example.com/v1
+kind: SetLabels
+metadata:
+  name: labels
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: example.com/fn:v1
+`)
+			opts := th.MakeOptionsPluginsEnabled()
+			if tc.expectedErr == "" {
+				th.Run("/repo/app", opts)
+				return
+			}
+			err := th.RunWithErr("/repo/app", opts)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expectedErr)
+			}
+		})
+	}
+}
+
+// writeFakeCosign puts a cosign in the PATH that fails,
+// as for an unsigned image, returning a func to restore it.
+func writeFakeCosign(t *testing.T) func() {
+	t.Helper()
+	return writeFakeCommands(t, map[string]string{"cosign": `#!/bin/sh
+echo "Error: no matching signatures" >&2
+exit 1
+`})
+}
+
+// writeFakeCommands puts the scripts, by the names of the
+// commands they fake, in the PATH, returning a func to
+// restore it.
+func writeFakeCommands(t *testing.T, scripts map[string]string) func() {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "commands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, script := range scripts {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPolicyUnsignedFunctionImage(t *testing.T) {
+	defer writeFakeCosign(t)()
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("/repo/"+types.PolicyFileName, `
+signatures:
+  key: keys/cosign.pub
+`)
+	th.WriteK("/repo/app", `
+transformers:
+- fn.yaml
+`)
+	th.WriteF("/repo/app/fn.yaml", `
+apiVersion: example.com/v1
+kind: SetLabels
+metadata:
+  name: labels
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: example.com/fn:v1
+`)
+	err := th.RunWithErr("/repo/app", th.MakeOptionsPluginsEnabled())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"the kustomization in /repo/app refers to function image "+
+				"example.com/fn:v1, which must be signed, but its signature "+
+				"couldn't be verified: exit status 1: Error: no matching signatures")
+	}
+}
+
+func TestPolicySignedFunctionImageRunByDigest(t *testing.T) {
+	log, err := ioutil.TempFile("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+	defer os.Remove(log.Name())
+	defer writeFakeCommands(t, map[string]string{
+		"cosign": `#!/bin/sh
+echo '[{"critical":{"image":{"docker-manifest-digest":"sha256:1234"}}}]'
+`,
+		"docker": `#!/bin/sh
+echo "$@" >` + log.Name() + `
+exit 1
+`,
+	})()
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("/repo/"+types.PolicyFileName, `
+signatures:
+  key: keys/cosign.pub
+`)
+	th.WriteK("/repo/app", `
+transformers:
+- fn.yaml
+`)
+	th.WriteF("/repo/app/fn.yaml", `
+apiVersion: example.com/v1
+kind: SetLabels
+metadata:
+  name: labels
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: example.com/fn:v1
+`)
+	assert.Error(t, th.RunWithErr("/repo/app", th.MakeOptionsPluginsEnabled()))
+	// The image run is the one whose signature was
+	// verified, whatever its tag refers to by then.
+	b, _ := ioutil.ReadFile(log.Name())
+	assert.Contains(t, string(b), " example.com/fn@sha256:1234")
+}
+
+func TestPolicyRequireSignatures(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("/app", `
+resources:
+- deployment.yaml
+`)
+	th.WriteF("/app/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`)
+	opts := th.MakeDefaultOptions()
+	opts.RequireSignatures = true
+	err := th.RunWithErr("/app", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no signature policy is set")
+	}
+	opts.Signatures = &types.SignaturePolicy{
+		Identity: "release@example.com",
+		Issuer:   "https://accounts.google.com",
+	}
+	th.Run("/app", opts)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func TestProgress(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("base", `
+resources:
+- service.yaml
+`)
+	th.WriteF("base/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteK("overlay", `
+namePrefix: prod-
+resources:
+- ../base
+configMapGenerator:
+- name: settings
+  literals:
+  - tier=web
+`)
+	var events []string
+	opts := th.MakeDefaultOptions()
+	opts.Progress = func(e types.ProgressEvent) {
+		events = append(events, fmt.Sprintf("%s %s %s %d",
+			e.Kind, filepath.Base(e.Dir), e.Stage, e.Resources))
+	}
+	th.Run("overlay", opts)
+	assert.Equal(t, []string{
+		"StageStarted overlay resources 0",
+		"StageStarted base resources 0",
+		"StageFinished base resources 1",
+		"StageStarted base generators 0",
+		"StageFinished base generators 1",
+		"StageStarted base transformers 0",
+		"StageFinished base transformers 1",
+		"StageStarted base validators 0",
+		"StageFinished base validators 1",
+		"StageFinished overlay resources 1",
+		"StageStarted overlay generators 0",
+		"StageFinished overlay generators 2",
+		"StageStarted overlay transformers 0",
+		"StageFinished overlay transformers 2",
+		"StageStarted overlay validators 0",
+		"StageFinished overlay validators 2",
+	}, events)
+}
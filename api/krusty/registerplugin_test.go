@@ -0,0 +1,158 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/yaml"
+)
+
+// annotator is a statically compiled custom transformer.
+type annotator struct {
+	Key   string `json:"key,omitempty" yaml:"key,omitempty"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+func (p *annotator) Config(_ *resmap.PluginHelpers, c []byte) error {
+	return yaml.Unmarshal(c, p)
+}
+
+func (p *annotator) Transform(m resmap.ResMap) error {
+	for _, r := range m.Resources() {
+		annotations := r.GetAnnotations()
+		annotations[p.Key] = p.Value
+		r.SetAnnotations(annotations)
+	}
+	return nil
+}
+
+func TestRegisteredPlugin(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+resources:
+- cm.yaml
+transformers:
+- annotator.yaml
+`)
+	th.WriteF("cm.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+`)
+	th.WriteF("annotator.yaml", `
+apiVersion: example.com/v1
+kind: Annotator
+metadata:
+  name: team
+key: team
+value: payments
+`)
+	// Allowed, though only builtin plugins are.
+	opts := th.MakeOptionsPluginsDisabled()
+	opts.Experimental = understanding(krusty.ExperimentalRegisteredPlugins)
+	assert.NoError(t, opts.RegisterPlugin("Annotator", func() resmap.Configurable {
+		return &annotator{}
+	}))
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    team: payments
+  name: cm
+`)
+
+	// Without the registration, it's an external plugin.
+	err := th.RunWithErr(".", th.MakeOptionsPluginsDisabled())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "external plugins disabled")
+	}
+}
+
+func TestRegisterPluginErrors(t *testing.T) {
+	f := func() resmap.Configurable { return &annotator{} }
+	opts := krusty.MakeDefaultOptions()
+	assert.NoError(t, opts.RegisterPlugin("Annotator", f))
+	assert.EqualError(t, opts.RegisterPlugin("Annotator", f),
+		"plugin Annotator is already registered")
+	assert.EqualError(t, opts.RegisterPlugin("LabelTransformer", f),
+		"plugin LabelTransformer is a builtin plugin")
+	assert.EqualError(t, opts.RegisterPlugin("", f),
+		"a registered plugin must have a name")
+	assert.EqualError(t, opts.RegisterPlugin("Other", nil),
+		"plugin Other has no factory")
+}
+
+// greeter is a statically compiled custom generator.
+type greeter struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	h    *resmap.PluginHelpers
+}
+
+func (p *greeter) Config(h *resmap.PluginHelpers, c []byte) error {
+	p.h = h
+	return yaml.Unmarshal(c, p)
+}
+
+func (p *greeter) Generate() (resmap.ResMap, error) {
+	return p.h.ResmapFactory().NewResMapFromBytes([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: greeting
+data:
+  greeting: hello ` + p.Name + `
+`))
+}
+
+func TestRegisteredGenerator(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+generators:
+- greeter.yaml
+transformers:
+- annotator.yaml
+`)
+	th.WriteF("greeter.yaml", `
+apiVersion: builtin
+kind: Greeter
+metadata:
+  name: greeter
+name: world
+`)
+	th.WriteF("annotator.yaml", `
+apiVersion: example.com/v1
+kind: Annotator
+metadata:
+  name: team
+key: team
+value: payments
+`)
+	opts := th.MakeDefaultOptions()
+	opts.Experimental = understanding(krusty.ExperimentalRegisteredPlugins)
+	assert.NoError(t, opts.RegisterPlugin("Greeter", func() resmap.Configurable {
+		return &greeter{}
+	}))
+	assert.NoError(t, opts.RegisterPlugin("Annotator", func() resmap.Configurable {
+		return &annotator{}
+	}))
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  greeting: hello world
+kind: ConfigMap
+metadata:
+  annotations:
+    team: payments
+  name: greeting
+`)
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// rejectingRewriter rejects every reference, recording it.
+func rejectingRewriter(refs *[]types.RemoteRef) types.RemoteRefRewriter {
+	return func(ref types.RemoteRef) (types.RemoteRef, error) {
+		*refs = append(*refs, ref)
+		return ref, fmt.Errorf("not allowed")
+	}
+}
+
+func TestRemoteRefRewriterRejectsBase(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("/app", `
+resources:
+- https://github.com/example/repo//base?ref=v1
+`)
+	var refs []types.RemoteRef
+	opts := th.MakeDefaultOptions()
+	opts.RemoteRefRewriter = rejectingRewriter(&refs)
+	err := th.RunWithErr("/app", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"the kustomization in /app refers to remote base "+
+				"https://github.com/example/repo//base?ref=v1, which was rejected: not allowed")
+	}
+	// A resource is loaded as a file, and then as a base.
+	assert.Equal(t, []types.RemoteRef{{
+		Kind:     types.RemoteRefURL,
+		Referrer: "/app",
+		URL:      "https://github.com/example/repo//base?ref=v1",
+	}, {
+		Kind:     types.RemoteRefGit,
+		Referrer: "/app",
+		URL:      "https://github.com/example/repo//base?ref=v1",
+	}}, refs)
+}
+
+func TestRemoteRefRewriterRejectsURL(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("/app", `
+resources:
+- https://example.com/deployment.yaml
+`)
+	var refs []types.RemoteRef
+	opts := th.MakeDefaultOptions()
+	opts.RemoteRefRewriter = rejectingRewriter(&refs)
+	err := th.RunWithErr("/app", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"the kustomization in /app refers to the URL "+
+				"https://example.com/deployment.yaml, which was rejected: not allowed")
+	}
+}
+
+func TestRemoteRefRewriterNotARemoteBase(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("/app", `
+resources:
+- https://github.com/example/repo//base?ref=v1
+`)
+	opts := th.MakeDefaultOptions()
+	opts.RemoteRefRewriter = func(ref types.RemoteRef) (types.RemoteRef, error) {
+		ref.URL = "mirror"
+		return ref, nil
+	}
+	err := th.RunWithErr("/app", opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"the remote base https://github.com/example/repo//base?ref=v1 of the "+
+				"kustomization in /app was rewritten to mirror, which isn't a remote base")
+	}
+}
+
+func TestRemoteRefRewriterFunctionImage(t *testing.T) {
+	defer writeFakeCosign(t)()
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("/repo/"+types.PolicyFileName, `
+allowedFunctionImages:
+- example.com
+signatures:
+  key: keys/cosign.pub
+`)
+	th.WriteK("/repo/app", `
+transformers:
+- fn.yaml
+`)
+	th.WriteF("/repo/app/fn.yaml", `
+apiVersion: example.com/v1
+kind: SetLabels
+metadata:
+  name: labels
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: example.com/fn:v1
+`)
+	opts := th.MakeOptionsPluginsEnabled()
+	opts.RemoteRefRewriter = func(ref types.RemoteRef) (types.RemoteRef, error) {
+		ref.URL = strings.Replace(ref.URL, "example.com/", "mirror.example.com/", 1)
+		return ref, nil
+	}
+	err := th.RunWithErr("/repo/app", opts)
+	if assert.Error(t, err) {
+		// The policy allows the image written, and the signature
+		// of the image run, that of the mirror, is verified.
+		assert.Contains(t, err.Error(),
+			"the kustomization in /repo/app refers to function image "+
+				"mirror.example.com/fn:v1, which must be signed")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestReplacementsInGeneratedFiles(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("base", `
+configMapGenerator:
+- name: app
+  files:
+  - app.conf
+  literals:
+  - mode=${DB_HOST}
+  options:
+    applyReplacements: true
+- name: static
+  files:
+  - app.conf
+`)
+	th.WriteF("base/app.conf", `listen 8080
+upstream ${DB_HOST}:5432
+`)
+	th.WriteK("prod", `
+resources:
+- ../base
+- db.yaml
+replacements:
+- source:
+    kind: Service
+    name: prod-db
+    fieldPath: metadata.name
+  targets:
+  - select:
+      kind: ConfigMap
+    options:
+      token: ${DB_HOST}
+`)
+	th.WriteF("prod/db.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: prod-db
+`)
+	m := th.Run("prod", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  app.conf: |
+    listen 8080
+    upstream prod-db:5432
+  mode: ${DB_HOST}
+kind: ConfigMap
+metadata:
+  name: app-7kt79fdct9
+---
+apiVersion: v1
+data:
+  app.conf: |
+    listen 8080
+    upstream ${DB_HOST}:5432
+kind: ConfigMap
+metadata:
+  name: static-mf72b9456d
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: prod-db
+`)
+}
@@ -1,6 +1,10 @@
 package krusty_test
 
 import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
 	"testing"
 
 	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
@@ -269,3 +273,211 @@ spec:
         name: nginx
 `)
 }
+
+func TestReplacementsLogCreatedFields(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t)
+	defer th.Reset()
+
+	th.WriteK(".", `
+resources:
+- resource.yaml
+
+replacements:
+- source:
+    kind: ConfigMap
+    fieldPath: data.replicas
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.replicas
+    - spec.replcias
+    options:
+      create: true
+`)
+	th.WriteF("resource.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  replicas: "3"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	opts := th.MakeDefaultOptions()
+	opts.LogCreatedFields = true
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+data:
+  replicas: "3"
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replcias: "3"
+  replicas: "3"
+`)
+	logged := buf.String()
+	if !strings.Contains(logged,
+		"replacement created field spec.replcias in resource apps_v1_Deployment|~X|web") {
+		t.Fatalf("expected the creation of spec.replcias to be logged:\n%s", logged)
+	}
+	if strings.Contains(logged, "spec.replicas") {
+		t.Fatalf("expected the update of spec.replicas not to be logged:\n%s", logged)
+	}
+}
+
+func TestReplacementsStrictGeneratedNames(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t)
+	defer th.Reset()
+
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+
+configMapGenerator:
+- name: settings
+  literals:
+  - host=example.com
+
+replacements:
+- source:
+    kind: ConfigMap
+    name: settings
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - metadata.annotations.settings
+    options:
+      create: true
+`)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	// The annotation silently misses the hash suffix.
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    settings: settings
+  name: web
+---
+apiVersion: v1
+data:
+  host: example.com
+kind: ConfigMap
+metadata:
+  name: settings-99m2fh947t
+`)
+
+	opts := th.MakeDefaultOptions()
+	opts.StrictGeneratedNames = true
+	err := th.RunWithErr(".", opts)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), `replacement copies "settings", `+
+		`the name of generated resource ~G_v1_ConfigMap|~X|settings, to `+
+		`metadata.annotations.settings of apps_v1_Deployment|~X|web before `+
+		`the name gets its hash suffix`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without hashes, the names are final.
+	opts.DisabledBuiltins = []string{"HashTransformer"}
+	th.Run(".", opts)
+}
+
+func TestReplacementsStrictGeneratedNamesToNameReference(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - host=example.com
+- name: app
+  literals:
+  - settings=settings
+
+replacements:
+- source:
+    kind: ConfigMap
+    name: app
+    fieldPath: data.settings
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=web].envFrom.0.configMapRef.name
+`)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        envFrom:
+        - configMapRef:
+            name: placeholder
+`)
+	// The name reference gets the final name, so it's
+	// not failed, though the value is a generated name.
+	opts := th.MakeDefaultOptions()
+	opts.StrictGeneratedNames = true
+	m := th.Run(".", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: settings-99m2fh947t
+        name: web
+---
+apiVersion: v1
+data:
+  host: example.com
+kind: ConfigMap
+metadata:
+  name: settings-99m2fh947t
+---
+apiVersion: v1
+data:
+  settings: settings
+kind: ConfigMap
+metadata:
+  name: app-g2hc9d82fc
+`)
+}
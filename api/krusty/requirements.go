@@ -0,0 +1,101 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// withRequirements returns the kustomizer building the
+// kustomization at path, which, if the EnableRequirements
+// option is set and the kustomization is on the file system,
+// has the options its requirements need.
+func (b *Kustomizer) withRequirements(
+	fSys filesys.FileSystem, path string) *Kustomizer {
+	if !b.options.EnableRequirements {
+		return b
+	}
+	r := readRequirements(fSys, path)
+	if r == nil {
+		return b
+	}
+	o := *b.options
+	pc := *o.PluginConfig
+	o.PluginConfig = &pc
+	if r.LoadRestrictor == types.LoadRestrictionsNone.String() {
+		o.LoadRestrictions = types.LoadRestrictionsNone
+	}
+	if r.Helm {
+		pc.HelmConfig.Enabled = true
+	}
+	if r.Plugins {
+		pc.PluginRestrictions = types.PluginRestrictionsNone
+	}
+	if r.Network {
+		pc.FnpLoadingOptions.Network = true
+	}
+	enabled := *b
+	enabled.options = &o
+	return &enabled
+}
+
+// readRequirements returns the requirements of the kustomization
+// in the directory path, or nil if it has none, or isn't one
+// that can be read, leaving its loading to report why.
+func readRequirements(fSys filesys.FileSystem, path string) *types.Requirements {
+	if !fSys.IsDir(path) {
+		return nil
+	}
+	for _, n := range konfig.RecognizedKustomizationFileNames() {
+		content, err := fSys.ReadFile(filepath.Join(path, n))
+		if err != nil {
+			continue
+		}
+		var k struct {
+			Requirements *types.Requirements `json:"requirements,omitempty"`
+		}
+		if err = yaml.Unmarshal(content, &k); err != nil {
+			return nil
+		}
+		return k.Requirements
+	}
+	return nil
+}
+
+// checkRequirements returns an error naming the flags
+// enabling what the requirements of the kustomization in
+// dir need that the options don't provide.
+func (b *Kustomizer) checkRequirements(r *types.Requirements, dir string) error {
+	pc := b.options.PluginConfig
+	var missing []string
+	if r.LoadRestrictor == types.LoadRestrictionsNone.String() &&
+		b.options.LoadRestrictions != types.LoadRestrictionsNone {
+		missing = append(missing, "--load-restrictor "+r.LoadRestrictor)
+	}
+	if r.Helm && !pc.HelmConfig.Enabled {
+		missing = append(missing, "--enable-helm")
+	}
+	if r.Plugins && pc.PluginRestrictions != types.PluginRestrictionsNone {
+		missing = append(missing, "--enable-alpha-plugins")
+	}
+	if r.Exec && !pc.FnpLoadingOptions.EnableExec {
+		missing = append(missing, "--enable-exec")
+	}
+	if r.Network && !pc.FnpLoadingOptions.Network {
+		missing = append(missing, "--network")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"the kustomization in %s requires %s, which the build doesn't enable",
+		dir, strings.Join(missing, ", "))
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeSharedService(th kusttest_test.Harness) {
+	th.WriteF("shared/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+}
+
+func TestRequirementsEnabled(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedService(th)
+	th.WriteK("app", `
+requirements:
+  loadRestrictor: LoadRestrictionsNone
+resources:
+- ../shared/service.yaml
+`)
+	opts := th.MakeDefaultOptions()
+	opts.EnableRequirements = true
+	m := th.Run("app", opts)
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+}
+
+func TestRequirementsDemanded(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeSharedService(th)
+	th.WriteK("app", `
+requirements:
+  loadRestrictor: LoadRestrictionsNone
+  helm: true
+resources:
+- ../shared/service.yaml
+`)
+	err := th.RunWithErr("app", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		"the kustomization in /app requires --load-restrictor LoadRestrictionsNone, "+
+			"--enable-helm, which the build doesn't enable")
+}
+
+func TestRequirementsExecNotEnabled(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("app", `
+requirements:
+  exec: true
+  plugins: true
+`)
+	opts := th.MakeDefaultOptions()
+	opts.EnableRequirements = true
+	err := th.RunWithErr("app", opts)
+	assert.Contains(t, err.Error(),
+		"the kustomization in /app requires --enable-exec, which the build doesn't enable")
+}
+
+func TestRequirementsOfBase(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("base", `
+requirements:
+  helm: true
+`)
+	th.WriteK("overlay", `
+resources:
+- ../base
+`)
+	opts := th.MakeDefaultOptions()
+	opts.EnableRequirements = true
+	err := th.RunWithErr("overlay", opts)
+	assert.Contains(t, err.Error(),
+		"the kustomization in /base requires --enable-helm, which the build doesn't enable")
+}
+
+func TestRequirementsUnknownLoadRestrictor(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("app", `
+requirements:
+  loadRestrictor: none
+`)
+	err := th.RunWithErr("app", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		"requirements.loadRestrictor is none, expected LoadRestrictionsRootOnly or LoadRestrictionsNone")
+}
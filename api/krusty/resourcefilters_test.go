@@ -0,0 +1,115 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeVendoredManifests(th kusttest_test.Harness) {
+	th.WriteK("vendor/upstream", `
+resources:
+- deployment.yaml
+namePrefix: upstream-
+`)
+	th.WriteF("vendor/upstream/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+`)
+	th.WriteF("vendor/upstream/deployment-test.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: controller-test
+`)
+	th.WriteF("vendor/upstream/a-service.yml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: controller
+`)
+	th.WriteF("vendor/upstream/README.md", `
+Not a manifest.
+`)
+	th.WriteF("vendor/upstream/crds/widgets.yaml", `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`)
+}
+
+func TestResourceWithExclude(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeVendoredManifests(th)
+	th.WriteK(".", `
+namespace: system
+resources:
+- path: vendor/upstream
+  exclude:
+  - "*-test.yaml"
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	// The kustomization of the directory isn't built.
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: controller
+  namespace: system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+  namespace: system
+`)
+}
+
+func TestResourceWithInclude(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeVendoredManifests(th)
+	th.WriteK(".", `
+resources:
+- path: vendor/upstream
+  include:
+  - crds/*.yaml
+  - deployment*.yaml
+  exclude:
+  - deployment-test.yaml
+`)
+	m := th.Run(".", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+`)
+}
+
+func TestResourceWithBadGlob(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeVendoredManifests(th)
+	th.WriteK(".", `
+resources:
+- path: vendor/upstream
+  exclude:
+  - "[-test.yaml"
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"the glob '[-test.yaml' of resource 'vendor/upstream'")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestRunWithInput(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteF("patch.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+`)
+	th.WriteK(".", `
+namePrefix: dev-
+resources:
+- service.yaml
+patchesStrategicMerge:
+- patch.yaml
+`)
+	opts := th.MakeDefaultOptions()
+	m, err := krusty.MakeKustomizer(&opts).RunWithInput(
+		th.GetFSys(), ".", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: dev-web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: dev-web
+spec:
+  replicas: 3
+`)
+}
+
+func TestRunWithInputConflict(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteK(".", `
+resources:
+- service.yaml
+`)
+	opts := th.MakeDefaultOptions()
+	_, err := krusty.MakeKustomizer(&opts).RunWithInput(
+		th.GetFSys(), ".", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty
+
+import (
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+)
+
+// schemaUse lets runs using the same OpenAPI schema proceed at
+// once, and makes those using another wait for them to end, as
+// the schema in use is a global of the openapi package.
+type schemaUse struct {
+	mu    sync.Mutex
+	ended *sync.Cond
+	// key identifies the schema selected and parsed,
+	// if valid.
+	key   string
+	valid bool
+	users int
+}
+
+var theSchemaUse = newSchemaUse()
+
+func newSchemaUse() *schemaUse {
+	s := &schemaUse{}
+	s.ended = sync.NewCond(&s.mu)
+	return s
+}
+
+// acquire waits until no run uses a schema other than the one
+// key identifies, and then, unless it's the one parsed already,
+// resets the schema, selects it with set, and parses it.
+func (s *schemaUse) acquire(key string, set func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.users > 0 && s.key != key {
+		s.ended.Wait()
+	}
+	if s.users == 0 && (!s.valid || s.key != key) {
+		s.valid = false
+		openapi.ResetOpenAPI()
+		if err := set(); err != nil {
+			return err
+		}
+		// Parsed now, rather than while in use.
+		openapi.Schema()
+		s.key, s.valid = key, true
+	}
+	s.users++
+	return nil
+}
+
+// release ends a use of the schema acquired.
+func (s *schemaUse) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users--
+	if s.users == 0 {
+		s.ended.Broadcast()
+	}
+}
+
+// buildSchema is the use of theSchemaUse by a build.
+type buildSchema struct {
+	kustomizer *Kustomizer
+	pc         *types.PluginConfig
+	acquired   bool
+	// chosen is whether the kustomization built, a base
+	// or the version of Kubernetes targeted specifies the
+	// schema, which the bases then can't change.
+	chosen bool
+}
+
+// use selects the OpenAPI schema the kustomization specifies,
+// given the custom schema it loads, or else the builtin one of the
+// version of Kubernetes the build targets.  It waits for runs using
+// other schemas to end; the caller must release it.
+func (s *buildSchema) use(k types.Kustomization, custom []byte) error {
+	version, err := s.kustomizer.honorKubeVersion(k, s.pc)
+	if err != nil {
+		return err
+	}
+	err = theSchemaUse.acquire(
+		fmt.Sprint(k.OpenAPI, custom, version), func() error {
+			err := openapi.SetSchema(k.OpenAPI, custom, true)
+			if err != nil || version == "" {
+				return err
+			}
+			return openapi.SetSchema(
+				map[string]string{"version": version}, nil, true)
+		})
+	if err != nil {
+		return err
+	}
+	s.acquired = true
+	s.chosen = len(k.OpenAPI) > 0 || version != ""
+	return nil
+}
+
+// useBase selects the schema of a base, given its openapi field
+// and the custom schema it loads, if the build uses the default
+// one; the first base specifying one wins.  The default one is
+// released first, so that builds switching don't wait for each
+// other.
+func (s *buildSchema) useBase(openAPI map[string]string, custom []byte) error {
+	if !s.acquired {
+		return openapi.SetSchema(openAPI, custom, false)
+	}
+	if s.chosen {
+		return nil
+	}
+	s.release()
+	err := theSchemaUse.acquire(
+		fmt.Sprint(openAPI, custom, ""), func() error {
+			return openapi.SetSchema(openAPI, custom, true)
+		})
+	if err != nil {
+		return err
+	}
+	s.acquired, s.chosen = true, true
+	return nil
+}
+
+// release ends the use of the schema, if any.
+func (s *buildSchema) release() {
+	if s.acquired {
+		theSchemaUse.release()
+		s.acquired = false
+	}
+}
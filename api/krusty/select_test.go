@@ -0,0 +1,198 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func selectOptions(
+	t *testing.T, th kusttest_test.Harness, query string) krusty.Options {
+	t.Helper()
+	q, err := resmap.Query(query)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	opts := th.MakeDefaultOptions()
+	opts.Select = q
+	opts.Experimental = understanding(krusty.ExperimentalSelect)
+	opts.Warnings = &types.Warnings{}
+	return opts
+}
+
+func writeUmbrella(th kusttest_test.Harness, resources string) {
+	th.WriteK("/app/web", `
+resources:
+- deployment.yaml
+`)
+	th.WriteF("/app/web/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`)
+	th.WriteK("/app/db", `
+resources:
+- service.yaml
+`)
+	th.WriteF("/app/db/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+`)
+	th.WriteF("/app/namespace.yaml", `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+`)
+	th.WriteK("/app", resources)
+}
+
+func TestSelectSkipsSources(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeUmbrella(th, `
+namePrefix: shop-
+resources:
+- namespace.yaml
+- web
+- db
+- missing
+- https://github.com/example/repo//cache?ref=v1
+`)
+	var refs []types.RemoteRef
+	opts := selectOptions(t, th, "@source=web")
+	opts.RemoteRefRewriter = rejectingRewriter(&refs)
+	th.AssertActualEqualsExpected(th.Run("/app", opts), `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: shop-web
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: web
+`)
+	assert.Empty(t, refs)
+	assert.Empty(t, opts.Warnings.List)
+}
+
+func TestSelectOtherFields(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeUmbrella(th, `
+resources:
+- namespace.yaml
+- web
+- db
+`)
+	opts := selectOptions(t, th, "kind=Service || @source=namespace.yaml")
+	th.AssertActualEqualsExpected(th.Run("/app", opts), `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+`)
+	assert.Empty(t, opts.Warnings.List)
+}
+
+func TestSelectReferringToSkippedSource(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeUmbrella(th, `
+resources:
+- web
+- config
+patches:
+- target:
+    kind: Deployment
+  patch: |-
+    - op: add
+      path: /spec/template/spec/containers/0/envFrom
+      value:
+      - configMapRef:
+          name: settings
+`)
+	th.WriteK("/app/config", `
+configMapGenerator:
+- name: settings
+  literals:
+  - LEVEL=debug
+`)
+	opts := selectOptions(t, th, "@source=web")
+	th.AssertActualEqualsExpected(th.Run("/app", opts), `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: settings-t84bgbmfg7
+        image: nginx
+        name: web
+`)
+	assert.Equal(t, []types.Warning{{
+		Reason: types.WarningSkippedSources,
+		Path:   "/app",
+		Fields: []string{"ConfigMap settings"},
+	}}, opts.Warnings.List)
+}
+
+func TestSelectFailingWithoutSkippedSource(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeUmbrella(th, `
+resources:
+- web
+- db
+patchesStrategicMerge:
+- service-patch.yaml
+`)
+	th.WriteF("/app/service-patch.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  labels:
+    app: db
+`)
+	opts := selectOptions(t, th, "@source=web")
+	th.AssertActualEqualsExpected(th.Run("/app", opts), `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: web
+`)
+	assert.Equal(t, []types.Warning{{
+		Reason: types.WarningSkippedSources,
+		Path:   "/app",
+	}}, opts.Warnings.List)
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/sidecar"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TestSidecarHelperProcess is the persistent function of
+// TestPersistentExecFunction, run as the test binary.  It
+// annotates the resources with the count of its invocations,
+// and, once stopped, writes the file given by the environment.
+func TestSidecarHelperProcess(t *testing.T) {
+	stopped := os.Getenv("KUSTOMIZE_TEST_SIDECAR_STOPPED")
+	if stopped == "" {
+		return
+	}
+	invocations := 0
+	err := sidecar.Serve(os.Stdin, os.Stdout, func(r io.Reader, w io.Writer) error {
+		invocations++
+		rw := &kio.ByteReadWriter{Reader: r, Writer: w}
+		return kio.Pipeline{
+			Inputs: []kio.Reader{rw},
+			Filters: []kio.Filter{kio.FilterFunc(
+				func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+					for _, n := range nodes {
+						if err := n.PipeE(yaml.SetAnnotation(
+							fmt.Sprintf("invocation-%d", invocations), "true")); err != nil {
+							return nil, err
+						}
+					}
+					return nodes, nil
+				})},
+			Outputs: []kio.Writer{rw},
+		}.Execute()
+	})
+	if err == nil {
+		err = ioutil.WriteFile(stopped, []byte("stopped"), 0600)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestPersistentExecFunction(t *testing.T) {
+	dir := t.TempDir()
+	stopped := filepath.Join(dir, "stopped")
+	os.Setenv("KUSTOMIZE_TEST_SIDECAR_STOPPED", stopped)
+	defer os.Unsetenv("KUSTOMIZE_TEST_SIDECAR_STOPPED")
+	fn := filepath.Join(dir, "fn.sh")
+	if err := ioutil.WriteFile(fn, []byte(fmt.Sprintf(
+		"#!/bin/sh\nexec %q -test.run='^TestSidecarHelperProcess$'\n",
+		os.Args[0])), 0700); err != nil {
+		t.Fatal(err)
+	}
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+resources:
+- configmap.yaml
+transformers:
+- first.yaml
+- second.yaml
+`)
+	th.WriteF("configmap.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+`)
+	for _, name := range []string{"first", "second"} {
+		th.WriteF(name+".yaml", fmt.Sprintf(`
+apiVersion: example.com/v1
+kind: Annotator
+metadata:
+  name: %s
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: %s
+        persistent: true
+`, name, fn))
+	}
+	o := th.MakeOptionsPluginsEnabled()
+	o.PluginConfig.FnpLoadingOptions.EnableExec = true
+	m := th.Run(".", o)
+	// Both transformers were served by the same process.
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    config.kubernetes.io/path: configmap_app.yaml
+    invocation-1: "true"
+    invocation-2: "true"
+  name: app
+`)
+	// The process was stopped once the build ended.
+	content, err := ioutil.ReadFile(stopped)
+	assert.NoError(t, err)
+	assert.Equal(t, "stopped", string(content))
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeStatefulSetWithClaims(th kusttest_test.Harness) {
+	th.WriteF("base/resources.yaml", `
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: fast
+provisioner: example.com/fast
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  serviceName: db
+  template:
+    spec:
+      containers:
+      - name: db
+        image: postgres
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      storageClassName: fast
+      resources:
+        requests:
+          storage: 1Gi
+`)
+	th.WriteK("base", `
+resources:
+- resources.yaml
+`)
+}
+
+func TestStatefulSetVolumeClaimTemplatesTransformedByDefault(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeStatefulSetWithClaims(th)
+	th.WriteK("overlay", `
+namePrefix: prod-
+commonLabels:
+  env: prod
+resources:
+- ../base
+`)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  labels:
+    env: prod
+  name: prod-fast
+provisioner: example.com/fast
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  labels:
+    env: prod
+  name: prod-db
+spec:
+  selector:
+    matchLabels:
+      env: prod
+  serviceName: db
+  template:
+    metadata:
+      labels:
+        env: prod
+    spec:
+      containers:
+      - image: postgres
+        name: db
+  volumeClaimTemplates:
+  - metadata:
+      labels:
+        env: prod
+      name: data
+    spec:
+      resources:
+        requests:
+          storage: 1Gi
+      storageClassName: prod-fast
+`)
+}
+
+func TestStatefulSetPreserveVolumeClaimTemplates(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeStatefulSetWithClaims(th)
+	th.WriteK("overlay", `
+namePrefix: prod-
+commonLabels:
+  env: prod
+commonAnnotations:
+  team: data
+statefulSetOptions:
+  preserveVolumeClaimTemplates: true
+resources:
+- ../base
+`)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  annotations:
+    team: data
+  labels:
+    env: prod
+  name: prod-fast
+provisioner: example.com/fast
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  annotations:
+    team: data
+  labels:
+    env: prod
+  name: prod-db
+spec:
+  selector:
+    matchLabels:
+      env: prod
+  serviceName: db
+  template:
+    metadata:
+      annotations:
+        team: data
+      labels:
+        env: prod
+    spec:
+      containers:
+      - image: postgres
+        name: db
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      resources:
+        requests:
+          storage: 1Gi
+      storageClassName: fast
+`)
+}
+
+func TestStatefulSetPreserveVolumeClaimTemplatesOfBasesAndComponents(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeStatefulSetWithClaims(th)
+	th.WriteK("middle", `
+commonLabels:
+  tier: db
+resources:
+- ../base
+`)
+	th.WriteC("component", `
+commonAnnotations:
+  team: data
+`)
+	th.WriteK("overlay", `
+statefulSetOptions:
+  preserveVolumeClaimTemplates: true
+resources:
+- ../middle
+components:
+- ../component
+`)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  annotations:
+    team: data
+  labels:
+    tier: db
+  name: fast
+provisioner: example.com/fast
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  annotations:
+    team: data
+  labels:
+    tier: db
+  name: db
+spec:
+  selector:
+    matchLabels:
+      tier: db
+  serviceName: db
+  template:
+    metadata:
+      annotations:
+        team: data
+      labels:
+        tier: db
+    spec:
+      containers:
+      - image: postgres
+        name: db
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      resources:
+        requests:
+          storage: 1Gi
+      storageClassName: fast
+`)
+}
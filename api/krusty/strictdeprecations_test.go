@@ -0,0 +1,68 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeDeprecatedBase(th kusttest_test.Harness) {
+	th.WriteF("base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	th.WriteF("base/patch.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+`)
+	th.WriteK("base", `
+resources:
+- deployment.yaml
+patchesStrategicMerge:
+- patch.yaml
+`)
+	th.WriteK("overlay", `
+resources:
+- ../base
+namePrefix: prod-
+`)
+}
+
+func TestDeprecatedFieldsAllowedByDefault(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeDeprecatedBase(th)
+	m := th.Run("overlay", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-web
+spec:
+  replicas: 2
+`)
+}
+
+func TestStrictDeprecationsRejectsDeprecatedFieldsInBase(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeDeprecatedBase(th)
+	opts := th.MakeDefaultOptions()
+	opts.StrictDeprecations = true
+	err := th.RunWithErr("overlay", opts)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "deprecated fields in kustomization file under") ||
+		!strings.Contains(err.Error(), "'patchesStrategicMerge' is deprecated; use 'patches'") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
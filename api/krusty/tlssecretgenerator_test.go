@@ -0,0 +1,63 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeTLSSecretGenerator(th kusttest_test.Harness) {
+	th.WriteK(".", `
+namePrefix: dev-
+resources:
+- deployment.yaml
+tlsSecretGenerator:
+- name: web-tls
+  dnsNames:
+  - web
+`)
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      volumes:
+      - name: tls
+        secret:
+          secretName: web-tls
+`)
+}
+
+func TestTLSSecretGenerator(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTLSSecretGenerator(th)
+	opts := th.MakeDefaultOptions()
+	opts.PluginConfig.TLSSecretGeneratorEnabled = true
+	m := th.Run(".", opts)
+	secret := m.Resources()[1]
+	assert.True(t, strings.HasPrefix(secret.GetName(), "dev-web-tls-"))
+	data := secret.GetDataMap()
+	assert.Len(t, data, 2)
+	assert.Contains(t, data, "tls.crt")
+	assert.Contains(t, data, "tls.key")
+	assert.Contains(t, m.Resources()[0].MustYaml(),
+		"secretName: "+secret.GetName())
+
+	// The name, and so the Secret, is the same at each build.
+	assert.Equal(t, secret.GetName(), th.Run(".", opts).Resources()[1].GetName())
+}
+
+func TestTLSSecretGeneratorDisabled(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeTLSSecretGenerator(th)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(), "must specify --enable-tls-secret-generator")
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func TestUnknownFieldsWarned(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteK(".", `
+resources:
+- service.yaml
+namePrefix: app-
+argocd.argoproj.io/sync-options: Prune=false
+`)
+	opts := th.MakeDefaultOptions()
+	opts.Warnings = &types.Warnings{}
+	th.AssertActualEqualsExpected(th.Run(".", opts), `
+apiVersion: v1
+kind: Service
+metadata:
+  name: app-web
+`)
+	assert.Equal(t, []types.Warning{{
+		Reason: types.WarningUnknownFields,
+		Path:   "/",
+		Fields: []string{"argocd.argoproj.io/sync-options"},
+	}}, opts.Warnings.List)
+}
+
+func TestMisspelledFieldFails(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteF("service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteK(".", `
+resources:
+- service.yaml
+patchesStrategicMerg:
+- patch.yaml
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `unknown field "patchesStrategicMerg"`)
+	}
+}
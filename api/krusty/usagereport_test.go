@@ -0,0 +1,107 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func TestUsageReport(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("base", `
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: cm
+  env: cm.env
+`)
+	th.WriteF("base/cm.env", "A=B\n")
+	th.WriteF("base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	th.WriteC("component", `
+commonLabels:
+  team: a
+`)
+	th.WriteK("overlay", `
+bases:
+- ../base
+components:
+- ../component
+namePrefix: p-
+transformers:
+- annotations.yaml
+- annotator.yaml
+`)
+	th.WriteF("overlay/annotator.yaml", `
+apiVersion: example.com/v1
+kind: Annotator
+metadata:
+  name: team
+key: team
+value: payments
+`)
+	th.WriteF("overlay/annotations.yaml", `
+apiVersion: builtin
+kind: AnnotationsTransformer
+metadata:
+  name: notImportantHere
+annotations:
+  a: b
+fieldSpecs:
+- path: metadata/annotations
+  create: true
+`)
+	opts := th.MakeDefaultOptions()
+	opts.Experimental = understanding(krusty.ExperimentalRegisteredPlugins)
+	assert.NoError(t, opts.RegisterPlugin("Annotator", func() resmap.Configurable {
+		return &annotator{}
+	}))
+	opts.UsageReport = &types.UsageReport{}
+	th.Run("overlay", opts)
+	assert.Equal(t, &types.UsageReport{
+		Kustomizations: 3,
+		Components:     1,
+		Fields: map[string]int{
+			"bases":              1,
+			"commonLabels":       1,
+			"components":         1,
+			"configMapGenerator": 1,
+			"namePrefix":         1,
+			"resources":          1,
+			"transformers":       1,
+		},
+		DeprecatedFields: map[string]int{
+			"bases":                  1,
+			"configMapGenerator.env": 1,
+		},
+		Builtins: map[string]int{
+			"AnnotationsTransformer":  1,
+			"ConfigMapGenerator":      1,
+			"HashTransformer":         1,
+			"LabelTransformer":        1,
+			"PrefixSuffixTransformer": 1,
+		},
+		Plugins: map[string]int{"registered": 1},
+	}, opts.UsageReport)
+}
+
+func TestUsageReportNotRequested(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+namePrefix: p-
+`)
+	opts := th.MakeDefaultOptions()
+	assert.Nil(t, opts.UsageReport)
+	th.Run(".", opts)
+}
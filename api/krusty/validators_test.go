@@ -0,0 +1,105 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func writeValidatedDeployment(th kusttest_test.Harness) {
+	th.WriteF("deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`)
+	th.WriteF("validator.yaml", `
+apiVersion: example.com/v1
+kind: CheckReplicas
+metadata:
+  name: check-replicas
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: ./fnplugin_test/fnvalidatortest.sh
+`)
+}
+
+func makeOptionsExecEnabled(th kusttest_test.Harness) krusty.Options {
+	o := th.MakeOptionsPluginsEnabled()
+	o.PluginConfig.FnpLoadingOptions.EnableExec = true
+	return o
+}
+
+func TestValidatorsErrorResults(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeValidatedDeployment(th)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+validators:
+- validator.yaml
+`)
+	err := th.RunWithErr(".", makeOptionsExecEnabled(th))
+	assert.Contains(t, err.Error(), "[error] api: example.com/v1, "+
+		"kind: CheckReplicas, name: check-replicas apps/v1 Deployment web: "+
+		"has no owner label")
+	assert.NotContains(t, err.Error(), "has a single replica")
+}
+
+func TestValidatorsSeverities(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	writeValidatedDeployment(th)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+validators:
+- validator.yaml
+validatorsOptions:
+  severities:
+    error: warn
+`)
+	m := th.Run(".", makeOptionsExecEnabled(th))
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`)
+	th.WriteK(".", `
+resources:
+- deployment.yaml
+validators:
+- validator.yaml
+validatorsOptions:
+  severities:
+    error: ignore
+    warning: fail
+`)
+	err := th.RunWithErr(".", makeOptionsExecEnabled(th))
+	assert.Contains(t, err.Error(),
+		"apps/v1 Deployment web spec.replicas: has a single replica")
+	assert.NotContains(t, err.Error(), "has no owner label")
+}
+
+func TestValidatorsUnknownSeverity(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK(".", `
+validatorsOptions:
+  severities:
+    fatal: fail
+`)
+	err := th.RunWithErr(".", th.MakeDefaultOptions())
+	assert.Contains(t, err.Error(),
+		`validatorsOptions: unknown severity "fatal", `+
+			`expected one of error, warning or info`)
+}
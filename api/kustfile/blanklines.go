@@ -0,0 +1,115 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kustfile
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// blankLines records the blank lines of a file that the yaml
+// parser drops, to restore them when writing the file: those
+// at its start, and those around the head comment of each top
+// level field.
+type blankLines struct {
+	leading []string
+	fields  map[*yaml.Node]fieldBlankLines
+}
+
+// fieldBlankLines are the blank lines above the head comment
+// of a field, or the field if it has none, and below it.
+type fieldBlankLines struct {
+	above, below []string
+}
+
+// findBlankLines returns the blank lines of the content,
+// whose top level mapping is m.
+func findBlankLines(content string, m *yaml.Node) blankLines {
+	lines := strings.Split(content, "\n")
+	b := blankLines{fields: make(map[*yaml.Node]fieldBlankLines)}
+	for i := 0; i < len(lines) && isBlank(lines[i]); i++ {
+		b.leading = append(b.leading, lines[i])
+	}
+	if m.Kind != yaml.MappingNode {
+		return b
+	}
+	// blankRun returns the blank lines ending at line j,
+	// and the index of the line above them.
+	blankRun := func(j int) ([]string, int) {
+		var run []string
+		for ; j >= 0 && j < len(lines) && isBlank(lines[j]); j-- {
+			run = append([]string{lines[j]}, run...)
+		}
+		return run, j
+	}
+	for i := 0; i < len(m.Content); i += 2 {
+		k := m.Content[i]
+		var f fieldBlankLines
+		j := k.Line - 2
+		if n := countLines(k.HeadComment); n > 0 {
+			f.below, j = blankRun(j)
+			j -= n
+		}
+		f.above, j = blankRun(j)
+		if j < 0 {
+			// The blank lines at the start are leading.
+			f.above = nil
+		}
+		if f.above != nil || f.below != nil {
+			b.fields[k] = f
+		}
+	}
+	return b
+}
+
+// restore returns the content written from the mapping m,
+// with the blank lines recorded put back, where the content
+// lacks them.
+func (b blankLines) restore(content string, m *yaml.Node) string {
+	var keys []*yaml.Node
+	if m.Kind == yaml.MappingNode {
+		for i := 0; i < len(m.Content); i += 2 {
+			keys = append(keys, m.Content[i])
+		}
+	}
+	var result []string
+	insert := func(pos int, blank []string) {
+		if len(blank) > 0 && pos > 0 && !isBlank(result[pos-1]) {
+			result = append(result[:pos],
+				append(append([]string{}, blank...), result[pos:]...)...)
+		}
+	}
+	for _, l := range strings.Split(content, "\n") {
+		if len(keys) > 0 && strings.HasPrefix(l, keys[0].Value+":") {
+			f := b.fields[keys[0]]
+			n := countLines(keys[0].HeadComment)
+			insert(len(result)-n, f.above)
+			if n > 0 {
+				insert(len(result), f.below)
+			}
+			keys = keys[1:]
+		}
+		result = append(result, l)
+	}
+	if len(result) > 0 && !isBlank(result[0]) {
+		result = append(append([]string{}, b.leading...), result...)
+	}
+	return strings.Join(result, "\n")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// countLines returns the number of lines the comment is
+// written on; the parser ends a comment followed by blank
+// lines with a newline, which isn't written.
+func countLines(comment string) int {
+	comment = strings.TrimRight(comment, "\n")
+	if comment == "" {
+		return 0
+	}
+	return strings.Count(comment, "\n") + 1
+}
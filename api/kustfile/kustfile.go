@@ -0,0 +1,177 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kustfile reads kustomization files into Kustomizations,
+// and writes them back once changed, keeping the comments of the
+// files, the order of their fields, and the style of the values
+// that didn't change, so that tools editing kustomizations, e.g.
+// kustomize edit, only change what they mean to.  Indentation,
+// and the spacing before line comments, are written the way the
+// yaml writer writes them.
+package kustfile
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/types"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// File is a kustomization file and its Kustomization.
+type File struct {
+	// Path is the path of the file, if read from one.
+	Path string
+
+	// Kustomization is the content of the file, fixed as
+	// builds fix it, e.g. with bases moved to resources,
+	// to change before writing the file back.
+	Kustomization *types.Kustomization
+
+	// node is the file as read, or nil if it was empty,
+	// and blank the blank lines the node lacks.
+	node  *kyaml.RNode
+	blank blankLines
+}
+
+// Load reads the kustomization file in dir, which must hold
+// exactly one of the recognized kustomization file names.
+func Load(fSys filesys.FileSystem, dir string) (*File, error) {
+	var paths []string
+	for _, n := range konfig.RecognizedKustomizationFileNames() {
+		if p := filepath.Join(dir, n); fSys.Exists(p) {
+			paths = append(paths, p)
+		}
+	}
+	switch len(paths) {
+	case 0:
+		return nil, fmt.Errorf("no kustomization file in %s", dir)
+	case 1:
+	default:
+		return nil, fmt.Errorf(
+			"found multiple kustomization files in %s: %v", dir, paths)
+	}
+	return LoadFile(fSys, paths[0])
+}
+
+// LoadFile reads the kustomization file at path, by any
+// name, e.g. prod.kustomization.yaml.
+func LoadFile(fSys filesys.FileSystem, path string) (*File, error) {
+	content, err := fSys.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	f.Path = path
+	return f, nil
+}
+
+// Parse returns the file of the content, which may be empty.
+func Parse(content []byte) (*File, error) {
+	data, err := types.FixKustomizationPreUnmarshalling(content)
+	if err != nil {
+		return nil, err
+	}
+	k := &types.Kustomization{}
+	if err = k.UnmarshalWithExtensions(data); err != nil {
+		return nil, err
+	}
+	k.FixKustomizationPostUnmarshalling()
+	f := &File{Kustomization: k}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if f.node, err = kyaml.Parse(escapeNonASCII(string(data))); err != nil {
+			return nil, err
+		}
+		unescapeValues(f.node.YNode())
+		f.blank = findBlankLines(string(data), f.node.YNode())
+	}
+	return f, nil
+}
+
+// Marshal returns the content of the file, holding its
+// Kustomization, with the comments, field order and value
+// styles of the file as read, for the values still in it.
+// The fields added are placed after the others, in the
+// order kustomize writes new kustomizations in.
+func (f *File) Marshal() ([]byte, error) {
+	if f.Kustomization == nil {
+		return nil, fmt.Errorf("the file has no kustomization")
+	}
+	b, err := yaml.Marshal(f.Kustomization)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := kyaml.Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+	sortTopLevelFields(updated.YNode())
+	if f.node == nil {
+		return []byte(updated.MustString()), nil
+	}
+	// The node is merged into as is, rather than copied,
+	// as the blank lines are recorded by its fields.
+	merged := merge(f.node.YNode(), updated.YNode(), true)
+	s, err := kyaml.String(merged)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(unescapeNonASCII(f.blank.restore(s, merged))), nil
+}
+
+// Save writes the file back to its path.
+func (f *File) Save(fSys filesys.FileSystem) error {
+	if f.Path == "" {
+		return fmt.Errorf("the file has no path")
+	}
+	content, err := f.Marshal()
+	if err != nil {
+		return err
+	}
+	return fSys.WriteFile(f.Path, content)
+}
+
+// The yaml parser mangles the characters of comments that aren't
+// ASCII, e.g. quotes, so the content is parsed with them escaped,
+// unescaping the values right away, and the comments once written.
+var escapedRune = regexp.MustCompile(`<kustfile:U\+([0-9A-F]+)>`)
+
+func escapeNonASCII(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+		} else {
+			fmt.Fprintf(&b, "<kustfile:U+%X>", r)
+		}
+	}
+	return b.String()
+}
+
+func unescapeNonASCII(s string) string {
+	return escapedRune.ReplaceAllStringFunc(s, func(m string) string {
+		r, err := strconv.ParseInt(escapedRune.FindStringSubmatch(m)[1], 16, 32)
+		if err != nil {
+			return m
+		}
+		return string(rune(r))
+	})
+}
+
+func unescapeValues(n *kyaml.Node) {
+	n.Value = unescapeNonASCII(n.Value)
+	for _, c := range n.Content {
+		unescapeValues(c)
+	}
+}
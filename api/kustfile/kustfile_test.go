@@ -0,0 +1,246 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kustfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+const commented = `
+
+# The web app, as run in production.
+# “Owned” by the web team.
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+
+namePrefix: 'prod-' # keep the quotes
+resources:
+- ../base
+# the ingress only runs in production
+- ingress.yaml
+
+# Pinned by the release tooling.
+images:
+- name: web
+  newTag: v1 # the released tag
+`
+
+func TestRoundTrip(t *testing.T) {
+	f, err := Parse([]byte(commented))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "prod-", f.Kustomization.NamePrefix)
+	b, err := f.Marshal()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, commented, string(b))
+}
+
+func TestChanges(t *testing.T) {
+	f, err := Parse([]byte(commented))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	k := f.Kustomization
+	k.NamePrefix = "production-"
+	k.Resources = append(k.Resources, "pdb.yaml")
+	k.Images[0].NewTag = "v2"
+	k.Images = append(k.Images, types.Image{Name: "proxy", NewTag: "1.17"})
+	k.EnvSources = []string{"RELEASE"}
+	b, err := f.Marshal()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `
+
+# The web app, as run in production.
+# “Owned” by the web team.
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+
+namePrefix: 'production-' # keep the quotes
+resources:
+- ../base
+# the ingress only runs in production
+- ingress.yaml
+- pdb.yaml
+
+# Pinned by the release tooling.
+images:
+- name: web
+  newTag: v2 # the released tag
+- name: proxy
+  newTag: "1.17"
+envSources:
+- RELEASE
+`, string(b))
+}
+
+func TestComponentsWithValues(t *testing.T) {
+	f, err := Parse([]byte(`components:
+- ../components/monitoring # no values
+- path: ../components/scaling
+  valuesInline:
+    replicas: 3
+`))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	k := f.Kustomization
+	assert.Equal(t, []types.Component{
+		{Path: "../components/monitoring"},
+		{Path: "../components/scaling",
+			ValuesInline: map[string]interface{}{"replicas": float64(3)}},
+	}, k.Components)
+	k.Components = append(k.Components, types.Component{
+		Path: "../components/backup", ValuesFile: "backup-values.yaml"})
+	b, err := f.Marshal()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `components:
+- ../components/monitoring # no values
+- path: ../components/scaling
+  valuesInline:
+    replicas: 3
+- path: ../components/backup
+  valuesFile: backup-values.yaml
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`, string(b))
+}
+
+func TestResourcesWithFilters(t *testing.T) {
+	f, err := Parse([]byte(`resources:
+- deployment.yaml
+- path: vendor/upstream
+  exclude:
+  - '*-test.yaml'
+`))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	k := f.Kustomization
+	assert.Equal(t, []string{"deployment.yaml", "vendor/upstream"}, k.Resources)
+	assert.Equal(t, []types.ResourceFilter{
+		{Path: "vendor/upstream", Exclude: []string{"*-test.yaml"}},
+	}, k.ResourceFilters)
+	k.Resources = append(k.Resources, "vendor/crds")
+	k.ResourceFilters = append(k.ResourceFilters, types.ResourceFilter{
+		Path: "vendor/crds", Include: []string{"*.json"}})
+	b, err := f.Marshal()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `resources:
+- deployment.yaml
+- path: vendor/upstream
+  exclude:
+  - '*-test.yaml'
+- include:
+  - '*.json'
+  path: vendor/crds
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`, string(b))
+	_, err = Parse([]byte(`resources:
+- exclude: ["*-test.yaml"]
+`))
+	assert.Error(t, err)
+}
+
+func TestRemovedFieldKeepsHeadComment(t *testing.T) {
+	f, err := Parse([]byte(`# Shared by all environments.
+namePrefix: dev-
+resources:
+- deployment.yaml
+`))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	f.Kustomization.NamePrefix = ""
+	b, err := f.Marshal()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `# Shared by all environments.
+resources:
+- deployment.yaml
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`, string(b))
+}
+
+func TestNewFile(t *testing.T) {
+	f, err := Parse(nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	f.Kustomization.Namespace = "web"
+	f.Kustomization.Resources = []string{"deployment.yaml"}
+	f.Kustomization.NamePrefix = "prod-"
+	b, err := f.Marshal()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- deployment.yaml
+namePrefix: prod-
+namespace: web
+`, string(b))
+}
+
+func TestLoadAndSave(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	_, err := Load(fSys, "app")
+	if assert.Error(t, err) {
+		assert.Equal(t, "no kustomization file in app", err.Error())
+	}
+	fSys.WriteFile("app/kustomization.yaml", []byte(commented))
+	f, err := Load(fSys, "app")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "app/kustomization.yaml", f.Path)
+	f.Kustomization.Namespace = "web"
+	if !assert.NoError(t, f.Save(fSys)) {
+		t.FailNow()
+	}
+	b, err := fSys.ReadFile("app/kustomization.yaml")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, commented+"namespace: web\n", string(b))
+
+	fSys.WriteFile("app/kustomization.yml", []byte(commented))
+	_, err = Load(fSys, "app")
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(
+			err.Error(), "found multiple kustomization files in app"))
+	}
+}
+
+func TestFieldOrder(t *testing.T) {
+	fields := make(map[string]bool)
+	typ := reflect.TypeOf(types.Kustomization{})
+	for i := 0; i < typ.NumField(); i++ {
+		fields[strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]] = true
+	}
+	for n := range fieldOrder {
+		assert.True(t, fields[n] || n == "apiVersion" || n == "kind",
+			"%s isn't a field of kustomizations", n)
+	}
+	assert.Equal(t, 0, fieldOrder["apiVersion"])
+	assert.Less(t, fieldOrder["resources"], fieldOrder["namePrefix"])
+}
@@ -0,0 +1,271 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kustfile
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// fieldOrder holds the top level fields of kustomizations, in
+// the order they're written in when added: first the commonly
+// used ones, in the order kustomize edit has always written them,
+// then the others, in the order of the Kustomization type.
+var fieldOrder = determineFieldOrder()
+
+func determineFieldOrder() map[string]int {
+	result := make(map[string]int)
+	for _, n := range []string{
+		"apiVersion",
+		"kind",
+		"metadata",
+		"resources",
+		"bases",
+		"namePrefix",
+		"nameSuffix",
+		"namespace",
+		"crds",
+		"commonLabels",
+		"labels",
+		"commonAnnotations",
+		"patchesStrategicMerge",
+		"patchesJson6902",
+		"patches",
+		"configMapGenerator",
+		"secretGenerator",
+		"generatorOptions",
+		"vars",
+		"images",
+		"replicas",
+		"configurations",
+		"generators",
+		"transformers",
+		"inventory",
+		"components",
+	} {
+		result[n] = len(result)
+	}
+	t := reflect.TypeOf(types.Kustomization{})
+	for i := 0; i < t.NumField(); i++ {
+		n := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if _, ok := result[n]; !ok && n != "" && n != "-" {
+			result[n] = len(result)
+		}
+	}
+	return result
+}
+
+// sortTopLevelFields sorts the fields of the mapping node
+// by fieldOrder.
+func sortTopLevelFields(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode {
+		sortFields(n.Content, func(a, b string) bool {
+			return fieldOrder[a] < fieldOrder[b]
+		})
+	}
+}
+
+// sortFields stably sorts the key and value pairs of
+// the content of a mapping node by their keys.
+func sortFields(content []*yaml.Node, less func(a, b string) bool) {
+	var pairs [][2]*yaml.Node
+	for i := 0; i+1 < len(content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{content[i], content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return less(pairs[i][0].Value, pairs[j][0].Value)
+	})
+	for i, p := range pairs {
+		content[2*i], content[2*i+1] = p[0], p[1]
+	}
+}
+
+// merge returns the original node, changed to hold what the
+// updated one does, keeping the comments, order and style of
+// what didn't change.  The top level node is that of the file.
+func merge(orig, updated *yaml.Node, top bool) *yaml.Node {
+	if orig.Kind != updated.Kind {
+		return replace(orig, updated)
+	}
+	switch orig.Kind {
+	case yaml.MappingNode:
+		mergeMapping(orig, updated, top)
+		return orig
+	case yaml.SequenceNode:
+		mergeSequence(orig, updated)
+		return orig
+	case yaml.ScalarNode:
+		if equal(orig, updated) {
+			return orig
+		}
+		if orig.ShortTag() == yaml.NodeTagString &&
+			updated.ShortTag() == yaml.NodeTagString &&
+			orig.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+			orig.Value = updated.Value
+			return orig
+		}
+	}
+	return replace(orig, updated)
+}
+
+// replace returns the updated node, with the comments
+// of the original one.
+func replace(orig, updated *yaml.Node) *yaml.Node {
+	updated.HeadComment = orig.HeadComment
+	updated.LineComment = orig.LineComment
+	updated.FootComment = orig.FootComment
+	return updated
+}
+
+// mergeMapping merges the fields of the updated mapping into
+// those of the original, in their order, removing those it
+// lacks and appending those only it has, or, below the top
+// level, sorting them in if the original's are sorted, as
+// kustomize has always written them.  Fields are matched
+// ignoring case, as they are when unmarshalled, and renamed
+// as updated.
+func mergeMapping(orig, updated *yaml.Node, top bool) {
+	used := make(map[int]bool)
+	var content []*yaml.Node
+	// The head comment of a removed field, e.g. the first
+	// of the file, goes to the next field kept.
+	var comment string
+	for i := 0; i+1 < len(orig.Content); i += 2 {
+		k, v := orig.Content[i], orig.Content[i+1]
+		j := findKey(updated, k.Value, used)
+		if j < 0 {
+			comment = joinComments(comment, k.HeadComment)
+			continue
+		}
+		used[j] = true
+		k.Value = updated.Content[j].Value
+		k.HeadComment = joinComments(comment, k.HeadComment)
+		comment = ""
+		content = append(content, k, merge(v, updated.Content[j+1], false))
+	}
+	sorted := !top && keysSorted(content)
+	for j := 0; j+1 < len(updated.Content); j += 2 {
+		if !used[j] {
+			k := updated.Content[j]
+			k.HeadComment = joinComments(comment, k.HeadComment)
+			comment = ""
+			content = append(content, k, updated.Content[j+1])
+		}
+	}
+	if sorted {
+		sortFields(content, func(a, b string) bool { return a < b })
+	}
+	orig.Content = content
+	if comment != "" {
+		orig.FootComment = joinComments(orig.FootComment, comment)
+	}
+}
+
+// keysSorted returns whether the keys of the
+// content of a mapping node are sorted.
+func keysSorted(content []*yaml.Node) bool {
+	for i := 2; i+1 < len(content); i += 2 {
+		if content[i].Value < content[i-2].Value {
+			return false
+		}
+	}
+	return true
+}
+
+// findKey returns the index of the unused key of the
+// mapping matching the name, or -1 if there's none.
+func findKey(m *yaml.Node, name string, used map[int]bool) int {
+	match := -1
+	for j := 0; j+1 < len(m.Content); j += 2 {
+		if used[j] {
+			continue
+		}
+		if m.Content[j].Value == name {
+			return j
+		}
+		if match < 0 && strings.EqualFold(m.Content[j].Value, name) {
+			match = j
+		}
+	}
+	return match
+}
+
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+// mergeSequence merges the elements of the updated sequence
+// into those of the original, keeping the original elements
+// equal to updated ones, merging those at the same index as
+// an updated mapping into it, e.g. an edited generator, and
+// dropping the others.
+func mergeSequence(orig, updated *yaml.Node) {
+	used := make([]bool, len(orig.Content))
+	content := make([]*yaml.Node, len(updated.Content))
+	for i, u := range updated.Content {
+		for j, o := range orig.Content {
+			if !used[j] && equal(o, u) {
+				used[j] = true
+				content[i] = o
+				break
+			}
+		}
+	}
+	for i, u := range updated.Content {
+		if content[i] != nil {
+			continue
+		}
+		if i < len(orig.Content) && !used[i] &&
+			orig.Content[i].Kind == yaml.MappingNode &&
+			u.Kind == yaml.MappingNode {
+			used[i] = true
+			content[i] = merge(orig.Content[i], u, false)
+		} else {
+			content[i] = u
+		}
+	}
+	orig.Content = content
+}
+
+// equal returns whether the nodes hold the same
+// values, in any style and field order.
+func equal(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind {
+		return false
+	}
+	switch a.Kind {
+	case yaml.ScalarNode:
+		return a.Value == b.Value && a.ShortTag() == b.ShortTag()
+	case yaml.MappingNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			j := findKey(b, a.Content[i].Value, nil)
+			if j < 0 || b.Content[j].Value != a.Content[i].Value ||
+				!equal(a.Content[i+1], b.Content[j+1]) {
+				return false
+			}
+		}
+		return true
+	case yaml.SequenceNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := range a.Content {
+			if !equal(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
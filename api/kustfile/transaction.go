@@ -0,0 +1,182 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kustfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// Transaction changes a kustomization file, through any number
+// of modifications, validating the kustomization and writing
+// the file only once, when committed, so that the file is never
+// left holding only some of the changes, or an invalid
+// kustomization, e.g. by automation making several edits.
+//
+//	t, err := kustfile.Begin(fSys, "overlays/prod")
+//	...
+//	err = t.Modify(func(k *types.Kustomization) error {
+//	  k.Resources = append(k.Resources, "pdb.yaml")
+//	  return nil
+//	})
+//	...
+//	err = t.Commit()
+//
+// A transaction is done once committed or rolled back.
+type Transaction struct {
+	fSys filesys.FileSystem
+	file *File
+	// original is the content of the file when the
+	// transaction began, and invalid what was invalid
+	// in its kustomization then, which isn't blamed
+	// on the modifications.
+	original []byte
+	invalid  map[string]bool
+	done     bool
+}
+
+// Begin begins a transaction changing the
+// kustomization file in dir.
+func Begin(fSys filesys.FileSystem, dir string) (*Transaction, error) {
+	f, err := Load(fSys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return begin(fSys, f)
+}
+
+// BeginFile begins a transaction changing the kustomization
+// file at path, by any name, e.g. prod.kustomization.yaml.
+func BeginFile(fSys filesys.FileSystem, path string) (*Transaction, error) {
+	f, err := LoadFile(fSys, path)
+	if err != nil {
+		return nil, err
+	}
+	return begin(fSys, f)
+}
+
+func begin(fSys filesys.FileSystem, f *File) (*Transaction, error) {
+	original, err := fSys.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	invalid := make(map[string]bool)
+	for _, e := range validate(f.Kustomization) {
+		invalid[e] = true
+	}
+	return &Transaction{
+		fSys: fSys, file: f, original: original, invalid: invalid}, nil
+}
+
+// Path returns the path of the file the transaction changes.
+func (t *Transaction) Path() string {
+	return t.file.Path
+}
+
+// Kustomization returns a copy of the kustomization,
+// with the modifications made so far.
+func (t *Transaction) Kustomization() (*types.Kustomization, error) {
+	if t.done {
+		return nil, errDone
+	}
+	return copyKustomization(t.file.Kustomization)
+}
+
+// Modify calls fn to change the kustomization.  If fn errs,
+// the kustomization is left as it was, and the error returned.
+func (t *Transaction) Modify(fn func(k *types.Kustomization) error) error {
+	if t.done {
+		return errDone
+	}
+	k, err := copyKustomization(t.file.Kustomization)
+	if err != nil {
+		return err
+	}
+	if err = fn(k); err != nil {
+		return err
+	}
+	t.file.Kustomization = k
+	return nil
+}
+
+// Commit validates the kustomization and writes the file,
+// ending the transaction.  The file is left as it was if
+// the modifications made the kustomization invalid, or if
+// the file changed since the transaction began, which would
+// lose that change.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return errDone
+	}
+	t.done = true
+	var errs []string
+	for _, e := range validate(t.file.Kustomization) {
+		if !t.invalid[e] {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("not writing %s, as its kustomization would be invalid: %s",
+			t.file.Path, strings.Join(errs, "; "))
+	}
+	content, err := t.file.Marshal()
+	if err != nil {
+		return err
+	}
+	// The content must read back as the kustomization it holds.
+	if _, err = Parse(content); err != nil {
+		return fmt.Errorf("not writing %s: %v", t.file.Path, err)
+	}
+	current, err := t.fSys.ReadFile(t.file.Path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, t.original) {
+		return fmt.Errorf(
+			"not writing %s, which changed since the transaction began",
+			t.file.Path)
+	}
+	return t.fSys.WriteFile(t.file.Path, content)
+}
+
+// Rollback ends the transaction, discarding its modifications;
+// it does nothing if the transaction is done already.
+func (t *Transaction) Rollback() {
+	t.done = true
+}
+
+var errDone = fmt.Errorf("the transaction is done")
+
+// validate returns what in the kustomization
+// a build would reject outright.
+func validate(k *types.Kustomization) []string {
+	errs := k.EnforceFields()
+	if err := k.CommonLabelsOptions.Validate(); err != nil {
+		errs = append(errs, "commonLabelsOptions: "+err.Error())
+	}
+	if err := k.CommonAnnotationsOptions.Validate(); err != nil {
+		errs = append(errs, "commonAnnotationsOptions: "+err.Error())
+	}
+	if err := k.ValidatorsOptions.Validate(); err != nil {
+		errs = append(errs, "validatorsOptions: "+err.Error())
+	}
+	return errs
+}
+
+func copyKustomization(k *types.Kustomization) (*types.Kustomization, error) {
+	b, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	c := &types.Kustomization{}
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
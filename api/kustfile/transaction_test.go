@@ -0,0 +1,112 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kustfile
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+const original = `# The production overlay.
+resources:
+- ../base
+`
+
+func beginTransaction(t *testing.T) (filesys.FileSystem, *Transaction) {
+	t.Helper()
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("prod/kustomization.yaml", []byte(original))
+	tx, err := Begin(fSys, "prod")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return fSys, tx
+}
+
+func assertContent(t *testing.T, fSys filesys.FileSystem, expected string) {
+	t.Helper()
+	b, err := fSys.ReadFile("prod/kustomization.yaml")
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, string(b))
+	}
+}
+
+func TestTransactionCommit(t *testing.T) {
+	fSys, tx := beginTransaction(t)
+	assert.NoError(t, tx.Modify(func(k *types.Kustomization) error {
+		k.Resources = append(k.Resources, "pdb.yaml")
+		return nil
+	}))
+	assert.NoError(t, tx.Modify(func(k *types.Kustomization) error {
+		k.NamePrefix = "prod-"
+		return nil
+	}))
+	assert.Error(t, tx.Modify(func(k *types.Kustomization) error {
+		k.Namespace = "half-done"
+		return fmt.Errorf("no such namespace")
+	}))
+	assertContent(t, fSys, original)
+	if !assert.NoError(t, tx.Commit()) {
+		t.FailNow()
+	}
+	assertContent(t, fSys, `# The production overlay.
+resources:
+- ../base
+- pdb.yaml
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namePrefix: prod-
+`)
+	err := tx.Modify(func(k *types.Kustomization) error { return nil })
+	if assert.Error(t, err) {
+		assert.Equal(t, "the transaction is done", err.Error())
+	}
+}
+
+func TestTransactionInvalid(t *testing.T) {
+	fSys, tx := beginTransaction(t)
+	assert.NoError(t, tx.Modify(func(k *types.Kustomization) error {
+		k.EnvSources = []string{"IMAGE-TAG"}
+		return nil
+	}))
+	err := tx.Commit()
+	if assert.Error(t, err) {
+		assert.Equal(t,
+			"not writing prod/kustomization.yaml, as its kustomization would be "+
+				"invalid: envSources holds IMAGE-TAG, which isn't the name of an "+
+				"environment variable", err.Error())
+	}
+	assertContent(t, fSys, original)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	fSys, tx := beginTransaction(t)
+	assert.NoError(t, tx.Modify(func(k *types.Kustomization) error {
+		k.NamePrefix = "prod-"
+		return nil
+	}))
+	tx.Rollback()
+	assert.Error(t, tx.Commit())
+	assertContent(t, fSys, original)
+}
+
+func TestTransactionConflict(t *testing.T) {
+	fSys, tx := beginTransaction(t)
+	assert.NoError(t, tx.Modify(func(k *types.Kustomization) error {
+		k.NamePrefix = "prod-"
+		return nil
+	}))
+	fSys.WriteFile("prod/kustomization.yaml", []byte("resources: []\n"))
+	err := tx.Commit()
+	if assert.Error(t, err) {
+		assert.Equal(t,
+			"not writing prod/kustomization.yaml, which changed since the "+
+				"transaction began", err.Error())
+	}
+	assertContent(t, fSys, "resources: []\n")
+}
@@ -10,11 +10,14 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/ifc"
 	"sigs.k8s.io/kustomize/api/internal/git"
+	"sigs.k8s.io/kustomize/api/internal/kusterr"
+	"sigs.k8s.io/kustomize/api/types"
 )
 
 // fileLoader is a kustomization's interface to files.
@@ -95,6 +98,14 @@ type fileLoader struct {
 	// Used to clone repositories.
 	cloner git.Cloner
 
+	// If this is non-nil, what the loader may fetch,
+	// shared with the loaders it creates.
+	policy *types.Policy
+
+	// If this is non-nil, what rewrites the remote references
+	// of the loader, shared with the loaders it creates.
+	rewriter types.RemoteRefRewriter
+
 	// Used to clean up, as needed.
 	cleaner func() error
 }
@@ -141,10 +152,28 @@ func newLoaderAtConfirmedDir(
 		referrer:       referrer,
 		fSys:           fSys,
 		cloner:         cloner,
+		policy:         referrer.getPolicy(),
+		rewriter:       referrer.getRewriter(),
 		cleaner:        func() error { return nil },
 	}
 }
 
+// getPolicy returns the policy of fl, which may be nil.
+func (fl *fileLoader) getPolicy() *types.Policy {
+	if fl == nil {
+		return nil
+	}
+	return fl.policy
+}
+
+// getRewriter returns the rewriter of fl, which may be nil.
+func (fl *fileLoader) getRewriter() types.RemoteRefRewriter {
+	if fl == nil {
+		return nil
+	}
+	return fl.rewriter
+}
+
 // Assure that the given path is in fact a directory.
 func demandDirectoryRoot(
 	fSys filesys.FileSystem, path string) (filesys.ConfirmedDir, error) {
@@ -177,8 +206,18 @@ func (fl *fileLoader) New(path string) (ifc.Loader, error) {
 		if err = fl.errIfRepoCycle(repoSpec); err != nil {
 			return nil, err
 		}
+		err = fl.policy.CheckGitRepo(
+			fl.root.String(), repoSpec.Host, repoSpec.OrgRepo)
+		if err != nil {
+			return nil, err
+		}
+		repoSpec, err = rewriteRepoSpec(
+			fl.rewriter, fl.root.String(), path, repoSpec)
+		if err != nil {
+			return nil, err
+		}
 		return newLoaderAtGitClone(
-			repoSpec, fl.fSys, fl, fl.cloner)
+			repoSpec, fl.fSys, fl, fl.cloner, fl.policy, fl.rewriter)
 	}
 
 	if filepath.IsAbs(path) {
@@ -202,12 +241,13 @@ func (fl *fileLoader) New(path string) (ifc.Loader, error) {
 // directory holding a cloned git repo.
 func newLoaderAtGitClone(
 	repoSpec *git.RepoSpec, fSys filesys.FileSystem,
-	referrer *fileLoader, cloner git.Cloner) (ifc.Loader, error) {
+	referrer *fileLoader, cloner git.Cloner,
+	policy *types.Policy, rewriter types.RemoteRefRewriter) (ifc.Loader, error) {
 	cleaner := repoSpec.Cleaner(fSys)
 	err := cloner(repoSpec)
 	if err != nil {
 		cleaner()
-		return nil, err
+		return nil, &kusterr.InfrastructureError{Err: err}
 	}
 	root, f, err := fSys.CleanedAbs(repoSpec.AbsPath())
 	if err != nil {
@@ -232,6 +272,8 @@ func newLoaderAtGitClone(
 		repoSpec:       repoSpec,
 		fSys:           fSys,
 		cloner:         cloner,
+		policy:         policy,
+		rewriter:       rewriter,
 		cleaner:        cleaner,
 	}, nil
 }
@@ -268,15 +310,18 @@ func (fl *fileLoader) containingRepo() *git.RepoSpec {
 // is equal to or above the root of any ancestor.
 func (fl *fileLoader) errIfArgEqualOrHigher(
 	candidateRoot filesys.ConfirmedDir) error {
-	if fl.root.HasPrefix(candidateRoot) {
-		return fmt.Errorf(
-			"cycle detected: candidate root '%s' contains visited root '%s'",
-			candidateRoot, fl.root)
-	}
-	if fl.referrer == nil {
-		return nil
+	for l := fl; l != nil; l = l.referrer {
+		if l.root == candidateRoot {
+			return fmt.Errorf("cycle detected: %s",
+				fl.loadingChain(candidateRoot.String()))
+		}
+		if l.root.HasPrefix(candidateRoot) {
+			return fmt.Errorf(
+				"cycle detected: candidate root '%s' contains visited root '%s', in %s",
+				candidateRoot, l.root, fl.loadingChain(candidateRoot.String()))
+		}
 	}
-	return fl.referrer.errIfArgEqualOrHigher(candidateRoot)
+	return nil
 }
 
 // TODO(monopole): Distinguish branches?
@@ -284,17 +329,35 @@ func (fl *fileLoader) errIfArgEqualOrHigher(
 // path foo and tag bar and a git URI with the same
 // path but a different tag?
 func (fl *fileLoader) errIfRepoCycle(newRepoSpec *git.RepoSpec) error {
-	// TODO(monopole): Use parsed data instead of Raw().
-	if fl.repoSpec != nil &&
-		strings.HasPrefix(fl.repoSpec.Raw(), newRepoSpec.Raw()) {
-		return fmt.Errorf(
-			"cycle detected: URI '%s' referenced by previous URI '%s'",
-			newRepoSpec.Raw(), fl.repoSpec.Raw())
+	for l := fl; l != nil; l = l.referrer {
+		// TODO(monopole): Use parsed data instead of Raw().
+		if l.repoSpec != nil &&
+			strings.HasPrefix(l.repoSpec.Raw(), newRepoSpec.Raw()) {
+			return fmt.Errorf(
+				"cycle detected: URI '%s' referenced by previous URI '%s', in %s",
+				newRepoSpec.Raw(), l.repoSpec.Raw(),
+				fl.loadingChain(newRepoSpec.Raw()))
+		}
 	}
-	if fl.referrer == nil {
-		return nil
+	return nil
+}
+
+// loadingChain describes the roots of the loaders leading to
+// fl, then fl's, then next, e.g. "overlay -> base -> overlay",
+// giving the URIs of those loading from repos.
+func (fl *fileLoader) loadingChain(next string) string {
+	chain := []string{next}
+	for l := fl; l != nil; l = l.referrer {
+		if l.repoSpec != nil {
+			chain = append(chain, l.repoSpec.Raw())
+		} else {
+			chain = append(chain, l.root.String())
+		}
 	}
-	return fl.referrer.errIfRepoCycle(newRepoSpec)
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return strings.Join(chain, " -> ")
 }
 
 // Load returns the content of file at the given path,
@@ -302,6 +365,18 @@ func (fl *fileLoader) errIfRepoCycle(newRepoSpec *git.RepoSpec) error {
 // to the root.
 func (fl *fileLoader) Load(path string) ([]byte, error) {
 	if u, err := url.Parse(path); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		if err = fl.policy.CheckURL(fl.root.String(), path); err != nil {
+			return nil, err
+		}
+		ref, err := fl.rewriter.Rewrite(types.RemoteRef{
+			Kind:     types.RemoteRefURL,
+			Referrer: fl.root.String(),
+			URL:      path,
+		})
+		if err != nil {
+			return nil, err
+		}
+		path = ref.URL
 		var hc *http.Client
 		if fl.http != nil {
 			hc = fl.http
@@ -310,12 +385,12 @@ func (fl *fileLoader) Load(path string) ([]byte, error) {
 		}
 		resp, err := hc.Get(path)
 		if err != nil {
-			return nil, err
+			return nil, &kusterr.InfrastructureError{Err: err}
 		}
 		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return nil, err
+			return nil, &kusterr.InfrastructureError{Err: err}
 		}
 		return body, nil
 	}
@@ -329,6 +404,38 @@ func (fl *fileLoader) Load(path string) ([]byte, error) {
 	return fl.fSys.ReadFile(path)
 }
 
+// Glob returns the paths of the files matching the given
+// pattern, per filepath.Match semantics.  A relative pattern
+// is taken relative to the root, and the paths returned
+// are relative to the root too.  Matches that the
+// loadRestrictor disallows are an error, as they would
+// be if named explicitly.
+func (fl *fileLoader) Glob(pattern string) ([]string, error) {
+	relative := !filepath.IsAbs(pattern)
+	if relative {
+		pattern = fl.root.Join(pattern)
+	}
+	matches, err := fl.fSys.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	result := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if _, err := fl.loadRestrictor(fl.fSys, fl.root, m); err != nil {
+			return nil, err
+		}
+		if relative {
+			m, err = filepath.Rel(fl.root.String(), m)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
 // Cleanup runs the cleaner.
 func (fl *fileLoader) Cleanup() error {
 	return fl.cleaner()
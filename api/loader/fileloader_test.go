@@ -95,6 +95,48 @@ func TestLoaderLoad(t *testing.T) {
 	}
 }
 
+func TestLoaderGlob(t *testing.T) {
+	l1 := makeLoader()
+	l2, err := l1.New("foo/project")
+	if err != nil {
+		t.Fatalf("unexpected err: %v\n", err)
+	}
+	g, ok := l2.(ifc.Globber)
+	if !ok {
+		t.Fatalf("expected the loader to be a Globber")
+	}
+	paths, err := g.Glob("file*.yaml")
+	if err != nil {
+		t.Fatalf("unexpected glob error: %v", err)
+	}
+	expected := []string{"fileA.yaml", "fileD.yaml"}
+	if !reflect.DeepEqual(expected, paths) {
+		t.Fatalf("expected %v, but got %v", expected, paths)
+	}
+	paths, err = g.Glob("subdir*/*.yaml")
+	if err != nil {
+		t.Fatalf("unexpected glob error: %v", err)
+	}
+	expected = []string{"subdir1/fileB.yaml", "subdir2/fileC.yaml"}
+	if !reflect.DeepEqual(expected, paths) {
+		t.Fatalf("expected %v, but got %v", expected, paths)
+	}
+	paths, err = g.Glob("nothing*.yaml")
+	if err != nil {
+		t.Fatalf("unexpected glob error: %v", err)
+	}
+	if len(paths) != 0 {
+		t.Fatalf("expected no matches, but got %v", paths)
+	}
+	l3, err := l2.New("subdir1")
+	if err != nil {
+		t.Fatalf("unexpected err: %v\n", err)
+	}
+	if _, err = l3.(ifc.Globber).Glob("../*.yaml"); err == nil {
+		t.Fatalf("expected security error")
+	}
+}
+
 func TestLoaderNewSubDir(t *testing.T) {
 	l1, err := makeLoader().New("foo/project")
 	if err != nil {
@@ -187,6 +229,30 @@ func TestLoaderBadRelative(t *testing.T) {
 	}
 }
 
+func TestLocalCycleDetection(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.MkdirAll("/app/overlay")
+	fSys.MkdirAll("/app/base")
+	root, err := demandDirectoryRoot(fSys, "/app/overlay")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	l1 := newLoaderAtConfirmedDir(
+		RestrictionRootOnly, root, fSys, nil, git.DoNothingCloner(""))
+	l2, err := l1.New("../base")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	_, err = l2.New("../overlay")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	expected := "cycle detected: /app/overlay -> /app/base -> /app/overlay"
+	if err.Error() != expected {
+		t.Fatalf("expected error %q, got %q", expected, err.Error())
+	}
+}
+
 func TestLoaderMisc(t *testing.T) {
 	l := makeLoader()
 	_, err := l.New("")
@@ -387,7 +453,7 @@ whatever
 	}
 	l, err := newLoaderAtGitClone(
 		repoSpec, fSys, nil,
-		git.DoNothingCloner(filesys.ConfirmedDir(coRoot)))
+		git.DoNothingCloner(filesys.ConfirmedDir(coRoot)), nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected err: %v\n", err)
 	}
@@ -466,7 +532,7 @@ func TestLoaderDisallowsLocalBaseFromRemoteOverlay(t *testing.T) {
 	}
 	l1, err = newLoaderAtGitClone(
 		repoSpec, fSys, nil,
-		git.DoNothingCloner(filesys.ConfirmedDir(cloneRoot)))
+		git.DoNothingCloner(filesys.ConfirmedDir(cloneRoot)), nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected err: %v\n", err)
 	}
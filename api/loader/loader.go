@@ -5,9 +5,12 @@
 package loader
 
 import (
+	"fmt"
+
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/ifc"
 	"sigs.k8s.io/kustomize/api/internal/git"
+	"sigs.k8s.io/kustomize/api/types"
 )
 
 // NewLoader returns a Loader pointed at the given target.
@@ -19,16 +22,81 @@ import (
 func NewLoader(
 	lr LoadRestrictorFunc,
 	target string, fSys filesys.FileSystem) (ifc.Loader, error) {
+	return NewLoaderUsingCloner(lr, target, fSys, git.ClonerUsingGitExec)
+}
+
+// NewLoaderUsingCloner is NewLoader, cloning remote
+// targets and bases with cloner, e.g. that of a
+// git.CloneCache.
+func NewLoaderUsingCloner(
+	lr LoadRestrictorFunc, target string,
+	fSys filesys.FileSystem, cloner git.Cloner) (ifc.Loader, error) {
+	return NewLoaderUsingPolicy(lr, target, fSys, cloner, nil)
+}
+
+// NewLoaderUsingPolicy is NewLoaderUsingCloner, the loader, and
+// those it creates, refusing to fetch what the policy forbids.
+// The policy may be nil, forbidding nothing.
+func NewLoaderUsingPolicy(
+	lr LoadRestrictorFunc, target string, fSys filesys.FileSystem,
+	cloner git.Cloner, policy *types.Policy) (ifc.Loader, error) {
+	return NewLoaderUsingRewriter(lr, target, fSys, cloner, policy, nil)
+}
+
+// NewLoaderUsingRewriter is NewLoaderUsingPolicy, the loader, and
+// those it creates, fetching the remote bases and files that the
+// rewriter, which may be nil, has them fetch in place of those
+// the kustomizations refer to.
+func NewLoaderUsingRewriter(
+	lr LoadRestrictorFunc, target string, fSys filesys.FileSystem,
+	cloner git.Cloner, policy *types.Policy,
+	rewriter types.RemoteRefRewriter) (ifc.Loader, error) {
 	repoSpec, err := git.NewRepoSpecFromUrl(target)
 	if err == nil {
 		// The target qualifies as a remote git target.
+		err = policy.CheckGitRepo(target, repoSpec.Host, repoSpec.OrgRepo)
+		if err != nil {
+			return nil, err
+		}
+		repoSpec, err = rewriteRepoSpec(rewriter, target, target, repoSpec)
+		if err != nil {
+			return nil, err
+		}
 		return newLoaderAtGitClone(
-			repoSpec, fSys, nil, git.ClonerUsingGitExec)
+			repoSpec, fSys, nil, cloner, policy, rewriter)
 	}
 	root, err := demandDirectoryRoot(fSys, target)
 	if err != nil {
 		return nil, err
 	}
-	return newLoaderAtConfirmedDir(
-		lr, root, fSys, nil, git.ClonerUsingGitExec), nil
+	l := newLoaderAtConfirmedDir(lr, root, fSys, nil, cloner)
+	l.policy = policy
+	l.rewriter = rewriter
+	return l, nil
+}
+
+// rewriteRepoSpec returns the spec of the repository to
+// clone in place of the remote base at url, referred to
+// by the kustomization in referrer.
+func rewriteRepoSpec(
+	rewriter types.RemoteRefRewriter, referrer, url string,
+	repoSpec *git.RepoSpec) (*git.RepoSpec, error) {
+	if rewriter == nil {
+		return repoSpec, nil
+	}
+	ref, err := rewriter.Rewrite(types.RemoteRef{
+		Kind:     types.RemoteRefGit,
+		Referrer: referrer,
+		URL:      url,
+	})
+	if err != nil || ref.URL == url {
+		return repoSpec, err
+	}
+	rewritten, err := git.NewRepoSpecFromUrl(ref.URL)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"the remote base %s of the kustomization in %s was rewritten to %s, "+
+				"which isn't a remote base: %v", url, referrer, ref.URL, err)
+	}
+	return rewritten, nil
 }
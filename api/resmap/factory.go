@@ -4,6 +4,9 @@
 package resmap
 
 import (
+	"fmt"
+	"path/filepath"
+
 	"github.com/pkg/errors"
 	"sigs.k8s.io/kustomize/api/ifc"
 	"sigs.k8s.io/kustomize/api/internal/kusterr"
@@ -53,14 +56,44 @@ func (rmF *Factory) FromResourceSlice(ress []*resource.Resource) ResMap {
 // FromFile returns a ResMap given a resource path.
 func (rmF *Factory) FromFile(
 	loader ifc.Loader, path string) (ResMap, error) {
+	return rmF.fromFile(loader, path, false)
+}
+
+// FromFileAllowingRaw is like FromFile, but the documents that
+// aren't Kubernetes resources become raw resources (see
+// resource.IsRaw), named after the file and their index in it.
+func (rmF *Factory) FromFileAllowingRaw(
+	loader ifc.Loader, path string) (ResMap, error) {
+	return rmF.fromFile(loader, path, true)
+}
+
+func (rmF *Factory) fromFile(
+	loader ifc.Loader, path string, allowRaw bool) (ResMap, error) {
 	content, err := loader.Load(path)
 	if err != nil {
 		return nil, err
 	}
-	m, err := rmF.NewResMapFromBytes(content)
+	origin := path
+	if !filepath.IsAbs(origin) {
+		origin = filepath.Join(loader.Root(), path)
+	}
+	var m ResMap
+	if allowRaw {
+		var resources []*resource.Resource
+		resources, err = rmF.resF.SliceFromBytesAllowingRaw(
+			content, func(i int) string { return fmt.Sprintf("%s#%d", origin, i) })
+		if err == nil {
+			m, err = newResMapFromResourceSlice(resources)
+		}
+	} else {
+		m, err = rmF.NewResMapFromBytes(content)
+	}
 	if err != nil {
 		return nil, kusterr.Handler(err, path)
 	}
+	for _, r := range m.Resources() {
+		r.SetOrigin(origin)
+	}
 	return m, nil
 }
 
@@ -0,0 +1,586 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resmap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/kustomize/api/resource"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ResQuery is a compiled query selecting resources, e.g.
+//
+//	kind=Deployment && metadata.labels.app=web
+//
+// A query is made of comparisons of fields, combined with &&, ||
+// and !, and grouped with parentheses.  A field is one of kind,
+// name, namespace, group, version and apiVersion, those of the
+// current id of the resource, @source, the entry of the resources
+// of the kustomization built that the resource came from (see
+// resource.Source), or else the path of a field of the
+// resource, as in replacements, e.g. metadata.labels.app or
+// spec.template.spec.containers.[name=web].image, in which dots of
+// keys are escaped with a backslash.  It's compared to a value,
+// a word or a double-quoted string, with:
+//
+//	=   equals
+//	!=  doesn't equal, a missing field not equaling anything
+//	=~  matches the regex, which must match all of the value
+//	!~  doesn't match the regex
+//
+// A field by itself, e.g. metadata.labels.app, tests that the
+// resource has the field, a resource having a @source if it came
+// from an entry.  The @ keeps @source apart from the path of a
+// field named source, which source is.
+type ResQuery struct {
+	expr string
+	root queryNode
+}
+
+// Query compiles the query expr, which may then be evaluated
+// against any number of resources and ResMaps.
+func Query(expr string) (*ResQuery, error) {
+	p := queryParser{lexer: queryLexer{src: expr}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return nil, fmt.Errorf("query %q is empty", expr)
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return &ResQuery{expr: expr, root: root}, nil
+}
+
+// String returns the query as it was written.
+func (q *ResQuery) String() string {
+	return q.expr
+}
+
+// Matches returns whether the query matches r.
+func (q *ResQuery) Matches(r *resource.Resource) (bool, error) {
+	matched, err := q.root.eval(r)
+	if err != nil {
+		return false, fmt.Errorf("query %q: %v", q.expr, err)
+	}
+	return matched, nil
+}
+
+// Select returns the resources of m that the query
+// matches, in ResMap order.
+func (q *ResQuery) Select(m ResMap) ([]*resource.Resource, error) {
+	candidates := m.Resources()
+	if w, ok := m.(*resWrangler); ok {
+		candidates = w.queryCandidates(q)
+	}
+	var result []*resource.Resource
+	for _, r := range candidates {
+		matched, err := q.Matches(r)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// ExcludesSource returns whether the query matches none of the
+// resources whose source is entry, whatever their other fields
+// are, e.g. for a build selecting resources with it to leave
+// the entry out.  A query such as @source=../web excludes every
+// entry but ../web; one not comparing the source, none.
+func (q *ResQuery) ExcludesSource(entry string) bool {
+	matched, decided := q.root.decide(sourceField, entry)
+	return decided && !matched
+}
+
+// literal returns the value that the field must equal for
+// the query to match, if the query requires one.
+func (q *ResQuery) literal(field string) string {
+	return q.root.literal(field)
+}
+
+// queryCandidates returns the resources that q may match,
+// narrowed by the index if q requires a name or a kind.
+func (m *resWrangler) queryCandidates(q *ResQuery) []*resource.Resource {
+	if name := q.literal("name"); name != "" {
+		return m.GetMatchingResourcesByAnyName(name)
+	}
+	if kind := q.literal("kind"); kind != "" {
+		return m.resourcesAt(
+			m.indexed(func(x *resIndex) positions { return x.byKind[kind] }))
+	}
+	return m.rList
+}
+
+// compiledQueries caches the queries of selectors,
+// which are selected with repeatedly.
+var compiledQueries sync.Map
+
+// compiledQuery returns the query expr, compiled once.
+func compiledQuery(expr string) (*ResQuery, error) {
+	if q, ok := compiledQueries.Load(expr); ok {
+		return q.(*ResQuery), nil
+	}
+	q, err := Query(expr)
+	if err != nil {
+		return nil, err
+	}
+	compiledQueries.Store(expr, q)
+	return q, nil
+}
+
+type queryNode interface {
+	eval(r *resource.Resource) (bool, error)
+	literal(field string) string
+	// decide returns whether the query matches the resources whose
+	// field, one not of a path, is value, and whether that's decided
+	// by the field alone, rather than depending on other fields.
+	decide(field, value string) (matched, decided bool)
+}
+
+type andNode struct{ left, right queryNode }
+
+func (n andNode) eval(r *resource.Resource) (bool, error) {
+	matched, err := n.left.eval(r)
+	if err != nil || !matched {
+		return false, err
+	}
+	return n.right.eval(r)
+}
+
+func (n andNode) literal(field string) string {
+	if v := n.left.literal(field); v != "" {
+		return v
+	}
+	return n.right.literal(field)
+}
+
+func (n andNode) decide(field, value string) (bool, bool) {
+	left, leftDecided := n.left.decide(field, value)
+	if leftDecided && !left {
+		return false, true
+	}
+	right, rightDecided := n.right.decide(field, value)
+	if rightDecided && !right {
+		return false, true
+	}
+	return true, leftDecided && rightDecided
+}
+
+type orNode struct{ left, right queryNode }
+
+func (n orNode) eval(r *resource.Resource) (bool, error) {
+	matched, err := n.left.eval(r)
+	if err != nil || matched {
+		return matched, err
+	}
+	return n.right.eval(r)
+}
+
+func (n orNode) literal(string) string {
+	return ""
+}
+
+func (n orNode) decide(field, value string) (bool, bool) {
+	left, leftDecided := n.left.decide(field, value)
+	if leftDecided && left {
+		return true, true
+	}
+	right, rightDecided := n.right.decide(field, value)
+	if rightDecided && right {
+		return true, true
+	}
+	return false, leftDecided && rightDecided
+}
+
+type notNode struct{ operand queryNode }
+
+func (n notNode) eval(r *resource.Resource) (bool, error) {
+	matched, err := n.operand.eval(r)
+	return !matched, err
+}
+
+func (n notNode) literal(string) string {
+	return ""
+}
+
+func (n notNode) decide(field, value string) (bool, bool) {
+	matched, decided := n.operand.decide(field, value)
+	return !matched, decided
+}
+
+// comparisonNode compares a field to a value,
+// or tests that it's there if op is empty.
+type comparisonNode struct {
+	field string
+	path  []string
+	op    string
+	value string
+	regex *regexp.Regexp
+}
+
+func (n comparisonNode) eval(r *resource.Resource) (bool, error) {
+	value, found, err := n.fieldValue(r)
+	if err != nil {
+		return false, err
+	}
+	return n.compare(value, found), nil
+}
+
+// compare returns whether the comparison holds
+// for the value of a field, if found.
+func (n comparisonNode) compare(value string, found bool) bool {
+	switch n.op {
+	case "":
+		return found
+	case "=":
+		return found && value == n.value
+	case "!=":
+		return !found || value != n.value
+	case "=~":
+		return found && n.regex.MatchString(value)
+	default: // "!~"
+		return !found || !n.regex.MatchString(value)
+	}
+}
+
+func (n comparisonNode) decide(field, value string) (bool, bool) {
+	if n.path != nil || n.field != field {
+		return false, false
+	}
+	return n.compare(value, value != ""), true
+}
+
+func (n comparisonNode) literal(field string) string {
+	if n.op == "=" && n.path == nil && n.field == field {
+		return n.value
+	}
+	return ""
+}
+
+// fieldValue returns the value of the field of r, and
+// whether r has it, a field that isn't a scalar having none.
+func (n comparisonNode) fieldValue(r *resource.Resource) (string, bool, error) {
+	if n.field == sourceField {
+		return r.Source(), r.Source() != "", nil
+	}
+	if n.path == nil {
+		id := r.CurId()
+		switch n.field {
+		case "kind":
+			return id.Kind, true, nil
+		case "name":
+			return id.Name, true, nil
+		case "namespace":
+			return id.Namespace, true, nil
+		case "group":
+			return id.Group, true, nil
+		case "version":
+			return id.Version, true, nil
+		default: // "apiVersion"
+			return id.ApiVersion(), true, nil
+		}
+	}
+	node, err := r.LookupField(n.path)
+	if err != nil {
+		return "", false, fmt.Errorf("looking up %s: %v", n.field, err)
+	}
+	if node == nil {
+		return "", false, nil
+	}
+	if node.YNode().Kind != kyaml.ScalarNode {
+		return "", n.op == "", nil
+	}
+	return node.YNode().Value, true, nil
+}
+
+// idFields are the fields of the ids of resources.
+var idFields = map[string]bool{
+	"kind":       true,
+	"name":       true,
+	"namespace":  true,
+	"group":      true,
+	"version":    true,
+	"apiVersion": true,
+}
+
+// sourceField is the field of the entries
+// that resources come from.
+const sourceField = "@source"
+
+type queryParser struct {
+	lexer queryLexer
+	tok   queryToken
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("query %q at offset %d: %s",
+		p.lexer.src, p.tok.offset, fmt.Sprintf(format, args...))
+}
+
+// advance moves to the next token, failing with an error
+// of the position of the token it failed to read.
+func (p *queryParser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		p.tok.offset = p.lexer.pos
+		return p.errorf("%v", err)
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOr {
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokAnd {
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	switch p.tok.kind {
+	case tokNot:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	case tokLParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected ) rather than %s", p.tok)
+		}
+		return n, p.advance()
+	case tokWord:
+		return p.parseComparison()
+	}
+	return nil, p.errorf("expected a field rather than %s", p.tok)
+}
+
+func (p *queryParser) parseComparison() (queryNode, error) {
+	n := comparisonNode{field: p.tok.text}
+	if !idFields[n.field] && n.field != sourceField {
+		path, err := kyaml.SplitFieldPath(n.field)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		n.path = path
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokOp {
+		return n, nil
+	}
+	n.op = p.tok.text
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	switch p.tok.kind {
+	case tokWord:
+		n.value = unescape(p.tok.text)
+	case tokString:
+		n.value = p.tok.text
+	default:
+		return nil, p.errorf("expected a value rather than %s", p.tok)
+	}
+	if n.op == "=~" || n.op == "!~" {
+		regex, err := regexp.Compile("^(?:" + n.value + ")$")
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		n.regex = regex
+	}
+	return n, p.advance()
+}
+
+// unescape removes the backslashes escaping
+// the characters of a word.
+func unescape(word string) string {
+	var b strings.Builder
+	escaped := false
+	for _, c := range word {
+		if c == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokOp
+	tokWord
+	tokString
+)
+
+type queryToken struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+type queryLexer struct {
+	src string
+	pos int
+}
+
+// next returns the next token of the query.
+func (l *queryLexer) next() (queryToken, error) {
+	for l.pos < len(l.src) && strings.IndexByte(" \t\r\n", l.src[l.pos]) >= 0 {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos == len(l.src) {
+		return queryToken{kind: tokEOF, offset: start}, nil
+	}
+	token := func(kind tokenKind, n int) (queryToken, error) {
+		l.pos += n
+		return queryToken{kind: kind, text: l.src[start:l.pos], offset: start}, nil
+	}
+	rest := l.src[l.pos:]
+	switch {
+	case strings.HasPrefix(rest, "&&"):
+		return token(tokAnd, 2)
+	case strings.HasPrefix(rest, "||"):
+		return token(tokOr, 2)
+	case strings.HasPrefix(rest, "!="), strings.HasPrefix(rest, "!~"),
+		strings.HasPrefix(rest, "=~"):
+		return token(tokOp, 2)
+	case strings.HasPrefix(rest, "=="):
+		l.pos += 2
+		return queryToken{kind: tokOp, text: "=", offset: start}, nil
+	}
+	switch rest[0] {
+	case '=':
+		return token(tokOp, 1)
+	case '!':
+		return token(tokNot, 1)
+	case '(':
+		return token(tokLParen, 1)
+	case ')':
+		return token(tokRParen, 1)
+	case '&', '|', '~':
+		return queryToken{}, fmt.Errorf("unexpected '%c'", rest[0])
+	case '"':
+		return l.quoted()
+	}
+	return l.word()
+}
+
+// word reads a field or a value, in which a list entry
+// selector, e.g. [name=web], may hold any character but ].
+func (l *queryLexer) word() (queryToken, error) {
+	start := l.pos
+	inEntry := false
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src):
+			l.pos++
+		case inEntry:
+			inEntry = c != ']'
+		case c == '[':
+			inEntry = true
+		case strings.IndexByte(" \t\r\n()!=&|~\"", c) >= 0:
+			return queryToken{kind: tokWord, text: l.src[start:l.pos], offset: start}, nil
+		}
+		l.pos++
+	}
+	if inEntry {
+		return queryToken{}, fmt.Errorf("unterminated list entry selector")
+	}
+	return queryToken{kind: tokWord, text: l.src[start:], offset: start}, nil
+}
+
+// quoted reads a double-quoted string, in which a
+// backslash escapes a double quote or a backslash.
+func (l *queryLexer) quoted() (queryToken, error) {
+	start := l.pos
+	var b strings.Builder
+	for l.pos++; l.pos < len(l.src); l.pos++ {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src):
+			l.pos++
+			b.WriteByte(l.src[l.pos])
+		case c == '"':
+			l.pos++
+			return queryToken{kind: tokString, text: b.String(), offset: start}, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return queryToken{}, fmt.Errorf("unterminated string")
+}
@@ -0,0 +1,317 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resmap_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func setupRMForQueries(t *testing.T) ResMap {
+	t.Helper()
+	result, err := rmF.NewResMapFromBytes([]byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+  labels:
+    app: web
+    app.kubernetes.io/part-of: shop
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  labels:
+    app: worker
+spec:
+  template:
+    spec:
+      containers:
+      - name: worker
+        image: busybox
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: prod
+  labels:
+    app: web
+`))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return result
+}
+
+func TestQuery(t *testing.T) {
+	m := setupRMForQueries(t)
+	testCases := map[string]struct {
+		query    string
+		expected []string
+	}{
+		"kindAndLabel": {
+			query:    "kind=Deployment && metadata.labels.app=web",
+			expected: []string{"Deployment web"},
+		},
+		"idFields": {
+			query:    `group == apps && namespace != "" && version=v1`,
+			expected: []string{"Deployment web"},
+		},
+		"or": {
+			query:    "kind=Service || metadata.labels.app=worker",
+			expected: []string{"Deployment worker", "Service web"},
+		},
+		"notAndParentheses": {
+			query:    "!(kind=Service || name=worker)",
+			expected: []string{"Deployment web"},
+		},
+		"regex": {
+			query:    `spec.template.spec.containers.[name=web].image =~ "nginx:.*"`,
+			expected: []string{"Deployment web"},
+		},
+		"regexMatchesAllOfTheValue": {
+			query:    "kind =~ Deploy",
+			expected: nil,
+		},
+		"notRegex": {
+			query:    "apiVersion !~ apps/.*",
+			expected: []string{"Service web"},
+		},
+		"missingFieldNotEqualing": {
+			query:    "spec.replicas != 3",
+			expected: []string{"Deployment worker", "Service web"},
+		},
+		"exists": {
+			query:    "spec.replicas || metadata.labels.app\\.kubernetes\\.io/part-of",
+			expected: []string{"Deployment web"},
+		},
+		"mapFieldExists": {
+			query:    "kind=Deployment && !metadata.namespace && spec.template",
+			expected: []string{"Deployment worker"},
+		},
+		"quoted": {
+			query:    `metadata.labels.app="web" && name="web"`,
+			expected: []string{"Deployment web", "Service web"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			q, err := Query(tc.query)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			resources, err := q.Select(m)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			var actual []string
+			for _, r := range resources {
+				actual = append(actual, r.GetKind()+" "+r.GetName())
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	testCases := map[string]struct {
+		query       string
+		expectedErr string
+	}{
+		"empty": {
+			query:       "  ",
+			expectedErr: `query "  " is empty`,
+		},
+		"missingValue": {
+			query:       "kind=",
+			expectedErr: `query "kind=" at offset 5: expected a value rather than end of query`,
+		},
+		"singleAmpersand": {
+			query:       "kind=Service & name=web",
+			expectedErr: `query "kind=Service & name=web" at offset 13: unexpected '&'`,
+		},
+		"unbalancedParentheses": {
+			query:       "(kind=Service",
+			expectedErr: `query "(kind=Service" at offset 13: expected ) rather than end of query`,
+		},
+		"trailingToken": {
+			query:       "kind=Service name=web",
+			expectedErr: `query "kind=Service name=web" at offset 13: unexpected 'name'`,
+		},
+		"unterminatedString": {
+			query:       `name="web`,
+			expectedErr: `query "name=\"web" at offset 9: unterminated string`,
+		},
+		"badRegex": {
+			query:       `name=~"("`,
+			expectedErr: "error parsing regexp",
+		},
+		"badPath": {
+			query:       "metadata..name",
+			expectedErr: "has an empty part",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Query(tc.query)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestSelectWithQuery(t *testing.T) {
+	m := setupRMForQueries(t)
+	resources, err := m.Select(types.Selector{
+		KrmId: types.KrmId{Namespace: "prod"},
+		Query: "metadata.labels.app=web && kind!=Service",
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, resources, 1) {
+		assert.Equal(t, "web", resources[0].GetName())
+		assert.Equal(t, "Deployment", resources[0].GetKind())
+	}
+	_, err = m.Select(types.Selector{Query: "kind=="})
+	assert.Error(t, err)
+}
+
+func TestQuerySource(t *testing.T) {
+	m := setupRMForQueries(t)
+	m.Resources()[0].SetSource("../web")
+	m.Resources()[2].SetSource("../web")
+	for query, expected := range map[string][]string{
+		"@source=../web":                 {"Deployment web", "Service web"},
+		"@source":                        {"Deployment web", "Service web"},
+		"!@source":                       {"Deployment worker"},
+		`@source != "../web"`:            {"Deployment worker"},
+		"@source=~../.* && kind=Service": {"Service web"},
+	} {
+		t.Run(query, func(t *testing.T) {
+			q, err := Query(query)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			resources, err := q.Select(m)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			var actual []string
+			for _, r := range resources {
+				actual = append(actual, r.GetKind()+" "+r.GetName())
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestQueryFieldNamedSource(t *testing.T) {
+	m, err := rmF.NewResMapFromBytes([]byte(`
+apiVersion: example.com/v1
+kind: Mirror
+metadata:
+  name: charts
+source: https://charts.example.com
+`))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	m.Resources()[0].SetSource("../mirrors")
+	for query, expected := range map[string]bool{
+		`source="https://charts.example.com"`:  true,
+		"source=../mirrors":                    false,
+		"@source=../mirrors":                   true,
+		`@source="https://charts.example.com"`: false,
+	} {
+		t.Run(query, func(t *testing.T) {
+			q, err := Query(query)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			matched, err := q.Matches(m.Resources()[0])
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, expected, matched)
+		})
+	}
+}
+
+func TestExcludesSource(t *testing.T) {
+	testCases := map[string]struct {
+		query    string
+		excluded []string
+		kept     []string
+	}{
+		"equals": {
+			query:    "@source=../web",
+			excluded: []string{"../db", ""},
+			kept:     []string{"../web"},
+		},
+		"notEquals": {
+			query:    "@source != ../db",
+			excluded: []string{"../db"},
+			kept:     []string{"../web", ""},
+		},
+		"regex": {
+			query:    `@source =~ "https://github.com/example/.*"`,
+			excluded: []string{"../web"},
+			kept:     []string{"https://github.com/example/apps//web"},
+		},
+		"andOtherField": {
+			query:    "kind=Deployment && @source=../web",
+			excluded: []string{"../db"},
+			kept:     []string{"../web"},
+		},
+		"orOtherField": {
+			query: "kind=Namespace || @source=../web",
+			kept:  []string{"../db", "../web"},
+		},
+		"orSources": {
+			query:    "(@source=../web || @source=../db) && name=web",
+			excluded: []string{"../cache"},
+			kept:     []string{"../web", "../db"},
+		},
+		"not": {
+			query:    "!(@source=../web || kind=Service)",
+			excluded: []string{"../web"},
+			kept:     []string{"../db"},
+		},
+		"fieldNamedSource": {
+			query: "source=../web",
+			kept:  []string{"../web", "../db", ""},
+		},
+		"otherFieldsOnly": {
+			query: "kind=Deployment",
+			kept:  []string{"../web", ""},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			q, err := Query(tc.query)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			for _, entry := range tc.excluded {
+				assert.True(t, q.ExcludesSource(entry), entry)
+			}
+			for _, entry := range tc.kept {
+				assert.False(t, q.ExcludesSource(entry), entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resmap
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/resource"
+)
+
+// resIndex indexes the resources of a resWrangler by their
+// positions in its list, so that finding resources by id, name,
+// kind or namespace doesn't take a scan of the list.
+//
+// Resources' ids change as they're transformed, so before use
+// the index is updated, reindexing only the resources whose ids
+// have changed since they were indexed (see resource.IdChanges).
+type resIndex struct {
+	// idChanges is the value of resource.IdChanges
+	// when the index was last updated.
+	idChanges uint64
+
+	// entries holds what's indexed of the resource
+	// at each position of the list.
+	entries []indexEntry
+
+	// byId indexes resources by current id,
+	// with the namespace made effective.
+	byId map[string]positions
+
+	// byName indexes resources by current and previous names.
+	byName map[string]positions
+
+	// byKind indexes resources by current kind.
+	byKind map[string]positions
+
+	// byCurrentNamespace and byOriginalNamespace index resources
+	// by the effective namespaces of their current and original ids.
+	byCurrentNamespace  map[string]positions
+	byOriginalNamespace map[string]positions
+
+	// currentGroups and originalGroups cache the resources at
+	// the positions in byCurrentNamespace and byOriginalNamespace,
+	// until the index changes.
+	currentGroups  map[string][]*resource.Resource
+	originalGroups map[string][]*resource.Resource
+}
+
+type indexEntry struct {
+	r         *resource.Resource
+	idChanges uint64
+	id        string
+	names     []string
+	kind      string
+	curNs     string
+	orgNs     string
+}
+
+func newResIndex() *resIndex {
+	return &resIndex{
+		byId:                make(map[string]positions),
+		byName:              make(map[string]positions),
+		byKind:              make(map[string]positions),
+		byCurrentNamespace:  make(map[string]positions),
+		byOriginalNamespace: make(map[string]positions),
+	}
+}
+
+// update brings the index up to date with list, to which
+// resources may only have been appended since the last update,
+// other than by replacing them through reindex.
+func (x *resIndex) update(list []*resource.Resource) {
+	idChanges := resource.IdChanges()
+	if x.idChanges == idChanges && len(x.entries) == len(list) {
+		return
+	}
+	for i, e := range x.entries {
+		if e.r != list[i] || e.idChanges != list[i].IdChanges() {
+			x.reindex(i, list[i])
+		}
+	}
+	for i := len(x.entries); i < len(list); i++ {
+		x.add(i, list[i])
+	}
+	x.idChanges = idChanges
+}
+
+// reindex indexes r in place of the resource at position i.
+func (x *resIndex) reindex(i int, r *resource.Resource) {
+	x.remove(i)
+	x.add(i, r)
+}
+
+func (x *resIndex) add(i int, r *resource.Resource) {
+	curId := r.CurId()
+	e := indexEntry{
+		r:         r,
+		idChanges: r.IdChanges(),
+		id:        idKey(curId),
+		names:     []string{curId.Name},
+		kind:      curId.Kind,
+		curNs:     curId.EffectiveNamespace(),
+		orgNs:     r.OrgId().EffectiveNamespace(),
+	}
+	for _, id := range r.PrevIds() {
+		if !containsString(e.names, id.Name) {
+			e.names = append(e.names, id.Name)
+		}
+	}
+	if i == len(x.entries) {
+		x.entries = append(x.entries, e)
+	} else {
+		x.entries[i] = e
+	}
+	insert(x.byId, e.id, i)
+	for _, n := range e.names {
+		insert(x.byName, n, i)
+	}
+	insert(x.byKind, e.kind, i)
+	insert(x.byCurrentNamespace, e.curNs, i)
+	insert(x.byOriginalNamespace, e.orgNs, i)
+	x.currentGroups, x.originalGroups = nil, nil
+}
+
+func (x *resIndex) remove(i int) {
+	e := x.entries[i]
+	remove(x.byId, e.id, i)
+	for _, n := range e.names {
+		remove(x.byName, n, i)
+	}
+	remove(x.byKind, e.kind, i)
+	remove(x.byCurrentNamespace, e.curNs, i)
+	remove(x.byOriginalNamespace, e.orgNs, i)
+	x.currentGroups, x.originalGroups = nil, nil
+}
+
+// resources returns the resources at the positions.
+func (x *resIndex) resources(p positions) []*resource.Resource {
+	if len(p) == 0 {
+		return nil
+	}
+	result := make([]*resource.Resource, len(p))
+	for i, pos := range p {
+		result[i] = x.entries[pos].r
+	}
+	return result
+}
+
+// groupedByCurrentNamespace returns the resources
+// grouped by the namespaces of their current ids.
+func (x *resIndex) groupedByCurrentNamespace() map[string][]*resource.Resource {
+	if x.currentGroups == nil {
+		x.currentGroups = x.groups(x.byCurrentNamespace)
+	}
+	return copyGroups(x.currentGroups)
+}
+
+// groupedByOriginalNamespace returns the resources
+// grouped by the namespaces of their original ids.
+func (x *resIndex) groupedByOriginalNamespace() map[string][]*resource.Resource {
+	if x.originalGroups == nil {
+		x.originalGroups = x.groups(x.byOriginalNamespace)
+	}
+	return copyGroups(x.originalGroups)
+}
+
+func (x *resIndex) groups(index map[string]positions) map[string][]*resource.Resource {
+	result := make(map[string][]*resource.Resource, len(index))
+	for key, p := range index {
+		result[key] = x.resources(p)
+	}
+	return result
+}
+
+// copyGroups returns a copy of the groups of resources.  The slices
+// are shared, but capped, so appending to them doesn't change them.
+func copyGroups(
+	groups map[string][]*resource.Resource) map[string][]*resource.Resource {
+	result := make(map[string][]*resource.Resource, len(groups))
+	for key, list := range groups {
+		result[key] = list[:len(list):len(list)]
+	}
+	return result
+}
+
+// idKey returns the key of id in resIndex.byId; ids
+// are equal, per ResId.Equals, if their keys are.
+func idKey(id resid.ResId) string {
+	id.Namespace = id.EffectiveNamespace()
+	return id.String()
+}
+
+// positions are positions in a list, in ascending order.
+type positions []int
+
+func insert(index map[string]positions, key string, i int) {
+	p := index[key]
+	j := sort.SearchInts(p, i)
+	if j < len(p) && p[j] == i {
+		return
+	}
+	p = append(p, 0)
+	copy(p[j+1:], p[j:])
+	p[j] = i
+	index[key] = p
+}
+
+func remove(index map[string]positions, key string, i int) {
+	p := index[key]
+	j := sort.SearchInts(p, i)
+	if j == len(p) || p[j] != i {
+		return
+	}
+	if len(p) == 1 {
+		delete(index, key)
+		return
+	}
+	index[key] = append(p[:j], p[j+1:]...)
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
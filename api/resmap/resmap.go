@@ -20,6 +20,70 @@ type Transformer interface {
 	Transform(m ResMap) error
 }
 
+// A RawDocumentsTransformer is a Transformer which handles the
+// raw documents, that aren't Kubernetes resources, among the
+// resources it transforms, e.g. a function they're data for.
+// Other transformers don't see them (see TransformResources).
+type RawDocumentsTransformer interface {
+	Transformer
+	// TransformsRawDocuments marks the transformer as one.
+	TransformsRawDocuments()
+}
+
+// TransformResources applies the transformer to m.  Unless it's
+// a RawDocumentsTransformer, it's applied to the Kubernetes
+// resources only, the raw documents (see resource.IsRaw) staying
+// where they are among them.
+func TransformResources(m ResMap, t Transformer) error {
+	if _, ok := t.(RawDocumentsTransformer); ok {
+		return t.Transform(m)
+	}
+	var raw []int
+	krm := New()
+	for i, r := range m.Resources() {
+		if r.IsRaw() {
+			raw = append(raw, i)
+			continue
+		}
+		if err := krm.Append(r); err != nil {
+			return err
+		}
+	}
+	if len(raw) == 0 {
+		return t.Transform(m)
+	}
+	all := m.Resources()
+	if err := t.Transform(krm); err != nil {
+		return err
+	}
+	result := krm.Resources()
+	for _, i := range raw {
+		at := i
+		if at > len(result) {
+			at = len(result)
+		}
+		result = append(result[:at],
+			append([]*resource.Resource{all[i]}, result[at:]...)...)
+	}
+	m.Clear()
+	for _, r := range result {
+		if err := m.Append(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A ResultsValidator is a Transformer which, run as a validator
+// (see types.Kustomization.Validators), reports the results of
+// its checks, e.g. a KRM function returning results.
+type ResultsValidator interface {
+	Transformer
+	// Validate checks m, which it may modify, and returns the
+	// results; it errs only if it fails to check m at all.
+	Validate(m ResMap) ([]types.ValidationResult, error)
+}
+
 // A Generator creates an instance of ResMap.
 type Generator interface {
 	Generate() (ResMap, error)
@@ -88,6 +152,16 @@ type TransformerPlugin interface {
 // fails or finds too many, it might make sense to then try
 // the CurrId.  Depends on the situation.
 //
+// The resources are kept in the order they were appended,
+// which is the order of the build output unless it's sorted.
+// Replacing a resource keeps its place, and removing one keeps
+// the order of the rest.  Every method returning resources, or
+// their ids, returns them in this order, whatever order their
+// inputs were in; the resources in each slice of the maps
+// returned by the GroupedBy methods are in it too, though the
+// maps themselves must be iterated by sorted keys to be
+// deterministic.
+//
 // TODO: get rid of this interface (use bare resWrangler).
 // There aren't multiple implementations any more.
 type ResMap interface {
@@ -149,13 +223,19 @@ type ResMap interface {
 	GetIndexOfCurrentId(id resid.ResId) (int, error)
 
 	// GetMatchingResourcesByCurrentId returns the resources
-	// who's CurId is matched by the argument.
+	// who's CurId is matched by the argument, in ResMap order.
 	GetMatchingResourcesByCurrentId(matches IdMatcher) []*resource.Resource
 
 	// GetMatchingResourcesByAnyId returns the resources
-	// who's current or previous IDs is matched by the argument.
+	// who's current or previous IDs is matched by the argument,
+	// in ResMap order.
 	GetMatchingResourcesByAnyId(matches IdMatcher) []*resource.Resource
 
+	// GetMatchingResourcesByAnyName returns the resources
+	// who's current or previous names include the argument,
+	// in ResMap order.
+	GetMatchingResourcesByAnyName(name string) []*resource.Resource
+
 	// GetByCurrentId is shorthand for calling
 	// GetMatchingResourcesByCurrentId with a matcher requiring
 	// an exact match, returning an error on multiple or no matches.
@@ -241,7 +321,7 @@ type ResMap interface {
 	Debug(title string)
 
 	// Select returns a list of resources that
-	// are selected by a Selector
+	// are selected by a Selector, in ResMap order.
 	Select(types.Selector) ([]*resource.Resource, error)
 
 	// ToRNodeSlice returns a copy of the resources as RNodes.
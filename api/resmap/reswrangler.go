@@ -6,6 +6,8 @@ package resmap
 import (
 	"bytes"
 	"fmt"
+	"regexp"
+	"sync"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/kustomize/api/resid"
@@ -23,6 +25,14 @@ type resWrangler struct {
 	// specify in kustomizations to be maintained and
 	// available as an option for final YAML rendering.
 	rList []*resource.Resource
+
+	// Index of rList, built when first needed; see index.
+	idx *resIndex
+
+	// mu guards idx, which is updated as it's read, so that
+	// the ResMap may be read by many goroutines at once, as
+	// long as none of them changes it or its resources.
+	mu sync.Mutex
 }
 
 func newOne() *resWrangler {
@@ -34,6 +44,44 @@ func newOne() *resWrangler {
 // Clear implements ResMap.
 func (m *resWrangler) Clear() {
 	m.rList = nil
+	m.idx = nil
+}
+
+// indexed returns a copy of the positions that find looks up
+// in the index of the resources, brought up to date.  It's done
+// holding the lock, as even readers update the index, so the
+// positions mustn't be those the index holds on to.
+func (m *resWrangler) indexed(find func(x *resIndex) positions) positions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := find(m.updatedIndex())
+	if len(p) == 0 {
+		return nil
+	}
+	return append(positions(nil), p...)
+}
+
+// resourcesAt returns the resources at the positions.
+func (m *resWrangler) resourcesAt(p positions) []*resource.Resource {
+	if len(p) == 0 {
+		return nil
+	}
+	result := make([]*resource.Resource, len(p))
+	for i, pos := range p {
+		result[i] = m.rList[pos]
+	}
+	return result
+}
+
+// updatedIndex returns the index of the resources, up to date,
+// for callers holding the lock.  Other than Append, methods
+// changing rList must reset or update the index.
+func (m *resWrangler) updatedIndex() *resIndex {
+	if m.idx == nil {
+		m.idx = newResIndex()
+	}
+	m.idx.update(m.rList)
+	return m.idx
 }
 
 // DropEmpties quickly drops empty resources.
@@ -46,6 +94,7 @@ func (m *resWrangler) DropEmpties() {
 		}
 	}
 	m.rList = rList
+	m.idx = nil
 }
 
 // Size implements ResMap.
@@ -72,7 +121,7 @@ func (m *resWrangler) Resources() []*resource.Resource {
 // Append implements ResMap.
 func (m *resWrangler) Append(res *resource.Resource) error {
 	id := res.CurId()
-	if r := m.GetMatchingResourcesByCurrentId(id.Equals); len(r) > 0 {
+	if r := m.getByCurrentId(id); len(r) > 0 {
 		return fmt.Errorf(
 			"may not add resource with an already registered id: %s", id)
 	}
@@ -97,6 +146,7 @@ func (m *resWrangler) Remove(adios resid.ResId) error {
 		return fmt.Errorf("id %s not found in removal", adios)
 	}
 	m.rList = rList
+	m.idx = nil
 	return nil
 }
 
@@ -111,6 +161,9 @@ func (m *resWrangler) Replace(res *resource.Resource) (int, error) {
 		return -1, fmt.Errorf("cannot find resource with id %s to replace", id)
 	}
 	m.rList[i] = res
+	if m.idx != nil {
+		m.idx.reindex(i, res)
+	}
 	return i, nil
 }
 
@@ -149,18 +202,15 @@ func (m *resWrangler) GetByIndex(i int) *resource.Resource {
 
 // GetIndexOfCurrentId implements ResMap.
 func (m *resWrangler) GetIndexOfCurrentId(id resid.ResId) (int, error) {
-	count := 0
-	result := -1
-	for i, r := range m.rList {
-		if id.Equals(r.CurId()) {
-			count++
-			result = i
-		}
+	key := idKey(id)
+	p := m.indexed(func(x *resIndex) positions { return x.byId[key] })
+	if len(p) > 1 {
+		return -1, fmt.Errorf("id matched %d resources", len(p))
 	}
-	if count > 1 {
-		return -1, fmt.Errorf("id matched %d resources", count)
+	if len(p) == 0 {
+		return -1, nil
 	}
-	return result, nil
+	return p[0], nil
 }
 
 type IdFromResource func(r *resource.Resource) resid.ResId
@@ -173,6 +223,35 @@ func (m *resWrangler) GetMatchingResourcesByCurrentId(
 	return m.filteredById(matches, GetCurrentId)
 }
 
+// getByCurrentId returns the resources whose CurId equals id.
+func (m *resWrangler) getByCurrentId(id resid.ResId) []*resource.Resource {
+	key := idKey(id)
+	return m.resourcesAt(
+		m.indexed(func(x *resIndex) positions { return x.byId[key] }))
+}
+
+// getByAnyId returns the resources whose current
+// or previous ids include one equal to id.
+func (m *resWrangler) getByAnyId(id resid.ResId) []*resource.Resource {
+	var result []*resource.Resource
+	for _, r := range m.GetMatchingResourcesByAnyName(id.Name) {
+		for _, rid := range append(r.PrevIds(), r.CurId()) {
+			if id.Equals(rid) {
+				result = append(result, r)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// GetMatchingResourcesByAnyName implements ResMap.
+func (m *resWrangler) GetMatchingResourcesByAnyName(
+	name string) []*resource.Resource {
+	return m.resourcesAt(
+		m.indexed(func(x *resIndex) positions { return x.byName[name] }))
+}
+
 // GetMatchingResourcesByAnyId implements ResMap.
 func (m *resWrangler) GetMatchingResourcesByAnyId(
 	matches IdMatcher) []*resource.Resource {
@@ -202,13 +281,13 @@ func (m *resWrangler) filteredById(
 // GetByCurrentId implements ResMap.
 func (m *resWrangler) GetByCurrentId(
 	id resid.ResId) (*resource.Resource, error) {
-	return demandOneMatch(m.GetMatchingResourcesByCurrentId, id, "Current")
+	return demandOneMatch(m.getByCurrentId, id, "Current")
 }
 
 // GetById implements ResMap.
 func (m *resWrangler) GetById(
 	id resid.ResId) (*resource.Resource, error) {
-	r, err := demandOneMatch(m.GetMatchingResourcesByAnyId, id, "Id")
+	r, err := demandOneMatch(m.getByAnyId, id, "Id")
 	if err != nil {
 		return nil, fmt.Errorf(
 			"%s; failed to find unique target for patch %s",
@@ -217,11 +296,11 @@ func (m *resWrangler) GetById(
 	return r, nil
 }
 
-type resFinder func(IdMatcher) []*resource.Resource
+type resFinder func(resid.ResId) []*resource.Resource
 
 func demandOneMatch(
 	f resFinder, id resid.ResId, s string) (*resource.Resource, error) {
-	r := f(id.Equals)
+	r := f(id)
 	if len(r) == 1 {
 		return r[0], nil
 	}
@@ -233,45 +312,33 @@ func demandOneMatch(
 
 // GroupedByCurrentNamespace implements ResMap.GroupByCurrentNamespace
 func (m *resWrangler) GroupedByCurrentNamespace() map[string][]*resource.Resource {
-	items := m.groupedByCurrentNamespace()
+	items := m.groupedByNamespace(false)
 	delete(items, resid.TotallyNotANamespace)
 	return items
 }
 
 // NonNamespaceable implements ResMap.NonNamespaceable
 func (m *resWrangler) NonNamespaceable() []*resource.Resource {
-	return m.groupedByCurrentNamespace()[resid.TotallyNotANamespace]
-}
-
-func (m *resWrangler) groupedByCurrentNamespace() map[string][]*resource.Resource {
-	byNamespace := make(map[string][]*resource.Resource)
-	for _, res := range m.rList {
-		namespace := res.CurId().EffectiveNamespace()
-		if _, found := byNamespace[namespace]; !found {
-			byNamespace[namespace] = []*resource.Resource{}
-		}
-		byNamespace[namespace] = append(byNamespace[namespace], res)
-	}
-	return byNamespace
+	return m.groupedByNamespace(false)[resid.TotallyNotANamespace]
 }
 
 // GroupedByNamespace implements ResMap.GroupByOrginalNamespace
 func (m *resWrangler) GroupedByOriginalNamespace() map[string][]*resource.Resource {
-	items := m.groupedByOriginalNamespace()
+	items := m.groupedByNamespace(true)
 	delete(items, resid.TotallyNotANamespace)
 	return items
 }
 
-func (m *resWrangler) groupedByOriginalNamespace() map[string][]*resource.Resource {
-	byNamespace := make(map[string][]*resource.Resource)
-	for _, res := range m.rList {
-		namespace := res.OrgId().EffectiveNamespace()
-		if _, found := byNamespace[namespace]; !found {
-			byNamespace[namespace] = []*resource.Resource{}
-		}
-		byNamespace[namespace] = append(byNamespace[namespace], res)
+// groupedByNamespace returns the resources grouped by the
+// namespaces of their original or current ids, which the
+// index caches, so it's done holding the lock.
+func (m *resWrangler) groupedByNamespace(original bool) map[string][]*resource.Resource {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if original {
+		return m.updatedIndex().groupedByOriginalNamespace()
 	}
-	return byNamespace
+	return m.updatedIndex().groupedByCurrentNamespace()
 }
 
 // AsYaml implements ResMap.
@@ -313,7 +380,7 @@ func (m *resWrangler) ErrorIfNotEqualSets(other ResMap) error {
 	seen := make(map[int]bool)
 	for _, r1 := range m.rList {
 		id := r1.CurId()
-		others := m2.GetMatchingResourcesByCurrentId(id.Equals)
+		others := m2.getByCurrentId(id)
 		if len(others) == 0 {
 			return fmt.Errorf(
 				"id in self missing from other; id: %s", id)
@@ -483,7 +550,7 @@ func (m *resWrangler) AbsorbAll(other ResMap) error {
 
 func (m *resWrangler) appendReplaceOrMerge(res *resource.Resource) error {
 	id := res.CurId()
-	matches := m.GetMatchingResourcesByAnyId(id.Equals)
+	matches := m.getByAnyId(id)
 	switch len(matches) {
 	case 0:
 		switch res.Behavior() {
@@ -537,7 +604,13 @@ func (m *resWrangler) Select(s types.Selector) ([]*resource.Resource, error) {
 	if err != nil {
 		return nil, err
 	}
-	for _, r := range m.rList {
+	var q *ResQuery
+	if s.Query != "" {
+		if q, err = compiledQuery(s.Query); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range m.selectCandidates(s, q) {
 		curId := r.CurId()
 		orgId := r.OrgId()
 
@@ -577,11 +650,46 @@ func (m *resWrangler) Select(s types.Selector) ([]*resource.Resource, error) {
 		if !matched {
 			continue
 		}
+
+		// matches the query
+		if q != nil {
+			matched, err = q.Matches(r)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+		}
 		result = append(result, r)
 	}
 	return result, nil
 }
 
+// selectCandidates returns the resources that may be selected
+// by s, narrowed by the index if s gives a name or a kind that's
+// not a regex, or else if its query q, which may be nil, does.
+func (m *resWrangler) selectCandidates(
+	s types.Selector, q *ResQuery) []*resource.Resource {
+	switch {
+	case isLiteral(s.Name):
+		return m.GetMatchingResourcesByAnyName(s.Name)
+	case isLiteral(s.Kind):
+		return m.resourcesAt(
+			m.indexed(func(x *resIndex) positions { return x.byKind[s.Kind] }))
+	case q != nil:
+		return m.queryCandidates(q)
+	default:
+		return m.rList
+	}
+}
+
+// isLiteral reports whether the selector regex
+// matches only itself, and isn't empty.
+func isLiteral(pattern string) bool {
+	return pattern != "" && regexp.QuoteMeta(pattern) == pattern
+}
+
 // ToRNodeSlice returns a copy of the resources as RNodes.
 func (m *resWrangler) ToRNodeSlice() []*kyaml.RNode {
 	result := make([]*kyaml.RNode, len(m.rList))
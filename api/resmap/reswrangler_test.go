@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -1348,3 +1349,139 @@ spec:
         name: nginx
 `, imagename)
 }
+
+func names(resources []*resource.Resource) []string {
+	var result []string
+	for _, r := range resources {
+		result = append(result, r.GetName())
+	}
+	return result
+}
+
+func TestOrderIsKept(t *testing.T) {
+	isCm := func(id resid.ResId) bool { return id.Kind == "ConfigMap" }
+	for _, order := range [][]int{
+		{1, 2, 3, 4, 5},
+		{5, 4, 3, 2, 1},
+		{3, 1, 5, 2, 4},
+	} {
+		m := New()
+		var expected []string
+		for _, i := range order {
+			doAppend(t, m, makeCm(i))
+			expected = append(expected, fmt.Sprintf("cm%03d", i))
+		}
+		assert.Equal(t, expected, names(m.Resources()))
+		assert.Equal(t, expected, names(m.GetMatchingResourcesByCurrentId(isCm)))
+		assert.Equal(t, expected, names(m.GetMatchingResourcesByAnyId(isCm)))
+		assert.Equal(t, expected, names(m.GroupedByCurrentNamespace()[resid.DefaultNamespace]))
+		assert.Equal(t, expected, names(m.GroupedByOriginalNamespace()[resid.DefaultNamespace]))
+		selected, err := m.Select(types.Selector{
+			KrmId: types.KrmId{Gvk: resid.Gvk{Kind: "ConfigMap"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, names(selected))
+		assert.Equal(t, expected, names(m.DeepCopy().Resources()))
+
+		// Replacing keeps the place, removing the order of the rest.
+		_, err = m.Replace(makeCm(order[1]))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, names(m.Resources()))
+		doRemove(t, m, makeCm(order[2]).OrgId())
+		assert.Equal(t,
+			append(append([]string{}, expected[:2]...), expected[3:]...),
+			names(m.Resources()))
+	}
+}
+
+func TestIndexFollowsIdChanges(t *testing.T) {
+	m := New()
+	for i := 1; i <= 4; i++ {
+		doAppend(t, m, makeCm(i))
+	}
+	getName := func(id resid.ResId) string {
+		r, err := m.GetByCurrentId(id)
+		if err != nil {
+			return err.Error()
+		}
+		return r.GetName()
+	}
+	cmId := func(name string) resid.ResId {
+		return resid.NewResId(resid.Gvk{Version: "v1", Kind: "ConfigMap"}, name)
+	}
+	assert.Equal(t, "cm002", getName(cmId("cm002")))
+
+	// Renamed through the resource.
+	r := m.GetByIndex(1)
+	r.StorePreviousId()
+	r.SetName("renamed")
+	assert.Equal(t, "renamed", getName(cmId("renamed")))
+	assert.Contains(t, getName(cmId("cm002")), "no matches")
+	found, err := m.GetById(cmId("cm002"))
+	assert.NoError(t, err)
+	assert.Equal(t, r, found)
+	assert.Equal(t, []string{"renamed"},
+		names(m.GetMatchingResourcesByAnyName("cm002")))
+	assert.Error(t, m.Append(makeCm(1).DeepCopy()))
+
+	// Renamed through its node.
+	assert.NoError(t, m.GetByIndex(2).Node().SetName("cm001"))
+	assert.Contains(t, getName(cmId("cm001")), "multiple matches")
+	i, err := m.GetIndexOfCurrentId(cmId("cm001"))
+	assert.Error(t, err)
+	assert.Equal(t, -1, i)
+	selected, err := m.Select(types.Selector{KrmId: types.KrmId{Name: "cm001"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cm001", "cm001"}, names(selected))
+
+	// Moved to another namespace.
+	m.GetByIndex(3).SetNamespace("other")
+	assert.Equal(t, []string{"cm004"},
+		names(m.GroupedByCurrentNamespace()["other"]))
+	assert.Equal(t, []string{"cm001", "renamed", "cm001"},
+		names(m.GroupedByCurrentNamespace()[resid.DefaultNamespace]))
+
+	// Replaced and removed.
+	replacement := makeCm(4)
+	replacement.SetNamespace("other")
+	replacement.SetLabels(map[string]string{"replaced": "true"})
+	_, err = m.Replace(replacement)
+	assert.NoError(t, err)
+	found, err = m.GetByCurrentId(replacement.CurId())
+	assert.NoError(t, err)
+	assert.Equal(t, replacement, found)
+	doRemove(t, m, cmId("renamed"))
+	assert.Equal(t, []string{"cm001", "cm001", "cm004"}, names(m.Resources()))
+	i, err = m.GetIndexOfCurrentId(replacement.CurId())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, i)
+}
+
+func TestConcurrentReads(t *testing.T) {
+	m := New()
+	for i := 1; i <= 20; i++ {
+		doAppend(t, m, makeCm(i))
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 1; i <= 20; i++ {
+				name := fmt.Sprintf("cm%03d", i)
+				if found := m.GetMatchingResourcesByAnyName(name); len(found) != 1 {
+					t.Errorf("goroutine %d found %d resources named %s", g, len(found), name)
+				}
+				if _, err := m.GetByCurrentId(resid.NewResId(
+					resid.Gvk{Version: "v1", Kind: "ConfigMap"}, name)); err != nil {
+					t.Error(err)
+				}
+				selected, err := m.Select(types.Selector{KrmId: types.KrmId{
+					Gvk: resid.Gvk{Kind: "ConfigMap"}}})
+				if err != nil || len(selected) != 20 {
+					t.Errorf("goroutine %d selected %d resources: %v", g, len(selected), err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -187,3 +187,27 @@ func TestFindPatchTargets(t *testing.T) {
 			t, testcase.count, len(actual), "test=%s target=%v", n, testcase.target)
 	}
 }
+
+func TestSelectOrder(t *testing.T) {
+	rm := setupRMForPatchTargets(t)
+	expected := []resid.ResId{}
+	for _, r := range rm.Resources() {
+		expected = append(expected, r.CurId())
+	}
+	// Whatever the selector, matches are in ResMap order.
+	for _, s := range []types.Selector{
+		{},
+		{KrmId: types.KrmId{Gvk: resid.Gvk{Group: "group1"}}},
+		{KrmId: types.KrmId{Name: ".*name.*"}},
+	} {
+		selected, err := rm.Select(s)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		var actual []resid.ResId
+		for _, r := range selected {
+			actual = append(actual, r.CurId())
+		}
+		assert.Equal(t, expected, actual)
+	}
+}
@@ -22,6 +22,9 @@ import (
 // Factory makes instances of Resource.
 type Factory struct {
 	hasher ifc.KustHasher
+
+	// inputLimits bound the YAML read, kio.DefaultInputLimits if nil.
+	inputLimits *kio.InputLimits
 }
 
 // NewFactory makes an instance of Factory.
@@ -34,6 +37,12 @@ func (rf *Factory) Hasher() ifc.KustHasher {
 	return rf.hasher
 }
 
+// SetInputLimits sets the limits of the YAML the factory
+// reads, kio.DefaultInputLimits if nil.
+func (rf *Factory) SetInputLimits(l *kio.InputLimits) {
+	rf.inputLimits = l
+}
+
 // FromMap returns a new instance of Resource.
 func (rf *Factory) FromMap(m map[string]interface{}) *Resource {
 	return rf.FromMapAndOption(m, nil)
@@ -132,11 +141,58 @@ func (rf *Factory) resourcesFromRNodes(
 	return
 }
 
-func (rf *Factory) RNodesFromBytes(b []byte) (result []*yaml.RNode, err error) {
-	nodes, err := kio.FromBytes(b)
+func (rf *Factory) RNodesFromBytes(b []byte) ([]*yaml.RNode, error) {
+	nodes, err := kio.FromBytesWithLimits(b, rf.inputLimits)
 	if err != nil {
 		return nil, err
 	}
+	return rf.expandNodes(nodes)
+}
+
+// SliceFromBytesAllowingRaw is like SliceFromBytes, but the
+// documents lacking an apiVersion or kind, rather than being
+// an error, become raw Resources (see IsRaw), named by rawName
+// from their index among the documents.
+func (rf *Factory) SliceFromBytesAllowingRaw(
+	in []byte, rawName func(i int) string) ([]*Resource, error) {
+	nodes, err := kio.FromBytesWithLimits(in, rf.inputLimits)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Resource
+	for i, n := range nodes {
+		if n.IsNilOrEmpty() {
+			continue
+		}
+		if !isRaw(n) {
+			expanded, err := rf.expandNodes([]*yaml.RNode{n})
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, rf.resourcesFromRNodes(expanded)...)
+			continue
+		}
+		r := rf.makeOne(n, nil)
+		r.raw = rawName(i)
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// isRaw returns whether the node isn't a Kubernetes
+// resource, lacking an apiVersion or kind.
+func isRaw(n *yaml.RNode) bool {
+	for _, f := range []string{yaml.APIVersionField, yaml.KindField} {
+		if field := n.Field(f); field.IsNilOrEmpty() || yaml.GetValue(field.Value) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// expandNodes drops the nodes to ignore, and
+// replaces the lists with their items.
+func (rf *Factory) expandNodes(nodes []*yaml.RNode) (result []*yaml.RNode, err error) {
 	nodes, err = rf.dropBadNodes(nodes)
 	if err != nil {
 		return nil, err
@@ -188,7 +244,7 @@ func (rf *Factory) convertObjectSliceToNodeSlice(
 		if err != nil {
 			return
 		}
-		nodes, err = kio.FromBytes(bytes)
+		nodes, err = kio.FromBytesWithLimits(bytes, rf.inputLimits)
 		if err != nil {
 			return
 		}
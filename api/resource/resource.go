@@ -9,6 +9,7 @@ import (
 	"log"
 	"reflect"
 	"strings"
+	"sync/atomic"
 
 	"sigs.k8s.io/kustomize/api/filters/patchstrategicmerge"
 	"sigs.k8s.io/kustomize/api/ifc"
@@ -28,7 +29,21 @@ type Resource struct {
 	options     *types.GenArgs
 	refBy       []resid.ResId
 	refVarNames []string
-}
+	// origin is the file the resource was loaded from, if any.
+	origin string
+	// source is the entry of the resources of the kustomization
+	// built that the resource came from, if any.
+	source string
+	// idChanges counts the changes of the resource's ids.
+	idChanges uint64
+	// raw, if not empty, is the name of a document that isn't
+	// a Kubernetes resource (see IsRaw).
+	raw string
+}
+
+// RawGvk is the Gvk of the documents that aren't Kubernetes
+// resources, lacking an apiVersion or kind, when they're allowed.
+var RawGvk = resid.Gvk{Group: "kustomize.config.k8s.io", Kind: "RawDocument"}
 
 const (
 	buildAnnotationPreviousKinds      = konfig.ConfigAnnoDomain + "/previousKinds"
@@ -41,6 +56,10 @@ const (
 	// and kinds of their targets
 	buildAnnotationAllowNameChange = konfig.ConfigAnnoDomain + "/allowNameChange"
 	buildAnnotationAllowKindChange = konfig.ConfigAnnoDomain + "/allowKindChange"
+	// and how they merge their lists into their targets'
+	buildAnnotationMergeLists = konfig.ConfigAnnoDomain + "/mergeLists"
+	// and whether their comments are copied to their targets
+	buildAnnotationKeepComments = konfig.ConfigAnnoDomain + "/keepComments"
 )
 
 var buildAnnotations = []string{
@@ -51,18 +70,26 @@ var buildAnnotations = []string{
 	buildAnnotationPreviousNamespaces,
 	buildAnnotationAllowNameChange,
 	buildAnnotationAllowKindChange,
+	buildAnnotationMergeLists,
+	buildAnnotationKeepComments,
 }
 
 func (r *Resource) AsRNode() *kyaml.RNode {
 	return r.node.Copy()
 }
 
+// Node returns the node of the resource, not a copy.  Since
+// it may be changed through the node, calling Node counts as
+// a change of the resource's ids (see IdChanges).
 func (r *Resource) Node() *kyaml.RNode {
+	r.idsChanged()
 	return r.node
 }
 
 func (r *Resource) ResetPrimaryData(incoming *Resource) {
-	r.node = incoming.node.Copy()
+	r.changingIds(func() {
+		r.node = incoming.node.Copy()
+	})
 }
 
 func (r *Resource) GetAnnotations() map[string]string {
@@ -78,6 +105,13 @@ func (r *Resource) GetFieldValue(f string) (interface{}, error) {
 	return r.node.GetFieldValue(f)
 }
 
+// LookupField returns the node of r at the path, split as by
+// yaml.SplitFieldPath, or nil if it has none.  It's not a copy,
+// and mustn't be changed.
+func (r *Resource) LookupField(path []string) (*kyaml.RNode, error) {
+	return r.node.Pipe(kyaml.Lookup(path...))
+}
+
 func (r *Resource) GetDataMap() map[string]string {
 	return r.node.GetDataMap()
 }
@@ -87,6 +121,9 @@ func (r *Resource) GetBinaryDataMap() map[string]string {
 }
 
 func (r *Resource) GetGvk() resid.Gvk {
+	if r.IsRaw() {
+		return RawGvk
+	}
 	meta, err := r.node.GetMeta()
 	if err != nil {
 		return resid.GvkFromString("")
@@ -100,6 +137,9 @@ func (r *Resource) Hash(h ifc.KustHasher) (string, error) {
 }
 
 func (r *Resource) GetKind() string {
+	if r.IsRaw() {
+		return RawGvk.Kind
+	}
 	return r.node.GetKind()
 }
 
@@ -112,6 +152,9 @@ func (r *Resource) GetLabels() map[string]string {
 }
 
 func (r *Resource) GetName() string {
+	if r.IsRaw() {
+		return r.raw
+	}
 	return r.node.GetName()
 }
 
@@ -125,6 +168,15 @@ func (r *Resource) GetString(p string) (string, error) {
 	return r.node.GetString(p)
 }
 
+// IsRaw reports whether the resource is a document that isn't
+// a Kubernetes resource, e.g. data for functions.  Its id is
+// made of RawGvk and the name it was given, it's passed through
+// the build as is, and only transformers which say they handle
+// raw documents (see resmap.RawDocumentsTransformer) see it.
+func (r *Resource) IsRaw() bool {
+	return r.raw != ""
+}
+
 func (r *Resource) IsEmpty() bool {
 	return r.node.IsNilOrEmpty()
 }
@@ -146,12 +198,15 @@ func (r *Resource) MatchesAnnotationSelector(selector string) (bool, error) {
 }
 
 func (r *Resource) SetAnnotations(m map[string]string) {
-	if len(m) == 0 {
-		// Force field erasure.
-		r.node.SetAnnotations(nil)
-		return
-	}
-	r.node.SetAnnotations(m)
+	// The previous ids are kept in annotations.
+	r.changingIds(func() {
+		if len(m) == 0 {
+			// Force field erasure.
+			r.node.SetAnnotations(nil)
+			return
+		}
+		r.node.SetAnnotations(m)
+	})
 }
 
 func (r *Resource) SetDataMap(m map[string]string) {
@@ -163,10 +218,12 @@ func (r *Resource) SetBinaryDataMap(m map[string]string) {
 }
 
 func (r *Resource) SetGvk(gvk resid.Gvk) {
-	r.node.SetMapField(
-		kyaml.NewScalarRNode(gvk.Kind), kyaml.KindField)
-	r.node.SetMapField(
-		kyaml.NewScalarRNode(gvk.ApiVersion()), kyaml.APIVersionField)
+	r.changingIds(func() {
+		r.node.SetMapField(
+			kyaml.NewScalarRNode(gvk.Kind), kyaml.KindField)
+		r.node.SetMapField(
+			kyaml.NewScalarRNode(gvk.ApiVersion()), kyaml.APIVersionField)
+	})
 }
 
 func (r *Resource) SetLabels(m map[string]string) {
@@ -179,11 +236,15 @@ func (r *Resource) SetLabels(m map[string]string) {
 }
 
 func (r *Resource) SetName(n string) {
-	r.node.SetName(n)
+	r.changingIds(func() {
+		r.node.SetName(n)
+	})
 }
 
 func (r *Resource) SetNamespace(n string) {
-	r.node.SetNamespace(n)
+	r.changingIds(func() {
+		r.node.SetNamespace(n)
+	})
 }
 
 func (r *Resource) SetKind(k string) {
@@ -192,8 +253,11 @@ func (r *Resource) SetKind(k string) {
 	r.SetGvk(gvk)
 }
 
-func (r *Resource) UnmarshalJSON(s []byte) error {
-	return r.node.UnmarshalJSON(s)
+func (r *Resource) UnmarshalJSON(s []byte) (err error) {
+	r.changingIds(func() {
+		err = r.node.UnmarshalJSON(s)
+	})
+	return err
 }
 
 // ResCtx is an interface describing the contextual added
@@ -234,6 +298,9 @@ func (r *Resource) copyOtherFields(other *Resource) {
 	r.options = other.options
 	r.refBy = other.copyRefBy()
 	r.refVarNames = copyStringSlice(other.refVarNames)
+	r.origin = other.origin
+	r.source = other.source
+	r.raw = other.raw
 }
 
 func (r *Resource) MergeDataMapFrom(o *Resource) {
@@ -292,6 +359,29 @@ func (r *Resource) NodeEqual(o *Resource) bool {
 	return reflect.DeepEqual(r.node, o.node)
 }
 
+// ContentEquals returns true if the resources have the same
+// content, other than build annotations, regardless of how
+// it's formatted, e.g. the order of fields.  Whether they're
+// frozen counts, though RemoveBuildAnnotations removes the
+// frozen annotation.
+func (r *Resource) ContentEquals(o *Resource) (bool, error) {
+	if r.IsFrozen() != o.IsFrozen() {
+		return false, nil
+	}
+	rc, oc := r.DeepCopy(), o.DeepCopy()
+	rc.RemoveBuildAnnotations()
+	oc.RemoveBuildAnnotations()
+	m1, err := rc.Map()
+	if err != nil {
+		return false, err
+	}
+	m2, err := oc.Map()
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(m1, m2), nil
+}
+
 func (r *Resource) copyRefBy() []resid.ResId {
 	if r.refBy == nil {
 		return nil
@@ -377,6 +467,8 @@ func (r *Resource) PrefixesSuffixesEquals(o ResCtx) bool {
 // RemoveBuildAnnotations removes annotations created by the build process.
 // These are internal-only to kustomize, added to the data pipeline to
 // track name changes so name references can be fixed.
+// The frozen and replacement files annotations, also meant for
+// kustomize only, go too.
 func (r *Resource) RemoveBuildAnnotations() {
 	annotations := r.GetAnnotations()
 	if len(annotations) == 0 {
@@ -385,9 +477,17 @@ func (r *Resource) RemoveBuildAnnotations() {
 	for _, a := range buildAnnotations {
 		delete(annotations, a)
 	}
+	delete(annotations, konfig.FrozenAnnotation)
+	delete(annotations, konfig.ReplacementFilesAnnotation)
 	r.SetAnnotations(annotations)
 }
 
+// IsFrozen reports whether the resource is annotated
+// as one that transformers must not change.
+func (r *Resource) IsFrozen() bool {
+	return r.GetAnnotations()[konfig.FrozenAnnotation] == "true"
+}
+
 func (r *Resource) setPreviousId(ns string, n string, k string) *Resource {
 	r.appendCsvAnnotation(buildAnnotationPreviousNames, n)
 	r.appendCsvAnnotation(buildAnnotationPreviousNamespaces, ns)
@@ -423,6 +523,44 @@ func (r *Resource) KindChangeAllowed() bool {
 	return false
 }
 
+// SetMergeLists sets how the resource, a strategic merge
+// patch, merges its lists into those of its targets.
+func (r *Resource) SetMergeLists(m types.MergeLists) {
+	annotations := r.GetAnnotations()
+	annotations[buildAnnotationMergeLists] = string(m)
+	r.SetAnnotations(annotations)
+}
+
+// listMerging returns how the resource, a strategic merge
+// patch, merges its lists into those of its targets.
+func (r *Resource) listMerging() kyaml.MergeOptionsListMerging {
+	switch types.MergeLists(r.GetAnnotations()[buildAnnotationMergeLists]) {
+	case types.MergeListsAppend:
+		return kyaml.MergeOptionsListsAppended
+	case types.MergeListsByKey:
+		return kyaml.MergeOptionsListsMergedByKey
+	case types.MergeListsReplace:
+		return kyaml.MergeOptionsListsReplaced
+	default:
+		return kyaml.MergeOptionsListsBySchema
+	}
+}
+
+// SetKeepComments makes the resource, a strategic merge
+// patch, copy its comments to the fields of its targets
+// that have none.
+func (r *Resource) SetKeepComments() {
+	annotations := r.GetAnnotations()
+	annotations[buildAnnotationKeepComments] = "true"
+	r.SetAnnotations(annotations)
+}
+
+// keepsComments returns whether the resource, a strategic
+// merge patch, copies its comments to its targets.
+func (r *Resource) keepsComments() bool {
+	return r.GetAnnotations()[buildAnnotationKeepComments] == "true"
+}
+
 // String returns resource as JSON.
 func (r *Resource) String() string {
 	bs, err := r.MarshalJSON()
@@ -451,6 +589,31 @@ func (r *Resource) MustYaml() string {
 	return string(yml)
 }
 
+// SetOrigin records the file the resource was loaded from,
+// so that errors about its fields can give their positions.
+func (r *Resource) SetOrigin(path string) {
+	r.origin = path
+}
+
+// Origin returns the file the resource was loaded from,
+// or the empty string if it wasn't loaded from a file.
+func (r *Resource) Origin() string {
+	return r.origin
+}
+
+// SetSource records the entry of the resources of the kustomization
+// built that the resource came from, e.g. ../base, a file or a URL.
+func (r *Resource) SetSource(entry string) {
+	r.source = entry
+}
+
+// Source returns the entry of the resources of the kustomization
+// built that the resource came from, or the empty string if it came
+// from none, e.g. being generated by the kustomization or a component.
+func (r *Resource) Source() string {
+	return r.source
+}
+
 // SetOptions updates the generator options for the resource.
 func (r *Resource) SetOptions(o *types.GenArgs) {
 	r.options = o
@@ -469,6 +632,9 @@ func (r *Resource) NeedHashSuffix() bool {
 
 // GetNamespace returns the namespace the resource thinks it's in.
 func (r *Resource) GetNamespace() string {
+	if r.IsRaw() {
+		return ""
+	}
 	namespace, _ := r.GetString("metadata.namespace")
 	// if err, namespace is empty, so no need to check.
 	return namespace
@@ -554,7 +720,9 @@ func (r *Resource) ApplySmPatch(patch *Resource) error {
 		r.StorePreviousId()
 	}
 	if err := r.ApplyFilter(patchstrategicmerge.Filter{
-		Patch: patch.node,
+		Patch:        patch.node,
+		ListMerging:  patch.listMerging(),
+		KeepComments: patch.keepsComments(),
 	}); err != nil {
 		return err
 	}
@@ -572,14 +740,73 @@ func (r *Resource) ApplySmPatch(patch *Resource) error {
 }
 
 func (r *Resource) ApplyFilter(f kio.Filter) error {
-	l, err := f.Filter([]*kyaml.RNode{r.node})
-	if len(l) == 0 {
-		// The node was deleted.  The following makes r.IsEmpty() true.
-		r.node = nil
+	var err error
+	r.changingIds(func() {
+		var l []*kyaml.RNode
+		l, err = f.Filter([]*kyaml.RNode{r.node})
+		if len(l) == 0 {
+			// The node was deleted.  The following makes r.IsEmpty() true.
+			r.node = nil
+		}
+	})
+	if pe, found := kyaml.AsPositionError(err); found && pe.File == "" && r.origin != "" {
+		return &kyaml.PositionError{
+			File: r.origin, Line: pe.Line, Column: pe.Column, Err: err}
 	}
 	return err
 }
 
+// idChanges counts the changes of the ids of all Resources.
+var idChanges uint64
+
+// IdChanges returns the count of the changes of the ids, current
+// or previous, of all Resources.  While it's unchanged, an index
+// of Resources by their ids is up to date.
+//
+// The count may also grow on changes which might have changed
+// ids, e.g. on calls of Node, but it never misses a change made
+// through a Resource.
+func IdChanges() uint64 {
+	return atomic.LoadUint64(&idChanges)
+}
+
+// IdChanges returns the count of the changes of the ids of r,
+// as counted by the function IdChanges, so that an index of
+// Resources needn't reindex the Resources whose ids haven't
+// changed.
+func (r *Resource) IdChanges() uint64 {
+	return r.idChanges
+}
+
+func (r *Resource) idsChanged() {
+	r.idChanges++
+	atomic.AddUint64(&idChanges, 1)
+}
+
+// changingIds runs f, which may change r, counting
+// a change of ids if r's ids differ afterwards.
+func (r *Resource) changingIds(f func()) {
+	before := r.ids()
+	f()
+	if r.node == nil || r.ids() != before {
+		r.idsChanged()
+	}
+}
+
+// ids returns a summary of the current and previous ids of r.
+func (r *Resource) ids() string {
+	if r.node == nil {
+		return ""
+	}
+	annotations := r.GetAnnotations()
+	return strings.Join([]string{
+		r.CurId().String(),
+		annotations[buildAnnotationPreviousNames],
+		annotations[buildAnnotationPreviousNamespaces],
+		annotations[buildAnnotationPreviousKinds],
+	}, "|")
+}
+
 func mergeStringMaps(maps ...map[string]string) map[string]string {
 	result := map[string]string{}
 	for _, m := range maps {
@@ -13,6 +13,8 @@ import (
 	"sigs.k8s.io/kustomize/api/resid"
 	. "sigs.k8s.io/kustomize/api/resource"
 	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 var factory = provider.NewDefaultDepProvider().GetResourceFactory()
@@ -1130,3 +1132,44 @@ spec:
 		t.Fatalf("expected '%s', got '%s'", expected, actual)
 	}
 }
+
+func TestIdChanges(t *testing.T) {
+	r := testConfigMap.DeepCopy()
+	changes := func(change func()) (uint64, uint64) {
+		all, own := IdChanges(), r.IdChanges()
+		change()
+		return IdChanges() - all, r.IdChanges() - own
+	}
+	setName := func(n string) kio.FilterFunc {
+		return func(nodes []*kyaml.RNode) ([]*kyaml.RNode, error) {
+			return nodes, nodes[0].SetName(n)
+		}
+	}
+	for name, test := range map[string]struct {
+		change  func()
+		changed bool
+	}{
+		"SetName":            {func() { r.SetName("tigger") }, true},
+		"SetName, same name": {func() { r.SetName(r.GetName()) }, false},
+		"SetNamespace":       {func() { r.SetNamespace("woods") }, true},
+		"SetKind":            {func() { r.SetKind("Secret") }, true},
+		"SetLabels":          {func() { r.SetLabels(map[string]string{"a": "b"}) }, false},
+		"StorePreviousId":    {func() { r.StorePreviousId() }, true},
+		"ApplyFilter, renaming": {func() {
+			assert.NoError(t, r.ApplyFilter(setName("eeyore")))
+		}, true},
+		"ApplyFilter, not renaming": {func() {
+			assert.NoError(t, r.ApplyFilter(setName(r.GetName())))
+		}, false},
+		// The node may be changed through it.
+		"Node": {func() { r.Node() }, true},
+	} {
+		all, own := changes(test.change)
+		if test.changed {
+			assert.NotZero(t, own, name)
+			assert.True(t, all >= own, name)
+		} else {
+			assert.Zero(t, own, name)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package signature checks the signatures, and attestations,
+// that a policy requires of the function images and helm
+// charts builds use, by running cosign.
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// command is the cosign executable, looked up in the PATH.
+const command = "cosign"
+
+// Verify errors if the policy requires signatures, and the
+// image, or chart, that the kustomization in the referrer
+// directory refers to, as what, e.g. function image, isn't
+// signed as the policy requires.  The policy may be nil.
+//
+// It returns ref pinned to the digest of the manifest whose
+// signatures it verified, e.g. example.com/fn@sha256:...,
+// for the caller to run, or pull, that manifest rather than
+// whatever the tag of ref refers to by then; or ref itself,
+// if the policy doesn't require signatures.
+func Verify(policy *types.Policy, referrer, what, ref string) (string, error) {
+	if policy == nil || policy.Signatures == nil {
+		return ref, nil
+	}
+	s := policy.Signatures
+	out, err := run(verifyArgs("verify", s, ref))
+	if err != nil {
+		return "", unverified(referrer, what, ref, err)
+	}
+	digest, err := verifiedDigest(out)
+	if err != nil {
+		return "", unverified(referrer, what, ref, err)
+	}
+	pinned := repository(ref) + "@" + digest
+	if strings.Contains(ref, "@") && pinned != ref {
+		return "", unverified(referrer, what, ref,
+			fmt.Errorf("the signatures verified are of the digest %s", digest))
+	}
+	if s.AttestationType != "" {
+		args := verifyArgs(
+			"verify-attestation", s, pinned, "--type", s.AttestationType)
+		if _, err = run(args); err != nil {
+			return "", unverified(referrer, what, ref,
+				fmt.Errorf("attestation of type %s: %v", s.AttestationType, err))
+		}
+	}
+	return pinned, nil
+}
+
+// payload is the part of the payload of a signature, as
+// cosign verify prints them, naming what was signed.
+type payload struct {
+	Critical struct {
+		Image struct {
+			Digest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifiedDigest returns the digest of the manifest that
+// cosign verify, with output out, verified the signatures
+// of, as their payloads hold it.
+func verifiedDigest(out []byte) (string, error) {
+	var payloads []payload
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var list []payload
+		err := dec.Decode(&list)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading the signatures verified: %v", err)
+		}
+		payloads = append(payloads, list...)
+	}
+	digest := ""
+	for _, p := range payloads {
+		d := p.Critical.Image.Digest
+		if d == "" || (digest != "" && d != digest) {
+			return "", fmt.Errorf(
+				"the signatures verified don't name a single digest")
+		}
+		digest = d
+	}
+	if digest == "" {
+		return "", fmt.Errorf("no signatures were verified")
+	}
+	return digest, nil
+}
+
+// repository returns ref without its tag or digest.
+func repository(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// verifyArgs returns the arguments of the cosign subcommand
+// checking ref as the signature policy requires, with
+// the extra flags of the subcommand.
+func verifyArgs(
+	subcommand string, s *types.SignaturePolicy, ref string, extra ...string) []string {
+	args := append([]string{subcommand}, extra...)
+	if s.Key != "" {
+		args = append(args, "--key", s.Key)
+	} else {
+		args = append(args,
+			"--certificate-identity", s.Identity,
+			"--certificate-oidc-issuer", s.Issuer)
+	}
+	return append(args, ref)
+}
+
+// run runs cosign with the arguments, returning its output.
+func run(args []string) ([]byte, error) {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil, fmt.Errorf("%s isn't installed: %v", command, err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+func unverified(referrer, what, ref string, err error) error {
+	return fmt.Errorf(
+		"the kustomization in %s refers to %s %s, which must be signed, "+
+			"but its signature couldn't be verified: %v",
+		referrer, what, ref, err)
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/api/signature"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// fakeCosign puts a cosign in the PATH that logs its arguments
+// to the returned file, failing for images tagged unsigned, and
+// otherwise printing a signature of the digest sha256:1234.
+func fakeCosign(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cosign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "log")
+	err = ioutil.WriteFile(filepath.Join(dir, "cosign"), []byte(`#!/bin/sh
+echo "$@" >>`+log+`
+case "$*" in
+*:unsigned) echo "Error: no matching signatures" >&2; exit 1;;
+verify\ *) echo '[{"critical":{"image":{"docker-manifest-digest":"sha256:1234"}}}]';;
+esac
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return log, func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	testCases := map[string]struct {
+		signatures  *types.SignaturePolicy
+		ref         string
+		expected    string
+		expectedLog string
+		expectedErr string
+	}{
+		"noSignatures": {
+			ref:      "example.com/fn:unsigned",
+			expected: "example.com/fn:unsigned",
+		},
+		"key": {
+			signatures:  &types.SignaturePolicy{Key: "/keys/cosign.pub"},
+			ref:         "example.com/fn:v1",
+			expected:    "example.com/fn@sha256:1234",
+			expectedLog: "verify --key /keys/cosign.pub example.com/fn:v1\n",
+		},
+		"registryWithPort": {
+			signatures:  &types.SignaturePolicy{Key: "/keys/cosign.pub"},
+			ref:         "localhost:5000/fn",
+			expected:    "localhost:5000/fn@sha256:1234",
+			expectedLog: "verify --key /keys/cosign.pub localhost:5000/fn\n",
+		},
+		"digest": {
+			signatures:  &types.SignaturePolicy{Key: "/keys/cosign.pub"},
+			ref:         "example.com/fn@sha256:1234",
+			expected:    "example.com/fn@sha256:1234",
+			expectedLog: "verify --key /keys/cosign.pub example.com/fn@sha256:1234\n",
+		},
+		"otherDigest": {
+			signatures:  &types.SignaturePolicy{Key: "/keys/cosign.pub"},
+			ref:         "example.com/fn@sha256:5678",
+			expectedLog: "verify --key /keys/cosign.pub example.com/fn@sha256:5678\n",
+			expectedErr: "the kustomization in /app refers to function image " +
+				"example.com/fn@sha256:5678, which must be signed, but its " +
+				"signature couldn't be verified: " +
+				"the signatures verified are of the digest sha256:1234",
+		},
+		"keylessWithAttestation": {
+			signatures: &types.SignaturePolicy{
+				Identity:        "release@example.com",
+				Issuer:          "https://accounts.google.com",
+				AttestationType: "slsaprovenance",
+			},
+			ref:      "example.com/fn:v1",
+			expected: "example.com/fn@sha256:1234",
+			expectedLog: "verify --certificate-identity release@example.com " +
+				"--certificate-oidc-issuer https://accounts.google.com " +
+				"example.com/fn:v1\n" +
+				"verify-attestation --type slsaprovenance " +
+				"--certificate-identity release@example.com " +
+				"--certificate-oidc-issuer https://accounts.google.com " +
+				"example.com/fn@sha256:1234\n",
+		},
+		"unsigned": {
+			signatures:  &types.SignaturePolicy{Key: "/keys/cosign.pub"},
+			ref:         "example.com/fn:unsigned",
+			expectedLog: "verify --key /keys/cosign.pub example.com/fn:unsigned\n",
+			expectedErr: "the kustomization in /app refers to function image " +
+				"example.com/fn:unsigned, which must be signed, but its " +
+				"signature couldn't be verified: exit status 1: " +
+				"Error: no matching signatures",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			log, restore := fakeCosign(t)
+			defer restore()
+			policy := &types.Policy{Signatures: tc.signatures}
+			ref, err := Verify(policy, "/app", "function image", tc.ref)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, ref)
+			} else if assert.Error(t, err) {
+				assert.Equal(t, tc.expectedErr, err.Error())
+			}
+			b, _ := ioutil.ReadFile(log)
+			assert.Equal(t, tc.expectedLog, string(b))
+		})
+	}
+}
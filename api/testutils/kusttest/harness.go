@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"sigs.k8s.io/kustomize/api/filesys"
+	pLdr "sigs.k8s.io/kustomize/api/internal/plugins/loader"
 	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/kustomize/api/konfig/builtinpluginconsts"
 	"sigs.k8s.io/kustomize/api/krusty"
@@ -85,12 +86,22 @@ func (th Harness) MakeOptionsPluginsDisabled() krusty.Options {
 
 // Enables use of non-builtin plugins.
 func (th Harness) MakeOptionsPluginsEnabled() krusty.Options {
-	pc := types.EnabledPluginConfig(types.BploLoadFromFileSys)
+	pc := types.EnabledPluginConfig(builtinPluginLoadingOptions())
 	o := *krusty.MakeDefaultOptions()
 	o.PluginConfig = pc
 	return o
 }
 
+// builtinPluginLoadingOptions loads the builtin plugins from the
+// object code compiled by PrepBuiltin if this build can load Go
+// plugins, and uses the statically linked ones otherwise.
+func builtinPluginLoadingOptions() types.BuiltinPluginLoadingOptions {
+	if pLdr.GoPluginsSupported {
+		return types.BploLoadFromFileSys
+	}
+	return types.BploUseStaticallyLinked
+}
+
 // Run, failing on error.
 func (th Harness) Run(path string, o krusty.Options) resmap.ResMap {
 	m, err := krusty.MakeKustomizer(&o).Run(th.fSys, path)
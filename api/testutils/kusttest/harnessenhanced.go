@@ -77,7 +77,7 @@ func makeBaseEnhancedHarness(t *testing.T) *HarnessEnhanced {
 		pte: newPluginTestEnv(t).set(),
 		rf:  rf,
 		pl: pLdr.NewLoader(
-			types.EnabledPluginConfig(types.BploLoadFromFileSys),
+			types.EnabledPluginConfig(builtinPluginLoadingOptions()),
 			rf,
 			// Plugin configs are always located on disk,
 			// regardless of the test harness's FS
@@ -108,7 +108,13 @@ func (th *HarnessEnhanced) GetPluginConfig() *types.PluginConfig {
 	return th.pl.Config()
 }
 
+// PrepBuiltin compiles the builtin plugin k as a Go plugin, to
+// test its source, if this build can load Go plugins.  Otherwise
+// the statically linked builtin is used.
 func (th *HarnessEnhanced) PrepBuiltin(k string) *HarnessEnhanced {
+	if !pLdr.GoPluginsSupported {
+		return th
+	}
 	return th.BuildGoPlugin(konfig.BuiltinPluginPackage, "", k)
 }
 
@@ -156,6 +162,21 @@ func (th *HarnessEnhanced) LoadAndRunGenerator(
 	return rm
 }
 
+func (th *HarnessEnhanced) ErrorFromLoadAndRunGenerator(
+	config string) error {
+	res, err := th.rf.RF().FromBytes([]byte(config))
+	if err != nil {
+		return err
+	}
+	g, err := th.pl.LoadGenerator(
+		th.ldr, valtest_test.MakeFakeValidator(), res)
+	if err != nil {
+		return err
+	}
+	_, err = g.Generate()
+	return err
+}
+
 func (th *HarnessEnhanced) LoadAndRunTransformer(
 	config, input string) resmap.ResMap {
 	resMap, err := th.RunTransformer(config, input)
@@ -0,0 +1,25 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// DependsOnAnnotation is the annotation listing the resources
+// an applier is to wait for, before applying the resource
+// holding it, as cli-utils based appliers understand it.
+const DependsOnAnnotation = "config.kubernetes.io/depends-on"
+
+// ApplyHints select the dependencies between the resources of
+// the build to record in their DependsOnAnnotation, so that
+// appliers sequence the resources, e.g. a custom resource being
+// applied once its CustomResourceDefinition is established.
+type ApplyHints struct {
+	// CustomResources makes custom resources depend on the
+	// CustomResourceDefinitions of the build defining them.
+	CustomResources bool `json:"customResources,omitempty" yaml:"customResources,omitempty"`
+
+	// Webhooks makes webhook configurations depend on the
+	// workloads serving them, i.e. those the pods of which are
+	// selected by the services of the build they call, or on
+	// these services if they select none.
+	Webhooks bool `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+}
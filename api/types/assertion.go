@@ -0,0 +1,28 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// Assertion states a property that resources must have
+// once the build is done.
+type Assertion struct {
+	// Name identifies the assertion in error messages.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Select chooses the resources to check.
+	// If omitted, every resource is checked.
+	Select *Selector `json:"select,omitempty" yaml:"select,omitempty"`
+
+	// FieldPath is a dot separated path to the field that
+	// must exist, e.g. 'spec.replicas'.  A path element '*'
+	// matches every item of a list, and one like '[name=app]'
+	// the items of a list having the given field value.
+	FieldPath string `json:"fieldPath" yaml:"fieldPath"`
+
+	// Equals, if set, is the value the field must have.
+	Equals string `json:"equals,omitempty" yaml:"equals,omitempty"`
+
+	// Matches, if set, is a regular expression
+	// that the value of the field must match.
+	Matches string `json:"matches,omitempty" yaml:"matches,omitempty"`
+}
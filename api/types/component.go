@@ -0,0 +1,80 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// ComponentValuesKind is the kind of the resource holding the
+	// values a component is instantiated with, which its
+	// replacements and transformers see, e.g. to take a setting
+	// from with a replacement whose source is of the kind.  It
+	// isn't part of the output.
+	ComponentValuesKind = "ComponentValues"
+
+	// ComponentValuesName is the name of that resource.
+	ComponentValuesName = "values"
+)
+
+// Component is an entry of the components of a kustomization:
+// the path of a component, and the values to instantiate it with,
+// so that overlays may share a component, instantiating it with
+// different settings.
+//
+// It's written as the bare path, if it has no values:
+//
+//	components:
+//	- ../components/monitoring
+//	- path: ../components/replicas
+//	  valuesInline:
+//	    replicas: 3
+type Component struct {
+	// Path is the relative path, absolute path, or URL of the component.
+	Path string `json:"path" yaml:"path"`
+
+	// ValuesFile is the path of a yaml file holding values,
+	// relative to the kustomization.
+	ValuesFile string `json:"valuesFile,omitempty" yaml:"valuesFile,omitempty"`
+
+	// ValuesInline holds values, overriding those of the same
+	// keys in the ValuesFile.
+	ValuesInline map[string]interface{} `json:"valuesInline,omitempty" yaml:"valuesInline,omitempty"`
+}
+
+// HasValues returns whether the component is to be
+// instantiated with values.
+func (c Component) HasValues() bool {
+	return c.ValuesFile != "" || len(c.ValuesInline) > 0
+}
+
+// MarshalJSON writes the component as its path, if it has no values.
+func (c Component) MarshalJSON() ([]byte, error) {
+	if !c.HasValues() {
+		return json.Marshal(c.Path)
+	}
+	type plain Component
+	return json.Marshal(plain(c))
+}
+
+// UnmarshalJSON reads the component from its path, or an object.
+func (c *Component) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*c = Component{Path: path}
+		return nil
+	}
+	type plain Component
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if p.Path == "" {
+		return fmt.Errorf("a component given as an object must have a path")
+	}
+	*c = Component(p)
+	return nil
+}
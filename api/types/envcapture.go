@@ -0,0 +1,69 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// envVarName matches the names envSources may declare.
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvCapture records the environment variables that builds
+// read through the envSources of kustomizations, with their
+// values, e.g. for a provenance manifest to audit builds by,
+// or to reproduce them.
+//
+// Only the values that builds write to their output anyway,
+// e.g. to ConfigMaps, are recorded in clear.  Those of the
+// variables read for Secrets, or exported to functions, are
+// recorded as their sha256 digests, e.g. "sha256:2c26b4...",
+// to check them by without revealing them.
+//
+// It may be recorded to by builds made at once, and the
+// Record methods may be called on a nil capture, which then
+// does nothing.
+type EnvCapture struct {
+	mu sync.Mutex
+	// Values holds the value, or digest, of each variable read.
+	Values map[string]string `json:"values,omitempty"`
+	// redacted holds the variables whose digests Values holds.
+	redacted map[string]bool
+}
+
+// Record records that a build read the variable's value,
+// to write to its output.
+func (c *EnvCapture) Record(name, value string) {
+	c.record(name, value, false)
+}
+
+// RecordRedacted records that a build read the variable's
+// value, to keep out of its output, e.g. for a Secret.  The
+// digest of the value is recorded, even if the value was, or
+// will be, read to write to the output too.
+func (c *EnvCapture) RecordRedacted(name, value string) {
+	c.record(name, value, true)
+}
+
+func (c *EnvCapture) record(name, value string, redact bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Values == nil {
+		c.Values = make(map[string]string)
+		c.redacted = make(map[string]bool)
+	}
+	switch {
+	case redact:
+		c.Values[name] = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(value)))
+		c.redacted[name] = true
+	case !c.redacted[name]:
+		c.Values[name] = value
+	}
+}
@@ -26,28 +26,73 @@ import (
 //   path: spec/template/metadata/labels
 //   create: true
 // }
+//
+// A field spec may also select resources by their labels or
+// annotations, e.g. to tell apart the custom resources of two
+// operators that share a kind but not a schema.
 type FieldSpec struct {
 	resid.Gvk          `json:",inline,omitempty" yaml:",inline,omitempty"`
+	// Path is the path to the field, either slash delimited,
+	// e.g. spec/template/metadata/labels, or dot delimited, as
+	// in replacements, e.g. spec.containers.[name=app].image.
 	Path               string `json:"path,omitempty" yaml:"path,omitempty"`
 	CreateIfNotPresent bool   `json:"create,omitempty" yaml:"create,omitempty"`
+
+	// LabelSelector, if set, restricts the field spec to the
+	// resources whose labels match it. It follows the label
+	// selection expression
+	// https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#api
+	LabelSelector string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+
+	// AnnotationSelector, if set, restricts the field spec to
+	// the resources whose annotations match it, as LabelSelector
+	// does for labels.
+	AnnotationSelector string `json:"annotationSelector,omitempty" yaml:"annotationSelector,omitempty"`
 }
 
 func (fs FieldSpec) String() string {
-	return fmt.Sprintf(
+	s := fmt.Sprintf(
 		"%s:%v:%s", fs.Gvk.String(), fs.CreateIfNotPresent, fs.Path)
+	if fs.LabelSelector != "" {
+		s += ":labels(" + fs.LabelSelector + ")"
+	}
+	if fs.AnnotationSelector != "" {
+		s += ":annotations(" + fs.AnnotationSelector + ")"
+	}
+	return s
 }
 
 // If true, the primary key is the same, but other fields might not be.
+// The selectors are part of the primary key, so that a field spec
+// restricted to some resources doesn't merge into one that isn't.
 func (fs FieldSpec) effectivelyEquals(other FieldSpec) bool {
-	return fs.IsSelected(&other.Gvk) && fs.Path == other.Path
+	return fs.IsSelected(&other.Gvk) && fs.Path == other.Path &&
+		fs.LabelSelector == other.LabelSelector &&
+		fs.AnnotationSelector == other.AnnotationSelector
 }
 
 type FsSlice []FieldSpec
 
 func (s FsSlice) Len() int      { return len(s) }
 func (s FsSlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// Less orders by Gvk, then Path, then the selectors, then
+// CreateIfNotPresent, so that sorting gives the same order
+// however s was ordered.
 func (s FsSlice) Less(i, j int) bool {
-	return s[i].Gvk.IsLessThan(s[j].Gvk)
+	if !s[i].Gvk.Equals(s[j].Gvk) {
+		return s[i].Gvk.IsLessThan(s[j].Gvk)
+	}
+	if s[i].Path != s[j].Path {
+		return s[i].Path < s[j].Path
+	}
+	if s[i].LabelSelector != s[j].LabelSelector {
+		return s[i].LabelSelector < s[j].LabelSelector
+	}
+	if s[i].AnnotationSelector != s[j].AnnotationSelector {
+		return s[i].AnnotationSelector < s[j].AnnotationSelector
+	}
+	return !s[i].CreateIfNotPresent && s[j].CreateIfNotPresent
 }
 
 // MergeAll merges the argument into this, returning the result.
@@ -74,7 +119,7 @@ func (s FsSlice) MergeOne(x FieldSpec) (FsSlice, error) {
 	if i > -1 {
 		// It's already there.
 		if s[i].CreateIfNotPresent != x.CreateIfNotPresent {
-			return nil, fmt.Errorf("conflicting fieldspecs")
+			return nil, fmt.Errorf("conflicting fieldspecs %s and %s", s[i], x)
 		}
 		return s, nil
 	}
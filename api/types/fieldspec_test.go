@@ -6,6 +6,7 @@ package types_test
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -142,3 +143,27 @@ func TestFsSlice_MergeAll(t *testing.T) {
 		}
 	}
 }
+
+func TestFsSlice_Sort(t *testing.T) {
+	expected := FsSlice{
+		{Path: "spec/template/metadata/labels", Gvk: resid.Gvk{Kind: "Deployment"}},
+		{Path: "spec/selector/matchLabels", Gvk: resid.Gvk{Kind: "Job"}},
+		{Path: "metadata/labels", CreateIfNotPresent: true},
+		{Path: "spec/selector"},
+		{Path: "spec/selector", CreateIfNotPresent: true},
+	}
+	for _, order := range [][]int{
+		{0, 1, 2, 3, 4},
+		{4, 3, 2, 1, 0},
+		{2, 4, 1, 0, 3},
+	} {
+		var s FsSlice
+		for _, i := range order {
+			s = append(s, expected[i])
+		}
+		sort.Sort(s)
+		if !reflect.DeepEqual(expected, s) {
+			t.Fatalf("order %v: expected: %v\n but got: %v\n", order, expected, s)
+		}
+	}
+}
@@ -18,6 +18,13 @@ type GeneratorOptions struct {
 
 	// Immutable if true add to all generated resources.
 	Immutable bool `json:"immutable,omitempty" yaml:"immutable,omitempty"`
+
+	// ApplyReplacements if true lets the replacements of the build
+	// write into the content of the file sources of ConfigMap
+	// generators: a replacement target setting options.token, but
+	// no fieldPaths, replaces the token in that content.  Secret
+	// generators ignore it.
+	ApplyReplacements bool `json:"applyReplacements,omitempty" yaml:"applyReplacements,omitempty"`
 }
 
 // MergeGlobalOptionsIntoLocal merges two instances of GeneratorOptions.
@@ -48,6 +55,9 @@ func MergeGlobalOptionsIntoLocal(
 	if globalOpts.Immutable {
 		localOpts.Immutable = true
 	}
+	if globalOpts.ApplyReplacements {
+		localOpts.ApplyReplacements = true
+	}
 	return localOpts
 }
 
@@ -64,6 +64,30 @@ type HelmChart struct {
 	// Legal values: 'merge', 'override', 'replace'.
 	// Defaults to 'override'.
 	ValuesMerge string `json:"valuesMerge,omitempty" yaml:"valuesMerge,omitempty"`
+
+	// IncludeCRDs specifies if Helm should also generate CustomResourceDefinitions.
+	// Defaults to 'false'.
+	IncludeCRDs bool `json:"includeCRDs,omitempty" yaml:"includeCRDs,omitempty"`
+
+	// SkipTests skips the chart's tests, i.e. the templates
+	// annotated as helm test hooks.
+	SkipTests bool `json:"skipTests,omitempty" yaml:"skipTests,omitempty"`
+
+	// SkipSchemaValidation skips validating the values
+	// against the chart's values.schema.json.
+	SkipSchemaValidation bool `json:"skipSchemaValidation,omitempty" yaml:"skipSchemaValidation,omitempty"`
+
+	// KubeVersion is the kubernetes version the chart is
+	// rendered for, as seen by .Capabilities.KubeVersion,
+	// e.g. '1.21.0'.
+	KubeVersion string `json:"kubeVersion,omitempty" yaml:"kubeVersion,omitempty"`
+
+	// ApiVersions lists the api versions the chart should
+	// see as available in .Capabilities.APIVersions, e.g.
+	// 'monitoring.coreos.com/v1'.  Helm only knows of the
+	// builtin kubernetes versions when not talking to a
+	// cluster, so charts that gate on others need them here.
+	ApiVersions []string `json:"apiVersions,omitempty" yaml:"apiVersions,omitempty"`
 }
 
 // HelmChartArgs contains arguments to helm.
@@ -15,7 +15,24 @@ type Image struct {
 	// NewTag is the value used to replace the original tag.
 	NewTag string `json:"newTag,omitempty" yaml:"newTag,omitempty"`
 
+	// NewTagFrom, if set, is where in the build to take the new
+	// tag from, e.g. a ConfigMap listing the versions of images,
+	// instead of NewTag.
+	NewTagFrom *ValueSource `json:"newTagFrom,omitempty" yaml:"newTagFrom,omitempty"`
+
 	// Digest is the value used to replace the original image tag.
 	// If digest is present NewTag value is ignored.
 	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
 }
+
+// ValueSource is a field of a resource in the build
+// to take a value from.
+type ValueSource struct {
+	// The resource to take the value from; its name may
+	// be the one it has before, or after, transformations.
+	KrmId `json:",inline,omitempty" yaml:",inline,omitempty"`
+
+	// FieldPath is the path to the field holding the value,
+	// as in replacements, e.g. data.frontend.
+	FieldPath string `json:"fieldPath,omitempty" yaml:"fieldPath,omitempty"`
+}
@@ -7,6 +7,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	"sigs.k8s.io/yaml"
 )
@@ -29,6 +32,12 @@ type Kustomization struct {
 	// OpenAPI contains information about what kubernetes schema to use.
 	OpenAPI map[string]string `json:"openapi,omitempty" yaml:"openapi,omitempty"`
 
+	// KubeVersion is the version of Kubernetes the build targets, e.g. 1.29.
+	// Unless openapi specifies otherwise, it selects the builtin schema to use,
+	// and it is passed on to plugins, e.g. to helm.  Only the kustomization
+	// at the root of the build can set it.
+	KubeVersion string `json:"kubeVersion,omitempty" yaml:"kubeVersion,omitempty"`
+
 	//
 	// Operators - what kustomize can do.
 	//
@@ -44,15 +53,26 @@ type Kustomization struct {
 	// Namespace to add to all objects.
 	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
 
+	// NamespaceOptions control how the namespace above is applied.
+	NamespaceOptions *NamespaceOptions `json:"namespaceOptions,omitempty" yaml:"namespaceOptions,omitempty"`
+
 	// CommonLabels to add to all objects and selectors.
 	CommonLabels map[string]string `json:"commonLabels,omitempty" yaml:"commonLabels,omitempty"`
 
+	// CommonLabelsOptions control whether the commonLabels above
+	// override the values resources already have for their keys.
+	CommonLabelsOptions *MetadataOptions `json:"commonLabelsOptions,omitempty" yaml:"commonLabelsOptions,omitempty"`
+
 	// Labels to add to all objects but not selectors.
 	Labels []Label `json:"labels,omitempty" yaml:"labels,omitempty"`
 
 	// CommonAnnotations to add to all objects.
 	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:"commonAnnotations,omitempty"`
 
+	// CommonAnnotationsOptions control whether the commonAnnotations
+	// above override the values resources already have for their keys.
+	CommonAnnotationsOptions *MetadataOptions `json:"commonAnnotationsOptions,omitempty" yaml:"commonAnnotationsOptions,omitempty"`
+
 	// PatchesStrategicMerge specifies the relative path to a file
 	// containing a strategic merge patch.  Format documented at
 	// https://github.com/kubernetes/community/blob/master/contributors/devel/strategic-merge-patch.md
@@ -74,6 +94,11 @@ type Kustomization struct {
 	// patch, but this operator is simpler to specify.
 	Images []Image `json:"images,omitempty" yaml:"images,omitempty"`
 
+	// RegistryMirrors maps container registries, e.g. docker.io, to
+	// mirrors, e.g. mirror.example.com/dockerhub, replacing them in
+	// the references of all images, after any changes by Images.
+	RegistryMirrors map[string]string `json:"registryMirrors,omitempty" yaml:"registryMirrors,omitempty"`
+
 	// Replacements is a list of replacements, which will copy nodes from a
 	// specified source to N specified targets.
 	Replacements []ReplacementField `json:"replacements,omitempty" yaml:"replacements,omitempty"`
@@ -82,6 +107,10 @@ type Kustomization struct {
 	// specification. This can also be done with a patch.
 	Replicas []Replica `json:"replicas,omitempty" yaml:"replicas,omitempty"`
 
+	// StatefulSetOptions control how the transformers
+	// above treat StatefulSets.
+	StatefulSetOptions *StatefulSetOptions `json:"statefulSetOptions,omitempty" yaml:"statefulSetOptions,omitempty"`
+
 	// Vars allow things modified by kustomize to be injected into a
 	// kubernetes object specification. A var is a name (e.g. FOO) associated
 	// with a field in a specific resource instance.  The field must
@@ -100,9 +129,21 @@ type Kustomization struct {
 	// via relative paths, absolute paths, or URLs.
 	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
 
+	// ResourceFilters holds the filters of the directories of the
+	// Resources whose files are filtered by globs.  They're read
+	// from, and written to, the entries of the resources.
+	ResourceFilters []ResourceFilter `json:"-" yaml:"-"`
+
 	// Components specifies relative paths to specifications of other Components
-	// via relative paths, absolute paths, or URLs.
-	Components []string `json:"components,omitempty" yaml:"components,omitempty"`
+	// via relative paths, absolute paths, or URLs, and the values, if any,
+	// to instantiate them with.
+	Components []Component `json:"components,omitempty" yaml:"components,omitempty"`
+
+	// DeduplicateIdenticalResources, if true, makes a resource that
+	// comes from more than one of the resources and components
+	// above, e.g. from a base shared by two bases, added once, if
+	// its content is the same each time, rather than an error.
+	DeduplicateIdenticalResources bool `json:"deduplicateIdenticalResources,omitempty" yaml:"deduplicateIdenticalResources,omitempty"`
 
 	// Crds specifies relative paths to Custom Resource Definition files.
 	// This allows custom resources to be recognized as operands, making
@@ -133,6 +174,11 @@ type Kustomization struct {
 	// the map will have a suffix hash generated from its contents.
 	SecretGenerator []SecretArgs `json:"secretGenerator,omitempty" yaml:"secretGenerator,omitempty"`
 
+	// TLSSecretGenerator is a list of kubernetes.io/tls secrets,
+	// holding certificates and their keys, to generate for dev
+	// and test overlays. It requires the generator be enabled.
+	TLSSecretGenerator []TLSSecretArgs `json:"tlsSecretGenerator,omitempty" yaml:"tlsSecretGenerator,omitempty"`
+
 	// HelmGlobals contains helm configuration that isn't chart specific.
 	HelmGlobals *HelmGlobals `json:"helmGlobals,omitempty" yaml:"helmGlobals,omitempty"`
 
@@ -146,7 +192,9 @@ type Kustomization struct {
 	// GeneratorOptions modify behavior of all ConfigMap and Secret generators.
 	GeneratorOptions *GeneratorOptions `json:"generatorOptions,omitempty" yaml:"generatorOptions,omitempty"`
 
-	// Configurations is a list of transformer configuration files
+	// Configurations is a list of transformer configuration files, or
+	// of directories or remote refs of them, merged in order, each
+	// overriding the conflicting field specs of those before it.
 	Configurations []string `json:"configurations,omitempty" yaml:"configurations,omitempty"`
 
 	// Generators is a list of files containing custom generators
@@ -158,9 +206,42 @@ type Kustomization struct {
 	// Validators is a list of files containing validators
 	Validators []string `json:"validators,omitempty" yaml:"validators,omitempty"`
 
+	// ValidatorsOptions control whether the results the validators
+	// above report fail the build, are warned about or ignored.
+	ValidatorsOptions *ValidatorsOptions `json:"validatorsOptions,omitempty" yaml:"validatorsOptions,omitempty"`
+
+	// Assertions is a list of properties that the resources must
+	// have once built, after all the transformations of the build,
+	// failing the build if they don't.  Those of a base are only
+	// checked against the resources of the base.
+	Assertions []Assertion `json:"assertions,omitempty" yaml:"assertions,omitempty"`
+
+	// ApplyHints select the dependencies between the resources
+	// built to annotate them with, for appliers to sequence them.
+	// Only those of the kustomization built are used.
+	ApplyHints *ApplyHints `json:"applyHints,omitempty" yaml:"applyHints,omitempty"`
+
+	// EnvSources names the environment variables the build of
+	// the kustomization may read: those the envVars of its
+	// generators hold, and, if it's set, those exported to the
+	// containers of its functions, i.e. listed in their envs
+	// without a value.  Reading another is an error.
+	EnvSources []string `json:"envSources,omitempty" yaml:"envSources,omitempty"`
+
+	// Requirements declare what the build of the kustomization
+	// needs enabled, e.g. helm or plugins.
+	Requirements *Requirements `json:"requirements,omitempty" yaml:"requirements,omitempty"`
+
 	// Inventory appends an object that contains the record
 	// of all other objects, which can be used in apply, prune and delete
 	Inventory *Inventory `json:"inventory,omitempty" yaml:"inventory,omitempty"`
+
+	// Extensions holds the top-level fields of the file unknown
+	// to kustomize whose names are namespaced, i.e. hold a '.'
+	// or '/', e.g. those of third-party tools, as read by
+	// UnmarshalWithExtensions.  Builds ignore them, and they're
+	// written back as they are.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // FixKustomizationPostUnmarshalling fixes things
@@ -205,6 +286,82 @@ func (k *Kustomization) FixKustomizationPostUnmarshalling() {
 	k.HelmChartInflationGenerator = nil
 }
 
+// DeprecatedFields returns, for each deprecated field
+// in use, a message naming the field and how to migrate
+// away from it.  It must be called before
+// FixKustomizationPostUnmarshalling, which moves the
+// content of some deprecated fields to newer fields.
+func (k *Kustomization) DeprecatedFields() []string {
+	var msgs []string
+	for _, d := range k.deprecations() {
+		msgs = append(msgs, d.msg)
+	}
+	return msgs
+}
+
+// DeprecatedFieldNames is like DeprecatedFields, but returns
+// just the name of each deprecated field in use, once, e.g.
+// bases or configMapGenerator.env.
+func (k *Kustomization) DeprecatedFieldNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, d := range k.deprecations() {
+		if !seen[d.field] {
+			seen[d.field] = true
+			names = append(names, d.field)
+		}
+	}
+	return names
+}
+
+type deprecation struct {
+	field string
+	msg   string
+}
+
+func (k *Kustomization) deprecations() []deprecation {
+	var result []deprecation
+	if len(k.Bases) > 0 {
+		result = append(result, deprecation{"bases", "'bases' is deprecated; " +
+			"list bases in 'resources' instead (run 'kustomize edit fix')"})
+	}
+	if len(k.Vars) > 0 {
+		result = append(result, deprecation{"vars", "'vars' is deprecated; " +
+			"use 'replacements' instead"})
+	}
+	if len(k.PatchesStrategicMerge) > 0 {
+		result = append(result, deprecation{"patchesStrategicMerge",
+			"'patchesStrategicMerge' is deprecated; " +
+				"use 'patches', giving each patch file as a 'path' entry"})
+	}
+	if len(k.PatchesJson6902) > 0 {
+		result = append(result, deprecation{"patchesJson6902",
+			"'patchesJson6902' is deprecated; " +
+				"use 'patches', which accepts JSON 6902 patches with a 'target' " +
+				"(run 'kustomize edit fix')"})
+	}
+	if len(k.HelmChartInflationGenerator) > 0 {
+		result = append(result, deprecation{"helmChartInflationGenerator",
+			"'helmChartInflationGenerator' is deprecated; " +
+				"use 'helmGlobals' and 'helmCharts' (run 'kustomize edit fix')"})
+	}
+	for _, g := range k.ConfigMapGenerator {
+		if g.EnvSource != "" {
+			result = append(result, deprecation{"configMapGenerator.env",
+				"'env' in configMapGenerator '" + g.Name +
+					"' is deprecated; use 'envs' instead"})
+		}
+	}
+	for _, g := range k.SecretGenerator {
+		if g.EnvSource != "" {
+			result = append(result, deprecation{"secretGenerator.env",
+				"'env' in secretGenerator '" + g.Name +
+					"' is deprecated; use 'envs' instead"})
+		}
+	}
+	return result
+}
+
 // FixKustomizationPreMarshalling fixes things
 // that should occur after the kustomization file
 // has been processed.
@@ -244,22 +401,190 @@ func (k *Kustomization) EnforceFields() []string {
 	if k.APIVersion != "" && k.APIVersion != requiredVersion {
 		errs = append(errs, "apiVersion for "+k.Kind+" should be "+requiredVersion)
 	}
+	for _, name := range k.EnvSources {
+		if !envVarName.MatchString(name) {
+			errs = append(errs, "envSources holds "+name+", which isn't the name of an environment variable")
+		}
+	}
+	if k.Requirements != nil {
+		if err := k.Requirements.Validate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
 	return errs
 }
 
 // Unmarshal replace k with the content in YAML input y
 func (k *Kustomization) Unmarshal(y []byte) error {
+	var nk Kustomization
+	if err := nk.UnmarshalWithExtensions(y); err != nil {
+		return err
+	}
+	if names := nk.UnknownFields(); len(names) > 0 {
+		return fmt.Errorf("json: unknown field %q", names[0])
+	}
+	*k = nk
+	return nil
+}
+
+// UnmarshalWithExtensions is like Unmarshal, but keeps the top-level
+// fields unknown to kustomize with namespaced names, e.g.
+// argocd.argoproj.io/sync-options, in Extensions, rather than
+// failing.  Other unknown fields, e.g. misspelled ones, still fail.
+func (k *Kustomization) UnmarshalWithExtensions(y []byte) error {
 	j, err := yaml.YAMLToJSON(y)
 	if err != nil {
 		return err
 	}
-	dec := json.NewDecoder(bytes.NewReader(j))
-	dec.DisallowUnknownFields()
 	var nk Kustomization
-	err = dec.Decode(&nk)
-	if err != nil {
+	if err = json.Unmarshal(j, &nk); err != nil {
 		return err
 	}
 	*k = nk
 	return nil
 }
+
+// UnknownFields returns the sorted names of the Extensions.
+func (k *Kustomization) UnknownFields() []string {
+	var result []string
+	for name := range k.Extensions {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// UnmarshalJSON reads the fields known to kustomize into k,
+// failing on unknown fields within them, and the other
+// top-level fields with namespaced names into Extensions,
+// failing on those left.
+func (k *Kustomization) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if fields == nil {
+		*k = Kustomization{}
+		return nil
+	}
+	known := map[string]json.RawMessage{}
+	var extensions map[string]interface{}
+	var unknown []string
+	for name, raw := range fields {
+		if isKustomizationField(name) {
+			known[name] = raw
+			continue
+		}
+		if !isExtensionField(name) {
+			unknown = append(unknown, name)
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if extensions == nil {
+			extensions = map[string]interface{}{}
+		}
+		extensions[name] = v
+	}
+	var filters []ResourceFilter
+	for name, raw := range known {
+		if strings.EqualFold(name, "resources") {
+			var paths []string
+			var err error
+			if paths, filters, err = splitResources(raw); err != nil {
+				return err
+			}
+			if known[name], err = json.Marshal(paths); err != nil {
+				return err
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("json: unknown field %q", unknown[0])
+	}
+	b, err := json.Marshal(known)
+	if err != nil {
+		return err
+	}
+	type plain Kustomization
+	var p plain
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&p); err != nil {
+		return err
+	}
+	*k = Kustomization(p)
+	k.ResourceFilters = filters
+	k.Extensions = extensions
+	return nil
+}
+
+// MarshalJSON writes the Extensions of k along with its
+// fields, and the ResourceFilters in its resources.
+func (k Kustomization) MarshalJSON() ([]byte, error) {
+	type plain Kustomization
+	b, err := json.Marshal(plain(k))
+	if err != nil || (len(k.Extensions) == 0 && len(k.ResourceFilters) == 0) {
+		return b, err
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	if len(k.Resources) > 0 {
+		fields["resources"] = k.joinResources()
+	}
+	for name, v := range k.Extensions {
+		if _, ok := fields[name]; !ok {
+			fields[name] = v
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// kustomizationFields holds the json names of the
+// fields of Kustomization, including those inlined.
+var kustomizationFields = jsonFieldNames(reflect.TypeOf(Kustomization{}))
+
+func jsonFieldNames(t reflect.Type) []string {
+	var result []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case name == "-":
+		case f.Anonymous && name == "":
+			result = append(result, jsonFieldNames(f.Type)...)
+		case name == "":
+			result = append(result, f.Name)
+		default:
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// isExtensionField returns whether the name, unknown to
+// kustomize, is namespaced, as those of the fields of
+// other tools are, so that it's kept as an extension
+// rather than taken for a misspelled field.
+func isExtensionField(name string) bool {
+	return strings.ContainsAny(name, "./")
+}
+
+// isKustomizationField returns whether the name is
+// that of a field of Kustomization, ignoring case, as
+// json does.
+func isKustomizationField(name string) bool {
+	for _, f := range kustomizationFields {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,8 @@ package types
 import (
 	"reflect"
 	"testing"
+
+	"sigs.k8s.io/yaml"
 )
 
 func fixKustomizationPostUnmarshallingCheck(k, e *Kustomization) bool {
@@ -212,6 +214,59 @@ unknown: foo`)
 	}
 }
 
+func TestUnmarshalWithExtensions(t *testing.T) {
+	y := []byte(`
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namePrefix: app-
+argoproj.io/argocd:
+  syncWave: 1000000
+example.com: foo`)
+	var k Kustomization
+	if err := k.UnmarshalWithExtensions(y); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k.NamePrefix != "app-" {
+		t.Fatalf("wrong unmarshal result: %v", k)
+	}
+	if names := k.UnknownFields(); !reflect.DeepEqual(names, []string{"argoproj.io/argocd", "example.com"}) {
+		t.Fatalf("wrong unknown fields: %v", names)
+	}
+	b, err := yaml.Marshal(k)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := `apiVersion: kustomize.config.k8s.io/v1beta1
+argoproj.io/argocd:
+  syncWave: 1000000
+example.com: foo
+kind: Kustomization
+namePrefix: app-
+`
+	if string(b) != expect {
+		t.Fatalf("expect %v but got: %v", expect, string(b))
+	}
+
+	// Fields of names not namespaced are taken for misspelled ones.
+	err = k.UnmarshalWithExtensions([]byte(`
+resouces:
+- deployment.yaml`))
+	expect = "json: unknown field \"resouces\""
+	if err == nil || err.Error() != expect {
+		t.Fatalf("expect %v but got: %v", expect, err)
+	}
+
+	// Only top-level fields are extensions.
+	err = k.UnmarshalWithExtensions([]byte(`
+configMapGenerator:
+- name: app
+  unknown: foo`))
+	expect = "json: unknown field \"unknown\""
+	if err == nil || err.Error() != expect {
+		t.Fatalf("expect %v but got: %v", expect, err)
+	}
+}
+
 func TestUnmarshal_InvalidYaml(t *testing.T) {
 	y := []byte(`
 apiVersion: kustomize.config.k8s.io/v1beta1
@@ -223,3 +278,36 @@ unknown`)
 		t.Fatalf("expect an error")
 	}
 }
+
+func TestDeprecatedFields(t *testing.T) {
+	var k Kustomization
+	if msgs := k.DeprecatedFields(); len(msgs) != 0 {
+		t.Fatalf("unexpected deprecations: %v", msgs)
+	}
+	k.Bases = []string{"base"}
+	k.Vars = []Var{{Name: "FOO"}}
+	k.PatchesStrategicMerge = []PatchStrategicMerge{"patch.yaml"}
+	k.PatchesJson6902 = []Patch{{Path: "patch.json"}}
+	k.SecretGenerator = []SecretArgs{{GeneratorArgs: GeneratorArgs{
+		Name:          "secret",
+		KvPairSources: KvPairSources{EnvSource: "secret.env"},
+	}}}
+	expected := []string{
+		"'bases' is deprecated; list bases in 'resources' instead (run 'kustomize edit fix')",
+		"'vars' is deprecated; use 'replacements' instead",
+		"'patchesStrategicMerge' is deprecated; use 'patches', giving each patch file as a 'path' entry",
+		"'patchesJson6902' is deprecated; use 'patches', which accepts JSON 6902 patches with a 'target' (run 'kustomize edit fix')",
+		"'env' in secretGenerator 'secret' is deprecated; use 'envs' instead",
+	}
+	if msgs := k.DeprecatedFields(); !reflect.DeepEqual(msgs, expected) {
+		t.Fatalf("expected\n%v\nbut got\n%v", expected, msgs)
+	}
+	k.SecretGenerator = append(k.SecretGenerator, k.SecretGenerator[0])
+	expectedNames := []string{
+		"bases", "vars", "patchesStrategicMerge",
+		"patchesJson6902", "secretGenerator.env",
+	}
+	if names := k.DeprecatedFieldNames(); !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("expected %v but got %v", expectedNames, names)
+	}
+}
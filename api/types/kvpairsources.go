@@ -29,6 +29,14 @@ type KvPairSources struct {
 	// (wikipedia.org/wiki/INI_file)
 	EnvSources []string `json:"envs,omitempty" yaml:"envs,omitempty"`
 
+	// EnvVarSources is a list of environment variables,
+	// each giving a key the variable's value.  A source
+	// takes the form [{key}=]{variable}; if the "key="
+	// part is missing, the key is the variable's name.
+	// The kustomization's envSources must declare the
+	// variables, and they must be set.
+	EnvVarSources []string `json:"envVars,omitempty" yaml:"envVars,omitempty"`
+
 	// Older, singular form of EnvSources.
 	// On edits (e.g. `kustomize fix`) this is merged into the plural form
 	// for consistency with LiteralSources and FileSources.
@@ -0,0 +1,77 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+)
+
+// ConflictPolicy says what to do when a key to add to the
+// labels, or annotations, of a resource already has a value
+// there that differs from the one to add.
+type ConflictPolicy string
+
+const (
+	// ConflictOverride replaces the value; it's the default.
+	ConflictOverride ConflictPolicy = "override"
+	// ConflictKeepExisting leaves the value as it is.  For
+	// labels, it's decided by resource, from the value of its
+	// metadata.labels, so that the selectors of a workload and
+	// its template are left, or set, together.
+	ConflictKeepExisting ConflictPolicy = "keep-existing"
+	// ConflictError fails the build.
+	ConflictError ConflictPolicy = "error"
+)
+
+// MetadataOptions control how a kustomization's commonLabels,
+// or commonAnnotations, are applied to resources already
+// having some of their keys, with other values.
+type MetadataOptions struct {
+	// OnConflict is the policy of the keys Keys doesn't name.
+	OnConflict ConflictPolicy `json:"onConflict,omitempty" yaml:"onConflict,omitempty"`
+
+	// Keys holds the policies of particular keys, e.g.
+	// keep-existing for cert-manager.io/cluster-issuer.
+	Keys map[string]ConflictPolicy `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// PolicyFor returns the policy of the key, which
+// is ConflictOverride if o is nil or doesn't set any.
+func (o *MetadataOptions) PolicyFor(key string) ConflictPolicy {
+	if o == nil {
+		return ConflictOverride
+	}
+	if p, ok := o.Keys[key]; ok && p != "" {
+		return p
+	}
+	if o.OnConflict != "" {
+		return o.OnConflict
+	}
+	return ConflictOverride
+}
+
+// Validate errors if o holds an unknown policy; o may be nil.
+func (o *MetadataOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if err := o.OnConflict.validate(); err != nil {
+		return fmt.Errorf("onConflict: %v", err)
+	}
+	for k, p := range o.Keys {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("the policy of key %s: %v", k, err)
+		}
+	}
+	return nil
+}
+
+func (p ConflictPolicy) validate() error {
+	switch p {
+	case "", ConflictOverride, ConflictKeepExisting, ConflictError:
+		return nil
+	}
+	return fmt.Errorf("unknown conflict policy %q, expected one of %s, %s or %s",
+		p, ConflictOverride, ConflictKeepExisting, ConflictError)
+}
@@ -0,0 +1,12 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// NamespaceOptions control how a kustomization's
+// namespace field is applied.
+type NamespaceOptions struct {
+	// Exclude selects resources whose namespace fields,
+	// including metadata.namespace, are left as they are.
+	Exclude []Selector `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
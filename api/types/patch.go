@@ -9,8 +9,14 @@ import "reflect"
 // and its targets.
 // The content of the patch can either be from a file
 // or from an inline string.
+// A Strategic Merge Patch may hold several documents, applied
+// in order, each to the resource it names or, with a Target,
+// all to the targets.
 type Patch struct {
 	// Path is a relative file path to the patch file.
+	// It may be a glob (e.g. patches/*.yaml), in which case
+	// each matching file is applied as its own patch, in
+	// lexical order of the file paths.
 	Path string `json:"path,omitempty" yaml:"path,omitempty"`
 
 	// Patch is the content of a patch.
@@ -21,8 +27,46 @@ type Patch struct {
 
 	// Options is a list of options for the patch
 	Options map[string]bool `json:"options,omitempty" yaml:"options,omitempty"`
+
+	// MergeLists is how the lists of a strategic merge patch
+	// are merged into those of its targets.  Left out, lists
+	// are merged by key if their schema says so, and replaced
+	// otherwise, which replaces all the lists of resources
+	// without a schema, e.g. custom resources.
+	MergeLists MergeLists `json:"mergeLists,omitempty" yaml:"mergeLists,omitempty"`
+
+	// Delete holds field paths, e.g. metadata.annotations.owner
+	// or spec.template.spec.tolerations.[key=dedicated], whose
+	// fields or list entries are deleted from the targets,
+	// after the patch, if any, is applied.
+	Delete []string `json:"delete,omitempty" yaml:"delete,omitempty"`
+
+	// DataKey, if set, names a key in the data of each target
+	// ConfigMap whose value is a YAML or JSON document (JSON if
+	// the key ends in .json); the patch is applied to that
+	// document rather than to the ConfigMap.
+	DataKey string `json:"dataKey,omitempty" yaml:"dataKey,omitempty"`
 }
 
+// MergeLists is how a strategic merge patch merges lists.
+type MergeLists string
+
+const (
+	// MergeListsAppend appends the elements of the
+	// patch's lists to those of the target's.
+	MergeListsAppend MergeLists = "append"
+
+	// MergeListsByKey merges the lists of maps by their
+	// merge key, per their schema or, lacking one, the name
+	// field of their elements, and replaces the other lists.
+	MergeListsByKey MergeLists = "merge-by-key"
+
+	// MergeListsReplace replaces the target's lists
+	// with the patch's, even those of a schema
+	// saying they're merged by key.
+	MergeListsReplace MergeLists = "replace"
+)
+
 // Equals return true if p equals o.
 func (p *Patch) Equals(o Patch) bool {
 	targetEqual := (p.Target == o.Target) ||
@@ -30,5 +74,8 @@ func (p *Patch) Equals(o Patch) bool {
 	return p.Path == o.Path &&
 		p.Patch == o.Patch &&
 		targetEqual &&
-		reflect.DeepEqual(p.Options, o.Options)
+		reflect.DeepEqual(p.Options, o.Options) &&
+		p.MergeLists == o.MergeLists &&
+		reflect.DeepEqual(p.Delete, o.Delete) &&
+		p.DataKey == o.DataKey
 }
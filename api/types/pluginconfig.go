@@ -21,12 +21,30 @@ type PluginConfig struct {
 
 	// HelmConfig contains metadata needed for allowing and running helm.
 	HelmConfig HelmConfig
+
+	// TLSSecretGeneratorEnabled allows generating TLS secrets,
+	// whose keys are only fit for dev and test.
+	TLSSecretGeneratorEnabled bool
+
+	// KubeVersion is the version of Kubernetes the build
+	// targets, if known, for plugins whose output depends on it.
+	KubeVersion string
+
+	// Policy, if not nil, restricts the helm repositories
+	// plugins pull charts from, and the functions run.
+	Policy *Policy
+
+	// RemoteRefRewriter, if not nil, is given the helm charts
+	// plugins pull, and the images of the functions they run,
+	// to rewrite or reject.
+	RemoteRefRewriter RemoteRefRewriter
 }
 
 func EnabledPluginConfig(b BuiltinPluginLoadingOptions) (pc *PluginConfig) {
 	pc = MakePluginConfig(PluginRestrictionsNone, b)
 	pc.FnpLoadingOptions.EnableStar = true
 	pc.HelmConfig.Enabled = true
+	pc.TLSSecretGeneratorEnabled = true
 	// If this command is not on PATH, tests needing it should skip.
 	pc.HelmConfig.Command = "helmV3"
 	return
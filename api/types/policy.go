@@ -0,0 +1,175 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PolicyFileName is the name of the file holding the Policy of
+// the kustomizations in the directory it's in and below, e.g.
+// at the top of a repository.
+const PolicyFileName = ".kustomize-policy.yaml"
+
+// Policy restricts what builds may fetch and run: the remote
+// bases, helm charts and function images kustomizations refer
+// to, and whether they may use the network at all.
+//
+// A list left out allows anything; a list given allows only
+// what's in it, so that an empty one allows nothing.
+//
+// The Check methods may be called on a nil policy,
+// and then allow everything.
+type Policy struct {
+	// AllowedGitRepos are the git repositories remote bases
+	// may be in, by host, e.g. github.com, by organization,
+	// e.g. github.com/kubernetes-sigs, or by repository,
+	// e.g. github.com/kubernetes-sigs/kustomize.
+	AllowedGitRepos []string `json:"allowedGitRepos,omitempty" yaml:"allowedGitRepos,omitempty"`
+
+	// AllowedHelmRepos are the URLs of the helm repositories
+	// charts may be pulled from, e.g. https://charts.example.com,
+	// also allowing the repositories below them.
+	AllowedHelmRepos []string `json:"allowedHelmRepos,omitempty" yaml:"allowedHelmRepos,omitempty"`
+
+	// AllowedFunctionImages are the container images functions
+	// may run, by repository, e.g. gcr.io/kpt-fn, allowing any
+	// image below it, or by image, e.g. gcr.io/kpt-fn/set-labels,
+	// allowing any tag or digest of it.
+	AllowedFunctionImages []string `json:"allowedFunctionImages,omitempty" yaml:"allowedFunctionImages,omitempty"`
+
+	// DenyNetwork forbids builds to use the network: to clone
+	// remote bases, load resources from URLs, pull helm charts,
+	// or run functions with network access.
+	DenyNetwork bool `json:"denyNetwork,omitempty" yaml:"denyNetwork,omitempty"`
+
+	// Signatures, if set, requires the function images builds
+	// run, and the helm charts they pull, to be signed.
+	Signatures *SignaturePolicy `json:"signatures,omitempty" yaml:"signatures,omitempty"`
+
+	// Path is the file the policy was read from.
+	Path string `json:"-" yaml:"-"`
+}
+
+// PolicyViolation is the error of a kustomization referring
+// to something its policy doesn't allow.
+type PolicyViolation struct {
+	// Policy is the path of the policy file.
+	Policy string
+	// Referrer is the directory of the kustomization
+	// referring to what's forbidden.
+	Referrer string
+	// Forbidden describes it, e.g. git repository example.com/x.
+	Forbidden string
+}
+
+func (e *PolicyViolation) Error() string {
+	return fmt.Sprintf(
+		"the kustomization in %s refers to %s, which the policy in %s forbids",
+		e.Referrer, e.Forbidden, e.Policy)
+}
+
+func (p *Policy) violation(referrer, format string, args ...interface{}) error {
+	return &PolicyViolation{
+		Policy:    p.Path,
+		Referrer:  referrer,
+		Forbidden: fmt.Sprintf(format, args...),
+	}
+}
+
+// CheckGitRepo errors if the policy forbids the kustomization
+// in the referrer directory to clone the repository, given by
+// its host, e.g. https://github.com/, and organization and name.
+func (p *Policy) CheckGitRepo(referrer, host, orgRepo string) error {
+	if p == nil {
+		return nil
+	}
+	repo := trimHost(host) + "/" + strings.TrimSuffix(orgRepo, ".git")
+	if p.DenyNetwork {
+		return p.violation(referrer, "the remote git repository %s", repo)
+	}
+	if p.AllowedGitRepos != nil && !allowedBelow(p.AllowedGitRepos, repo, "/") {
+		return p.violation(referrer, "git repository %s", repo)
+	}
+	return nil
+}
+
+// CheckURL errors if the policy forbids the kustomization
+// in the referrer directory to load the URL.
+func (p *Policy) CheckURL(referrer, url string) error {
+	if p != nil && p.DenyNetwork {
+		return p.violation(referrer, "the URL %s", url)
+	}
+	return nil
+}
+
+// CheckHelmRepo errors if the policy forbids the kustomization
+// in the referrer directory to pull charts from the helm
+// repository at the URL.
+func (p *Policy) CheckHelmRepo(referrer, url string) error {
+	if p == nil {
+		return nil
+	}
+	if p.DenyNetwork {
+		return p.violation(referrer, "the helm repository %s", url)
+	}
+	if p.AllowedHelmRepos == nil {
+		return nil
+	}
+	var allowed []string
+	for _, a := range p.AllowedHelmRepos {
+		allowed = append(allowed, strings.TrimSuffix(a, "/"))
+	}
+	if !allowedBelow(allowed, strings.TrimSuffix(url, "/"), "/") {
+		return p.violation(referrer, "helm repository %s", url)
+	}
+	return nil
+}
+
+// CheckFunction errors if the policy forbids the kustomization
+// in the referrer directory to run a function in the container
+// image, with network access or not.
+func (p *Policy) CheckFunction(referrer, image string, network bool) error {
+	if p == nil {
+		return nil
+	}
+	if network && p.DenyNetwork {
+		return p.violation(referrer, "the function %s, with network access", image)
+	}
+	if p.AllowedFunctionImages != nil &&
+		!allowedBelow(p.AllowedFunctionImages, image, "/", ":", "@") {
+		return p.violation(referrer, "function image %s", image)
+	}
+	return nil
+}
+
+// allowedBelow returns whether s is one of the allowed,
+// or one of them followed by a separator and more.
+func allowedBelow(allowed []string, s string, separators ...string) bool {
+	for _, a := range allowed {
+		if s == a {
+			return true
+		}
+		for _, sep := range separators {
+			if strings.HasPrefix(s, a+sep) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trimHost returns the host of a repository's URL without
+// its scheme, user and separator, e.g. github.com for
+// https://github.com/ or git@github.com:.
+func trimHost(host string) string {
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	return strings.TrimRight(host, "/:")
+}
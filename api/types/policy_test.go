@@ -0,0 +1,49 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/api/types"
+)
+
+func TestPolicyChecks(t *testing.T) {
+	p := &Policy{
+		AllowedGitRepos:       []string{"github.com/kubernetes-sigs", "gitlab.example.com"},
+		AllowedHelmRepos:      []string{"https://charts.example.com/"},
+		AllowedFunctionImages: []string{"gcr.io/kpt-fn", "example.com/fn"},
+		Path:                  "/repo/" + PolicyFileName,
+	}
+	assert.NoError(t, p.CheckGitRepo("/repo/app", "https://github.com/", "kubernetes-sigs/kustomize"))
+	assert.NoError(t, p.CheckGitRepo("/repo/app", "git@github.com:", "kubernetes-sigs/kustomize.git"))
+	assert.NoError(t, p.CheckGitRepo("/repo/app", "https://gitlab.example.com/", "team/configs"))
+	assert.Error(t, p.CheckGitRepo("/repo/app", "https://github.com/", "kubernetes-sigs-fork/kustomize"))
+	assert.Error(t, p.CheckGitRepo("/repo/app", "https://gitlab.example.com.evil/", "team/configs"))
+
+	assert.NoError(t, p.CheckHelmRepo("/repo/app", "https://charts.example.com"))
+	assert.NoError(t, p.CheckHelmRepo("/repo/app", "https://charts.example.com/stable"))
+	assert.Error(t, p.CheckHelmRepo("/repo/app", "https://charts.example.community"))
+
+	assert.NoError(t, p.CheckFunction("/repo/app", "gcr.io/kpt-fn/set-labels:v0.1", false))
+	assert.NoError(t, p.CheckFunction("/repo/app", "example.com/fn@sha256:abc", true))
+	assert.Error(t, p.CheckFunction("/repo/app", "example.com/fnord:v1", false))
+
+	assert.NoError(t, p.CheckURL("/repo/app", "https://example.com/x.yaml"))
+	p.DenyNetwork = true
+	assert.EqualError(t,
+		p.CheckGitRepo("/repo/app", "https://github.com/", "kubernetes-sigs/kustomize"),
+		"the kustomization in /repo/app refers to the remote git repository "+
+			"github.com/kubernetes-sigs/kustomize, which the policy in "+
+			"/repo/.kustomize-policy.yaml forbids")
+	assert.Error(t, p.CheckURL("/repo/app", "https://example.com/x.yaml"))
+	assert.Error(t, p.CheckHelmRepo("/repo/app", "https://charts.example.com"))
+	assert.Error(t, p.CheckFunction("/repo/app", "gcr.io/kpt-fn/set-labels:v0.1", true))
+	assert.NoError(t, p.CheckFunction("/repo/app", "gcr.io/kpt-fn/set-labels:v0.1", false))
+
+	var none *Policy
+	assert.NoError(t, none.CheckGitRepo("/repo/app", "https://example.com/", "x/y"))
+	assert.NoError(t, none.CheckFunction("/repo/app", "example.com/x", true))
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// ProgressFunc receives the events of a build, e.g. to show
+// the progress of builds taking minutes, fetching many remote
+// bases and running functions.
+//
+// It's called from the goroutines making builds, so must be
+// safe to call from several at once if builds run in parallel.
+// A nil ProgressFunc may be reported to, and then does nothing.
+type ProgressFunc func(ProgressEvent)
+
+// Report calls f with the event, if f isn't nil.
+func (f ProgressFunc) Report(e ProgressEvent) {
+	if f != nil {
+		f(e)
+	}
+}
+
+// ProgressEventKind says what a ProgressEvent reports.
+type ProgressEventKind string
+
+const (
+	// ProgressStageStarted reports that a kustomization
+	// started a stage of its build.
+	ProgressStageStarted ProgressEventKind = "StageStarted"
+	// ProgressStageFinished reports that a kustomization
+	// finished a stage, holding the resources counted.
+	ProgressStageFinished ProgressEventKind = "StageFinished"
+	// ProgressFetchStarted reports that the build started
+	// fetching a remote repository.
+	ProgressFetchStarted ProgressEventKind = "FetchStarted"
+	// ProgressFetchFinished reports that the build fetched
+	// a remote repository, or failed to.
+	ProgressFetchFinished ProgressEventKind = "FetchFinished"
+)
+
+// The stages of the build of a kustomization, each reported
+// when started and finished, for it and each base and
+// component it loads.
+const (
+	StageResources    = "resources"
+	StageGenerators   = "generators"
+	StageTransformers = "transformers"
+	StageValidators   = "validators"
+)
+
+// ProgressEvent is an event of a build.
+type ProgressEvent struct {
+	Kind ProgressEventKind
+
+	// Stage is the stage started or finished, e.g. StageResources.
+	Stage string
+
+	// Dir is the root of the kustomization of the stage.
+	Dir string
+
+	// Resources is how many resources the kustomization
+	// holds after the stage finished.
+	Resources int
+
+	// Repo is the remote repository fetched, with its ref if
+	// any, e.g. https://github.com/example/base.git?ref=v1.
+	Repo string
+
+	// Err is why the fetch failed, if it did.
+	Err error
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "fmt"
+
+// RemoteRefKind is the kind of content a RemoteRef refers to.
+type RemoteRefKind string
+
+const (
+	// RemoteRefGit is a remote base, or target, in a git repository.
+	RemoteRefGit RemoteRefKind = "git"
+
+	// RemoteRefHelmChart is a chart in a helm repository
+	// or an OCI registry.
+	RemoteRefHelmChart RemoteRefKind = "helmChart"
+
+	// RemoteRefImage is the container image of a function.
+	RemoteRefImage RemoteRefKind = "image"
+
+	// RemoteRefURL is a file loaded from a URL, e.g. a resource.
+	RemoteRefURL RemoteRefKind = "url"
+)
+
+// RemoteRef is a reference of a kustomization to remote content,
+// as a RemoteRefRewriter is given it before it's fetched.
+type RemoteRef struct {
+	// Kind is what the reference is to.
+	Kind RemoteRefKind
+
+	// Referrer is the directory of the kustomization
+	// holding the reference.
+	Referrer string
+
+	// URL is where the content is: the URL of a remote base, as
+	// the kustomization has it, e.g.
+	// https://github.com/org/repo//dir?ref=v1; the repository
+	// of a helm chart, e.g. https://charts.example.com or
+	// oci://registry.example.com/charts; the image of a
+	// function; or the URL of a file.
+	URL string
+
+	// Name and Version are those of a helm chart;
+	// the version may be empty, for the latest.
+	Name    string
+	Version string
+}
+
+func (r RemoteRef) String() string {
+	switch r.Kind {
+	case RemoteRefGit:
+		return "remote base " + r.URL
+	case RemoteRefHelmChart:
+		return fmt.Sprintf("helm chart %s in %s", r.Name, r.URL)
+	case RemoteRefImage:
+		return "function image " + r.URL
+	default:
+		return "the URL " + r.URL
+	}
+}
+
+// RemoteRefRewriter is given each reference of a build to remote
+// content before it's fetched, returning the reference to fetch
+// instead, e.g. one to a mirror, or an error, to reject it.  The
+// Kind and Referrer of the reference returned are ignored.
+type RemoteRefRewriter func(ref RemoteRef) (RemoteRef, error)
+
+// Rewrite returns the reference to fetch in place of ref, that
+// returned by the rewriter, which may be nil, leaving ref as is.
+func (f RemoteRefRewriter) Rewrite(ref RemoteRef) (RemoteRef, error) {
+	if f == nil {
+		return ref, nil
+	}
+	result, err := f(ref)
+	if err != nil {
+		return RemoteRef{}, fmt.Errorf(
+			"the kustomization in %s refers to %s, which was rejected: %v",
+			ref.Referrer, ref, err)
+	}
+	result.Kind, result.Referrer = ref.Kind, ref.Referrer
+	return result, nil
+}
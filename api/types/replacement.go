@@ -20,6 +20,13 @@ type SourceSelector struct {
 	// A specific object to read it from.
 	KrmId `json:",inline,omitempty" yaml:",inline,omitempty"`
 
+	// LabelSelector, if set, restricts the objects to read it
+	// from to those whose labels match it, e.g. to select one
+	// whose name varies by environment.  It follows the label
+	// selection expression
+	// https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#api
+	LabelSelector string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+
 	// Structured field path expected in the allowed object.
 	FieldPath string `json:"fieldPath" yaml:"fieldPath"`
 
@@ -36,15 +43,24 @@ type TargetSelector struct {
 	Reject []*Selector `json:"reject" yaml:"reject"`
 
 	// Structured field paths expected in each allowed object.
+	// A list entry selector, e.g. [name=app], selects all the
+	// entries matching it; its value may be a glob, as in
+	// spec.template.spec.volumes.[name=*config*].configMap.name.
 	FieldPaths []string `json:"fieldPaths" yaml:"fieldPaths"`
 
+	// Structured field path within the document held, as a
+	// string, by each of the FieldPaths above.  The format of
+	// that document is given by Options.Format.
+	InnerFieldPath string `json:"innerFieldPath,omitempty" yaml:"innerFieldPath,omitempty"`
+
 	// Used to refine the interpretation of the field.
 	Options *FieldOptions `json:"options" yaml:"options"`
 }
 
 // FieldOptions refine the interpretation of FieldPaths.
 type FieldOptions struct {
-	// Used to split/join the field.
+	// Used to split/join the field.  With Aggregate join,
+	// the delimiter the values are joined with; comma if empty.
 	Delimiter string `json:"delimiter" yaml:"delimiter"`
 
 	// Which position in the split to consider.
@@ -56,4 +72,42 @@ type FieldOptions struct {
 
 	// If field missing, add it.
 	Create bool `json:"create" yaml:"create"`
+
+	// Template, used with Create, holds the fields of the list
+	// entry to create if the last entry selected by the field
+	// path, e.g. [name=FOO] in env.[name=FOO].valueFrom, is
+	// missing.  The field matched by the selector is set too.
+	Template map[string]interface{} `json:"template,omitempty" yaml:"template,omitempty"`
+
+	// The format of a document held in a string field, one
+	// of yaml, json or properties.  Used with innerFieldPath.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Aggregate, in the options of a source, lets the source
+	// select several resources, making the value of the
+	// fieldPath in each into one: join, the values joined by
+	// Delimiter; min or max, the least or greatest of the
+	// values, numbers; or count, the number of values.
+	Aggregate string `json:"aggregate,omitempty" yaml:"aggregate,omitempty"`
+
+	// OnMultipleMatches, in the options of a source, is which of
+	// several resources the source selects to read the value from:
+	// error, the default, fails the replacement; first, the first
+	// in the order of the resources; latest, the one of the latest
+	// time in its config.kubernetes.io/creationTimestamp annotation,
+	// those without one being the earliest, and the later in the
+	// order of the resources if two are of the same time.
+	OnMultipleMatches string `json:"onMultipleMatches,omitempty" yaml:"onMultipleMatches,omitempty"`
+
+	// Token, in the options of a target, makes the replacement
+	// replace each occurrence of the token in the string fields
+	// rather than the whole fields.  With no fieldPaths, the
+	// fields are the file sources of ConfigMap generators setting
+	// options.applyReplacements.
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+
+	// SourceStyle, in the options of a target, makes the
+	// replaced fields take the comments and style, e.g. the
+	// quoting, of the source value, rather than keep their own.
+	SourceStyle bool `json:"sourceStyle,omitempty" yaml:"sourceStyle,omitempty"`
 }
@@ -0,0 +1,42 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "fmt"
+
+// Requirements declare what the build of a kustomization needs
+// enabled, which is otherwise disabled by default, so that a
+// build missing it fails up front, saying how to enable it, or,
+// where it's safe to, enables it.
+type Requirements struct {
+	// LoadRestrictor is the load restrictor the kustomization
+	// needs, LoadRestrictionsNone if it loads files from outside
+	// its root.
+	LoadRestrictor string `json:"loadRestrictor,omitempty" yaml:"loadRestrictor,omitempty"`
+
+	// Helm is true if the kustomization inflates helm charts.
+	Helm bool `json:"helm,omitempty" yaml:"helm,omitempty"`
+
+	// Plugins is true if the kustomization uses plugins,
+	// e.g. KRM functions.
+	Plugins bool `json:"plugins,omitempty" yaml:"plugins,omitempty"`
+
+	// Exec is true if the kustomization runs exec functions.
+	Exec bool `json:"exec,omitempty" yaml:"exec,omitempty"`
+
+	// Network is true if its functions need the network.
+	Network bool `json:"network,omitempty" yaml:"network,omitempty"`
+}
+
+// Validate returns an error if the requirements
+// set a load restrictor that doesn't exist.
+func (r *Requirements) Validate() error {
+	switch r.LoadRestrictor {
+	case "", LoadRestrictionsRootOnly.String(), LoadRestrictionsNone.String():
+		return nil
+	}
+	return fmt.Errorf(
+		"requirements.loadRestrictor is %s, expected %s or %s", r.LoadRestrictor,
+		LoadRestrictionsRootOnly.String(), LoadRestrictionsNone.String())
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultResourceIncludes are the globs a ResourceFilter
+// includes when it has none of its own.
+var DefaultResourceIncludes = []string{"*.yaml", "*.yml", "*.json"}
+
+// ResourceFilter filters the files of a directory of the
+// resources of a kustomization by globs, e.g. one vendored
+// from upstream, to use some of its files without copying
+// or deleting any.
+//
+// The directory is an entry of the resources, written with
+// its filter rather than as the bare path:
+//
+//	resources:
+//	- deployment.yaml
+//	- path: vendor/upstream/manifests
+//	  exclude:
+//	  - "*-test.yaml"
+type ResourceFilter struct {
+	// Path is the path of the directory, as in the resources.
+	Path string `json:"path" yaml:"path"`
+
+	// Include holds the globs, relative to the directory at
+	// Path, of the files to read, DefaultResourceIncludes if
+	// there are none.
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+
+	// Exclude holds the globs, relative to the directory at
+	// Path, of the files not to read, though included.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// ResourceFilterOf returns the filter of the resource at
+// path, if any.
+func (k *Kustomization) ResourceFilterOf(path string) (ResourceFilter, bool) {
+	for _, f := range k.ResourceFilters {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return ResourceFilter{}, false
+}
+
+// splitResources splits the resources of a kustomization,
+// each a path or a filter, into the paths, holding those of
+// the filters too, and the filters.
+func splitResources(data []byte) ([]string, []ResourceFilter, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, err
+	}
+	var paths []string
+	var filters []ResourceFilter
+	for _, e := range entries {
+		var path string
+		if err := json.Unmarshal(e, &path); err == nil {
+			paths = append(paths, path)
+			continue
+		}
+		var f ResourceFilter
+		dec := json.NewDecoder(bytes.NewReader(e))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return nil, nil, err
+		}
+		if f.Path == "" {
+			return nil, nil, fmt.Errorf(
+				"a resource given as an object must have a path")
+		}
+		for _, g := range filters {
+			if g.Path == f.Path {
+				return nil, nil, fmt.Errorf(
+					"the resource '%s' has more than one filter", f.Path)
+			}
+		}
+		paths = append(paths, f.Path)
+		filters = append(filters, f)
+	}
+	return paths, filters, nil
+}
+
+// joinResources returns the resources of k, with those
+// having filters written as their filters.
+func (k *Kustomization) joinResources() []interface{} {
+	result := make([]interface{}, len(k.Resources))
+	for i, path := range k.Resources {
+		if f, ok := k.ResourceFilterOf(path); ok {
+			result[i] = f
+		} else {
+			result[i] = path
+		}
+	}
+	return result
+}
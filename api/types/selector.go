@@ -25,6 +25,12 @@ type Selector struct {
 	// https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#api
 	// It matches with the resource labels.
 	LabelSelector string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+
+	// Query is a query the resource must match too, e.g.
+	// spec.template.spec.containers.[name=web].image=~"nginx:.*",
+	// as compiled by resmap.Query.  It's used by the selectors of
+	// patches and assertions, which select from a ResMap.
+	Query string `json:"query,omitempty" yaml:"query,omitempty"`
 }
 
 // KrmId refers to a GVKN/Ns of a resource.
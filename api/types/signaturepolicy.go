@@ -0,0 +1,44 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+)
+
+// SignaturePolicy requires the function images builds run,
+// and the helm charts they pull, to be signed, as checked by
+// cosign, either with a key or keylessly, by an identity.
+type SignaturePolicy struct {
+	// Key is the public key signatures are checked with: a
+	// file, relative to the policy file, or a KMS URI, e.g.
+	// gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	// Identity and Issuer are those of the certificates of
+	// keyless signatures, e.g. release@example.com and
+	// https://accounts.google.com.
+	Identity string `json:"identity,omitempty" yaml:"identity,omitempty"`
+	Issuer   string `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+
+	// AttestationType, if set, also requires an attestation
+	// of that predicate type, e.g. slsaprovenance, signed
+	// the same way.
+	AttestationType string `json:"attestationType,omitempty" yaml:"attestationType,omitempty"`
+}
+
+// Validate errors unless s sets a key, or an identity and
+// an issuer, but not both.
+func (s *SignaturePolicy) Validate() error {
+	keyless := s.Identity != "" || s.Issuer != ""
+	switch {
+	case s.Key != "" && keyless:
+		return fmt.Errorf("signatures set both a key and an identity")
+	case s.Key == "" && !keyless:
+		return fmt.Errorf("signatures set neither a key nor an identity")
+	case keyless && (s.Identity == "" || s.Issuer == ""):
+		return fmt.Errorf("signatures must set both an identity and its issuer")
+	}
+	return nil
+}
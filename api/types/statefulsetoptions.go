@@ -0,0 +1,30 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "strings"
+
+// StatefulSetOptions control how a kustomization's
+// transformers treat StatefulSets.
+type StatefulSetOptions struct {
+	// PreserveVolumeClaimTemplates, if true, keeps the
+	// transformers (labels, annotations, name prefixes and
+	// suffixes, namespaces and name references) of the
+	// kustomization, and of the bases and components it
+	// loads, from changing spec.volumeClaimTemplates.
+	//
+	// The volumeClaimTemplates of an existing StatefulSet
+	// can't be updated, and renaming them would orphan the
+	// volumes already claimed, so changing them breaks
+	// rolling updates.
+	PreserveVolumeClaimTemplates bool `json:"preserveVolumeClaimTemplates,omitempty" yaml:"preserveVolumeClaimTemplates,omitempty"`
+}
+
+// IsVolumeClaimTemplatesFieldSpec returns true if the field
+// spec selects a field within the volumeClaimTemplates
+// of a StatefulSet.
+func IsVolumeClaimTemplatesFieldSpec(fs FieldSpec) bool {
+	return (fs.Kind == "" || fs.Kind == "StatefulSet") &&
+		strings.HasPrefix(fs.Path, "spec/volumeClaimTemplates")
+}
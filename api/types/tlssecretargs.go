@@ -0,0 +1,51 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// TLSSecretArgs contains the metadata of how to generate a
+// kubernetes.io/tls Secret holding a certificate and its key,
+// for dev and test overlays.
+//
+// The key pair is derived from these args and, if it's signed
+// by a CA, from the CA's key, so that builds make the same
+// Secret until the args change or the certificate is rotated.
+// Without a CA, anyone who can read the kustomization can
+// derive the key.
+type TLSSecretArgs struct {
+	// GeneratorArgs for the secret. Its sources add keys
+	// to those of the certificate, tls.crt, tls.key and,
+	// if signed by a CA, ca.crt.
+	GeneratorArgs `json:",inline,omitempty" yaml:",inline,omitempty"`
+
+	// CommonName is the common name of the certificate's
+	// subject, by default its first DNS name, or else the
+	// name of the secret.
+	CommonName string `json:"commonName,omitempty" yaml:"commonName,omitempty"`
+
+	// DNSNames are the DNS names the certificate is for.
+	DNSNames []string `json:"dnsNames,omitempty" yaml:"dnsNames,omitempty"`
+
+	// IPAddresses are the IP addresses the certificate is for.
+	IPAddresses []string `json:"ipAddresses,omitempty" yaml:"ipAddresses,omitempty"`
+
+	// CA, if set, is the CA signing the certificate,
+	// which is otherwise self-signed.
+	CA *TLSCA `json:"ca,omitempty" yaml:"ca,omitempty"`
+
+	// RotationPeriod, if set, e.g. 720h, is how often the
+	// certificate is renewed, with a new key pair; each lasts
+	// two periods, so that the one it renews stays valid while
+	// the Secret's new name, given by its new hash, rolls out.
+	// If not set, the certificate doesn't expire.
+	RotationPeriod string `json:"rotationPeriod,omitempty" yaml:"rotationPeriod,omitempty"`
+}
+
+// TLSCA names the files, relative to the kustomization root,
+// holding the PEM encoded certificate and key of a CA.  The key
+// must be an Ed25519 or RSA one: ECDSA signatures differ at each
+// build, and so would the Secret and its name suffix hash.
+type TLSCA struct {
+	CertFile string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+}
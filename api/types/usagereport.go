@@ -0,0 +1,122 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"encoding/json"
+)
+
+// UsageReport summarizes which kustomize features a build
+// used, so that the reports of many builds may be aggregated,
+// e.g. to find those still using deprecated fields.
+//
+// It holds only the names of kustomization fields, builtin
+// plugins and plugin types, with how often each was used.
+// The names of objects, files and non-builtin plugins, and
+// any content, are never recorded.
+//
+// The Record methods may be called on a nil report,
+// and then do nothing.
+type UsageReport struct {
+	// Kustomizations counts the kustomization files loaded,
+	// including those of bases and components.
+	Kustomizations int `json:"kustomizations"`
+
+	// Components counts the kustomizations loaded as components.
+	Components int `json:"components"`
+
+	// Fields counts the kustomizations setting each field,
+	// e.g. namePrefix.
+	Fields map[string]int `json:"fields,omitempty"`
+
+	// DeprecatedFields counts the kustomizations setting each
+	// deprecated field, e.g. bases or configMapGenerator.env.
+	DeprecatedFields map[string]int `json:"deprecatedFields,omitempty"`
+
+	// Builtins counts the instances of each builtin plugin run.
+	Builtins map[string]int `json:"builtins,omitempty"`
+
+	// Plugins counts the non-builtin plugins run, by type,
+	// i.e. exec, go, fn (a KRM function) or registered.
+	Plugins map[string]int `json:"plugins,omitempty"`
+}
+
+// The plugin types counted by UsageReport.Plugins.
+const (
+	UsagePluginExec       = "exec"
+	UsagePluginGo         = "go"
+	UsagePluginFn         = "fn"
+	UsagePluginRegistered = "registered"
+)
+
+// RecordKustomization counts the fields used by k, which must
+// not yet have been fixed by FixKustomizationPostUnmarshalling.
+func (r *UsageReport) RecordKustomization(k *Kustomization) {
+	if r == nil {
+		return
+	}
+	r.Kustomizations++
+	if k.Kind == ComponentKind {
+		r.Components++
+	}
+	for _, f := range usedFields(k) {
+		addCount(&r.Fields, f, 1)
+	}
+	for _, f := range k.DeprecatedFieldNames() {
+		addCount(&r.DeprecatedFields, f, 1)
+	}
+}
+
+// RecordBuiltin counts n instances of the named builtin plugin.
+func (r *UsageReport) RecordBuiltin(name string, n int) {
+	if r == nil || n == 0 {
+		return
+	}
+	addCount(&r.Builtins, name, n)
+}
+
+// RecordPlugin counts a non-builtin plugin of the given type.
+func (r *UsageReport) RecordPlugin(pluginType string) {
+	if r == nil {
+		return
+	}
+	addCount(&r.Plugins, pluginType, 1)
+}
+
+func addCount(m *map[string]int, key string, n int) {
+	if *m == nil {
+		*m = make(map[string]int)
+	}
+	(*m)[key] += n
+}
+
+// usedFields returns the names of the non-empty
+// fields of k, other than its type, metadata and
+// Extensions, which may name anything.
+func usedFields(k *Kustomization) []string {
+	b, err := json.Marshal(k)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil
+	}
+	var result []string
+	for name, v := range fields {
+		switch name {
+		case "apiVersion", "kind", "metadata":
+			continue
+		}
+		if _, ok := k.Extensions[name]; ok {
+			continue
+		}
+		switch string(v) {
+		case "null", `""`, "[]", "{}", "false", "0":
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResultAction says what a build does about the results that
+// validators report with a severity.
+type ResultAction string
+
+const (
+	// ResultFail fails the build.
+	ResultFail ResultAction = "fail"
+	// ResultWarn logs the results as warnings.
+	ResultWarn ResultAction = "warn"
+	// ResultIgnore drops the results.
+	ResultIgnore ResultAction = "ignore"
+)
+
+// The severities of results, as KRM functions report them.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// ValidatorsOptions control what a build does about the
+// results that the validators of a kustomization report.
+type ValidatorsOptions struct {
+	// Severities holds the actions of the severities error,
+	// warning and info.  By default, errors fail the build,
+	// warnings are logged and infos ignored.
+	Severities map[string]ResultAction `json:"severities,omitempty" yaml:"severities,omitempty"`
+}
+
+// ActionFor returns the action for results of the severity,
+// a result without a severity being an error; o may be nil.
+func (o *ValidatorsOptions) ActionFor(severity string) ResultAction {
+	if severity == "" {
+		severity = SeverityError
+	}
+	if o != nil {
+		if a, ok := o.Severities[severity]; ok && a != "" {
+			return a
+		}
+	}
+	switch severity {
+	case SeverityError:
+		return ResultFail
+	case SeverityWarning:
+		return ResultWarn
+	}
+	return ResultIgnore
+}
+
+// Validate errors if o holds an unknown severity
+// or action; o may be nil.
+func (o *ValidatorsOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	for s, a := range o.Severities {
+		switch s {
+		case SeverityError, SeverityWarning, SeverityInfo:
+		default:
+			return fmt.Errorf("unknown severity %q, expected one of %s, %s or %s",
+				s, SeverityError, SeverityWarning, SeverityInfo)
+		}
+		switch a {
+		case "", ResultFail, ResultWarn, ResultIgnore:
+		default:
+			return fmt.Errorf(
+				"the action of severity %s: unknown action %q, expected one of %s, %s or %s",
+				s, a, ResultFail, ResultWarn, ResultIgnore)
+		}
+	}
+	return nil
+}
+
+// ValidationResult is a result that a validator reports,
+// e.g. an item of the results of a KRM function.
+type ValidationResult struct {
+	// Validator names the validator reporting the result.
+	Validator string
+
+	// Severity is error, warning or info.
+	Severity string
+
+	// Message says what the result is about.
+	Message string
+
+	// Resource names the resource concerned, if any,
+	// e.g. apps/v1 Deployment web.
+	Resource string
+
+	// Field is the path of the field concerned, if any.
+	Field string
+}
+
+// String returns the result as a line to log.
+func (r ValidationResult) String() string {
+	severity := r.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+	var where []string
+	for _, s := range []string{r.Validator, r.Resource, r.Field} {
+		if s != "" {
+			where = append(where, s)
+		}
+	}
+	if len(where) == 0 {
+		return fmt.Sprintf("[%s] %s", severity, r.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", severity, strings.Join(where, " "), r.Message)
+}
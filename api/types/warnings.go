@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// The reasons of Warnings.
+const (
+	// WarningUnknownFields warns of the top-level fields
+	// of a kustomization file unknown to kustomize, which
+	// are kept as its Extensions.
+	WarningUnknownFields = "UnknownFields"
+
+	// WarningBuiltinDefaults warns of the kinds whose
+	// defaults weren't applied, or stripped, as their
+	// schemas are the builtin Kubernetes ones.
+	WarningBuiltinDefaults = "BuiltinDefaults"
+
+	// WarningSkippedSources warns that a build selecting
+	// resources was made again with the sources it had left
+	// out, as it failed, or the resources selected referred
+	// to resources it lacked, without them.
+	WarningSkippedSources = "SkippedSources"
+)
+
+// Warning is something a build, or an edit, of a
+// kustomization went on despite.
+type Warning struct {
+	// Reason is the kind of the warning, e.g.
+	// WarningUnknownFields.
+	Reason string `json:"reason"`
+
+	// Path is the path of the kustomization file, or
+	// of the directory holding it.
+	Path string `json:"path"`
+
+	// Fields holds the names of what is warned of, e.g. the
+	// fields or the kinds, if any.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// UnknownFieldsWarning warns of the fields of the
+// kustomization file at path unknown to kustomize.
+func UnknownFieldsWarning(path string, fields []string) Warning {
+	return Warning{Reason: WarningUnknownFields, Path: path, Fields: fields}
+}
+
+// BuiltinDefaultsWarning warns of the kinds of the resources of
+// the build of the kustomization at path whose defaults weren't
+// applied, or stripped, as their schemas are builtin ones.
+func BuiltinDefaultsWarning(path string, kinds []string) Warning {
+	return Warning{Reason: WarningBuiltinDefaults, Path: path, Fields: kinds}
+}
+
+// SkippedSourcesWarning warns that the build of the kustomization
+// at path was made again with the sources it had left out, as
+// the resources selected referred to the resources, e.g. ConfigMap
+// cfg, without them, or as it failed, if there are none.
+func SkippedSourcesWarning(path string, references []string) Warning {
+	return Warning{Reason: WarningSkippedSources, Path: path, Fields: references}
+}
+
+func (w Warning) String() string {
+	switch w.Reason {
+	case WarningUnknownFields:
+		return fmt.Sprintf(
+			"warning: %s: keeping the fields unknown to kustomize as extensions: %s",
+			w.Path, strings.Join(w.Fields, ", "))
+	case WarningBuiltinDefaults:
+		return fmt.Sprintf(
+			"warning: %s: leaving the defaults of %s as is, as only those of "+
+				"CRDs and custom schemas are known, not those the API server "+
+				"sets for builtin kinds", w.Path, strings.Join(w.Fields, ", "))
+	case WarningSkippedSources:
+		if len(w.Fields) == 0 {
+			return fmt.Sprintf(
+				"warning: %s: loading the sources the selection left out, "+
+					"as the build failed without them", w.Path)
+		}
+		return fmt.Sprintf(
+			"warning: %s: loading the sources the selection left out, "+
+				"as the resources selected refer to %s, which the build "+
+				"lacked without them", w.Path, strings.Join(w.Fields, ", "))
+	default:
+		return fmt.Sprintf("warning: %s: %s %s",
+			w.Path, w.Reason, strings.Join(w.Fields, ", "))
+	}
+}
+
+// Warnings collects the warnings of builds, for the caller
+// to report as it sees fit once they're done.
+//
+// The Add method is safe for concurrent use.  It may be
+// called on nil Warnings, and then does nothing.
+type Warnings struct {
+	List []Warning `json:"warnings,omitempty"`
+	mu   sync.Mutex
+}
+
+// Add appends the warning.
+func (w *Warnings) Add(warning Warning) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.List = append(w.List, warning)
+}
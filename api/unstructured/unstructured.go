@@ -0,0 +1,171 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package unstructured converts resources, RNodes and resource
+// maps to and from the objects of the Unstructured type of
+// k8s.io/apimachinery, i.e. the maps its Object field holds,
+// without depending on apimachinery:
+//
+//	obj, err := unstructured.FromResource(r)
+//	u := &apiunstructured.Unstructured{Object: obj}
+//
+// The objects hold only the types Unstructured expects, those
+// decoded from JSON, but with int64 rather than float64 for
+// whole numbers, where decoding YAML gives ints, which its
+// deep copies panic on.
+//
+// The annotations of objects are kept as they are, both ways,
+// including those kustomize and kyaml use internally, e.g.
+// config.kubernetes.io/path and the build annotations recording
+// previous names, so that resources converted back and forth
+// may be transformed further as if they never were.
+package unstructured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// FromRNode returns the object of the node.
+func FromRNode(n *yaml.RNode) (map[string]interface{}, error) {
+	b, err := n.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var obj map[string]interface{}
+	if err = d.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if err = normalizeNumbers(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// ToRNode returns the node of the object, erring
+// if its labels or annotations aren't all strings.
+func ToRNode(obj map[string]interface{}) (*yaml.RNode, error) {
+	for _, field := range []string{yaml.LabelsField, yaml.AnnotationsField} {
+		if err := errIfNotStrings(obj, field); err != nil {
+			return nil, err
+		}
+	}
+	return yaml.FromMap(obj)
+}
+
+// FromResource returns the object of the resource.
+func FromResource(r *resource.Resource) (map[string]interface{}, error) {
+	obj, err := FromRNode(r.AsRNode())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", r.CurId(), err)
+	}
+	return obj, nil
+}
+
+// ToResource returns a resource made by the factory
+// from the object.
+func ToResource(
+	rf *resource.Factory, obj map[string]interface{}) (*resource.Resource, error) {
+	n, err := ToRNode(obj)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := rf.ResourcesFromRNodes([]*yaml.RNode{n})
+	if err != nil {
+		return nil, err
+	}
+	if len(rs) != 1 {
+		return nil, fmt.Errorf("the object isn't a resource")
+	}
+	return rs[0], nil
+}
+
+// FromResMap returns the objects of the resources of m, in order.
+func FromResMap(m resmap.ResMap) ([]map[string]interface{}, error) {
+	var objs []map[string]interface{}
+	for _, r := range m.Resources() {
+		obj, err := FromResource(r)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// ToResMap returns a resource map made by the factory
+// from the objects, in order.
+func ToResMap(
+	rmF *resmap.Factory, objs []map[string]interface{}) (resmap.ResMap, error) {
+	m := resmap.New()
+	for i, obj := range objs {
+		r, err := ToResource(rmF.RF(), obj)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %v", i, err)
+		}
+		if err = m.Append(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// normalizeNumbers replaces the json.Numbers in v, a map or
+// slice, by int64s, for whole numbers, or float64s.
+func normalizeNumbers(v interface{}) error {
+	var err error
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if v[k], err = normalizeNumber(e); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			if v[i], err = normalizeNumber(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeNumber(v interface{}) (interface{}, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v, normalizeNumbers(v)
+	}
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+	return n.Float64()
+}
+
+// errIfNotStrings errors if the field of the metadata
+// of the object isn't a map of strings.
+func errIfNotStrings(obj map[string]interface{}, field string) error {
+	meta, _ := obj[yaml.MetadataField].(map[string]interface{})
+	m, ok := meta[field]
+	if !ok || m == nil {
+		return nil
+	}
+	entries, ok := m.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("metadata.%s isn't a map", field)
+	}
+	for k, e := range entries {
+		if _, ok := e.(string); !ok {
+			return fmt.Errorf(
+				"metadata.%s.%s is %v, which isn't a string", field, k, e)
+		}
+	}
+	return nil
+}
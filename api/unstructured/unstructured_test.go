@@ -0,0 +1,103 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package unstructured_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/provider"
+	"sigs.k8s.io/kustomize/api/resmap"
+	. "sigs.k8s.io/kustomize/api/unstructured"
+)
+
+var rmF = resmap.NewFactory(provider.NewDefaultDepProvider().GetResourceFactory())
+
+func TestRoundTrip(t *testing.T) {
+	m, err := rmF.NewResMapFromBytes([]byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    config.kubernetes.io/path: base/deployment.yaml
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web
+        resources:
+          limits:
+            cpu: 0.5
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: flags
+data:
+  a: b
+`))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	r := m.Resources()[0]
+	r.AddNamePrefix("prod-")
+	r.SetName("prod-web")
+	objs, err := FromResMap(m)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name": "prod-web",
+			"annotations": map[string]interface{}{
+				"config.kubernetes.io/path":     "base/deployment.yaml",
+				"config.kubernetes.io/prefixes": "prod-",
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "web",
+							"image": "web",
+							"resources": map[string]interface{}{
+								"limits": map[string]interface{}{"cpu": 0.5},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, objs[0])
+
+	back, err := ToResMap(rmF, objs)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, m.ErrorIfNotEqualLists(back))
+	assert.Equal(t, []string{"prod-"}, back.Resources()[0].GetNamePrefixes())
+}
+
+func TestToRNodeNonStringAnnotation(t *testing.T) {
+	_, err := ToRNode(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":        "flags",
+			"annotations": map[string]interface{}{"enabled": true},
+		},
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t,
+			"metadata.annotations.enabled is true, which isn't a string",
+			err.Error())
+	}
+}
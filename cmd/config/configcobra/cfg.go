@@ -25,6 +25,7 @@ func GetCfg(name string) *cobra.Command {
 	cmd.AddCommand(commands.ListSettersCommand(name))
 	cmd.AddCommand(commands.MergeCommand(name))
 	cmd.AddCommand(commands.Merge3Command(name))
+	cmd.AddCommand(commands.PullCommand(name))
 	cmd.AddCommand(commands.SetCommand(name))
 	cmd.AddCommand(commands.TreeCommand(name))
 
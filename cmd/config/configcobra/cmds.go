@@ -28,6 +28,7 @@ var (
 	ListSetters        = commands.ListSettersCommand
 	Merge              = commands.MergeCommand
 	Merge3             = commands.Merge3Command
+	Pull               = commands.PullCommand
 	RunFn              = commands.RunCommand
 	Set                = commands.SetCommand
 	Sink               = commands.SinkCommand
@@ -0,0 +1,259 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/cmd/config/internal/generateddocs/commands"
+	"sigs.k8s.io/kustomize/cmd/config/runner"
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// defaultPruneFields are the fields set by the server,
+// which don't belong in configuration.
+var defaultPruneFields = []string{
+	"status",
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.deletionTimestamp",
+	"metadata.deletionGracePeriodSeconds",
+	"metadata.managedFields",
+	"metadata.selfLink",
+	`metadata.annotations.kubectl\.kubernetes\.io/last-applied-configuration`,
+	`metadata.annotations.deployment\.kubernetes\.io/revision`,
+	"spec.clusterIP",
+	"spec.clusterIPs",
+}
+
+// GetPullRunner returns a command for Pull.
+func GetPullRunner(name string) *PullRunner {
+	r := &PullRunner{}
+	c := &cobra.Command{
+		Use:     "pull -o DIR",
+		Short:   commands.PullShort,
+		Long:    commands.PullLong,
+		Example: commands.PullExamples,
+		RunE:    r.runE,
+		Args:    cobra.NoArgs,
+	}
+	runner.FixDocs(name, c)
+	c.Flags().StringVarP(&r.Output, "output", "o", "",
+		"the directory to write the Resources to.")
+	c.Flags().StringVar(&r.Kubeconfig, "kubeconfig", "",
+		"the kubeconfig file to use, instead of kubectl's default.")
+	c.Flags().StringVar(&r.Context, "context", "",
+		"the kubeconfig context to use, instead of the current one.")
+	c.Flags().StringVarP(&r.Namespace, "namespace", "n", "",
+		"the namespace to get Resources from, instead of the context's.")
+	c.Flags().BoolVarP(&r.AllNamespaces, "all-namespaces", "A", false,
+		"get Resources from all namespaces.")
+	c.Flags().StringVarP(&r.Selector, "selector", "l", "",
+		"the label selector the Resources must match, e.g. app=foo.")
+	c.Flags().StringSliceVar(&r.Kinds, "kinds",
+		[]string{"all", "configmaps", "serviceaccounts", "ingresses"},
+		"the kinds of Resources to get, as given to kubectl get.")
+	c.Flags().StringSliceVar(&r.PruneFields, "prune-fields", nil,
+		"fields to remove, e.g. spec.replicas, besides those the server sets.")
+	c.Flags().BoolVar(&r.KeepOwned, "keep-owned", false,
+		"keep the Resources owned by others, e.g. the Pods of Deployments.")
+	c.Flags().StringVar(&r.Kubectl, "kubectl", "kubectl",
+		"the kubectl command to run.")
+	r.Command = c
+	return r
+}
+
+func PullCommand(name string) *cobra.Command {
+	return GetPullRunner(name).Command
+}
+
+// PullRunner contains the run function
+type PullRunner struct {
+	Output        string
+	Kubeconfig    string
+	Context       string
+	Namespace     string
+	AllNamespaces bool
+	Selector      string
+	Kinds         []string
+	PruneFields   []string
+	KeepOwned     bool
+	Kubectl       string
+	Command       *cobra.Command
+}
+
+func (r *PullRunner) kubectlArgs() []string {
+	args := []string{"get", strings.Join(r.Kinds, ","), "-o", "yaml"}
+	if r.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", r.Kubeconfig)
+	}
+	if r.Context != "" {
+		args = append(args, "--context", r.Context)
+	}
+	if r.AllNamespaces {
+		args = append(args, "--all-namespaces")
+	} else if r.Namespace != "" {
+		args = append(args, "--namespace", r.Namespace)
+	}
+	if r.Selector != "" {
+		args = append(args, "--selector", r.Selector)
+	}
+	return args
+}
+
+func (r *PullRunner) runE(c *cobra.Command, args []string) error {
+	if r.Output == "" {
+		return errors.Errorf("must specify the directory to write to, with -o")
+	}
+	if len(r.Kinds) == 0 {
+		return errors.Errorf("must specify the kinds of Resources to get")
+	}
+	var paths [][]string
+	for _, f := range append(defaultPruneFields, r.PruneFields...) {
+		p, err := yaml.SplitFieldPath(f)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+	}
+	out, err := runner.RunKubectl(r.Kubectl, r.kubectlArgs())
+	if err != nil {
+		return runner.HandleError(c, err)
+	}
+	if err := os.MkdirAll(r.Output, 0700); err != nil {
+		return runner.HandleError(c, errors.Wrap(err))
+	}
+	return runner.HandleError(c, kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{
+			Reader:                bytes.NewReader(out),
+			OmitReaderAnnotations: true,
+		}},
+		Filters: []kio.Filter{
+			kio.FilterFunc(r.skipOwned),
+			kio.FilterAll(pruneFields(paths)),
+			kio.FilterFunc(r.setFiles),
+			kio.FilterFunc(addKustomization),
+		},
+		Outputs: []kio.Writer{&kio.LocalPackageWriter{PackagePath: r.Output}},
+	}.Execute())
+}
+
+// skipOwned removes the Resources owned by others,
+// unless they are to be kept.
+func (r *PullRunner) skipOwned(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if r.KeepOwned {
+		return nodes, nil
+	}
+	var result []*yaml.RNode
+	for _, n := range nodes {
+		owners, err := n.Pipe(yaml.Lookup("metadata", "ownerReferences"))
+		if err != nil {
+			return nil, err
+		}
+		if owners == nil || len(owners.YNode().Content) == 0 {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+// setFiles names the file of each Resource after its kind and
+// name, in a directory named after its namespace if getting
+// Resources from all namespaces, so that those of the same kind
+// and name in several namespaces don't collide.
+func (r *PullRunner) setFiles(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	var namespaced, others []*yaml.RNode
+	for _, n := range nodes {
+		meta, err := n.GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		if r.AllNamespaces && meta.Namespace != "" {
+			namespaced = append(namespaced, n)
+		} else {
+			others = append(others, n)
+		}
+	}
+	result, err := (&filters.FileSetter{FilenamePattern: "%s/%k_%n.yaml"}).Filter(namespaced)
+	if err != nil {
+		return nil, err
+	}
+	rest, err := (&filters.FileSetter{FilenamePattern: "%k_%n.yaml"}).Filter(others)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, rest...), nil
+}
+
+// pruneFields returns a filter removing the fields at the
+// paths, and then any metadata.annotations left empty.
+func pruneFields(paths [][]string) yaml.Filter {
+	return yaml.FilterFunc(func(n *yaml.RNode) (*yaml.RNode, error) {
+		for _, p := range paths {
+			parent, err := n.Pipe(yaml.Lookup(p[:len(p)-1]...))
+			if err != nil {
+				return nil, err
+			}
+			if parent == nil || parent.YNode().Kind != yaml.MappingNode {
+				continue
+			}
+			if _, err = parent.Pipe(yaml.Clear(p[len(p)-1])); err != nil {
+				return nil, err
+			}
+		}
+		annotations, err := n.Pipe(yaml.Lookup("metadata", "annotations"))
+		if err != nil {
+			return nil, err
+		}
+		if annotations != nil && len(annotations.YNode().Content) == 0 {
+			err = n.PipeE(yaml.Lookup("metadata"), yaml.Clear("annotations"))
+		}
+		return n, err
+	})
+}
+
+// addKustomization adds a kustomization listing
+// the files of the Resources as its resources.
+func addKustomization(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		path, _, err := kioutil.GetFileAnnotations(n)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	k, err := yaml.Parse(fmt.Sprintf(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+metadata:
+  annotations:
+    %s: kustomization.yaml
+    %s: "0"
+`, kioutil.PathAnnotation, kioutil.IndexAnnotation))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		if err = k.PipeE(yaml.SetField("resources", yaml.NewListRNode(files...))); err != nil {
+			return nil, err
+		}
+	}
+	return append(nodes, k), nil
+}
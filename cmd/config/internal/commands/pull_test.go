@@ -0,0 +1,238 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package commands_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/config/internal/commands"
+)
+
+const liveResources = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: foo
+    namespace: foo
+    uid: 1234
+    resourceVersion: "5678"
+    generation: 2
+    creationTimestamp: "2021-01-01T00:00:00Z"
+    labels:
+      app: foo
+    annotations:
+      deployment.kubernetes.io/revision: "2"
+      kubectl.kubernetes.io/last-applied-configuration: "{}"
+  spec:
+    replicas: 3
+    template:
+      spec:
+        containers:
+        - name: foo
+          image: foo:v1
+  status:
+    replicas: 3
+- apiVersion: apps/v1
+  kind: ReplicaSet
+  metadata:
+    name: foo-12345
+    namespace: foo
+    ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: foo
+  spec:
+    replicas: 3
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: foo
+    namespace: foo
+    annotations:
+      team: a
+  spec:
+    clusterIP: 10.0.0.1
+    clusterIPs:
+    - 10.0.0.1
+    ports:
+    - port: 80
+`
+
+// writeFakeKubectl writes a kubectl printing the resources,
+// and recording its args in the file args.
+func writeFakeKubectl(t *testing.T, d, resources string) string {
+	t.Helper()
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(d, "resources.yaml"), []byte(resources), 0600)) {
+		t.FailNow()
+	}
+	kubectl := filepath.Join(d, "kubectl")
+	if !assert.NoError(t, ioutil.WriteFile(kubectl, []byte(`#!/bin/sh
+echo "$@" > `+filepath.Join(d, "args")+`
+cat `+filepath.Join(d, "resources.yaml")+`
+`), 0700)) {
+		t.FailNow()
+	}
+	return kubectl
+}
+
+func TestPullCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kustomize-pull-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+	out := filepath.Join(d, "base")
+
+	r := commands.GetPullRunner("")
+	r.Command.SetArgs([]string{
+		"--kubectl", writeFakeKubectl(t, d, liveResources),
+		"--kubeconfig", "prod.yaml",
+		"-n", "foo",
+		"-l", "app=foo",
+		"--prune-fields", "spec.replicas",
+		"-o", out,
+	})
+	if !assert.NoError(t, r.Command.Execute()) {
+		t.FailNow()
+	}
+
+	args, err := ioutil.ReadFile(filepath.Join(d, "args"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t,
+		"get all,configmaps,serviceaccounts,ingresses -o yaml "+
+			"--kubeconfig prod.yaml --namespace foo --selector app=foo\n",
+		string(args))
+
+	files, err := ioutil.ReadDir(out)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{
+		"deployment_foo.yaml", "kustomization.yaml", "service_foo.yaml"}, names)
+
+	assertFile(t, filepath.Join(out, "kustomization.yaml"), `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- deployment_foo.yaml
+- service_foo.yaml
+`)
+	assertFile(t, filepath.Join(out, "deployment_foo.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: foo
+  labels:
+    app: foo
+spec:
+  template:
+    spec:
+      containers:
+      - name: foo
+        image: foo:v1
+`)
+	assertFile(t, filepath.Join(out, "service_foo.yaml"), `apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  namespace: foo
+  annotations:
+    team: a
+spec:
+  ports:
+  - port: 80
+`)
+}
+
+func TestPullCommandAllNamespaces(t *testing.T) {
+	d, err := ioutil.TempDir("", "kustomize-pull-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+	out := filepath.Join(d, "base")
+
+	r := commands.GetPullRunner("")
+	r.Command.SetArgs([]string{
+		"--kubectl", writeFakeKubectl(t, d, `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: web
+    namespace: dev
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: web
+    namespace: prod
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: ClusterRole
+  metadata:
+    name: web
+`),
+		"-A",
+		"--kinds", "services,clusterroles",
+		"-o", out,
+	})
+	if !assert.NoError(t, r.Command.Execute()) {
+		t.FailNow()
+	}
+
+	assertFile(t, filepath.Join(out, "kustomization.yaml"), `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- clusterrole_web.yaml
+- dev/service_web.yaml
+- prod/service_web.yaml
+`)
+	assertFile(t, filepath.Join(out, "dev", "service_web.yaml"), `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: dev
+`)
+	assertFile(t, filepath.Join(out, "prod", "service_web.yaml"), `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: prod
+`)
+	assertFile(t, filepath.Join(out, "clusterrole_web.yaml"), `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: web
+`)
+}
+
+func TestPullCommandRequiresOutput(t *testing.T) {
+	r := commands.GetPullRunner("")
+	r.Command.SetArgs([]string{"-l", "app=foo"})
+	r.Command.SilenceErrors = true
+	r.Command.SilenceUsage = true
+	assert.EqualError(t, r.Command.Execute(),
+		"must specify the directory to write to, with -o")
+}
+
+func assertFile(t *testing.T, path, expected string) {
+	t.Helper()
+	actual, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, expected, string(actual))
+}
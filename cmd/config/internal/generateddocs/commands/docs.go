@@ -245,6 +245,33 @@ For information on merge rules, run:
 var Merge3Examples = `
     kustomize cfg merge3 --ancestor a/ --from b/ --to c/`
 
+var PullShort = `[Alpha] Export live Resources from a cluster into a local directory.`
+var PullLong = `
+[Alpha] Export live Resources from a cluster into a local directory.
+
+    kustomize cfg pull -o DIR [--selector SELECTOR] [--kinds KINDS]
+
+  DIR:
+    Path to local directory to write the Resources to, one file per
+    Resource, along with a kustomization.yaml listing them.  With
+    --all-namespaces, those of each namespace are written to a
+    subdirectory named after it.
+
+` + "`" + `pull` + "`" + ` gets the Resources from the cluster with kubectl, so uses its
+kubeconfig, context and credentials, and removes the fields the server
+sets, e.g. status and metadata.uid, for the Resources to be usable as
+a kustomize base.  More fields may be removed with --prune-fields, as
+dot delimited paths.  Resources owned by others, e.g. the Pods of a
+Deployment, are skipped unless --keep-owned is set.
+`
+var PullExamples = `
+    # export the Resources of an application
+    kustomize cfg pull --selector app=foo -n foo -o base/
+
+    # export from another cluster, also removing the replicas
+    kustomize cfg pull --kubeconfig ~/.kube/prod --selector app=foo \
+      --prune-fields spec.replicas -o base/`
+
 var RunFnsShort = `[Alpha] Reoncile config functions to Resources.`
 var RunFnsLong = `
 [Alpha] Reconcile config functions to Resources.
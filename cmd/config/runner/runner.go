@@ -4,9 +4,11 @@
 package runner
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"strings"
 
 	"github.com/go-errors/errors"
@@ -139,6 +141,21 @@ func FixDocs(new string, c *cobra.Command) {
 	c.Example = strings.ReplaceAll(c.Example, cmdName, new)
 }
 
+// RunKubectl runs the kubectl executable with the args, returning
+// its output, or an error with what it wrote to stderr.
+func RunKubectl(kubectl string, args []string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(kubectl, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Errorf(
+			"running %s %s: %v: %s", kubectl, strings.Join(args, " "),
+			err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
 // containsString returns true if slice contains s
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
@@ -31,6 +31,11 @@ function runTest {
       # TODO: make work for non-linux
       echo "Not on linux or on remote CI; skipping $file"
     fi
+  elif grep -q "// +build goplugins" "$file"; then
+    # Loading Go plugins is only compiled into
+    # builds with the goplugins tag.
+    go test -v -tags=goplugins $file
+    code=$?
   else
     go test -v $file
     code=$?
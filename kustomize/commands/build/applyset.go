@@ -0,0 +1,218 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/provider"
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/resmap"
+)
+
+// The labels and annotations of applysets, per kubectl's
+// implementation of KEP-3659.  Those listing the kinds of the
+// members are pinned to the one kubectl reads since v1.27,
+// before which it read applyset.kubernetes.io/contains-group-resources,
+// whatever --applyset-tooling is.
+const (
+	applySetParentIdLabel        = "applyset.kubernetes.io/id"
+	applySetPartOfLabel          = "applyset.kubernetes.io/part-of"
+	applySetToolingAnnotation    = "applyset.kubernetes.io/tooling"
+	applySetGroupKindsAnnotation = "applyset.kubernetes.io/contains-group-kinds"
+	applySetNamespacesAnnotation = "applyset.kubernetes.io/additional-namespaces"
+)
+
+// maxApplySetNameLength is the length the names of applyset
+// parents are cut to, that of a DNS label.
+const maxApplySetNameLength = 63
+
+// minApplySetKubectlMinor is the minor version of the first
+// kubectl reading applySetGroupKindsAnnotation.
+const minApplySetKubectlMinor = 27
+
+// AddFlagApplySets adds the --applysets flag, and those
+// setting the namespace and tooling of the applysets.
+func AddFlagApplySets(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.applySet.enabled,
+		"applysets",
+		false,
+		"Make the output of each DIR an applyset, as kubectl apply --applyset "+
+			"does, adding a ConfigMap parenting it, named applyset-<DIR>-<hash "+
+			"of DIR>, so that each can be pruned on its own.")
+	set.StringVar(
+		&theFlags.applySet.namespace,
+		"applyset-namespace",
+		"",
+		"The namespace of the applyset parents. Defaults to the namespace "+
+			"of the resources of each DIR, if they have only one.")
+	set.StringVar(
+		&theFlags.applySet.tooling,
+		"applyset-tooling",
+		"kubectl/v1.27.0",
+		"The tooling annotation of the applyset parents, naming the tool "+
+			"that is to apply and prune them, as tool/version. If kubectl, it "+
+			"must be v1.27 or later.")
+}
+
+func validateFlagApplySets() error {
+	tooling := theFlags.applySet.tooling
+	if !theFlags.applySet.enabled || !strings.HasPrefix(tooling, "kubectl/") {
+		return nil
+	}
+	version := strings.Split(strings.TrimPrefix(tooling, "kubectl/v"), ".")
+	if len(version) < 2 {
+		return fmt.Errorf(
+			"--applyset-tooling %s must name the version of kubectl, e.g. kubectl/v1.27.0",
+			tooling)
+	}
+	major, err1 := strconv.Atoi(version[0])
+	minor, err2 := strconv.Atoi(version[1])
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf(
+			"--applyset-tooling %s must name the version of kubectl, e.g. kubectl/v1.27.0",
+			tooling)
+	}
+	if major == 1 && minor < minApplySetKubectlMinor {
+		return fmt.Errorf(
+			"--applyset-tooling %s names a kubectl older than v1.%d, which "+
+				"doesn't read the kinds of applysets from %s",
+			tooling, minApplySetKubectlMinor, applySetGroupKindsAnnotation)
+	}
+	return nil
+}
+
+// addApplySet makes the resources built from path an applyset,
+// labeling them as its members, and appending its parent.
+func addApplySet(m resmap.ResMap, path string) error {
+	name := applySetName(path)
+	namespace, others, err := applySetNamespaces(m)
+	if err != nil {
+		return fmt.Errorf("the applyset of %s: %v", path, err)
+	}
+	id := applySetId(name, namespace, "ConfigMap", "")
+	groupKinds := make(map[string]bool)
+	for _, r := range m.Resources() {
+		labels := r.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[applySetPartOfLabel] = id
+		r.SetLabels(labels)
+		groupKinds[groupKind(r.GetGvk())] = true
+	}
+	parent := provider.NewDefaultDepProvider().GetResourceFactory().FromMap(
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					applySetParentIdLabel: id,
+				},
+			},
+		})
+	annotations := map[string]string{
+		applySetToolingAnnotation:    theFlags.applySet.tooling,
+		applySetGroupKindsAnnotation: strings.Join(sortedKeys(groupKinds), ","),
+	}
+	if len(others) > 0 {
+		annotations[applySetNamespacesAnnotation] = strings.Join(others, ",")
+	}
+	parent.SetAnnotations(annotations)
+	return m.Append(parent)
+}
+
+// applySetName returns the name of the parent of the applyset
+// of the resources built from path: the path as a DNS name, cut
+// short if need be, followed by a hash of the path, so that those
+// of paths with the same DNS name, e.g. a/b and a-b, don't collide.
+func applySetName(path string) string {
+	hash := sha256.Sum256([]byte(filepath.ToSlash(filepath.Clean(path))))
+	suffix := "-" + hex.EncodeToString(hash[:])[:10]
+	name := dnsName(rootDirName(path))
+	if max := maxApplySetNameLength - len("applyset-") - len(suffix); len(name) > max {
+		name = strings.TrimRight(name[:max], "-.")
+	}
+	return "applyset-" + name + suffix
+}
+
+// applySetNamespaces returns the namespace of the parent of the
+// applyset of the resources, and the other namespaces they're in.
+// Resources without a namespace are taken to be in the parent's.
+func applySetNamespaces(m resmap.ResMap) (string, []string, error) {
+	inNamespaces := make(map[string]bool)
+	for _, r := range m.Resources() {
+		if ns := r.GetNamespace(); ns != "" && r.GetGvk().IsNamespaceableKind() {
+			inNamespaces[ns] = true
+		}
+	}
+	namespaces := sortedKeys(inNamespaces)
+	namespace := theFlags.applySet.namespace
+	switch {
+	case namespace != "":
+	case len(namespaces) == 0:
+		namespace = "default"
+	case len(namespaces) == 1:
+		namespace = namespaces[0]
+	default:
+		return "", nil, fmt.Errorf(
+			"resources are in namespaces %s, so --applyset-namespace must be set",
+			strings.Join(namespaces, ", "))
+	}
+	delete(inNamespaces, namespace)
+	return namespace, sortedKeys(inNamespaces), nil
+}
+
+// applySetId returns the id of the applyset with the given
+// parent, as kubectl computes it.
+func applySetId(name, namespace, kind, group string) string {
+	hash := sha256.Sum256(
+		[]byte(strings.Join([]string{name, namespace, kind, group}, ".")))
+	return fmt.Sprintf(
+		"applyset-%s-v1", base64.RawURLEncoding.EncodeToString(hash[:]))
+}
+
+// groupKind returns the group and kind of gvk as kubectl
+// writes them, e.g. Deployment.apps, or Service.
+func groupKind(gvk resid.Gvk) string {
+	if gvk.Group == "" {
+		return gvk.Kind
+	}
+	return gvk.Kind + "." + gvk.Group
+}
+
+// dnsName returns name as a DNS subdomain name, as object
+// names must be: lower case, with '-' for other characters.
+func dnsName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	return strings.Trim(name, "-.")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplySetName(t *testing.T) {
+	if applySetName("a/b") == applySetName("a-b") {
+		t.Fatalf("the parents of a/b and a-b are both named %s", applySetName("a/b"))
+	}
+	if applySetName("./a/b/") != applySetName("a/b") {
+		t.Fatalf("the parents of ./a/b/ and a/b are named %s and %s",
+			applySetName("./a/b/"), applySetName("a/b"))
+	}
+	long := strings.Repeat("overlays/", 20) + "prod"
+	name := applySetName(long)
+	if len(name) > maxApplySetNameLength {
+		t.Fatalf("%s is longer than %d", name, maxApplySetNameLength)
+	}
+	if !strings.HasPrefix(name, "applyset-overlays-overlays-") {
+		t.Fatalf("%s doesn't start with the path", name)
+	}
+	if applySetName(long+"x") == name {
+		t.Fatalf("the parents of paths cut short to the same name collide")
+	}
+}
@@ -4,31 +4,86 @@
 package build
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/types"
 )
 
 var theArgs struct {
-	kustomizationPath string
+	kustomizationPaths []string
 }
 
 var theFlags struct {
 	outputPath string
 	enable     struct {
-		plugins        bool
-		managedByLabel bool
-		helm           bool
-	}
-	helmCommand    string
-	loadRestrictor string
-	reorderOutput  string
-	fnOptions      types.FnPluginLoadingOptions
+		plugins            bool
+		managedByLabel     bool
+		helm               bool
+		requirements       bool
+		tlsSecretGenerator bool
+	}
+	helmCommand         string
+	loadRestrictor      string
+	reorderOutput       string
+	resourcesFromStdin  bool
+	immutableAgainst    string
+	onlyDelta           bool
+	against             string
+	strictDeprecations  bool
+	kubeVersion         string
+	disabledBuiltins    []string
+	usageReport         string
+	warnOnFrozenChanges bool
+	logCreatedFields    bool
+	keepComments        bool
+	applyDefaults       bool
+	stripDefaults       bool
+	keepDefaultsOf      []string
+	checkPlaceholders   string
+	allowedPlaceholders []string
+	clusterReferrals    string
+	strictGenNames      bool
+	allowNonKRM         bool
+	outputFormat        string
+	chunkSize           int
+	parameters          []string
+	selectQuery         string
+	maxParallelism      int
+	maxDepth            int
+	parallel            int
+	quietErrors         bool
+	progress            bool
+	debugDump           string
+	provenanceManifest  string
+	fnOptions           types.FnPluginLoadingOptions
+	applySet            struct {
+		enabled   bool
+		namespace string
+		tooling   string
+	}
+	cache struct {
+		enabled bool
+		dir     string
+	}
+}
+
+// theClones, if not nil, is the cache of the clones of
+// remote bases that builds share, e.g. those a daemon makes.
+var theClones *krusty.CloneCache
+
+// UseCloneCache makes the builds that follow clone remote
+// bases through the cache, or, if it's nil, on their own.
+func UseCloneCache(c *krusty.CloneCache) {
+	theClones = c
 }
 
 type Help struct {
@@ -48,7 +103,17 @@ The DIR argument must be a path to a directory containing
 '%s', or a git repository URL with a path suffix
 specifying same with respect to the repository root.
 If DIR is omitted, '.' is assumed.
-`, fN, fN),
+
+Several DIRs may be given, to build each of them in one run,
+sharing the clones of the remote bases they use.  Their output
+is written as one stream, or, if the --output flag names a
+directory, to a subdirectory of it per DIR.  If any fails, none
+is written, and the failures are summarized.
+
+A failed build exits with %d for errors in the configuration,
+%d for failures to fetch remote content, which retrying may get
+past, and %d for errors of kustomize itself.
+`, fN, fN, ExitConfigError, ExitInfrastructureError, ExitInternalError),
 		Example: fmt.Sprintf(`# Build the current working directory
   %s %s
 
@@ -57,7 +122,27 @@ If DIR is omitted, '.' is assumed.
 
 # Build from github
   %s %s https://github.com/kubernetes-sigs/kustomize.git/examples/helloWorld?ref=v1.0.6
-`, pgmName, cmdName, pgmName, cmdName, pgmName, cmdName),
+
+# Add resources rendered by another tool to the build
+  helm template ./chart | %s %s --resources-from-stdin ./overlay
+
+# Build several overlays, four at a time, into out/staging and out/production
+  %s %s overlays/staging overlays/production --parallel 4 -o out
+
+# Build an overlay whose namespace is ${TENANT} for the tenant acme
+  %s %s overlays/tenant --set TENANT=acme
+
+# Output only what changed in prod since the build saved in prod.yaml
+  %s %s overlays/prod --only-delta --against prod.yaml
+
+# Output only the Deployments of the apps/web base, not fetching the others
+  %s %s umbrella --select '@source=../apps/web && kind=Deployment'
+
+# Reuse the clones of remote bases that earlier builds kept
+  %s %s overlays/prod --cache
+`, pgmName, cmdName, pgmName, cmdName, pgmName, cmdName, pgmName, cmdName,
+			pgmName, cmdName, pgmName, cmdName, pgmName, cmdName, pgmName, cmdName,
+			pgmName, cmdName),
 	}
 }
 
@@ -74,58 +159,191 @@ func NewCmdBuild(
 			if err := Validate(args); err != nil {
 				return err
 			}
-			k := krusty.MakeKustomizer(
-				HonorKustomizeFlags(krusty.MakeDefaultOptions()),
-			)
-			m, err := k.Run(fSys, theArgs.kustomizationPath)
+			releaseClones, err := honorFlagCache()
+			if err != nil {
+				return err
+			}
+			defer releaseClones()
+			if len(theArgs.kustomizationPaths) > 1 {
+				return buildRoots(fSys, writer, cmd.ErrOrStderr())
+			}
+			kOpts := HonorKustomizeFlags(krusty.MakeDefaultOptions())
+			clearProgress := honorFlagProgress(kOpts, cmd.ErrOrStderr())
+			k := krusty.MakeKustomizer(kOpts)
+			m, err := run(k, fSys, cmd.InOrStdin())
+			clearProgress()
+			writeWarnings(cmd.ErrOrStderr(), kOpts.Warnings)
 			if err != nil {
 				return err
 			}
+			if theFlags.applySet.enabled {
+				if err = addApplySet(m, theArgs.kustomizationPaths[0]); err != nil {
+					return err
+				}
+			}
+			if kOpts.UsageReport != nil {
+				if err = writeUsageReport(fSys, kOpts.UsageReport); err != nil {
+					return err
+				}
+			}
+			if kOpts.EnvCapture != nil {
+				if err = writeProvenanceManifest(fSys, kOpts.EnvCapture); err != nil {
+					return err
+				}
+			}
+			if err = honorFlagImmutableAgainst(fSys, m); err != nil {
+				return err
+			}
+			var deleted []resid.ResId
+			if theFlags.onlyDelta {
+				m, deleted, err = honorFlagOnlyDelta(fSys, m)
+				if err != nil {
+					return err
+				}
+			}
 			if theFlags.outputPath != "" && fSys.IsDir(theFlags.outputPath) {
+				for _, f := range []struct {
+					name string
+					set  bool
+				}{
+					{flagChunkSizeName, theFlags.chunkSize > 0},
+					{flagOnlyDeltaName, theFlags.onlyDelta},
+				} {
+					if f.set {
+						return fmt.Errorf(
+							"--%s requires --output to name a file, not a directory",
+							f.name)
+					}
+				}
 				// Ignore writer; write to o.outputPath directly.
 				return MakeWriter(fSys).WriteIndividualFiles(
 					theFlags.outputPath, m)
 			}
-			yml, err := m.AsYaml()
-			if err != nil {
+			if theFlags.chunkSize > 0 {
+				return writeChunks(fSys, k, m)
+			}
+			var out bytes.Buffer
+			if err = k.Emit(&out, m, theFlags.outputFormat); err != nil {
+				return err
+			}
+			if err = writeDeletions(&out, deleted); err != nil {
 				return err
 			}
 			if theFlags.outputPath != "" {
 				// Ignore writer; write to o.outputPath directly.
-				return fSys.WriteFile(theFlags.outputPath, yml)
+				return writeOutputFile(fSys, theFlags.outputPath, out.Bytes())
 			}
-			_, err = writer.Write(yml)
+			_, err = writer.Write(out.Bytes())
 			return err
 		},
 	}
 	AddFlagOutputPath(cmd.Flags())
+	AddFlagOutputFormat(cmd.Flags())
+	AddFlagChunkSize(cmd.Flags())
 	AddFunctionBasicsFlags(cmd.Flags())
 	AddFlagLoadRestrictor(cmd.Flags())
 	AddFlagEnablePlugins(cmd.Flags())
 	AddFlagReorderOutput(cmd.Flags())
 	AddFlagEnableManagedbyLabel(cmd.Flags())
 	AddFlagEnableHelm(cmd.Flags())
+	AddFlagEnableRequirements(cmd.Flags())
+	AddFlagEnableTLSSecretGenerator(cmd.Flags())
+	AddFlagResourcesFromStdin(cmd.Flags())
+	AddFlagImmutableAgainst(cmd.Flags())
+	AddFlagOnlyDelta(cmd.Flags())
+	AddFlagStrictDeprecations(cmd.Flags())
+	AddFlagKubeVersion(cmd.Flags())
+	AddFlagDisableBuiltin(cmd.Flags())
+	AddFlagUsageReport(cmd.Flags())
+	AddFlagWarnOnFrozenChanges(cmd.Flags())
+	AddFlagLogCreatedFields(cmd.Flags())
+	AddFlagKeepComments(cmd.Flags())
+	AddFlagApplyDefaults(cmd.Flags())
+	AddFlagStripDefaults(cmd.Flags())
+	AddFlagCheckPlaceholders(cmd.Flags())
+	AddFlagClusterScopedReferrals(cmd.Flags())
+	AddFlagStrictGeneratedNames(cmd.Flags())
+	AddFlagAllowNonKRM(cmd.Flags())
+	AddFlagMaxParallelism(cmd.Flags())
+	AddFlagMaxDepth(cmd.Flags())
+	AddFlagParallel(cmd.Flags())
+	AddFlagQuietErrors(cmd.Flags())
+	AddFlagProgress(cmd.Flags())
+	AddFlagDebugDump(cmd.Flags())
+	AddFlagProvenanceManifest(cmd.Flags())
+	AddFlagCache(cmd.Flags())
+	AddFlagSet(cmd.Flags())
+	AddFlagSelect(cmd.Flags())
+	AddFlagApplySets(cmd.Flags())
 	return cmd
 }
 
+func run(k *krusty.Kustomizer, fSys filesys.FileSystem, in io.Reader) (resmap.ResMap, error) {
+	if !theFlags.resourcesFromStdin {
+		return k.Run(fSys, theArgs.kustomizationPaths[0])
+	}
+	input, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	return k.RunWithInput(fSys, theArgs.kustomizationPaths[0], input)
+}
+
 // Validate validates build command args and flags.
 func Validate(args []string) error {
-	if len(args) > 1 {
-		return fmt.Errorf(
-			"specify one path to " +
-				konfig.DefaultKustomizationFileName())
-	}
 	if len(args) == 0 {
-		theArgs.kustomizationPath = filesys.SelfDir
+		theArgs.kustomizationPaths = []string{filesys.SelfDir}
 	} else {
-		theArgs.kustomizationPath = args[0]
+		theArgs.kustomizationPaths = args
+	}
+	if len(args) > 1 {
+		if err := validateRoots(); err != nil {
+			return err
+		}
+	}
+	if err := validateFlagOutputFormat(); err != nil {
+		return err
+	}
+	if err := validateFlagChunkSize(); err != nil {
+		return err
+	}
+	if err := validateFlagSet(); err != nil {
+		return err
+	}
+	if err := validateFlagSelect(); err != nil {
+		return err
+	}
+	if err := validateFlagOnlyDelta(); err != nil {
+		return err
 	}
 	if err := validateFlagLoadRestrictor(); err != nil {
 		return err
 	}
+	if err := validateFlagDefaults(); err != nil {
+		return err
+	}
+	if err := validateFlagCheckPlaceholders(); err != nil {
+		return err
+	}
+	if err := validateFlagClusterScopedReferrals(); err != nil {
+		return err
+	}
+	if err := validateFlagApplySets(); err != nil {
+		return err
+	}
 	return validateFlagReorderOutput()
 }
 
+// writeWarnings writes the warnings of the builds, if any.
+func writeWarnings(w io.Writer, warnings *types.Warnings) {
+	if warnings == nil {
+		return
+	}
+	for _, warning := range warnings.List {
+		fmt.Fprintln(w, warning)
+	}
+}
+
 // HonorKustomizeFlags feeds command line data to the krusty options.
 // Flags and such are held in private package variables.
 func HonorKustomizeFlags(kOpts *krusty.Options) *krusty.Options {
@@ -139,6 +357,52 @@ func HonorKustomizeFlags(kOpts *krusty.Options) *krusty.Options {
 		kOpts.PluginConfig.HelmConfig.Enabled = theFlags.enable.helm
 	}
 	kOpts.PluginConfig.HelmConfig.Command = theFlags.helmCommand
+	kOpts.PluginConfig.TLSSecretGeneratorEnabled = theFlags.enable.tlsSecretGenerator
+	kOpts.EnableRequirements = theFlags.enable.requirements
+	// Validated by Validate.
+	kOpts.Parameters, _ = getFlagSetValue()
+	kOpts.Select, _ = getFlagSelectValue()
+	// The experimental features the flags use, at the versions
+	// they were written against, to bump, once the flags are
+	// checked, with each incompatible change to a feature.
+	kOpts.Experimental = &krusty.ExperimentalOptions{
+		Understands: map[krusty.ExperimentalFeature]int{
+			krusty.ExperimentalParameters:             1,
+			krusty.ExperimentalClusterScopedReferrals: 1,
+			krusty.ExperimentalDefaults:               1,
+			krusty.ExperimentalKeepComments:           1,
+			krusty.ExperimentalParallelPatches:        1,
+			krusty.ExperimentalNonKRM:                 1,
+			krusty.ExperimentalSelect:                 1,
+			krusty.ExperimentalDiskClones:             1,
+		},
+	}
 	kOpts.AddManagedbyLabel = isManagedByLabelEnabled()
+	kOpts.StrictDeprecations = theFlags.strictDeprecations
+	kOpts.KubeVersion = theFlags.kubeVersion
+	kOpts.DisabledBuiltins = theFlags.disabledBuiltins
+	kOpts.WarnOnFrozenChanges = theFlags.warnOnFrozenChanges
+	kOpts.LogCreatedFields = theFlags.logCreatedFields
+	kOpts.KeepComments = theFlags.keepComments
+	kOpts.ApplyDefaults = theFlags.applyDefaults
+	kOpts.StripDefaults = theFlags.stripDefaults
+	kOpts.KeepDefaultsOf = theFlags.keepDefaultsOf
+	kOpts.PlaceholderCheck = krusty.PlaceholderCheck(theFlags.checkPlaceholders)
+	kOpts.AllowedPlaceholders = theFlags.allowedPlaceholders
+	kOpts.ClusterScopedReferrals = krusty.ClusterScopedReferrals(
+		theFlags.clusterReferrals)
+	kOpts.StrictGeneratedNames = theFlags.strictGenNames
+	kOpts.AllowNonKRM = theFlags.allowNonKRM
+	kOpts.MaxParallelism = theFlags.maxParallelism
+	kOpts.MaxDepth = theFlags.maxDepth
+	kOpts.Clones = theClones
+	kOpts.DebugDumpDir = theFlags.debugDump
+	kOpts.Warnings = &types.Warnings{}
+	if theFlags.usageReport != "" {
+		kOpts.UsageReport = &types.UsageReport{}
+	}
+	if theFlags.provenanceManifest != "" {
+		kOpts.EnvCapture = &types.EnvCapture{}
+	}
 	return kOpts
 }
@@ -5,10 +5,14 @@ package build_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
+	"github.com/spf13/pflag"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/konfig"
 	"sigs.k8s.io/kustomize/api/provenance"
@@ -197,6 +201,843 @@ type: Opaque
 	}
 }
 
+func TestBuildWithResourcesFromStdin(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: foo-
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.SetIn(strings.NewReader(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+`))
+	cmd.Flags().Set("resources-from-stdin", "true")
+	defer cmd.Flags().Set("resources-from-stdin", "false")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo-cm
+`
+	if buffy.String() != expected {
+		t.Fatalf("Expected output:\n%s\n But got output:\n%s", expected, buffy)
+	}
+}
+
+func TestBuildStrictDeprecations(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+bases:
+- base
+`))
+	fSys.WriteFile("base/"+konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: foo-
+`))
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Flags().Set("strict-deprecations", "true")
+	defer cmd.Flags().Set("strict-deprecations", "false")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "'bases' is deprecated") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildKubeVersion(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+kubeVersion: "1.20"
+`))
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Flags().Set("kube-version", "twenty")
+	defer cmd.Flags().Set("kube-version", "")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "invalid Kubernetes version 'twenty'") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildEnableRequirements(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("app/"+konfig.DefaultKustomizationFileName(), []byte(`
+requirements:
+  loadRestrictor: LoadRestrictionsNone
+resources:
+- ../shared/service.yaml
+`))
+	fSys.WriteFile("shared/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	// The requirements aren't enabled by default.
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	err := cmd.RunE(cmd, []string{"app"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(),
+		"requires --load-restrictor LoadRestrictionsNone, which the build doesn't enable") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd.Flags().Set("enable-requirements", "true")
+	defer cmd.Flags().Set("enable-requirements", "false")
+	if err := cmd.RunE(cmd, []string{"app"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildEnableTLSSecretGenerator(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+tlsSecretGenerator:
+- name: web-tls
+  dnsNames:
+  - web
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	err := cmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(),
+		"must specify --enable-tls-secret-generator") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd.Flags().Set("enable-tls-secret-generator", "true")
+	defer cmd.Flags().Set("enable-tls-secret-generator", "false")
+	if err := cmd.RunE(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffy.String(), "type: kubernetes.io/tls") {
+		t.Fatalf("unexpected output: %s", buffy.String())
+	}
+}
+
+func TestBuildSet(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+namespace: ${TENANT}
+resources:
+- service.yaml
+`))
+	fSys.WriteFile("service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	defer cmd.Flags().Lookup("set").Value.(pflag.SliceValue).Replace(nil)
+	cmd.Flags().Set("set", "TENANT=acme")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffy.String(), "namespace: acme\n") {
+		t.Fatalf("expected the namespace acme:\n%s", buffy)
+	}
+	cmd.Flags().Set("set", "TENANT")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(),
+		"--set TENANT isn't of the form NAME=VALUE") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildSelect(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- web
+- missing
+`))
+	fSys.WriteFile("web/kustomization.yaml", []byte(`
+resources:
+- service.yaml
+`))
+	fSys.WriteFile("web/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	defer cmd.Flags().Set("select", "")
+	cmd.Flags().Set("select", "@source=web")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffy.String(), "name: web\n") {
+		t.Fatalf("expected the Service web:\n%s", buffy)
+	}
+	cmd.Flags().Set("select", "@source=")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(),
+		"--select: query \"@source=\" at offset 8: expected a value") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildCache(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: p-
+`))
+	dir := t.TempDir()
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	defer cmd.Flags().Set("cache", "false")
+	defer cmd.Flags().Set("cache-dir", "")
+	cmd.Flags().Set("cache", "true")
+	cmd.Flags().Set("cache-dir", dir)
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(CacheClonesDir(dir)); err != nil || !fi.IsDir() {
+		t.Fatalf("expected the clones directory to be made: %v", err)
+	}
+}
+
+func TestBuildDisableBuiltin(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: p-
+`))
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.Flags().Set("disable-builtin", "PrefixSuffixTransformer")
+	defer cmd.Flags().Lookup("disable-builtin").Value.(pflag.SliceValue).Replace(nil)
+	err := cmd.RunE(cmd, []string{})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(),
+		"requires the builtin PrefixSuffixTransformer, which is disabled") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildUsageReport(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+bases:
+- base
+`))
+	fSys.WriteFile("base/"+konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: foo-
+`))
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.Flags().Set("usage-report", "usage.json")
+	defer cmd.Flags().Set("usage-report", "")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := fSys.ReadFile("usage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "kustomizations": 2,
+  "components": 0,
+  "fields": {
+    "bases": 1,
+    "namePrefix": 1
+  },
+  "deprecatedFields": {
+    "bases": 1
+  },
+  "builtins": {
+    "HashTransformer": 1,
+    "LegacyOrderTransformer": 1,
+    "PrefixSuffixTransformer": 1
+  }
+}
+`
+	if string(actual) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, actual)
+	}
+}
+
+func TestBuildProgress(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- base
+`))
+	fSys.WriteFile("base/"+konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- sa.yaml
+`))
+	fSys.WriteFile("base/sa.yaml", []byte(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+`))
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), stdout)
+	cmd.SetErr(stderr)
+	cmd.Flags().Set("progress", "true")
+	defer cmd.Flags().Set("progress", "false")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(stderr.String(), "\r")
+	if !strings.Contains(stderr.String(), "/base: resources done, 1 resources") {
+		t.Fatalf("unexpected progress %q", stderr)
+	}
+	if last := lines[len(lines)-1]; last != "" {
+		t.Fatalf("expected the status line cleared, but got %q", last)
+	}
+	expected := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+`
+	if stdout.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, stdout)
+	}
+}
+
+func TestBuildDebugDump(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: p-
+resources:
+- sa.yaml
+`))
+	fSys.WriteFile("sa.yaml", []byte(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+`))
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.Flags().Set("debug-dump", "/dump")
+	defer cmd.Flags().Set("debug-dump", "")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := fSys.Glob("/dump/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"/dump/001-_-resources.yaml",
+		"/dump/002-_-PrefixSuffixTransformer.yaml",
+	}
+	if fmt.Sprint(files) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v but got %v", expected, files)
+	}
+}
+
+func TestBuildProvenanceManifest(t *testing.T) {
+	os.Setenv("KUSTOMIZE_TEST_RELEASE", "2021.10")
+	defer os.Unsetenv("KUSTOMIZE_TEST_RELEASE")
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+envSources:
+- KUSTOMIZE_TEST_RELEASE
+configMapGenerator:
+- name: release
+  envVars:
+  - KUSTOMIZE_TEST_RELEASE
+`))
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.Flags().Set("provenance-manifest", "provenance.json")
+	defer cmd.Flags().Set("provenance-manifest", "")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := fSys.ReadFile("provenance.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := provenance.GetProvenance()
+	expected := fmt.Sprintf(`{
+  "kustomize": {
+    "version": "%s",
+    "gitCommit": "%s",
+    "buildDate": "%s",
+    "goOs": "%s",
+    "goArch": "%s"
+  },
+  "env": {
+    "KUSTOMIZE_TEST_RELEASE": "2021.10"
+  }
+}
+`, p.Version, p.GitCommit, p.BuildDate, p.GoOs, p.GoArch)
+	if string(actual) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, actual)
+	}
+}
+
+func TestBuildWarnOnFrozenChanges(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: p-
+resources:
+- sa.yaml
+`))
+	fSys.WriteFile("sa.yaml", []byte(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+  annotations:
+    kustomize.config.k8s.io/frozen: "true"
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	err := cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "but a transformer changed it") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd.Flags().Set("warn-on-frozen-changes", "true")
+	defer cmd.Flags().Set("warn-on-frozen-changes", "false")
+	if err = cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+`
+	if buffy.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, buffy)
+	}
+}
+
+func TestBuildImmutableAgainst(t *testing.T) {
+	const previous = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+spec:
+  storageClassName: fast
+  resources:
+    requests:
+      storage: 10Gi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  clusterIP: 10.0.0.1
+`
+	testCases := map[string]struct {
+		resources string
+		errMsgs   []string
+	}{
+		"unchanged": {
+			resources: previous,
+		},
+		"allowed": {
+			resources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+spec:
+  storageClassName: fast
+  resources:
+    requests:
+      storage: 20480Mi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+		},
+		"violations": {
+			resources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+      tier: frontend
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+spec:
+  storageClassName: slow
+  resources:
+    requests:
+      storage: 5Gi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  clusterIP: 10.0.0.2
+`,
+			errMsgs: []string{
+				"build changes immutable fields relative to previous.yaml",
+				"apps_v1_Deployment|~X|web: field spec.selector",
+				"~G_v1_PersistentVolumeClaim|~X|data: field spec.storageClassName",
+				"~G_v1_PersistentVolumeClaim|~X|data: field spec.resources.requests.storage",
+				"was: 10Gi\n    now: 5Gi",
+				"~G_v1_Service|~X|web: field spec.clusterIP",
+			},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			fSys := filesys.MakeFsInMemory()
+			fSys.WriteFile("previous.yaml", []byte(previous))
+			fSys.WriteFile("resources.yaml", []byte(tc.resources))
+			fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- resources.yaml
+`))
+			buffy := new(bytes.Buffer)
+			cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+			cmd.Flags().Set("immutable-against", "previous.yaml")
+			defer cmd.Flags().Set("immutable-against", "")
+			err := cmd.RunE(cmd, []string{})
+			if len(tc.errMsgs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			for _, msg := range tc.errMsgs {
+				if !strings.Contains(err.Error(), msg) {
+					t.Fatalf("expected error to contain %q, but got:\n%v", msg, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildImmutableAgainstBase(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("base/kustomization.yaml", []byte(`
+resources:
+- deployment.yaml
+`))
+	fSys.WriteFile("base/deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+`))
+	fSys.WriteFile("overlay/kustomization.yaml", []byte(`
+resources:
+- ../base
+labels:
+- pairs:
+    tier: frontend
+  includeSelectors: true
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("immutable-against", "base")
+	defer cmd.Flags().Set("immutable-against", "")
+	err := cmd.RunE(cmd, []string{"overlay"})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, msg := range []string{
+		"build changes immutable fields relative to base",
+		"apps_v1_Deployment|~X|web: field spec.selector",
+	} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("expected error to contain %q, but got:\n%v", msg, err)
+		}
+	}
+}
+
+func TestBuildWithPathAnnotations(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- resources.yaml
+`))
+	fSys.WriteFile("resources.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  annotations:
+    config.kubernetes.io/path: app/web.yaml
+    config.kubernetes.io/index: "1"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    config.kubernetes.io/path: app/web.yaml
+    config.kubernetes.io/index: "0"
+    note: kept
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unplaced
+`))
+	fSys.Mkdir("out")
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("output", "out")
+	defer cmd.Flags().Set("output", "")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := fSys.ReadFile("out/app/web.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    note: kept
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+	if string(data) != expected {
+		t.Fatalf("Expected:\n%s\nBut got:\n%s\n", expected, string(data))
+	}
+	if !fSys.Exists("out/v1_configmap_unplaced.yaml") {
+		t.Fatalf("expected unannotated resource in its default file")
+	}
+	if fSys.Exists("out/apps_v1_deployment_web.yaml") {
+		t.Fatalf("unexpected default file for annotated resource")
+	}
+}
+
+func TestBuildWithEscapingPathAnnotation(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- resources.yaml
+`))
+	fSys.WriteFile("resources.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  annotations:
+    config.kubernetes.io/path: ../web.yaml
+`))
+	fSys.Mkdir("out")
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("output", "out")
+	defer cmd.Flags().Set("output", "")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "must be a path within the output directory") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// loadRoots writes the kustomizations a and b/c,
+// both of which have the configmap.yaml of base.
+func loadRoots(fSys filesys.FileSystem) {
+	fSys.WriteFile("base/"+konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- configmap.yaml
+`))
+	fSys.WriteFile("base/configmap.yaml", []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+`))
+	fSys.WriteFile("a/"+konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: a-
+resources:
+- ../base
+`))
+	fSys.WriteFile("b/c/"+konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: c-
+resources:
+- ../../base
+`))
+}
+
+func TestBuildRoots(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	loadRoots(fSys)
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("parallel", "2")
+	if err := cmd.RunE(cmd, []string{"a", "b/c"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a-cm
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: c-cm
+`
+	if buffy.String() != expected {
+		t.Fatalf("Expected output:\n%s\n But got output:\n%s", expected, buffy)
+	}
+}
+
+func TestBuildRootsToDirectory(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	loadRoots(fSys)
+	fSys.Mkdir("out")
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.Flags().Set("output", "out")
+	defer cmd.Flags().Set("output", "")
+	if err := cmd.RunE(cmd, []string{"a", "./b/c"}); err != nil {
+		t.Fatal(err)
+	}
+	for file, name := range map[string]string{
+		"out/a/v1_configmap_a-cm.yaml":   "a-cm",
+		"out/b_c/v1_configmap_c-cm.yaml": "c-cm",
+	} {
+		data, err := fSys.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "name: "+name) {
+			t.Fatalf("unexpected content of %s:\n%s", file, data)
+		}
+	}
+	err := cmd.RunE(cmd, []string{"a", "b/c", "b/../a"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(),
+		"the outputs of a and b/../a would both be written to out/a") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildRootsErrorSummary(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	loadRoots(fSys)
+	fSys.WriteFile("unreachable/"+konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- http://127.0.0.1:1/cm.yaml
+`))
+	stderr := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.SetErr(stderr)
+	err := cmd.RunE(cmd, []string{"a", "unreachable"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "1 of 2 DIRs failed to build" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code := ExitCode(err); code != ExitInfrastructureError {
+		t.Fatalf("expected exit code %d, got %d", ExitInfrastructureError, code)
+	}
+	for _, s := range []string{
+		"Error building unreachable: ",
+		"DIR          RESULT          ERROR\n",
+		"a            ok ",
+		"unreachable  infrastructure  ",
+	} {
+		if !strings.Contains(stderr.String(), s) {
+			t.Fatalf("expected %q in:\n%s", s, stderr)
+		}
+	}
+
+	// Errors in the configuration take precedence.
+	stderr.Reset()
+	cmd.Flags().Set("quiet-errors", "true")
+	defer cmd.Flags().Set("quiet-errors", "false")
+	err = cmd.RunE(cmd, []string{"a", "unreachable", "missing"})
+	if code := ExitCode(err); code != ExitConfigError {
+		t.Fatalf("expected exit code %d, got %d", ExitConfigError, code)
+	}
+	if strings.Contains(stderr.String(), "Error building") {
+		t.Fatalf("expected only the summary:\n%s", stderr)
+	}
+	if !strings.Contains(stderr.String(), "missing      config          ") {
+		t.Fatalf("expected missing to fail with a config error:\n%s", stderr)
+	}
+}
+
+func TestBuildRootsRejectedFlags(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	loadRoots(fSys)
+	for flag, value := range map[string]string{
+		"parallel":             "0",
+		"resources-from-stdin": "true",
+		"immutable-against":    "old.yaml",
+		"usage-report":         "report.json",
+	} {
+		cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+		cmd.Flags().Set(flag, value)
+		err := cmd.RunE(cmd, []string{"a", "b/c"})
+		cmd.Flags().Set(flag, cmd.Flags().Lookup(flag).DefValue)
+		if err == nil {
+			t.Fatalf("expected an error for --%s", flag)
+		}
+		if !strings.Contains(err.Error(), "--"+flag) {
+			t.Fatalf("unexpected error for --%s: %v", flag, err)
+		}
+	}
+}
+
 func TestHelp(t *testing.T) {
 	fSys := filesys.MakeFsInMemory()
 	buffy := new(bytes.Buffer)
@@ -215,6 +1056,78 @@ func TestHelp(t *testing.T) {
 	}
 }
 
+func TestBuildOnlyDelta(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+configMapGenerator:
+- name: same
+  literals:
+  - a=1
+- name: changed
+  literals:
+  - a=2
+- name: added
+  literals:
+  - a=1
+generatorOptions:
+  disableNameSuffixHash: true
+`))
+	fSys.WriteFile("prev.yaml", []byte(`apiVersion: v1
+data:
+  a: "1"
+kind: ConfigMap
+metadata:
+  name: same
+---
+apiVersion: v1
+data:
+  a: "1"
+kind: ConfigMap
+metadata:
+  name: changed
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: removed
+  namespace: web
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("only-delta", "true")
+	defer cmd.Flags().Set("only-delta", "false")
+	cmd.Flags().Set("against", "prev.yaml")
+	defer cmd.Flags().Set("against", "")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: v1
+data:
+  a: "1"
+kind: ConfigMap
+metadata:
+  name: added
+---
+apiVersion: v1
+data:
+  a: "2"
+kind: ConfigMap
+metadata:
+  name: changed
+# Deleted relative to prev.yaml:
+# - v1 Service web/removed
+`
+	if buffy.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, buffy)
+	}
+	cmd.Flags().Set("only-delta", "false")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(),
+		"--against requires --only-delta") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestValidation(t *testing.T) {
 	var cases = map[string]struct {
 		args  []string
@@ -224,9 +1137,7 @@ func TestValidation(t *testing.T) {
 		"dotArg":    {[]string{"."}, "unable to find one of "},
 		"file":      {[]string{"beans"}, "'beans' doesn't exist"},
 		"directory": {[]string{"a/b/c"}, "'a/b/c' doesn't exist"},
-		"tooManyArgs": {[]string{"too", "many"},
-			"specify one path to " +
-				konfig.DefaultKustomizationFileName()},
+		"twoArgs":   {[]string{"too", "many"}, "2 of 2 DIRs failed to build"},
 	}
 	for n := range cases {
 		tc := cases[n]
@@ -234,6 +1145,7 @@ func TestValidation(t *testing.T) {
 			fSys := filesys.MakeFsInMemory()
 			buffy := new(bytes.Buffer)
 			cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+			cmd.SetErr(buffy)
 			err := cmd.RunE(cmd, tc.args)
 			if len(tc.erMsg) > 0 {
 				if err == nil {
@@ -251,3 +1163,269 @@ func TestValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildRootsApplySets(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	loadRoots(fSys)
+	fSys.WriteFile("b/c/"+konfig.DefaultKustomizationFileName(), []byte(`
+namePrefix: c-
+namespace: prod
+resources:
+- ../../base
+- role.yaml
+`))
+	fSys.WriteFile("b/c/role.yaml", []byte(`
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("applysets", "true")
+	defer cmd.Flags().Set("applysets", "false")
+	if err := cmd.RunE(cmd, []string{"a", "b/c"}); err != nil {
+		t.Fatal(err)
+	}
+	// The ids are those kubectl gives the parents.
+	expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    applyset.kubernetes.io/part-of: applyset-leCG8BMQIZ12uWiovzl4LoZ3lZiVJFBFmZvOZZpYfh8-v1
+  name: a-cm
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    applyset.kubernetes.io/contains-group-kinds: ConfigMap
+    applyset.kubernetes.io/tooling: kubectl/v1.27.0
+  labels:
+    applyset.kubernetes.io/id: applyset-leCG8BMQIZ12uWiovzl4LoZ3lZiVJFBFmZvOZZpYfh8-v1
+  name: applyset-a-ca978112ca
+  namespace: default
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  labels:
+    applyset.kubernetes.io/part-of: applyset-qqo5XqXdRktx8oP4JWdWKPPuZehf25sbTYaUu9xxCKw-v1
+  name: c-reader
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    applyset.kubernetes.io/part-of: applyset-qqo5XqXdRktx8oP4JWdWKPPuZehf25sbTYaUu9xxCKw-v1
+  name: c-cm
+  namespace: prod
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    applyset.kubernetes.io/contains-group-kinds: ClusterRole.rbac.authorization.k8s.io,ConfigMap
+    applyset.kubernetes.io/tooling: kubectl/v1.27.0
+  labels:
+    applyset.kubernetes.io/id: applyset-qqo5XqXdRktx8oP4JWdWKPPuZehf25sbTYaUu9xxCKw-v1
+  name: applyset-b-c-b9e2beb9a0
+  namespace: prod
+`
+	if buffy.String() != expected {
+		t.Fatalf("Expected output:\n%s\n But got output:\n%s", expected, buffy)
+	}
+
+	// Resources in several namespaces need the parent's.
+	fSys.WriteFile("a/"+konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- ../b/c
+- configmap.yaml
+`))
+	fSys.WriteFile("a/configmap.yaml", []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: dev
+`))
+	err := cmd.RunE(cmd, []string{"a"})
+	if err == nil || !strings.Contains(err.Error(),
+		"resources are in namespaces dev, prod, so --applyset-namespace must be set") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buffy.Reset()
+	cmd.Flags().Set("applyset-namespace", "prod")
+	defer cmd.Flags().Set("applyset-namespace", "")
+	if err = cmd.RunE(cmd, []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffy.String(),
+		"applyset.kubernetes.io/additional-namespaces: dev\n") {
+		t.Fatalf("expected the namespace dev to be additional:\n%s", buffy)
+	}
+}
+
+func TestBuildApplySetTooling(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	loadRoots(fSys)
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("applysets", "true")
+	defer cmd.Flags().Set("applysets", "false")
+	defer cmd.Flags().Set("applyset-tooling", "kubectl/v1.27.0")
+	for tooling, errMsg := range map[string]string{
+		"kubectl/v1.26.3": "--applyset-tooling kubectl/v1.26.3 names a kubectl older than v1.27, " +
+			"which doesn't read the kinds of applysets from applyset.kubernetes.io/contains-group-kinds",
+		"kubectl/latest":  "--applyset-tooling kubectl/latest must name the version of kubectl",
+		"kubectl/v1.28.0": "",
+		"argocd/v2.7.0":   "",
+	} {
+		cmd.Flags().Set("applyset-tooling", tooling)
+		err := cmd.RunE(cmd, []string{"a"})
+		if errMsg == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tooling, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), errMsg) {
+			t.Fatalf("%s: expected error containing %q, got %v", tooling, errMsg, err)
+		}
+	}
+}
+
+func TestBuildOutputFormat(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- namespace.yaml
+`))
+	fSys.WriteFile("namespace.yaml", []byte(`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+`))
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), buffy)
+	cmd.Flags().Set("output-format", "json")
+	defer cmd.Flags().Set("output-format", "yaml")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Namespace",
+      "metadata": {
+        "name": "ns1"
+      }
+    }
+  ]
+}
+`
+	if buffy.String() != expected {
+		t.Fatalf("Expected output:\n%s\n But got output:\n%s", expected, buffy)
+	}
+
+	cmd.Flags().Set("output-format", "toml")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), `unknown --output-format "toml"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func writeNamespaces(fSys filesys.FileSystem) {
+	fSys.WriteFile(konfig.DefaultKustomizationFileName(), []byte(`
+resources:
+- namespaces.yaml
+`))
+	fSys.WriteFile("namespaces.yaml", []byte(`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns2
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns3
+`))
+}
+
+func TestBuildCompressedOutput(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	writeNamespaces(fSys)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.Flags().Set("output", "out.yaml.gz")
+	defer cmd.Flags().Set("output", "")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := fSys.ReadFile("out.yaml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "apiVersion: v1\nkind: Namespace\n") ||
+		strings.Count(string(out), "---\n") != 2 {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestBuildChunks(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	writeNamespaces(fSys)
+	cmd := NewCmdBuild(fSys, MakeHelp("foo", "bar"), new(bytes.Buffer))
+	cmd.Flags().Set("output", "out/all.yaml")
+	defer cmd.Flags().Set("output", "")
+	cmd.Flags().Set("chunk-size-bytes", "120")
+	defer cmd.Flags().Set("chunk-size-bytes", "0")
+	if err := cmd.RunE(cmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	namespace := func(name string) string {
+		return "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: " + name + "\n"
+	}
+	for path, expected := range map[string]string{
+		"out/all-1.yaml": namespace("ns1") + "---\n" + namespace("ns2"),
+		"out/all-2.yaml": namespace("ns3"),
+	} {
+		b, err := fSys.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expected {
+			t.Fatalf("expected %s to hold:\n%s\nbut it holds:\n%s", path, expected, b)
+		}
+	}
+	if fSys.Exists("out/all.yaml") {
+		t.Fatalf("expected no unchunked output")
+	}
+
+	cmd.Flags().Set("chunk-size-bytes", "40")
+	err := cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(),
+		"resource ~G_v1_Namespace|~X|ns1 is 53 bytes, more than --chunk-size-bytes 40 allows") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
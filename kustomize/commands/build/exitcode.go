@@ -0,0 +1,63 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// The exit codes of failed builds, by the kind of their
+// errors, so that CI may retry only the builds failing
+// for want of the network, say.
+const (
+	ExitConfigError         = 1
+	ExitInfrastructureError = 2
+	ExitInternalError       = 3
+)
+
+// ExitCode returns the exit code of a command failing
+// with err, by the kind of error it is.
+func ExitCode(err error) int {
+	kind := krusty.KindOf(err)
+	var be *batchError
+	if errors.As(err, &be) {
+		kind = be.kind
+	}
+	switch kind {
+	case krusty.InfrastructureError:
+		return ExitInfrastructureError
+	case krusty.InternalError:
+		return ExitInternalError
+	default:
+		return ExitConfigError
+	}
+}
+
+// batchError is the error of a build of several DIRs, of
+// which some failed, of the most severe kind of their errors.
+type batchError struct {
+	kind   krusty.ErrorKind
+	failed int
+	total  int
+}
+
+func (e *batchError) Error() string {
+	return fmt.Sprintf("%d of %d DIRs failed to build", e.failed, e.total)
+}
+
+// severity ranks the kinds of errors; a build retried
+// for its infrastructure errors should only have those.
+func severity(kind krusty.ErrorKind) int {
+	switch kind {
+	case krusty.InternalError:
+		return 2
+	case krusty.ConfigError:
+		return 1
+	default:
+		return 0
+	}
+}
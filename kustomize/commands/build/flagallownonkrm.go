@@ -0,0 +1,18 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagAllowNonKRM adds the --allow-non-krm flag.
+func AddFlagAllowNonKRM(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.allowNonKRM,
+		"allow-non-krm",
+		false,
+		"Pass the documents of resource files lacking an apiVersion or kind, "+
+			"e.g. data for functions, through the build as is, rather than failing.")
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// AddFlagCache adds the --cache and --cache-dir flags.
+func AddFlagCache(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.cache.enabled,
+		"cache",
+		false,
+		"Keep the clones of remote bases in the cache directory, for later "+
+			"builds to reuse rather than cloning them again. A clone stays "+
+			"at the commit it was first cloned at, even if its ref is a "+
+			"branch. See 'kustomize cache' to list and remove them.")
+	set.StringVar(
+		&theFlags.cache.dir,
+		"cache-dir",
+		"",
+		"The directory of the cache, if not $"+konfig.KustomizeCacheHomeEnv+
+			", else the kustomize directory of the user's cache directory.")
+}
+
+// CacheClonesDir returns the directory of the clones
+// that builds keep, below the cache directory dir,
+// or below the default one if dir is empty.
+func CacheClonesDir(dir string) string {
+	if dir == "" {
+		dir = konfig.DefaultCacheHome()
+	}
+	return filepath.Join(dir, konfig.RelClonesCacheHome)
+}
+
+// honorFlagCache has the builds that follow keep their
+// clones in the cache directory, if the --cache flag is set
+// and they don't share a cache already, returning the function
+// to call once they're done, releasing the clones.
+func honorFlagCache() (func(), error) {
+	if !theFlags.cache.enabled || theClones != nil {
+		return func() {}, nil
+	}
+	c, err := krusty.NewDiskCloneCache(CacheClonesDir(theFlags.cache.dir))
+	if err != nil {
+		return nil, err
+	}
+	UseCloneCache(c)
+	return func() {
+		UseCloneCache(nil)
+		_ = c.Cleanup()
+	}, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// AddFlagCheckPlaceholders adds the --check-placeholders
+// and --allow-placeholder flags.
+func AddFlagCheckPlaceholders(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.checkPlaceholders,
+		"check-placeholders",
+		"",
+		fmt.Sprintf(
+			"If %s, log the placeholders left unexpanded in the values of "+
+				"the output, such as ${TAG}, {{ .Values.tag }} or $(TAG), "+
+				"or, if %s, fail the build.",
+			krusty.PlaceholdersWarned, krusty.PlaceholdersFail))
+	set.StringSliceVar(
+		&theFlags.allowedPlaceholders,
+		"allow-placeholder",
+		nil,
+		"A glob of placeholders --check-placeholders passes, "+
+			"e.g. '$(POD_*)'; may be repeated.")
+}
+
+func validateFlagCheckPlaceholders() error {
+	switch krusty.PlaceholderCheck(theFlags.checkPlaceholders) {
+	case krusty.PlaceholdersIgnored:
+		if len(theFlags.allowedPlaceholders) > 0 {
+			return fmt.Errorf("--allow-placeholder requires --check-placeholders")
+		}
+		return nil
+	case krusty.PlaceholdersWarned, krusty.PlaceholdersFail:
+		return nil
+	}
+	return fmt.Errorf(
+		"unknown --check-placeholders %q, expected %s or %s",
+		theFlags.checkPlaceholders, krusty.PlaceholdersWarned, krusty.PlaceholdersFail)
+}
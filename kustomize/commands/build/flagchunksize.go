@@ -0,0 +1,127 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+)
+
+const flagChunkSizeName = "chunk-size-bytes"
+
+// AddFlagChunkSize adds the --chunk-size-bytes flag.
+func AddFlagChunkSize(set *pflag.FlagSet) {
+	set.IntVar(
+		&theFlags.chunkSize,
+		flagChunkSizeName,
+		0,
+		"If above 0, the output file named by --output is split into "+
+			"numbered files, e.g. out-1.yaml, out-2.yaml, each at most this "+
+			"many bytes, before any compression, and holding whole resources.")
+}
+
+func validateFlagChunkSize() error {
+	if theFlags.chunkSize < 0 {
+		return fmt.Errorf("--%s must not be negative", flagChunkSizeName)
+	}
+	if theFlags.chunkSize > 0 && theFlags.outputPath == "" {
+		return fmt.Errorf(
+			"--%s requires --output to name a file", flagChunkSizeName)
+	}
+	return nil
+}
+
+// writeChunks writes the resources, encoded in the output format,
+// to numbered files named after the output path, each at most
+// as big as the chunk size allows.
+func writeChunks(fSys filesys.FileSystem, k *krusty.Kustomizer, m resmap.ResMap) error {
+	chunks, err := makeChunks(k, m, theFlags.chunkSize)
+	if err != nil {
+		return err
+	}
+	for i, chunk := range chunks {
+		path := chunkPath(theFlags.outputPath, i+1, len(chunks))
+		if err = writeOutputFile(fSys, path, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// makeChunks encodes the resources in the output format, one
+// run of them per chunk, each chunk at most limit bytes.  A
+// resource too big for a chunk of its own is an error.
+func makeChunks(k *krusty.Kustomizer, m resmap.ResMap, limit int) ([][]byte, error) {
+	resources := m.Resources()
+	sizes := make([]int, len(resources))
+	for i := range resources {
+		b, err := emitResources(k, m, i, i+1)
+		if err != nil {
+			return nil, err
+		}
+		sizes[i] = len(b)
+	}
+	var chunks [][]byte
+	for start := 0; start < len(resources); {
+		end, size := start+1, sizes[start]
+		for end < len(resources) && size+sizes[end] <= limit {
+			size += sizes[end]
+			end++
+		}
+		// The sizes leave out what the format adds to encode
+		// several resources, so fewer may have to fit.
+		for {
+			b, err := emitResources(k, m, start, end)
+			if err != nil {
+				return nil, err
+			}
+			if len(b) <= limit {
+				chunks = append(chunks, b)
+				break
+			}
+			if end-start == 1 {
+				return nil, fmt.Errorf(
+					"resource %s is %d bytes, more than --%s %d allows",
+					resources[start].CurId(), len(b), flagChunkSizeName, limit)
+			}
+			end--
+		}
+		start = end
+	}
+	return chunks, nil
+}
+
+// emitResources encodes the resources of m from start
+// to end, exclusive, in the output format.
+func emitResources(k *krusty.Kustomizer, m resmap.ResMap, start, end int) ([]byte, error) {
+	part := resmap.New()
+	for _, r := range m.Resources()[start:end] {
+		if err := part.Append(r); err != nil {
+			return nil, err
+		}
+	}
+	var b bytes.Buffer
+	err := k.Emit(&b, part, theFlags.outputFormat)
+	return b.Bytes(), err
+}
+
+// chunkPath returns the path of the nth of count chunks of the
+// output path, numbered before its extensions, and padded so
+// that the paths sort in order, e.g. out-01.yaml.gz.
+func chunkPath(path string, n, count int) string {
+	dir, base := filepath.Split(path)
+	name, ext := base, ""
+	if i := strings.Index(base, "."); i > 0 {
+		name, ext = base[:i], base[i:]
+	}
+	width := len(fmt.Sprint(count))
+	return filepath.Join(dir, fmt.Sprintf("%s-%0*d%s", name, width, n, ext))
+}
@@ -0,0 +1,23 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import "testing"
+
+func TestChunkPath(t *testing.T) {
+	for _, tc := range []struct {
+		path     string
+		n, count int
+		expected string
+	}{
+		{"out.yaml", 1, 2, "out-1.yaml"},
+		{"dir/out.yaml.gz", 3, 12, "dir/out-03.yaml.gz"},
+		{"out", 2, 2, "out-2"},
+	} {
+		if actual := chunkPath(tc.path, tc.n, tc.count); actual != tc.expected {
+			t.Errorf("chunkPath(%q, %d, %d) is %q, expected %q",
+				tc.path, tc.n, tc.count, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// AddFlagClusterScopedReferrals adds the --cluster-scoped-referrals flag.
+func AddFlagClusterScopedReferrals(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.clusterReferrals,
+		"cluster-scoped-referrals",
+		string(krusty.ClusterScopedReferralsAny),
+		fmt.Sprintf(
+			"If %s, the name references of cluster-scoped objects naming "+
+				"no namespace, e.g. the subjects of ClusterRoleBindings, "+
+				"follow only the renamed objects of the namespace of the "+
+				"kustomization, rather than, if %s, of any namespace.",
+			krusty.ClusterScopedReferralsTarget, krusty.ClusterScopedReferralsAny))
+}
+
+func validateFlagClusterScopedReferrals() error {
+	switch krusty.ClusterScopedReferrals(theFlags.clusterReferrals) {
+	case krusty.ClusterScopedReferralsAny, krusty.ClusterScopedReferralsTarget:
+		return nil
+	}
+	return fmt.Errorf(
+		"unknown --cluster-scoped-referrals %q, expected %s or %s",
+		theFlags.clusterReferrals,
+		krusty.ClusterScopedReferralsAny, krusty.ClusterScopedReferralsTarget)
+}
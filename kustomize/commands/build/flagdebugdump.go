@@ -0,0 +1,21 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagDebugDump adds the --debug-dump flag.
+func AddFlagDebugDump(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.debugDump,
+		"debug-dump",
+		"",
+		"If specified, write to this directory, which must not exist or be "+
+			"empty, the resources of each kustomization after each step of "+
+			"the build that changes them, e.g. a generator or transformer, "+
+			"to numbered files, to find which step made a change. The values "+
+			"of secrets are redacted.")
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// AddFlagApplyDefaults adds the --apply-defaults flag.
+func AddFlagApplyDefaults(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.applyDefaults,
+		"apply-defaults",
+		false,
+		"Set the defaults of the CRDs of the output, and of the custom "+
+			"OpenAPI schema of the kustomization, if any, to the fields the "+
+			"resources omit, as the API server does, e.g. to diff the output "+
+			"against live objects. The defaults the API server sets for "+
+			"builtin kinds aren't known, as their builtin schemas hardly "+
+			"hold any; a warning lists the kinds left as is.")
+}
+
+// AddFlagStripDefaults adds the --strip-defaults and
+// --keep-defaults-of flags.
+func AddFlagStripDefaults(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.stripDefaults,
+		"strip-defaults",
+		false,
+		"Remove the fields of the resources equal to the defaults of "+
+			"their schemas, as for --apply-defaults, leaving minimal "+
+			"manifests to review.")
+	set.StringSliceVar(
+		&theFlags.keepDefaultsOf,
+		"keep-defaults-of",
+		nil,
+		"A kind, e.g. Deployment, whose resources --strip-defaults "+
+			"leaves as is; may be repeated.")
+}
+
+func validateFlagDefaults() error {
+	if theFlags.applyDefaults && theFlags.stripDefaults {
+		return fmt.Errorf(
+			"--apply-defaults and --strip-defaults can't be used together")
+	}
+	if len(theFlags.keepDefaultsOf) > 0 && !theFlags.stripDefaults {
+		return fmt.Errorf("--keep-defaults-of requires --strip-defaults")
+	}
+	return nil
+}
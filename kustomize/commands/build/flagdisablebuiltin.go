@@ -0,0 +1,20 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagDisableBuiltin adds the --disable-builtin flag.
+func AddFlagDisableBuiltin(set *pflag.FlagSet) {
+	set.StringSliceVar(
+		&theFlags.disabledBuiltins,
+		"disable-builtin",
+		nil,
+		"A builtin plugin not to run, e.g. LabelTransformer, or "+
+			"NameReferenceTransformer to leave references to renamed "+
+			"objects as is; may be repeated. Builds needing a disabled "+
+			"builtin fail.")
+}
@@ -0,0 +1,20 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagEnableRequirements adds the --enable-requirements flag.
+func AddFlagEnableRequirements(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.enable.requirements,
+		"enable-requirements",
+		false,
+		"Enable what the requirements field of a local kustomization "+
+			"needs, e.g. helm, other than exec functions. If false, the "+
+			"default, or for a remote kustomization, the build fails naming "+
+			"the flags it needs.")
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagEnableTLSSecretGenerator adds the
+// --enable-tls-secret-generator flag.
+func AddFlagEnableTLSSecretGenerator(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.enable.tlsSecretGenerator,
+		"enable-tls-secret-generator",
+		false,
+		"Enable the tlsSecretGenerator field, which generates certificates "+
+			"with keys only fit for dev and test.")
+}
@@ -0,0 +1,263 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const flagImmutableAgainstName = "immutable-against"
+
+// AddFlagImmutableAgainst adds the --immutable-against flag.
+func AddFlagImmutableAgainst(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.immutableAgainst,
+		flagImmutableAgainstName,
+		"",
+		"What to check the build against: a kustomization, as a directory "+
+			"or URL, which is built with the same flags, or a file holding "+
+			"the output of a previous build. If specified, the build fails "+
+			"if it changes a field that the API server won't allow to change "+
+			"on an existing object (e.g. a Deployment's selector).")
+}
+
+// immutableRule describes a field that can't be changed
+// on an existing object of the given kinds.
+type immutableRule struct {
+	// kinds holds kind names, optionally qualified
+	// by group as in "Job.batch".
+	kinds []string
+	path  []string
+	// changed reports whether going from old to new is
+	// a disallowed change.  If nil, any difference is.
+	changed func(old, new *yaml.RNode) bool
+	reason  string
+}
+
+var immutableRules = []immutableRule{
+	{
+		kinds:  []string{"Deployment.apps", "ReplicaSet.apps", "DaemonSet.apps", "StatefulSet.apps"},
+		path:   []string{"spec", "selector"},
+		reason: "the selector of an existing workload can't be changed; the object must be recreated",
+	},
+	{
+		kinds:  []string{"StatefulSet.apps"},
+		path:   []string{"spec", "volumeClaimTemplates"},
+		reason: "the volumeClaimTemplates of an existing StatefulSet can't be changed",
+	},
+	{
+		kinds:  []string{"StatefulSet.apps"},
+		path:   []string{"spec", "serviceName"},
+		reason: "the serviceName of an existing StatefulSet can't be changed",
+	},
+	{
+		kinds:  []string{"StatefulSet.apps"},
+		path:   []string{"spec", "podManagementPolicy"},
+		reason: "the podManagementPolicy of an existing StatefulSet can't be changed",
+	},
+	{
+		kinds:   []string{"Service"},
+		path:    []string{"spec", "clusterIP"},
+		changed: changedOnceSet,
+		reason:  "the clusterIP of an existing Service can't be changed once allocated",
+	},
+	{
+		kinds:   []string{"PersistentVolumeClaim"},
+		path:    []string{"spec", "storageClassName"},
+		changed: changedOnceSet,
+		reason:  "the storageClassName of an existing PersistentVolumeClaim can't be changed",
+	},
+	{
+		kinds:   []string{"PersistentVolumeClaim"},
+		path:    []string{"spec", "resources", "requests", "storage"},
+		changed: shrank,
+		reason:  "the storage request of an existing PersistentVolumeClaim can't be reduced",
+	},
+	{
+		kinds:  []string{"Job.batch"},
+		path:   []string{"spec", "selector"},
+		reason: "the selector of an existing Job can't be changed",
+	},
+	{
+		kinds:  []string{"Job.batch"},
+		path:   []string{"spec", "template"},
+		reason: "the pod template of an existing Job can't be changed; the Job must be recreated",
+	},
+}
+
+func (r immutableRule) appliesTo(res *resource.Resource) bool {
+	gvk := res.GetGvk()
+	for _, k := range r.kinds {
+		kind, group := k, ""
+		if i := strings.Index(k, "."); i > 0 {
+			kind, group = k[:i], k[i+1:]
+		}
+		if gvk.Kind == kind && gvk.Group == group {
+			return true
+		}
+	}
+	return false
+}
+
+// changedOnceSet allows a field that was unset to be set,
+// and a field that is set to be dropped from the manifest
+// (the server retains its value).
+func changedOnceSet(old, new *yaml.RNode) bool {
+	if isUnset(old) || isUnset(new) {
+		return false
+	}
+	return !nodesEqual(old, new)
+}
+
+func isUnset(n *yaml.RNode) bool {
+	return yaml.IsMissingOrNull(n) || n.YNode().Value == ""
+}
+
+// shrank reports whether a quantity became smaller.
+func shrank(old, new *yaml.RNode) bool {
+	if old == nil || new == nil {
+		return false
+	}
+	o, okO := parseQuantity(old.YNode().Value)
+	n, okN := parseQuantity(new.YNode().Value)
+	if !okO || !okN {
+		return !nodesEqual(old, new)
+	}
+	return n.Cmp(o) < 0
+}
+
+var quantityPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([a-zA-Z]*)$`)
+
+var quantitySuffixes = map[string]*big.Rat{
+	"":   big.NewRat(1, 1),
+	"m":  big.NewRat(1, 1000),
+	"k":  big.NewRat(1000, 1),
+	"M":  big.NewRat(1000*1000, 1),
+	"G":  big.NewRat(1000*1000*1000, 1),
+	"T":  new(big.Rat).SetInt64(1000 * 1000 * 1000 * 1000),
+	"P":  new(big.Rat).SetInt64(1000 * 1000 * 1000 * 1000 * 1000),
+	"E":  new(big.Rat).SetInt64(1000 * 1000 * 1000 * 1000 * 1000 * 1000),
+	"Ki": new(big.Rat).SetInt64(1 << 10),
+	"Mi": new(big.Rat).SetInt64(1 << 20),
+	"Gi": new(big.Rat).SetInt64(1 << 30),
+	"Ti": new(big.Rat).SetInt64(1 << 40),
+	"Pi": new(big.Rat).SetInt64(1 << 50),
+	"Ei": new(big.Rat).SetInt64(1 << 60),
+}
+
+// parseQuantity parses the common forms of a kubernetes
+// resource quantity, e.g. "500Mi" or "1.5G".
+func parseQuantity(s string) (*big.Rat, bool) {
+	m := quantityPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, false
+	}
+	mult, ok := quantitySuffixes[m[2]]
+	if !ok {
+		return nil, false
+	}
+	v, ok := new(big.Rat).SetString(m[1])
+	if !ok {
+		return nil, false
+	}
+	return v.Mul(v, mult), true
+}
+
+func nodesEqual(a, b *yaml.RNode) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	sa, errA := a.String()
+	sb, errB := b.String()
+	return errA == nil && errB == nil && sa == sb
+}
+
+func lookup(res *resource.Resource, path []string) (*yaml.RNode, error) {
+	return res.Node().Pipe(yaml.Lookup(path...))
+}
+
+func displayValue(n *yaml.RNode) string {
+	if n == nil {
+		return " <unset>"
+	}
+	if n.YNode().Kind == yaml.ScalarNode {
+		return " " + n.YNode().Value
+	}
+	s, err := n.String()
+	if err != nil {
+		return " <?>"
+	}
+	return "\n" + indent(strings.TrimSpace(s), "      ")
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// checkImmutableFields returns an error describing every change
+// from previous to current that the API server would reject
+// on update.  Resources are matched by their current ids;
+// resources that are new or gone are ignored.
+func checkImmutableFields(previous, current resmap.ResMap) error {
+	var violations []string
+	for _, res := range current.Resources() {
+		old, err := previous.GetByCurrentId(res.CurId())
+		if err != nil {
+			continue
+		}
+		for _, rule := range immutableRules {
+			if !rule.appliesTo(res) {
+				continue
+			}
+			o, err := lookup(old, rule.path)
+			if err != nil {
+				return err
+			}
+			n, err := lookup(res, rule.path)
+			if err != nil {
+				return err
+			}
+			changed := rule.changed
+			if changed == nil {
+				changed = func(o, n *yaml.RNode) bool { return !nodesEqual(o, n) }
+			}
+			if !changed(o, n) {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf(
+				"%s: field %s: %s\n    was:%s\n    now:%s",
+				res.CurId(), strings.Join(rule.path, "."), rule.reason,
+				displayValue(o), displayValue(n)))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return fmt.Errorf(
+		"build changes immutable fields relative to %s:\n  %s",
+		theFlags.immutableAgainst, strings.Join(violations, "\n  "))
+}
+
+func honorFlagImmutableAgainst(fSys filesys.FileSystem, m resmap.ResMap) error {
+	if theFlags.immutableAgainst == "" {
+		return nil
+	}
+	previous, err := loadPreviousBuild(fSys, theFlags.immutableAgainst)
+	if err != nil {
+		return fmt.Errorf(
+			"reading --%s %s: %v", flagImmutableAgainstName, theFlags.immutableAgainst, err)
+	}
+	return checkImmutableFields(previous, m)
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagKeepComments adds the --keep-comments flag.
+func AddFlagKeepComments(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.keepComments,
+		"keep-comments",
+		false,
+		"Keep the comments of the resources in the yaml output, "+
+			"copying those of strategic merge patches to the fields "+
+			"they set, e.g. for reviewers of committed rendered manifests.")
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagKubeVersion adds the --kube-version flag.
+func AddFlagKubeVersion(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.kubeVersion,
+		"kube-version",
+		"",
+		"The version of Kubernetes the build targets, e.g. 1.29. "+
+			"Overrides the kubeVersion field of the kustomization.")
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagLogCreatedFields adds the --log-created-fields flag.
+func AddFlagLogCreatedFields(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.logCreatedFields,
+		"log-created-fields",
+		false,
+		"Log each field that a replacement with options.create creates, "+
+			"rather than updates, to catch typos in field paths.")
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// AddFlagMaxDepth adds the --max-depth flag.
+func AddFlagMaxDepth(set *pflag.FlagSet) {
+	set.IntVar(
+		&theFlags.maxDepth,
+		"max-depth",
+		krusty.DefaultMaxDepth,
+		"How deeply bases and components may nest, e.g. 1 allows bases "+
+			"but not bases of bases; a negative value sets no limit.")
+}
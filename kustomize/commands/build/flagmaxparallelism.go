@@ -0,0 +1,19 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagMaxParallelism adds the --max-parallelism flag.
+func AddFlagMaxParallelism(set *pflag.FlagSet) {
+	set.IntVar(
+		&theFlags.maxParallelism,
+		"max-parallelism",
+		1,
+		"How many patches of a kustomization to apply at once, to distinct "+
+			"resources. Patches changing which resources patches select, "+
+			"e.g. renaming them, fail builds applying them in parallel.")
+}
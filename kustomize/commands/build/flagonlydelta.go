@@ -0,0 +1,153 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/provider"
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/resmap"
+)
+
+const (
+	flagOnlyDeltaName = "only-delta"
+	flagAgainstName   = "against"
+)
+
+// AddFlagOnlyDelta adds the --only-delta and --against flags.
+func AddFlagOnlyDelta(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.onlyDelta,
+		flagOnlyDeltaName,
+		false,
+		"Output only the resources that are new or differ from those of "+
+			"the build named by --against, followed by comments listing "+
+			"those of that build this one no longer has.")
+	set.StringVar(
+		&theFlags.against,
+		flagAgainstName,
+		"",
+		"What --only-delta compares the build to: a kustomization, "+
+			"as a directory or URL, which is built with the same flags, "+
+			"or a file holding the output of a previous build.")
+}
+
+func validateFlagOnlyDelta() error {
+	if !theFlags.onlyDelta {
+		if theFlags.against != "" {
+			return fmt.Errorf(
+				"--%s requires --%s", flagAgainstName, flagOnlyDeltaName)
+		}
+		return nil
+	}
+	if theFlags.against == "" {
+		return fmt.Errorf(
+			"--%s requires --%s", flagOnlyDeltaName, flagAgainstName)
+	}
+	if theFlags.outputFormat != krusty.FormatYaml {
+		return fmt.Errorf(
+			"--%s requires --output-format %s", flagOnlyDeltaName, krusty.FormatYaml)
+	}
+	if theFlags.chunkSize > 0 {
+		return fmt.Errorf(
+			"--%s may not be used with --%s", flagOnlyDeltaName, flagChunkSizeName)
+	}
+	return nil
+}
+
+// honorFlagOnlyDelta returns the resources of m that are new or
+// differ from those that the --against build has, and the ids of
+// those it has that m doesn't.  Resources are matched by their
+// current ids.
+func honorFlagOnlyDelta(fSys filesys.FileSystem, m resmap.ResMap) (
+	resmap.ResMap, []resid.ResId, error) {
+	previous, err := loadPreviousBuild(fSys, theFlags.against)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"reading --%s %s: %v", flagAgainstName, theFlags.against, err)
+	}
+	result := resmap.New()
+	for _, r := range m.Resources() {
+		old, err := previous.GetByCurrentId(r.CurId())
+		if err == nil {
+			same, err := sameJSON(old.MarshalJSON, r.MarshalJSON)
+			if err != nil {
+				return nil, nil, err
+			}
+			if same {
+				continue
+			}
+		}
+		if err = result.Append(r); err != nil {
+			return nil, nil, err
+		}
+	}
+	var deleted []resid.ResId
+	for _, r := range previous.Resources() {
+		if _, err := m.GetByCurrentId(r.CurId()); err != nil {
+			deleted = append(deleted, r.CurId())
+		}
+	}
+	return result, deleted, nil
+}
+
+// loadPreviousBuild reads the output of a previous build from
+// the file at path, or builds the kustomization that path names,
+// as a directory or URL, with the flags of this build other than
+// those recording it.
+func loadPreviousBuild(fSys filesys.FileSystem, path string) (resmap.ResMap, error) {
+	if fSys.Exists(path) && !fSys.IsDir(path) {
+		b, err := fSys.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return resmap.NewFactory(
+			provider.NewDefaultDepProvider().GetResourceFactory()).NewResMapFromBytes(b)
+	}
+	kOpts := HonorKustomizeFlags(krusty.MakeDefaultOptions())
+	kOpts.DebugDumpDir = ""
+	kOpts.UsageReport = nil
+	kOpts.EnvCapture = nil
+	return krusty.MakeKustomizer(kOpts).Run(fSys, path)
+}
+
+func sameJSON(a, b func() ([]byte, error)) (bool, error) {
+	ja, err := a()
+	if err != nil {
+		return false, err
+	}
+	jb, err := b()
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ja, jb), nil
+}
+
+// writeDeletions writes a comment listing the resources
+// that the --against build has, and this one doesn't.
+func writeDeletions(w io.Writer, deleted []resid.ResId) error {
+	if len(deleted) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "# Deleted relative to %s:\n", theFlags.against); err != nil {
+		return err
+	}
+	for _, id := range deleted {
+		name := id.Name
+		if id.Namespace != "" {
+			name = id.Namespace + "/" + name
+		}
+		if _, err := fmt.Fprintf(
+			w, "# - %s %s %s\n", id.ApiVersion(), id.Kind, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// AddFlagOutputFormat adds the --output-format flag.
+func AddFlagOutputFormat(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.outputFormat,
+		"output-format",
+		krusty.FormatYaml,
+		"The encoding of the output, yaml, or json, a v1 List. "+
+			"Files written to the directory named by --output are always yaml.")
+}
+
+func validateFlagOutputFormat() error {
+	formats := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).EmitFormats()
+	for _, f := range formats {
+		if theFlags.outputFormat == f {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"unknown --output-format %q, expected one of %s",
+		theFlags.outputFormat, strings.Join(formats, ", "))
+}
@@ -4,7 +4,12 @@
 package build
 
 import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+
 	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/filesys"
 )
 
 func AddFlagOutputPath(set *pflag.FlagSet) {
@@ -13,5 +18,23 @@ func AddFlagOutputPath(set *pflag.FlagSet) {
 		"output",
 		"o", // abbreviation
 		"",  // default
-		"If specified, write output to this path.")
+		"If specified, write output to this path. "+
+			"A path ending in .gz is written gzip compressed.")
+}
+
+// writeOutputFile writes the output to the file at path,
+// compressed with gzip if the path ends in .gz.
+func writeOutputFile(fSys filesys.FileSystem, path string, data []byte) error {
+	if !strings.HasSuffix(path, ".gz") {
+		return fSys.WriteFile(path, data)
+	}
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return fSys.WriteFile(path, b.Bytes())
 }
@@ -0,0 +1,17 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagParallel adds the --parallel flag.
+func AddFlagParallel(set *pflag.FlagSet) {
+	set.IntVar(
+		&theFlags.parallel,
+		"parallel",
+		1,
+		"How many DIRs to build at once, when given more than one.")
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// AddFlagProgress adds the --progress flag.
+func AddFlagProgress(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.progress,
+		"progress",
+		false,
+		"Show the progress of the build on a status line of stderr: "+
+			"the stage of the kustomization being built, the resources "+
+			"it holds and the remote repositories being fetched.")
+}
+
+// honorFlagProgress has the builds of the options show their
+// progress on w, if the --progress flag is set, returning the
+// function to call once they're done, clearing the status line.
+func honorFlagProgress(kOpts *krusty.Options, w io.Writer) func() {
+	if !theFlags.progress {
+		return func() {}
+	}
+	p := &progressLine{w: w}
+	kOpts.Progress = p.report
+	return p.clear
+}
+
+// progressLine renders the events of builds as a status
+// line, rewritten in place after each event.
+type progressLine struct {
+	mu      sync.Mutex
+	w       io.Writer
+	width   int
+	fetches int
+	fetched int
+}
+
+func (p *progressLine) report(e types.ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var line string
+	switch e.Kind {
+	case types.ProgressStageStarted:
+		line = fmt.Sprintf("%s: %s", e.Dir, e.Stage)
+	case types.ProgressStageFinished:
+		line = fmt.Sprintf("%s: %s done, %d resources", e.Dir, e.Stage, e.Resources)
+	case types.ProgressFetchStarted:
+		p.fetches++
+		line = fmt.Sprintf("fetching %s", e.Repo)
+	case types.ProgressFetchFinished:
+		p.fetched++
+		line = fmt.Sprintf("fetched %s", e.Repo)
+		if e.Err != nil {
+			line = fmt.Sprintf("failed to fetch %s", e.Repo)
+		}
+	default:
+		return
+	}
+	if p.fetches > 0 {
+		line = fmt.Sprintf("[%d/%d fetched] %s", p.fetched, p.fetches, line)
+	}
+	p.write(line)
+}
+
+// clear erases the status line.
+func (p *progressLine) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.width > 0 {
+		p.write("")
+		fmt.Fprint(p.w, "\r")
+	}
+}
+
+// write replaces the status line with line,
+// padding it to cover the one before.
+func (p *progressLine) write(line string) {
+	pad := p.width - len(line)
+	if pad < 0 {
+		pad = 0
+	}
+	fmt.Fprint(p.w, "\r"+line+strings.Repeat(" ", pad))
+	p.width = len(line)
+}
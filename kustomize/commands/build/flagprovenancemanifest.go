@@ -0,0 +1,45 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/provenance"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// AddFlagProvenanceManifest adds the --provenance-manifest flag.
+func AddFlagProvenanceManifest(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.provenanceManifest,
+		"provenance-manifest",
+		"",
+		"If specified, write to this path a JSON manifest of the build: "+
+			"the version of kustomize, and the environment variables the "+
+			"build read through the envSources of kustomizations, with "+
+			"their values, to audit or reproduce it. The values read for "+
+			"Secrets or functions are given as their sha256 digests.")
+}
+
+// provenanceManifest is what the --provenance-manifest flag writes.
+type provenanceManifest struct {
+	Kustomize provenance.Provenance `json:"kustomize"`
+	Env       map[string]string     `json:"env,omitempty"`
+}
+
+// writeProvenanceManifest writes the manifest of the build having
+// captured the environment to the path given by the flag.
+func writeProvenanceManifest(fSys filesys.FileSystem, env *types.EnvCapture) error {
+	b, err := json.MarshalIndent(provenanceManifest{
+		Kustomize: provenance.GetProvenance(),
+		Env:       env.Values,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fSys.WriteFile(theFlags.provenanceManifest, append(b, '\n'))
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagQuietErrors adds the --quiet-errors flag.
+func AddFlagQuietErrors(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.quietErrors,
+		"quiet-errors",
+		false,
+		"When building several DIRs, report only the summary of the "+
+			"failures, with the first line of each error, rather than "+
+			"the errors in full as well.")
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagResourcesFromStdin adds the --resources-from-stdin flag.
+func AddFlagResourcesFromStdin(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.resourcesFromStdin,
+		"resources-from-stdin",
+		false,
+		"Read a YAML stream from stdin and add its resources to "+
+			"the kustomization's resources before transformation.")
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/resmap"
+)
+
+// AddFlagSelect adds the --select flag.
+func AddFlagSelect(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.selectQuery,
+		"select",
+		"",
+		"Output only the resources matching the query, e.g. "+
+			"'@source=../apps/web && kind=Deployment', where @source is the "+
+			"entry of the resources of the kustomization they come from; "+
+			"the entries none can come from aren't loaded or fetched.")
+}
+
+func validateFlagSelect() error {
+	_, err := getFlagSelectValue()
+	return err
+}
+
+// getFlagSelectValue returns the query of the
+// resources to output, or nil to output all.
+func getFlagSelectValue() (*resmap.ResQuery, error) {
+	if theFlags.selectQuery == "" {
+		return nil, nil
+	}
+	q, err := resmap.Query(theFlags.selectQuery)
+	if err != nil {
+		return nil, fmt.Errorf("--select: %v", err)
+	}
+	return q, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// AddFlagSet adds the --set flag.
+func AddFlagSet(set *pflag.FlagSet) {
+	set.StringArrayVar(
+		&theFlags.parameters,
+		"set",
+		nil,
+		"A build parameter, as NAME=VALUE, that placeholders such as "+
+			"${NAME} in the namespace, namePrefix and commonLabels values "+
+			"of kustomizations are replaced by; may be repeated.")
+}
+
+func validateFlagSet() error {
+	_, err := getFlagSetValue()
+	return err
+}
+
+// getFlagSetValue returns the build parameters, by name.
+func getFlagSetValue() (map[string]string, error) {
+	if len(theFlags.parameters) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	for _, p := range theFlags.parameters {
+		i := strings.Index(p, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf(
+				"--set %s isn't of the form NAME=VALUE", p)
+		}
+		result[p[:i]] = p[i+1:]
+	}
+	return result, nil
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagStrictDeprecations adds the --strict-deprecations flag.
+func AddFlagStrictDeprecations(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.strictDeprecations,
+		"strict-deprecations",
+		false,
+		"Fail if any kustomization uses deprecated fields, "+
+			"e.g. vars, bases or patchesStrategicMerge.")
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagStrictGeneratedNames adds the --strict-generated-names flag.
+func AddFlagStrictGeneratedNames(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.strictGenNames,
+		"strict-generated-names",
+		false,
+		"Fail if a replacement copies the name of a generated resource, e.g. "+
+			"of a configMapGenerator, which replacements see without its hash suffix.")
+}
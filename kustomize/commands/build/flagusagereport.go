@@ -0,0 +1,34 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// AddFlagUsageReport adds the --usage-report flag.
+func AddFlagUsageReport(set *pflag.FlagSet) {
+	set.StringVar(
+		&theFlags.usageReport,
+		"usage-report",
+		"",
+		"If specified, write to this path a JSON summary of the kustomization "+
+			"fields, builtin plugins and plugin types the build used, e.g. to "+
+			"find deprecated fields across repositories. It holds no names, "+
+			"paths or content.")
+}
+
+// writeUsageReport writes the report to the
+// path given by the --usage-report flag.
+func writeUsageReport(fSys filesys.FileSystem, r *types.UsageReport) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fSys.WriteFile(theFlags.usageReport, append(b, '\n'))
+}
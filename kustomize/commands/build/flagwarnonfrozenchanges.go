@@ -0,0 +1,18 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AddFlagWarnOnFrozenChanges adds the --warn-on-frozen-changes flag.
+func AddFlagWarnOnFrozenChanges(set *pflag.FlagSet) {
+	set.BoolVar(
+		&theFlags.warnOnFrozenChanges,
+		"warn-on-frozen-changes",
+		false,
+		"Instead of failing if a transformer changes a resource annotated "+
+			"kustomize.config.k8s.io/frozen: \"true\", warn and revert the change.")
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+)
+
+// validateRoots validates the flags of a build of several DIRs.
+func validateRoots() error {
+	if theFlags.parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"resources-from-stdin", theFlags.resourcesFromStdin},
+		{flagImmutableAgainstName, theFlags.immutableAgainst != ""},
+		{"usage-report", theFlags.usageReport != ""},
+		{"debug-dump", theFlags.debugDump != ""},
+		{"provenance-manifest", theFlags.provenanceManifest != ""},
+		{flagChunkSizeName, theFlags.chunkSize > 0},
+		{flagOnlyDeltaName, theFlags.onlyDelta},
+	} {
+		if f.set {
+			return fmt.Errorf(
+				"--%s may not be used to build more than one DIR", f.name)
+		}
+	}
+	return nil
+}
+
+// buildRoots builds the kustomizations at the paths, as many at once
+// as the --parallel flag allows, with one Kustomizer sharing the
+// clones of remote bases between them.  Either all their outputs
+// are written, in the order of the paths, or none is, and the
+// failures are summarized to stderr.
+func buildRoots(
+	fSys filesys.FileSystem, writer io.Writer, stderr io.Writer) error {
+	paths := theArgs.kustomizationPaths
+	kOpts := HonorKustomizeFlags(krusty.MakeDefaultOptions())
+	kOpts.ReuseClones = true
+	clearProgress := honorFlagProgress(kOpts, stderr)
+	k := krusty.MakeKustomizer(kOpts)
+	defer k.Cleanup()
+	results := make([]resmap.ResMap, len(paths))
+	errs := make([]error, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < theFlags.parallel && i < len(paths); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				results[j], errs[j] = k.Run(fSys, paths[j])
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	clearProgress()
+	writeWarnings(stderr, kOpts.Warnings)
+	if err := summarizeErrors(stderr, paths, errs); err != nil {
+		return err
+	}
+	if theFlags.applySet.enabled {
+		for i, m := range results {
+			if err := addApplySet(m, paths[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if theFlags.outputPath != "" && fSys.IsDir(theFlags.outputPath) {
+		return writeRootDirs(fSys, paths, results)
+	}
+	// The outputs are concatenated, as YAML documents,
+	// or, in other formats, one after the other.
+	var stream bytes.Buffer
+	for _, m := range results {
+		if stream.Len() > 0 && m.Size() > 0 &&
+			theFlags.outputFormat == krusty.FormatYaml {
+			stream.WriteString("---\n")
+		}
+		if err := k.Emit(&stream, m, theFlags.outputFormat); err != nil {
+			return err
+		}
+	}
+	if theFlags.outputPath != "" {
+		return writeOutputFile(fSys, theFlags.outputPath, stream.Bytes())
+	}
+	_, err := writer.Write(stream.Bytes())
+	return err
+}
+
+// summarizeErrors writes the errors of the builds of the paths,
+// unless the --quiet-errors flag is set, and a table of the
+// results of the builds, returning a batchError, if any failed.
+func summarizeErrors(w io.Writer, paths []string, errs []error) error {
+	result := &batchError{total: len(paths)}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		kind := krusty.KindOf(err)
+		if result.failed == 0 || severity(kind) > severity(result.kind) {
+			result.kind = kind
+		}
+		result.failed++
+		if !theFlags.quietErrors {
+			fmt.Fprintf(w, "Error building %s: %v\n\n", paths[i], err)
+		}
+	}
+	if result.failed == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DIR\tRESULT\tERROR")
+	for i, err := range errs {
+		if err == nil {
+			fmt.Fprintf(tw, "%s\tok\t\n", paths[i])
+			continue
+		}
+		firstLine := strings.SplitN(err.Error(), "\n", 2)[0]
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", paths[i], krusty.KindOf(err), firstLine)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return result
+}
+
+// writeRootDirs writes the resources built from each path
+// to their own files, in a subdirectory, named after the
+// path, of the directory named by the --output flag.
+func writeRootDirs(
+	fSys filesys.FileSystem, paths []string, results []resmap.ResMap) error {
+	var dirs []string
+	pathOf := make(map[string]string)
+	for _, path := range paths {
+		dir := filepath.Join(theFlags.outputPath, rootDirName(path))
+		if other, ok := pathOf[dir]; ok {
+			return fmt.Errorf(
+				"the outputs of %s and %s would both be written to %s",
+				other, path, dir)
+		}
+		pathOf[dir] = path
+		dirs = append(dirs, dir)
+	}
+	for i, dir := range dirs {
+		if err := fSys.MkdirAll(dir); err != nil {
+			return err
+		}
+		if err := MakeWriter(fSys).WriteIndividualFiles(dir, results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rootDirName returns the name of the directory the resources
+// built from path are written to, e.g. overlays_prod for
+// overlays/prod, keeping only letters, digits, '-' and '.'.
+func rootDirName(path string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z',
+			r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, filepath.Clean(path))
+	name = strings.Trim(name, "._")
+	if name == "" {
+		return "root"
+	}
+	return name
+}
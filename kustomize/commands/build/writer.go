@@ -4,12 +4,16 @@
 package build
 
 import (
+	"fmt"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
 	"sigs.k8s.io/yaml"
 )
 
@@ -23,34 +27,140 @@ func MakeWriter(fSys filesys.FileSystem) *Writer {
 	}
 }
 
+// WriteIndividualFiles writes each resource in m to
+// its own file in the directory at dirPath.
 func (w Writer) WriteIndividualFiles(dirPath string, m resmap.ResMap) error {
+	_, err := w.WriteIndividualFilesWithNames(dirPath, m)
+	return err
+}
+
+// WriteIndividualFilesWithNames is like WriteIndividualFiles,
+// but also returns the names of the files written, in the
+// order they were written.
+//
+// Resources annotated with config.kubernetes.io/path are
+// written to that path, relative to dirPath, in the order
+// given by their config.kubernetes.io/index annotations.
+// These annotations aren't written.  All other resources
+// are written to files named after their ids.
+func (w Writer) WriteIndividualFilesWithNames(
+	dirPath string, m resmap.ResMap) ([]string, error) {
+	names, m, err := w.writeAnnotatedPaths(dirPath, m)
+	if err != nil {
+		return nil, err
+	}
 	byNamespace := m.GroupedByCurrentNamespace()
-	for namespace, resList := range byNamespace {
-		for _, res := range resList {
+	var namespaces []string
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	// Written in a fixed order, so the names returned don't vary.
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		for _, res := range byNamespace[namespace] {
 			fName := fileName(res)
 			if len(byNamespace) > 1 {
 				fName = strings.ToLower(namespace) + "_" + fName
 			}
 			if err := w.write(dirPath, fName, res); err != nil {
-				return err
+				return nil, err
 			}
+			names = append(names, fName)
 		}
 	}
 	for _, res := range m.NonNamespaceable() {
-		err := w.write(dirPath, fileName(res), res)
-		if err != nil {
-			return err
+		fName := fileName(res)
+		if err := w.write(dirPath, fName, res); err != nil {
+			return nil, err
 		}
+		names = append(names, fName)
 	}
-	return nil
+	return names, nil
 }
 
-func (w Writer) write(path, fName string, res *resource.Resource) error {
+// writeAnnotatedPaths writes the resources that have a path
+// annotation, returning the names of the files written and
+// a ResMap of the remaining resources.
+func (w Writer) writeAnnotatedPaths(
+	dirPath string, m resmap.ResMap) ([]string, resmap.ResMap, error) {
+	byPath := make(map[string][]*resource.Resource)
+	var paths []string
+	rest := resmap.New()
+	for _, res := range m.Resources() {
+		p, ok := res.GetAnnotations()[kioutil.PathAnnotation]
+		if !ok {
+			if err := rest.Append(res); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		p = filepath.Clean(p)
+		if filepath.IsAbs(p) || p == ".." ||
+			strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			return nil, nil, fmt.Errorf(
+				"%s of %s must be a path within the output directory, not %q",
+				kioutil.PathAnnotation, res.CurId(), p)
+		}
+		if _, ok := byPath[p]; !ok {
+			paths = append(paths, p)
+		}
+		byPath[p] = append(byPath[p], res)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		resList := byPath[p]
+		// Resources lacking an index keep their relative
+		// order, after those that have one.
+		sort.SliceStable(resList, func(i, j int) bool {
+			return fileIndex(resList[i]) < fileIndex(resList[j])
+		})
+		var docs []string
+		for _, res := range resList {
+			res = res.DeepCopy()
+			annotations := res.GetAnnotations()
+			delete(annotations, kioutil.PathAnnotation)
+			delete(annotations, kioutil.IndexAnnotation)
+			res.SetAnnotations(annotations)
+			yml, err := asYaml(res)
+			if err != nil {
+				return nil, nil, err
+			}
+			docs = append(docs, string(yml))
+		}
+		fullPath := filepath.Join(dirPath, p)
+		if err := w.fSys.MkdirAll(filepath.Dir(fullPath)); err != nil {
+			return nil, nil, err
+		}
+		if err := w.fSys.WriteFile(
+			fullPath, []byte(strings.Join(docs, "---\n"))); err != nil {
+			return nil, nil, err
+		}
+	}
+	return paths, rest, nil
+}
+
+func fileIndex(res *resource.Resource) int {
+	s, ok := res.GetAnnotations()[kioutil.IndexAnnotation]
+	if !ok {
+		return int(^uint(0) >> 1)
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return i
+}
+
+func asYaml(res *resource.Resource) ([]byte, error) {
 	m, err := res.Map()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	yml, err := yaml.Marshal(m)
+	return yaml.Marshal(m)
+}
+
+func (w Writer) write(path, fName string, res *resource.Resource) error {
+	yml, err := asYaml(res)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+	resmaptest_test "sigs.k8s.io/kustomize/api/testutils/resmaptest"
+)
+
+func configMap(ns, name string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": ns,
+		},
+	}
+}
+
+func TestWriteIndividualFilesWithNamesOrder(t *testing.T) {
+	m := resmaptest_test.NewRmBuilderDefault(t).
+		Add(configMap("ns2", "b")).
+		Add(configMap("ns1", "b")).
+		Add(configMap("ns3", "a")).
+		Add(configMap("ns1", "a")).
+		Add(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name": "ns1",
+			},
+		}).ResMap()
+	expected := []string{
+		"ns1_v1_configmap_b.yaml",
+		"ns1_v1_configmap_a.yaml",
+		"ns2_v1_configmap_b.yaml",
+		"ns3_v1_configmap_a.yaml",
+		"v1_namespace_ns1.yaml",
+	}
+	// Resources are grouped by namespace in a map, so write
+	// repeatedly to catch them being written in the map's order.
+	for i := 0; i < 10; i++ {
+		fSys := filesys.MakeFsInMemory()
+		names, err := MakeWriter(fSys).WriteIndividualFilesWithNames("out", m)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, expected, names)
+	}
+}
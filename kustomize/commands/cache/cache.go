@@ -0,0 +1,207 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cache holds the cache commands, which manage the
+// clones of remote bases that builds run with --cache keep.
+package cache
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+)
+
+// NewCmdCache returns an instance of 'cache' subcommand.
+func NewCmdCache(w io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "cache",
+		Short: "Manages the clones of remote bases that builds keep",
+		Long: `Manages the clones of remote bases that builds run with
+--cache keep in the cache directory, $` + konfig.KustomizeCacheHomeEnv + ` if set,
+else the kustomize directory of the user's cache directory.
+`,
+		Example: `
+	# Lists the clones kept
+	kustomize cache ls
+
+	# Removes those unused for 30 days, and then the least
+	# recently used ones while the clones take more than 5Gi
+	kustomize cache gc --max-age 30d --max-size 5Gi
+`,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.AddCommand(newCmdCacheLs(w))
+	c.AddCommand(newCmdCacheGc(w))
+	return c
+}
+
+func newCmdCacheLs(w io.Writer) *cobra.Command {
+	var dir string
+	c := &cobra.Command{
+		Use:   "ls",
+		Short: "Lists the clones kept, most recently used first",
+		Long: `Lists the clones kept, most recently used first, with
+their size, when they were last used and the processes using
+them, as host:pid.
+`,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clones, err := krusty.ListCachedClones(build.CacheClonesDir(dir))
+			if err != nil {
+				return err
+			}
+			return printClones(w, clones)
+		},
+	}
+	addFlagCacheDir(c, &dir)
+	return c
+}
+
+func newCmdCacheGc(w io.Writer) *cobra.Command {
+	var dir, maxAge, maxSize string
+	c := &cobra.Command{
+		Use:   "gc",
+		Short: "Removes the clones unused for long, or beyond a size",
+		Long: `Removes the clones last used longer ago than --max-age, and
+then, least recently used first, those making the clones take more
+than --max-size, listing them.  The clones that running builds use
+are left alone, so it may be run at any time, e.g. from a cron job.
+The locks and partial clones of builds that were killed are removed
+too.
+`,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := parseAge(maxAge)
+			if err != nil {
+				return fmt.Errorf("--max-age: %v", err)
+			}
+			size, err := parseSize(maxSize)
+			if err != nil {
+				return fmt.Errorf("--max-size: %v", err)
+			}
+			removed, err := krusty.CollectCachedClones(
+				build.CacheClonesDir(dir), age, size)
+			if err != nil {
+				return err
+			}
+			return printClones(w, removed)
+		},
+	}
+	addFlagCacheDir(c, &dir)
+	c.Flags().StringVar(
+		&maxAge, "max-age", "",
+		"Remove the clones last used longer ago than this, e.g. 30d or 12h.")
+	c.Flags().StringVar(
+		&maxSize, "max-size", "",
+		"Remove the least recently used clones while the clones take more "+
+			"than this, e.g. 5Gi or 500M.")
+	return c
+}
+
+func addFlagCacheDir(c *cobra.Command, dir *string) {
+	c.Flags().StringVar(
+		dir, "cache-dir", "",
+		"The cache directory, if not the default one.")
+}
+
+const lastUsedLayout = "2006-01-02 15:04"
+
+func printClones(w io.Writer, clones []krusty.CachedClone) error {
+	if len(clones) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tREF\tSIZE\tLAST USED\tUSERS")
+	for _, c := range clones {
+		ref, users := c.Ref, strings.Join(c.Users, ",")
+		if ref == "" {
+			ref = "-"
+		}
+		if users == "" {
+			users = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			c.Repo, ref, formatSize(c.Size),
+			c.LastUsed.Local().Format(lastUsedLayout), users)
+	}
+	return tw.Flush()
+}
+
+// parseAge parses a duration as time.ParseDuration does,
+// also taking a number of days, e.g. 30d, or weeks.
+// The empty duration is zero.
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if n := strings.TrimSuffix(s, suffix); n != s {
+			v, err := strconv.ParseUint(n, 10, 32)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+var sizeUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// parseSize parses a number of bytes, with, as Kubernetes
+// quantities may have, a binary or decimal suffix, e.g.
+// 5Gi or 500M.  The empty size is zero.
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	scale := int64(1)
+	n := s
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			n, scale = strings.TrimSuffix(s, u.suffix), u.scale
+			break
+		}
+	}
+	v, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || v < 0 || v > (1<<62)/scale {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return v * scale, nil
+}
+
+// formatSize formats a number of bytes with a binary suffix.
+func formatSize(n int64) string {
+	for i := len(sizeUnits)/2 - 1; i >= 0; i-- {
+		if u := sizeUnits[i]; n >= u.scale {
+			return fmt.Sprintf("%.1f%s", float64(n)/float64(u.scale), u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
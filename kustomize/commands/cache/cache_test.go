@@ -0,0 +1,130 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeClone writes a clone, as builds run with --cache
+// keep them, to the cache directory dir.
+func writeClone(t *testing.T, dir, id, ref string, size int, lastUsed time.Time) {
+	t.Helper()
+	clones := filepath.Join(dir, "clones")
+	if !assert.NoError(t, os.MkdirAll(filepath.Join(clones, id), 0700)) {
+		t.FailNow()
+	}
+	assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(clones, id, "kustomization.yaml"), make([]byte, size), 0600))
+	assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(clones, id+".json"), []byte(fmt.Sprintf(
+			`{"repo":"https://github.com/example/repo.git","ref":%q,`+
+				`"created":%q,"lastUsed":%q}`, ref,
+			lastUsed.Format(time.RFC3339), lastUsed.Format(time.RFC3339))),
+		0600))
+}
+
+func runCache(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	c := NewCmdCache(&out)
+	c.SetArgs(args)
+	c.SetOut(&bytes.Buffer{})
+	c.SetErr(&bytes.Buffer{})
+	err := c.Execute()
+	return out.String(), err
+}
+
+func TestCacheLsAndGc(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	writeClone(t, dir, "aaaa", "v1", 2048, now.Add(-40*24*time.Hour))
+	writeClone(t, dir, "bbbb", "v2", 3<<20, now.Add(-time.Hour))
+	writeClone(t, dir, "cccc", "v3", 100, now.Add(-2*time.Hour))
+
+	out, err := runCache(t, "ls", "--cache-dir", dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	lastUsed := func(d time.Duration) string {
+		return now.Add(-d).Local().Format(lastUsedLayout)
+	}
+	assert.Equal(t, `REPO                                 REF  SIZE   LAST USED         USERS
+https://github.com/example/repo.git  v2   3.0Mi  `+lastUsed(time.Hour)+`  -
+https://github.com/example/repo.git  v3   100B   `+lastUsed(2*time.Hour)+`  -
+https://github.com/example/repo.git  v1   2.0Ki  `+lastUsed(40*24*time.Hour)+`  -
+`, out)
+
+	// v1 expired, and then v3 is the least recently used.
+	out, err = runCache(t, "gc", "--cache-dir", dir,
+		"--max-age", "30d", "--max-size", "3Mi")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, out, " v1 ")
+	assert.Contains(t, out, " v3 ")
+	assert.NotContains(t, out, " v2 ")
+	out, err = runCache(t, "ls", "--cache-dir", dir)
+	assert.NoError(t, err)
+	assert.Contains(t, out, " v2 ")
+	assert.NotContains(t, out, " v1 ")
+	assert.NotContains(t, out, " v3 ")
+}
+
+func TestCacheLsEmpty(t *testing.T) {
+	out, err := runCache(t, "ls", "--cache-dir", filepath.Join(t.TempDir(), "none"))
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestCacheGcInvalidFlags(t *testing.T) {
+	dir := t.TempDir()
+	_, err := runCache(t, "gc", "--cache-dir", dir, "--max-age", "30x")
+	assert.Error(t, err)
+	_, err = runCache(t, "gc", "--cache-dir", dir, "--max-size", "5Gb")
+	assert.Error(t, err)
+}
+
+func TestParseAge(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"":    0,
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	} {
+		d, err := parseAge(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, d, s)
+	}
+	for _, s := range []string{"d", "-1h", "1.5d", "soon"} {
+		_, err := parseAge(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"":     0,
+		"1024": 1024,
+		"5Gi":  5 << 30,
+		"500M": 500e6,
+		"2Ki":  2048,
+	} {
+		n, err := parseSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, n, s)
+	}
+	for _, s := range []string{"Gi", "-1", "5GB", "1.5Gi"} {
+		_, err := parseSize(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -16,9 +16,16 @@ import (
 	"sigs.k8s.io/kustomize/cmd/config/completion"
 	"sigs.k8s.io/kustomize/cmd/config/configcobra"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/cache"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/create"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/daemon"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/diff"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/inspect"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/openapi"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/test"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/vendorbuild"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/verify"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/version"
 )
 
@@ -49,10 +56,19 @@ See https://sigs.k8s.io/kustomize
 		completion.NewCommand(),
 		makeBuildCommand(fSys, stdOut),
 		edit.NewCmdEdit(
-			fSys, pvd.GetFieldValidator(), pvd.GetResourceFactory()),
+			fSys, pvd.GetFieldValidator(), pvd.GetResourceFactory(), stdOut),
 		create.NewCmdCreate(fSys, pvd.GetResourceFactory()),
+		diff.NewCmdDiff(fSys, stdOut),
+		inspect.NewCmdInspect(fSys, stdOut),
+		verify.NewCmdVerify(fSys, stdOut),
+		test.NewCmdTest(fSys, stdOut),
 		version.NewCmdVersion(stdOut),
 		openapi.NewCmdOpenAPI(stdOut),
+		vendorbuild.NewCmdVendor(fSys),
+		cache.NewCmdCache(stdOut),
+		daemon.NewCmdDaemon(stdOut, func(w io.Writer) *cobra.Command {
+			return makeBuildCommand(fSys, w)
+		}),
 	)
 	configcobra.AddCommands(c, konfig.ProgramName)
 
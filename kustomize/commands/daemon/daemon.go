@@ -0,0 +1,151 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package daemon holds the daemon command, running a process
+// that makes builds for the kustomize commands run after it,
+// keeping what builds redo in its memory and on disk.
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDaemon returns the daemon command, its daemon
+// running the builds of the commands of newBuild.
+func NewCmdDaemon(w io.Writer, newBuild BuildCommandFunc) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "daemon",
+		Short: "Runs a daemon making builds, to share their work",
+		Long: fmt.Sprintf(`Runs a daemon making builds, to share their work.
+
+While a daemon listens on its unix socket, the build command
+has it make the build, unless it reads resources from stdin.
+The builds share the daemon's parsed OpenAPI schemas, and its
+clones of remote bases, with the helm charts pulled into them,
+saving the work of builds run one after another, e.g. in CI.
+
+The socket is %s, unless the %s
+environment variable names another, in a directory that only the
+user can write to; builds only use a daemon of the user.  Setting
+the %s environment variable to off keeps builds from using a
+daemon.
+
+Builds pass the daemon only the environment variables that
+kustomize, git, ssh and helm read, e.g. PATH, HOME and GIT_*,
+and those the %s environment variable lists, separated
+by commas, e.g. those of the envSources of kustomizations.
+`, SocketPath(), SocketEnv, DisableEnv, PassEnv),
+		Example: `  kustomize daemon start
+  kustomize build overlays/production
+  kustomize daemon stop`,
+	}
+	c.AddCommand(
+		newCmdStart(w, newBuild),
+		newCmdStatus(w),
+		newCmdStop(w),
+	)
+	return c
+}
+
+func newCmdStart(w io.Writer, newBuild BuildCommandFunc) *cobra.Command {
+	var foreground bool
+	var cloneTTL time.Duration
+	c := &cobra.Command{
+		Use:          "start",
+		Short:        "Starts a daemon in the background",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			socket := SocketPath()
+			if foreground {
+				s, err := listen(socket, newBuild, cloneTTL)
+				if err != nil {
+					return err
+				}
+				return s.serve()
+			}
+			if _, err := call(socket, &request{Op: opStatus}); err == nil {
+				return fmt.Errorf("a daemon is already listening on %s", socket)
+			}
+			return startInBackground(w, socket, cloneTTL)
+		},
+	}
+	c.Flags().BoolVar(&foreground, "foreground", false,
+		"run the daemon in this process, rather than in the background.")
+	c.Flags().DurationVar(&cloneTTL, "clone-ttl", time.Hour,
+		"how long to keep the clones of remote bases, "+
+			"so that builds see the changes of the branches they're at.")
+	return c
+}
+
+// startInBackground starts the daemon in a process of its own,
+// and waits for it to listen on the socket.
+func startInBackground(w io.Writer, socket string, cloneTTL time.Duration) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	d := exec.Command(self, "daemon", "start",
+		"--foreground", "--clone-ttl", cloneTTL.String())
+	if err = d.Start(); err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- d.Wait() }()
+	for i := 0; i < 100; i++ {
+		select {
+		case err = <-exited:
+			return fmt.Errorf("the daemon exited: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+		if _, err = call(socket, &request{Op: opStatus}); err == nil {
+			fmt.Fprintf(w, "daemon %d listening on %s\n", d.Process.Pid, socket)
+			return d.Process.Release()
+		}
+	}
+	d.Process.Kill()
+	return fmt.Errorf("the daemon didn't listen on %s: %v", socket, err)
+}
+
+func newCmdStatus(w io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "status",
+		Short:        "Describes the running daemon",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := call(SocketPath(), &request{Op: opStatus})
+			if err != nil {
+				return fmt.Errorf("no daemon is listening on %s", SocketPath())
+			}
+			s := resp.Status
+			fmt.Fprintf(w, "daemon %d, version %s, listening on %s\n",
+				s.Pid, s.Version, s.Socket)
+			fmt.Fprintf(w, "running for %s, made %d builds\n",
+				time.Since(s.Started).Round(time.Second), s.Builds)
+			return nil
+		},
+	}
+}
+
+func newCmdStop(w io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "stop",
+		Short:        "Stops the running daemon",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := call(SocketPath(), &request{Op: opStop}); err != nil {
+				return fmt.Errorf("no daemon is listening on %s", SocketPath())
+			}
+			fmt.Fprintln(w, "daemon stopped")
+			return nil
+		},
+	}
+}
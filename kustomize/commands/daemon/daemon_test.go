@@ -0,0 +1,144 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBuild echoes its args, working directory and FOO.
+func fakeBuild(w io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "build",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && args[0] == "fail" {
+				return fmt.Errorf("failed")
+			}
+			wd, _ := os.Getwd()
+			fmt.Fprintf(w, "%s %s %s\n",
+				strings.Join(args, ","), filepath.Base(wd), os.Getenv("FOO"))
+			return nil
+		},
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "d.sock")
+	os.Setenv(SocketEnv, socket)
+	defer os.Unsetenv(SocketEnv)
+
+	var stdout, stderr bytes.Buffer
+	_, ok := Forward([]string{"build", "a"}, &stdout, &stderr)
+	assert.False(t, ok, "forwarded without a daemon")
+
+	s, err := listen(socket, fakeBuild, time.Hour)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	served := make(chan error)
+	go func() { served <- s.serve() }()
+	_, err = listen(socket, fakeBuild, time.Hour)
+	assert.EqualError(t, err, "a daemon is already listening on "+socket)
+
+	work := filepath.Join(dir, "work")
+	assert.NoError(t, os.Mkdir(work, 0700))
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(work))
+	defer os.Chdir(wd)
+	os.Setenv("FOO", "bar")
+	defer os.Unsetenv("FOO")
+
+	// FOO isn't passed unless listed.
+	code, ok := Forward([]string{"build", "a", "b"}, &stdout, &stderr)
+	assert.True(t, ok)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "a,b work \n", stdout.String())
+
+	stdout.Reset()
+	os.Setenv(PassEnv, "BAR, FOO")
+	code, ok = Forward([]string{"build", "a", "b"}, &stdout, &stderr)
+	os.Unsetenv(PassEnv)
+	assert.True(t, ok)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "a,b work bar\n", stdout.String())
+
+	stdout.Reset()
+	code, ok = Forward([]string{"build", "fail"}, &stdout, &stderr)
+	assert.True(t, ok)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "Error: failed\n", stderr.String())
+
+	for _, args := range [][]string{
+		{"edit", "fix"},
+		{"build", "--resources-from-stdin", "."},
+		{"build", "--resources-from-stdin=true", "."},
+	} {
+		_, ok = Forward(args, &stdout, &stderr)
+		assert.False(t, ok, "forwarded %v", args)
+	}
+	os.Setenv(DisableEnv, "off")
+	_, ok = Forward([]string{"build"}, &stdout, &stderr)
+	os.Unsetenv(DisableEnv)
+	assert.False(t, ok, "forwarded with the daemon disabled")
+
+	resp, err := call(socket, &request{Op: opBuild, Version: "v0.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "the daemon is version unknown, not v0.0.0", resp.Error)
+
+	resp, err = call(socket, &request{Op: opStatus})
+	assert.NoError(t, err)
+	assert.Equal(t, os.Getpid(), resp.Status.Pid)
+	assert.Equal(t, 3, resp.Status.Builds)
+
+	_, err = call(socket, &request{Op: opStop})
+	assert.NoError(t, err)
+	assert.NoError(t, <-served)
+	_, err = os.Stat(socket)
+	assert.True(t, os.IsNotExist(err), "the socket remains")
+}
+
+func TestSocketOfOtherUsers(t *testing.T) {
+	shared := t.TempDir()
+	assert.NoError(t, os.Chmod(shared, 0777))
+	socket := filepath.Join(shared, "d.sock")
+	_, err := listen(socket, fakeBuild, time.Hour)
+	assert.EqualError(t, err,
+		"the socket directory "+shared+" is writable by other users")
+
+	// One listening there anyway isn't used.
+	l, err := net.Listen("unix", socket)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer l.Close()
+	os.Setenv(SocketEnv, socket)
+	defer os.Unsetenv(SocketEnv)
+	var stdout, stderr bytes.Buffer
+	_, ok := Forward([]string{"build", "a"}, &stdout, &stderr)
+	assert.False(t, ok, "forwarded to a socket others may have made")
+}
+
+func TestPassedEnv(t *testing.T) {
+	os.Setenv(PassEnv, "TENANT")
+	defer os.Unsetenv(PassEnv)
+	assert.Equal(t,
+		[]string{"PATH=/bin", "GIT_SSH_COMMAND=ssh", "TENANT=acme"},
+		passedEnv([]string{
+			"PATH=/bin", "GITHUB_TOKEN=secret", "GIT_SSH_COMMAND=ssh",
+			"TENANT=acme", "AWS_SECRET_ACCESS_KEY=secret",
+		}))
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Forward has the daemon, if one is listening, run the command
+// of the arguments, if it's a build, writing its output to
+// stdout and stderr and returning its exit code.
+//
+// It returns false, and the command is to run in this process,
+// if it isn't a build, or reads stdin, or if no daemon of this
+// version answers, or DisableEnv is "off".
+func Forward(args []string, stdout, stderr io.Writer) (int, bool) {
+	if os.Getenv(DisableEnv) == "off" ||
+		len(args) == 0 || args[0] != opBuild || readsStdin(args) {
+		return 0, false
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return 0, false
+	}
+	resp, err := call(SocketPath(), &request{
+		Op:      opBuild,
+		Version: version(),
+		Args:    args[1:],
+		Dir:     dir,
+		Env:     passedEnv(os.Environ()),
+	})
+	if err != nil || resp.Error != "" {
+		// A build run here gives the same result, or, if the
+		// socket isn't the user's, a trustworthy one.
+		return 0, false
+	}
+	stdout.Write(resp.Stdout)
+	stderr.Write(resp.Stderr)
+	return resp.ExitCode, true
+}
+
+// passedNames and passedPrefixes match the environment
+// variables builds, and the programs they run, git, ssh
+// and helm, read, which Forward passes to the daemon.
+var (
+	passedNames = map[string]bool{
+		"PATH": true, "HOME": true, "USER": true, "LOGNAME": true,
+		"SHELL": true, "TMPDIR": true, "TZ": true, "LANG": true,
+		"TERM": true, "SSH_AUTH_SOCK": true,
+		"HTTP_PROXY": true, "HTTPS_PROXY": true, "NO_PROXY": true,
+		"http_proxy": true, "https_proxy": true, "no_proxy": true,
+	}
+	passedPrefixes = []string{"LC_", "XDG_", "KUSTOMIZE_", "GIT_", "HELM_"}
+)
+
+// passedEnv returns the variables of env to pass to the daemon,
+// those builds read and those PassEnv lists, rather than all of
+// them, e.g. with the tokens of other programs.
+func passedEnv(env []string) []string {
+	extra := make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv(PassEnv), ",") {
+		extra[strings.TrimSpace(name)] = true
+	}
+	var result []string
+	for _, kv := range env {
+		name := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name = kv[:i]
+		}
+		if passedNames[name] || extra[name] || hasPassedPrefix(name) {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
+
+func hasPassedPrefix(name string) bool {
+	for _, p := range passedPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// readsStdin returns whether the build arguments
+// have it read resources from stdin.
+func readsStdin(args []string) bool {
+	for _, a := range args {
+		if a == "--" {
+			return false
+		}
+		if a == "--resources-from-stdin" ||
+			strings.HasPrefix(a, "--resources-from-stdin=") &&
+				a != "--resources-from-stdin=false" {
+			return true
+		}
+	}
+	return false
+}
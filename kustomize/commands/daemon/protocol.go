@@ -0,0 +1,100 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"sigs.k8s.io/kustomize/api/provenance"
+)
+
+const (
+	// SocketEnv names the environment variable holding the
+	// path of the daemon's unix socket, overriding the default.
+	SocketEnv = "KUSTOMIZE_DAEMON_SOCKET"
+
+	// DisableEnv names the environment variable which,
+	// set to "off", keeps builds from using a daemon.
+	DisableEnv = "KUSTOMIZE_DAEMON"
+
+	// PassEnv names the environment variable listing, separated
+	// by commas, the other environment variables builds are to
+	// have the daemon pass on, e.g. those of envSources.
+	PassEnv = "KUSTOMIZE_DAEMON_ENV"
+)
+
+// The operations of requests.
+const (
+	opBuild  = "build"
+	opStatus = "status"
+	opStop   = "stop"
+)
+
+// request is what a client sends the daemon, as JSON,
+// one per connection.
+type request struct {
+	Op string
+	// Version is the client's version; the daemon only
+	// builds for clients of its own version.
+	Version string
+	// Args are the arguments of the build command.
+	Args []string
+	// Dir and Env are the client's working directory and
+	// the part of its environment builds read, which the
+	// build runs in.
+	Dir string
+	Env []string
+}
+
+// response is what the daemon answers a request with.
+type response struct {
+	// Error, if set, is why the daemon couldn't do what it
+	// was asked; a failed build is an ExitCode and Stderr.
+	Error    string
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+	Status   *Status
+}
+
+// Status describes a running daemon.
+type Status struct {
+	Pid     int
+	Version string
+	Socket  string
+	Started time.Time
+	Builds  int
+}
+
+func version() string {
+	return provenance.GetProvenance().Semver()
+}
+
+// call sends the request to the daemon listening on the
+// socket, once it's checked to be the user's, returning its
+// response.
+func call(socket string, req *request) (*response, error) {
+	if err := checkSocket(socket); err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = checkPeer(conn); err != nil {
+		return nil, err
+	}
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	resp := &response{}
+	if err = json.NewDecoder(conn).Decode(resp); err != nil {
+		return nil, fmt.Errorf("reading the daemon's response: %v", err)
+	}
+	return resp, nil
+}
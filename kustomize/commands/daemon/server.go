@@ -0,0 +1,221 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+)
+
+// BuildCommandFunc returns a build command writing its output to w.
+type BuildCommandFunc func(w io.Writer) *cobra.Command
+
+// server runs builds for clients, one at a time, in its own
+// process, where the parsed OpenAPI schemas and the clones of
+// remote bases, and the helm charts pulled into them, outlive
+// the builds.
+//
+// Builds are made one at a time as a build changes the
+// process' working directory and environment to its client's,
+// and the build command keeps its flags in package variables.
+type server struct {
+	newBuild BuildCommandFunc
+	listener net.Listener
+	clones   *krusty.CloneCache
+	// cloneTTL is how long clones are kept, so that builds
+	// get the changes of the branches remote bases are at.
+	cloneTTL time.Duration
+
+	// buildMu is held while building.
+	buildMu     sync.Mutex
+	lastCleanup time.Time
+
+	mu       sync.Mutex
+	status   Status
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// listen returns a server listening on the socket, unless
+// another daemon already is, creating its directory, only
+// the user's, if need be.
+func listen(
+	socket string, newBuild BuildCommandFunc, cloneTTL time.Duration) (*server, error) {
+	if err := makeSocketDir(filepath.Dir(socket)); err != nil {
+		return nil, err
+	}
+	if _, err := call(socket, &request{Op: opStatus}); err == nil {
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	if _, err := os.Lstat(socket); err == nil {
+		// Left behind by a daemon that didn't stop.
+		if err = checkSocket(socket); err != nil {
+			return nil, err
+		}
+		os.Remove(socket)
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(socket, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	now := time.Now()
+	return &server{
+		newBuild: newBuild,
+		listener: l,
+		clones:   krusty.NewCloneCache(),
+		cloneTTL: cloneTTL,
+		status: Status{
+			Pid:     os.Getpid(),
+			Version: version(),
+			Socket:  socket,
+			Started: now,
+		},
+		lastCleanup: now,
+		stopped:     make(chan struct{}),
+	}, nil
+}
+
+// serve answers requests until the server is stopped.
+func (s *server) serve() error {
+	defer s.clones.Cleanup()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.stopped:
+				return nil
+			default:
+				return err
+			}
+		}
+		go s.handle(conn)
+	}
+}
+
+// stop closes the listener, ending serve.
+func (s *server) stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopped)
+		s.listener.Close()
+	})
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	req := &request{}
+	resp := &response{}
+	if err := checkPeer(conn); err != nil {
+		resp.Error = err.Error()
+	} else if err := json.NewDecoder(conn).Decode(req); err != nil {
+		resp.Error = fmt.Sprintf("reading the request: %v", err)
+	} else {
+		resp = s.do(req)
+	}
+	json.NewEncoder(conn).Encode(resp)
+	if req.Op == opStop {
+		s.stop()
+	}
+}
+
+func (s *server) do(req *request) *response {
+	switch req.Op {
+	case opStatus:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		status := s.status
+		return &response{Status: &status}
+	case opStop:
+		return &response{}
+	case opBuild:
+		if req.Version != s.status.Version {
+			return &response{Error: fmt.Sprintf(
+				"the daemon is version %s, not %s", s.status.Version, req.Version)}
+		}
+		return s.build(req)
+	default:
+		return &response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+}
+
+// build runs the build command with the request's arguments,
+// in its client's working directory and environment.
+func (s *server) build(req *request) *response {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+	s.mu.Lock()
+	s.status.Builds++
+	s.mu.Unlock()
+	if time.Since(s.lastCleanup) > s.cloneTTL {
+		s.clones.Cleanup()
+		s.lastCleanup = time.Now()
+	}
+	restore, err := enter(req.Dir, req.Env)
+	if err != nil {
+		return &response{Error: err.Error()}
+	}
+	defer restore()
+	var stdout, stderr bytes.Buffer
+	log.SetOutput(&stderr)
+	defer log.SetOutput(os.Stderr)
+	build.UseCloneCache(s.clones)
+	defer build.UseCloneCache(nil)
+	cmd := s.newBuild(&stdout)
+	cmd.SetArgs(req.Args)
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	// Printed here, as cobra prints errors to the output set.
+	cmd.SilenceErrors = true
+	resp := &response{}
+	if err = cmd.Execute(); err != nil {
+		fmt.Fprintln(&stderr, "Error:", err)
+		resp.ExitCode = build.ExitCode(err)
+	}
+	resp.Stdout, resp.Stderr = stdout.Bytes(), stderr.Bytes()
+	return resp
+}
+
+// enter changes the process' working directory and environment
+// to dir and env, returning the function changing them back.
+func enter(dir string, env []string) (func(), error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chdir(dir); err != nil {
+		return nil, err
+	}
+	oldEnv := os.Environ()
+	setEnv(env)
+	return func() {
+		os.Chdir(wd)
+		setEnv(oldEnv)
+	}, nil
+}
+
+func setEnv(env []string) {
+	os.Clearenv()
+	for _, kv := range env {
+		if i := strings.Index(kv, "="); i > 0 {
+			os.Setenv(kv[:i], kv[i+1:])
+		}
+	}
+}
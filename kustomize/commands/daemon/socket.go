@@ -0,0 +1,89 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// SocketPath returns the path of the daemon's unix socket: the
+// value of SocketEnv, or else daemon.sock in the directory of
+// the user's sockets, kustomize in $XDG_RUNTIME_DIR, or else
+// kustomize-<uid> in the temporary directory.
+func SocketPath() string {
+	if p := os.Getenv(SocketEnv); p != "" {
+		return p
+	}
+	if d := os.Getenv("XDG_RUNTIME_DIR"); d != "" {
+		return filepath.Join(d, "kustomize", "daemon.sock")
+	}
+	return filepath.Join(
+		os.TempDir(), fmt.Sprintf("kustomize-%d", os.Getuid()), "daemon.sock")
+}
+
+// makeSocketDir creates the directory of the socket, only the
+// user's, unless it exists, and checks it.
+func makeSocketDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return checkSocketDir(dir)
+}
+
+// checkSocketDir checks that the directory of the socket is
+// the user's, and that other users can't add to it, so that
+// they can't answer builds through sockets of theirs.
+func checkSocketDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("the socket directory %s isn't a directory", dir)
+	}
+	if uid, ok := fileOwner(fi); ok && uid != os.Getuid() {
+		return fmt.Errorf("the socket directory %s isn't the user's", dir)
+	}
+	if fi.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf(
+			"the socket directory %s is writable by other users", dir)
+	}
+	return nil
+}
+
+// checkSocket checks that the socket, and its directory,
+// are the user's.
+func checkSocket(socket string) error {
+	if err := checkSocketDir(filepath.Dir(socket)); err != nil {
+		return err
+	}
+	fi, err := os.Lstat(socket)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s isn't a socket", socket)
+	}
+	if uid, ok := fileOwner(fi); ok && uid != os.Getuid() {
+		return fmt.Errorf("the socket %s isn't the user's", socket)
+	}
+	return nil
+}
+
+// checkPeer checks that the process at the other end of the
+// connection is the user's, where the platform tells.
+func checkPeer(conn net.Conn) error {
+	uid, ok, err := peerUid(conn)
+	if err != nil {
+		return fmt.Errorf("getting the peer of the daemon socket: %v", err)
+	}
+	if ok && uid != os.Getuid() {
+		return fmt.Errorf(
+			"the peer of the daemon socket is user %d, not %d", uid, os.Getuid())
+	}
+	return nil
+}
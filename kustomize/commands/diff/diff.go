@@ -0,0 +1,289 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package diff holds the diff command, which compares
+// the output of two builds resource by resource.
+package diff
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+type diffFlags struct {
+	promote     bool
+	overlaysDir string
+}
+
+// NewCmdDiff returns an instance of 'diff' subcommand.
+func NewCmdDiff(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	var flags diffFlags
+	c := &cobra.Command{
+		Use:   "diff FROM TO",
+		Short: "Show how the build output of TO would change to match FROM",
+		Long: `Build the kustomizations FROM and TO, and show, resource by resource,
+what would change in TO's output if it were replaced by FROM's.
+
+Resources are matched by kind and name, and grouped by kind.
+Changes to container images are summarized first.
+
+With --promote, FROM and TO name environments rather than directories,
+and are looked up in the conventional overlay layout, i.e. as
+<overlays>/FROM and <overlays>/TO.
+`,
+		Example: `
+	# Show what promoting the stage overlay to prod would change
+	kustomize diff --promote stage prod
+
+	# Compare two kustomizations directly
+	kustomize diff overlays/stage overlays/prod
+`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("specify FROM and TO")
+			}
+			from, to := args[0], args[1]
+			if flags.promote {
+				from = filepath.Join(flags.overlaysDir, from)
+				to = filepath.Join(flags.overlaysDir, to)
+			}
+			return runDiff(fSys, w, from, to)
+		},
+	}
+	c.Flags().BoolVar(
+		&flags.promote,
+		"promote",
+		false,
+		"Treat FROM and TO as names of environments, "+
+			"i.e. of directories in the overlays directory.")
+	c.Flags().StringVar(
+		&flags.overlaysDir,
+		"overlays",
+		"overlays",
+		"The directory holding one overlay per environment, used with --promote.")
+	build.AddFlagLoadRestrictor(c.Flags())
+	build.AddFlagEnablePlugins(c.Flags())
+	build.AddFlagEnableHelm(c.Flags())
+	return c
+}
+
+func runDiff(fSys filesys.FileSystem, w io.Writer, from, to string) error {
+	fromM, err := makeBuild(fSys, from)
+	if err != nil {
+		return err
+	}
+	toM, err := makeBuild(fSys, to)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, Promotion(fromM, toM, from, to))
+	return err
+}
+
+func makeBuild(fSys filesys.FileSystem, path string) (resmap.ResMap, error) {
+	k := krusty.MakeKustomizer(
+		build.HonorKustomizeFlags(krusty.MakeDefaultOptions()))
+	m, err := k.Run(fSys, path)
+	if err != nil {
+		return nil, fmt.Errorf("building %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// key identifies a resource across environments, in which
+// namespaces usually differ.
+type key struct {
+	kind string
+	name string
+}
+
+func (k key) String() string {
+	return k.kind + "/" + k.name
+}
+
+func keyOf(r *resource.Resource) key {
+	return key{kind: r.GetGvk().StringWoEmptyField(), name: r.GetName()}
+}
+
+func index(m resmap.ResMap) map[key]*resource.Resource {
+	result := make(map[key]*resource.Resource)
+	for _, r := range m.Resources() {
+		result[keyOf(r)] = r
+	}
+	return result
+}
+
+// Promotion returns a report of the changes to the resources
+// in to that replacing them with those in from would make.
+func Promotion(from, to resmap.ResMap, fromName, toName string) string {
+	fromIdx, toIdx := index(from), index(to)
+	var keys []key
+	for k := range fromIdx {
+		keys = append(keys, k)
+	}
+	for k := range toIdx {
+		if _, ok := fromIdx[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].kind != keys[j].kind {
+			return keys[i].kind < keys[j].kind
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	var images []string
+	byKind := make(map[string][]string)
+	var kinds []string
+	for _, k := range keys {
+		newRes, oldRes := fromIdx[k], toIdx[k]
+		images = append(images, imageChanges(k, oldRes, newRes)...)
+		var entry string
+		switch {
+		case oldRes == nil:
+			entry = "  + " + k.name + "\n"
+		case newRes == nil:
+			entry = "  - " + k.name + "\n"
+		default:
+			d := unifiedDiff(oldRes, newRes, toName, fromName)
+			if d == "" {
+				continue
+			}
+			entry = "  ~ " + k.name + "\n" + indent(d, "    ")
+		}
+		if _, ok := byKind[k.kind]; !ok {
+			kinds = append(kinds, k.kind)
+		}
+		byKind[k.kind] = append(byKind[k.kind], entry)
+	}
+
+	var b strings.Builder
+	if len(images) == 0 && len(kinds) == 0 {
+		fmt.Fprintf(&b, "No changes from %s to %s.\n", toName, fromName)
+		return b.String()
+	}
+	if len(images) > 0 {
+		b.WriteString("Image changes:\n")
+		for _, i := range images {
+			b.WriteString("  " + i + "\n")
+		}
+		b.WriteString("\n")
+	}
+	for _, kind := range kinds {
+		b.WriteString(kind + ":\n")
+		for _, e := range byKind[kind] {
+			b.WriteString(e)
+		}
+	}
+	return b.String()
+}
+
+func unifiedDiff(oldRes, newRes *resource.Resource, oldName, newName string) string {
+	d, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(stripNamespace(oldRes)),
+		B:        difflib.SplitLines(stripNamespace(newRes)),
+		FromFile: oldName,
+		ToFile:   newName,
+		Context:  2,
+	})
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	return d
+}
+
+// stripNamespace returns the resource as YAML without its
+// namespace, which is expected to differ between environments.
+func stripNamespace(r *resource.Resource) string {
+	n := r.AsRNode()
+	_ = n.PipeE(yaml.Lookup(yaml.MetadataField), yaml.Clear(yaml.NamespaceField))
+	return n.MustString()
+}
+
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// imageChanges summarizes the differences in the
+// container images used by two versions of a resource.
+func imageChanges(k key, oldRes, newRes *resource.Resource) []string {
+	oldImages, newImages := containerImages(oldRes), containerImages(newRes)
+	var names []string
+	for n := range newImages {
+		names = append(names, n)
+	}
+	for n := range oldImages {
+		if _, ok := newImages[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	var result []string
+	for _, n := range names {
+		o, okO := oldImages[n]
+		nw, okN := newImages[n]
+		switch {
+		case okO && okN && o != nw:
+			result = append(result, fmt.Sprintf("%s %s: %s -> %s", k, n, o, nw))
+		case !okO && okN && oldRes != nil:
+			result = append(result, fmt.Sprintf("%s %s: added %s", k, n, nw))
+		case okO && !okN && newRes != nil:
+			result = append(result, fmt.Sprintf("%s %s: removed %s", k, n, o))
+		}
+	}
+	return result
+}
+
+// containerImages maps container names to images for every
+// mapping in the resource holding both a name and an image.
+func containerImages(r *resource.Resource) map[string]string {
+	result := make(map[string]string)
+	if r == nil {
+		return result
+	}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			var name, image string
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+				if v.Kind == yaml.ScalarNode {
+					switch k.Value {
+					case "name":
+						name = v.Value
+					case "image":
+						image = v.Value
+					}
+				}
+			}
+			if name != "" && image != "" {
+				result[name] = image
+			}
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(r.Node().YNode())
+	return result
+}
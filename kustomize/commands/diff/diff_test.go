@@ -0,0 +1,112 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+func writeLayout(fSys filesys.FileSystem) {
+	fSys.WriteFile("base/kustomization.yaml", []byte(`
+resources:
+- resources.yaml
+`))
+	fSys.WriteFile("base/resources.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: web:1.0
+      - name: proxy
+        image: envoy:1.17
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`))
+	fSys.WriteFile("overlays/stage/kustomization.yaml", []byte(`
+namespace: stage
+resources:
+- ../../base
+- extra.yaml
+images:
+- name: web
+  newTag: "1.1"
+replicas:
+- name: web
+  count: 2
+`))
+	fSys.WriteFile("overlays/stage/extra.yaml", []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: flags
+`))
+	fSys.WriteFile("overlays/prod/kustomization.yaml", []byte(`
+namespace: prod
+resources:
+- ../../base
+replicas:
+- name: web
+  count: 2
+`))
+}
+
+func TestDiffPromote(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	writeLayout(fSys)
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdDiff(fSys, buffy)
+	cmd.Flags().Set("promote", "true")
+	if err := cmd.RunE(cmd, []string{"stage", "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Image changes:
+  apps_v1_Deployment/web app: web:1.0 -> web:1.1
+
+apps_v1_Deployment:
+  ~ web
+    --- overlays/prod
+    +++ overlays/stage
+    @@ -9,5 +9,5 @@
+           containers:
+           - name: app
+    -        image: web:1.0
+    +        image: web:1.1
+           - name: proxy
+             image: envoy:1.17
+v1_ConfigMap:
+  + flags
+`
+	if buffy.String() != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, buffy.String())
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	writeLayout(fSys)
+	buffy := new(bytes.Buffer)
+	cmd := NewCmdDiff(fSys, buffy)
+	if err := cmd.RunE(cmd, []string{"overlays/prod", "overlays/prod"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "No changes from overlays/prod to overlays/prod.\n"
+	if buffy.String() != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, buffy.String())
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/loader"
+	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/internal/kustfile"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/internal/util"
 )
@@ -69,13 +70,24 @@ func (o *addComponentOptions) RunAddComponent(fSys filesys.FileSystem) error {
 
 	for _, component := range components {
 		if mf.GetPath() != component {
-			if kustfile.StringInSlice(component, m.Components) {
+			if hasComponent(m.Components, component) {
 				log.Printf("component %s already in kustomization file", component)
 				continue
 			}
-			m.Components = append(m.Components, component)
+			m.Components = append(m.Components, types.Component{Path: component})
 		}
 	}
 
 	return mf.Write(m)
 }
+
+// hasComponent returns whether a component has the path,
+// instantiated with values or not.
+func hasComponent(components []types.Component, path string) bool {
+	for _, c := range components {
+		if c.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -34,6 +34,9 @@ func newCmdAddConfigMap(
 
 	# Adds a configmap from env-file with behavior merge
 	kustomize edit add configmap my-configmap --behavior=merge --from-env-file=env/path.env	
+
+	# Replaces the sources of an existing configmap
+	kustomize edit add configmap my-configmap --replace-sources --from-literal=my-literal=67890
 `,
 		RunE: func(_ *cobra.Command, args []string) error {
 			err := flags.ExpandFileSource(fSys)
@@ -90,6 +93,11 @@ func newCmdAddConfigMap(
 		"disableNameSuffixHash",
 		false,
 		"Disable the name suffix for the configmap")
+	cmd.Flags().BoolVar(
+		&flags.ReplaceSources,
+		"replace-sources",
+		false,
+		"Replace the sources of an existing configmap with those given, rather than adding to them")
 	cmd.Flags().StringVar(
 		&flags.Behavior,
 		"behavior",
@@ -109,7 +117,7 @@ func addConfigMap(
 	k *types.Kustomization,
 	flags flagsAndArgs, rf *resource.Factory) error {
 	args := findOrMakeConfigMapArgs(k, flags.Name)
-	mergeFlagsIntoCmArgs(args, flags)
+	mergeFlagsIntoGeneratorArgs(&args.GeneratorArgs, flags)
 	// Validate by trying to create corev1.configmap.
 	args.Options = types.MergeGlobalOptionsIntoLocal(
 		args.Options, k.GeneratorOptions)
@@ -128,26 +136,3 @@ func findOrMakeConfigMapArgs(m *types.Kustomization, name string) *types.ConfigM
 	m.ConfigMapGenerator = append(m.ConfigMapGenerator, *cm)
 	return &m.ConfigMapGenerator[len(m.ConfigMapGenerator)-1]
 }
-
-func mergeFlagsIntoCmArgs(args *types.ConfigMapArgs, flags flagsAndArgs) {
-	if len(flags.LiteralSources) > 0 {
-		args.LiteralSources = append(
-			args.LiteralSources, flags.LiteralSources...)
-	}
-	if len(flags.FileSources) > 0 {
-		args.FileSources = append(
-			args.FileSources, flags.FileSources...)
-	}
-	if flags.EnvFileSource != "" {
-		args.EnvSources = append(
-			args.EnvSources, flags.EnvFileSource)
-	}
-	if flags.DisableNameSuffixHash {
-		args.Options = &types.GeneratorOptions{
-			DisableNameSuffixHash: true,
-		}
-	}
-	if flags.Behavior != "" {
-		args.Behavior = flags.Behavior
-	}
-}
@@ -6,6 +6,8 @@ package add
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/kv"
 	"sigs.k8s.io/kustomize/api/loader"
@@ -111,6 +113,44 @@ func TestMergeFlagsIntoConfigMapArgs_EnvSource(t *testing.T) {
 	}
 }
 
+func TestMergeFlagsIntoConfigMapArgs_SameKey(t *testing.T) {
+	k := &types.Kustomization{}
+	args := findOrMakeConfigMapArgs(k, "foo")
+	flags := flagsAndArgs{
+		LiteralSources: []string{"k1=v1", "k2=v2"},
+		FileSources:    []string{"dir/file1", "key2=file2"},
+	}
+	mergeFlagsIntoGeneratorArgs(&args.GeneratorArgs, flags)
+	// Adding the same sources again changes nothing.
+	mergeFlagsIntoGeneratorArgs(&args.GeneratorArgs, flags)
+	assert.Equal(t, []string{"k1=v1", "k2=v2"}, args.LiteralSources)
+	assert.Equal(t, []string{"dir/file1", "key2=file2"}, args.FileSources)
+
+	// Sources of the same key are replaced in place.
+	mergeFlagsIntoGeneratorArgs(&args.GeneratorArgs, flagsAndArgs{
+		LiteralSources: []string{"k1=new", "k3=v3"},
+		FileSources:    []string{"other/file1", "key2=other/file2"},
+	})
+	assert.Equal(t, []string{"k1=new", "k2=v2", "k3=v3"}, args.LiteralSources)
+	assert.Equal(t, []string{"other/file1", "key2=other/file2"}, args.FileSources)
+}
+
+func TestMergeFlagsIntoConfigMapArgs_ReplaceSources(t *testing.T) {
+	k := &types.Kustomization{}
+	args := findOrMakeConfigMapArgs(k, "foo")
+	mergeFlagsIntoGeneratorArgs(&args.GeneratorArgs, flagsAndArgs{
+		LiteralSources: []string{"k1=v1"},
+		FileSources:    []string{"file1"},
+	})
+	mergeFlagsIntoGeneratorArgs(&args.GeneratorArgs, flagsAndArgs{
+		EnvFileSource:  "env1",
+		ReplaceSources: true,
+	})
+	assert.Empty(t, args.LiteralSources)
+	assert.Empty(t, args.FileSources)
+	assert.Equal(t, []string{"env1"}, args.EnvSources)
+}
+
 func TestMergeFlagsIntoConfigMapArgs_Behavior(t *testing.T) {
 	k := &types.Kustomization{}
 	args := findOrMakeConfigMapArgs(k, "foo")
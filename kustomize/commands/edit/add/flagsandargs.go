@@ -32,6 +32,9 @@ type flagsAndArgs struct {
 	Namespace string
 	// Disable name suffix
 	DisableNameSuffixHash bool
+	// Replace, rather than add to, the sources of an existing
+	// configMap/Secret
+	ReplaceSources bool
 }
 
 // Validate validates required fields are set to support structured generation.
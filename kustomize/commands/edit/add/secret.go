@@ -4,6 +4,9 @@
 package add
 
 import (
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/ifc"
@@ -31,6 +34,9 @@ func newCmdAddSecret(
 
 	# Adds a secret from env-file
 	kustomize edit add secret my-secret --from-env-file=env/path.env
+
+	# Replaces the sources of an existing secret
+	kustomize edit add secret my-secret --replace-sources --from-literal=my-literal=67890
 `,
 		RunE: func(_ *cobra.Command, args []string) error {
 			err := flags.ExpandFileSource(fSys)
@@ -97,6 +103,11 @@ func newCmdAddSecret(
 		"disableNameSuffixHash",
 		false,
 		"Disable the name suffix for the secret")
+	cmd.Flags().BoolVar(
+		&flags.ReplaceSources,
+		"replace-sources",
+		false,
+		"Replace the sources of an existing secret with those given, rather than adding to them")
 
 	return cmd
 }
@@ -132,18 +143,25 @@ func findOrMakeSecretArgs(m *types.Kustomization, name, namespace, secretType st
 	return &m.SecretGenerator[len(m.SecretGenerator)-1]
 }
 
+// mergeFlagsIntoGeneratorArgs adds the sources given by the flags to
+// those of the generator, replacing any sources of the same key, so
+// that adding the same sources again changes nothing.  With
+// ReplaceSources, the generator's sources are replaced instead.
 func mergeFlagsIntoGeneratorArgs(args *types.GeneratorArgs, flags flagsAndArgs) {
-	if len(flags.LiteralSources) > 0 {
-		args.LiteralSources = append(
-			args.LiteralSources, flags.LiteralSources...)
+	if flags.ReplaceSources {
+		args.LiteralSources = nil
+		args.FileSources = nil
+		args.EnvSources = nil
 	}
-	if len(flags.FileSources) > 0 {
-		args.FileSources = append(
-			args.FileSources, flags.FileSources...)
+	for _, s := range flags.LiteralSources {
+		args.LiteralSources = mergeSource(args.LiteralSources, s, literalKey)
+	}
+	for _, s := range flags.FileSources {
+		args.FileSources = mergeSource(args.FileSources, s, fileKey)
 	}
 	if flags.EnvFileSource != "" {
-		args.EnvSources = append(
-			args.EnvSources, flags.EnvFileSource)
+		args.EnvSources = mergeSource(
+			args.EnvSources, flags.EnvFileSource, func(s string) string { return s })
 	}
 	if flags.DisableNameSuffixHash {
 		args.Options = &types.GeneratorOptions{
@@ -154,3 +172,30 @@ func mergeFlagsIntoGeneratorArgs(args *types.GeneratorArgs, flags flagsAndArgs)
 		args.Behavior = flags.Behavior
 	}
 }
+
+// mergeSource replaces the source of the same key as s, if any,
+// with s, or else appends s.
+func mergeSource(sources []string, s string, key func(string) string) []string {
+	for i, existing := range sources {
+		if key(existing) == key(s) {
+			sources[i] = s
+			return sources
+		}
+	}
+	return append(sources, s)
+}
+
+// literalKey returns the key of a literal source, i.e. key=value.
+func literalKey(s string) string {
+	return strings.SplitN(s, "=", 2)[0]
+}
+
+// fileKey returns the key of a file source, i.e. [key=]path,
+// which, like kubectl, defaults to the file's basename.
+func fileKey(s string) string {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) == 2 {
+		return parts[0]
+	}
+	return filepath.Base(s)
+}
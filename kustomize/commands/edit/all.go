@@ -4,6 +4,8 @@
 package edit
 
 import (
+	"io"
+
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/ifc"
@@ -12,14 +14,18 @@ import (
 	"sigs.k8s.io/kustomize/api/resource"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit/add"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit/fix"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit/list"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit/listbuiltin"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit/remove"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit/set"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/edit/sync"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/internal/kustfile"
 )
 
 // NewCmdEdit returns an instance of 'edit' subcommand.
 func NewCmdEdit(
-	fSys filesys.FileSystem, v ifc.Validator, rf *resource.Factory) *cobra.Command {
+	fSys filesys.FileSystem, v ifc.Validator, rf *resource.Factory,
+	w io.Writer) *cobra.Command {
 	c := &cobra.Command{
 		Use:   "edit",
 		Short: "Edits a kustomization file",
@@ -33,9 +39,19 @@ func NewCmdEdit(
 
 	# Sets the namesuffix field
 	kustomize edit set namesuffix <suffix-value>
+
+	# Prints the resources field as JSON
+	kustomize edit list resources
+
+	# Adds the images the resources refer to to the images field
+	kustomize edit sync images
+
+	# Sets the namespace in another kustomization of the directory
+	kustomize edit set namespace prod --kustomization prod.kustomization.yaml
 `,
 		Args: cobra.MinimumNArgs(1),
 	}
+	kustfile.AddFlagKustomization(c.PersistentFlags())
 
 	c.AddCommand(
 		add.NewCmdAdd(
@@ -48,6 +64,8 @@ func NewCmdEdit(
 			v),
 		fix.NewCmdFix(fSys),
 		remove.NewCmdRemove(fSys, v),
+		list.NewCmdList(fSys, w),
+		sync.NewCmdSync(fSys),
 		listbuiltin.NewCmdListBuiltinPlugin(),
 	)
 	return c
@@ -6,11 +6,14 @@ package fix
 import (
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/filters/patchjson6902"
+	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/kustomize/kustomize/v4/commands/internal/kustfile"
 )
 
 // NewCmdFix returns an instance of 'fix' subcommand.
 func NewCmdFix(fSys filesys.FileSystem) *cobra.Command {
+	var escapeJSONPaths bool
 	cmd := &cobra.Command{
 		Use:   "fix",
 		Short: "Fix the missing fields in kustomization file",
@@ -19,16 +22,22 @@ func NewCmdFix(fSys filesys.FileSystem) *cobra.Command {
 	# Fix the missing and deprecated fields in kustomization file
 	kustomize edit fix
 
+	# Also escape the ~s and /s in the keys of the paths of JSON patches
+	kustomize edit fix --escape-json-paths
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return RunFix(fSys)
+			return RunFix(fSys, escapeJSONPaths)
 		},
 	}
+	cmd.Flags().BoolVar(&escapeJSONPaths, "escape-json-paths", false,
+		"escape the ~s not followed by 0 or 1, and the /s in the keys of "+
+			"annotations, labels and the like, in the paths of JSON patches, "+
+			"inline or in patch files.")
 	return cmd
 }
 
 // RunFix runs `fix` command
-func RunFix(fSys filesys.FileSystem) error {
+func RunFix(fSys filesys.FileSystem, escapeJSONPaths bool) error {
 	mf, err := kustfile.NewKustomizationFile(fSys)
 	if err != nil {
 		return err
@@ -43,5 +52,44 @@ func RunFix(fSys filesys.FileSystem) error {
 	if err != nil {
 		return err
 	}
+	if escapeJSONPaths {
+		if err = escapePatchPaths(fSys, m.Patches); err != nil {
+			return err
+		}
+	}
 	return mf.Write(m)
 }
+
+// escapePatchPaths fixes the paths of the JSON patches,
+// those inline in the kustomization and those in files.
+func escapePatchPaths(fSys filesys.FileSystem, patches []types.Patch) error {
+	for i := range patches {
+		p := &patches[i]
+		if p.Patch != "" {
+			fixed, _, err := patchjson6902.FixPaths(p.Patch)
+			if err != nil {
+				return err
+			}
+			p.Patch = fixed
+			continue
+		}
+		// Globs and missing files are left to the build to report.
+		if p.Path == "" || !fSys.Exists(p.Path) || fSys.IsDir(p.Path) {
+			continue
+		}
+		content, err := fSys.ReadFile(p.Path)
+		if err != nil {
+			return err
+		}
+		fixed, changed, err := patchjson6902.FixPaths(string(content))
+		if err != nil {
+			return err
+		}
+		if changed {
+			if err = fSys.WriteFile(p.Path, []byte(fixed)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
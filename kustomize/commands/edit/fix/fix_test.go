@@ -198,3 +198,66 @@ labels:
 		t.Fatalf("error message '%s' doesn't match expected", err.Error())
 	}
 }
+
+func TestFixEscapeJSONPaths(t *testing.T) {
+	kustomizationContent := []byte(`
+patchesJson6902:
+- path: patch.yaml
+  target:
+    kind: Deployment
+patches:
+- patch: |-
+    - op: add
+      path: /metadata/annotations/example.com/owner
+      value: team-a
+  target:
+    kind: Service
+`)
+
+	expected := []byte(`
+patches:
+- patch: |-
+    - op: add
+      path: /metadata/annotations/example.com~1owner
+      value: team-a
+  target:
+    kind: Service
+- path: patch.yaml
+  target:
+    kind: Deployment
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`)
+	fSys := filesys.MakeFsInMemory()
+	testutils_test.WriteTestKustomizationWith(fSys, kustomizationContent)
+	err := fSys.WriteFile("patch.yaml", []byte(`[
+  {"op": "replace", "path": "/spec/template/metadata/labels/app.kubernetes.io/name", "value": "web"}
+]`))
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	cmd := NewCmdFix(fSys)
+	if err = cmd.Flags().Set("escape-json-paths", "true"); err != nil {
+		t.Fatalf("unexpected flag error: %v", err)
+	}
+	err = cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Errorf("unexpected cmd error: %v", err)
+	}
+	content, err := testutils_test.ReadTestKustomization(fSys)
+	if err != nil {
+		t.Errorf("unexpected read error: %v", err)
+	}
+	if diff := cmp.Diff(expected, content); diff != "" {
+		t.Errorf("Mismatch (-expected, +actual):\n%s", diff)
+	}
+	patch, err := fSys.ReadFile("patch.yaml")
+	if err != nil {
+		t.Errorf("unexpected read error: %v", err)
+	}
+	if diff := cmp.Diff(`[
+  {"op": "replace", "path": "/spec/template/metadata/labels/app.kubernetes.io~1name", "value": "web"}
+]`, string(patch)); diff != "" {
+		t.Errorf("Mismatch (-expected, +actual):\n%s", diff)
+	}
+}
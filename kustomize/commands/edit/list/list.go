@@ -0,0 +1,163 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package list holds the read-only edit list commands, which
+// print fields of the kustomization file for use by scripts.
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/internal/kustfile"
+	"sigs.k8s.io/yaml"
+)
+
+// A listing prints the kustomization fields of one kind of item.
+type listing struct {
+	use    string
+	short  string
+	fields func(k *types.Kustomization) map[string]interface{}
+}
+
+var listings = []listing{
+	{
+		use:   "resources",
+		short: "Lists the resources and components",
+		fields: func(k *types.Kustomization) map[string]interface{} {
+			return map[string]interface{}{
+				"resources":  nonNil(k.Resources),
+				"components": nonNil(k.Components),
+			}
+		},
+	},
+	{
+		use:   "images",
+		short: "Lists the image overrides",
+		fields: func(k *types.Kustomization) map[string]interface{} {
+			return map[string]interface{}{
+				"images": nonNil(k.Images),
+			}
+		},
+	},
+	{
+		use:   "patches",
+		short: "Lists the patches, of all kinds,",
+		fields: func(k *types.Kustomization) map[string]interface{} {
+			return map[string]interface{}{
+				"patches":               nonNil(k.Patches),
+				"patchesStrategicMerge": nonNil(k.PatchesStrategicMerge),
+				"patchesJson6902":       nonNil(k.PatchesJson6902),
+			}
+		},
+	},
+	{
+		use:   "replacements",
+		short: "Lists the replacements",
+		fields: func(k *types.Kustomization) map[string]interface{} {
+			return map[string]interface{}{
+				"replacements": nonNil(k.Replacements),
+			}
+		},
+	},
+	{
+		use:   "generators",
+		short: "Lists the ConfigMap, Secret and custom generators",
+		fields: func(k *types.Kustomization) map[string]interface{} {
+			return map[string]interface{}{
+				"configMapGenerator": nonNil(k.ConfigMapGenerator),
+				"secretGenerator":    nonNil(k.SecretGenerator),
+				"tlsSecretGenerator": nonNil(k.TLSSecretGenerator),
+				"generators":         nonNil(k.Generators),
+			}
+		},
+	},
+}
+
+// NewCmdList returns an instance of 'list' subcommand.
+func NewCmdList(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "list",
+		Short: "Prints fields of the kustomization file, for use by scripts.",
+		Long: `Prints fields of the kustomization file, as a JSON or YAML object
+holding such fields as the kustomization file has, and empty lists for
+those it doesn't have.  The kustomization file isn't changed.
+`,
+		Example: `
+	# Prints the resources and components
+	kustomize edit list resources
+
+	# Prints the images, as YAML
+	kustomize edit list images --format yaml
+`,
+		Args: cobra.MinimumNArgs(1),
+	}
+	for _, l := range listings {
+		c.AddCommand(newCmdListing(fSys, w, l))
+	}
+	return c
+}
+
+func newCmdListing(fSys filesys.FileSystem, w io.Writer, l listing) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use: l.use,
+		Short: l.short + " of " +
+			konfig.DefaultKustomizationFileName(),
+		Example: fmt.Sprintf(`
+		list %s
+		list %s --format yaml
+		`, l.use, l.use),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("list %s takes no arguments", l.use)
+			}
+			mf, err := kustfile.NewKustomizationFile(fSys)
+			if err != nil {
+				return err
+			}
+			m, err := mf.Read()
+			if err != nil {
+				return err
+			}
+			return print(w, format, l.fields(m))
+		},
+	}
+	cmd.Flags().StringVar(
+		&format, "format", "json", "The output format, json or yaml.")
+	return cmd
+}
+
+func print(w io.Writer, format string, fields map[string]interface{}) error {
+	var out []byte
+	var err error
+	switch format {
+	case "json":
+		out, err = json.MarshalIndent(fields, "", "  ")
+		out = append(out, '\n')
+	case "yaml":
+		out, err = yaml.Marshal(fields)
+	default:
+		return fmt.Errorf("unknown format '%s', expected json or yaml", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// nonNil returns the empty list in place of a nil slice,
+// so that missing fields are printed as empty lists.
+func nonNil(items interface{}) interface{} {
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && v.IsNil() {
+		return []interface{}{}
+	}
+	return items
+}
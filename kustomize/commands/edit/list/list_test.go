@@ -0,0 +1,107 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+	testutils_test "sigs.k8s.io/kustomize/kustomize/v4/commands/internal/testutils"
+)
+
+const kustomization = `
+resources:
+- deployment.yaml
+bases:
+- ../base
+images:
+- name: app
+  newTag: v2
+patchesStrategicMerge:
+- patch.yaml
+patchesJson6902:
+- path: jsonpatch.yaml
+  target:
+    kind: Deployment
+    name: app
+`
+
+func runList(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	fSys := filesys.MakeFsInMemory()
+	testutils_test.WriteTestKustomizationWith(fSys, []byte(kustomization))
+	var out bytes.Buffer
+	cmd := NewCmdList(fSys, &out)
+	cmd.SetArgs(args)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestListResources(t *testing.T) {
+	out, err := runList(t, "resources")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `{
+  "components": [],
+  "resources": [
+    "deployment.yaml",
+    "../base"
+  ]
+}
+`, out)
+}
+
+func TestListImagesAsYaml(t *testing.T) {
+	out, err := runList(t, "images", "--format", "yaml")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `images:
+- name: app
+  newTag: v2
+`, out)
+}
+
+func TestListPatches(t *testing.T) {
+	out, err := runList(t, "patches")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `{
+  "patches": [],
+  "patchesJson6902": [
+    {
+      "path": "jsonpatch.yaml",
+      "target": {
+        "kind": "Deployment",
+        "name": "app"
+      }
+    }
+  ],
+  "patchesStrategicMerge": [
+    "patch.yaml"
+  ]
+}
+`, out)
+}
+
+func TestListEmpty(t *testing.T) {
+	out, err := runList(t, "replacements")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "{\n  \"replacements\": []\n}\n", out)
+}
+
+func TestListErrors(t *testing.T) {
+	_, err := runList(t, "generators", "--format", "xml")
+	assert.EqualError(t, err, "unknown format 'xml', expected json or yaml")
+	_, err = runList(t, "generators", "extra")
+	assert.EqualError(t, err, "list generators takes no arguments")
+}
@@ -138,6 +138,7 @@ func (o *setImageOptions) RunSetImage(fSys filesys.FileSystem) error {
 			// Reuse the existing new tag when asterisk new tag is passed
 			if argIm.NewTag == preserveSeparator {
 				argIm = replaceNewTag(argIm, im.NewTag)
+				argIm.NewTagFrom = im.NewTagFrom
 			}
 
 			// Reuse the existing digest when asterisk disgest is passed
@@ -180,30 +181,18 @@ func (o *setImageOptions) RunSetImage(fSys filesys.FileSystem) error {
 }
 
 func replaceNewName(image types.Image, newName string) types.Image {
-	return types.Image{
-		Name:    image.Name,
-		NewName: newName,
-		NewTag:  image.NewTag,
-		Digest:  image.Digest,
-	}
+	image.NewName = newName
+	return image
 }
 
 func replaceNewTag(image types.Image, newTag string) types.Image {
-	return types.Image{
-		Name:    image.Name,
-		NewName: image.NewName,
-		NewTag:  newTag,
-		Digest:  image.Digest,
-	}
+	image.NewTag = newTag
+	return image
 }
 
 func replaceDigest(image types.Image, digest string) types.Image {
-	return types.Image{
-		Name:    image.Name,
-		NewName: image.NewName,
-		NewTag:  image.NewTag,
-		Digest:  digest,
-	}
+	image.Digest = digest
+	return image
 }
 
 func parse(arg string) (types.Image, error) {
@@ -289,6 +289,30 @@ func TestSetImage(t *testing.T) {
 					"  newTag: my-tag",
 				}},
 		},
+		{
+			description: "override new name but keep new tag from",
+			given: given{
+				args: []string{"image1=my-image1:*"},
+				infileImages: []string{
+					"images:",
+					"- name: image1",
+					"  newTagFrom:",
+					"    kind: ConfigMap",
+					"    name: versions",
+					"    fieldPath: data.image1",
+				},
+			},
+			expected: expected{
+				fileOutput: []string{
+					"images:",
+					"- name: image1",
+					"  newName: my-image1",
+					"  newTagFrom:",
+					"    kind: ConfigMap",
+					"    name: versions",
+					"    fieldPath: data.image1",
+				}},
+		},
 		{
 			description: "keep new name and new tag (rare case)",
 			given: given{
@@ -363,9 +387,9 @@ func TestSetImage(t *testing.T) {
 			expected: expected{
 				fileOutput: []string{
 					"images:",
-					"- digest: sha256:24a0c4b4a4c0eb97a1aabb8e29f18e917d05abfe1b7a7c07857230879ce7d3d3",
-					"  name: image2",
+					"- name: image2",
 					"  newName: my-image2",
+					"  digest: sha256:24a0c4b4a4c0eb97a1aabb8e29f18e917d05abfe1b7a7c07857230879ce7d3d3",
 				}},
 		},
 		{
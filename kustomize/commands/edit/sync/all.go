@@ -0,0 +1,28 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package sync
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+// NewCmdSync returns an instance of 'sync' subcommand.
+func NewCmdSync(fSys filesys.FileSystem) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "sync",
+		Short: "Syncs fields of the kustomization file with what it builds.",
+		Long:  "",
+		Example: `
+	# Adds the images the resources refer to to the images field
+	kustomize edit sync images
+`,
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	c.AddCommand(
+		newCmdSyncImages(fSys),
+	)
+	return c
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package sync
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/image"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/internal/kustfile"
+)
+
+type syncImagesOptions struct {
+	dir     string
+	pinTags bool
+}
+
+var errSyncImagesArgs = errors.New("specify one directory, or none for the current one")
+
+// newCmdSyncImages adds images entries for the images
+// the built resources refer to, but no entry covers.
+func newCmdSyncImages(fSys filesys.FileSystem) *cobra.Command {
+	var o syncImagesOptions
+
+	cmd := &cobra.Command{
+		Use:   "images [DIR]",
+		Short: `Adds the images the built resources refer to, but the images field doesn't cover, to it`,
+		Long: `Builds the kustomization in DIR, the current directory by default,
+and adds an entry to the images field of its kustomization file for each
+image the resources built refer to, but no entry names, neither by name
+nor by newName, so that tools bumping tags in the images field, rather
+than in the resources, can bump every image.
+`,
+		Example: `
+If the resources of the kustomization refer to the images nginx:1.21
+and postgres:13, and its images field only has an entry for nginx,
+the command
+  edit sync images --pin-tags
+will add
+
+images:
+- name: postgres
+  newTag: "13"
+
+to the kustomization file.  Without --pin-tags, only the name is added.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			return o.RunSyncImages(fSys)
+		},
+	}
+	cmd.Flags().BoolVar(&o.pinTags, "pin-tags", false,
+		"Set the newTag, or digest, of each entry added to the tag, or digest, the image has.")
+	return cmd
+}
+
+// Validate validates syncImages command.
+func (o *syncImagesOptions) Validate(args []string) error {
+	switch len(args) {
+	case 0:
+		o.dir = ""
+	case 1:
+		o.dir = args[0]
+	default:
+		return errSyncImagesArgs
+	}
+	return nil
+}
+
+// RunSyncImages runs syncImages command.
+func (o *syncImagesOptions) RunSyncImages(fSys filesys.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFileInDir(fSys, o.dir)
+	if err != nil {
+		return err
+	}
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+	// The path of the file, as the --kustomization
+	// flag may name one other than that of dir.
+	dir := mf.GetPath()
+	refs, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Images(fSys, dir)
+	if err != nil {
+		return err
+	}
+	added := make(map[string]bool)
+	for _, ref := range refs {
+		name, tag := image.Split(ref.Image)
+		if added[name] || isCovered(m.Images, ref.Image) {
+			continue
+		}
+		added[name] = true
+		img := types.Image{Name: name}
+		if o.pinTags {
+			if strings.HasPrefix(tag, "@") {
+				img.Digest = tag[1:]
+			} else if tag != "" {
+				img.NewTag = tag[1:]
+			}
+		}
+		m.Images = append(m.Images, img)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	sort.Slice(m.Images, func(i, j int) bool {
+		return m.Images[i].Name < m.Images[j].Name
+	})
+	return mf.Write(m)
+}
+
+// isCovered returns whether an entry of images names the
+// image, by its name, or by the newName it's changed to.
+func isCovered(images []types.Image, ref string) bool {
+	for _, img := range images {
+		if image.IsImageMatched(ref, img.Name) ||
+			(img.NewName != "" && image.IsImageMatched(ref, img.NewName)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package sync
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+const deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox
+      containers:
+      - name: web
+        image: nginx:1.21
+      - name: db
+        image: postgres:13
+      - name: proxy
+        image: envoyproxy/envoy@sha256:24a0c4b4a4c0eb97a1aabb8e29f18e917d05abfe1b7a7c07857230879ce7d3d3
+`
+
+func TestSyncImages(t *testing.T) {
+	testCases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"names": {
+			expected: `resources:
+- ../base
+images:
+- name: busybox
+- name: envoyproxy/envoy
+- name: my-registry/nginx
+  newTag: "1.22"
+- name: postgres
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`,
+		},
+		"pinned tags": {
+			args: []string{"--pin-tags"},
+			expected: `resources:
+- ../base
+images:
+- name: busybox
+- digest: sha256:24a0c4b4a4c0eb97a1aabb8e29f18e917d05abfe1b7a7c07857230879ce7d3d3
+  name: envoyproxy/envoy
+- name: my-registry/nginx
+  newTag: "1.22"
+- name: postgres
+  newTag: "13"
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fSys := filesys.MakeFsInMemory()
+			fSys.WriteFile("base/deployment.yaml", []byte(deployment))
+			fSys.WriteFile("base/kustomization.yaml", []byte(`resources:
+- deployment.yaml
+images:
+- name: nginx
+  newName: my-registry/nginx
+`))
+			// The overlay's entry covers nginx by
+			// the name the base's entry gives it.
+			fSys.WriteFile("app/kustomization.yaml", []byte(`resources:
+- ../base
+images:
+- name: my-registry/nginx
+  newTag: "1.22"
+`))
+			cmd := newCmdSyncImages(fSys)
+			if err := cmd.ParseFlags(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := cmd.RunE(cmd, []string{"app"}); err != nil {
+				t.Fatal(err)
+			}
+			content, err := fSys.ReadFile("app/kustomization.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tc.expected {
+				t.Fatalf("expected:\n%s\nbut got:\n%s", tc.expected, content)
+			}
+		})
+	}
+}
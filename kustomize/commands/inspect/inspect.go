@@ -0,0 +1,167 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inspect holds the inspect commands, which report
+// on the output of a build, or the kustomizations building
+// it, for use by other tools.
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+	"sigs.k8s.io/yaml"
+)
+
+// NewCmdInspect returns an instance of 'inspect' subcommand.
+func NewCmdInspect(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "inspect",
+		Short: "Reports on the output of a build, or its kustomizations",
+		Long:  "",
+		Example: `
+	# Lists the images used by the build of the current directory
+	kustomize inspect images
+
+	# Lists them, one per line, without the resources using them
+	kustomize inspect images overlays/prod --format text
+
+	# Lists the cycles among the bases and components of overlays/prod
+	kustomize inspect cycles overlays/prod
+`,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.AddCommand(newCmdInspectImages(fSys, w))
+	c.AddCommand(newCmdInspectCycles(fSys, w))
+	return c
+}
+
+func newCmdInspectImages(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	var format string
+	c := &cobra.Command{
+		Use:   "images [DIR]",
+		Short: "Lists the container images referenced by the output of a build",
+		Long: `Builds the kustomization in DIR, the current directory by
+default, and lists every reference to a container image in the
+output, with the resource making it and the file that resource
+came from.  The references found are those the images field
+would change, including those in fields of custom resources
+added by configurations.
+
+With --format text, only the images are listed, once each.
+`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := filesys.SelfDir
+			switch len(args) {
+			case 0:
+			case 1:
+				dir = args[0]
+			default:
+				return fmt.Errorf("specify one path to a kustomization")
+			}
+			k := krusty.MakeKustomizer(
+				build.HonorKustomizeFlags(krusty.MakeDefaultOptions()))
+			images, err := k.Images(fSys, dir)
+			if err != nil {
+				return err
+			}
+			return printImages(w, format, images)
+		},
+	}
+	c.Flags().StringVar(
+		&format, "format", "json", "The output format, json, yaml or text.")
+	build.AddFlagLoadRestrictor(c.Flags())
+	build.AddFlagEnablePlugins(c.Flags())
+	build.AddFlagEnableHelm(c.Flags())
+	return c
+}
+
+func newCmdInspectCycles(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "cycles [DIR]",
+		Short: "Lists the cycles among the bases and components of a kustomization",
+		Long: `Follows the resources, bases and components of the kustomization
+in DIR, the current directory by default, and of those it reaches,
+listing each cycle among them, one per line, e.g.
+
+  overlay -> base -> overlay
+
+The directories are given relative to DIR.  Only local directories
+are followed; remote bases aren't fetched.  Fails if there's any
+cycle, which would fail a build.
+`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := filesys.SelfDir
+			switch len(args) {
+			case 0:
+			case 1:
+				dir = args[0]
+			default:
+				return fmt.Errorf("specify one path to a kustomization")
+			}
+			cycles, err := krusty.Cycles(fSys, dir)
+			if err != nil {
+				return err
+			}
+			for _, c := range cycles {
+				if _, err = fmt.Fprintln(w, strings.Join(c, " -> ")); err != nil {
+					return err
+				}
+			}
+			switch len(cycles) {
+			case 0:
+				return nil
+			case 1:
+				return fmt.Errorf("found a cycle")
+			default:
+				return fmt.Errorf("found %d cycles", len(cycles))
+			}
+		},
+	}
+}
+
+func printImages(
+	w io.Writer, format string, images []krusty.ImageReference) error {
+	if images == nil {
+		images = []krusty.ImageReference{}
+	}
+	var out []byte
+	var err error
+	switch format {
+	case "json":
+		out, err = json.MarshalIndent(images, "", "  ")
+		out = append(out, '\n')
+	case "yaml":
+		out, err = yaml.Marshal(images)
+	case "text":
+		seen := make(map[string]bool)
+		var names []string
+		for _, i := range images {
+			if !seen[i.Image] {
+				seen[i.Image] = true
+				names = append(names, i.Image)
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			out = append(out, n+"\n"...)
+		}
+	default:
+		return fmt.Errorf(
+			"unknown format '%s', expected json, yaml or text", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
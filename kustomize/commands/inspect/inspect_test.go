@@ -0,0 +1,111 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inspect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+func runInspectImages(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("app/kustomization.yaml", []byte(`
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: flags
+  literals:
+  - a=b
+`))
+	fSys.WriteFile("app/deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: web:1.0
+      - name: proxy
+        image: envoy:1.17
+      initContainers:
+      - name: init
+        image: web:1.0
+`))
+	var out bytes.Buffer
+	c := NewCmdInspect(fSys, &out)
+	c.SetArgs(append([]string{"images"}, args...))
+	c.SetOut(&bytes.Buffer{})
+	c.SetErr(&bytes.Buffer{})
+	err := c.Execute()
+	return out.String(), err
+}
+
+func TestInspectImages(t *testing.T) {
+	out, err := runInspectImages(t, "app")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `[
+  {
+    "image": "web:1.0",
+    "resource": "apps_v1_Deployment|~X|web",
+    "origin": "/app/deployment.yaml"
+  },
+  {
+    "image": "envoy:1.17",
+    "resource": "apps_v1_Deployment|~X|web",
+    "origin": "/app/deployment.yaml"
+  }
+]
+`, out)
+}
+
+func TestInspectImagesAsText(t *testing.T) {
+	out, err := runInspectImages(t, "app", "--format", "text")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "envoy:1.17\nweb:1.0\n", out)
+}
+
+func TestInspectImagesErrors(t *testing.T) {
+	_, err := runInspectImages(t, "app", "--format", "xml")
+	assert.EqualError(t, err, "unknown format 'xml', expected json, yaml or text")
+	_, err = runInspectImages(t, "app", "other")
+	assert.EqualError(t, err, "specify one path to a kustomization")
+}
+
+func TestInspectCycles(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("app/overlay/kustomization.yaml", []byte(`
+resources:
+- ../base
+`))
+	fSys.WriteFile("app/base/kustomization.yaml", []byte(`
+resources:
+- ../overlay
+`))
+	var out bytes.Buffer
+	c := NewCmdInspect(fSys, &out)
+	c.SetArgs([]string{"cycles", "app/overlay"})
+	c.SetOut(&bytes.Buffer{})
+	c.SetErr(&bytes.Buffer{})
+	err := c.Execute()
+	if assert.Error(t, err) {
+		assert.Equal(t, "found a cycle", err.Error())
+	}
+	assert.Equal(t, ". -> ../base -> .\n", out.String())
+
+	out.Reset()
+	c.SetArgs([]string{"cycles", "app/base/.."})
+	assert.NoError(t, c.Execute())
+	assert.Empty(t, out.String())
+}
@@ -4,115 +4,63 @@
 package kustfile
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"reflect"
-	"regexp"
-	"strings"
+	"path/filepath"
 
+	"github.com/spf13/pflag"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/konfig"
+	apikustfile "sigs.k8s.io/kustomize/api/kustfile"
 	"sigs.k8s.io/kustomize/api/types"
-	"sigs.k8s.io/yaml"
 )
 
-var fieldMarshallingOrder = determineFieldOrder()
-
-// determineFieldOrder returns a slice of Kustomization field
-// names in the preferred order for serialization to a file.
-// The field list is checked against the actual struct type
-// to confirm that all fields are present, and no unknown
-// fields are specified. Deprecated fields are removed from
-// the list, meaning they will drop to the bottom on output
-// (if present). The ordering and/or deprecation of fields
-// in nested structs is not determined or considered.
-func determineFieldOrder() []string {
-	m := make(map[string]bool)
-	s := reflect.ValueOf(&types.Kustomization{}).Elem()
-	typeOfT := s.Type()
-	for i := 0; i < s.NumField(); i++ {
-		m[typeOfT.Field(i).Name] = false
-	}
-
-	ordered := []string{
-		"MetaData",
-		"Resources",
-		"Bases",
-		"NamePrefix",
-		"NameSuffix",
-		"Namespace",
-		"Crds",
-		"CommonLabels",
-		"Labels",
-		"CommonAnnotations",
-		"PatchesStrategicMerge",
-		"PatchesJson6902",
-		"Patches",
-		"ConfigMapGenerator",
-		"SecretGenerator",
-		"GeneratorOptions",
-		"Vars",
-		"Images",
-		"Replicas",
-		"Configurations",
-		"Generators",
-		"Transformers",
-		"Inventory",
-		"Components",
-	}
-
-	// Add deprecated fields here.
-	deprecated := map[string]bool{}
-
-	// Account for the inlined TypeMeta fields.
-	var result []string
-	result = append(result, "APIVersion", "Kind")
-	m["TypeMeta"] = true
-
-	// Make sure all these fields are recognized.
-	for _, n := range ordered {
-		if _, ok := m[n]; ok {
-			m[n] = true
-		} else {
-			log.Fatalf("%s is not a recognized field.", n)
-		}
-		// Keep if not deprecated.
-		if _, f := deprecated[n]; !f {
-			result = append(result, n)
-		}
-	}
-	return result
-}
-
-// commentedField records the comment associated with a kustomization field
-// field has to be a recognized kustomization field
-// comment can be empty
-type commentedField struct {
-	field   string
-	comment []byte
-}
-
-func (cf *commentedField) appendComment(comment []byte) {
-	cf.comment = append(cf.comment, comment...)
+type kustomizationFile struct {
+	path string
+	fSys filesys.FileSystem
+	// tx is the transaction begun by Read, which Write
+	// commits, keeping the file's comments and field order.
+	tx *apikustfile.Transaction
 }
 
-func squash(x [][]byte) []byte {
-	return bytes.Join(x, []byte(``))
-}
+// target is what the --kustomization flag names, if set:
+// a kustomization file, or a directory holding one.
+var target string
 
-type kustomizationFile struct {
-	path           string
-	fSys           filesys.FileSystem
-	originalFields []*commentedField
+// AddFlagKustomization adds the --kustomization flag, naming the
+// kustomization file for commands to edit, rather than the one
+// in the current directory.
+func AddFlagKustomization(set *pflag.FlagSet) {
+	set.StringVar(&target, "kustomization", "",
+		"The kustomization file to edit, by any name, e.g. prod.kustomization.yaml, "+
+			"or the directory holding it, rather than the current directory.  "+
+			"Paths given to commands are still relative to the current directory.")
 }
 
 // NewKustomizationFile returns a new instance.
 func NewKustomizationFile(fSys filesys.FileSystem) (*kustomizationFile, error) { // nolint
+	return NewKustomizationFileInDir(fSys, "")
+}
+
+// NewKustomizationFileInDir returns a new instance, for
+// the kustomization file in dir, rather than the one the
+// --kustomization flag names, or that of the current
+// directory, if dir is empty.
+func NewKustomizationFileInDir(
+	fSys filesys.FileSystem, dir string) (*kustomizationFile, error) { // nolint
 	mf := &kustomizationFile{fSys: fSys}
-	err := mf.validate()
+	if dir == "" && target != "" {
+		if !fSys.Exists(target) {
+			return nil, fmt.Errorf("--kustomization %s doesn't exist", target)
+		}
+		if !fSys.IsDir(target) {
+			mf.path = target
+			return mf, nil
+		}
+		dir = target
+	}
+	err := mf.validate(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -126,10 +74,11 @@ func (mf *kustomizationFile) GetPath() string {
 	return mf.path
 }
 
-func (mf *kustomizationFile) validate() error {
+func (mf *kustomizationFile) validate(dir string) error {
 	match := 0
 	var path []string
 	for _, kfilename := range konfig.RecognizedKustomizationFileNames() {
+		kfilename = filepath.Join(dir, kfilename)
 		if mf.fSys.Exists(kfilename) {
 			match += 1
 			path = append(path, kfilename)
@@ -154,36 +103,42 @@ func (mf *kustomizationFile) validate() error {
 }
 
 func (mf *kustomizationFile) Read() (*types.Kustomization, error) {
-	data, err := mf.fSys.ReadFile(mf.path)
+	tx, err := apikustfile.BeginFile(mf.fSys, mf.path)
 	if err != nil {
 		return nil, err
 	}
-	data, err = types.FixKustomizationPreUnmarshalling(data)
+	mf.tx = tx
+	k, err := tx.Kustomization()
 	if err != nil {
 		return nil, err
 	}
-	var k types.Kustomization
-	err = k.Unmarshal(data)
-	if err != nil {
-		return nil, err
+	if names := k.UnknownFields(); len(names) > 0 {
+		log.Print(types.UnknownFieldsWarning(mf.path, names))
 	}
-	k.FixKustomizationPostUnmarshalling()
-	err = mf.parseCommentedFields(data)
-	if err != nil {
-		return nil, err
-	}
-	return &k, err
+	return k, nil
 }
 
+// Write commits the kustomization, as changed since Read, or,
+// if not read, replacing what the file holds, if it's valid.
 func (mf *kustomizationFile) Write(kustomization *types.Kustomization) error {
 	if kustomization == nil {
 		return errors.New("util: kustomization file arg is nil")
 	}
-	data, err := mf.marshal(kustomization)
+	if mf.tx == nil {
+		if _, err := mf.Read(); err != nil {
+			return err
+		}
+	}
+	tx := mf.tx
+	mf.tx = nil
+	err := tx.Modify(func(k *types.Kustomization) error {
+		*k = *kustomization
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	return mf.fSys.WriteFile(mf.path, data)
+	return tx.Commit()
 }
 
 // StringInSlice returns true if the string is in the slice.
@@ -195,114 +150,3 @@ func StringInSlice(str string, list []string) bool {
 	}
 	return false
 }
-
-func (mf *kustomizationFile) parseCommentedFields(content []byte) error {
-	buffer := bytes.NewBuffer(content)
-	var comments [][]byte
-
-	line, err := buffer.ReadBytes('\n')
-	for err == nil {
-		if isCommentOrBlankLine(line) {
-			comments = append(comments, line)
-		} else {
-			matched, field := findMatchedField(line)
-			if matched {
-				mf.originalFields = append(mf.originalFields, &commentedField{field: field, comment: squash(comments)})
-				comments = [][]byte{}
-			} else if len(comments) > 0 {
-				mf.originalFields[len(mf.originalFields)-1].appendComment(squash(comments))
-				comments = [][]byte{}
-			}
-		}
-		line, err = buffer.ReadBytes('\n')
-	}
-
-	if err != io.EOF {
-		return err
-	}
-	return nil
-}
-
-// marshal converts a kustomization to a byte stream.
-func (mf *kustomizationFile) marshal(kustomization *types.Kustomization) ([]byte, error) {
-	var output []byte
-	for _, comment := range mf.originalFields {
-		output = append(output, comment.comment...)
-		content, err := marshalField(comment.field, kustomization)
-		if err != nil {
-			return content, err
-		}
-		output = append(output, content...)
-	}
-	for _, field := range fieldMarshallingOrder {
-		if mf.hasField(field) {
-			continue
-		}
-		content, err := marshalField(field, kustomization)
-		if err != nil {
-			return content, nil
-		}
-		output = append(output, content...)
-	}
-	return output, nil
-}
-
-func (mf *kustomizationFile) hasField(name string) bool {
-	for _, n := range mf.originalFields {
-		if n.field == name {
-			return true
-		}
-	}
-	return false
-}
-
-/*
- isCommentOrBlankLine determines if a line is a comment or blank line
- Return true for following lines
- # This line is a comment
-       # This line is also a comment with several leading white spaces
-
- (The line above is a blank line)
-*/
-func isCommentOrBlankLine(line []byte) bool {
-	s := bytes.TrimRight(bytes.TrimLeft(line, " "), "\n")
-	return len(s) == 0 || bytes.HasPrefix(s, []byte(`#`))
-}
-
-func findMatchedField(line []byte) (bool, string) {
-	for _, field := range fieldMarshallingOrder {
-		// (?i) is for case insensitive regexp matching
-		r := regexp.MustCompile("^(" + "(?i)" + field + "):")
-		if r.Match(line) {
-			return true, field
-		}
-	}
-	return false, ""
-}
-
-// marshalField marshal a given field of a kustomization object into yaml format.
-// If the field wasn't in the original kustomization.yaml file or wasn't added,
-// an empty []byte is returned.
-func marshalField(field string, kustomization *types.Kustomization) ([]byte, error) {
-	r := reflect.ValueOf(*kustomization)
-	v := r.FieldByName(strings.Title(field))
-
-	if !v.IsValid() || isEmpty(v) {
-		return []byte{}, nil
-	}
-
-	k := &types.Kustomization{}
-	kr := reflect.ValueOf(k)
-	kv := kr.Elem().FieldByName(strings.Title(field))
-	kv.Set(v)
-
-	return yaml.Marshal(k)
-}
-
-func isEmpty(v reflect.Value) bool {
-	// If v is a pointer type
-	if v.Type().Kind() == reflect.Ptr {
-		return v.IsNil()
-	}
-	return v.Len() == 0
-}
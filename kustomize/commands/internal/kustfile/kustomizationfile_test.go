@@ -4,6 +4,9 @@
 package kustfile
 
 import (
+	"bytes"
+	"log"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -16,46 +19,6 @@ import (
 	testutils_test "sigs.k8s.io/kustomize/kustomize/v4/commands/internal/testutils"
 )
 
-func TestFieldOrder(t *testing.T) {
-	expected := []string{
-		"APIVersion",
-		"Kind",
-		"MetaData",
-		"Resources",
-		"Bases",
-		"NamePrefix",
-		"NameSuffix",
-		"Namespace",
-		"Crds",
-		"CommonLabels",
-		"Labels",
-		"CommonAnnotations",
-		"PatchesStrategicMerge",
-		"PatchesJson6902",
-		"Patches",
-		"ConfigMapGenerator",
-		"SecretGenerator",
-		"GeneratorOptions",
-		"Vars",
-		"Images",
-		"Replicas",
-		"Configurations",
-		"Generators",
-		"Transformers",
-		"Inventory",
-		"Components",
-	}
-	actual := determineFieldOrder()
-	if len(expected) != len(actual) {
-		t.Fatalf("Incorrect field count.")
-	}
-	for i, n := range expected {
-		if n != actual[i] {
-			t.Fatalf("Bad field order.")
-		}
-	}
-}
-
 func TestWriteAndRead(t *testing.T) {
 	kustomization := &types.Kustomization{
 		NamePrefix: "prefix",
@@ -134,6 +97,52 @@ configMapGenerator:
 	}
 }
 
+func TestKustomizationFlag(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	testutils_test.WriteTestKustomization(fSys)
+	fSys.WriteFile("prod.kustomization.yaml", []byte(`namePrefix: prod-
+`))
+	fSys.WriteFile("staging/kustomization.yaml", []byte(`namePrefix: staging-
+`))
+	defer func() { target = "" }()
+	for n, expected := range map[string]string{
+		"prod.kustomization.yaml": "prod.kustomization.yaml",
+		"staging":                 "staging/kustomization.yaml",
+	} {
+		target = n
+		mf, err := NewKustomizationFile(fSys)
+		if err != nil {
+			t.Fatalf("Unexpected Error: %v", err)
+		}
+		if mf.GetPath() != expected {
+			t.Fatalf("Path expected: %s. Actual path: %v", expected, mf.GetPath())
+		}
+	}
+	target = "prod.kustomization.yaml"
+	mf, err := NewKustomizationFile(fSys)
+	if err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
+	k, err := mf.Read()
+	if err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
+	k.NameSuffix = "-v2"
+	if err = mf.Write(k); err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
+	actual, _ := fSys.ReadFile("prod.kustomization.yaml")
+	if !strings.Contains(string(actual), "nameSuffix: -v2\n") {
+		t.Fatalf("expected the nameSuffix in %q", actual)
+	}
+	target = "missing.kustomization.yaml"
+	_, err = NewKustomizationFile(fSys)
+	if err == nil || !strings.Contains(err.Error(),
+		"--kustomization missing.kustomization.yaml doesn't exist") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestPreserveComments(t *testing.T) {
 	kustomizationContentWithComments := []byte(
 		`# shem qing some comments
@@ -223,10 +232,10 @@ generatorOptions:
 # Some comments
 # This is some comment we should preserve
 # don't delete it
-  # See which field this comment goes into
 resources:
 - ../namespaces
 - pod.yaml
+# See which field this comment goes into
 - service.yaml
 
 apiVersion: kustomize.config.k8s.io/v1beta1
@@ -353,9 +362,12 @@ kind: Kustomization
 }
 
 func TestUnknownFieldInKustomization(t *testing.T) {
-	kContent := []byte(`
-foo:
-  bar
+	kContent := []byte(`example.com/foo:
+  bar: 1000000
+resources:
+- deployment.yaml
+# Read by a deployment tool.
+argocd.argoproj.io/sync-options: Prune=false
 `)
 	fSys := filesys.MakeFsInMemory()
 	testutils_test.WriteTestKustomizationWith(fSys, kContent)
@@ -364,8 +376,48 @@ foo:
 		t.Fatalf("Unexpected Error: %v", err)
 	}
 
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	k, err := mf.Read()
+	if err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "warning: kustomization.yaml: keeping the fields "+
+		"unknown to kustomize as extensions: argocd.argoproj.io/sync-options, example.com/foo") {
+		t.Fatalf("Expect a warning listing the unknown fields but got: %s", logs.String())
+	}
+	k.Resources = append(k.Resources, "service.yaml")
+	if err = mf.Write(k); err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
+	expected := []byte(`example.com/foo:
+  bar: 1000000
+resources:
+- deployment.yaml
+- service.yaml
+# Read by a deployment tool.
+argocd.argoproj.io/sync-options: Prune=false
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`)
+	actual, _ := fSys.ReadFile(mf.path)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Mismatch (-expected, +actual):\n%s", diff)
+	}
+}
+
+func TestMisspelledFieldInKustomization(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	testutils_test.WriteTestKustomizationWith(fSys, []byte(`resouces:
+- deployment.yaml
+`))
+	mf, err := NewKustomizationFile(fSys)
+	if err != nil {
+		t.Fatalf("Unexpected Error: %v", err)
+	}
 	_, err = mf.Read()
-	if err == nil || err.Error() != "json: unknown field \"foo\"" {
+	if err == nil || err.Error() != "json: unknown field \"resouces\"" {
 		t.Fatalf("Expect an unknown field error but got: %v", err)
 	}
 }
@@ -0,0 +1,234 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package record holds the test record command, which gets the
+// live resources a kustomization builds, to test it against.
+package record
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/cmd/config/runner"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// serverFields are the fields set by the server, which
+// don't belong in fixtures.
+var serverFields = []string{
+	"status",
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.deletionTimestamp",
+	"metadata.deletionGracePeriodSeconds",
+	"metadata.managedFields",
+	"metadata.ownerReferences",
+	"metadata.selfLink",
+	`metadata.annotations.kubectl\.kubernetes\.io/last-applied-configuration`,
+	`metadata.annotations.deployment\.kubernetes\.io/revision`,
+	"spec.clusterIP",
+	"spec.clusterIPs",
+}
+
+type recordFlags struct {
+	output     string
+	kubeconfig string
+	context    string
+	kubectl    string
+}
+
+// NewCmdRecord returns an instance of 'record' subcommand.
+func NewCmdRecord(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	var flags recordFlags
+	c := &cobra.Command{
+		Use:   "record [DIR]",
+		Short: "Records the live resources a kustomization builds as fixtures",
+		Long: `Builds the kustomization in DIR, the current directory by
+default, and gets each resource it outputs from the cluster of
+--kubeconfig with kubectl, writing it to a file of the fixtures
+directory, named <namespace>_<kind>_<name>.yaml, or
+<kind>_<name>.yaml for cluster scoped resources.
+
+The fixtures are sanitized: the fields the server sets, e.g.
+status and metadata.uid, are removed, and the values of secrets
+are emptied.  Resources not in the cluster are listed, and skipped.
+`,
+		Example: `
+	# Records the live resources of overlays/prod
+	kustomize test record overlays/prod --kubeconfig ~/.kube/prod
+
+	# Records them in testdata/prod, from the context staging
+	kustomize test record overlays/prod --context staging -o testdata/prod
+`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := filesys.SelfDir
+			switch len(args) {
+			case 0:
+			case 1:
+				dir = args[0]
+			default:
+				return fmt.Errorf("specify one path to a kustomization")
+			}
+			if flags.output == "" {
+				flags.output = filepath.Join(dir, "fixtures")
+			}
+			return runRecord(fSys, w, dir, flags)
+		},
+	}
+	c.Flags().StringVarP(&flags.output, "output", "o", "",
+		"The directory to write the fixtures to, DIR/fixtures by default.")
+	c.Flags().StringVar(&flags.kubeconfig, "kubeconfig", "",
+		"The kubeconfig file to use, instead of kubectl's default.")
+	c.Flags().StringVar(&flags.context, "context", "",
+		"The kubeconfig context to use, instead of the current one.")
+	c.Flags().StringVar(&flags.kubectl, "kubectl", "kubectl",
+		"The kubectl command to run.")
+	build.AddFlagLoadRestrictor(c.Flags())
+	build.AddFlagEnablePlugins(c.Flags())
+	build.AddFlagEnableHelm(c.Flags())
+	return c
+}
+
+func runRecord(
+	fSys filesys.FileSystem, w io.Writer, dir string, flags recordFlags) error {
+	k := krusty.MakeKustomizer(
+		build.HonorKustomizeFlags(krusty.MakeDefaultOptions()))
+	m, err := k.Run(fSys, dir)
+	if err != nil {
+		return err
+	}
+	var paths [][]string
+	for _, f := range serverFields {
+		p, err := yaml.SplitFieldPath(f)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+	}
+	if err = fSys.MkdirAll(flags.output); err != nil {
+		return err
+	}
+	recorded := 0
+	for _, r := range m.Resources() {
+		if r.IsRaw() {
+			continue
+		}
+		out, err := runner.RunKubectl(flags.kubectl, flags.getArgs(r))
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(out)) == 0 {
+			if _, err = fmt.Fprintf(w, "not found: %s\n", r.CurId()); err != nil {
+				return err
+			}
+			continue
+		}
+		n, err := yaml.Parse(string(out))
+		if err != nil {
+			return fmt.Errorf("reading the live %s: %v", r.CurId(), err)
+		}
+		gvk := r.GetGvk()
+		isSecret := gvk.Group == "" && gvk.Kind == "Secret"
+		if err = sanitize(n, paths, isSecret); err != nil {
+			return err
+		}
+		s, err := n.String()
+		if err != nil {
+			return err
+		}
+		err = fSys.WriteFile(
+			filepath.Join(flags.output, fixtureName(r)), []byte(s))
+		if err != nil {
+			return err
+		}
+		recorded++
+	}
+	_, err = fmt.Fprintf(w, "recorded %d fixtures in %s\n", recorded, flags.output)
+	return err
+}
+
+// getArgs returns the args of kubectl getting the live r,
+// printing nothing if it's not found.
+func (f recordFlags) getArgs(r *resource.Resource) []string {
+	gvk := r.GetGvk()
+	kind := gvk.Kind
+	if gvk.Group != "" {
+		kind = strings.Join([]string{gvk.Kind, gvk.Version, gvk.Group}, ".")
+	}
+	args := []string{"get", kind + "/" + r.GetName(),
+		"-o", "yaml", "--ignore-not-found"}
+	if ns := r.GetNamespace(); ns != "" && gvk.IsNamespaceableKind() {
+		args = append(args, "--namespace", ns)
+	}
+	if f.kubeconfig != "" {
+		args = append(args, "--kubeconfig", f.kubeconfig)
+	}
+	if f.context != "" {
+		args = append(args, "--context", f.context)
+	}
+	return args
+}
+
+// sanitize removes the fields at the paths from n, and
+// then any annotations left empty, and empties the values
+// of n if it's a secret.
+func sanitize(n *yaml.RNode, paths [][]string, isSecret bool) error {
+	for _, p := range paths {
+		parent, err := n.Pipe(yaml.Lookup(p[:len(p)-1]...))
+		if err != nil {
+			return err
+		}
+		if parent == nil || parent.YNode().Kind != yaml.MappingNode {
+			continue
+		}
+		if _, err = parent.Pipe(yaml.Clear(p[len(p)-1])); err != nil {
+			return err
+		}
+	}
+	annotations, err := n.Pipe(yaml.Lookup("metadata", "annotations"))
+	if err != nil {
+		return err
+	}
+	if annotations != nil && len(annotations.YNode().Content) == 0 {
+		if err = n.PipeE(yaml.Lookup("metadata"), yaml.Clear("annotations")); err != nil {
+			return err
+		}
+	}
+	if !isSecret {
+		return nil
+	}
+	for _, field := range []string{"data", "stringData"} {
+		values, err := n.Pipe(yaml.Lookup(field))
+		if err != nil {
+			return err
+		}
+		if values == nil || values.YNode().Kind != yaml.MappingNode {
+			continue
+		}
+		content := values.YNode().Content
+		for i := 1; i < len(content); i += 2 {
+			content[i] = yaml.NewStringRNode("").YNode()
+		}
+	}
+	return nil
+}
+
+// fixtureName returns the name of the file of the fixture of r.
+func fixtureName(r *resource.Resource) string {
+	name := strings.ToLower(r.GetKind() + "_" + r.GetName() + ".yaml")
+	if ns := r.GetNamespace(); ns != "" && r.GetGvk().IsNamespaceableKind() {
+		return strings.ToLower(ns) + "_" + name
+	}
+	return name
+}
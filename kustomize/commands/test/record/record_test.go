@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package record
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+const liveDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+  uid: 1234
+  resourceVersion: "5678"
+  generation: 2
+  annotations:
+    deployment.kubernetes.io/revision: "2"
+spec:
+  replicas: 3
+status:
+  replicas: 3
+`
+
+const liveSecret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: prod
+  creationTimestamp: "2021-01-01T00:00:00Z"
+data:
+  password: aHVudGVyMg==
+`
+
+// writeFakeKubectl writes a kubectl printing the live
+// deployment and secret, and nothing for other resources.
+func writeFakeKubectl(t *testing.T) string {
+	t.Helper()
+	d := t.TempDir()
+	for name, content := range map[string]string{
+		"deployment.yaml": liveDeployment,
+		"secret.yaml":     liveSecret,
+	} {
+		if !assert.NoError(t, ioutil.WriteFile(
+			filepath.Join(d, name), []byte(content), 0600)) {
+			t.FailNow()
+		}
+	}
+	kubectl := filepath.Join(d, "kubectl")
+	if !assert.NoError(t, ioutil.WriteFile(kubectl, []byte(`#!/bin/sh
+echo "$@" >> `+filepath.Join(d, "args")+`
+case "$2" in
+Deployment.v1.apps/web) cat `+filepath.Join(d, "deployment.yaml")+` ;;
+Secret/creds) cat `+filepath.Join(d, "secret.yaml")+` ;;
+esac
+`), 0700)) {
+		t.FailNow()
+	}
+	return kubectl
+}
+
+func TestRecord(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("app/kustomization.yaml", []byte(`
+namespace: prod
+resources:
+- resources.yaml
+`))
+	fSys.WriteFile("app/resources.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`))
+	kubectl := writeFakeKubectl(t)
+	buf := &bytes.Buffer{}
+	cmd := NewCmdRecord(fSys, buf)
+	assert.NoError(t, cmd.Flags().Set("kubectl", kubectl))
+	assert.NoError(t, cmd.Flags().Set("kubeconfig", "prod.config"))
+	if !assert.NoError(t, cmd.RunE(cmd, []string{"app"})) {
+		t.FailNow()
+	}
+	assert.Equal(t, `not found: ~G_v1_ConfigMap|prod|settings
+recorded 2 fixtures in app/fixtures
+`, buf.String())
+	args, err := ioutil.ReadFile(filepath.Join(filepath.Dir(kubectl), "args"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(args), "get Deployment.v1.apps/web -o yaml "+
+		"--ignore-not-found --namespace prod --kubeconfig prod.config\n")
+
+	b, err := fSys.ReadFile("app/fixtures/prod_deployment_web.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 3
+`, string(b))
+	b, err = fSys.ReadFile("app/fixtures/prod_secret_creds.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: prod
+data:
+  password: ""
+`, string(b))
+	assert.False(t, fSys.Exists("app/fixtures/prod_configmap_settings.yaml"))
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package test holds the test command, grouping the commands
+// helping to test kustomizations.
+package test
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/test/record"
+)
+
+// NewCmdTest returns an instance of 'test' subcommand.
+func NewCmdTest(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "test",
+		Short: "Commands helping to test kustomizations",
+		Example: `
+	# Records the live resources of overlays/prod as fixtures
+	kustomize test record overlays/prod --kubeconfig ~/.kube/prod
+`,
+	}
+	c.AddCommand(record.NewCmdRecord(fSys, w))
+	return c
+}
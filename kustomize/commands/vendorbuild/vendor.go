@@ -0,0 +1,116 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package vendorbuild holds the vendor command, which snapshots
+// the output of a build into a kustomization of its own.
+package vendorbuild
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/provenance"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AnnoVendoredFrom records the kustomization
+	// that a vendored snapshot was built from.
+	AnnoVendoredFrom = konfig.ConfigAnnoDomain + "/vendored-from"
+	// AnnoVendoredBy records the version of kustomize
+	// that built a vendored snapshot.
+	AnnoVendoredBy = konfig.ConfigAnnoDomain + "/vendored-by"
+)
+
+type vendorFlags struct {
+	outputDir string
+}
+
+// NewCmdVendor returns an instance of 'vendor' subcommand.
+func NewCmdVendor(fSys filesys.FileSystem) *cobra.Command {
+	var flags vendorFlags
+	c := &cobra.Command{
+		Use:   "vendor DIR -o OUTPUT_DIR",
+		Short: "Write a kustomization holding the build output of DIR",
+		Long: `Build the kustomization at DIR, write each resulting resource
+to its own file in OUTPUT_DIR, and write a kustomization file in
+OUTPUT_DIR that lists those files as its resources.
+
+The snapshot is stamped with the source kustomization and the version
+of kustomize that built it, so rendered output can be reviewed and
+promoted between environments as is.
+`,
+		Example: `
+	# Snapshot the production overlay
+	kustomize vendor overlays/production -o vendored/production
+`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("specify one path to a kustomization")
+			}
+			if flags.outputDir == "" {
+				return fmt.Errorf("must specify an output directory with -o")
+			}
+			return runVendor(fSys, args[0], flags.outputDir)
+		},
+	}
+	c.Flags().StringVarP(
+		&flags.outputDir,
+		"output",
+		"o",
+		"",
+		"Directory to write the snapshot to; created if absent.")
+	build.AddFlagLoadRestrictor(c.Flags())
+	build.AddFlagEnablePlugins(c.Flags())
+	build.AddFlagEnableHelm(c.Flags())
+	return c
+}
+
+func runVendor(fSys filesys.FileSystem, path, outputDir string) error {
+	if fSys.Exists(filepath.Join(outputDir, konfig.DefaultKustomizationFileName())) {
+		return fmt.Errorf(
+			"%s already holds a kustomization file", outputDir)
+	}
+	k := krusty.MakeKustomizer(
+		build.HonorKustomizeFlags(krusty.MakeDefaultOptions()))
+	m, err := k.Run(fSys, path)
+	if err != nil {
+		return err
+	}
+	if err = fSys.MkdirAll(outputDir); err != nil {
+		return err
+	}
+	names, err := build.MakeWriter(fSys).WriteIndividualFilesWithNames(outputDir, m)
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	kust := types.Kustomization{
+		TypeMeta: types.TypeMeta{
+			APIVersion: types.KustomizationVersion,
+			Kind:       types.KustomizationKind,
+		},
+		MetaData: &types.ObjectMeta{
+			Annotations: map[string]string{
+				AnnoVendoredFrom: path,
+				AnnoVendoredBy: fmt.Sprintf(
+					"kustomize-%s", provenance.GetProvenance().Semver()),
+			},
+		},
+		Resources: names,
+	}
+	b, err := yaml.Marshal(kust)
+	if err != nil {
+		return err
+	}
+	return fSys.WriteFile(
+		filepath.Join(outputDir, konfig.DefaultKustomizationFileName()), b)
+}
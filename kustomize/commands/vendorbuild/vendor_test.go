@@ -0,0 +1,98 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package vendorbuild
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/provenance"
+)
+
+func writeOverlay(fSys filesys.FileSystem) {
+	fSys.WriteFile("base/kustomization.yaml", []byte(`
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: config
+  literals:
+  - color=blue
+`))
+	fSys.WriteFile("base/deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`))
+	fSys.WriteFile("overlay/kustomization.yaml", []byte(`
+namePrefix: prod-
+resources:
+- ../base
+`))
+}
+
+func TestVendor(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	writeOverlay(fSys)
+	cmd := NewCmdVendor(fSys)
+	cmd.Flags().Set("output", "vendored")
+	if err := cmd.RunE(cmd, []string{"overlay"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := fSys.ReadFile("vendored/kustomization.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+metadata:
+  annotations:
+    config.kubernetes.io/vendored-by: kustomize-%s
+    config.kubernetes.io/vendored-from: overlay
+resources:
+- apps_v1_deployment_prod-web.yaml
+- v1_configmap_prod-config-747dfcb89d.yaml
+`, provenance.GetProvenance().Semver())
+	if string(data) != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, data)
+	}
+	data, err = fSys.ReadFile("vendored/apps_v1_deployment_prod-web.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-web
+`
+	if string(data) != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, data)
+	}
+
+	// The snapshot is itself buildable, and refuses to be overwritten.
+	cmd = NewCmdVendor(fSys)
+	cmd.Flags().Set("output", "vendored2")
+	if err = cmd.RunE(cmd, []string{"vendored"}); err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.RunE(cmd, []string{"vendored"})
+	if err == nil || !strings.Contains(err.Error(), "already holds a kustomization file") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVendorValidation(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	cmd := NewCmdVendor(fSys)
+	err := cmd.RunE(cmd, []string{"overlay"})
+	if err == nil || !strings.Contains(err.Error(), "must specify an output directory") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "specify one path") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
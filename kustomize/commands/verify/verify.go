@@ -0,0 +1,92 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package verify holds the verify command, which checks the
+// signatures of the function images and helm charts a build
+// uses.
+package verify
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+)
+
+// NewCmdVerify returns an instance of 'verify' subcommand.
+func NewCmdVerify(fSys filesys.FileSystem, w io.Writer) *cobra.Command {
+	var s types.SignaturePolicy
+	c := &cobra.Command{
+		Use:   "verify [DIR]",
+		Short: "Verifies the signatures of the function images and charts of a build",
+		Long: `Builds the kustomization in DIR, the current directory by
+default, checking with cosign that every function image it runs,
+and every helm chart it pulls, is signed, either with the key
+given by --key, or keylessly by the identity given by
+--certificate-identity and --certificate-oidc-issuer.  Without
+them, the signatures of the .kustomize-policy.yaml file of the
+kustomization are required, which a plain build checks too.
+Only charts in OCI registries can be signed.
+
+Functions and charts are only used with --enable-alpha-plugins
+and --enable-helm, as in a build; a function runs once its image
+is verified.  The output of the build is discarded.
+`,
+		Example: `
+	# Verifies the function images of overlays/prod with a key
+	kustomize verify overlays/prod --enable-alpha-plugins --key cosign.pub
+
+	# Verifies them, and their provenance, keylessly
+	kustomize verify overlays/prod --enable-alpha-plugins \
+	  --certificate-identity release@example.com \
+	  --certificate-oidc-issuer https://accounts.google.com \
+	  --attestation-type slsaprovenance
+`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := filesys.SelfDir
+			switch len(args) {
+			case 0:
+			case 1:
+				dir = args[0]
+			default:
+				return fmt.Errorf("specify one path to a kustomization")
+			}
+			opts := build.HonorKustomizeFlags(krusty.MakeDefaultOptions())
+			opts.RequireSignatures = true
+			if s != (types.SignaturePolicy{}) {
+				if s.Key != "" && !strings.Contains(s.Key, "://") {
+					key, err := filepath.Abs(s.Key)
+					if err != nil {
+						return err
+					}
+					s.Key = key
+				}
+				opts.Signatures = &s
+			}
+			if _, err := krusty.MakeKustomizer(opts).Run(fSys, dir); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(w, "verified the signatures used by %s\n", dir)
+			return err
+		},
+	}
+	c.Flags().StringVar(&s.Key, "key", "",
+		"The public key to check signatures with, a file or a KMS URI.")
+	c.Flags().StringVar(&s.Identity, "certificate-identity", "",
+		"The identity of keyless signatures, e.g. an email.")
+	c.Flags().StringVar(&s.Issuer, "certificate-oidc-issuer", "",
+		"The OIDC issuer of the identity of keyless signatures.")
+	c.Flags().StringVar(&s.AttestationType, "attestation-type", "",
+		"The predicate type of attestations to require too, e.g. slsaprovenance.")
+	build.AddFlagLoadRestrictor(c.Flags())
+	build.AddFlagEnablePlugins(c.Flags())
+	build.AddFlagEnableHelm(c.Flags())
+	return c
+}
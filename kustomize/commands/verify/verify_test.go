@@ -0,0 +1,69 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package verify
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+func TestVerify(t *testing.T) {
+	testCases := map[string]struct {
+		flags       map[string]string
+		expected    string
+		expectedErr string
+	}{
+		"noSignaturePolicy": {
+			expectedErr: "signatures are required, but no signature policy is set",
+		},
+		"keyless": {
+			flags: map[string]string{
+				"certificate-identity":    "release@example.com",
+				"certificate-oidc-issuer": "https://accounts.google.com",
+			},
+			expected: "verified the signatures used by app\n",
+		},
+		"keyAndIdentity": {
+			flags: map[string]string{
+				"key":                  "k8s://ns/cosign",
+				"certificate-identity": "release@example.com",
+			},
+			expectedErr: "signatures set both a key and an identity",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fSys := filesys.MakeFsInMemory()
+			fSys.WriteFile("app/kustomization.yaml", []byte(`
+resources:
+- deployment.yaml
+`))
+			fSys.WriteFile("app/deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`))
+			buf := &bytes.Buffer{}
+			cmd := NewCmdVerify(fSys, buf)
+			for f, v := range tc.flags {
+				if !assert.NoError(t, cmd.Flags().Set(f, v)) {
+					t.FailNow()
+				}
+			}
+			err := cmd.RunE(cmd, []string{"app"})
+			if tc.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
@@ -8,11 +8,16 @@ import (
 	"os"
 
 	"sigs.k8s.io/kustomize/kustomize/v4/commands"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/build"
+	"sigs.k8s.io/kustomize/kustomize/v4/commands/daemon"
 )
 
 func main() {
+	if code, ok := daemon.Forward(os.Args[1:], os.Stdout, os.Stderr); ok {
+		os.Exit(code)
+	}
 	if err := commands.NewDefaultCommand().Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(build.ExitCode(err))
 	}
 	os.Exit(0)
 }
@@ -143,6 +143,11 @@ type FunctionSpec struct {
 
 type ExecSpec struct {
 	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Persistent runs the executable once, to serve every
+	// invocation of the function over its stdin and stdout,
+	// rather than once for each. See the sidecar package.
+	Persistent bool `json:"persistent,omitempty" yaml:"persistent,omitempty"`
 }
 
 // ContainerSpec defines a spec for running a function as a container
@@ -0,0 +1,22 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sidecar contains the persistent exec function
+// implementation: the executable is started once, and serves
+// the ResourceLists of every invocation of the function, e.g.
+// by each transformer of a build, over its stdin and stdout,
+// rather than being started for each.
+//
+// Each ResourceList is sent in a frame, a header line of the
+// kind of the frame and the length of its payload, and then
+// the payload:
+//
+//	resources 182
+//	apiVersion: config.kubernetes.io/v1alpha1
+//	kind: ResourceList
+//	...
+//
+// The executable answers each with a frame of the transformed
+// ResourceList, or an error frame whose payload is the message
+// to fail the invocation with. Serve implements its side.
+package sidecar
@@ -0,0 +1,62 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package sidecar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+const (
+	// resourcesFrame holds a ResourceList.
+	resourcesFrame = "resources"
+
+	// errorFrame holds the message an invocation failed with.
+	errorFrame = "error"
+)
+
+// writeFrame writes the payload to w in a frame of the kind.
+func writeFrame(w io.Writer, kind string, payload []byte) error {
+	if _, err := fmt.Fprintf(w, "%s %d\n", kind, len(payload)); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readFrame reads a frame from r, returning its kind and payload,
+// or io.EOF if r ends before the frame starts.  It fails on a frame
+// whose payload is larger than limit bytes, without reading it.
+func readFrame(r *bufio.Reader, limit int64) (string, []byte, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && header == "" {
+			return "", nil, io.EOF
+		}
+		return "", nil, errors.Errorf("truncated frame header %q", header)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return "", nil, errors.Errorf("malformed frame header %q", header)
+	}
+	n, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || n < 0 {
+		return "", nil, errors.Errorf("malformed frame header %q", header)
+	}
+	if n > limit {
+		return "", nil, errors.Errorf(
+			"%s frame of %d bytes exceeds the limit of %d bytes", fields[0], n, limit)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return "", nil, errors.Errorf(
+			"truncated %s frame: %v", fields[0], err)
+	}
+	return fields[0], payload, nil
+}
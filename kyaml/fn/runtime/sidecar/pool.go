@@ -0,0 +1,215 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package sidecar
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// defaultStopTimeout is how long a process is given to exit
+// once its stdin is closed, for a Pool with no StopTimeout.
+const defaultStopTimeout = 10 * time.Second
+
+// Pool holds the running processes of persistent functions,
+// one for each executable and arguments, to reuse them across
+// invocations. They are started by the first invocation, and
+// run until the pool is closed, so whoever makes a pool must
+// close it, e.g. once a build or a run of functions ends.
+type Pool struct {
+	// MaxFrameSize is the size, in bytes, above which a ResourceList
+	// a process answers with fails the invocation, rather than
+	// being read.  If zero, kio.DefaultInputLimits.MaxInputSize.
+	MaxFrameSize int64
+
+	// StopTimeout is how long Close waits for a process to exit,
+	// once its stdin is closed, before killing it.  If zero,
+	// ten seconds.
+	StopTimeout time.Duration
+
+	mu        sync.Mutex
+	processes map[string]*process
+}
+
+// process is a running persistent function.
+type process struct {
+	// mu serializes the invocations of the process.
+	mu     sync.Mutex
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func poolKey(path string, args []string) string {
+	return strings.Join(append([]string{path}, args...), "\x00")
+}
+
+// Invoke sends the ResourceList read from reader to the process
+// of the executable at path with the args, starting it if it
+// isn't running, and writes the ResourceList it answers with to
+// writer. A process that fails to answer is stopped, so that the
+// next invocation starts a new one.
+func (p *Pool) Invoke(
+	path string, args []string, reader io.Reader, writer io.Writer) error {
+	input, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	proc, err := p.get(path, args)
+	if err != nil {
+		return err
+	}
+	limit := p.MaxFrameSize
+	if limit == 0 {
+		limit = kio.DefaultInputLimits.MaxInputSize
+	}
+	kind, payload, err := proc.invoke(input, limit)
+	if err != nil {
+		p.remove(path, args, proc)
+		return errors.Errorf("persistent function %s: %v", proc.name, err)
+	}
+	switch kind {
+	case resourcesFrame:
+		_, err = writer.Write(payload)
+		return err
+	case errorFrame:
+		return errors.Errorf(
+			"persistent function %s: %s", proc.name, payload)
+	default:
+		p.remove(path, args, proc)
+		return errors.Errorf(
+			"persistent function %s: unknown frame kind %q", proc.name, kind)
+	}
+}
+
+// Close stops the processes of the pool, by closing their stdin,
+// and waits for them to exit, killing those that don't within the
+// StopTimeout. The pool can be used afterwards, starting new
+// processes.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	processes := p.processes
+	p.processes = nil
+	p.mu.Unlock()
+	timeout := p.StopTimeout
+	if timeout == 0 {
+		timeout = defaultStopTimeout
+	}
+	var errs []string
+	for _, proc := range processes {
+		if err := proc.stop(timeout); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf(
+			"stopping persistent functions: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (p *Pool) get(path string, args []string) (*process, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := poolKey(path, args)
+	if proc, ok := p.processes[key]; ok {
+		return proc, nil
+	}
+	proc, err := start(path, args)
+	if err != nil {
+		return nil, err
+	}
+	if p.processes == nil {
+		p.processes = map[string]*process{}
+	}
+	p.processes[key] = proc
+	return proc, nil
+}
+
+// remove kills the process, which may not be reading its stdin
+// anymore, and removes it from the pool unless it has already
+// been replaced.
+func (p *Pool) remove(path string, args []string, proc *process) {
+	p.mu.Lock()
+	key := poolKey(path, args)
+	if p.processes[key] == proc {
+		delete(p.processes, key)
+	}
+	p.mu.Unlock()
+	_ = proc.cmd.Process.Kill()
+	_ = proc.stop(0)
+}
+
+func start(path string, args []string) (*process, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &process{
+		name:   path,
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+	}, nil
+}
+
+func (proc *process) invoke(input []byte, limit int64) (string, []byte, error) {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	if err := writeFrame(proc.stdin, resourcesFrame, input); err != nil {
+		return "", nil, err
+	}
+	kind, payload, err := readFrame(proc.stdout, limit)
+	if err == io.EOF {
+		return "", nil, errors.Errorf("exited without answering")
+	}
+	return kind, payload, err
+}
+
+// stop closes the stdin of the process and waits for it to exit,
+// killing it if it hasn't within the timeout, if above zero.
+func (proc *process) stop(timeout time.Duration) error {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	_ = proc.stdin.Close()
+	exited := make(chan error, 1)
+	go func() { exited <- proc.cmd.Wait() }()
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+	select {
+	case err := <-exited:
+		if err != nil {
+			return errors.Errorf("%s: %v", proc.name, err)
+		}
+		return nil
+	case <-timedOut:
+		_ = proc.cmd.Process.Kill()
+		<-exited
+		return errors.Errorf(
+			"%s: killed, not having exited %v after its stdin closed", proc.name, timeout)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package sidecar
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/runtimeutil"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Filter runs a persistent exec function, in a process of the
+// Pool.  A Filter with no Pool runs the function in a process of
+// its own, stopped once the invocation ends.
+type Filter struct {
+	// Path is the path to the executable to run
+	Path string `yaml:"path,omitempty"`
+
+	// Args are the arguments to the executable
+	Args []string `yaml:"args,omitempty"`
+
+	// Pool holds the process of the executable
+	Pool *Pool `yaml:"-"`
+
+	runtimeutil.FunctionFilter
+}
+
+func (c *Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	c.FunctionFilter.Run = c.Run
+	return c.FunctionFilter.Filter(nodes)
+}
+
+func (c *Filter) Run(reader io.Reader, writer io.Writer) error {
+	if c.Pool != nil {
+		return c.Pool.Invoke(c.Path, c.Args, reader, writer)
+	}
+	pool := &Pool{}
+	err := pool.Invoke(c.Path, c.Args, reader, writer)
+	if closeErr := pool.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Serve is the side of the executable of a persistent function:
+// it runs fn on the ResourceList of each frame read from in,
+// until in ends, answering each on out with the ResourceList fn
+// writes, or with the error it returns. fn has the signature of
+// the Run of a function filter, reading and writing ResourceLists
+// as an executable run for each invocation does on its stdin and
+// stdout.
+func Serve(in io.Reader, out io.Writer, fn func(io.Reader, io.Writer) error) error {
+	r := bufio.NewReader(in)
+	w := bufio.NewWriter(out)
+	for {
+		kind, payload, err := readFrame(r, kio.DefaultInputLimits.MaxInputSize)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var result bytes.Buffer
+		if kind != resourcesFrame {
+			err = writeFrame(w, errorFrame, []byte("unknown frame kind "+kind))
+		} else if fnErr := fn(bytes.NewReader(payload), &result); fnErr != nil {
+			err = writeFrame(w, errorFrame, []byte(fnErr.Error()))
+		} else {
+			err = writeFrame(w, resourcesFrame, result.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
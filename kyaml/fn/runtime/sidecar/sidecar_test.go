@@ -0,0 +1,170 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package sidecar_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/sidecar"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TestHelperProcess is the executable of the persistent
+// functions of the tests, run as the test binary with
+// the mode after "--".
+func TestHelperProcess(t *testing.T) {
+	mode := ""
+	for i, arg := range os.Args {
+		if arg == "--" && i+1 < len(os.Args) {
+			mode = os.Args[i+1]
+		}
+	}
+	if mode == "" {
+		return
+	}
+	invocations := 0
+	err := sidecar.Serve(os.Stdin, os.Stdout, func(r io.Reader, w io.Writer) error {
+		invocations++
+		switch mode {
+		case "exit":
+			os.Exit(0)
+		case "huge":
+			// A frame header claiming a TiB of payload.
+			fmt.Fprint(os.Stdout, "resources 1099511627776\n")
+			time.Sleep(time.Hour)
+		}
+		rw := &kio.ByteReadWriter{Reader: r, Writer: w}
+		return kio.Pipeline{
+			Inputs: []kio.Reader{rw},
+			Filters: []kio.Filter{kio.FilterFunc(
+				func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+					for _, n := range nodes {
+						if n.GetName() == "bad" {
+							return nil, fmt.Errorf("%s is bad", n.GetName())
+						}
+						if err := n.PipeE(yaml.SetAnnotation(
+							"invocation", fmt.Sprint(invocations))); err != nil {
+							return nil, err
+						}
+					}
+					return nodes, nil
+				})},
+			Outputs: []kio.Writer{rw},
+		}.Execute()
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if mode == "linger" {
+		// Ignore the end of stdin.
+		time.Sleep(time.Hour)
+	}
+	os.Exit(0)
+}
+
+func helperFilter(pool *sidecar.Pool, mode string) *sidecar.Filter {
+	return &sidecar.Filter{
+		Path: os.Args[0],
+		Args: []string{"-test.run=^TestHelperProcess$", "--", mode},
+		Pool: pool,
+	}
+}
+
+// invocation runs the filter on a resource of the name,
+// returning the invocation annotation of the process.
+func invocation(t *testing.T, f *sidecar.Filter, name string) (string, error) {
+	t.Helper()
+	output, err := f.Filter([]*yaml.RNode{yaml.MustParse(fmt.Sprintf(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+`, name))})
+	if err != nil {
+		return "", err
+	}
+	if !assert.Len(t, output, 1) {
+		t.FailNow()
+	}
+	annotations, err := output[0].GetAnnotations()
+	return annotations["invocation"], err
+}
+
+func TestFilter_ReusesProcess(t *testing.T) {
+	pool := &sidecar.Pool{}
+	defer pool.Close()
+	for _, expected := range []string{"1", "2", "3"} {
+		// Each filter is another invocation of the function.
+		actual, err := invocation(t, helperFilter(pool, "serve"), "cm")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, expected, actual)
+	}
+
+	// Other arguments are another function.
+	other := helperFilter(pool, "serve")
+	other.Args = append(other.Args, "other")
+	actual, err := invocation(t, other, "cm")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", actual)
+
+	assert.NoError(t, pool.Close())
+	actual, err = invocation(t, helperFilter(pool, "serve"), "cm")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", actual)
+}
+
+func TestFilter_Errors(t *testing.T) {
+	pool := &sidecar.Pool{}
+	defer pool.Close()
+	f := helperFilter(pool, "serve")
+	_, err := invocation(t, f, "bad")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "bad is bad")
+	}
+	// The process survives the error of an invocation.
+	actual, err := invocation(t, f, "cm")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", actual)
+}
+
+func TestFilter_Exits(t *testing.T) {
+	pool := &sidecar.Pool{}
+	defer pool.Close()
+	_, err := invocation(t, helperFilter(pool, "exit"), "cm")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "exited without answering")
+	}
+	_, err = invocation(t, &sidecar.Filter{Path: "/nonexistent", Pool: pool}, "cm")
+	assert.Error(t, err)
+}
+
+func TestFilter_FrameTooLarge(t *testing.T) {
+	pool := &sidecar.Pool{}
+	defer pool.Close()
+	_, err := invocation(t, helperFilter(pool, "huge"), "cm")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"resources frame of 1099511627776 bytes exceeds the limit of")
+	}
+}
+
+func TestPool_CloseKillsLingering(t *testing.T) {
+	pool := &sidecar.Pool{StopTimeout: 100 * time.Millisecond}
+	actual, err := invocation(t, helperFilter(pool, "linger"), "cm")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", actual)
+	err = pool.Close()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "killed, not having exited 100ms")
+	}
+}
@@ -46,12 +46,16 @@ type ByteReadWriter struct {
 	NoWrap             bool
 	WrappingAPIVersion string
 	WrappingKind       string
+
+	// Limits bound the documents read.  If nil, DefaultInputLimits apply.
+	Limits *InputLimits
 }
 
 func (rw *ByteReadWriter) Read() ([]*yaml.RNode, error) {
 	b := &ByteReader{
 		Reader:                rw.Reader,
 		OmitReaderAnnotations: rw.OmitReaderAnnotations,
+		Limits:                rw.Limits,
 	}
 	val, err := b.Read()
 	if rw.FunctionConfig == nil {
@@ -87,9 +91,16 @@ func ParseAll(inputs ...string) ([]*yaml.RNode, error) {
 
 // FromBytes reads from a byte slice.
 func FromBytes(bs []byte) ([]*yaml.RNode, error) {
+	return FromBytesWithLimits(bs, nil)
+}
+
+// FromBytesWithLimits is FromBytes, with the given limits, or
+// DefaultInputLimits if nil.
+func FromBytesWithLimits(bs []byte, limits *InputLimits) ([]*yaml.RNode, error) {
 	return (&ByteReader{
 		OmitReaderAnnotations: true,
 		Reader:                bytes.NewBuffer(bs),
+		Limits:                limits,
 	}).Read()
 }
 
@@ -129,6 +140,9 @@ type ByteReader struct {
 	// WrappingKind is set by Read(), and is the kind of the object that
 	// the read objects were originally wrapped in.
 	WrappingKind string
+
+	// Limits bound the documents read.  If nil, DefaultInputLimits apply.
+	Limits *InputLimits
 }
 
 var _ Reader = &ByteReader{}
@@ -138,22 +152,39 @@ func (r *ByteReader) Read() ([]*yaml.RNode, error) {
 
 	// by manually splitting resources -- otherwise the decoder will get the Resource
 	// boundaries wrong for header comments.
+	limits := DefaultInputLimits
+	if r.Limits != nil {
+		limits = *r.Limits
+	}
 	input := &bytes.Buffer{}
-	_, err := io.Copy(input, r.Reader)
+	n, err := io.Copy(input, limits.limitReader(r.Reader))
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
+	if err = limits.checkInputSize(n); err != nil {
+		return nil, err
+	}
 
 	// replace the ending \r\n (line ending used in windows) with \n and then separate by \n---\n
 	values := strings.Split(strings.ReplaceAll(input.String(), "\r\n", "\n"), "\n---\n")
 
 	index := 0
+	// offset is the number of lines of the input before the
+	// value, so that the lines of its nodes are those of the
+	// input rather than of the value.
+	offset := 0
 	for i := range values {
+		valueOffset := offset
+		// The value, and the "---" line after it.
+		offset += strings.Count(values[i], "\n") + 2
 		// the Split used above will eat the tail '\n' from each resource. This may affect the
 		// literal string value since '\n' is meaningful in it.
 		if i != len(values)-1 {
 			values[i] += "\n"
 		}
+		if err := limits.checkDocumentSize(i, values[i]); err != nil {
+			return nil, err
+		}
 		decoder := yaml.NewDecoder(bytes.NewBufferString(values[i]))
 		node, err := r.decode(index, decoder)
 		if err == io.EOF {
@@ -166,6 +197,10 @@ func (r *ByteReader) Read() ([]*yaml.RNode, error) {
 			// empty value
 			continue
 		}
+		if err := limits.checkNode(i, node.YNode()); err != nil {
+			return nil, err
+		}
+		shiftLines(node.YNode(), valueOffset)
 
 		// ok if no metadata -- assume not an InputList
 		meta, err := node.GetMeta()
@@ -209,6 +244,20 @@ func (r *ByteReader) Read() ([]*yaml.RNode, error) {
 	return output, nil
 }
 
+// shiftLines adds offset to the lines of n and the nodes
+// in it, those parsed rather than added after.
+func shiftLines(n *yaml.Node, offset int) {
+	if offset == 0 {
+		return
+	}
+	if n.Line > 0 {
+		n.Line += offset
+	}
+	for _, c := range n.Content {
+		shiftLines(c, offset)
+	}
+}
+
 func (r *ByteReader) decode(index int, decoder *yaml.Decoder) (*yaml.RNode, error) {
 	node := &yaml.Node{}
 	err := decoder.Decode(node)
@@ -749,3 +749,25 @@ items:
 		})
 	}
 }
+
+func TestByteReaderLines(t *testing.T) {
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(`---
+a: b
+---
+# The second.
+c: d
+---
+e:
+  f: g
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, nodes, 3) {
+		t.FailNow()
+	}
+	// The lines are those of the input, not of its documents.
+	assert.Equal(t, 2, nodes[0].Field("a").Key.YNode().Line)
+	assert.Equal(t, 5, nodes[1].Field("c").Key.YNode().Line)
+	assert.Equal(t, 8, nodes[2].Field("e").Value.Field("f").Key.YNode().Line)
+}
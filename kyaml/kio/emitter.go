@@ -0,0 +1,100 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"encoding/json"
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Emitter encodes resources for output, e.g. as YAML, so that
+// embedders may produce encodings of their own, such as CUE,
+// HCL or protobuf.
+type Emitter interface {
+	// Emit writes the encoding of the nodes, in order, to w.
+	// The nodes mustn't be changed.
+	Emit(w io.Writer, nodes []*yaml.RNode) error
+}
+
+// EmitterFunc is an Emitter implemented by a function.
+type EmitterFunc func(w io.Writer, nodes []*yaml.RNode) error
+
+// Emit implements Emitter.
+func (f EmitterFunc) Emit(w io.Writer, nodes []*yaml.RNode) error {
+	return f(w, nodes)
+}
+
+// YAMLEmitter emits the nodes as YAML documents, separated
+// by ---, keeping the order of fields and the comments.
+type YAMLEmitter struct {
+	// KeepReaderAnnotations keeps the annotations Readers set,
+	// e.g. config.kubernetes.io/index, which are otherwise
+	// left out.
+	KeepReaderAnnotations bool
+}
+
+var _ Emitter = YAMLEmitter{}
+
+// Emit implements Emitter.
+func (e YAMLEmitter) Emit(w io.Writer, nodes []*yaml.RNode) error {
+	return ByteWriter{
+		Writer:                w,
+		KeepReaderAnnotations: e.KeepReaderAnnotations,
+	}.Write(copyNodes(nodes))
+}
+
+// JSONEmitter emits the nodes as the items of a JSON
+// v1 List, as kubectl get -o json does.
+type JSONEmitter struct {
+	// Indent is the indentation of the JSON;
+	// if empty, it's written on one line.
+	Indent string
+
+	// KeepReaderAnnotations is as for YAMLEmitter.
+	KeepReaderAnnotations bool
+}
+
+var _ Emitter = JSONEmitter{}
+
+// Emit implements Emitter.
+func (e JSONEmitter) Emit(w io.Writer, nodes []*yaml.RNode) error {
+	list := struct {
+		APIVersion string            `json:"apiVersion"`
+		Kind       string            `json:"kind"`
+		Items      []json.RawMessage `json:"items"`
+	}{APIVersion: "v1", Kind: "List", Items: []json.RawMessage{}}
+	for _, n := range nodes {
+		if !e.KeepReaderAnnotations {
+			n = n.Copy()
+			if err := n.PipeE(yaml.ClearAnnotation(kioutil.IndexAnnotation)); err != nil {
+				return errors.Wrap(err)
+			}
+			if err := yaml.ClearEmptyAnnotations(n); err != nil {
+				return err
+			}
+		}
+		item, err := n.MarshalJSON()
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		list.Items = append(list.Items, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", e.Indent)
+	return errors.Wrap(enc.Encode(list))
+}
+
+// copyNodes returns copies of the nodes, for writers that
+// change the nodes they write, e.g. clearing annotations.
+func copyNodes(nodes []*yaml.RNode) []*yaml.RNode {
+	result := make([]*yaml.RNode, len(nodes))
+	for i, n := range nodes {
+		result[i] = n.Copy()
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestEmitters(t *testing.T) {
+	input := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a # the first
+  annotations:
+    config.kubernetes.io/index: '0'
+data:
+  k: v
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: b
+  annotations:
+    config.kubernetes.io/index: '1'
+`
+	testCases := map[string]struct {
+		emitter  Emitter
+		expected string
+	}{
+		"yaml": {
+			emitter: YAMLEmitter{},
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a # the first
+data:
+  k: v
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: b
+`,
+		},
+		"json": {
+			emitter: JSONEmitter{},
+			expected: `{"apiVersion":"v1","kind":"List","items":[` +
+				`{"apiVersion":"v1","data":{"k":"v"},"kind":"ConfigMap","metadata":{"name":"a"}},` +
+				`{"apiVersion":"v1","kind":"Service","metadata":{"name":"b"}}]}
+`,
+		},
+		"json, indented, of nothing": {
+			emitter: EmitterFunc(func(w io.Writer, _ []*yaml.RNode) error {
+				return JSONEmitter{Indent: "  "}.Emit(w, nil)
+			}),
+			expected: `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": []
+}
+`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			nodes, err := (&ByteReader{Reader: bytes.NewBufferString(input)}).Read()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			var out bytes.Buffer
+			if !assert.NoError(t, tc.emitter.Emit(&out, nodes)) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, out.String())
+			// The nodes are left as they were.
+			annotations, err := nodes[0].GetAnnotations()
+			if assert.NoError(t, err) {
+				assert.Equal(t, "0", annotations["config.kubernetes.io/index"])
+			}
+		})
+	}
+}
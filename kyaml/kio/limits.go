@@ -0,0 +1,178 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// InputLimits bound the documents a ByteReader accepts, so that
+// hostile input, e.g. a YAML bomb in a remote base, fails to be
+// read rather than exhausting memory.  A zero limit disables
+// the corresponding check.
+type InputLimits struct {
+	// MaxInputSize is the maximum size, in bytes, of the input,
+	// i.e. of all its documents.  No more than that is read.
+	MaxInputSize int64
+
+	// MaxDocumentSize is the maximum size, in bytes, of a document.
+	MaxDocumentSize int
+
+	// MaxNestingDepth is the maximum depth of the nodes
+	// of a document, counting those reached through aliases.
+	MaxNestingDepth int
+
+	// MaxAliasExpansion is the maximum number of nodes that the
+	// aliases of a document may expand to, i.e. the number of
+	// nodes added to the document by replacing each alias with
+	// a copy of its anchor.
+	MaxAliasExpansion int
+}
+
+// DefaultInputLimits are the limits used by a ByteReader whose
+// Limits are nil.  They are well beyond what legitimate configuration
+// needs.
+var DefaultInputLimits = InputLimits{
+	MaxInputSize:      256 << 20,
+	MaxDocumentSize:   32 << 20,
+	MaxNestingDepth:   1000,
+	MaxAliasExpansion: 1000000,
+}
+
+// NoInputLimits disables all the checks.
+var NoInputLimits = InputLimits{}
+
+// limitReader returns r, reading at most one byte past
+// the size limit, so that checkInputSize may tell that the
+// input exceeds it without reading all of it.
+func (l InputLimits) limitReader(r io.Reader) io.Reader {
+	if l.MaxInputSize <= 0 {
+		return r
+	}
+	return io.LimitReader(r, l.MaxInputSize+1)
+}
+
+// checkInputSize returns an error if the n bytes
+// read through limitReader exceed the size limit.
+func (l InputLimits) checkInputSize(n int64) error {
+	if l.MaxInputSize > 0 && n > l.MaxInputSize {
+		return errors.Errorf(
+			"the input exceeds the limit of %d bytes", l.MaxInputSize)
+	}
+	return nil
+}
+
+// checkDocumentSize returns an error if document i is too large.
+func (l InputLimits) checkDocumentSize(i int, doc string) error {
+	if l.MaxDocumentSize > 0 && len(doc) > l.MaxDocumentSize {
+		return errors.Errorf(
+			"document %d is %d bytes, which exceeds the limit of %d bytes",
+			i, len(doc), l.MaxDocumentSize)
+	}
+	return nil
+}
+
+// checkNode returns an error if document i, whose root is
+// node, is nested too deeply or expands to too many nodes.
+func (l InputLimits) checkNode(i int, node *yaml.Node) error {
+	if l.MaxNestingDepth <= 0 && l.MaxAliasExpansion <= 0 {
+		return nil
+	}
+	m := &nodeMeasure{measured: make(map[*yaml.Node]nodeSize)}
+	size, err := m.measure(node)
+	if err != nil {
+		return errors.WrapPrefixf(err, "document %d", i)
+	}
+	if l.MaxNestingDepth > 0 && size.depth > l.MaxNestingDepth {
+		return errors.Errorf(
+			"document %d is nested %d deep, which exceeds the limit of %d",
+			i, size.depth, l.MaxNestingDepth)
+	}
+	if l.MaxAliasExpansion > 0 && size.count-m.unaliased() > l.MaxAliasExpansion {
+		return errors.Errorf(
+			"the aliases of document %d expand to more than %d nodes",
+			i, l.MaxAliasExpansion)
+	}
+	return nil
+}
+
+// nodeSize is the size of a node with its aliases expanded.
+type nodeSize struct {
+	// count is the number of nodes, including the node itself.
+	count int
+	// depth is the depth of the node, 1 for a scalar.
+	depth int
+}
+
+// nodeMeasure measures nodes as if their aliases were expanded,
+// remembering the size of each node measured, so that measuring
+// never takes longer than walking the unexpanded nodes.
+type nodeMeasure struct {
+	measured map[*yaml.Node]nodeSize
+	// visiting holds the nodes being measured, to detect cycles.
+	visiting map[*yaml.Node]bool
+}
+
+// unaliased returns the number of nodes measured other
+// than aliases, i.e. the size of the unexpanded document.
+func (m *nodeMeasure) unaliased() int {
+	result := 0
+	for n := range m.measured {
+		if n.Kind != yaml.AliasNode {
+			result++
+		}
+	}
+	return result
+}
+
+// maxCount caps the counts, which grow
+// exponentially in the depth of aliases.
+const maxCount = 1 << 40
+
+func (m *nodeMeasure) measure(n *yaml.Node) (nodeSize, error) {
+	if s, ok := m.measured[n]; ok {
+		return s, nil
+	}
+	if m.visiting == nil {
+		m.visiting = make(map[*yaml.Node]bool)
+	}
+	if m.visiting[n] {
+		return nodeSize{}, errors.Errorf("alias %s refers to itself", n.Value)
+	}
+	m.visiting[n] = true
+	defer delete(m.visiting, n)
+
+	var s nodeSize
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		as, err := m.measure(n.Alias)
+		if err != nil {
+			return nodeSize{}, err
+		}
+		s = as
+	} else {
+		s = nodeSize{count: 1, depth: 1}
+		for _, c := range n.Content {
+			cs, err := m.measure(c)
+			if err != nil {
+				return nodeSize{}, err
+			}
+			s.count = capped(s.count + cs.count)
+			if cs.depth+1 > s.depth {
+				s.depth = cs.depth + 1
+			}
+		}
+	}
+	m.measured[n] = s
+	return s, nil
+}
+
+func capped(n int) int {
+	if n > maxCount {
+		return maxCount
+	}
+	return n
+}
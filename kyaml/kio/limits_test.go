@@ -0,0 +1,125 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// billionLaughs expands to 9^9 strings.
+const billionLaughs = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: lol
+data:
+  a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+  b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]
+  c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b]
+  d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c]
+  e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d]
+  f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e]
+  g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f]
+  h: &h [*g,*g,*g,*g,*g,*g,*g,*g,*g]
+  i: &i [*h,*h,*h,*h,*h,*h,*h,*h,*h]
+`
+
+const withAliases = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ok
+  labels: &labels
+    app: web
+  annotations: *labels
+`
+
+func nested(depth int) string {
+	return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: deep
+data:
+  x: ` + strings.Repeat("[", depth) + strings.Repeat("]", depth) + "\n"
+}
+
+func TestInputLimits(t *testing.T) {
+	testCases := map[string]struct {
+		input  string
+		limits *InputLimits
+		err    string
+	}{
+		"aliases within limits": {
+			input: withAliases,
+		},
+		"alias bomb": {
+			input: billionLaughs,
+			err:   "the aliases of document 0 expand to more than 1000000 nodes",
+		},
+		"alias bomb without limits": {
+			input:  billionLaughs,
+			limits: &NoInputLimits,
+		},
+		"aliases over a custom limit": {
+			input:  withAliases,
+			limits: &InputLimits{MaxAliasExpansion: 1},
+			err:    "the aliases of document 0 expand to more than 1 nodes",
+		},
+		"nesting within limits": {
+			input: nested(100),
+		},
+		"nesting too deep": {
+			input:  nested(100),
+			limits: &InputLimits{MaxNestingDepth: 50},
+			err:    "document 0 is nested 102 deep, which exceeds the limit of 50",
+		},
+		"input too large": {
+			input:  "a: b\n---\na: " + strings.Repeat("b", 100) + "\n",
+			limits: &InputLimits{MaxInputSize: 50},
+			err:    "the input exceeds the limit of 50 bytes",
+		},
+		"document too large": {
+			input:  "a: b\n---\na: " + strings.Repeat("b", 100) + "\n",
+			limits: &InputLimits{MaxDocumentSize: 50},
+			err:    "document 1 is 104 bytes, which exceeds the limit of 50 bytes",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := (&ByteReader{
+				Reader:                bytes.NewBufferString(tc.input),
+				OmitReaderAnnotations: true,
+				Limits:                tc.limits,
+			}).Read()
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, tc.err, err.Error())
+			}
+		})
+	}
+}
+
+// endless is an input that never ends.
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+func TestInputLimitsStopReading(t *testing.T) {
+	_, err := (&ByteReader{
+		Reader: endless{},
+		Limits: &InputLimits{MaxInputSize: 1 << 20},
+	}).Read()
+	assert.EqualError(t, err, "the input exceeds the limit of 1048576 bytes")
+}
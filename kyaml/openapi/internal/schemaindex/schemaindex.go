@@ -0,0 +1,203 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package schemaindex precompiles a swagger document into an index
+// that loads an order of magnitude faster than the document itself.
+//
+// Most of a Kubernetes swagger document describes its api paths,
+// which are only needed to tell whether resources are namespaced,
+// so the index keeps just that, along with the raw definitions.
+package schemaindex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// FormatVersion is the version of the encoding of the index; it
+// must be bumped whenever Index changes, so that stale indexes
+// are rejected rather than misread.
+const FormatVersion = 1
+
+const (
+	// gvkExtensionKey is the key of the kubernetes group version kind extension
+	gvkExtensionKey = "x-kubernetes-group-version-kind"
+
+	// groupKey is the key to lookup the group from the GVK extension
+	groupKey = "group"
+	// versionKey is the key to lookup the version from the GVK extension
+	versionKey = "version"
+	// kindKey is the the to lookup the kind from the GVK extension
+	kindKey = "kind"
+)
+
+// Index is the precompiled form of a swagger document.  It holds
+// slices sorted by name rather than maps so that it encodes the
+// same way each time it is generated.
+type Index struct {
+	// FormatVersion is the version of the encoding.
+	FormatVersion int
+
+	// Definitions are the definitions of the document.
+	Definitions []Definition
+
+	// Scopes tell whether each resource type is namespaced.
+	Scopes []Scope
+}
+
+// Definition is a named definition, as compacted JSON.
+type Definition struct {
+	Name   string
+	Schema json.RawMessage
+}
+
+// Scope tells whether a resource type is namespaced.
+type Scope struct {
+	yaml.TypeMeta
+	Namespaced bool
+}
+
+// FromSwagger builds the index of the swagger document b.
+func FromSwagger(b []byte) (*Index, error) {
+	var swagger spec.Swagger
+	if err := swagger.UnmarshalJSON(b); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	var raw struct {
+		Definitions map[string]json.RawMessage `json:"definitions"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	x := &Index{FormatVersion: FormatVersion}
+	for k, d := range raw.Definitions {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, d); err != nil {
+			return nil, errors.WrapPrefixf(err, "definition %s", k)
+		}
+		x.Definitions = append(x.Definitions, Definition{Name: k, Schema: buf.Bytes()})
+	}
+	sort.Slice(x.Definitions, func(i, j int) bool {
+		return x.Definitions[i].Name < x.Definitions[j].Name
+	})
+	for t, namespaced := range Namespaceability(swagger.Paths) {
+		x.Scopes = append(x.Scopes, Scope{TypeMeta: t, Namespaced: namespaced})
+	}
+	sort.Slice(x.Scopes, func(i, j int) bool {
+		if x.Scopes[i].APIVersion != x.Scopes[j].APIVersion {
+			return x.Scopes[i].APIVersion < x.Scopes[j].APIVersion
+		}
+		return x.Scopes[i].Kind < x.Scopes[j].Kind
+	})
+	return x, nil
+}
+
+// Encode writes the gzipped, gob encoded index to w.
+func (x *Index) Encode(w io.Writer) error {
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if err = gob.NewEncoder(gz).Encode(x); err != nil {
+		return errors.Wrap(err)
+	}
+	return errors.Wrap(gz.Close())
+}
+
+// Decode reads an index written by Encode.
+func Decode(b []byte) (*Index, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer gz.Close()
+	var x Index
+	if err = gob.NewDecoder(gz).Decode(&x); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if x.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf(
+			"schema index has format version %d, expected %d",
+			x.FormatVersion, FormatVersion)
+	}
+	return &x, nil
+}
+
+// Schemas parses the definitions of the index.
+func (x *Index) Schemas() (spec.Definitions, error) {
+	result := make(spec.Definitions, len(x.Definitions))
+	for _, d := range x.Definitions {
+		var s spec.Schema
+		if err := s.UnmarshalJSON(d.Schema); err != nil {
+			return nil, errors.WrapPrefixf(err, "definition %s", d.Name)
+		}
+		result[d.Name] = s
+	}
+	return result, nil
+}
+
+// Namespaced tells whether each resource type of the index is namespaced.
+func (x *Index) Namespaced() map[yaml.TypeMeta]bool {
+	result := make(map[yaml.TypeMeta]bool, len(x.Scopes))
+	for _, s := range x.Scopes {
+		result[s.TypeMeta] = s.Namespaced
+	}
+	return result
+}
+
+// Namespaceability looks at the api paths for the resources to determine
+// if they are cluster-scoped or namespace-scoped. The gvk of the resource
+// for each path is found by looking at the x-kubernetes-group-version-kind
+// extension. If a path exists for the resource that contains a namespace
+// path parameter, the resource is namespace-scoped.
+func Namespaceability(paths *spec.Paths) map[yaml.TypeMeta]bool {
+	result := make(map[yaml.TypeMeta]bool)
+	if paths == nil {
+		return result
+	}
+	for path, pathInfo := range paths.Paths {
+		if pathInfo.Get == nil {
+			continue
+		}
+		gvk, found := pathInfo.Get.VendorExtensible.Extensions[gvkExtensionKey]
+		if !found {
+			continue
+		}
+		typeMeta, found := ToTypeMeta(gvk)
+		if !found {
+			continue
+		}
+		if strings.Contains(path, "namespaces/{namespace}") {
+			result[typeMeta] = true
+		} else if _, found := result[typeMeta]; !found {
+			result[typeMeta] = false
+		}
+	}
+	return result
+}
+
+// ToTypeMeta converts the value of a group version kind extension
+// to the TypeMeta it describes.
+func ToTypeMeta(ext interface{}) (yaml.TypeMeta, bool) {
+	m, ok := ext.(map[string]interface{})
+	if !ok {
+		return yaml.TypeMeta{}, false
+	}
+
+	g := m[groupKey].(string)
+	apiVersion := m[versionKey].(string)
+	if g != "" {
+		apiVersion = g + "/" + apiVersion
+	}
+	return yaml.TypeMeta{Kind: m[kindKey].(string), APIVersion: apiVersion}, true
+}
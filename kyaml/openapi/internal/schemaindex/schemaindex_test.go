@@ -0,0 +1,112 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemaindex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const swagger = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "v1.20.4"},
+  "paths": {
+    "/api/v1/namespaces/{namespace}/configmaps": {
+      "get": {"x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "ConfigMap"}}
+    },
+    "/api/v1/configmaps": {
+      "get": {"x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "ConfigMap"}}
+    },
+    "/apis/rbac.authorization.k8s.io/v1/clusterroles": {
+      "get": {"x-kubernetes-group-version-kind": {"group": "rbac.authorization.k8s.io", "version": "v1", "kind": "ClusterRole"}}
+    }
+  },
+  "definitions": {
+    "io.k8s.api.core.v1.ConfigMap": {
+      "type": "object",
+      "properties": {
+        "data": {"type": "object", "additionalProperties": {"type": "string"}},
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "", "version": "v1", "kind": "ConfigMap"}]
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+      "type": "object",
+      "properties": {"name": {"type": "string"}}
+    }
+  }
+}`
+
+func TestRoundTrip(t *testing.T) {
+	x, err := FromSwagger([]byte(swagger))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var buf bytes.Buffer
+	if !assert.NoError(t, x.Encode(&buf)) {
+		t.FailNow()
+	}
+	decoded, err := Decode(buf.Bytes())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, x, decoded)
+
+	var expected spec.Swagger
+	if !assert.NoError(t, expected.UnmarshalJSON([]byte(swagger))) {
+		t.FailNow()
+	}
+	definitions, err := decoded.Schemas()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, expected.Definitions, definitions)
+	assert.Equal(t, map[yaml.TypeMeta]bool{
+		{APIVersion: "v1", Kind: "ConfigMap"}:                             true,
+		{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"}: false,
+	}, decoded.Namespaced())
+}
+
+func TestEncodeIsStable(t *testing.T) {
+	var first bytes.Buffer
+	for i := 0; i < 5; i++ {
+		x, err := FromSwagger([]byte(swagger))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		var buf bytes.Buffer
+		if !assert.NoError(t, x.Encode(&buf)) {
+			t.FailNow()
+		}
+		if i == 0 {
+			first = buf
+			continue
+		}
+		assert.Equal(t, first.Bytes(), buf.Bytes())
+	}
+}
+
+func TestDecodeRejectsStaleFormat(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if !assert.NoError(t, gob.NewEncoder(gz).Encode(&Index{FormatVersion: FormatVersion - 1})) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, gz.Close()) {
+		t.FailNow()
+	}
+	_, err := Decode(buf.Bytes())
+	assert.EqualError(t, err, "schema index has format version 0, expected 1")
+}
+
+func TestDecodeRejectsGarbage(t *testing.T) {
+	_, err := Decode([]byte("{}"))
+	assert.Error(t, err)
+}
@@ -15,4 +15,12 @@ var OpenAPIMustAsset = map[string]func(string) []byte{
 	"v1204": v1204.MustAsset,
 }
 
+var OpenAPISchemaIndex = map[string][]byte{
+	"v1204": v1204.SchemaIndex,
+}
+
+var OpenAPIKubeVersion = map[string]string{
+	"v1204": "v1.20.4",
+}
+
 const DefaultOpenAPI = "v1204"
@@ -0,0 +1,88 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
+)
+
+// KubeVersion is the major and minor version of a Kubernetes release.
+type KubeVersion struct {
+	Major int
+	Minor int
+}
+
+// ParseKubeVersion parses a Kubernetes version such as "1.29",
+// "v1.29" or "v1.29.3"; the patch version is ignored.
+func ParseKubeVersion(s string) (KubeVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return KubeVersion{}, fmt.Errorf(
+			"invalid Kubernetes version '%s', expected e.g. 1.29", s)
+	}
+	var v [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return KubeVersion{}, fmt.Errorf(
+				"invalid Kubernetes version '%s', expected e.g. 1.29", s)
+		}
+		v[i] = n
+	}
+	return KubeVersion{Major: v[0], Minor: v[1]}, nil
+}
+
+// Less reports whether v is an earlier release than o.
+func (v KubeVersion) Less(o KubeVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	return v.Minor < o.Minor
+}
+
+func (v KubeVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// SchemaVersionFor returns the builtin schema version to use for
+// builds targeting the given Kubernetes version, i.e. the schema of
+// the latest release not newer than it or, if all of them are newer,
+// the schema of the earliest release.
+func SchemaVersionFor(kubeVersion string) (string, error) {
+	target, err := ParseKubeVersion(kubeVersion)
+	if err != nil {
+		return "", err
+	}
+	type builtin struct {
+		name    string
+		version KubeVersion
+	}
+	var builtins []builtin
+	for name, v := range kubernetesapi.OpenAPIKubeVersion {
+		kv, err := ParseKubeVersion(v)
+		if err != nil {
+			return "", fmt.Errorf("builtin schema %s: %v", name, err)
+		}
+		builtins = append(builtins, builtin{name: name, version: kv})
+	}
+	if len(builtins) == 0 {
+		return "", fmt.Errorf("there are no builtin schemas")
+	}
+	sort.Slice(builtins, func(i, j int) bool {
+		return builtins[i].version.Less(builtins[j].version)
+	})
+	result := builtins[0].name
+	for _, b := range builtins {
+		if target.Less(b.version) {
+			break
+		}
+		result = b.name
+	}
+	return result, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKubeVersion(t *testing.T) {
+	for _, s := range []string{"1.20", "v1.20", "v1.20.4", " 1.20.0 "} {
+		v, err := ParseKubeVersion(s)
+		if !assert.NoError(t, err, s) {
+			t.FailNow()
+		}
+		assert.Equal(t, KubeVersion{Major: 1, Minor: 20}, v, s)
+	}
+	for _, s := range []string{"", "1", "1.x", "1.2.3.4", "latest"} {
+		_, err := ParseKubeVersion(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestKubeVersionLess(t *testing.T) {
+	assert.True(t, KubeVersion{1, 9}.Less(KubeVersion{1, 20}))
+	assert.False(t, KubeVersion{1, 20}.Less(KubeVersion{1, 20}))
+	assert.True(t, KubeVersion{1, 29}.Less(KubeVersion{2, 0}))
+}
+
+func TestSchemaVersionFor(t *testing.T) {
+	testCases := map[string]string{
+		"1.20":    "v1204",
+		"1.29":    "v1204",
+		"v1.20.1": "v1204",
+		// Older than any builtin schema.
+		"1.16": "v1204",
+	}
+	for kubeVersion, expected := range testCases {
+		actual, err := SchemaVersionFor(kubeVersion)
+		if !assert.NoError(t, err, kubeVersion) {
+			t.FailNow()
+		}
+		assert.Equal(t, expected, actual, kubeVersion)
+	}
+	_, err := SchemaVersionFor("one.twenty")
+	assert.EqualError(t, err, "invalid Kubernetes version 'one.twenty', expected e.g. 1.29")
+}
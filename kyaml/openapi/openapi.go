@@ -13,6 +13,7 @@ import (
 
 	"github.com/go-openapi/spec"
 	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/openapi/internal/schemaindex"
 	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
 	"sigs.k8s.io/kustomize/kyaml/openapi/kustomizationapi"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -219,17 +220,7 @@ func AddDefinitions(definitions spec.Definitions) {
 }
 
 func toTypeMeta(ext interface{}) (yaml.TypeMeta, bool) {
-	m, ok := ext.(map[string]interface{})
-	if !ok {
-		return yaml.TypeMeta{}, false
-	}
-
-	g := m[groupKey].(string)
-	apiVersion := m[versionKey].(string)
-	if g != "" {
-		apiVersion = g + "/" + apiVersion
-	}
-	return yaml.TypeMeta{Kind: m[kindKey].(string), APIVersion: apiVersion}, true
+	return schemaindex.ToTypeMeta(ext)
 }
 
 // Resolve resolves the reference against the global schema
@@ -422,13 +413,6 @@ const (
 	// kubernetesMergeKeyMapList is the list of merge keys when there needs to be multiple
 	// -- the extension is an array of strings
 	kubernetesMergeKeyMapList = "x-kubernetes-list-map-keys"
-
-	// groupKey is the key to lookup the group from the GVK extension
-	groupKey = "group"
-	// versionKey is the key to lookup the version from the GVK extension
-	versionKey = "version"
-	// kindKey is the the to lookup the kind from the GVK extension
-	kindKey = "kind"
 )
 
 // SetSchema sets the kubernetes OpenAPI schema version to use
@@ -451,6 +435,7 @@ func SetSchema(openAPIField map[string]string, schema []byte, reset bool) error
 	}
 
 	// use builtin version
+	customSchema = nil
 	kubernetesOpenAPIVersion = strings.ReplaceAll(version, ".", "")
 	if kubernetesOpenAPIVersion == "" {
 		return nil
@@ -458,10 +443,15 @@ func SetSchema(openAPIField map[string]string, schema []byte, reset bool) error
 	if _, ok := kubernetesapi.OpenAPIMustAsset[kubernetesOpenAPIVersion]; !ok {
 		return fmt.Errorf("the specified OpenAPI version is not built in")
 	}
-	customSchema = nil
 	return nil
 }
 
+// UsesCustomSchema returns whether the schema in use is a custom
+// one, set with SetSchema, rather than a builtin Kubernetes one.
+func UsesCustomSchema() bool {
+	return customSchema != nil
+}
+
 // GetSchemaVersion returns what kubernetes OpenAPI version is being used
 func GetSchemaVersion() string {
 	switch {
@@ -507,16 +497,13 @@ func parseBuiltinSchema(version string) {
 		return
 	}
 
-	// parse the swagger, this should never fail
-	assetName := filepath.Join(
-		"kubernetesapi",
-		version,
-		"swagger.json")
-
-	if err := parse(kubernetesapi.OpenAPIMustAsset[version](assetName)); err != nil {
+	definitions, namespaceability, err := builtinDefinitions(version)
+	if err != nil {
 		// this should never happen
 		panic(err)
 	}
+	AddDefinitions(definitions)
+	addNamespaceability(namespaceability)
 
 	if err := parse(kustomizationapi.MustAsset(kustomizationAPIAssetName)); err != nil {
 		// this should never happen
@@ -524,6 +511,42 @@ func parseBuiltinSchema(version string) {
 	}
 }
 
+// builtinDefinitions returns the definitions of the builtin schema
+// version, and whether its resource types are namespaced, from
+// builtinSchemas, loading them first if need be.
+func builtinDefinitions(version string) (spec.Definitions, map[yaml.TypeMeta]bool, error) {
+	s, err := builtinSchema(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.schema.Definitions, s.namespaced, nil
+}
+
+// loadBuiltinDefinitions loads the definitions of the builtin schema
+// version, and whether its resource types are namespaced, from its
+// precompiled index, which is much faster than parsing its swagger,
+// unless the index is missing or stale.
+func loadBuiltinDefinitions(version string) (spec.Definitions, map[yaml.TypeMeta]bool, error) {
+	if b, ok := kubernetesapi.OpenAPISchemaIndex[version]; ok {
+		if x, err := schemaindex.Decode(b); err == nil {
+			definitions, err := x.Schemas()
+			return definitions, x.Namespaced(), err
+		}
+	}
+	// parse the swagger, this should never fail
+	assetName := filepath.Join(
+		"kubernetesapi",
+		version,
+		"swagger.json")
+
+	var swagger spec.Swagger
+	if err := swagger.UnmarshalJSON(
+		kubernetesapi.OpenAPIMustAsset[version](assetName)); err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+	return swagger.Definitions, schemaindex.Namespaceability(swagger.Paths), nil
+}
+
 // parse parses and indexes a single json schema
 func parse(b []byte) error {
 	var swagger spec.Swagger
@@ -538,33 +561,19 @@ func parse(b []byte) error {
 }
 
 // findNamespaceability looks at the api paths for the resource to determine
-// if it is cluster-scoped or namespace-scoped. The gvk of the resource
-// for each path is found by looking at the x-kubernetes-group-version-kind
-// extension. If a path exists for the resource that contains a namespace path
-// parameter, the resource is namespace-scoped.
+// if it is cluster-scoped or namespace-scoped.
 func findNamespaceability(paths *spec.Paths) {
+	addNamespaceability(schemaindex.Namespaceability(paths))
+}
+
+// addNamespaceability records whether resource types are namespaced.
+func addNamespaceability(namespaceability map[yaml.TypeMeta]bool) {
 	if globalSchema.namespaceabilityByResourceType == nil {
 		globalSchema.namespaceabilityByResourceType = make(map[yaml.TypeMeta]bool)
 	}
 
-	if paths == nil {
-		return
-	}
-
-	for path, pathInfo := range paths.Paths {
-		if pathInfo.Get == nil {
-			continue
-		}
-		gvk, found := pathInfo.Get.VendorExtensible.Extensions[kubernetesGVKExtensionKey]
-		if !found {
-			continue
-		}
-		typeMeta, found := toTypeMeta(gvk)
-		if !found {
-			continue
-		}
-
-		if strings.Contains(path, "namespaces/{namespace}") {
+	for typeMeta, namespaced := range namespaceability {
+		if namespaced {
 			// if we find a namespace path parameter, we just update the map
 			// directly
 			globalSchema.namespaceabilityByResourceType[typeMeta] = true
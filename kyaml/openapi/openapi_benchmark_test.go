@@ -8,7 +8,9 @@ import (
 	"testing"
 
 	"github.com/go-openapi/spec"
+	"sigs.k8s.io/kustomize/kyaml/openapi/internal/schemaindex"
 	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
+	"sigs.k8s.io/kustomize/kyaml/openapi/kustomizationapi"
 )
 
 // Benchmark for swagger parsing (UnmarshalJSON)
@@ -45,3 +47,47 @@ func BenchmarkAssetRead(t *testing.B) {
 		kubernetesapi.OpenAPIMustAsset[version](assetName)
 	}
 }
+
+// Benchmark for decoding the precompiled schema index
+func BenchmarkSchemaIndexDecode(t *testing.B) {
+	b := kubernetesapi.OpenAPISchemaIndex[kubernetesOpenAPIDefaultVersion]
+	for i := 0; i < t.N; i++ {
+		x, err := schemaindex.Decode(b)
+		if err != nil {
+			t.Fatalf("schemaindex.Decode failed: %v", err)
+		}
+		if _, err = x.Schemas(); err != nil {
+			t.Fatalf("Schemas failed: %v", err)
+		}
+	}
+}
+
+// Benchmark for the cold start of a build, i.e. initializing the
+// global schema from the precompiled index
+func BenchmarkInitSchema(t *testing.B) {
+	defer ResetOpenAPI()
+	for i := 0; i < t.N; i++ {
+		ResetOpenAPI()
+		initSchema()
+	}
+}
+
+// Benchmark for initializing the global schema by parsing the
+// swagger, as done before the index was precompiled
+func BenchmarkInitSchemaFromSwagger(t *testing.B) {
+	defer ResetOpenAPI()
+	assetName := filepath.Join(
+		"kubernetesapi",
+		kubernetesOpenAPIDefaultVersion,
+		"swagger.json")
+	for i := 0; i < t.N; i++ {
+		ResetOpenAPI()
+		b := kubernetesapi.OpenAPIMustAsset[kubernetesOpenAPIDefaultVersion](assetName)
+		if err := parse(b); err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		if err := parse(kustomizationapi.MustAsset(kustomizationAPIAssetName)); err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+	}
+}
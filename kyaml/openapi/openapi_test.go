@@ -6,9 +6,14 @@ package openapi
 import (
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"reflect"
 	"testing"
 
+	"github.com/go-openapi/spec"
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/openapi/internal/schemaindex"
+	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -316,3 +321,31 @@ func TestIsNamespaceScoped_custom(t *testing.T) {
 	assert.True(t, isFound)
 	assert.True(t, isNamespaceable)
 }
+
+// The precompiled index of each builtin schema must match its
+// swagger; if it doesn't, run `make kubernetesapi/schemaindex`.
+func TestBuiltinSchemaIndexIsCurrent(t *testing.T) {
+	for version, asset := range kubernetesapi.OpenAPIMustAsset {
+		b, ok := kubernetesapi.OpenAPISchemaIndex[version]
+		if !assert.True(t, ok, "no index for %s", version) {
+			continue
+		}
+		x, err := schemaindex.Decode(b)
+		if !assert.NoError(t, err, version) {
+			continue
+		}
+		definitions, err := x.Schemas()
+		if !assert.NoError(t, err, version) {
+			continue
+		}
+		var swagger spec.Swagger
+		if !assert.NoError(t, swagger.UnmarshalJSON(
+			asset(filepath.Join("kubernetesapi", version, "swagger.json")))) {
+			t.FailNow()
+		}
+		assert.Equal(t, len(swagger.Definitions), len(definitions), version)
+		assert.True(t, reflect.DeepEqual(swagger.Definitions, definitions),
+			"the definitions of %s differ", version)
+		assert.Equal(t, schemaindex.Namespaceability(swagger.Paths), x.Namespaced(), version)
+	}
+}
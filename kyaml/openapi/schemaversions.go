@@ -0,0 +1,82 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-openapi/spec"
+	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// loadedSchema is a builtin schema, loaded once.
+type loadedSchema struct {
+	schema     *spec.Schema
+	namespaced map[yaml.TypeMeta]bool
+}
+
+// builtinSchemas caches the builtin schemas by version, both those
+// parsed into the global schema and those asked for with
+// SchemaForVersion, so that each is only loaded once, when first
+// needed.  Unlike globalSchema, ResetOpenAPI leaves it alone, as the
+// builtin schemas never change.
+var builtinSchemas = struct {
+	sync.Mutex
+	byVersion map[string]*loadedSchema
+}{byVersion: make(map[string]*loadedSchema)}
+
+// builtinSchema returns the builtin schema version,
+// loading it first if it's not in builtinSchemas.
+func builtinSchema(version string) (*loadedSchema, error) {
+	builtinSchemas.Lock()
+	defer builtinSchemas.Unlock()
+	if s, ok := builtinSchemas.byVersion[version]; ok {
+		return s, nil
+	}
+	if _, ok := kubernetesapi.OpenAPIMustAsset[version]; !ok {
+		return nil, fmt.Errorf("the OpenAPI version %s is not built in", version)
+	}
+	definitions, namespaced, err := loadBuiltinDefinitions(version)
+	if err != nil {
+		return nil, err
+	}
+	s := &loadedSchema{
+		schema:     &spec.Schema{SchemaProps: spec.SchemaProps{Definitions: definitions}},
+		namespaced: namespaced,
+	}
+	builtinSchemas.byVersion[version] = s
+	return s, nil
+}
+
+// SchemaForVersion returns the builtin Kubernetes schema of version v,
+// e.g. "v1204", or of the release best matching the Kubernetes version
+// v, e.g. "1.20" (see SchemaVersionFor).  Unlike SetSchema, this leaves
+// the schema used by the rest of this package alone, though both share
+// the schemas they load.  The returned schema must not be modified.
+func SchemaForVersion(v string) (*spec.Schema, error) {
+	version := v
+	if _, ok := kubernetesapi.OpenAPIMustAsset[version]; !ok {
+		var err error
+		if version, err = SchemaVersionFor(v); err != nil {
+			return nil, err
+		}
+	}
+	s, err := builtinSchema(version)
+	if err != nil {
+		return nil, err
+	}
+	return s.schema, nil
+}
+
+// BuiltinSchemaVersions returns the versions of
+// the builtin schemas, keyed by their names.
+func BuiltinSchemaVersions() map[string]string {
+	result := make(map[string]string, len(kubernetesapi.OpenAPIKubeVersion))
+	for name, v := range kubernetesapi.OpenAPIKubeVersion {
+		result[name] = v
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaForVersion(t *testing.T) {
+	ResetOpenAPI()
+	defer ResetOpenAPI()
+
+	s, err := SchemaForVersion("v1204")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, found := s.Definitions["io.k8s.api.apps.v1.Deployment"]
+	assert.True(t, found)
+
+	// A Kubernetes version selects the matching schema,
+	// which is only parsed once.
+	other, err := SchemaForVersion("1.20")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Same(t, s, other)
+
+	// The schema used by the rest of the package is untouched.
+	assert.False(t, globalSchema.schemaInit)
+
+	_, err = SchemaForVersion("v9999")
+	assert.EqualError(t, err, "invalid Kubernetes version 'v9999', expected e.g. 1.29")
+}
+
+func TestSchemaForVersionSharesTheGlobalSchemaLoad(t *testing.T) {
+	ResetOpenAPI()
+	defer ResetOpenAPI()
+
+	initSchema()
+	loaded, found := builtinSchemas.byVersion[kubernetesOpenAPIDefaultVersion]
+	if !assert.True(t, found) {
+		t.FailNow()
+	}
+	s, err := SchemaForVersion(kubernetesOpenAPIDefaultVersion)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Same(t, loaded.schema, s)
+}
+
+func TestBuiltinSchemaVersions(t *testing.T) {
+	assert.Equal(t, map[string]string{"v1204": "v1.20.4"}, BuiltinSchemaVersions())
+}
@@ -10,3 +10,5 @@ $MYGOBIN/go-bindata \
   --pkg "${VERSION//.}" \
   -o kubernetesapi/"${VERSION//.}"/swagger.go \
   kubernetesapi/"${VERSION//.}"/swagger.json
+
+go run ./scripts/makeschemaindex kubernetesapi/"${VERSION//.}"
@@ -7,7 +7,8 @@
 # kubernetesapi/openapiinfo.go
 #
 # This script should only be run after the
-# swagger.json and swagger.go files are generated.
+# swagger.json, swagger.go and schemaindex.go
+# files are generated.
 
 set -e
 
@@ -19,6 +20,7 @@ fi
 
 info_list=()
 version_list=()
+kube_version_list=()
 
 V=`ls kubernetesapi | grep v.*`
 for VERSION in $V
@@ -29,6 +31,7 @@ do
     tr -d '\n' )
   info_list+=( $openapiinfo )
   version_list+=( ${VERSION} )
+  kube_version_list+=( $(jq -r '.info.version' kubernetesapi/$VERSION/swagger.json) )
 done
 
 
@@ -74,6 +77,36 @@ do
 EOF
 done
 
+# add the precompiled index of each schema, which
+# is loaded in place of parsing the swagger.json
+cat <<EOF >>kubernetesapi/openapiinfo.go
+}
+
+var OpenAPISchemaIndex = map[string][]byte{
+EOF
+
+for version in ${version_list[@]}
+do
+  cat <<EOF >>kubernetesapi/openapiinfo.go
+  "$version": $version.SchemaIndex,
+EOF
+done
+
+# add the kubernetes version of each schema, used
+# to pick the schema matching a build's kubeVersion
+cat <<EOF >>kubernetesapi/openapiinfo.go
+}
+
+var OpenAPIKubeVersion = map[string]string{
+EOF
+
+for i in ${!version_list[@]}
+do
+  cat <<EOF >>kubernetesapi/openapiinfo.go
+  "${version_list[$i]}": "${kube_version_list[$i]}",
+EOF
+done
+
 # add latest version to be used as a default
 cat <<EOF >>kubernetesapi/openapiinfo.go
 }
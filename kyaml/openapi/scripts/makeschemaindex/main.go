@@ -0,0 +1,71 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// makeschemaindex precompiles the swagger.json of a builtin schema
+// version into the schemaindex.bin loaded by the openapi package
+// in its place, and writes the schemaindex.go embedding it.
+//
+// Usage, in kyaml/openapi:
+//
+//	go run ./scripts/makeschemaindex kubernetesapi/v1204
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/kyaml/openapi/internal/schemaindex"
+)
+
+const indexDotGo = `// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by ./scripts/makeschemaindex; DO NOT EDIT.
+
+package %s
+
+import (
+	_ "embed"
+)
+
+// SchemaIndex is the precompiled form of swagger.json.
+//
+//go:embed schemaindex.bin
+var SchemaIndex []byte
+`
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: makeschemaindex {schema directory}")
+		os.Exit(1)
+	}
+	if err := run(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string) error {
+	b, err := ioutil.ReadFile(filepath.Join(dir, "swagger.json"))
+	if err != nil {
+		return err
+	}
+	x, err := schemaindex.FromSwagger(b)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err = x.Encode(&buf); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(
+		filepath.Join(dir, "schemaindex.bin"), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(
+		filepath.Join(dir, "schemaindex.go"),
+		[]byte(fmt.Sprintf(indexDotGo, filepath.Base(dir))), 0644)
+}
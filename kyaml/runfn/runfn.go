@@ -19,6 +19,7 @@ import (
 	"sigs.k8s.io/kustomize/kyaml/fn/runtime/container"
 	"sigs.k8s.io/kustomize/kyaml/fn/runtime/exec"
 	"sigs.k8s.io/kustomize/kyaml/fn/runtime/runtimeutil"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/sidecar"
 	"sigs.k8s.io/kustomize/kyaml/fn/runtime/starlark"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
@@ -67,6 +68,12 @@ type RunFns struct {
 	// EnableExec will enable exec functions
 	EnableExec bool
 
+	// Sidecars holds the processes of persistent exec functions,
+	// for them to be reused across runs; the caller closes it.
+	// If nil, the processes are those of the run, stopped once
+	// it ends.
+	Sidecars *sidecar.Pool
+
 	// DisableContainers will disable functions run as containers
 	DisableContainers bool
 
@@ -104,14 +111,22 @@ type RunFns struct {
 }
 
 // Execute runs the command
-func (r RunFns) Execute() error {
+func (r RunFns) Execute() (err error) {
 	// make the path absolute so it works on mac
-	var err error
 	r.Path, err = filepath.Abs(r.Path)
 	if err != nil {
 		return errors.Wrap(err)
 	}
 
+	if r.Sidecars == nil {
+		r.Sidecars = &sidecar.Pool{}
+		defer func() {
+			if closeErr := r.Sidecars.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
 	// default the containerFilterProvider if it hasn't been override.  Split out for testing.
 	(&r).init()
 	nodes, fltrs, output, err := r.getNodesAndFilters()
@@ -206,6 +221,8 @@ func (r RunFns) runFunctions(
 				identifier = filter.Image
 			case *exec.Filter:
 				identifier = filter.Path
+			case *sidecar.Filter:
+				identifier = filter.Path
 			case *starlark.Filter:
 				identifier = filter.String()
 			default:
@@ -507,6 +524,16 @@ func (r *RunFns) ffp(spec runtimeutil.FunctionSpec, api *yaml.RNode, currentUser
 		return sf, nil
 	}
 
+	if r.EnableExec && spec.Exec.Path != "" && spec.Exec.Persistent {
+		sf := &sidecar.Filter{Path: spec.Exec.Path, Pool: r.Sidecars}
+
+		sf.FunctionConfig = api
+		sf.GlobalScope = r.GlobalScope
+		sf.ResultsFile = resultsFile
+		sf.DeferFailure = spec.DeferFailure
+		return sf, nil
+	}
+
 	if r.EnableExec && spec.Exec.Path != "" {
 		ef := &exec.Filter{Path: spec.Exec.Path}
 
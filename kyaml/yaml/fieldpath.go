@@ -0,0 +1,230 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package yaml
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SplitFieldPath splits a field path into the parts taken by
+// Lookup, LookupCreate and PathMatcher.  It's the grammar shared
+// by the kustomize features addressing fields, e.g. replacements
+// and fieldSpecs.  The parts of a path are delimited by dots, and
+// each may be one of:
+//   - a field name, e.g. spec, in which a dot, a backslash or an
+//     opening bracket may be escaped with a backslash, as in
+//     data.config\.yaml
+//   - a list entry selector, e.g. [name=app], in which dots need
+//     no escaping and the value may be a glob, e.g. [name=*config*]
+//   - a list index, e.g. 0, or - for the last entry
+//   - a wildcard, *, for all the entries of a list
+//
+// The empty path has no parts.
+func SplitFieldPath(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	var parts []string
+	var part strings.Builder
+	inEntry := false
+	escaped := false
+	for i, c := range p {
+		switch {
+		case escaped:
+			part.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case inEntry:
+			part.WriteRune(c)
+			inEntry = c != ']'
+		case c == '[' && part.Len() == 0:
+			part.WriteRune(c)
+			inEntry = true
+		case c == '.':
+			if part.Len() == 0 {
+				return nil, fmt.Errorf(
+					"field path '%s' has an empty part at offset %d", p, i)
+			}
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteRune(c)
+		}
+	}
+	switch {
+	case escaped:
+		return nil, fmt.Errorf("field path '%s' ends with an escape", p)
+	case inEntry:
+		return nil, fmt.Errorf("field path '%s' has an unterminated list entry selector", p)
+	case part.Len() == 0:
+		return nil, fmt.Errorf("field path '%s' ends with a dot", p)
+	}
+	return append(parts, part.String()), nil
+}
+
+// SplitLegacyFieldPath splits a field path in the slash
+// delimited syntax of fieldSpecs and configurations files,
+// e.g. spec/template/metadata/labels, in which a slash may be
+// escaped with a backslash, and lists are traversed implicitly.
+func SplitLegacyFieldPath(p string) []string {
+	ps := strings.Split(p, "/")
+	var res []string
+	res = append(res, ps[0])
+	for i := 1; i < len(ps); i++ {
+		last := len(res) - 1
+		if strings.HasSuffix(res[last], `\`) {
+			res[last] = strings.TrimSuffix(res[last], `\`) + "/" + ps[i]
+		} else {
+			res = append(res, ps[i])
+		}
+	}
+	return res
+}
+
+// IsLegacyFieldPath reports whether p, a fieldSpec path, is in
+// the slash delimited syntax rather than the syntax of
+// SplitFieldPath, i.e. whether its first unescaped delimiter,
+// outside list entry selectors, is a slash rather than a dot.
+// Either may be in the field names of the other, e.g. in
+// metadata/annotations/example.com and in
+// metadata.annotations.app\.kubernetes\.io/name.  Paths of a
+// single field name are the same in both.
+func IsLegacyFieldPath(p string) bool {
+	inEntry := false
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case c == '\\':
+			i++
+		case inEntry:
+			inEntry = c != ']'
+		case c == '[':
+			inEntry = true
+		case c == '.':
+			return false
+		case c == '/':
+			return true
+		}
+	}
+	return false
+}
+
+// IsListEntryPart reports whether the part of a field path
+// selects entries of a list, i.e. is a list entry selector,
+// a list index or a wildcard.  A list index may also be the
+// name of a field of a map, e.g. 8080 in data.8080 (see
+// SelectsListEntries).
+func IsListEntryPart(part string) bool {
+	if part == "*" || part == "-" || IsListIndex(part) {
+		return true
+	}
+	return isListIndexNumber(part)
+}
+
+// SelectsListEntries reports whether the part of a field path
+// selects entries of node, rather than naming one of its fields:
+// IsListEntryPart is true, and the part isn't a list index, i.e. a
+// number, on a map, for which it names a field, e.g. 8080 in
+// data.8080.
+func SelectsListEntries(node *RNode, part string) bool {
+	return IsListEntryPart(part) &&
+		!(isListIndexNumber(part) && !node.IsNil() && node.YNode().Kind == MappingNode)
+}
+
+func isListIndexNumber(part string) bool {
+	_, err := strconv.Atoi(part)
+	return err == nil
+}
+
+// SelectListEntries returns the entries of the list selected
+// by the part of a field path, for which IsListEntryPart
+// must be true.
+func SelectListEntries(list *RNode, part string) ([]*RNode, error) {
+	if list.YNode().Kind != SequenceNode {
+		return nil, fmt.Errorf("'%s' selects list entries, but the field isn't a list", part)
+	}
+	entries := list.YNode().Content
+	switch {
+	case part == "*":
+		var result []*RNode
+		for _, e := range entries {
+			result = append(result, NewRNode(e))
+		}
+		return result, nil
+	case part == "-":
+		if len(entries) == 0 {
+			return nil, nil
+		}
+		return []*RNode{NewRNode(entries[len(entries)-1])}, nil
+	case IsListIndex(part):
+		key, pattern, err := SplitIndexNameValue(part)
+		if err != nil {
+			return nil, err
+		}
+		var result []*RNode
+		for _, e := range entries {
+			matched, err := entryMatches(NewRNode(e), key, pattern)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				result = append(result, NewRNode(e))
+			}
+		}
+		return result, nil
+	}
+	i, err := strconv.Atoi(part)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' doesn't select list entries", part)
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("list index %d cannot be negative", i)
+	}
+	if i >= len(entries) {
+		return nil, nil
+	}
+	return []*RNode{NewRNode(entries[i])}, nil
+}
+
+// IsGlobSelector reports whether the list entry selector,
+// e.g. [name=*config*], matches values by a glob.
+func IsGlobSelector(part string) bool {
+	_, pattern, err := SplitIndexNameValue(part)
+	return err == nil && isGlob(pattern)
+}
+
+// entryMatches reports whether the value of the key field of
+// the list entry, or the entry itself if key is empty, matches
+// the pattern.
+func entryMatches(entry *RNode, key, pattern string) (bool, error) {
+	v := entry
+	if key != "" {
+		if entry.YNode().Kind != MappingNode {
+			return false, nil
+		}
+		f := entry.Field(key)
+		if f == nil {
+			return false, nil
+		}
+		v = f.Value
+	}
+	if v.YNode().Kind != ScalarNode {
+		return false, nil
+	}
+	if !isGlob(pattern) {
+		return v.YNode().Value == pattern, nil
+	}
+	matched, err := path.Match(pattern, v.YNode().Value)
+	if err != nil {
+		return false, fmt.Errorf("invalid list entry pattern '%s': %v", pattern, err)
+	}
+	return matched, nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFieldPath(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		expected []string
+		err      string
+	}{
+		"empty": {
+			path: "",
+		},
+		"fields": {
+			path:     "spec.template.metadata",
+			expected: []string{"spec", "template", "metadata"},
+		},
+		"escaped dot": {
+			path:     `data.config\.yaml`,
+			expected: []string{"data", "config.yaml"},
+		},
+		"escaped bracket and backslash": {
+			path:     `data.\[x\\y`,
+			expected: []string{"data", `[x\y`},
+		},
+		"list entry selector": {
+			path:     "spec.containers.[name=app].image",
+			expected: []string{"spec", "containers", "[name=app]", "image"},
+		},
+		"dots in list entry selector": {
+			path:     "spec.hosts.[name=a.example.com].port",
+			expected: []string{"spec", "hosts", "[name=a.example.com]", "port"},
+		},
+		"glob, index and wildcard": {
+			path:     "spec.volumes.[name=*config*].items.0.paths.*",
+			expected: []string{"spec", "volumes", "[name=*config*]", "items", "0", "paths", "*"},
+		},
+		"empty part": {
+			path: "spec..template",
+			err:  "field path 'spec..template' has an empty part at offset 5",
+		},
+		"trailing dot": {
+			path: "spec.",
+			err:  "field path 'spec.' ends with a dot",
+		},
+		"trailing escape": {
+			path: `spec\`,
+			err:  `field path 'spec\' ends with an escape`,
+		},
+		"unterminated selector": {
+			path: "spec.containers.[name=app",
+			err:  "field path 'spec.containers.[name=app' has an unterminated list entry selector",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			actual, err := SplitFieldPath(tc.path)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSplitLegacyFieldPath(t *testing.T) {
+	assert.Equal(t, []string{"metadata", "annotations", "a.b/c"},
+		SplitLegacyFieldPath(`metadata/annotations/a.b\/c`))
+	assert.True(t, IsLegacyFieldPath("metadata/name"))
+	assert.False(t, IsLegacyFieldPath(`metadata.name`))
+	assert.False(t, IsLegacyFieldPath(`a\/b`))
+	assert.True(t, IsLegacyFieldPath("metadata/annotations/example.com"))
+	assert.False(t, IsLegacyFieldPath(`metadata.annotations.app\.kubernetes\.io/name`))
+	assert.False(t, IsLegacyFieldPath("spec.containers.[name=a/b].image"))
+}
+
+func TestSelectsListEntries(t *testing.T) {
+	list := MustParse("- a\n")
+	m := MustParse("\"8080\": web\n")
+	assert.True(t, SelectsListEntries(list, "0"))
+	assert.True(t, SelectsListEntries(list, "[name=a]"))
+	assert.False(t, SelectsListEntries(m, "8080"))
+	assert.True(t, SelectsListEntries(m, "[name=a]"))
+	assert.False(t, SelectsListEntries(list, "name"))
+}
+
+func TestSelectListEntries(t *testing.T) {
+	list := MustParse(`
+- name: main-config
+  id: 1
+- name: data
+  id: 2
+- name: configfiles
+  id: 3
+- id: 4
+`)
+	testCases := map[string]struct {
+		part     string
+		expected []string
+		err      string
+	}{
+		"key":      {part: "[name=data]", expected: []string{"2"}},
+		"glob":     {part: "[name=*config*]", expected: []string{"1", "3"}},
+		"no match": {part: "[name=other]"},
+		"wildcard": {part: "*", expected: []string{"1", "2", "3", "4"}},
+		"index":    {part: "1", expected: []string{"2"}},
+		"last":     {part: "-", expected: []string{"4"}},
+		"past end": {part: "9"},
+		"bad glob": {part: "[name=[]", err: "invalid list entry pattern '[': syntax error in pattern"},
+		"field":    {part: "name", err: "'name' doesn't select list entries"},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			entries, err := SelectListEntries(list, tc.part)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			var ids []string
+			for _, e := range entries {
+				ids = append(ids, GetValue(e.Field("id").Value))
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+	_, err := SelectListEntries(MustParse("a: b"), "*")
+	assert.EqualError(t, err, "'*' selects list entries, but the field isn't a list")
+}
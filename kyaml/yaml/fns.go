@@ -482,7 +482,7 @@ func (l PathGetter) Filter(rn *RNode) (*RNode, error) {
 			nextPart = l.Path[i+1]
 		}
 		var fltr Filter
-		fltr, err = l.getFilter(part, nextPart, &fieldPath)
+		fltr, err = l.getFilter(match, part, nextPart, &fieldPath)
 		if err != nil {
 			return nil, err
 		}
@@ -495,11 +495,13 @@ func (l PathGetter) Filter(rn *RNode) (*RNode, error) {
 	return match, nil
 }
 
-func (l PathGetter) getFilter(part, nextPart string, fieldPath *[]string) (Filter, error) {
+func (l PathGetter) getFilter(
+	rn *RNode, part, nextPart string, fieldPath *[]string) (Filter, error) {
 	idx, err := strconv.Atoi(part)
 	switch {
-	case err == nil:
-		// part is a number
+	case err == nil && rn.YNode().Kind != yaml.MappingNode:
+		// part is a number, indexing a list; on a map, it's
+		// the name of a field, e.g. 8080 in data.8080
 		if idx < 0 {
 			return nil, fmt.Errorf("array index %d cannot be negative", idx)
 		}
@@ -725,10 +727,10 @@ func ErrorIfInvalid(rn *RNode, kind yaml.Kind) error {
 
 	if rn.YNode().Kind != kind {
 		s, _ := rn.String()
-		return errors.Errorf(
+		return ErrorAt(rn, errors.Errorf(
 			"wrong Node Kind for %s expected: %v was %v: value: {%s}",
 			strings.Join(rn.FieldPath(), "."),
-			nodeTypeIndex[kind], nodeTypeIndex[rn.YNode().Kind], strings.TrimSpace(s))
+			nodeTypeIndex[kind], nodeTypeIndex[rn.YNode().Kind], strings.TrimSpace(s)))
 	}
 
 	if kind == yaml.MappingNode {
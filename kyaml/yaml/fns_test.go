@@ -578,6 +578,33 @@ a: {}
 	assert.Equal(t, "h\n", assertNoErrorString(t)(rn.String()))
 }
 
+func TestLookupNumericKeys(t *testing.T) {
+	node, err := Parse(`
+data:
+  "8080": web
+ports:
+- 80
+`)
+	assert.NoError(t, err)
+	rn, err := node.Pipe(Lookup("data", "8080"))
+	assert.NoError(t, err)
+	assert.Equal(t, "web\n", assertNoErrorString(t)(rn.String()))
+
+	rn, err = node.Pipe(Lookup("ports", "0"))
+	assert.NoError(t, err)
+	assert.Equal(t, "80\n", assertNoErrorString(t)(rn.String()))
+
+	rn, err = node.Pipe(LookupCreate(yaml.ScalarNode, "data", "9090"))
+	assert.NoError(t, err)
+	rn.YNode().Value = "metrics"
+	assert.Equal(t, `data:
+  "8080": web
+  9090: metrics
+ports:
+- 80
+`, assertNoErrorString(t)(node.String()))
+}
+
 func TestLookup(t *testing.T) {
 	s := `n: o
 a:
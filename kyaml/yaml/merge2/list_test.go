@@ -487,4 +487,210 @@ items: []
 			ListIncreaseDirection: yaml.MergeOptionsListAppend,
 		},
 	},
+
+	//
+	// Test Case
+	//
+	{description: `lists replaced`,
+		source: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: foo2
+        image: foo2:v2
+`,
+		dest: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: foo1
+      - name: foo2
+        image: foo2:v1
+`,
+		expected: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: foo2
+        image: foo2:v2
+`,
+		mergeOptions: yaml.MergeOptions{
+			ListMerging: yaml.MergeOptionsListsReplaced,
+		},
+	},
+
+	//
+	// Test Case
+	//
+	{description: `lists appended`,
+		source: `
+kind: Example
+spec:
+  steps:
+  - run: test
+  args:
+  - --verbose
+`,
+		dest: `
+kind: Example
+spec:
+  steps:
+  - run: build
+  args:
+  - --fast
+`,
+		expected: `
+kind: Example
+spec:
+  steps:
+  - run: build
+  - run: test
+  args:
+  - --fast
+  - --verbose
+`,
+		mergeOptions: yaml.MergeOptions{
+			ListMerging: yaml.MergeOptionsListsAppended,
+		},
+	},
+
+	//
+	// Test Case
+	//
+	{description: `lists without schema merged by key`,
+		source: `
+kind: Example
+spec:
+  stages:
+  - name: test
+    timeout: 10m
+  args:
+  - --verbose
+`,
+		dest: `
+kind: Example
+spec:
+  stages:
+  - name: build
+  - name: test
+    parallel: true
+  args:
+  - --fast
+`,
+		expected: `
+kind: Example
+spec:
+  stages:
+  - name: build
+  - name: test
+    parallel: true
+    timeout: 10m
+  args:
+  - --verbose
+`,
+		mergeOptions: yaml.MergeOptions{
+			ListMerging: yaml.MergeOptionsListsMergedByKey,
+		},
+	},
+
+	//
+	// Test Case
+	//
+	{description: `delete elements of a list without merge key`,
+		source: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: foo
+        $patch: delete
+`,
+		dest: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: foo
+        operator: Exists
+      - key: bar
+        operator: Exists
+`,
+		expected: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: bar
+        operator: Exists
+`,
+	},
+
+	//
+	// Test Case
+	//
+	{description: `delete and add elements of a list without schema`,
+		source: `
+kind: Example
+spec:
+  env:
+  - name: DEBUG
+    $patch: delete
+  - name: LEVEL
+    value: info
+`,
+		dest: `
+kind: Example
+spec:
+  env:
+  - name: DEBUG
+    value: "true"
+  - name: REGION
+    value: us-east1
+`,
+		expected: `
+kind: Example
+spec:
+  env:
+  - name: REGION
+    value: us-east1
+  - name: LEVEL
+    value: info
+`,
+	},
+
+	//
+	// Test Case
+	//
+	{description: `delete elements of a missing list`,
+		source: `
+kind: Example
+spec:
+  env:
+  - name: DEBUG
+    $patch: delete
+`,
+		dest: `
+kind: Example
+spec: {}
+`,
+		expected: `
+kind: Example
+spec: {}
+`,
+	},
 }
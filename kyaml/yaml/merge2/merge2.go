@@ -108,13 +108,21 @@ func (m Merger) VisitList(nodes walk.Sources, s *openapi.ResourceSchema, kind wa
 	if err := m.SetStyle(nodes); err != nil {
 		return nil, err
 	}
-	if kind == walk.NonAssociateList {
-		// Override value
-		if nodes.Origin() != nil {
-			return nodes.Origin(), nil
+	if kind == walk.NonAssociateList || kind == walk.AppendedList {
+		if nodes.Origin() == nil {
+			// Keep
+			return nodes.Dest(), nil
 		}
-		// Keep
-		return nodes.Dest(), nil
+		if yaml.IsMissingOrNull(nodes.Dest()) || nodes.Origin().IsTaggedNull() {
+			// Add, without the elements to delete
+			return elideDeletedElements(nodes.Origin())
+		}
+		if kind == walk.AppendedList || hasDeletedElements(nodes.Origin()) {
+			// Delete and append elements
+			return editList(nodes.Dest(), nodes.Origin())
+		}
+		// Override value
+		return nodes.Origin(), nil
 	}
 
 	// Add
@@ -99,3 +99,104 @@ func elideSequencePatchDirective(patch *yaml.RNode, value string) error {
 		Values:  []string{value},
 	})
 }
+
+// isDeletedElement reports whether the element of a list
+// that isn't merged by key, e.g.
+//   - key: example
+//     $patch: delete
+// is a directive to delete the elements of the destination
+// whose fields hold its values.
+func isDeletedElement(elem *yaml.Node) bool {
+	if elem.Kind != yaml.MappingNode || len(elem.Content) < 4 {
+		return false
+	}
+	v, err := yaml.NewRNode(elem).Pipe(yaml.Get(strategicMergePatchDirectiveKey))
+	return err == nil && v != nil && v.YNode().Value == smpDelete.String()
+}
+
+// hasDeletedElements reports whether any element
+// of the list is a directive to delete elements.
+func hasDeletedElements(list *yaml.RNode) bool {
+	for _, elem := range list.Content() {
+		if isDeletedElement(elem) {
+			return true
+		}
+	}
+	return false
+}
+
+// elideDeletedElements removes the directives to delete
+// elements from the list, returning nil if nothing's left.
+func elideDeletedElements(list *yaml.RNode) (*yaml.RNode, error) {
+	if !hasDeletedElements(list) {
+		return list, nil
+	}
+	var content []*yaml.Node
+	for _, elem := range list.Content() {
+		if !isDeletedElement(elem) {
+			content = append(content, elem)
+		}
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+	list.YNode().Content = content
+	return list, nil
+}
+
+// editList deletes the elements of dest which the directives
+// of src match, and appends the other elements of src.
+func editList(dest, src *yaml.RNode) (*yaml.RNode, error) {
+	var deleted []*yaml.Node
+	var added []*yaml.Node
+	for _, elem := range src.Content() {
+		if isDeletedElement(elem) {
+			deleted = append(deleted, elem)
+		} else {
+			added = append(added, elem)
+		}
+	}
+	var content []*yaml.Node
+	for _, elem := range dest.Content() {
+		matched := false
+		for _, d := range deleted {
+			if matched = deletedElementMatches(d, elem); matched {
+				break
+			}
+		}
+		if !matched {
+			content = append(content, elem)
+		}
+	}
+	dest.YNode().Content = append(content, added...)
+	return dest, nil
+}
+
+// deletedElementMatches reports whether each field of the
+// directive to delete, other than the directive, has the
+// same value in the element.
+func deletedElementMatches(directive, elem *yaml.Node) bool {
+	if elem.Kind != yaml.MappingNode {
+		return false
+	}
+	e := yaml.NewRNode(elem)
+	for i := 0; i < len(directive.Content); i += 2 {
+		key := directive.Content[i].Value
+		if key == strategicMergePatchDirectiveKey {
+			continue
+		}
+		f := e.Field(key)
+		if f == nil {
+			return false
+		}
+		want, err := yaml.NewRNode(directive.Content[i+1]).String()
+		if err != nil {
+			return false
+		}
+		got, err := f.Value.String()
+		if err != nil || got != want {
+			return false
+		}
+	}
+	return true
+}
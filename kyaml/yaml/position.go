@@ -0,0 +1,68 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package yaml
+
+import (
+	"errors"
+	"fmt"
+
+	goerrors "github.com/go-errors/errors"
+)
+
+// PositionError is an error about a node, giving the position
+// of the node in the YAML it was parsed from.
+//
+// The position is only reported once File, the name of the
+// file the YAML came from, is known; until then the error
+// reads as the error it wraps.
+type PositionError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PositionError) Error() string {
+	if e.File == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorAt returns err as a PositionError at the position of n,
+// unless n has no position, i.e. wasn't parsed from YAML, or
+// err already has one.  If err is nil, returns nil.
+func ErrorAt(n *RNode, err error) error {
+	if err == nil || n.IsNil() || n.YNode().Line == 0 {
+		return err
+	}
+	if _, found := AsPositionError(err); found {
+		return err
+	}
+	return &PositionError{
+		Line:   n.YNode().Line,
+		Column: n.YNode().Column,
+		Err:    err,
+	}
+}
+
+// AsPositionError returns the PositionError err wraps, if any.
+func AsPositionError(err error) (*PositionError, bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case *PositionError:
+			return e, true
+		case *goerrors.Error:
+			// go-errors doesn't support errors.Unwrap.
+			err = e.Err
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package yaml
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+func TestErrorAt(t *testing.T) {
+	rn := MustParse(`
+a:
+  b: c
+`)
+	b, err := rn.Pipe(Lookup("a", "b"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	err = ErrorAt(b, fmt.Errorf("bad value"))
+	assert.EqualError(t, err, "bad value")
+	pe, found := AsPositionError(errors.WrapPrefixf(err, "setting a.b"))
+	if assert.True(t, found) {
+		assert.Equal(t, 3, pe.Line)
+		assert.Equal(t, 6, pe.Column)
+		pe.File = "f.yaml"
+		assert.EqualError(t, pe, "f.yaml:3: bad value")
+	}
+
+	// An error keeps the first position it's given.
+	assert.Equal(t, err, ErrorAt(rn, err))
+
+	// Nodes not parsed from YAML have no position.
+	_, found = AsPositionError(ErrorAt(NewScalarRNode("x"), fmt.Errorf("bad value")))
+	assert.False(t, found)
+
+	assert.NoError(t, ErrorAt(b, nil))
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-openapi/spec"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// FieldWalker walks a node along its schema, visiting the fields
+// of the properties of its objects, those of their additional
+// properties, and the elements of its lists.
+type FieldWalker struct {
+	// Root holds the definitions the schemas refer to, or is nil
+	// if they refer to none.
+	Root *spec.Schema
+
+	// Enter is called for each property of the schema of an
+	// object, in order of name, with the schema of the property,
+	// its references resolved, and its value, or nil if the
+	// object omits it.  It returns the value to walk into, if any.
+	Enter func(object *yaml.RNode, name string, value *yaml.RNode,
+		s *spec.Schema) (*yaml.RNode, error)
+
+	// Leave, if set, is called for each value Enter returns,
+	// once it's been walked into.
+	Leave func(object *yaml.RNode, name string, value *yaml.RNode,
+		s *spec.Schema) error
+}
+
+// Walk walks node along s.
+func (w FieldWalker) Walk(node *yaml.RNode, s *spec.Schema) error {
+	s, err := Resolve(s, w.Root)
+	if err != nil || s == nil {
+		return err
+	}
+	switch node.YNode().Kind {
+	case yaml.MappingNode:
+		var names []string
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err = w.walkField(node, name, s.Properties[name]); err != nil {
+				return err
+			}
+		}
+		if s.AdditionalProperties == nil || s.AdditionalProperties.Schema == nil {
+			return nil
+		}
+		return node.VisitFields(func(field *yaml.MapNode) error {
+			if _, ok := s.Properties[field.Key.YNode().Value]; ok {
+				return nil
+			}
+			return w.Walk(field.Value, s.AdditionalProperties.Schema)
+		})
+	case yaml.SequenceNode:
+		if s.Items == nil || s.Items.Schema == nil {
+			return nil
+		}
+		return node.VisitElements(func(element *yaml.RNode) error {
+			return w.Walk(element, s.Items.Schema)
+		})
+	}
+	return nil
+}
+
+func (w FieldWalker) walkField(object *yaml.RNode, name string, s spec.Schema) error {
+	p, err := Resolve(&s, w.Root)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if p == nil {
+		return nil
+	}
+	var value *yaml.RNode
+	if field := object.Field(name); field != nil {
+		value = field.Value
+	}
+	if value, err = w.Enter(object, name, value, p); err != nil || value == nil {
+		return err
+	}
+	if err = w.Walk(value, p); err != nil {
+		return fmt.Errorf("%s.%v", name, err)
+	}
+	if w.Leave == nil {
+		return nil
+	}
+	return w.Leave(object, name, value, p)
+}
+
+// Resolve returns s, following its references in root.
+func Resolve(s *spec.Schema, root *spec.Schema) (*spec.Schema, error) {
+	for s != nil && s.Ref.String() != "" {
+		if root == nil {
+			return nil, fmt.Errorf("unresolvable reference %s", s.Ref.String())
+		}
+		var err error
+		if s, err = openapi.Resolve(&s.Ref, root); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// SetDefaults sets the defaults of s, whose references root
+// resolves, to the fields node omits, those of the objects it
+// has.  As with the API server, the defaults of the fields of
+// a default are set too.
+func SetDefaults(node *yaml.RNode, s *spec.Schema, root *spec.Schema) error {
+	return FieldWalker{
+		Root: root,
+		Enter: func(object *yaml.RNode, name string, value *yaml.RNode,
+			s *spec.Schema) (*yaml.RNode, error) {
+			if value != nil || s.Default == nil {
+				return value, nil
+			}
+			value, err := defaultValue(name, s)
+			if err != nil {
+				return nil, err
+			}
+			// An empty object marshals as {}, which shouldn't keep
+			// the flow style once the defaults of its fields are set.
+			value.YNode().Style = 0
+			return value, object.PipeE(yaml.SetField(name, value))
+		},
+	}.Walk(node, s)
+}
+
+// StripDefaults removes the fields of node equal to the defaults
+// of s, whose references root resolves.  It's the converse of
+// SetDefaults: objects left equal to their defaults once their
+// fields are stripped are removed too.
+func StripDefaults(node *yaml.RNode, s *spec.Schema, root *spec.Schema) error {
+	return FieldWalker{
+		Root: root,
+		Enter: func(_ *yaml.RNode, _ string, value *yaml.RNode,
+			_ *spec.Schema) (*yaml.RNode, error) {
+			return value, nil
+		},
+		Leave: func(object *yaml.RNode, name string, value *yaml.RNode,
+			s *spec.Schema) error {
+			if s.Default == nil {
+				return nil
+			}
+			d, err := defaultValue(name, s)
+			if err != nil {
+				return err
+			}
+			equal, err := equalValues(value, d)
+			if err != nil || !equal {
+				return err
+			}
+			_, err = object.Pipe(yaml.Clear(name))
+			return err
+		},
+	}.Walk(node, s)
+}
+
+// defaultValue returns the default of s, the schema of the
+// field name, as a node.
+func defaultValue(name string, s *spec.Schema) (*yaml.RNode, error) {
+	b, err := yaml.Marshal(s.Default)
+	if err != nil {
+		return nil, fmt.Errorf("%s: the default %v: %v", name, s.Default, err)
+	}
+	value, err := yaml.Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: the default %v: %v", name, s.Default, err)
+	}
+	return value, nil
+}
+
+// equalValues returns whether a and b hold the same data,
+// whatever their styles.
+func equalValues(a, b *yaml.RNode) (bool, error) {
+	var x, y interface{}
+	if err := a.YNode().Decode(&x); err != nil {
+		return false, err
+	}
+	if err := b.YNode().Decode(&y); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(x, y), nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	. "sigs.k8s.io/kustomize/kyaml/yaml/schema"
+)
+
+// defaultsSchema refers to a definition, for Resolve to follow.
+const defaultsSchema = `{
+  "definitions": {
+    "port": {
+      "type": "object",
+      "properties": {
+        "protocol": {"type": "string", "default": "TCP"}
+      }
+    }
+  },
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer", "default": 1},
+    "strategy": {
+      "type": "object",
+      "default": {},
+      "properties": {
+        "type": {"type": "string", "default": "RollingUpdate"}
+      }
+    },
+    "ports": {
+      "type": "array",
+      "items": {"$ref": "#/definitions/port"}
+    },
+    "selector": {
+      "type": "object",
+      "additionalProperties": {"type": "string", "default": "ignored"}
+    }
+  }
+}`
+
+func parseSchema(t *testing.T) *spec.Schema {
+	t.Helper()
+	var s spec.Schema
+	if !assert.NoError(t, s.UnmarshalJSON([]byte(defaultsSchema))) {
+		t.FailNow()
+	}
+	return &s
+}
+
+func TestSetDefaults(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"omitted": {
+			input: `
+selector:
+  app: web
+`,
+			expected: `
+selector:
+  app: web
+replicas: 1
+strategy:
+  type: RollingUpdate
+`,
+		},
+		"itemsAndSetFields": {
+			input: `
+replicas: 3
+strategy:
+  type: Recreate
+ports:
+- port: 80
+- port: 53
+  protocol: UDP
+selector:
+  app: web
+`,
+			expected: `
+replicas: 3
+strategy:
+  type: Recreate
+ports:
+- port: 80
+  protocol: TCP
+- port: 53
+  protocol: UDP
+selector:
+  app: web
+`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s := parseSchema(t)
+			node := yaml.MustParse(tc.input)
+			if !assert.NoError(t, SetDefaults(node, s, s)) {
+				t.FailNow()
+			}
+			assert.Equal(t,
+				strings.TrimSpace(tc.expected), strings.TrimSpace(node.MustString()))
+		})
+	}
+}
+
+func TestStripDefaults(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"allDefaults": {
+			input: `
+replicas: 1
+strategy:
+  type: RollingUpdate
+ports:
+- port: 80
+  protocol: TCP
+`,
+			expected: `
+ports:
+- port: 80
+`,
+		},
+		"otherValues": {
+			input: `
+replicas: "1"
+strategy:
+  type: Recreate
+ports:
+- port: 53
+  protocol: UDP
+selector:
+  app: ignored
+`,
+			expected: `
+replicas: "1"
+strategy:
+  type: Recreate
+ports:
+- port: 53
+  protocol: UDP
+selector:
+  app: ignored
+`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s := parseSchema(t)
+			node := yaml.MustParse(tc.input)
+			if !assert.NoError(t, StripDefaults(node, s, s)) {
+				t.FailNow()
+			}
+			assert.Equal(t,
+				strings.TrimSpace(tc.expected), strings.TrimSpace(node.MustString()))
+		})
+	}
+}
+
+func TestResolveUnresolvable(t *testing.T) {
+	s := parseSchema(t)
+	_, err := Resolve(s.Properties["ports"].Items.Schema, nil)
+	assert.EqualError(t, err, "unresolvable reference #/definitions/port")
+}
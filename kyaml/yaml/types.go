@@ -229,9 +229,33 @@ const (
 	MergeOptionsListPrepend
 )
 
+// MergeOptionsListMerging is how merge merges the lists of the
+// destination and source
+type MergeOptionsListMerging int
+
+const (
+	// MergeOptionsListsBySchema merges the lists with a merge
+	// strategy, per their schema, or else, if associative lists
+	// are inferred, their elements, and replaces the others.
+	MergeOptionsListsBySchema MergeOptionsListMerging = iota
+	// MergeOptionsListsReplaced replaces the lists of the
+	// destination with those of the source.
+	MergeOptionsListsReplaced
+	// MergeOptionsListsAppended appends the elements of the
+	// lists of the source to those of the destination.
+	MergeOptionsListsAppended
+	// MergeOptionsListsMergedByKey merges the lists of maps by
+	// their merge key, per their schema or, lacking one, the
+	// name field of their elements, and replaces the others.
+	MergeOptionsListsMergedByKey
+)
+
 // MergeOptions is a struct which contains the options for merge
 type MergeOptions struct {
 	// ListIncreaseDirection indicates should merge function prepend the items from
 	// source list to destination or append.
 	ListIncreaseDirection MergeOptionsListIncreaseDirection
+
+	// ListMerging indicates how the lists are merged.
+	ListMerging MergeOptionsListMerging
 }
@@ -11,3 +11,12 @@ import (
 func (l Walker) walkNonAssociativeSequence() (*yaml.RNode, error) {
 	return l.VisitList(l.Sources, l.Schema, NonAssociateList)
 }
+
+// walkAppendedSequence returns the value of VisitList, for
+// a list whose source elements are appended to the dest's.
+func (l Walker) walkAppendedSequence() (*yaml.RNode, error) {
+	if len(l.Sources) != 2 {
+		return l.walkNonAssociativeSequence()
+	}
+	return l.VisitList(l.Sources, l.Schema, AppendedList)
+}
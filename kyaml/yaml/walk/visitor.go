@@ -13,6 +13,9 @@ type ListKind int32
 const (
 	AssociativeList ListKind = 1 + iota
 	NonAssociateList
+	// AppendedList is a list whose source elements are to be
+	// appended to the destination's, per the MergeOptions.
+	AppendedList
 )
 
 // Visitor is invoked by walk with source and destination node pairs
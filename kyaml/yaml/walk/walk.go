@@ -67,6 +67,18 @@ func (l Walker) Walk() (*yaml.RNode, error) {
 		if err := yaml.ErrorIfAnyInvalidAndNonNull(yaml.SequenceNode, l.Sources...); err != nil {
 			return nil, err
 		}
+		switch l.MergeOptions.ListMerging {
+		case yaml.MergeOptionsListsReplaced:
+			return l.walkNonAssociativeSequence()
+		case yaml.MergeOptionsListsAppended:
+			return l.walkAppendedSequence()
+		case yaml.MergeOptionsListsMergedByKey:
+			if schema.IsAssociative(l.Schema, l.Sources, l.InferAssociativeLists) ||
+				schema.IsAssociative(nil, l.Sources, true) {
+				return l.walkAssociativeSequence()
+			}
+			return l.walkNonAssociativeSequence()
+		}
 		// AssociativeSequence means the items in the sequence are associative. They can be merged
 		// according to merge key.
 		if schema.IsAssociative(l.Schema, l.Sources, l.InferAssociativeLists) {
@@ -0,0 +1,291 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Rewrite deprecated apiVersions to their replacements, adjusting
+// the resources where the schema change is mechanical, and reporting
+// those that can't be migrated without a human's judgement.
+type plugin struct {
+	// Migrations extend, and override, the built-in migrations.
+	Migrations []Migration `json:"migrations,omitempty" yaml:"migrations,omitempty"`
+
+	// Strict, if true, fails the build when a resource
+	// can't be migrated mechanically, rather than
+	// logging a warning and leaving it as it is.
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty"`
+
+	// KubeVersion is the version of Kubernetes the build targets;
+	// apiVersions it doesn't serve yet aren't migrated to.
+	// Defaults to the kubeVersion of the build, if any.
+	KubeVersion string `json:"kubeVersion,omitempty" yaml:"kubeVersion,omitempty"`
+
+	kubeVersion *openapi.KubeVersion
+}
+
+// Migration replaces the apiVersion of a kind.
+type Migration struct {
+	Kind string `json:"kind" yaml:"kind"`
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to,omitempty" yaml:"to,omitempty"`
+
+	// Manual, if specified, explains why resources can't be migrated
+	// mechanically; they're reported rather than rewritten.
+	Manual string `json:"manual,omitempty" yaml:"manual,omitempty"`
+
+	// Since is the first version of Kubernetes serving To.
+	Since string `json:"since,omitempty" yaml:"since,omitempty"`
+
+	// adjust makes the schema changes the migration requires.
+	adjust func(*kyaml.RNode) error
+}
+
+var builtinMigrations = []Migration{
+	{Kind: "PodDisruptionBudget", From: "policy/v1beta1", To: "policy/v1", Since: "1.21"},
+	{Kind: "CronJob", From: "batch/v1beta1", To: "batch/v1", Since: "1.21"},
+	{Kind: "Deployment", From: "extensions/v1beta1", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "Deployment", From: "apps/v1beta1", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "Deployment", From: "apps/v1beta2", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "DaemonSet", From: "extensions/v1beta1", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "DaemonSet", From: "apps/v1beta2", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "ReplicaSet", From: "extensions/v1beta1", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "ReplicaSet", From: "apps/v1beta2", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "StatefulSet", From: "apps/v1beta1", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "StatefulSet", From: "apps/v1beta2", To: "apps/v1", Since: "1.9", adjust: addSelector},
+	{Kind: "Ingress", From: "extensions/v1beta1", To: "networking.k8s.io/v1", Since: "1.19", adjust: adjustIngress},
+	{Kind: "Ingress", From: "networking.k8s.io/v1beta1", To: "networking.k8s.io/v1", Since: "1.19", adjust: adjustIngress},
+	{Kind: "IngressClass", From: "networking.k8s.io/v1beta1", To: "networking.k8s.io/v1", Since: "1.19"},
+	{Kind: "NetworkPolicy", From: "extensions/v1beta1", To: "networking.k8s.io/v1", Since: "1.7"},
+	{Kind: "ClusterRole", From: "rbac.authorization.k8s.io/v1beta1", To: "rbac.authorization.k8s.io/v1", Since: "1.8"},
+	{Kind: "ClusterRoleBinding", From: "rbac.authorization.k8s.io/v1beta1", To: "rbac.authorization.k8s.io/v1", Since: "1.8"},
+	{Kind: "Role", From: "rbac.authorization.k8s.io/v1beta1", To: "rbac.authorization.k8s.io/v1", Since: "1.8"},
+	{Kind: "RoleBinding", From: "rbac.authorization.k8s.io/v1beta1", To: "rbac.authorization.k8s.io/v1", Since: "1.8"},
+	{Kind: "PriorityClass", From: "scheduling.k8s.io/v1beta1", To: "scheduling.k8s.io/v1", Since: "1.14"},
+	{Kind: "StorageClass", From: "storage.k8s.io/v1beta1", To: "storage.k8s.io/v1", Since: "1.6"},
+	{Kind: "CSIDriver", From: "storage.k8s.io/v1beta1", To: "storage.k8s.io/v1", Since: "1.18"},
+	{Kind: "CSINode", From: "storage.k8s.io/v1beta1", To: "storage.k8s.io/v1", Since: "1.17"},
+	{Kind: "VolumeAttachment", From: "storage.k8s.io/v1beta1", To: "storage.k8s.io/v1", Since: "1.13"},
+	{Kind: "Lease", From: "coordination.k8s.io/v1beta1", To: "coordination.k8s.io/v1", Since: "1.14"},
+	{Kind: "HorizontalPodAutoscaler", From: "autoscaling/v2beta2", To: "autoscaling/v2", Since: "1.23"},
+	{Kind: "HorizontalPodAutoscaler", From: "autoscaling/v2beta1", To: "autoscaling/v2", Since: "1.23",
+		Manual: "the metrics of autoscaling/v2 have a different structure"},
+	{Kind: "CustomResourceDefinition", From: "apiextensions.k8s.io/v1beta1", To: "apiextensions.k8s.io/v1", Since: "1.16",
+		Manual: "apiextensions.k8s.io/v1 requires a structural schema for each version"},
+	{Kind: "MutatingWebhookConfiguration", From: "admissionregistration.k8s.io/v1beta1",
+		To: "admissionregistration.k8s.io/v1", Since: "1.16",
+		Manual: "admissionregistration.k8s.io/v1 requires sideEffects and admissionReviewVersions"},
+	{Kind: "ValidatingWebhookConfiguration", From: "admissionregistration.k8s.io/v1beta1",
+		To: "admissionregistration.k8s.io/v1", Since: "1.16",
+		Manual: "admissionregistration.k8s.io/v1 requires sideEffects and admissionReviewVersions"},
+	{Kind: "PodSecurityPolicy", From: "policy/v1beta1",
+		Manual: "PodSecurityPolicy was removed without replacement; use Pod Security Admission"},
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Migrations = nil
+	p.Strict = false
+	p.KubeVersion = ""
+	p.kubeVersion = nil
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	if p.KubeVersion == "" && h.GeneralConfig() != nil {
+		p.KubeVersion = h.GeneralConfig().KubeVersion
+	}
+	if p.KubeVersion != "" {
+		v, err := openapi.ParseKubeVersion(p.KubeVersion)
+		if err != nil {
+			return err
+		}
+		p.kubeVersion = &v
+	}
+	for _, m := range p.Migrations {
+		if m.Kind == "" || m.From == "" {
+			return fmt.Errorf("each migration must specify a kind and the apiVersion it's from")
+		}
+		if m.To == "" && m.Manual == "" {
+			return fmt.Errorf(
+				"the migration of %s %s must specify an apiVersion to migrate to, "+
+					"or why it must be done manually", m.From, m.Kind)
+		}
+		if m.Since != "" {
+			if _, err = openapi.ParseKubeVersion(m.Since); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	migrations := make(map[string]Migration)
+	for _, mg := range builtinMigrations {
+		migrations[mg.From+" "+mg.Kind] = mg
+	}
+	for _, mg := range p.Migrations {
+		migrations[mg.From+" "+mg.Kind] = mg
+	}
+	var manual []string
+	for _, r := range m.Resources() {
+		mg, ok := migrations[r.GetGvk().ApiVersion()+" "+r.GetKind()]
+		if !ok {
+			continue
+		}
+		if !p.served(mg) {
+			continue
+		}
+		if mg.Manual != "" {
+			manual = append(manual, fmt.Sprintf("%s: %s", r.CurId(), mg.Manual))
+			continue
+		}
+		if err := migrate(r, mg); err != nil {
+			return fmt.Errorf("migrating %s to %s: %v", r.CurId(), mg.To, err)
+		}
+	}
+	if len(manual) == 0 {
+		return nil
+	}
+	sort.Strings(manual)
+	report := "resources using deprecated apiVersions must be migrated manually:\n  " +
+		strings.Join(manual, "\n  ")
+	if p.Strict {
+		return fmt.Errorf("%s", report)
+	}
+	log.Printf("Warning: %s", report)
+	return nil
+}
+
+// served reports whether the Kubernetes version the build
+// targets, if known, serves the apiVersion migrated to.
+func (p *plugin) served(mg Migration) bool {
+	if p.kubeVersion == nil || mg.Since == "" {
+		return true
+	}
+	since, err := openapi.ParseKubeVersion(mg.Since)
+	return err != nil || !p.kubeVersion.Less(since)
+}
+
+func migrate(r *resource.Resource, mg Migration) error {
+	if mg.adjust != nil {
+		if err := mg.adjust(r.Node()); err != nil {
+			return err
+		}
+	}
+	group, version := resid.ParseGroupVersion(mg.To)
+	r.SetGvk(resid.Gvk{Group: group, Version: version, Kind: r.GetKind()})
+	return nil
+}
+
+// addSelector adds the selector that apps/v1 requires, and
+// that earlier versions defaulted to the pod template labels.
+func addSelector(n *kyaml.RNode) error {
+	selector, err := n.Pipe(kyaml.Lookup("spec", "selector"))
+	if err != nil || selector != nil {
+		return err
+	}
+	labels, err := n.Pipe(
+		kyaml.Lookup("spec", "template", kyaml.MetadataField, kyaml.LabelsField))
+	if err != nil {
+		return err
+	}
+	if labels == nil {
+		return fmt.Errorf("a selector is required and there are no pod template labels to derive it from")
+	}
+	return n.PipeE(
+		kyaml.LookupCreate(kyaml.MappingNode, "spec", "selector"),
+		kyaml.SetField("matchLabels", labels.Copy()))
+}
+
+// adjustIngress converts v1beta1 backends to v1 backends,
+// and adds the pathType v1 requires.
+func adjustIngress(n *kyaml.RNode) error {
+	spec, err := n.Pipe(kyaml.Lookup("spec"))
+	if err != nil || spec == nil {
+		return err
+	}
+	if backend := spec.Field("backend"); backend != nil {
+		if err = adjustBackend(backend.Value); err != nil {
+			return err
+		}
+		if err = spec.PipeE(kyaml.SetField("defaultBackend", backend.Value)); err != nil {
+			return err
+		}
+		if err = spec.PipeE(kyaml.Clear("backend")); err != nil {
+			return err
+		}
+	}
+	rules, err := spec.Pipe(kyaml.Lookup("rules"))
+	if err != nil || rules == nil {
+		return err
+	}
+	return rules.VisitElements(func(rule *kyaml.RNode) error {
+		paths, err := rule.Pipe(kyaml.Lookup("http", "paths"))
+		if err != nil || paths == nil {
+			return err
+		}
+		return paths.VisitElements(func(path *kyaml.RNode) error {
+			if path.Field("pathType") == nil {
+				if err := path.PipeE(kyaml.SetField(
+					"pathType", kyaml.NewScalarRNode("ImplementationSpecific"))); err != nil {
+					return err
+				}
+			}
+			backend, err := path.Pipe(kyaml.Lookup("backend"))
+			if err != nil || backend == nil {
+				return err
+			}
+			return adjustBackend(backend)
+		})
+	})
+}
+
+// adjustBackend converts serviceName and servicePort
+// to the service a v1 Ingress backend refers to.
+func adjustBackend(backend *kyaml.RNode) error {
+	name := backend.Field("serviceName")
+	port := backend.Field("servicePort")
+	if name == nil {
+		return nil
+	}
+	service := kyaml.NewMapRNode(nil)
+	if err := service.PipeE(kyaml.SetField("name", name.Value)); err != nil {
+		return err
+	}
+	if port != nil {
+		portField := "number"
+		if port.Value.YNode().ShortTag() == kyaml.NodeTagString {
+			portField = "name"
+		}
+		if err := service.PipeE(
+			kyaml.LookupCreate(kyaml.MappingNode, "port"),
+			kyaml.SetField(portField, port.Value)); err != nil {
+			return err
+		}
+	}
+	if err := backend.PipeE(kyaml.Clear("serviceName")); err != nil {
+		return err
+	}
+	if err := backend.PipeE(kyaml.Clear("servicePort")); err != nil {
+		return err
+	}
+	return backend.PipeE(kyaml.SetField("service", service))
+}
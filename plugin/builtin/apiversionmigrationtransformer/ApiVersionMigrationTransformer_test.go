@@ -0,0 +1,213 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestApiVersionMigrationTransformer(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ApiVersionMigrationTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: ApiVersionMigrationTransformer
+metadata:
+  name: notImportantHere
+migrations:
+- kind: Widget
+  from: example.com/v1alpha1
+  to: example.com/v1
+`, `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+spec:
+  maxUnavailable: 1
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  backend:
+    serviceName: default
+    servicePort: 80
+  rules:
+  - http:
+      paths:
+      - path: /
+        backend:
+          serviceName: web
+          servicePort: http
+---
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: w
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: current
+spec:
+  selector:
+    matchLabels:
+      app: current
+`, `
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+spec:
+  maxUnavailable: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  defaultBackend:
+    service:
+      name: default
+      port:
+        number: 80
+  rules:
+  - http:
+      paths:
+      - backend:
+          service:
+            name: web
+            port:
+              name: http
+        path: /
+        pathType: ImplementationSpecific
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: current
+spec:
+  selector:
+    matchLabels:
+      app: current
+`)
+}
+
+func TestApiVersionMigrationTransformerStrict(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ApiVersionMigrationTransformer")
+	defer th.Reset()
+
+	err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ApiVersionMigrationTransformer
+metadata:
+  name: notImportantHere
+strict: true
+`, `
+apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: restricted
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, expected := range []string{
+		"resources using deprecated apiVersions must be migrated manually:",
+		"apiextensions.k8s.io_v1beta1_CustomResourceDefinition|~X|widgets.example.com: " +
+			"apiextensions.k8s.io/v1 requires a structural schema for each version",
+		"policy_v1beta1_PodSecurityPolicy|~X|restricted: " +
+			"PodSecurityPolicy was removed without replacement",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q in error: %v", expected, err)
+		}
+	}
+}
+
+func TestApiVersionMigrationTransformerKubeVersion(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ApiVersionMigrationTransformer")
+	defer th.Reset()
+
+	// Kubernetes 1.20 doesn't serve policy/v1 or batch/v1 CronJobs yet.
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: ApiVersionMigrationTransformer
+metadata:
+  name: notImportantHere
+kubeVersion: "1.20"
+`, `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+---
+apiVersion: networking.k8s.io/v1beta1
+kind: IngressClass
+metadata:
+  name: public
+`, `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+---
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: public
+`)
+}
@@ -0,0 +1,271 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Annotate resources with the resources of the build they
+// depend on, in the config.kubernetes.io/depends-on format,
+// so that appliers understanding it sequence them: custom
+// resources after their CustomResourceDefinitions, and webhook
+// configurations after the workloads serving the webhooks.
+type plugin struct {
+	// CustomResources makes custom resources depend on the
+	// CustomResourceDefinitions defining them.
+	CustomResources bool `json:"customResources,omitempty" yaml:"customResources,omitempty"`
+
+	// Webhooks makes webhook configurations depend on the
+	// workloads behind the services they call, or on these
+	// services if they select no workload of the build.
+	Webhooks bool `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	_ *resmap.PluginHelpers, c []byte) (err error) {
+	p.CustomResources = false
+	p.Webhooks = false
+	return yaml.Unmarshal(c, p)
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	deps := make(map[*resource.Resource][]string)
+	if p.CustomResources {
+		if err := addCustomResourceDeps(m, deps); err != nil {
+			return err
+		}
+	}
+	if p.Webhooks {
+		if err := addWebhookDeps(m, deps); err != nil {
+			return err
+		}
+	}
+	for _, r := range m.Resources() {
+		if len(deps[r]) == 0 {
+			continue
+		}
+		annotations := r.GetAnnotations()
+		annotations[types.DependsOnAnnotation] = mergeDeps(
+			annotations[types.DependsOnAnnotation], deps[r])
+		r.SetAnnotations(annotations)
+	}
+	return nil
+}
+
+const (
+	crdGroup = "apiextensions.k8s.io"
+	crdKind  = "CustomResourceDefinition"
+)
+
+func addCustomResourceDeps(
+	m resmap.ResMap, deps map[*resource.Resource][]string) error {
+	var crd struct {
+		Spec struct {
+			Group string `json:"group"`
+			Names struct {
+				Kind string `json:"kind"`
+			} `json:"names"`
+		} `json:"spec"`
+	}
+	crds := make(map[string]*resource.Resource)
+	for _, r := range m.Resources() {
+		gvk := r.GetGvk()
+		if gvk.Group != crdGroup || gvk.Kind != crdKind {
+			continue
+		}
+		if err := decode(r, &crd); err != nil {
+			return err
+		}
+		crds[crd.Spec.Group+"/"+crd.Spec.Names.Kind] = r
+	}
+	if len(crds) == 0 {
+		return nil
+	}
+	for _, r := range m.Resources() {
+		gvk := r.GetGvk()
+		if def, ok := crds[gvk.Group+"/"+gvk.Kind]; ok && def != r {
+			deps[r] = append(deps[r], dependencyId(def, ""))
+		}
+	}
+	return nil
+}
+
+const webhookGroup = "admissionregistration.k8s.io"
+
+// workloadKinds are the kinds of the apps group serving webhooks.
+var workloadKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+func addWebhookDeps(
+	m resmap.ResMap, deps map[*resource.Resource][]string) error {
+	services := make(map[string]*resource.Resource)
+	var workloads []*resource.Resource
+	for _, r := range m.Resources() {
+		gvk := r.GetGvk()
+		switch {
+		case gvk.Group == "" && gvk.Kind == "Service":
+			services[namespaceOf(r)+"/"+r.GetName()] = r
+		case gvk.Group == "apps" && workloadKinds[gvk.Kind]:
+			workloads = append(workloads, r)
+		}
+	}
+	var config struct {
+		Webhooks []struct {
+			ClientConfig struct {
+				Service *struct {
+					Namespace string `json:"namespace"`
+					Name      string `json:"name"`
+				} `json:"service"`
+			} `json:"clientConfig"`
+		} `json:"webhooks"`
+	}
+	for _, r := range m.Resources() {
+		gvk := r.GetGvk()
+		if gvk.Group != webhookGroup ||
+			(gvk.Kind != "ValidatingWebhookConfiguration" &&
+				gvk.Kind != "MutatingWebhookConfiguration") {
+			continue
+		}
+		config.Webhooks = nil
+		if err := decode(r, &config); err != nil {
+			return err
+		}
+		for _, w := range config.Webhooks {
+			ref := w.ClientConfig.Service
+			if ref == nil {
+				continue
+			}
+			ns := ref.Namespace
+			if ns == "" {
+				ns = "default"
+			}
+			svc, ok := services[ns+"/"+ref.Name]
+			if !ok {
+				continue
+			}
+			served, err := servingWorkloads(svc, ns, workloads)
+			if err != nil {
+				return err
+			}
+			if len(served) == 0 {
+				deps[r] = append(deps[r], dependencyId(svc, ns))
+			}
+			for _, wl := range served {
+				deps[r] = append(deps[r], dependencyId(wl, ns))
+			}
+		}
+	}
+	return nil
+}
+
+// servingWorkloads returns the workloads of the namespace ns
+// the pods of which svc selects.
+func servingWorkloads(svc *resource.Resource, ns string,
+	workloads []*resource.Resource) ([]*resource.Resource, error) {
+	var service struct {
+		Spec struct {
+			Selector map[string]string `json:"selector"`
+		} `json:"spec"`
+	}
+	if err := decode(svc, &service); err != nil {
+		return nil, err
+	}
+	if len(service.Spec.Selector) == 0 {
+		return nil, nil
+	}
+	var workload struct {
+		Spec struct {
+			Template struct {
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	var result []*resource.Resource
+	for _, wl := range workloads {
+		if namespaceOf(wl) != ns {
+			continue
+		}
+		workload.Spec.Template.Metadata.Labels = nil
+		if err := decode(wl, &workload); err != nil {
+			return nil, err
+		}
+		if selects(service.Spec.Selector, workload.Spec.Template.Metadata.Labels) {
+			result = append(result, wl)
+		}
+	}
+	return result, nil
+}
+
+func selects(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func decode(r *resource.Resource, v interface{}) error {
+	b, err := r.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("reading %s: %v", r.CurId(), err)
+	}
+	return nil
+}
+
+// namespaceOf returns the namespace of r, a namespaced
+// resource, defaulting to the one appliers default to.
+func namespaceOf(r *resource.Resource) string {
+	if ns := r.GetNamespace(); ns != "" {
+		return ns
+	}
+	return "default"
+}
+
+// dependencyId returns the id of r in a depends-on annotation,
+// i.e. group/kind/name, or group/namespaces/ns/kind/name if r
+// is namespaced, in the namespace ns.
+func dependencyId(r *resource.Resource, ns string) string {
+	gvk := r.GetGvk()
+	if ns == "" {
+		return strings.Join([]string{gvk.Group, gvk.Kind, r.GetName()}, "/")
+	}
+	return strings.Join(
+		[]string{gvk.Group, "namespaces", ns, gvk.Kind, r.GetName()}, "/")
+}
+
+// mergeDeps adds the ids to those of the annotation value,
+// dropping duplicates.
+func mergeDeps(value string, ids []string) string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, id := range append(strings.Split(value, ","), ids...) {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return strings.Join(result, ",")
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestApplyHintsTransformerCustomResources(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ApplyHintsTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: ApplyHintsTransformer
+metadata:
+  name: notImportantHere
+customResources: true
+`, `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  names:
+    kind: CronTab
+    plural: crontabs
+  scope: Namespaced
+---
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: nightly
+  namespace: jobs
+  annotations:
+    config.kubernetes.io/depends-on: /namespaces/jobs/ConfigMap/settings
+---
+apiVersion: other.example.com/v1
+kind: CronTab
+metadata:
+  name: unrelated
+`, `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  names:
+    kind: CronTab
+    plural: crontabs
+  scope: Namespaced
+---
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  annotations:
+    config.kubernetes.io/depends-on: /namespaces/jobs/ConfigMap/settings,apiextensions.k8s.io/CustomResourceDefinition/crontabs.stable.example.com
+  name: nightly
+  namespace: jobs
+---
+apiVersion: other.example.com/v1
+kind: CronTab
+metadata:
+  name: unrelated
+`)
+}
+
+func TestApplyHintsTransformerWebhooks(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ApplyHintsTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: ApplyHintsTransformer
+metadata:
+  name: notImportantHere
+webhooks: true
+`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: webhook
+  namespace: system
+spec:
+  template:
+    metadata:
+      labels:
+        app: webhook
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: webhook
+  namespace: system
+spec:
+  selector:
+    app: webhook
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: external
+  namespace: system
+spec:
+  selector:
+    app: elsewhere
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validating
+webhooks:
+- name: validate.example.com
+  clientConfig:
+    service:
+      name: webhook
+      namespace: system
+- name: unknown.example.com
+  clientConfig:
+    url: https://example.com/validate
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: mutating
+webhooks:
+- name: mutate.example.com
+  clientConfig:
+    service:
+      name: external
+      namespace: system
+`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: webhook
+  namespace: system
+spec:
+  template:
+    metadata:
+      labels:
+        app: webhook
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: webhook
+  namespace: system
+spec:
+  selector:
+    app: webhook
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: external
+  namespace: system
+spec:
+  selector:
+    app: elsewhere
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  annotations:
+    config.kubernetes.io/depends-on: apps/namespaces/system/Deployment/webhook
+  name: validating
+webhooks:
+- clientConfig:
+    service:
+      name: webhook
+      namespace: system
+  name: validate.example.com
+- clientConfig:
+    url: https://example.com/validate
+  name: unknown.example.com
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  annotations:
+    config.kubernetes.io/depends-on: /namespaces/system/Service/external
+  name: mutating
+webhooks:
+- clientConfig:
+    service:
+      name: external
+      namespace: system
+  name: mutate.example.com
+`)
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/api/hasher"
+	"sigs.k8s.io/kustomize/api/ifc"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/types"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const defaultChecksumAnnotation = "checksum/config"
+
+// Annotate the pod templates of workloads with a checksum of
+// the ConfigMaps and Secrets they reference, so that changing
+// those rolls the workloads without renaming anything.
+type plugin struct {
+	// Annotation is the key of the pod template annotation
+	// holding the checksum.  Defaults to checksum/config.
+	Annotation string `json:"annotation,omitempty" yaml:"annotation,omitempty"`
+
+	// Selectors select the ConfigMaps and Secrets that contribute
+	// to the checksum.  If empty, all of them do.
+	Selectors []types.Selector `json:"selectors,omitempty" yaml:"selectors,omitempty"`
+
+	hasher ifc.KustHasher
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Annotation = ""
+	p.Selectors = nil
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	if p.Annotation == "" {
+		p.Annotation = defaultChecksumAnnotation
+	}
+	p.hasher = h.ResmapFactory().RF().Hasher()
+	return nil
+}
+
+// configKey identifies a ConfigMap or Secret
+// as a workload in its namespace refers to it.
+type configKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	configs, err := p.selectConfigs(m)
+	if err != nil {
+		return err
+	}
+	if len(configs) == 0 {
+		return nil
+	}
+	for _, r := range m.Resources() {
+		template, err := podTemplate(r)
+		if err != nil {
+			return err
+		}
+		if template == nil {
+			continue
+		}
+		var hashes []string
+		seen := make(map[*resource.Resource]bool)
+		for _, ref := range configRefs(template) {
+			for _, ns := range namespacesOf(r) {
+				ref.namespace = ns
+				if c, ok := configs[ref]; ok && !seen[c.res] {
+					seen[c.res] = true
+					hashes = append(hashes, c.hash)
+					break
+				}
+			}
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+		sum, err := hasher.SortArrayAndComputeHash(hashes)
+		if err != nil {
+			return err
+		}
+		if err = template.PipeE(
+			kyaml.LookupCreate(kyaml.MappingNode, kyaml.MetadataField, kyaml.AnnotationsField),
+			kyaml.SetField(p.Annotation, kyaml.NewStringRNode(sum))); err != nil {
+			return fmt.Errorf("setting %s on %s: %v", p.Annotation, r.CurId(), err)
+		}
+	}
+	return nil
+}
+
+// config is a selected ConfigMap or Secret, with its hash.
+type config struct {
+	res  *resource.Resource
+	hash string
+}
+
+// selectConfigs returns the selected ConfigMaps and Secrets, with
+// their hashes, keyed by their current and previous ids, as the
+// references of workloads may not be updated yet to their current
+// names, e.g. when the step runs before the names are prefixed.
+func (p *plugin) selectConfigs(m resmap.ResMap) (map[configKey]config, error) {
+	candidates := m.Resources()
+	if len(p.Selectors) > 0 {
+		candidates = nil
+		for _, s := range p.Selectors {
+			selected, err := m.Select(s)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, selected...)
+		}
+	}
+	result := make(map[configKey]config)
+	for _, r := range candidates {
+		id := r.CurId()
+		if id.Group != "" || (id.Kind != "ConfigMap" && id.Kind != "Secret") {
+			continue
+		}
+		h, err := r.Hash(p.hasher)
+		if err != nil {
+			return nil, err
+		}
+		c := config{res: r, hash: h}
+		result[configKey{
+			kind:      id.Kind,
+			namespace: id.EffectiveNamespace(),
+			name:      id.Name,
+		}] = c
+		for _, prev := range r.PrevIds() {
+			key := configKey{
+				kind:      id.Kind,
+				namespace: prev.EffectiveNamespace(),
+				name:      prev.Name,
+			}
+			// The current ids of the others take precedence.
+			if _, ok := result[key]; !ok {
+				result[key] = c
+			}
+		}
+	}
+	return result, nil
+}
+
+// namespacesOf returns the current namespace of the
+// workload r, then those it had before.
+func namespacesOf(r *resource.Resource) []string {
+	result := []string{r.CurId().EffectiveNamespace()}
+	for _, prev := range r.PrevIds() {
+		result = append(result, prev.EffectiveNamespace())
+	}
+	return result
+}
+
+// podTemplate returns the pod template of a workload,
+// or nil if the resource has none.
+func podTemplate(r *resource.Resource) (*kyaml.RNode, error) {
+	id := r.CurId()
+	var path []string
+	switch {
+	case id.Group == "apps" &&
+		(id.Kind == "Deployment" || id.Kind == "StatefulSet" ||
+			id.Kind == "DaemonSet" || id.Kind == "ReplicaSet"):
+		path = []string{"spec", "template"}
+	case id.Group == "batch" && id.Kind == "Job":
+		path = []string{"spec", "template"}
+	case id.Group == "batch" && id.Kind == "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template"}
+	default:
+		return nil, nil
+	}
+	return r.Node().Pipe(kyaml.Lookup(path...))
+}
+
+// configRefs returns the ConfigMaps and Secrets a pod template
+// refers to through its volumes and its containers' environment.
+func configRefs(template *kyaml.RNode) []configKey {
+	var refs []configKey
+	add := func(kind string, n *kyaml.RNode, path ...string) {
+		name, err := n.Pipe(kyaml.Lookup(path...))
+		if err != nil || name == nil || name.YNode().Value == "" {
+			return
+		}
+		refs = append(refs, configKey{kind: kind, name: name.YNode().Value})
+	}
+	each := func(n *kyaml.RNode, path []string, f func(*kyaml.RNode)) {
+		list, err := n.Pipe(kyaml.Lookup(path...))
+		if err != nil || list == nil {
+			return
+		}
+		_ = list.VisitElements(func(e *kyaml.RNode) error {
+			f(e)
+			return nil
+		})
+	}
+	each(template, []string{"spec", "volumes"}, func(v *kyaml.RNode) {
+		add("ConfigMap", v, "configMap", "name")
+		add("Secret", v, "secret", "secretName")
+		each(v, []string{"projected", "sources"}, func(s *kyaml.RNode) {
+			add("ConfigMap", s, "configMap", "name")
+			add("Secret", s, "secret", "name")
+		})
+	})
+	for _, containers := range []string{"containers", "initContainers"} {
+		each(template, []string{"spec", containers}, func(c *kyaml.RNode) {
+			each(c, []string{"envFrom"}, func(e *kyaml.RNode) {
+				add("ConfigMap", e, "configMapRef", "name")
+				add("Secret", e, "secretRef", "name")
+			})
+			each(c, []string{"env"}, func(e *kyaml.RNode) {
+				add("ConfigMap", e, "valueFrom", "configMapKeyRef", "name")
+				add("Secret", e, "valueFrom", "secretKeyRef", "name")
+			})
+		})
+	}
+	return refs
+}
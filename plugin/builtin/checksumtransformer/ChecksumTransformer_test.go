@@ -0,0 +1,282 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+const checksumInput = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  level: debug
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secret
+data:
+  password: c2VjcmV0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other-config
+data:
+  unused: "true"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app
+        envFrom:
+        - configMapRef:
+            name: app-config
+        env:
+        - name: PASSWORD
+          valueFrom:
+            secretKeyRef:
+              name: app-secret
+              key: password
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          volumes:
+          - name: config
+            configMap:
+              name: app-config
+          containers:
+          - name: job
+            image: job
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: unconfigured
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app
+`
+
+func TestChecksumTransformer(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ChecksumTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: ChecksumTransformer
+metadata:
+  name: notImportantHere
+`, checksumInput, `
+apiVersion: v1
+data:
+  level: debug
+kind: ConfigMap
+metadata:
+  name: app-config
+---
+apiVersion: v1
+data:
+  password: c2VjcmV0
+kind: Secret
+metadata:
+  name: app-secret
+---
+apiVersion: v1
+data:
+  unused: "true"
+kind: ConfigMap
+metadata:
+  name: other-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    metadata:
+      annotations:
+        checksum/config: c5fd6dh7d9
+    spec:
+      containers:
+      - env:
+        - name: PASSWORD
+          valueFrom:
+            secretKeyRef:
+              key: password
+              name: app-secret
+        envFrom:
+        - configMapRef:
+            name: app-config
+        image: app
+        name: app
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        metadata:
+          annotations:
+            checksum/config: hc4mg5289h
+        spec:
+          containers:
+          - image: job
+            name: job
+          volumes:
+          - configMap:
+              name: app-config
+            name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: unconfigured
+spec:
+  template:
+    spec:
+      containers:
+      - image: app
+        name: app
+`)
+}
+
+func TestChecksumTransformerSelectors(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ChecksumTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: ChecksumTransformer
+metadata:
+  name: notImportantHere
+annotation: example.com/config-hash
+selectors:
+- kind: Secret
+`, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  level: debug
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secret
+data:
+  password: c2VjcmV0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      volumes:
+      - name: config
+        projected:
+          sources:
+          - configMap:
+              name: app-config
+          - secret:
+              name: app-secret
+      containers:
+      - name: app
+        image: app
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  template:
+    spec:
+      containers:
+      - name: db
+        image: db
+        envFrom:
+        - configMapRef:
+            name: app-config
+`, `
+apiVersion: v1
+data:
+  level: debug
+kind: ConfigMap
+metadata:
+  name: app-config
+---
+apiVersion: v1
+data:
+  password: c2VjcmV0
+kind: Secret
+metadata:
+  name: app-secret
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    metadata:
+      annotations:
+        example.com/config-hash: 869bt6cfc2
+    spec:
+      containers:
+      - image: app
+        name: app
+      volumes:
+      - name: config
+        projected:
+          sources:
+          - configMap:
+              name: app-config
+          - secret:
+              name: app-secret
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  template:
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: app-config
+        image: db
+        name: db
+`)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/imdario/mergo"
 	"github.com/pkg/errors"
 	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/signature"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/yaml"
 )
@@ -31,6 +32,13 @@ type HelmChartInflationGeneratorPlugin struct {
 	types.HelmGlobals
 	types.HelmChart
 	tmpDir string
+	// verifiedChart is the reference, by digest, of the chart
+	// whose signatures were verified, if the policy requires
+	// charts to be signed.
+	verifiedChart string
+	// defaultValuesFile is whether the ValuesFile is that of
+	// the chart, rather than one of the kustomization.
+	defaultValuesFile bool
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -65,6 +73,9 @@ func (p *HelmChartInflationGeneratorPlugin) Config(
 	if err = yaml.Unmarshal(config, p); err != nil {
 		return
 	}
+	if p.KubeVersion == "" {
+		p.KubeVersion = h.GeneralConfig().KubeVersion
+	}
 	return p.validateArgs()
 }
 
@@ -100,6 +111,7 @@ func (p *HelmChartInflationGeneratorPlugin) validateArgs() (err error) {
 	// disabled).
 	if p.ValuesFile == "" {
 		p.ValuesFile = filepath.Join(p.ChartHome, p.Name, "values.yaml")
+		p.defaultValuesFile = true
 	}
 
 	if err = p.errIfIllegalValuesMerge(); err != nil {
@@ -132,12 +144,25 @@ func (p *HelmChartInflationGeneratorPlugin) errIfIllegalValuesMerge() error {
 }
 
 func (p *HelmChartInflationGeneratorPlugin) absChartHome() string {
+	if p.verifiedChart != "" {
+		return filepath.Join(p.tmpDir, "charts")
+	}
 	if filepath.IsAbs(p.ChartHome) {
 		return p.ChartHome
 	}
 	return filepath.Join(p.h.Loader().Root(), p.ChartHome)
 }
 
+// loadValuesFile returns the content of the ValuesFile.
+func (p *HelmChartInflationGeneratorPlugin) loadValuesFile() ([]byte, error) {
+	if p.verifiedChart != "" && p.defaultValuesFile {
+		// The chart was pulled to the tmpDir, out of the loader's reach.
+		return ioutil.ReadFile(
+			filepath.Join(p.absChartHome(), p.Name, "values.yaml"))
+	}
+	return p.h.Loader().Load(p.ValuesFile)
+}
+
 func (p *HelmChartInflationGeneratorPlugin) runHelmCommand(
 	args []string) ([]byte, error) {
 	stdout := new(bytes.Buffer)
@@ -181,7 +206,7 @@ func (p *HelmChartInflationGeneratorPlugin) createNewMergedValuesFile() (
 }
 
 func (p *HelmChartInflationGeneratorPlugin) replaceValuesInline() error {
-	pValues, err := p.h.Loader().Load(p.ValuesFile)
+	pValues, err := p.loadValuesFile()
 	if err != nil {
 		return err
 	}
@@ -202,7 +227,7 @@ func (p *HelmChartInflationGeneratorPlugin) replaceValuesInline() error {
 
 // copyValuesFile to avoid branching.  TODO: get rid of this.
 func (p *HelmChartInflationGeneratorPlugin) copyValuesFile() (string, error) {
-	b, err := p.h.Loader().Load(p.ValuesFile)
+	b, err := p.loadValuesFile()
 	if err != nil {
 		return "", err
 	}
@@ -231,12 +256,19 @@ func (p *HelmChartInflationGeneratorPlugin) Generate() (rm resmap.ResMap, err er
 	if err = p.checkHelmVersion(); err != nil {
 		return nil, err
 	}
-	if path, exists := p.chartExistsLocally(); !exists {
+	if p.signaturesRequired() {
+		// A chart in the chart home can't be told to be the
+		// one signed, so the chart is pulled, by digest, once
+		// its signatures are verified.
+		if err = p.pullChart(); err != nil {
+			return nil, err
+		}
+	} else if path, exists := p.chartExistsLocally(); !exists {
 		if p.Repo == "" {
 			return nil, fmt.Errorf(
 				"no repo specified for pull, no chart found at '%s'", path)
 		}
-		if _, err := p.runHelmCommand(p.pullCommand()); err != nil {
+		if err = p.pullChart(); err != nil {
 			return nil, err
 		}
 	}
@@ -267,6 +299,23 @@ func (p *HelmChartInflationGeneratorPlugin) Generate() (rm resmap.ResMap, err er
 	return nil, err
 }
 
+// pullChart pulls the chart from its repo, as the policy
+// and the rewriter of the plugin config, if any, have it.
+func (p *HelmChartInflationGeneratorPlugin) pullChart() error {
+	err := p.h.GeneralConfig().Policy.CheckHelmRepo(p.h.Loader().Root(), p.Repo)
+	if err != nil {
+		return err
+	}
+	if err = p.rewriteChart(); err != nil {
+		return err
+	}
+	if err = p.verifyChart(); err != nil {
+		return err
+	}
+	_, err = p.runHelmCommand(p.pullCommand())
+	return err
+}
+
 func (p *HelmChartInflationGeneratorPlugin) templateCommand() []string {
 	args := []string{"template"}
 	if p.ReleaseName != "" {
@@ -276,6 +325,21 @@ func (p *HelmChartInflationGeneratorPlugin) templateCommand() []string {
 	if p.ValuesFile != "" {
 		args = append(args, "--values", p.ValuesFile)
 	}
+	if p.IncludeCRDs {
+		args = append(args, "--include-crds")
+	}
+	if p.SkipTests {
+		args = append(args, "--skip-tests")
+	}
+	if p.SkipSchemaValidation {
+		args = append(args, "--skip-schema-validation")
+	}
+	if p.KubeVersion != "" {
+		args = append(args, "--kube-version", p.KubeVersion)
+	}
+	for _, v := range p.ApiVersions {
+		args = append(args, "--api-versions", v)
+	}
 	if p.ReleaseName == "" {
 		// AFAICT, this doesn't work as intended due to a bug in helm.
 		// See https://github.com/helm/helm/issues/6019
@@ -289,15 +353,85 @@ func (p *HelmChartInflationGeneratorPlugin) pullCommand() []string {
 	args := []string{
 		"pull",
 		"--untar",
-		"--untardir", p.absChartHome(),
-		"--repo", p.Repo,
-		p.Name}
+		"--untardir", p.absChartHome()}
+	switch {
+	case p.verifiedChart != "":
+		return append(args, "oci://"+p.verifiedChart)
+	case p.isOCIRepo():
+		args = append(args, strings.TrimSuffix(p.Repo, "/")+"/"+p.Name)
+	default:
+		args = append(args, "--repo", p.Repo, p.Name)
+	}
 	if p.Version != "" {
 		args = append(args, "--version", p.Version)
 	}
 	return args
 }
 
+// isOCIRepo returns whether the chart is pulled from
+// an OCI registry, rather than a helm repository.
+func (p *HelmChartInflationGeneratorPlugin) isOCIRepo() bool {
+	return strings.HasPrefix(p.Repo, "oci://")
+}
+
+// rewriteChart has the chart pulled from where the rewriter of
+// the plugin config, if any, has it pulled from.
+func (p *HelmChartInflationGeneratorPlugin) rewriteChart() error {
+	ref, err := p.h.GeneralConfig().RemoteRefRewriter.Rewrite(types.RemoteRef{
+		Kind:     types.RemoteRefHelmChart,
+		Referrer: p.h.Loader().Root(),
+		URL:      p.Repo,
+		Name:     p.Name,
+		Version:  p.Version,
+	})
+	if err != nil {
+		return err
+	}
+	p.Repo, p.Name, p.Version = ref.URL, ref.Name, ref.Version
+	return nil
+}
+
+// signaturesRequired returns whether the policy
+// requires charts to be signed.
+func (p *HelmChartInflationGeneratorPlugin) signaturesRequired() bool {
+	policy := p.h.GeneralConfig().Policy
+	return policy != nil && policy.Signatures != nil
+}
+
+// verifyChart errors if the policy requires charts to be
+// signed, and the chart to pull isn't, and otherwise has
+// the chart pulled by the digest whose signatures were
+// verified.  Only charts in OCI registries can be signed.
+func (p *HelmChartInflationGeneratorPlugin) verifyChart() error {
+	if !p.signaturesRequired() {
+		return nil
+	}
+	if !p.isOCIRepo() {
+		where := "in " + p.Repo
+		if p.Repo == "" {
+			where = "local, with no repo to pull it from"
+		}
+		return fmt.Errorf(
+			"charts must be signed, which only charts in OCI registries "+
+				"can be, but chart %s is %s", p.Name, where)
+	}
+	ref := strings.TrimSuffix(strings.TrimPrefix(p.Repo, "oci://"), "/") +
+		"/" + p.Name
+	if p.Version != "" {
+		ref += ":" + p.Version
+	}
+	verified, err := signature.Verify(
+		p.h.GeneralConfig().Policy, p.h.Loader().Root(), "helm chart", ref)
+	if err != nil {
+		return err
+	}
+	if err = p.establishTmpDir(); err != nil {
+		return err
+	}
+	p.verifiedChart = verified
+	return nil
+}
+
 // chartExistsLocally will return true if the chart does exist in
 // local chart home.
 func (p *HelmChartInflationGeneratorPlugin) chartExistsLocally() (string, bool) {
@@ -4,6 +4,7 @@
 package main_test
 
 import (
+	"strings"
 	"testing"
 
 	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
@@ -553,3 +554,189 @@ spec:
         name: my-image
 `)
 }
+
+func TestImageTagTransformerRegistryMirrors(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("/app", `
+resources:
+- resources.yaml
+configurations:
+- config.yaml
+images:
+- name: old-image-name
+  newName: docker.io/team/new-image-name
+registryMirrors:
+  docker.io: mirror.example.com/dockerhub
+  gcr.io: mirror.example.com/gcr
+`)
+	th.WriteF("/app/config.yaml", `
+images:
+- path: spec/runner/image
+  kind: Runner
+`)
+	th.WriteF("/app/resources.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deploy1
+spec:
+  template:
+    spec:
+      containers:
+      - image: old-image-name:v1
+        name: app
+      - image: gcr.io/project/sidecar@sha256:12345
+        name: sidecar
+      initContainers:
+      - image: quay.io/init:v2
+        name: init
+---
+apiVersion: example.com/v1
+kind: Runner
+metadata:
+  name: runner
+spec:
+  runner:
+    image: nginx
+`)
+
+	m := th.Run("/app", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deploy1
+spec:
+  template:
+    spec:
+      containers:
+      - image: mirror.example.com/dockerhub/team/new-image-name:v1
+        name: app
+      - image: mirror.example.com/gcr/project/sidecar@sha256:12345
+        name: sidecar
+      initContainers:
+      - image: quay.io/init:v2
+        name: init
+---
+apiVersion: example.com/v1
+kind: Runner
+metadata:
+  name: runner
+spec:
+  runner:
+    image: mirror.example.com/dockerhub/library/nginx
+`)
+}
+
+func TestImageTagTransformerNewTagFrom(t *testing.T) {
+	th := kusttest_test.MakeHarness(t)
+	th.WriteK("/app", `
+namePrefix: prod-
+resources:
+- resources.yaml
+configMapGenerator:
+- name: versions
+  literals:
+  - frontend=v1.4.2
+images:
+- name: frontend
+  newTagFrom:
+    kind: ConfigMap
+    name: versions
+    fieldPath: data.frontend
+`)
+	th.WriteF("/app/resources.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+spec:
+  template:
+    spec:
+      containers:
+      - image: frontend:latest
+        name: frontend
+`)
+	m := th.Run("/app", th.MakeDefaultOptions())
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-frontend
+spec:
+  template:
+    spec:
+      containers:
+      - image: frontend:v1.4.2
+        name: frontend
+---
+apiVersion: v1
+data:
+  frontend: v1.4.2
+kind: ConfigMap
+metadata:
+  name: prod-versions-mfbb2mhfgh
+`)
+}
+
+func TestImageTagTransformerNewTagFromErrors(t *testing.T) {
+	testCases := map[string]struct {
+		image string
+		err   string
+	}{
+		"no such resource": {
+			image: `
+- name: frontend
+  newTagFrom:
+    kind: ConfigMap
+    name: releases
+    fieldPath: data.frontend
+`,
+			err: "the newTagFrom of image frontend selects 0 resources, expected one",
+		},
+		"no such field": {
+			image: `
+- name: frontend
+  newTagFrom:
+    kind: ConfigMap
+    name: versions
+    fieldPath: data.backend
+`,
+			err: "the newTagFrom of image frontend: data.backend of " +
+				"~G_v1_ConfigMap|~X|versions doesn't hold a tag",
+		},
+		"newTag too": {
+			image: `
+- name: frontend
+  newTag: v1
+  newTagFrom:
+    kind: ConfigMap
+    name: versions
+    fieldPath: data.frontend
+`,
+			err: "image frontend can't have both newTag and newTagFrom",
+		},
+	}
+	for n, tc := range testCases {
+		tc := tc
+		t.Run(n, func(t *testing.T) {
+			th := kusttest_test.MakeHarness(t)
+			th.WriteK("/app", `
+resources:
+- versions.yaml
+images:`+tc.image)
+			th.WriteF("/app/versions.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: versions
+data:
+  frontend: v1.4.2
+`)
+			err := th.RunWithErr("/app", th.MakeDefaultOptions())
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	sigs.k8s.io/kustomize/api v0.0.0
+	sigs.k8s.io/kustomize/kyaml v0.10.17
 	sigs.k8s.io/yaml v1.2.0
 )
 
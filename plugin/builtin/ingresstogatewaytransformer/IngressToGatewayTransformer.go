@@ -0,0 +1,358 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	gatewayAPIVersion = "gateway.networking.k8s.io/v1"
+	ingressClassKey   = "kubernetes.io/ingress.class"
+)
+
+// Convert Ingresses into the Gateway API's HTTPRoutes, attached
+// to the Gateway the Ingress's class is mapped to, optionally
+// generating that Gateway.
+type plugin struct {
+	// Gateways map ingress classes to Gateways.
+	// Ingresses of classes not mapped are left alone.
+	Gateways []GatewayMapping `json:"gateways,omitempty" yaml:"gateways,omitempty"`
+
+	// KeepIngresses, if true, keeps the Ingresses
+	// alongside the HTTPRoutes replacing them.
+	KeepIngresses bool `json:"keepIngresses,omitempty" yaml:"keepIngresses,omitempty"`
+
+	h *resmap.PluginHelpers
+}
+
+// GatewayMapping maps an ingress class to a Gateway.
+type GatewayMapping struct {
+	// IngressClassName is the class of the Ingresses to convert,
+	// as given by spec.ingressClassName or the legacy class
+	// annotation.  If empty, Ingresses without a class match.
+	IngressClassName string `json:"ingressClassName,omitempty" yaml:"ingressClassName,omitempty"`
+
+	// Name and Namespace identify the Gateway
+	// the resulting HTTPRoutes attach to.
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+
+	// GatewayClassName, if specified, generates the
+	// Gateway, of this class, listening for HTTP and,
+	// if the Ingresses use TLS, for HTTPS.
+	GatewayClassName string `json:"gatewayClassName,omitempty" yaml:"gatewayClassName,omitempty"`
+}
+
+// The parts of networking.k8s.io/v1 Ingress that are converted.
+type ingress struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace,omitempty"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		IngressClassName string          `json:"ingressClassName,omitempty"`
+		DefaultBackend   *ingressBackend `json:"defaultBackend,omitempty"`
+		TLS              []struct {
+			Hosts      []string `json:"hosts,omitempty"`
+			SecretName string   `json:"secretName,omitempty"`
+		} `json:"tls,omitempty"`
+		Rules []struct {
+			Host string `json:"host,omitempty"`
+			HTTP *struct {
+				Paths []struct {
+					Path     string         `json:"path,omitempty"`
+					PathType string         `json:"pathType,omitempty"`
+					Backend  ingressBackend `json:"backend"`
+				} `json:"paths"`
+			} `json:"http,omitempty"`
+		} `json:"rules,omitempty"`
+	} `json:"spec"`
+}
+
+type ingressBackend struct {
+	Service *struct {
+		Name string `json:"name"`
+		Port struct {
+			Name   string `json:"name,omitempty"`
+			Number int    `json:"number,omitempty"`
+		} `json:"port"`
+	} `json:"service,omitempty"`
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Gateways = nil
+	p.KeepIngresses = false
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	if len(p.Gateways) == 0 {
+		return fmt.Errorf("must specify at least one gateway")
+	}
+	for _, g := range p.Gateways {
+		if g.Name == "" {
+			return fmt.Errorf(
+				"the gateway for ingress class '%s' must have a name", g.IngressClassName)
+		}
+	}
+	p.h = h
+	return nil
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	var objects []map[string]interface{}
+	// The TLS secrets of the converted Ingresses, by gateway.
+	tls := make(map[int][]tlsSecret)
+	converted := make(map[int]bool)
+	for _, r := range m.Resources() {
+		if r.GetKind() != "Ingress" || r.GetGvk().Group != "networking.k8s.io" {
+			continue
+		}
+		var ing ingress
+		if err := unmarshalIngress(r, &ing); err != nil {
+			return err
+		}
+		i := p.gatewayFor(&ing)
+		if i < 0 {
+			continue
+		}
+		routes, err := httpRoutes(&ing, p.Gateways[i])
+		if err != nil {
+			return fmt.Errorf("converting ingress %s: %v", r.CurId(), err)
+		}
+		objects = append(objects, routes...)
+		for _, t := range ing.Spec.TLS {
+			tls[i] = append(tls[i], tlsSecret{
+				hosts: t.Hosts, name: t.SecretName, namespace: ing.Metadata.Namespace})
+		}
+		converted[i] = true
+		if !p.KeepIngresses {
+			if err = m.Remove(r.CurId()); err != nil {
+				return err
+			}
+		}
+	}
+	for i, g := range p.Gateways {
+		if converted[i] && g.GatewayClassName != "" {
+			objects = append(objects, gateway(g, tls[i]))
+		}
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+	var b []byte
+	for _, o := range objects {
+		y, err := yaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		b = append(b, []byte("---\n")...)
+		b = append(b, y...)
+	}
+	rm, err := p.h.ResmapFactory().NewResMapFromBytes(b)
+	if err != nil {
+		return err
+	}
+	return m.AppendAll(rm)
+}
+
+func unmarshalIngress(r *resource.Resource, ing *ingress) error {
+	y, err := r.AsYAML()
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(y, ing)
+}
+
+// gatewayFor returns the index of the gateway
+// the ingress is mapped to, or -1 if there is none.
+func (p *plugin) gatewayFor(ing *ingress) int {
+	class := ing.Spec.IngressClassName
+	if class == "" {
+		class = ing.Metadata.Annotations[ingressClassKey]
+	}
+	for i, g := range p.Gateways {
+		if g.IngressClassName == class {
+			return i
+		}
+	}
+	return -1
+}
+
+// httpRoutes returns an HTTPRoute for each host of the ingress,
+// named after the ingress, with the host appended if there are
+// several of them.
+func httpRoutes(ing *ingress, g GatewayMapping) ([]map[string]interface{}, error) {
+	var hosts []string
+	rulesByHost := make(map[string][]interface{})
+	for _, rule := range ing.Spec.Rules {
+		if _, ok := rulesByHost[rule.Host]; !ok {
+			hosts = append(hosts, rule.Host)
+			rulesByHost[rule.Host] = nil
+		}
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backend, err := backendRef(path.Backend)
+			if err != nil {
+				return nil, err
+			}
+			pathType := "PathPrefix"
+			if path.PathType == "Exact" {
+				pathType = "Exact"
+			}
+			value := path.Path
+			if value == "" {
+				value = "/"
+			}
+			rulesByHost[rule.Host] = append(rulesByHost[rule.Host], map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"path": map[string]interface{}{"type": pathType, "value": value},
+					},
+				},
+				"backendRefs": []interface{}{backend},
+			})
+		}
+	}
+	if ing.Spec.DefaultBackend != nil {
+		backend, err := backendRef(*ing.Spec.DefaultBackend)
+		if err != nil {
+			return nil, err
+		}
+		if len(hosts) == 0 {
+			hosts = []string{""}
+		}
+		for _, h := range hosts {
+			rulesByHost[h] = append(rulesByHost[h], map[string]interface{}{
+				"backendRefs": []interface{}{backend},
+			})
+		}
+	}
+	parentRef := map[string]interface{}{"name": g.Name}
+	if g.Namespace != "" {
+		parentRef["namespace"] = g.Namespace
+	}
+	var routes []map[string]interface{}
+	for _, h := range hosts {
+		name := ing.Metadata.Name
+		if len(hosts) > 1 {
+			name += "-" + hostSuffix(h)
+		}
+		meta := map[string]interface{}{"name": name}
+		if ing.Metadata.Namespace != "" {
+			meta["namespace"] = ing.Metadata.Namespace
+		}
+		spec := map[string]interface{}{
+			"parentRefs": []interface{}{parentRef},
+			"rules":      rulesByHost[h],
+		}
+		if h != "" {
+			spec["hostnames"] = []string{h}
+		}
+		routes = append(routes, map[string]interface{}{
+			"apiVersion": gatewayAPIVersion,
+			"kind":       "HTTPRoute",
+			"metadata":   meta,
+			"spec":       spec,
+		})
+	}
+	return routes, nil
+}
+
+// hostSuffix makes a host usable in an object name.
+func hostSuffix(host string) string {
+	if host == "" {
+		return "default"
+	}
+	return strings.NewReplacer("*", "wildcard", ".", "-").Replace(host)
+}
+
+func backendRef(b ingressBackend) (map[string]interface{}, error) {
+	if b.Service == nil {
+		return nil, fmt.Errorf("only service backends can be converted")
+	}
+	if b.Service.Port.Number == 0 {
+		return nil, fmt.Errorf(
+			"backend service '%s' must have a port number; "+
+				"HTTPRoutes can't refer to ports by name", b.Service.Name)
+	}
+	return map[string]interface{}{
+		"name": b.Service.Name,
+		"port": b.Service.Port.Number,
+	}, nil
+}
+
+type tlsSecret struct {
+	hosts     []string
+	name      string
+	namespace string
+}
+
+// gateway returns the Gateway of the mapping, listening for
+// HTTP, and for HTTPS on each host having a TLS secret.
+func gateway(g GatewayMapping, secrets []tlsSecret) map[string]interface{} {
+	allowedRoutes := map[string]interface{}{
+		"namespaces": map[string]interface{}{"from": "All"},
+	}
+	listeners := []interface{}{
+		map[string]interface{}{
+			"name":          "http",
+			"protocol":      "HTTP",
+			"port":          80,
+			"allowedRoutes": allowedRoutes,
+		},
+	}
+	for i, s := range secrets {
+		ref := map[string]interface{}{"kind": "Secret", "name": s.name}
+		if s.namespace != "" && s.namespace != g.Namespace {
+			ref["namespace"] = s.namespace
+		}
+		hosts := s.hosts
+		if len(hosts) == 0 {
+			hosts = []string{""}
+		}
+		for j, h := range hosts {
+			l := map[string]interface{}{
+				"name":     fmt.Sprintf("https-%d-%d", i, j),
+				"protocol": "HTTPS",
+				"port":     443,
+				"tls": map[string]interface{}{
+					"mode":            "Terminate",
+					"certificateRefs": []interface{}{ref},
+				},
+				"allowedRoutes": allowedRoutes,
+			}
+			if h != "" {
+				l["hostname"] = h
+			}
+			listeners = append(listeners, l)
+		}
+	}
+	meta := map[string]interface{}{"name": g.Name}
+	if g.Namespace != "" {
+		meta["namespace"] = g.Namespace
+	}
+	return map[string]interface{}{
+		"apiVersion": gatewayAPIVersion,
+		"kind":       "Gateway",
+		"metadata":   meta,
+		"spec": map[string]interface{}{
+			"gatewayClassName": g.GatewayClassName,
+			"listeners":        listeners,
+		},
+	}
+}
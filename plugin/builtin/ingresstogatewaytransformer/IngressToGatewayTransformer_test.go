@@ -0,0 +1,274 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestIngressToGatewayTransformer(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("IngressToGatewayTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: IngressToGatewayTransformer
+metadata:
+  name: notImportantHere
+gateways:
+- ingressClassName: public
+  name: public
+  namespace: gateways
+  gatewayClassName: example
+`, `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  namespace: apps
+spec:
+  ingressClassName: public
+  tls:
+  - hosts:
+    - www.example.com
+    secretName: www-tls
+  rules:
+  - host: www.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: web
+            port:
+              number: 80
+      - path: /healthz
+        pathType: Exact
+        backend:
+          service:
+            name: health
+            port:
+              number: 8080
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: internal
+  namespace: apps
+  annotations:
+    kubernetes.io/ingress.class: internal
+spec:
+  defaultBackend:
+    service:
+      name: web
+      port:
+        number: 80
+`, `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  annotations:
+    kubernetes.io/ingress.class: internal
+  name: internal
+  namespace: apps
+spec:
+  defaultBackend:
+    service:
+      name: web
+      port:
+        number: 80
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: web
+  namespace: apps
+spec:
+  hostnames:
+  - www.example.com
+  parentRefs:
+  - name: public
+    namespace: gateways
+  rules:
+  - backendRefs:
+    - name: web
+      port: 80
+    matches:
+    - path:
+        type: PathPrefix
+        value: /
+  - backendRefs:
+    - name: health
+      port: 8080
+    matches:
+    - path:
+        type: Exact
+        value: /healthz
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: public
+  namespace: gateways
+spec:
+  gatewayClassName: example
+  listeners:
+  - allowedRoutes:
+      namespaces:
+        from: All
+    name: http
+    port: 80
+    protocol: HTTP
+  - allowedRoutes:
+      namespaces:
+        from: All
+    hostname: www.example.com
+    name: https-0-0
+    port: 443
+    protocol: HTTPS
+    tls:
+      certificateRefs:
+      - kind: Secret
+        name: www-tls
+        namespace: apps
+      mode: Terminate
+`)
+}
+
+func TestIngressToGatewayTransformerHosts(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("IngressToGatewayTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: IngressToGatewayTransformer
+metadata:
+  name: notImportantHere
+gateways:
+- name: shared
+keepIngresses: true
+`, `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: sites
+spec:
+  rules:
+  - host: a.example.com
+    http:
+      paths:
+      - backend:
+          service:
+            name: a
+            port:
+              number: 80
+  - host: "*.b.example.com"
+    http:
+      paths:
+      - path: /api
+        pathType: ImplementationSpecific
+        backend:
+          service:
+            name: b
+            port:
+              number: 80
+`, `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: sites
+spec:
+  rules:
+  - host: a.example.com
+    http:
+      paths:
+      - backend:
+          service:
+            name: a
+            port:
+              number: 80
+  - host: '*.b.example.com'
+    http:
+      paths:
+      - backend:
+          service:
+            name: b
+            port:
+              number: 80
+        path: /api
+        pathType: ImplementationSpecific
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: sites-a-example-com
+spec:
+  hostnames:
+  - a.example.com
+  parentRefs:
+  - name: shared
+  rules:
+  - backendRefs:
+    - name: a
+      port: 80
+    matches:
+    - path:
+        type: PathPrefix
+        value: /
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: sites-wildcard-b-example-com
+spec:
+  hostnames:
+  - '*.b.example.com'
+  parentRefs:
+  - name: shared
+  rules:
+  - backendRefs:
+    - name: b
+      port: 80
+    matches:
+    - path:
+        type: PathPrefix
+        value: /api
+`)
+}
+
+func TestIngressToGatewayTransformerNamedPort(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("IngressToGatewayTransformer")
+	defer th.Reset()
+
+	err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: IngressToGatewayTransformer
+metadata:
+  name: notImportantHere
+gateways:
+- name: shared
+`, `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  defaultBackend:
+    service:
+      name: web
+      port:
+        name: http
+`)
+	if err == nil || !strings.Contains(err.Error(),
+		"backend service 'web' must have a port number") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -13,8 +13,9 @@ import (
 
 // Add the given labels to the given field specifications.
 type plugin struct {
-	Labels     map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
-	FieldSpecs []types.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	Labels     map[string]string      `json:"labels,omitempty" yaml:"labels,omitempty"`
+	FieldSpecs []types.FieldSpec      `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	Options    *types.MetadataOptions `json:"options,omitempty" yaml:"options,omitempty"`
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -24,7 +25,11 @@ func (p *plugin) Config(
 	_ *resmap.PluginHelpers, c []byte) (err error) {
 	p.Labels = nil
 	p.FieldSpecs = nil
-	return yaml.Unmarshal(c, p)
+	p.Options = nil
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	return p.Options.Validate()
 }
 
 func (p *plugin) Transform(m resmap.ResMap) error {
@@ -35,6 +40,7 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 		err := r.ApplyFilter(labels.Filter{
 			Labels:  p.Labels,
 			FsSlice: p.FieldSpecs,
+			Options: p.Options,
 		})
 		if err != nil {
 			return err
@@ -6,9 +6,12 @@ package main
 
 import (
 	"fmt"
+	"log"
 
 	"sigs.k8s.io/kustomize/api/filters/namespace"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/yaml"
 )
@@ -17,6 +20,12 @@ import (
 type plugin struct {
 	types.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
 	FieldSpecs       []types.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	// ClusterScoped lists kinds declared cluster-scoped, e.g.
+	// in the crds field of a kustomization, whose instances
+	// the openapi data would have namespaced.
+	ClusterScoped []resid.Gvk `json:"clusterScoped,omitempty" yaml:"clusterScoped,omitempty"`
+	// Exclude selects resources to leave untouched.
+	Exclude []types.Selector `json:"exclude,omitempty" yaml:"exclude,omitempty"`
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -26,6 +35,8 @@ func (p *plugin) Config(
 	_ *resmap.PluginHelpers, c []byte) (err error) {
 	p.Namespace = ""
 	p.FieldSpecs = nil
+	p.ClusterScoped = nil
+	p.Exclude = nil
 	return yaml.Unmarshal(c, p)
 }
 
@@ -33,23 +44,62 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 	if len(p.Namespace) == 0 {
 		return nil
 	}
+	f := namespace.Filter{
+		Namespace:     p.Namespace,
+		FsSlice:       p.FieldSpecs,
+		ClusterScoped: append(p.ClusterScoped, clusterScopedCrds(m)...),
+	}
+	excluded := make(map[*resource.Resource]bool)
+	for _, sel := range p.Exclude {
+		matches, err := m.Select(sel)
+		if err != nil {
+			return err
+		}
+		for _, r := range matches {
+			excluded[r] = true
+		}
+	}
 	for _, r := range m.Resources() {
-		if r.IsEmpty() {
+		if r.IsEmpty() || excluded[r] {
 			// Don't mutate empty objects?
 			continue
 		}
+		if gvk := r.GetGvk(); gvk.IsNamespaceableKind() && !f.IsNamespaceable(gvk) {
+			log.Printf(
+				"Warning: not setting namespace %s on %s %s, which is declared cluster-scoped",
+				p.Namespace, gvk.StringWoEmptyField(), r.GetName())
+		}
 		r.StorePreviousId()
-		if err := r.ApplyFilter(namespace.Filter{
-			Namespace: p.Namespace,
-			FsSlice:   p.FieldSpecs,
-		}); err != nil {
+		if err := r.ApplyFilter(f); err != nil {
 			return err
 		}
-		matches := m.GetMatchingResourcesByCurrentId(r.CurId().Equals)
-		if len(matches) != 1 {
+		if _, err := m.GetByCurrentId(r.CurId()); err != nil {
 			return fmt.Errorf(
-				"namespace transformation produces ID conflict: %+v", matches)
+				"namespace transformation produces ID conflict: %v", err)
 		}
 	}
 	return nil
 }
+
+// clusterScopedCrds returns the kinds defined
+// by the cluster-scoped CustomResourceDefinitions in m.
+func clusterScopedCrds(m resmap.ResMap) (result []resid.Gvk) {
+	for _, r := range m.Resources() {
+		gvk := r.GetGvk()
+		if gvk.Group != "apiextensions.k8s.io" ||
+			gvk.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		scope, err := r.GetString("spec.scope")
+		if err != nil || scope != "Cluster" {
+			continue
+		}
+		group, _ := r.GetString("spec.group")
+		kind, err := r.GetString("spec.names.kind")
+		if err != nil || kind == "" {
+			continue
+		}
+		result = append(result, resid.Gvk{Group: group, Kind: kind})
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultDenyName = "default-deny"
+	allowDNSName    = "allow-dns"
+)
+
+// Add a default-deny NetworkPolicy, and one allowing DNS lookups,
+// to every namespace in the build, leaving alone the policies of
+// those names already there.  This is a transformer rather than
+// a generator as the namespaces are only known once the other
+// resources have been accumulated.
+type plugin struct {
+	// Namespaces lists the namespaces configured one by one,
+	// which get the policies even if nothing else is in them.
+	Namespaces []Namespace `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+
+	// Exclude lists the namespaces to leave without baseline policies.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+
+	// AllowDNS, if false, omits the policies allowing egress
+	// to the cluster's DNS service.  Defaults to true.
+	AllowDNS *bool `json:"allowDNS,omitempty" yaml:"allowDNS,omitempty"`
+
+	h *resmap.PluginHelpers
+}
+
+// Namespace is a namespace configured by itself.
+type Namespace struct {
+	Name string `json:"name" yaml:"name"`
+
+	// AllowDNS, if set, overrides the AllowDNS of the
+	// transformer for this namespace.
+	AllowDNS *bool `json:"allowDNS,omitempty" yaml:"allowDNS,omitempty"`
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Namespaces = nil
+	p.Exclude = nil
+	p.AllowDNS = nil
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	excluded := make(map[string]bool)
+	for _, ns := range p.Exclude {
+		excluded[ns] = true
+	}
+	seen := make(map[string]bool)
+	for i, ns := range p.Namespaces {
+		if ns.Name == "" {
+			return fmt.Errorf("namespace %d must specify a name", i)
+		}
+		if seen[ns.Name] {
+			return fmt.Errorf("namespace '%s' is listed more than once", ns.Name)
+		}
+		if excluded[ns.Name] {
+			return fmt.Errorf("namespace '%s' is both listed and excluded", ns.Name)
+		}
+		seen[ns.Name] = true
+	}
+	p.h = h
+	return nil
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	namespaces := make(map[string]bool)
+	allowDNS := make(map[string]*bool)
+	for _, ns := range p.Namespaces {
+		namespaces[ns.Name] = true
+		allowDNS[ns.Name] = ns.AllowDNS
+	}
+	// Policies already in the build take precedence.
+	existing := make(map[string]bool)
+	for _, r := range m.Resources() {
+		id := r.CurId()
+		switch {
+		case id.Group == "" && id.Kind == "Namespace":
+			namespaces[id.Name] = true
+		case r.GetNamespace() != "":
+			namespaces[r.GetNamespace()] = true
+			if id.Group == "networking.k8s.io" && id.Kind == "NetworkPolicy" {
+				existing[r.GetNamespace()+"/"+id.Name] = true
+			}
+		}
+	}
+	for _, ns := range p.Exclude {
+		delete(namespaces, ns)
+	}
+	var names []string
+	for ns := range namespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, ns := range names {
+		if !existing[ns+"/"+defaultDenyName] {
+			if err := p.write(&b, ns, defaultDenyName, defaultDenySpec); err != nil {
+				return err
+			}
+		}
+		allow := p.AllowDNS
+		if allowDNS[ns] != nil {
+			allow = allowDNS[ns]
+		}
+		if (allow == nil || *allow) && !existing[ns+"/"+allowDNSName] {
+			if err := p.write(&b, ns, allowDNSName, allowDNSSpec); err != nil {
+				return err
+			}
+		}
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	policies, err := p.h.ResmapFactory().NewResMapFromBytes([]byte(b.String()))
+	if err != nil {
+		return err
+	}
+	return m.AppendAll(policies)
+}
+
+var defaultDenySpec = map[string]interface{}{
+	"podSelector": map[string]interface{}{},
+	"policyTypes": []string{"Ingress", "Egress"},
+}
+
+var allowDNSSpec = map[string]interface{}{
+	"podSelector": map[string]interface{}{},
+	"policyTypes": []string{"Egress"},
+	"egress": []interface{}{
+		map[string]interface{}{
+			"to": []interface{}{
+				map[string]interface{}{
+					"namespaceSelector": map[string]interface{}{
+						"matchLabels": map[string]string{
+							"kubernetes.io/metadata.name": "kube-system",
+						},
+					},
+					"podSelector": map[string]interface{}{
+						"matchLabels": map[string]string{
+							"k8s-app": "kube-dns",
+						},
+					},
+				},
+			},
+			"ports": []interface{}{
+				map[string]interface{}{"port": 53, "protocol": "UDP"},
+				map[string]interface{}{"port": 53, "protocol": "TCP"},
+			},
+		},
+	},
+}
+
+func (p *plugin) write(
+	b *strings.Builder, ns, name string, spec map[string]interface{}) error {
+	y, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": ns,
+		},
+		"spec": spec,
+	})
+	if err != nil {
+		return fmt.Errorf("making %s NetworkPolicy for %s: %v", name, ns, err)
+	}
+	b.WriteString("---\n")
+	b.Write(y)
+	return nil
+}
@@ -0,0 +1,299 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+const allowDNS = `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-dns
+  namespace: web
+spec:
+  egress:
+  - ports:
+    - port: 53
+      protocol: UDP
+    - port: 53
+      protocol: TCP
+    to:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: kube-system
+      podSelector:
+        matchLabels:
+          k8s-app: kube-dns
+  podSelector: {}
+  policyTypes:
+  - Egress
+`
+
+func TestNetworkPolicyBaselineTransformer(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("NetworkPolicyBaselineTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: NetworkPolicyBaselineTransformer
+metadata:
+  name: notImportantHere
+exclude:
+- legacy
+`, `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: api
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-dns
+  namespace: api
+spec:
+  podSelector: {}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: legacy
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+`, `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: api
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-dns
+  namespace: api
+spec:
+  podSelector: {}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: legacy
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: api
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  - Egress
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: web
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  - Egress
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-dns
+  namespace: web
+spec:
+  egress:
+  - ports:
+    - port: 53
+      protocol: UDP
+    - port: 53
+      protocol: TCP
+    to:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: kube-system
+      podSelector:
+        matchLabels:
+          k8s-app: kube-dns
+  podSelector: {}
+  policyTypes:
+  - Egress
+`)
+}
+
+func TestNetworkPolicyBaselineTransformerNoDNS(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("NetworkPolicyBaselineTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: NetworkPolicyBaselineTransformer
+metadata:
+  name: notImportantHere
+allowDNS: false
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: api
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: api
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: api
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  - Egress
+`)
+}
+
+func TestNetworkPolicyBaselineTransformerNamespaces(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("NetworkPolicyBaselineTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: NetworkPolicyBaselineTransformer
+metadata:
+  name: notImportantHere
+allowDNS: false
+namespaces:
+- name: web
+  allowDNS: true
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: api
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: api
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: api
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  - Egress
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: web
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  - Egress
+---`+allowDNS)
+}
+
+func TestNetworkPolicyBaselineTransformerErrors(t *testing.T) {
+	testCases := map[string]struct {
+		config string
+		errMsg string
+	}{
+		"unnamed": {
+			config: `
+namespaces:
+- allowDNS: false
+`,
+			errMsg: "namespace 0 must specify a name",
+		},
+		"duplicate": {
+			config: `
+namespaces:
+- name: web
+- name: web
+`,
+			errMsg: "namespace 'web' is listed more than once",
+		},
+		"excluded": {
+			config: `
+namespaces:
+- name: web
+exclude:
+- web
+`,
+			errMsg: "namespace 'web' is both listed and excluded",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			th := kusttest_test.MakeEnhancedHarness(t).
+				PrepBuiltin("NetworkPolicyBaselineTransformer")
+			defer th.Reset()
+
+			err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: NetworkPolicyBaselineTransformer
+metadata:
+  name: notImportantHere
+`+tc.config, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: api
+`)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Fatalf("expected error containing %q, got %v", tc.errMsg, err)
+			}
+		})
+	}
+}
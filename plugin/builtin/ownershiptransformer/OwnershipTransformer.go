@@ -0,0 +1,146 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"sigs.k8s.io/kustomize/api/filters/labels"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Label resources with the teams owning the files they were read
+// from, per a mapping of paths to teams like a CODEOWNERS file, so
+// that the output carries ownership metadata, e.g. for alert routing.
+// Resources not read from files, e.g. generated ones, aren't labeled.
+type plugin struct {
+	// Owners map paths to the teams owning the files under them.
+	// As in a CODEOWNERS file, the last one matching a file applies.
+	Owners []Ownership `json:"owners,omitempty" yaml:"owners,omitempty"`
+
+	// TeamLabel and OwnerLabel are the labels set,
+	// team and owner by default.
+	TeamLabel  string `json:"teamLabel,omitempty" yaml:"teamLabel,omitempty"`
+	OwnerLabel string `json:"ownerLabel,omitempty" yaml:"ownerLabel,omitempty"`
+
+	// FieldSpecs are the fields the labels are set in,
+	// metadata/labels by default.
+	FieldSpecs []types.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+
+	// root is the directory of the kustomization,
+	// which the paths are relative to.
+	root string
+}
+
+// Ownership gives the team, and optionally the owner,
+// of the files and directories matching a path.
+type Ownership struct {
+	// Path is relative to the kustomization, e.g. ../services/db,
+	// and may be a pattern, per filepath.Match, e.g.
+	// ../services/*/db.  It matches the files under a
+	// directory it matches.
+	Path  string `json:"path" yaml:"path"`
+	Team  string `json:"team,omitempty" yaml:"team,omitempty"`
+	Owner string `json:"owner,omitempty" yaml:"owner,omitempty"`
+}
+
+// labelValue matches the values labels may have.
+var labelValue = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Owners = nil
+	p.TeamLabel = ""
+	p.OwnerLabel = ""
+	p.FieldSpecs = nil
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	p.root = h.Loader().Root()
+	if p.TeamLabel == "" {
+		p.TeamLabel = "team"
+	}
+	if p.OwnerLabel == "" {
+		p.OwnerLabel = "owner"
+	}
+	if len(p.FieldSpecs) == 0 {
+		p.FieldSpecs = []types.FieldSpec{
+			{Path: "metadata/labels", CreateIfNotPresent: true}}
+	}
+	for i := range p.Owners {
+		o := &p.Owners[i]
+		if o.Path == "" {
+			return fmt.Errorf("owners entry %d must have a path", i+1)
+		}
+		if filepath.IsAbs(o.Path) {
+			return fmt.Errorf("owners path '%s' must be relative", o.Path)
+		}
+		o.Path = filepath.Clean(o.Path)
+		if _, err = filepath.Match(o.Path, ""); err != nil {
+			return fmt.Errorf("owners path '%s' is a bad pattern", o.Path)
+		}
+		if o.Team == "" && o.Owner == "" {
+			return fmt.Errorf("owners path '%s' must have a team or an owner", o.Path)
+		}
+		for _, v := range []string{o.Team, o.Owner} {
+			if len(v) > 63 || !labelValue.MatchString(v) {
+				return fmt.Errorf(
+					"'%s', an owner of path '%s', isn't a valid label value", v, o.Path)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	for _, r := range m.Resources() {
+		o := p.ownership(r.Origin())
+		if o == nil {
+			continue
+		}
+		l := make(map[string]string)
+		if o.Team != "" {
+			l[p.TeamLabel] = o.Team
+		}
+		if o.Owner != "" {
+			l[p.OwnerLabel] = o.Owner
+		}
+		err := r.ApplyFilter(labels.Filter{
+			Labels:  l,
+			FsSlice: p.FieldSpecs,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownership returns the last of the owners matching
+// the file at origin, or nil if none does.
+func (p *plugin) ownership(origin string) *Ownership {
+	if origin == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(p.root, origin)
+	if err != nil {
+		return nil
+	}
+	for i := len(p.Owners) - 1; i >= 0; i-- {
+		for path := rel; path != "."; path = filepath.Dir(path) {
+			if ok, _ := filepath.Match(p.Owners[i].Path, path); ok {
+				return &p.Owners[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/provider"
+	"sigs.k8s.io/kustomize/api/resmap"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+const owners = `
+apiVersion: builtin
+kind: OwnershipTransformer
+metadata:
+  name: notImportantHere
+owners:
+- path: "*"
+  team: platform
+- path: services/*/db
+  team: dba
+- path: services/payments
+  team: payments
+  owner: alice
+- path: ../shared
+  team: shared
+`
+
+func TestOwnershipTransformer(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("OwnershipTransformer")
+	defer th.Reset()
+	th.ResetLoaderRoot("/app")
+
+	rf := resmap.NewFactory(provider.NewDefaultDepProvider().GetResourceFactory())
+	m, err := rf.NewResMapFromBytes([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: top
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: payments
+  labels:
+    app: payments
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: payments-db
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: search-db
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: shared
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: generated
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, origin := range []string{
+		"kustomization.yaml",
+		"services/payments/deployment.yaml",
+		"services/payments/db/statefulset.yaml",
+		"services/search/db/service.yaml",
+		"../shared/secret.yaml",
+		"",
+	} {
+		if origin != "" {
+			origin = filepath.Join(th.GetRoot(), origin)
+		}
+		m.Resources()[i].SetOrigin(origin)
+	}
+	m, err = th.RunTransformerFromResMap(owners, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	th.AssertActualEqualsExpectedNoIdAnnotations(m, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    team: platform
+  name: top
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: payments
+    owner: alice
+    team: payments
+  name: payments
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  labels:
+    owner: alice
+    team: payments
+  name: payments-db
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    team: dba
+  name: search-db
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  labels:
+    team: shared
+  name: shared
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: generated
+`)
+}
+
+func TestOwnershipTransformerErrors(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("OwnershipTransformer")
+	defer th.Reset()
+
+	for config, expected := range map[string]string{
+		"owners:\n- team: a\n":                    "owners entry 1 must have a path",
+		"owners:\n- path: /abs\n  team: a\n":      "owners path '/abs' must be relative",
+		"owners:\n- path: a[\n  team: a\n":        "owners path 'a[' is a bad pattern",
+		"owners:\n- path: a\n":                    "owners path 'a' must have a team or an owner",
+		"owners:\n- path: a\n  owner: '@alice'\n": "'@alice', an owner of path 'a', isn't a valid label value",
+	} {
+		err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: OwnershipTransformer
+metadata:
+  name: notImportantHere
+`+config, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+`)
+		if err == nil {
+			t.Fatalf("expected an error for:\n%s", config)
+		}
+		if !strings.HasSuffix(err.Error(), expected) {
+			t.Fatalf("expected error %q, got %q", expected, err)
+		}
+	}
+}
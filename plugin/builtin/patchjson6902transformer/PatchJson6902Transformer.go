@@ -70,7 +70,10 @@ func (p *plugin) Config(
 		return fmt.Errorf(
 			"patch appears to be empty; file=%s, JsonOp=%s", p.Path, p.JsonOp)
 	}
-	return err
+	if err = patchjson6902.ValidatePaths(p.decodedPatch); err != nil {
+		return errors.Wrapf(err, "in the patch %s", p.JsonOp)
+	}
+	return nil
 }
 
 func (p *plugin) Transform(m resmap.ResMap) error {
@@ -79,6 +79,33 @@ jsonOp: 'thisIsNotAPatch'
 	})
 }
 
+func TestUnescapedPathJson6902Transformer(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("PatchJson6902Transformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckError(`
+apiVersion: builtin
+kind: PatchJson6902Transformer
+metadata:
+  name: notImportantHere
+target:
+  group: apps
+  version: v1
+  kind: Deployment
+  name: myDeploy
+jsonOp: '[{"op": "add", "path": "/metadata/annotations/a~b", "value": "x"}]'
+`, target, func(t *testing.T, err error) {
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+		if !strings.Contains(err.Error(),
+			`the path of operation 0: "/metadata/annotations/a~b" has a ~ not followed by 0 or 1`) {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	})
+}
+
 func TestBothEmptyJson6902Transformer(t *testing.T) {
 	th := kusttest_test.MakeEnhancedHarness(t).
 		PrepBuiltin("PatchJson6902Transformer")
@@ -17,6 +17,9 @@ type plugin struct {
 	loadedPatches []*resource.Resource
 	Paths         []types.PatchStrategicMerge `json:"paths,omitempty" yaml:"paths,omitempty"`
 	Patches       string                      `json:"patches,omitempty" yaml:"patches,omitempty"`
+	// KeepComments copies the comments of the patches
+	// to the fields of their targets that have none.
+	KeepComments bool `json:"keepComments,omitempty" yaml:"keepComments,omitempty"`
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -49,6 +52,11 @@ func (p *plugin) Config(
 		return fmt.Errorf(
 			"patch appears to be empty; files=%v, Patch=%s", p.Paths, p.Patches)
 	}
+	if p.KeepComments {
+		for _, patch := range p.loadedPatches {
+			patch.SetKeepComments()
+		}
+	}
 	return nil
 }
 
@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	jsonpatch "github.com/evanphx/json-patch"
+	"sigs.k8s.io/kustomize/api/filters/fielddelete"
+	"sigs.k8s.io/kustomize/api/filters/patchembedded"
 	"sigs.k8s.io/kustomize/api/filters/patchjson6902"
 	"sigs.k8s.io/kustomize/api/resmap"
 	"sigs.k8s.io/kustomize/api/resource"
@@ -17,12 +19,20 @@ import (
 )
 
 type plugin struct {
-	loadedPatch  *resource.Resource
-	decodedPatch jsonpatch.Patch
-	Path         string          `json:"path,omitempty" yaml:"path,omitempty"`
-	Patch        string          `json:"patch,omitempty" yaml:"patch,omitempty"`
-	Target       *types.Selector `json:"target,omitempty" yaml:"target,omitempty"`
-	Options      map[string]bool `json:"options,omitempty" yaml:"options,omitempty"`
+	// loadedPatches are the documents of a strategic
+	// merge patch, applied in order.
+	loadedPatches []*resource.Resource
+	decodedPatch  jsonpatch.Patch
+	Path          string           `json:"path,omitempty" yaml:"path,omitempty"`
+	Patch         string           `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Target        *types.Selector  `json:"target,omitempty" yaml:"target,omitempty"`
+	Options       map[string]bool  `json:"options,omitempty" yaml:"options,omitempty"`
+	MergeLists    types.MergeLists `json:"mergeLists,omitempty" yaml:"mergeLists,omitempty"`
+	Delete        []string         `json:"delete,omitempty" yaml:"delete,omitempty"`
+	DataKey       string           `json:"dataKey,omitempty" yaml:"dataKey,omitempty"`
+	// KeepComments copies the comments of a strategic merge
+	// patch to the fields of its targets that have none.
+	KeepComments bool `json:"keepComments,omitempty" yaml:"keepComments,omitempty"`
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -35,7 +45,7 @@ func (p *plugin) Config(
 		return err
 	}
 	p.Patch = strings.TrimSpace(p.Patch)
-	if p.Patch == "" && p.Path == "" {
+	if p.Patch == "" && p.Path == "" && len(p.Delete) == 0 {
 		return fmt.Errorf(
 			"must specify one of patch and path in\n%s", string(c))
 	}
@@ -50,11 +60,47 @@ func (p *plugin) Config(
 		}
 		p.Patch = string(loaded)
 	}
+	switch p.MergeLists {
+	case "", types.MergeListsAppend, types.MergeListsByKey, types.MergeListsReplace:
+	default:
+		return fmt.Errorf(
+			"unknown mergeLists %q, expected one of %s, %s or %s",
+			p.MergeLists, types.MergeListsAppend, types.MergeListsByKey,
+			types.MergeListsReplace)
+	}
+	if p.DataKey != "" {
+		// The patch is of a document in the data
+		// of the targets, not of a resource.
+		if p.Target == nil {
+			return fmt.Errorf(
+				"must specify a target for patch of data key %s", p.DataKey)
+		}
+		if p.MergeLists != "" {
+			return fmt.Errorf(
+				"mergeLists doesn't apply to patches of data keys")
+		}
+		if len(p.Delete) > 0 {
+			return fmt.Errorf(
+				"delete doesn't apply to patches of data keys")
+		}
+		return nil
+	}
+	if p.Patch == "" && len(p.Delete) > 0 {
+		// The patch only deletes fields.
+		if p.Target == nil {
+			return fmt.Errorf("must specify a target for patch deleting fields")
+		}
+		if p.MergeLists != "" {
+			return fmt.Errorf(
+				"mergeLists only applies to strategic merge patches")
+		}
+		return nil
+	}
 
-	patchSM, errSM := h.ResmapFactory().RF().FromBytes([]byte(p.Patch))
+	patchesSM, errSM := smPatchesFromBytes(h, []byte(p.Patch))
 	patchJson, errJson := jsonPatchFromBytes([]byte(p.Patch))
 	if (errSM == nil && errJson == nil) ||
-		(patchSM != nil && patchJson != nil) {
+		(patchesSM != nil && patchJson != nil) {
 		return fmt.Errorf(
 			"illegally qualifies as both an SM and JSON patch: [%v]",
 			p.Patch)
@@ -64,68 +110,251 @@ func (p *plugin) Config(
 			"unable to parse SM or JSON patch from [%v]", p.Patch)
 	}
 	if errSM == nil {
-		p.loadedPatch = patchSM
-		if p.Options["allowNameChange"] {
-			p.loadedPatch.SetAllowNameChange("true")
-		}
-		if p.Options["allowKindChange"] {
-			p.loadedPatch.SetAllowKindChange("true")
+		p.loadedPatches = patchesSM
+		for _, patch := range p.loadedPatches {
+			if p.Options["allowNameChange"] {
+				patch.SetAllowNameChange("true")
+			}
+			if p.Options["allowKindChange"] {
+				patch.SetAllowKindChange("true")
+			}
+			if p.MergeLists != "" {
+				patch.SetMergeLists(p.MergeLists)
+			}
+			if p.KeepComments {
+				patch.SetKeepComments()
+			}
 		}
 	} else {
+		if p.MergeLists != "" {
+			return fmt.Errorf(
+				"mergeLists only applies to strategic merge patches, not to\n%s",
+				p.Patch)
+		}
+		if err := patchjson6902.ValidatePaths(patchJson); err != nil {
+			return err
+		}
 		p.decodedPatch = patchJson
 	}
 	return nil
 }
 
 func (p *plugin) Transform(m resmap.ResMap) error {
-	if p.loadedPatch == nil {
+	if p.DataKey != "" {
+		return p.transformEmbedded(m)
+	}
+	if p.Patch == "" || p.loadedPatches == nil {
 		return p.transformJson6902(m, p.decodedPatch)
-	} else {
+	}
+	if len(p.Delete) == 0 {
 		// The patch was a strategic merge patch
-		return p.transformStrategicMerge(m, p.loadedPatch)
+		return p.transformStrategicMerge(m)
+	}
+	// Selected before the patch may change their names.
+	resources, err := p.Targets(m)
+	if err != nil {
+		return err
+	}
+	if err = p.transformStrategicMerge(m); err != nil {
+		return err
+	}
+	for _, res := range resources {
+		if err = p.deleteFields(res); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-// transformStrategicMerge applies the provided strategic merge patch
-// to all the resources in the ResMap that match either the Target or
-// the identifier of the patch.
-func (p *plugin) transformStrategicMerge(m resmap.ResMap, patch *resource.Resource) error {
+// transformStrategicMerge applies the documents of the strategic
+// merge patch, in order, to all the resources in the ResMap that
+// match the Target, or, if there is none, each document to the
+// resource matching its identifier.
+func (p *plugin) transformStrategicMerge(m resmap.ResMap) error {
 	if p.Target == nil {
-		target, err := m.GetById(patch.OrgId())
-		if err != nil {
-			return err
+		for _, patch := range p.loadedPatches {
+			target, err := m.GetById(patch.OrgId())
+			if err != nil {
+				return err
+			}
+			if err = target.ApplySmPatch(patch); err != nil {
+				return err
+			}
 		}
-		return target.ApplySmPatch(patch)
+		return nil
 	}
 	selected, err := m.Select(*p.Target)
 	if err != nil {
 		return err
 	}
-	return m.ApplySmPatch(resource.MakeIdSet(selected), patch)
+	for _, patch := range p.loadedPatches {
+		if err = m.ApplySmPatch(resource.MakeIdSet(selected), patch); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // transformJson6902 applies the provided json6902 patch
 // to all the resources in the ResMap that match the Target.
 func (p *plugin) transformJson6902(m resmap.ResMap, patch jsonpatch.Patch) error {
-	if p.Target == nil {
-		return fmt.Errorf("must specify a target for patch %s", p.Patch)
+	resources, err := p.Targets(m)
+	if err != nil {
+		return err
 	}
+	for _, res := range resources {
+		if err = p.PatchResource(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformEmbedded applies the patch to the document
+// held by the DataKey of every resource matching the Target.
+func (p *plugin) transformEmbedded(m resmap.ResMap) error {
 	resources, err := m.Select(*p.Target)
 	if err != nil {
 		return err
 	}
 	for _, res := range resources {
+		if err = p.PatchResource(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Targets returns the resources of m the patch applies to.
+func (p *plugin) Targets(m resmap.ResMap) ([]*resource.Resource, error) {
+	if p.Target != nil {
+		return m.Select(*p.Target)
+	}
+	if p.loadedPatches == nil {
+		return nil, fmt.Errorf("must specify a target for patch %s", p.Patch)
+	}
+	var result []*resource.Resource
+	for _, patch := range p.loadedPatches {
+		target, err := m.GetById(patch.OrgId())
+		if err != nil {
+			return nil, err
+		}
+		if !containsResource(result, target) {
+			result = append(result, target)
+		}
+	}
+	return result, nil
+}
+
+func containsResource(resources []*resource.Resource, res *resource.Resource) bool {
+	for _, r := range resources {
+		if r == res {
+			return true
+		}
+	}
+	return false
+}
+
+// isTargetOf returns whether res is the resource the
+// identifier of the patch document names, as ResMap.GetById
+// finds it.
+func isTargetOf(patch, res *resource.Resource) bool {
+	for _, id := range append(res.PrevIds(), res.CurId()) {
+		if patch.OrgId().Equals(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// PatchResource applies the patch to res, one of its Targets.
+// It only reads the patch, so it may be called for many
+// targets at once.
+func (p *plugin) PatchResource(res *resource.Resource) error {
+	if err := p.patchResource(res); err != nil {
+		return err
+	}
+	return p.deleteFields(res)
+}
+
+// deleteFields deletes the field paths of Delete from res.
+func (p *plugin) deleteFields(res *resource.Resource) error {
+	if len(p.Delete) == 0 {
+		return nil
+	}
+	if err := res.ApplyFilter(fielddelete.Filter{
+		FieldPaths: p.Delete,
+	}); err != nil {
+		return fmt.Errorf("patching %s: %v", res.CurId(), err)
+	}
+	return nil
+}
+
+func (p *plugin) patchResource(res *resource.Resource) error {
+	switch {
+	case p.Patch == "":
+		return nil
+	case p.DataKey != "":
+		format := patchembedded.FormatYaml
+		if strings.HasSuffix(p.DataKey, ".json") {
+			format = patchembedded.FormatJson
+		}
+		if err := res.ApplyFilter(patchembedded.Filter{
+			FieldPath: []string{"data", p.DataKey},
+			Patch:     p.Patch,
+			Format:    format,
+		}); err != nil {
+			return fmt.Errorf(
+				"patching data key %s of %s: %v", p.DataKey, res.CurId(), err)
+		}
+		return nil
+	case p.loadedPatches == nil:
 		res.StorePreviousId()
-		err = res.ApplyFilter(patchjson6902.Filter{
+		return res.ApplyFilter(patchjson6902.Filter{
 			Patch: p.Patch,
 		})
-		if err != nil {
+	}
+	// Matched before any document may rename res.
+	var patches []*resource.Resource
+	for _, loaded := range p.loadedPatches {
+		if p.Target != nil || isTargetOf(loaded, res) {
+			patches = append(patches, loaded)
+		}
+	}
+	for _, loaded := range patches {
+		if p.Target == nil {
+			if err := res.ApplySmPatch(loaded); err != nil {
+				return err
+			}
+			continue
+		}
+		// As ResMap.ApplySmPatch does, patch with a copy of
+		// the patch taking the Gvk of res.
+		patch := loaded.DeepCopy()
+		patch.CopyMergeMetaDataFieldsFrom(loaded)
+		patch.SetGvk(res.GetGvk())
+		patch.SetKind(loaded.GetKind())
+		if err := res.ApplySmPatch(patch); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// smPatchesFromBytes loads the documents of a
+// strategic merge patch from a bytes input.
+func smPatchesFromBytes(
+	h *resmap.PluginHelpers, in []byte) ([]*resource.Resource, error) {
+	patches, err := h.ResmapFactory().RF().SliceFromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no strategic merge patch documents in %s", in)
+	}
+	return patches, nil
+}
+
 // jsonPatchFromBytes loads a Json 6902 patch from
 // a bytes input
 func jsonPatchFromBytes(
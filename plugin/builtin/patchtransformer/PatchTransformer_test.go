@@ -276,6 +276,164 @@ spec:
 `)
 }
 
+func TestPatchTransformerMultipleDocuments(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("PatchTransformer")
+	defer th.Reset()
+
+	th.WriteF("patches.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myDeploy
+spec:
+  replica: 3
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: yourDeploy
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.21
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myDeploy
+spec:
+  replica: 4
+`)
+
+	// Each document patches the resource it names, in order.
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: PatchTransformer
+metadata:
+  name: notImportantHere
+path: patches.yaml
+`,
+		someDeploymentResources,
+		`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    old-label: old-value
+  name: myDeploy
+spec:
+  replica: 4
+  template:
+    metadata:
+      labels:
+        old-label: old-value
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    new-label: new-value
+  name: yourDeploy
+spec:
+  replica: 1
+  template:
+    metadata:
+      labels:
+        new-label: new-value
+    spec:
+      containers:
+      - image: nginx:1.21
+        name: nginx
+---
+apiVersion: apps/v1
+kind: MyKind
+metadata:
+  label:
+    old-label: old-value
+  name: myDeploy
+spec:
+  template:
+    metadata:
+      labels:
+        old-label: old-value
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+`)
+
+	// With a target, all the documents patch it, in order.
+	th.RunTransformerAndCheckResult(`
+apiVersion: builtin
+kind: PatchTransformer
+metadata:
+  name: notImportantHere
+path: patches.yaml
+target:
+  kind: MyKind
+`,
+		someDeploymentResources,
+		`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    old-label: old-value
+  name: myDeploy
+spec:
+  replica: 2
+  template:
+    metadata:
+      labels:
+        old-label: old-value
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    new-label: new-value
+  name: yourDeploy
+spec:
+  replica: 1
+  template:
+    metadata:
+      labels:
+        new-label: new-value
+    spec:
+      containers:
+      - image: nginx:1.7.9
+        name: nginx
+---
+apiVersion: apps/v1
+kind: MyKind
+metadata:
+  label:
+    old-label: old-value
+  name: myDeploy
+spec:
+  replica: 4
+  template:
+    metadata:
+      labels:
+        old-label: old-value
+    spec:
+      containers:
+      - image: nginx:1.21
+        name: nginx
+`)
+}
+
 func TestPatchTransformerSmpSidecars(t *testing.T) {
 	th := kusttest_test.MakeEnhancedHarness(t).
 		PrepBuiltin("PatchTransformer")
@@ -828,3 +986,125 @@ spec:
           protocol: TCP
 `)
 }
+
+const pipelineResource = `
+apiVersion: ci.example.com/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  stages:
+  - name: compile
+  - name: test
+    parallel: true
+  args:
+  - --fast
+`
+
+func mergeListsPatchConfig(mergeLists string) string {
+	return `
+apiVersion: builtin
+kind: PatchTransformer
+metadata:
+  name: notImportantHere
+patch: |-
+  apiVersion: ci.example.com/v1
+  kind: Pipeline
+  metadata:
+    name: build
+  spec:
+    stages:
+    - name: test
+      timeout: 10m
+    args:
+    - --verbose
+mergeLists: ` + mergeLists + `
+`
+}
+
+func TestPatchTransformerMergeLists(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("PatchTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckResult(
+		mergeListsPatchConfig("append"), pipelineResource, `
+apiVersion: ci.example.com/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  args:
+  - --fast
+  - --verbose
+  stages:
+  - name: compile
+  - name: test
+    parallel: true
+  - name: test
+    timeout: 10m
+`)
+	th.RunTransformerAndCheckResult(
+		mergeListsPatchConfig("merge-by-key"), pipelineResource, `
+apiVersion: ci.example.com/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  args:
+  - --verbose
+  stages:
+  - name: test
+    parallel: true
+    timeout: 10m
+  - name: compile
+`)
+	th.RunTransformerAndCheckResult(
+		mergeListsPatchConfig("replace"), pipelineResource, `
+apiVersion: ci.example.com/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  args:
+  - --verbose
+  stages:
+  - name: test
+    timeout: 10m
+`)
+	th.RunTransformerAndCheckError(
+		mergeListsPatchConfig("zip"), pipelineResource,
+		func(t *testing.T, err error) {
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), `unknown mergeLists "zip"`) {
+				t.Fatalf("unexpected err: %v", err)
+			}
+		})
+}
+
+func TestPatchTransformerMergeListsJson(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("PatchTransformer")
+	defer th.Reset()
+
+	th.RunTransformerAndCheckError(`
+apiVersion: builtin
+kind: PatchTransformer
+metadata:
+  name: notImportantHere
+target:
+  kind: Pipeline
+patch: '[{"op": "add", "path": "/spec/args/-", "value": "--verbose"}]'
+mergeLists: append
+`, pipelineResource, func(t *testing.T, err error) {
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+		if !strings.Contains(err.Error(),
+			"mergeLists only applies to strategic merge patches") {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	})
+}
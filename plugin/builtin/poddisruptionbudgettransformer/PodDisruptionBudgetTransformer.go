@@ -0,0 +1,258 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/types"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Add a PodDisruptionBudget for each selected Deployment and
+// StatefulSet that doesn't have one yet, selecting the workload's
+// pods as the workload itself does.  The budget is named after the
+// workload and its kind, e.g. web-deployment, so that those of
+// workloads of either kind sharing a name don't collide.
+type plugin struct {
+	// Selectors select the workloads to protect.
+	// If empty, all Deployments and StatefulSets are.
+	Selectors []types.Selector `json:"selectors,omitempty" yaml:"selectors,omitempty"`
+
+	// MinAvailable is the number or percentage of pods
+	// that must remain available.
+	MinAvailable IntOrString `json:"minAvailable,omitempty" yaml:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of pods that
+	// may be unavailable.  Defaults to 1 if MinAvailable is empty.
+	MaxUnavailable IntOrString `json:"maxUnavailable,omitempty" yaml:"maxUnavailable,omitempty"`
+
+	h *resmap.PluginHelpers
+}
+
+// IntOrString holds a number, e.g. 1, or a percentage, e.g. "50%".
+type IntOrString string
+
+func (v *IntOrString) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*v = IntOrString(s)
+		return nil
+	}
+	if _, err := strconv.Atoi(string(b)); err != nil {
+		return fmt.Errorf("expected an integer or a percentage, got %s", b)
+	}
+	*v = IntOrString(b)
+	return nil
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Selectors = nil
+	p.MinAvailable = ""
+	p.MaxUnavailable = ""
+	if err = yaml.Unmarshal(c, p); err != nil {
+		return err
+	}
+	if p.MinAvailable != "" && p.MaxUnavailable != "" {
+		return fmt.Errorf("only one of minAvailable and maxUnavailable may be specified")
+	}
+	if p.MinAvailable == "" && p.MaxUnavailable == "" {
+		p.MaxUnavailable = "1"
+	}
+	p.h = h
+	return nil
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	workloads, err := p.selectWorkloads(m)
+	if err != nil {
+		return err
+	}
+	var budgets []*resource.Resource
+	for _, r := range m.Resources() {
+		if r.GetKind() == "PodDisruptionBudget" && r.GetGvk().Group == "policy" {
+			budgets = append(budgets, r)
+		}
+	}
+	for _, w := range workloads {
+		selector, labels, err := podSelector(w)
+		if err != nil {
+			return err
+		}
+		if selector == nil || hasBudget(budgets, w, labels) {
+			continue
+		}
+		pdb, err := p.makeBudget(w, selector)
+		if err != nil {
+			return err
+		}
+		if _, err = m.GetById(pdb.OrgId()); err == nil {
+			return fmt.Errorf(
+				"the PodDisruptionBudget %s of %s is already a resource, "+
+					"not selecting the workload's pods", pdb.GetName(), w.CurId())
+		}
+		if err = m.Append(pdb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *plugin) selectWorkloads(m resmap.ResMap) ([]*resource.Resource, error) {
+	candidates := m.Resources()
+	if len(p.Selectors) > 0 {
+		candidates = nil
+		for _, s := range p.Selectors {
+			selected, err := m.Select(s)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, selected...)
+		}
+	}
+	var result []*resource.Resource
+	seen := make(map[*resource.Resource]bool)
+	for _, r := range candidates {
+		gvk := r.GetGvk()
+		if seen[r] || gvk.Group != "apps" ||
+			(gvk.Kind != "Deployment" && gvk.Kind != "StatefulSet") {
+			continue
+		}
+		seen[r] = true
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// podSelector returns the label selector of the workload's pods,
+// derived from the pod template labels if the workload has no
+// selector, along with the pod template labels.
+func podSelector(w *resource.Resource) (*kyaml.RNode, map[string]string, error) {
+	labels, err := stringMap(
+		w.Node(), "spec", "template", kyaml.MetadataField, kyaml.LabelsField)
+	if err != nil {
+		return nil, nil, err
+	}
+	selector, err := w.Node().Pipe(kyaml.Lookup("spec", "selector"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if selector != nil {
+		return selector.Copy(), labels, nil
+	}
+	if len(labels) == 0 {
+		return nil, labels, nil
+	}
+	selector = kyaml.NewMapRNode(nil)
+	if err = selector.PipeE(
+		kyaml.SetField("matchLabels", kyaml.NewMapRNode(&labels))); err != nil {
+		return nil, nil, err
+	}
+	return selector, labels, nil
+}
+
+// hasBudget reports whether one of the budgets, all of which
+// must be PodDisruptionBudgets, selects the workload's pods.
+func hasBudget(budgets []*resource.Resource, w *resource.Resource, labels map[string]string) bool {
+	for _, b := range budgets {
+		if !b.CurId().IsNsEquals(w.CurId()) {
+			continue
+		}
+		matchLabels, err := stringMap(b.Node(), "spec", "selector", "matchLabels")
+		if err != nil || len(matchLabels) == 0 {
+			continue
+		}
+		matches := true
+		for k, v := range matchLabels {
+			if labels[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *plugin) makeBudget(
+	w *resource.Resource, selector *kyaml.RNode) (*resource.Resource, error) {
+	pdb, err := kyaml.Parse(`
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+spec: {}
+`)
+	if err != nil {
+		return nil, err
+	}
+	if err = pdb.SetName(budgetName(w)); err != nil {
+		return nil, err
+	}
+	if ns := w.GetNamespace(); ns != "" {
+		if err = pdb.SetNamespace(ns); err != nil {
+			return nil, err
+		}
+	}
+	field, value := "maxUnavailable", p.MaxUnavailable
+	if p.MinAvailable != "" {
+		field, value = "minAvailable", p.MinAvailable
+	}
+	if err = pdb.PipeE(kyaml.Lookup("spec"),
+		kyaml.SetField(field, value.node())); err != nil {
+		return nil, err
+	}
+	if err = pdb.PipeE(kyaml.Lookup("spec"),
+		kyaml.SetField("selector", selector)); err != nil {
+		return nil, err
+	}
+	y, err := pdb.String()
+	if err != nil {
+		return nil, err
+	}
+	return p.h.ResmapFactory().RF().FromBytes([]byte(y))
+}
+
+// budgetName returns the name of the budget of the workload,
+// e.g. web-deployment for the Deployment web.
+func budgetName(w *resource.Resource) string {
+	return w.GetName() + "-" + strings.ToLower(w.GetKind())
+}
+
+// stringMap returns the scalar fields of the map at path.
+func stringMap(n *kyaml.RNode, path ...string) (map[string]string, error) {
+	result := make(map[string]string)
+	m, err := n.Pipe(kyaml.Lookup(path...))
+	if err != nil || m == nil {
+		return result, err
+	}
+	err = m.VisitFields(func(f *kyaml.MapNode) error {
+		result[f.Key.YNode().Value] = f.Value.YNode().Value
+		return nil
+	})
+	return result, err
+}
+
+// node returns a node holding an integer if the
+// value is one, and a string (e.g. "50%") otherwise.
+func (v IntOrString) node() *kyaml.RNode {
+	if _, err := strconv.Atoi(string(v)); err == nil {
+		return kyaml.NewScalarRNode(string(v))
+	}
+	return kyaml.NewStringRNode(string(v))
+}
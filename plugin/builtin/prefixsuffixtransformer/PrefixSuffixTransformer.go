@@ -9,6 +9,7 @@ import (
 	"sigs.k8s.io/kustomize/api/filters/prefixsuffix"
 	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/yaml"
 )
@@ -63,6 +64,13 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 			if !id.IsSelected(&fs.Gvk) {
 				continue
 			}
+			match, err := matchesSelectors(r, &fs)
+			if err != nil {
+				return err
+			}
+			if !match {
+				continue
+			}
 			// TODO: move this test into the filter.
 			if smellsLikeANameChange(&fs) {
 				// "metadata/name" is the only field.
@@ -76,7 +84,7 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 					r.StorePreviousId()
 				}
 			}
-			err := r.ApplyFilter(prefixsuffix.Filter{
+			err = r.ApplyFilter(prefixsuffix.Filter{
 				Prefix:    p.Prefix,
 				Suffix:    p.Suffix,
 				FieldSpec: fs,
@@ -89,6 +97,21 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 	return nil
 }
 
+// matchesSelectors returns true if the resource's labels and
+// annotations match the field spec's selectors, if it has any.
+func matchesSelectors(r *resource.Resource, fs *types.FieldSpec) (bool, error) {
+	if fs.LabelSelector != "" {
+		match, err := r.MatchesLabelSelector(fs.LabelSelector)
+		if !match || err != nil {
+			return false, err
+		}
+	}
+	if fs.AnnotationSelector != "" {
+		return r.MatchesAnnotationSelector(fs.AnnotationSelector)
+	}
+	return true, nil
+}
+
 func smellsLikeANameChange(fs *types.FieldSpec) bool {
 	return fs.Path == "metadata/name"
 }
@@ -4,6 +4,7 @@
 package main_test
 
 import (
+	"strings"
 	"testing"
 
 	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
@@ -112,6 +113,45 @@ spec:
 `)
 }
 
+func TestReplacementTransformerFromInvalidPath(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ReplacementTransformer")
+	defer th.Reset()
+
+	th.WriteF("replacement.yaml", `
+source:
+  kind: Deployment
+targets:
+- select:
+    kind: Deployment
+  fieldPaths:
+  - spec.replicas
+  options:
+    index: first`)
+
+	err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplacementTransformer
+metadata:
+  name: notImportantHere
+replacements:
+- path: replacement.yaml
+`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deploy
+`)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	expected := "invalid replacement file: replacement.yaml:10: " +
+		"targets[0].options.index: must be an integer, not a string"
+	if !strings.HasSuffix(err.Error(), expected) {
+		t.Fatalf("expected error ending in %q, got %v", expected, err)
+	}
+}
+
 func TestReplacementTransformerComplexType(t *testing.T) {
 	th := kusttest_test.MakeEnhancedHarness(t).
 		PrepBuiltin("ReplacementTransformer")
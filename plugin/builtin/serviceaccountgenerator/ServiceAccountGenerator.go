@@ -0,0 +1,177 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Generate a ServiceAccount together with the Role (or ClusterRole)
+// granting it permissions and the binding between the two, all
+// named after the generator.
+type plugin struct {
+	h                *resmap.PluginHelpers
+	types.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// ClusterRole, if true, generates a ClusterRole and
+	// ClusterRoleBinding rather than a Role and RoleBinding.
+	ClusterRole bool `json:"clusterRole,omitempty" yaml:"clusterRole,omitempty"`
+
+	// Rules are the permissions granted to the ServiceAccount.
+	Rules []Rule `json:"rules,omitempty" yaml:"rules,omitempty"`
+
+	// AggregateLabels, if specified, make the ClusterRole an
+	// aggregate of the ClusterRoles having these labels.
+	AggregateLabels map[string]string `json:"aggregateLabels,omitempty" yaml:"aggregateLabels,omitempty"`
+
+	// Token, if true, also generates a long-lived token
+	// Secret for the ServiceAccount.
+	Token bool `json:"token,omitempty" yaml:"token,omitempty"`
+}
+
+// Rule is an RBAC policy rule.
+type Rule struct {
+	APIGroups       []string `json:"apiGroups,omitempty" yaml:"apiGroups,omitempty"`
+	Resources       []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty" yaml:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty" yaml:"nonResourceURLs,omitempty"`
+	Verbs           []string `json:"verbs" yaml:"verbs"`
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(h *resmap.PluginHelpers, config []byte) (err error) {
+	p.ObjectMeta = types.ObjectMeta{}
+	p.ClusterRole = false
+	p.Rules = nil
+	p.AggregateLabels = nil
+	p.Token = false
+	if err = yaml.Unmarshal(config, p); err != nil {
+		return err
+	}
+	if p.Name == "" {
+		return fmt.Errorf("must specify the name of the ServiceAccount in metadata.name")
+	}
+	if len(p.Rules) == 0 && len(p.AggregateLabels) == 0 {
+		return fmt.Errorf(
+			"ServiceAccount '%s' must be granted rules or aggregateLabels", p.Name)
+	}
+	if len(p.AggregateLabels) > 0 && !p.ClusterRole {
+		return fmt.Errorf(
+			"aggregateLabels of ServiceAccount '%s' require clusterRole: true", p.Name)
+	}
+	for i, r := range p.Rules {
+		if len(r.Verbs) == 0 {
+			return fmt.Errorf(
+				"rule %d of ServiceAccount '%s' must specify verbs", i, p.Name)
+		}
+	}
+	p.h = h
+	return nil
+}
+
+func (p *plugin) Generate() (resmap.ResMap, error) {
+	roleKind, bindingKind := "Role", "RoleBinding"
+	if p.ClusterRole {
+		roleKind, bindingKind = "ClusterRole", "ClusterRoleBinding"
+	}
+	objects := []map[string]interface{}{
+		{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata":   p.meta(true),
+		},
+		p.role(roleKind),
+		{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       bindingKind,
+			"metadata":   p.meta(!p.ClusterRole),
+			"roleRef": map[string]interface{}{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     roleKind,
+				"name":     p.Name,
+			},
+			"subjects": []interface{}{p.subject()},
+		},
+	}
+	if p.Token {
+		meta := p.meta(true)
+		meta["name"] = p.Name + "-token"
+		annotations := map[string]string{
+			"kubernetes.io/service-account.name": p.Name,
+		}
+		for k, v := range p.Annotations {
+			annotations[k] = v
+		}
+		meta["annotations"] = annotations
+		objects = append(objects, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   meta,
+			"type":       "kubernetes.io/service-account-token",
+		})
+	}
+	var b []byte
+	for _, o := range objects {
+		y, err := yaml.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, []byte("---\n")...)
+		b = append(b, y...)
+	}
+	return p.h.ResmapFactory().NewResMapFromBytes(b)
+}
+
+func (p *plugin) role(kind string) map[string]interface{} {
+	role := map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       kind,
+		"metadata":   p.meta(!p.ClusterRole),
+	}
+	if len(p.Rules) > 0 {
+		role["rules"] = p.Rules
+	}
+	if len(p.AggregateLabels) > 0 {
+		role["aggregationRule"] = map[string]interface{}{
+			"clusterRoleSelectors": []interface{}{
+				map[string]interface{}{"matchLabels": p.AggregateLabels},
+			},
+		}
+	}
+	return role
+}
+
+func (p *plugin) subject() map[string]interface{} {
+	s := map[string]interface{}{
+		"kind": "ServiceAccount",
+		"name": p.Name,
+	}
+	if p.Namespace != "" {
+		s["namespace"] = p.Namespace
+	}
+	return s
+}
+
+// meta returns the metadata shared by the generated
+// objects, with a namespace if they are namespaced.
+func (p *plugin) meta(namespaced bool) map[string]interface{} {
+	m := map[string]interface{}{"name": p.Name}
+	if namespaced && p.Namespace != "" {
+		m["namespace"] = p.Namespace
+	}
+	if len(p.Labels) > 0 {
+		m["labels"] = p.Labels
+	}
+	if len(p.Annotations) > 0 {
+		m["annotations"] = p.Annotations
+	}
+	return m
+}
@@ -0,0 +1,184 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+func TestServiceAccountGenerator(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ServiceAccountGenerator")
+	defer th.Reset()
+
+	rm := th.LoadAndRunGenerator(`
+apiVersion: builtin
+kind: ServiceAccountGenerator
+metadata:
+  name: app
+  namespace: apps
+  labels:
+    app: app
+rules:
+- apiGroups: [""]
+  resources: [configmaps]
+  verbs: [get, list, watch]
+token: true
+`)
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  labels:
+    app: app
+  name: app
+  namespace: apps
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  labels:
+    app: app
+  name: app
+  namespace: apps
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  verbs:
+  - get
+  - list
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  labels:
+    app: app
+  name: app
+  namespace: apps
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: app
+subjects:
+- kind: ServiceAccount
+  name: app
+  namespace: apps
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  annotations:
+    kubernetes.io/service-account.name: app
+  labels:
+    app: app
+  name: app-token
+  namespace: apps
+type: kubernetes.io/service-account-token
+`)
+}
+
+func TestServiceAccountGeneratorAggregateClusterRole(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("ServiceAccountGenerator")
+	defer th.Reset()
+
+	rm := th.LoadAndRunGenerator(`
+apiVersion: builtin
+kind: ServiceAccountGenerator
+metadata:
+  name: monitor
+  namespace: monitoring
+clusterRole: true
+aggregateLabels:
+  example.com/aggregate-to-monitor: "true"
+`)
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: monitor
+  namespace: monitoring
+---
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      example.com/aggregate-to-monitor: "true"
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: monitor
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: monitor
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: monitor
+subjects:
+- kind: ServiceAccount
+  name: monitor
+  namespace: monitoring
+`)
+}
+
+func TestServiceAccountGeneratorErrors(t *testing.T) {
+	testCases := map[string]struct {
+		config string
+		err    string
+	}{
+		"noPermissions": {
+			config: `
+apiVersion: builtin
+kind: ServiceAccountGenerator
+metadata:
+  name: app
+`,
+			err: "ServiceAccount 'app' must be granted rules or aggregateLabels",
+		},
+		"aggregateRole": {
+			config: `
+apiVersion: builtin
+kind: ServiceAccountGenerator
+metadata:
+  name: app
+aggregateLabels:
+  a: b
+`,
+			err: "aggregateLabels of ServiceAccount 'app' require clusterRole: true",
+		},
+		"noVerbs": {
+			config: `
+apiVersion: builtin
+kind: ServiceAccountGenerator
+metadata:
+  name: app
+rules:
+- resources: [pods]
+`,
+			err: "rule 0 of ServiceAccount 'app' must specify verbs",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			th := kusttest_test.MakeEnhancedHarness(t).
+				PrepBuiltin("ServiceAccountGenerator")
+			defer th.Reset()
+			err := th.ErrorFromLoadAndRunGenerator(tc.config)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
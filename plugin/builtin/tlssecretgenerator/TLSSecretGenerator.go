@@ -0,0 +1,268 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/kustomize/api/kv"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Generate a kubernetes.io/tls Secret holding an Ed25519
+// certificate and key, self-signed or signed by a local CA.
+// The key is derived from the configuration, so builds make
+// the same Secret, and the same name suffix hash, until the
+// configuration changes or the rotation period rolls over.
+type plugin struct {
+	h                *resmap.PluginHelpers
+	types.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	types.TLSSecretArgs
+
+	ips    []net.IP
+	period time.Duration
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+// The validity of certificates that aren't rotated,
+// the latest time RFC 5280 allows.
+var (
+	noRotationNotBefore = time.Unix(0, 0).UTC()
+	noRotationNotAfter  = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+)
+
+func (p *plugin) Config(h *resmap.PluginHelpers, config []byte) (err error) {
+	if h.GeneralConfig() == nil {
+		return fmt.Errorf("unable to access general config")
+	}
+	if !h.GeneralConfig().TLSSecretGeneratorEnabled {
+		return fmt.Errorf("must specify --enable-tls-secret-generator")
+	}
+	p.TLSSecretArgs = types.TLSSecretArgs{}
+	p.ips = nil
+	p.period = 0
+	if err = yaml.Unmarshal(config, p); err != nil {
+		return err
+	}
+	if p.TLSSecretArgs.Name == "" {
+		p.TLSSecretArgs.Name = p.Name
+	}
+	if p.TLSSecretArgs.Namespace == "" {
+		p.TLSSecretArgs.Namespace = p.Namespace
+	}
+	if p.TLSSecretArgs.Name == "" {
+		return fmt.Errorf("must specify the name of the TLS secret")
+	}
+	for _, s := range p.IPAddresses {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf(
+				"TLS secret '%s' has IP address '%s', which isn't one",
+				p.TLSSecretArgs.Name, s)
+		}
+		p.ips = append(p.ips, ip)
+	}
+	if p.CA != nil && (p.CA.CertFile == "" || p.CA.KeyFile == "") {
+		return fmt.Errorf(
+			"the ca of TLS secret '%s' must specify certFile and keyFile",
+			p.TLSSecretArgs.Name)
+	}
+	if p.RotationPeriod != "" {
+		p.period, err = time.ParseDuration(p.RotationPeriod)
+		if err != nil || p.period < time.Second {
+			return fmt.Errorf(
+				"TLS secret '%s' has rotationPeriod '%s', expected a duration "+
+					"of at least 1s, e.g. 720h", p.TLSSecretArgs.Name, p.RotationPeriod)
+		}
+	}
+	p.h = h
+	return nil
+}
+
+func (p *plugin) Generate() (resmap.ResMap, error) {
+	ca, caKey, err := p.loadCA()
+	if err != nil {
+		return nil, err
+	}
+	notBefore, notAfter := noRotationNotBefore, noRotationNotAfter
+	if p.period > 0 {
+		notBefore = time.Now().UTC().Truncate(p.period)
+		notAfter = notBefore.Add(2 * p.period)
+	}
+	seed := p.seed(caKey, notBefore)
+	key := ed25519.NewKeyFromSeed(seed)
+	serial := sha256.Sum256(seed)
+	cert := &x509.Certificate{
+		// Positive, and at most 20 bytes long, as RFC 5280 requires.
+		SerialNumber:          new(big.Int).SetBytes(serial[:16]),
+		Subject:               pkix.Name{CommonName: p.commonName()},
+		DNSNames:              p.DNSNames,
+		IPAddresses:           p.ips,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	parent, signer := cert, crypto.Signer(key)
+	if ca != nil {
+		parent, signer = ca, caKey
+	}
+	// Ed25519 and RSA keys make the same signature at each build,
+	// which loadCA makes sure the key of the CA is.
+	der, err := x509.CreateCertificate(rand.Reader, cert, parent, key.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"unable to make the certificate of TLS secret '%s': %w", p.TLSSecretArgs.Name, err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	args := types.SecretArgs{GeneratorArgs: p.GeneratorArgs, Type: "kubernetes.io/tls"}
+	args.LiteralSources = append([]string{
+		"tls.crt=" + encodePEM("CERTIFICATE", der),
+		"tls.key=" + encodePEM("PRIVATE KEY", keyDer),
+	}, p.LiteralSources...)
+	if ca != nil {
+		args.LiteralSources = append(args.LiteralSources,
+			"ca.crt="+encodePEM("CERTIFICATE", ca.Raw))
+	}
+	return p.h.ResmapFactory().FromSecretArgs(
+		kv.NewLoader(p.h.Loader(), p.h.Validator()), args)
+}
+
+func (p *plugin) commonName() string {
+	switch {
+	case p.CommonName != "":
+		return p.CommonName
+	case len(p.DNSNames) > 0:
+		return p.DNSNames[0]
+	default:
+		return p.TLSSecretArgs.Name
+	}
+}
+
+// seed returns the seed of the key pair, which hashes everything
+// the certificate depends on, and the key of the CA, if any, so
+// that, given a CA, the configuration alone doesn't give the key.
+func (p *plugin) seed(caKey crypto.Signer, notBefore time.Time) []byte {
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	write("kustomize TLSSecretGenerator")
+	if caKey != nil {
+		// Validated by loadCA.
+		b, _ := x509.MarshalPKCS8PrivateKey(caKey)
+		h.Write(b)
+	}
+	write(p.TLSSecretArgs.Namespace)
+	write(p.TLSSecretArgs.Name)
+	write(p.commonName())
+	for _, n := range p.DNSNames {
+		write(n)
+	}
+	write("")
+	for _, ip := range p.ips {
+		write(ip.String())
+	}
+	write("")
+	write(strconv.FormatInt(notBefore.Unix(), 10))
+	return h.Sum(nil)
+}
+
+// loadCA loads the certificate and key of the CA, if any.
+func (p *plugin) loadCA() (*x509.Certificate, crypto.Signer, error) {
+	if p.CA == nil {
+		return nil, nil, nil
+	}
+	b, err := p.loadPEM(p.CA.CertFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf(
+			"the CA certificate %s holds a %s", p.CA.CertFile, b.Type)
+	}
+	ca, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the CA certificate %s: %w", p.CA.CertFile, err)
+	}
+	b, err = p.loadPEM(p.CA.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePrivateKey(b)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the CA key %s: %w", p.CA.KeyFile, err)
+	}
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		return nil, nil, fmt.Errorf(
+			"the CA key %s is an ECDSA key, whose signatures differ at each "+
+				"build, and so would the TLS secret '%s'; use an Ed25519 or RSA key",
+			p.CA.KeyFile, p.TLSSecretArgs.Name)
+	}
+	return ca, key, nil
+}
+
+func (p *plugin) loadPEM(path string) (*pem.Block, error) {
+	content, err := p.h.Loader().Load(path)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := pem.Decode(content)
+	if b == nil {
+		return nil, fmt.Errorf("%s isn't PEM encoded", path)
+	}
+	return b, nil
+}
+
+func parsePrivateKey(b *pem.Block) (crypto.Signer, error) {
+	var key interface{}
+	var err error
+	switch b.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(b.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(b.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(b.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+func encodePEM(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
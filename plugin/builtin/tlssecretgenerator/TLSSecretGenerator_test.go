@@ -0,0 +1,263 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/resmap"
+	kusttest_test "sigs.k8s.io/kustomize/api/testutils/kusttest"
+)
+
+// parseCertificate returns the certificate held by the
+// data key of the only resource of the map.
+func parseCertificate(t *testing.T, rm resmap.ResMap, key string) *x509.Certificate {
+	t.Helper()
+	if !assert.Equal(t, 1, rm.Size()) {
+		t.FailNow()
+	}
+	data, err := base64.StdEncoding.DecodeString(
+		rm.Resources()[0].GetDataMap()[key])
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	b, _ := pem.Decode(data)
+	if !assert.NotNil(t, b) {
+		t.FailNow()
+	}
+	cert, err := x509.ParseCertificate(b.Bytes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return cert
+}
+
+func TestTLSSecretGeneratorSelfSigned(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("TLSSecretGenerator")
+	defer th.Reset()
+
+	config := `
+apiVersion: builtin
+kind: TLSSecretGenerator
+metadata:
+  name: web-tls
+  namespace: apps
+dnsNames:
+- web.apps.svc
+- web
+ipAddresses:
+- 127.0.0.1
+`
+	rm := th.LoadAndRunGenerator(config)
+	r := rm.Resources()[0]
+	assert.Equal(t, "apps", r.GetNamespace())
+	typ, _ := r.GetString("type")
+	assert.Equal(t, "kubernetes.io/tls", typ)
+	cert := parseCertificate(t, rm, "tls.crt")
+	assert.Equal(t, "web.apps.svc", cert.Subject.CommonName)
+	assert.Equal(t, []string{"web.apps.svc", "web"}, cert.DNSNames)
+	assert.Equal(t, "127.0.0.1", cert.IPAddresses[0].String())
+	assert.NoError(t, cert.CheckSignature(
+		cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature))
+	assert.True(t, cert.NotAfter.After(time.Now().AddDate(100, 0, 0)))
+	_, ok := r.GetDataMap()["tls.key"]
+	assert.True(t, ok)
+
+	// The Secret is the same at each build.
+	again := th.LoadAndRunGenerator(config)
+	assert.Equal(t, rm.Resources()[0].MustYaml(), again.Resources()[0].MustYaml())
+}
+
+// writeCA writes the certificate and key of a CA, with the
+// key, to the files ca.crt and ca.key, returning the certificate.
+func writeCA(
+	t *testing.T, th *kusttest_test.HarnessEnhanced, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dev CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	th.WriteF("ca.crt", string(pem.EncodeToMemory(
+		&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	th.WriteF("ca.key", string(pem.EncodeToMemory(
+		&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})))
+	ca, err := x509.ParseCertificate(der)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return ca
+}
+
+// caKeys returns a key of each type a CA may have.
+func caKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return map[string]crypto.Signer{"ed25519": ed25519Key, "rsa": rsaKey}
+}
+
+func TestTLSSecretGeneratorCA(t *testing.T) {
+	config := `
+apiVersion: builtin
+kind: TLSSecretGenerator
+metadata:
+  name: web-tls
+commonName: web
+ca:
+  certFile: ca.crt
+  keyFile: ca.key
+rotationPeriod: 24h
+`
+	for n, key := range caKeys(t) {
+		key := key
+		t.Run(n, func(t *testing.T) {
+			th := kusttest_test.MakeEnhancedHarness(t).
+				PrepBuiltin("TLSSecretGenerator")
+			defer th.Reset()
+			ca := writeCA(t, th, key)
+
+			rm := th.LoadAndRunGenerator(config)
+			cert := parseCertificate(t, rm, "tls.crt")
+			assert.Equal(t, "web", cert.Subject.CommonName)
+			assert.Equal(t, "dev CA", cert.Issuer.CommonName)
+			assert.NoError(t, cert.CheckSignatureFrom(ca))
+			assert.Equal(t, 48*time.Hour, cert.NotAfter.Sub(cert.NotBefore))
+			assert.True(t, cert.NotBefore.Before(time.Now()))
+			assert.True(t, cert.NotAfter.After(time.Now().Add(24*time.Hour)))
+			assert.True(t, ca.Equal(parseCertificate(t, rm, "ca.crt")))
+
+			// The Secret is the same at each build.
+			again := th.LoadAndRunGenerator(config)
+			assert.Equal(t, rm.Resources()[0].MustYaml(), again.Resources()[0].MustYaml())
+		})
+	}
+}
+
+func TestTLSSecretGeneratorECDSACA(t *testing.T) {
+	th := kusttest_test.MakeEnhancedHarness(t).
+		PrepBuiltin("TLSSecretGenerator")
+	defer th.Reset()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	writeCA(t, th, key)
+	err = th.ErrorFromLoadAndRunGenerator(`
+apiVersion: builtin
+kind: TLSSecretGenerator
+metadata:
+  name: web-tls
+ca:
+  certFile: ca.crt
+  keyFile: ca.key
+`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"the CA key ca.key is an ECDSA key, whose signatures differ at each "+
+				"build, and so would the TLS secret 'web-tls'; use an Ed25519 or RSA key")
+	}
+}
+
+func TestTLSSecretGeneratorErrors(t *testing.T) {
+	testCases := map[string]struct {
+		config string
+		err    string
+	}{
+		"bad ip address": {
+			config: `
+apiVersion: builtin
+kind: TLSSecretGenerator
+metadata:
+  name: web-tls
+ipAddresses:
+- localhost
+`,
+			err: "TLS secret 'web-tls' has IP address 'localhost', which isn't one",
+		},
+		"bad rotation period": {
+			config: `
+apiVersion: builtin
+kind: TLSSecretGenerator
+metadata:
+  name: web-tls
+rotationPeriod: 30d
+`,
+			err: "TLS secret 'web-tls' has rotationPeriod '30d', expected a duration",
+		},
+		"ca without key": {
+			config: `
+apiVersion: builtin
+kind: TLSSecretGenerator
+metadata:
+  name: web-tls
+ca:
+  certFile: ca.crt
+`,
+			err: "the ca of TLS secret 'web-tls' must specify certFile and keyFile",
+		},
+		"ca key not pem": {
+			config: `
+apiVersion: builtin
+kind: TLSSecretGenerator
+metadata:
+  name: web-tls
+ca:
+  certFile: ca.crt
+  keyFile: kustomization.yaml
+`,
+			err: "kustomization.yaml isn't PEM encoded",
+		},
+	}
+	for n, tc := range testCases {
+		tc := tc
+		t.Run(n, func(t *testing.T) {
+			th := kusttest_test.MakeEnhancedHarness(t).
+				PrepBuiltin("TLSSecretGenerator")
+			defer th.Reset()
+			writeCA(t, th, caKeys(t)["ed25519"])
+			th.WriteF("kustomization.yaml", "resources: []\n")
+			err := th.ErrorFromLoadAndRunGenerator(tc.config)
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error %q, got %q", tc.err, err.Error())
+			}
+		})
+	}
+}